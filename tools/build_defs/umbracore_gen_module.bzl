@@ -2,32 +2,133 @@
 UmbraCore module generator rule.
 
 This rule provides a way to generate new Foundation-free modules with the standard directory structure
-in accordance with the UmbraCore refactoring plan.
+in accordance with the UmbraCore refactoring plan. With docs = True, the module also gets a
+Documentation.docc catalog with a landing article and a <Module>DocC target, registered in
+docc_config.yml so the documentation builds pick it up.
+
+The generator refuses to touch a module directory that already exists. Pass --force to overwrite
+its template files, or --add-missing to create only the ones it lacks (e.g. a Tests directory or
+a DocC catalog for an older module):
+
+    bazel run //tools:gen_time_types -- --add-missing
 """
 
 def _umbracore_gen_module_impl(ctx):
     module_name = ctx.attr.module_name
+    generate_docs = "True" if ctx.attr.docs else "False"
     
     # Create a Python script that generates the module structure
     script_content = """
 #!/usr/bin/env python3
+import argparse
 import os
 import sys
 
 def create_file(path, content):
+    verb = "Overwrote " if os.path.exists(path) else "Created "
     os.makedirs(os.path.dirname(path), exist_ok=True)
     with open(path, 'w') as f:
         f.write(content)
-    print("Created " + path)
+    print(verb + path)
+
+def docc_files(module_name):
+    # Landing article: DocC uses the article titled with the module symbol
+    # as the catalog's root page.
+    article = (
+        "# ``" + module_name + "``\\n\\n"
+        "Summarise what " + module_name + " provides in one sentence.\\n\\n"
+        "## Overview\\n\\n"
+        "Describe the module's purpose, its main types and how it fits into UmbraCore.\\n\\n"
+        "## Topics\\n\\n"
+        "### Essentials\\n\\n"
+        "- ``" + module_name + "``\\n"
+    )
+    info_plist = (
+        '<?xml version="1.0" encoding="UTF-8"?>\\n'
+        '<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">\\n'
+        '<plist version="1.0">\\n'
+        '<dict>\\n'
+        '    <key>CFBundleIdentifier</key>\\n'
+        '    <string>com.umbra.' + module_name + '</string>\\n'
+        '    <key>CFBundleName</key>\\n'
+        '    <string>' + module_name + '</string>\\n'
+        '    <key>CFBundleDisplayName</key>\\n'
+        '    <string>' + module_name + ' Documentation</string>\\n'
+        '    <key>CFBundleVersion</key>\\n'
+        '    <string>1.0.0</string>\\n'
+        '</dict>\\n'
+        '</plist>\\n'
+    )
+    return article, info_plist
+
+def docc_build_stanza(module_name):
+    # Same layout as Sources/CoreDTOs: <Module>DocC is what
+    # tools/scripts/build_docc.sh builds, and the DocC alias is what the
+    # docc-build workflow discovers.
+    return (
+        "\\n# DocC documentation for " + module_name + "\\n"
+        "docc_documentation(\\n"
+        '    name = "' + module_name + 'DocC",\\n'
+        '    module_name = "' + module_name + '",\\n'
+        "    localonly = True,\\n"
+        "    srcs = glob(\\n"
+        "        [\\n"
+        '            "Documentation.docc/**/*.md",\\n'
+        '            "Documentation.docc/**/*.plist",\\n'
+        '            "Sources/**/*.swift",\\n'
+        "        ],\\n"
+        "        allow_empty = True,\\n"
+        "    ),\\n"
+        ")\\n"
+        "\\n"
+        "alias(\\n"
+        '    name = "DocC",\\n'
+        '    actual = ":' + module_name + 'DocC",\\n'
+        ")\\n"
+    )
+
+def register_docc_target(module_name, module_path):
+    # Adds the module to the targets list of docc_config.yml, unless it is
+    # already there.
+    config = "docc_config.yml"
+    if not os.path.exists(config):
+        return
+    with open(config) as f:
+        lines = f.read().split("\\n")
+    target = "//" + module_path + ":" + module_name + "DocC"
+    if any(line.strip() == "- target: " + target for line in lines):
+        return
+    if "targets:" not in lines:
+        return
+    end = lines.index("targets:") + 1
+    while end < len(lines) and (lines[end].startswith(" ") or lines[end].startswith("-")):
+        end += 1
+    lines[end:end] = [
+        "  - target: " + target,
+        "    output: " + module_path + "/" + module_name + "DocC.doccarchive",
+        "    module: " + module_name,
+    ]
+    with open(config, "w") as f:
+        f.write("\\n".join(lines))
+    print("Registered " + target + " in " + config)
 
 def main():
+    # bazel run starts the script in its runfiles tree; generate the
+    # module in the workspace instead.
+    os.chdir(os.environ.get("BUILD_WORKSPACE_DIRECTORY", "."))
+
+    parser = argparse.ArgumentParser(description="Generate the {module_name} module.")
+    mode = parser.add_mutually_exclusive_group()
+    mode.add_argument("--force", action="store_true",
+                      help="overwrite the files of an existing module")
+    mode.add_argument("--add-missing", action="store_true",
+                      help="create only the template files an existing module lacks")
+    args = parser.parse_args()
+
     module_name = "{module_name}"
     module_path = "Sources/" + module_name
-    
-    # Create directory structure
-    os.makedirs(module_path + "/Sources", exist_ok=True)
-    os.makedirs(module_path + "/Tests", exist_ok=True)
-    
+    generate_docs = {generate_docs}
+
     # Create BUILD.bazel file
     build_content = '''load("//tools/build_defs:umbracore_module.bzl", "umbracore_foundation_free_module", "umbracore_test_module")
 
@@ -81,25 +182,68 @@ class {module_name}Tests: XCTestCase {{
 }}
 '''
 
+    # The templates are already filled in: the rule formats this whole
+    # script with the module name.
+    if generate_docs:
+        build_content = build_content.replace(
+            '"umbracore_test_module")\\n',
+            '"umbracore_test_module")\\nload("//tools/swift:docc_rules.bzl", "docc_documentation")\\n',
+            1,
+        ) + docc_build_stanza(module_name)
+
+    files = [
+        (module_path + "/BUILD.bazel", build_content),
+        (module_path + "/Sources/" + module_name + ".swift", source_content),
+        (module_path + "/Tests/" + module_name + "Tests.swift", test_content),
+    ]
+    if generate_docs:
+        article, info_plist = docc_files(module_name)
+        files.append((module_path + "/Documentation.docc/" + module_name + ".md", article))
+        files.append((module_path + "/Documentation.docc/Info.plist", info_plist))
+
+    # An existing module is only touched when asked to: --force rewrites
+    # every template file, --add-missing creates just the absent ones.
+    existing = [path for path, _ in files if os.path.exists(path)]
+    if os.path.isdir(module_path) and not (args.force or args.add_missing):
+        print("Error: " + module_path + " already exists.", file=sys.stderr)
+        for path in existing:
+            print("  - " + path, file=sys.stderr)
+        print("Re-run with --add-missing to create only the missing files, or --force to overwrite them.", file=sys.stderr)
+        sys.exit(1)
+
     # Write the files
-    create_file(module_path + "/BUILD.bazel", build_content.format(module_name=module_name))
-    create_file(module_path + "/Sources/" + module_name + ".swift", source_content.format(module_name=module_name))
-    create_file(module_path + "/Tests/" + module_name + "Tests.swift", test_content.format(module_name=module_name))
-    
-    print("\\nCreated " + module_name + " module at " + module_path)
+    written = []
+    for path, content in files:
+        if path in existing and not args.force:
+            print("Kept existing " + path)
+            continue
+        create_file(path, content)
+        written.append(path)
+    if generate_docs:
+        register_docc_target(module_name, module_path)
+
+    if not written:
+        print("\\n" + module_path + " already has every template file; nothing to do")
+        return
+
+    verb = "Created" if not existing else "Updated"
+    print("\\n" + verb + " " + module_name + " module at " + module_path)
     print("• Module structure:")
-    print("  - " + module_path + "/BUILD.bazel")
-    print("  - " + module_path + "/Sources/" + module_name + ".swift")
-    print("  - " + module_path + "/Tests/" + module_name + "Tests.swift")
+    for path, _ in files:
+        note = "" if path in written else " (kept)"
+        print("  - " + path + note)
     print("\\nNext steps:")
     print("1. Add your core types to " + module_path + "/Sources/")
     print("2. Add tests to " + module_path + "/Tests/")
     print("3. Update dependencies in " + module_path + "/BUILD.bazel if needed")
+    if generate_docs:
+        print("4. Write the landing article in " + module_path + "/Documentation.docc/" + module_name + ".md")
 
 if __name__ == "__main__":
     main()
     """.format(
         module_name = module_name,
+        generate_docs = generate_docs,
     )
     
     # Create the generator script
@@ -133,6 +277,10 @@ umbracore_gen_module = rule(
             mandatory = True,
             doc = "Name of the module to create",
         ),
+        "docs": attr.bool(
+            default = False,
+            doc = "Also create a Documentation.docc catalog and a <module_name>DocC target",
+        ),
     },
     executable = True,
 )
@@ -0,0 +1,111 @@
+// Package analyzer lets a team add its own checks to umbratool without
+// forking it. An analyzer sees every file of its scope through the shared
+// walk, and its findings go through the usual reports and results store.
+//
+// Analyzers are either compiled in, by registering from the init function
+// of a package that the plugins package imports, or run as a subprocess
+// that a config names by its command line (see Process). This package sits
+// outside internal so that analyzers kept in another repository can import
+// it.
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Analyzer is a custom check. A run calls Configure once, AnalyzeFile for
+// each file of the analyzer's scope in path order, and Finish once at the
+// end. They are never called concurrently.
+type Analyzer interface {
+	// Name is the name configs select the analyzer by, in kebab case.
+	Name() string
+	// Configure receives the analyzer's config section, which it decodes
+	// with config.Decode, or nil when it has none.
+	Configure(config *yaml.Node) error
+	// AnalyzeFile checks one file.
+	AnalyzeFile(f *File) ([]Finding, error)
+	// Finish returns the findings that need every file, such as
+	// comparisons between files.
+	Finish() ([]Finding, error)
+}
+
+// File is a file handed to AnalyzeFile.
+type File struct {
+	// Path is relative to the project root, with forward slashes.
+	Path string `json:"path"`
+	// Module is the module the file belongs to.
+	Module  string `json:"module"`
+	Content []byte `json:"-"`
+}
+
+// Finding is one problem an analyzer reports. Module defaults to the
+// module of File.
+type Finding struct {
+	Analyzer string `json:"analyzer"`
+	Module   string `json:"module,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	// Kind distinguishes the findings of an analyzer with several checks;
+	// it may be empty.
+	Kind    string `json:"kind,omitempty"`
+	Message string `json:"message"`
+	// Fingerprint is set by umbratool once the analyzers have run;
+	// analyzers leave it empty.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+var (
+	mu         sync.Mutex
+	registered = make(map[string]Analyzer)
+)
+
+// Register makes a compiled-in analyzer available to configs under its
+// name. It is meant to be called from init and panics when the name is
+// empty or taken.
+func Register(a Analyzer) {
+	mu.Lock()
+	defer mu.Unlock()
+	name := a.Name()
+	if name == "" {
+		panic("analyzer: Register with an empty name")
+	}
+	if _, dup := registered[name]; dup {
+		panic("analyzer: duplicate analyzer " + name)
+	}
+	registered[name] = a
+}
+
+// Lookup returns the compiled-in analyzer called name.
+func Lookup(name string) (Analyzer, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	a, ok := registered[name]
+	return a, ok
+}
+
+// Registered returns the names of the compiled-in analyzers, sorted.
+func Registered() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	names := make([]string, 0, len(registered))
+	for name := range registered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupSpec(s Spec) (Analyzer, error) {
+	if len(s.Command) > 0 {
+		return &Process{name: s.Name, Command: s.Command}, nil
+	}
+	a, ok := Lookup(s.Name)
+	if !ok {
+		return nil, fmt.Errorf("analyzer %s is not compiled in and has no command", s.Name)
+	}
+	return a, nil
+}
@@ -0,0 +1,160 @@
+package analyzer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Process is an analyzer run as a subprocess, so it can be written in any
+// language and kept in any repository. The process reads one JSON request
+// per line on stdin and answers each with one JSON line on stdout:
+//
+//	{"call":"configure","config":{...}}
+//	{"call":"analyzeFile","file":{"path":"Sources/Foo/A.swift","module":"Foo","content":"..."}}
+//	{"call":"finish"}
+//
+// The answer is {"findings":[...]}, the findings having the fields of
+// Finding, or {"error":"..."} to stop the run. The process should exit
+// when stdin closes after finish. Its stderr is passed through.
+type Process struct {
+	name string
+	// Command is the command line; a relative program path containing a
+	// slash is taken relative to Dir.
+	Command []string
+	// Dir is the working directory, the project root in a run.
+	Dir string
+
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out *bufio.Reader
+}
+
+type request struct {
+	Call   string `json:"call"`
+	Config any    `json:"config,omitempty"`
+	File   *file  `json:"file,omitempty"`
+}
+
+type file struct {
+	File
+	Content string `json:"content"`
+}
+
+type response struct {
+	Findings []Finding `json:"findings"`
+	Error    string    `json:"error"`
+}
+
+// Name returns the name the config gives the analyzer.
+func (p *Process) Name() string { return p.name }
+
+// Configure starts the process and sends it its config.
+func (p *Process) Configure(config *yaml.Node) error {
+	var value any
+	if config != nil {
+		if err := config.Decode(&value); err != nil {
+			return err
+		}
+	}
+	if err := p.start(); err != nil {
+		return err
+	}
+	_, err := p.call(request{Call: "configure", Config: value})
+	return err
+}
+
+// AnalyzeFile sends f to the process.
+func (p *Process) AnalyzeFile(f *File) ([]Finding, error) {
+	return p.call(request{Call: "analyzeFile", File: &file{File: *f, Content: string(f.Content)}})
+}
+
+// Finish asks the process for its last findings and waits for it to exit.
+func (p *Process) Finish() ([]Finding, error) {
+	findings, err := p.call(request{Call: "finish"})
+	if cerr := p.close(); err == nil {
+		err = cerr
+	}
+	return findings, err
+}
+
+func (p *Process) start() error {
+	program := p.Command[0]
+	if !filepath.IsAbs(program) && strings.ContainsAny(program, `/\`) {
+		program = filepath.Join(p.Dir, program)
+	}
+	p.cmd = exec.Command(program, p.Command[1:]...)
+	p.cmd.Dir = p.Dir
+	p.cmd.Stderr = os.Stderr
+	in, err := p.cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	out, err := p.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := p.cmd.Start(); err != nil {
+		return err
+	}
+	p.in, p.out = in, bufio.NewReader(out)
+	return nil
+}
+
+func (p *Process) call(req request) ([]Finding, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.in.Write(append(data, '\n')); err != nil {
+		return nil, p.failed(req.Call, err)
+	}
+	line, err := p.out.ReadBytes('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = errors.New("exited without answering")
+		}
+		return nil, p.failed(req.Call, err)
+	}
+	var resp response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, p.failed(req.Call, fmt.Errorf("bad answer: %w", err))
+	}
+	if resp.Error != "" {
+		return nil, p.failed(req.Call, errors.New(resp.Error))
+	}
+	return resp.Findings, nil
+}
+
+// failed wraps err, stopping the process so the run does not leave it
+// behind.
+func (p *Process) failed(call string, err error) error {
+	p.abort()
+	return fmt.Errorf("%s: %w", call, err)
+}
+
+// abort kills the process, if it is still running.
+func (p *Process) abort() {
+	if p.cmd != nil {
+		p.cmd.Process.Kill()
+		p.close()
+	}
+}
+
+func (p *Process) close() error {
+	if p.cmd == nil {
+		return nil
+	}
+	p.in.Close()
+	err := p.cmd.Wait()
+	p.cmd = nil
+	return err
+}
@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes a table of the analyzers run, then their findings
+// by analyzer.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	b.WriteString("# Custom Analyzers\n\n")
+	if len(r.Analyzers) == 0 {
+		b.WriteString("No analyzers are enabled; list them in the analyzers section of umbratool.yaml.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	fmt.Fprintf(&b, "**%d findings from %d analyzers**\n\n", len(r.Findings), len(r.Analyzers))
+	b.WriteString("| Analyzer | Files | Findings |\n")
+	b.WriteString("|----------|-------|----------|\n")
+	for _, s := range r.Analyzers {
+		fmt.Fprintf(&b, "| %s | %d | %d |\n", s.Name, s.Files, s.Findings)
+	}
+
+	for _, s := range r.Analyzers {
+		if s.Findings == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", s.Name)
+		for _, f := range r.Findings {
+			if f.Analyzer != s.Name {
+				continue
+			}
+			b.WriteString("- ")
+			if f.Kind != "" {
+				fmt.Fprintf(&b, "[%s] ", f.Kind)
+			}
+			b.WriteString(f.Message)
+			switch {
+			case f.Line > 0:
+				fmt.Fprintf(&b, " (`%s:%d`)", f.File, f.Line)
+			case f.File != "":
+				fmt.Fprintf(&b, " (`%s`)", f.File)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	out := *r
+	if out.Analyzers == nil {
+		out.Analyzers = []Summary{}
+	}
+	if out.Findings == nil {
+		out.Findings = []Finding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,269 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/configschema"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Spec enables one analyzer in the analyzers section of umbratool.yaml:
+//
+//	analyzers:
+//	  - name: no-force-unwrap
+//	    scope: [Sources/SecurityImplementation]
+//	    config:
+//	      allow: [IBOutlet]
+//	  - name: licence-check
+//	    command: [tools/analyzers/licence-check, --strict]
+//	    extensions: [.swift, .h]
+//
+// Without a command the name selects a compiled-in analyzer.
+type Spec struct {
+	Name    string   `yaml:"name"`
+	Command []string `yaml:"command"`
+	// Scope lists the directories walked, Sources by default.
+	Scope []string `yaml:"scope"`
+	// Extensions restricts the files to these extensions, .swift by
+	// default.
+	Extensions []string  `yaml:"extensions"`
+	Config     yaml.Node `yaml:"config"`
+}
+
+// LoadConfig reads the analyzers section of a config file. A file without
+// one enables no analyzers.
+func LoadConfig(file string) ([]Spec, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := configschema.Validate(configschema.KindUmbratool, file, data); err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Analyzers []Spec `yaml:"analyzers"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	seen := make(map[string]bool)
+	for i, s := range doc.Analyzers {
+		if s.Name == "" {
+			return nil, fmt.Errorf("%s: analyzer %d: name is required", file, i+1)
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("%s: analyzer %s is enabled twice", file, s.Name)
+		}
+		seen[s.Name] = true
+		if len(s.Scope) == 0 {
+			doc.Analyzers[i].Scope = []string{"Sources"}
+		}
+		if len(s.Extensions) == 0 {
+			doc.Analyzers[i].Extensions = []string{".swift"}
+		}
+	}
+	return doc.Analyzers, nil
+}
+
+// Summary is what one analyzer did in a run.
+type Summary struct {
+	Name     string `json:"name"`
+	Files    int    `json:"files"`
+	Findings int    `json:"findings"`
+}
+
+// Report is the result of a run.
+type Report struct {
+	Analyzers []Summary `json:"analyzers"`
+	Findings  []Finding `json:"findings"`
+}
+
+// batch is how many files are read ahead of the analyzers, which bounds
+// the memory a run holds.
+const batch = 256
+
+// Run runs the analyzers of specs over root. Each file is read once, then
+// handed to every analyzer whose scope and extensions cover it. Rules name
+// the module of each file. Run stops at the first error an analyzer
+// returns, and between files once ctx is done.
+func Run(ctx context.Context, root string, specs []Spec, rules []modulenames.Rule) (*Report, error) {
+	analyzers := make([]Analyzer, len(specs))
+	for i, s := range specs {
+		a, err := lookupSpec(s)
+		if err != nil {
+			return nil, err
+		}
+		if p, ok := a.(*Process); ok {
+			p.Dir = root
+		}
+		analyzers[i] = a
+	}
+	defer func() {
+		for _, a := range analyzers {
+			if p, ok := a.(*Process); ok {
+				p.abort()
+			}
+		}
+	}()
+	for i, a := range analyzers {
+		var config *yaml.Node
+		if !specs[i].Config.IsZero() {
+			config = &specs[i].Config
+		}
+		if err := a.Configure(config); err != nil {
+			return nil, fmt.Errorf("%s: %w", a.Name(), err)
+		}
+	}
+
+	paths, err := scopeFiles(root, specs)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &moduleindex.Index{Modules: rules}
+	r := &Report{Analyzers: make([]Summary, len(specs))}
+	for i, a := range analyzers {
+		r.Analyzers[i].Name = a.Name()
+	}
+	add := func(i int, findings []Finding, f *File) {
+		for _, x := range findings {
+			x.Analyzer = analyzers[i].Name()
+			if x.File == "" && f != nil {
+				x.File = f.Path
+			}
+			if x.Module == "" && x.File != "" {
+				x.Module = moduleOf(index, x.File)
+			}
+			r.Findings = append(r.Findings, x)
+		}
+		r.Analyzers[i].Findings += len(findings)
+	}
+
+	for start := 0; start < len(paths); start += batch {
+		chunk := paths[start:min(start+batch, len(paths))]
+		files, err := pool.MapContext(ctx, chunk, func(rel string) (*File, error) {
+			content, err := os.ReadFile(filepath.Join(root, rel))
+			if err != nil {
+				return nil, err
+			}
+			return &File{Path: rel, Module: moduleOf(index, rel), Content: content}, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			for i, a := range analyzers {
+				if !covers(specs[i], f.Path) {
+					continue
+				}
+				findings, err := a.AnalyzeFile(f)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %s: %w", a.Name(), f.Path, err)
+				}
+				r.Analyzers[i].Files++
+				add(i, findings, f)
+			}
+		}
+	}
+
+	for i, a := range analyzers {
+		findings, err := a.Finish()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", a.Name(), err)
+		}
+		add(i, findings, nil)
+	}
+
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		a, b := r.Findings[i], r.Findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Analyzer != b.Analyzer {
+			return a.Analyzer < b.Analyzer
+		}
+		return a.Message < b.Message
+	})
+	return r, nil
+}
+
+// scopeFiles returns the files any spec covers, sorted.
+func scopeFiles(root string, specs []Spec) ([]string, error) {
+	var dirs []string
+	for _, s := range specs {
+		for _, dir := range s.Scope {
+			dirs = append(dirs, path.Clean(filepath.ToSlash(dir)))
+		}
+	}
+	sort.Strings(dirs)
+	dirs = slices.Compact(dirs)
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{}, func(rel string) error {
+			rel = path.Join(dir, rel)
+			if seen[rel] {
+				return nil
+			}
+			seen[rel] = true
+			for _, s := range specs {
+				if covers(s, rel) {
+					paths = append(paths, rel)
+					break
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// covers reports whether rel lies in the scope of s and has one of its
+// extensions.
+func covers(s Spec, rel string) bool {
+	if !slices.Contains(s.Extensions, path.Ext(rel)) {
+		return false
+	}
+	for _, dir := range s.Scope {
+		dir = path.Clean(filepath.ToSlash(dir))
+		if dir == "." || strings.HasPrefix(rel, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func moduleOf(index *moduleindex.Index, rel string) string {
+	if r, ok := index.ForPath(rel); ok {
+		return r.ModuleName
+	}
+	return workspace.ModuleForPath(rel)
+}
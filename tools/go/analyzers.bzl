@@ -0,0 +1,113 @@
+"""Aspects that run the umbratool analyzers on Swift targets inside Bazel.
+
+Each aspect lists the Swift sources of a target in a manifest and runs one
+analyzer over exactly those files, writing a JSON report next to the
+target's outputs. Reports are cached like any other action, so only targets
+whose sources change are analysed again:
+
+    bazel build //Sources/... \\
+        --aspects=//tools/go:analyzers.bzl%complexity_aspect \\
+        --output_groups=umbratool_complexity
+
+protocol_aspect also lists the sources of the target's transitive deps, so
+conformances to protocols declared in them are checked; it re-runs when
+those change too.
+"""
+
+UmbratoolSrcsInfo = provider(
+    doc = "Swift sources of a target and its transitive deps.",
+    fields = {
+        "transitive_srcs": "depset of the Swift source files of the target and its deps",
+    },
+)
+
+def _swift_srcs(ctx):
+    # Only sources of the main repository: generated files and external
+    # packages have no module in the workspace layout the analyzers use.
+    return [
+        f
+        for src in getattr(ctx.rule.attr, "srcs", [])
+        for f in src.files.to_list()
+        if f.extension == "swift" and f.is_source and not f.owner.workspace_name
+    ]
+
+def _deps_srcs(ctx):
+    return depset(transitive = [
+        dep[UmbratoolSrcsInfo].transitive_srcs
+        for dep in getattr(ctx.rule.attr, "deps", [])
+        if UmbratoolSrcsInfo in dep
+    ])
+
+def _manifest(ctx, name, files):
+    manifest = ctx.actions.declare_file(name)
+    content = ctx.actions.args()
+    content.set_param_file_format("multiline")
+    content.add_all(files)
+    ctx.actions.write(manifest, content)
+    return manifest
+
+def _analyse(target, ctx, command, srcs, deps = None):
+    name = target.label.name
+    srcs_manifest = _manifest(ctx, "%s.%s.srcs" % (name, command), srcs)
+    report = ctx.actions.declare_file("%s.%s.json" % (name, command))
+
+    args = ctx.actions.args()
+    args.add(command)
+    args.add("--srcs-manifest", srcs_manifest)
+    inputs = [srcs_manifest]
+    transitive = [depset(srcs)]
+    if deps != None:
+        deps_manifest = _manifest(ctx, "%s.%s.deps" % (name, command), deps)
+        args.add("--deps-manifest", deps_manifest)
+        inputs.append(deps_manifest)
+        transitive.append(deps)
+    args.add("--format", "json")
+    args.add("--output", report)
+
+    ctx.actions.run(
+        executable = ctx.executable._umbratool,
+        arguments = [args],
+        inputs = depset(inputs, transitive = transitive),
+        outputs = [report],
+        # Stamps reports with a fixed time, so an unchanged target
+        # reproduces its report exactly.
+        env = {"SOURCE_DATE_EPOCH": "0"},
+        mnemonic = "Umbratool" + "".join([part.capitalize() for part in command.split("-")]),
+        progress_message = "Running umbratool %s on %%{label}" % command,
+    )
+    return report
+
+def _complexity_impl(target, ctx):
+    srcs = _swift_srcs(ctx)
+    if not srcs:
+        return []
+    report = _analyse(target, ctx, "complexity", srcs)
+    return [OutputGroupInfo(umbratool_complexity = depset([report]))]
+
+def _protocol_impl(target, ctx):
+    srcs = _swift_srcs(ctx)
+    deps = _deps_srcs(ctx)
+    info = UmbratoolSrcsInfo(transitive_srcs = depset(srcs, transitive = [deps]))
+    if not srcs:
+        return [info]
+    report = _analyse(target, ctx, "protocol-check", srcs, deps)
+    return [info, OutputGroupInfo(umbratool_protocols = depset([report]))]
+
+_UMBRATOOL = attr.label(
+    default = "//tools/go/cmd/umbratool",
+    executable = True,
+    cfg = "exec",
+)
+
+complexity_aspect = aspect(
+    implementation = _complexity_impl,
+    doc = "Writes <target>.complexity.json, the umbratool complexity report of the target's Swift sources.",
+    attrs = {"_umbratool": _UMBRATOOL},
+)
+
+protocol_aspect = aspect(
+    implementation = _protocol_impl,
+    doc = "Writes <target>.protocol-check.json, the protocol conformance issues in the target's Swift sources.",
+    attr_aspects = ["deps"],
+    attrs = {"_umbratool": _UMBRATOOL},
+)
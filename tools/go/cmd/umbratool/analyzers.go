@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/analyzer"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+	_ "github.com/mpy-dev-ml/UmbraCore/tools/go/plugins"
+)
+
+func init() {
+	register(command{
+		name:    "analyzers",
+		summary: "Run the custom analyzers enabled in umbratool.yaml, compiled in or as subprocesses",
+		run:     runAnalyzers,
+	})
+}
+
+func runAnalyzers(args []string) error {
+	fs := newFlagSet("analyzers")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	configPath := fs.String("config", "umbratool.yaml", "Config holding the analyzers section, relative to the project root")
+	only := fs.String("only", "", "Comma-separated analyzers to run (default: every enabled one)")
+	list := fs.Bool("list", false, "List the compiled-in analyzers and exit")
+	strict := fs.Bool("strict", false, "Fail when an analyzer reports findings")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *list {
+		for _, name := range analyzer.Registered() {
+			fmt.Println(name)
+		}
+		return nil
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	specs, err := analyzer.LoadConfig(rootPath(projectRoot, *configPath))
+	if err != nil {
+		return err
+	}
+	if names := splitList(*only); len(names) > 0 {
+		var selected []analyzer.Spec
+		for _, name := range names {
+			found := false
+			for _, s := range specs {
+				if s.Name == name {
+					selected = append(selected, s)
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("--only: analyzer %s is not enabled in %s", name, *configPath)
+			}
+		}
+		specs = selected
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	report, err := analyzer.Run(runContext, projectRoot, specs, rules)
+	if err != nil {
+		return err
+	}
+
+	issues := make([]store.Issue, 0, len(report.Findings))
+	for _, f := range report.Findings {
+		kind := f.Analyzer
+		if f.Kind != "" {
+			kind += "/" + f.Kind
+		}
+		issues = append(issues, store.Issue{Module: f.Module, File: f.File, Line: f.Line, Kind: kind, Message: f.Message})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for n := range report.Findings {
+		report.Findings[n].Fingerprint = issues[n].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return analyzer.WriteMarkdown(w, report)
+		case "json":
+			return analyzer.WriteJSON(w, report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("analyzers", projectRoot, func(s *metrics.Set) {
+		for _, f := range report.Findings {
+			s.Add("analyzer_findings", "Custom analyzer findings per module by analyzer.", 1, "module", f.Module, "analyzer", f.Analyzer)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if *strict && len(report.Findings) > 0 {
+		var names []string
+		for _, s := range report.Analyzers {
+			if s.Findings > 0 {
+				names = append(names, s.Name)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "analyzers: %d findings from %s\n", len(report.Findings), strings.Join(names, ", "))
+		return errCheckFailed
+	}
+	return nil
+}
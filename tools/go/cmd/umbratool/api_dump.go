@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/apidump"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/deprecation"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/worktree"
+)
+
+func init() {
+	register(command{
+		name:    "api-dump",
+		summary: "List the public declarations of every module, at the work tree or another revision",
+		run:     runAPIDump,
+	})
+}
+
+func runAPIDump(args []string) error {
+	fs := newFlagSet("api-dump")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	rev := fs.String("rev", "", "Git revision to dump (default: the work tree)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to dump")
+	output := fs.String("output", "", "Output file (default: stdout)")
+	format := fs.String("format", "json", "Output format: json or markdown")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	api, _, err := dumpAPI(projectRoot, *rev, scopeList(*dirs))
+	if err != nil {
+		return err
+	}
+	return writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "json":
+			return apidump.WriteJSON(w, api)
+		case "markdown":
+			return apidump.WriteMarkdown(w, api)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+}
+
+// dumpAPI returns the API and the MODULE.bazel version of the project at
+// rev, checked out in a temporary worktree, or of the work tree when rev
+// is empty.
+func dumpAPI(projectRoot, rev string, dirs []string) (*apidump.API, string, error) {
+	root := projectRoot
+	if rev != "" {
+		tree, err := worktree.Checkout(projectRoot, rev)
+		if err != nil {
+			return nil, "", err
+		}
+		defer func() {
+			if err := tree.Remove(); err != nil {
+				fmt.Fprintf(os.Stderr, "umbratool: removing the worktree of %s: %v\n", rev, err)
+			}
+		}()
+		root = tree.Root
+	}
+	rules, err := moduleindex.Rules(root)
+	if err != nil {
+		return nil, "", err
+	}
+	api, err := apidump.Dump(root, apidump.Options{Dirs: dirs, Rules: rules})
+	if err != nil {
+		return nil, "", err
+	}
+	version, err := deprecation.ModuleVersion(root)
+	if err != nil {
+		return nil, "", err
+	}
+	return api, version, nil
+}
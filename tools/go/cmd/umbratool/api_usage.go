@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/apiusage"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "api-usage",
+		summary: "Count the public symbols each module uses of the modules it imports, as a coupling heatmap",
+		run:     runAPIUsage,
+	})
+}
+
+func runAPIUsage(args []string) error {
+	fs := newFlagSet("api-usage")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to scan")
+	narrow := fs.Int("narrow", 2, "Report dependencies using at most this many symbols as narrowing candidates")
+	consolidate := fs.Int("consolidate", 40, "Report dependencies using at least this many symbols as consolidation candidates")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	report, err := apiusage.Scan(projectRoot, apiusage.Options{Dirs: scopeList(*dirs), Rules: rules})
+	if err != nil {
+		return err
+	}
+
+	var issues []store.Issue
+	var narrowed []int
+	for n, p := range report.Pairs {
+		if len(p.Symbols) <= *narrow {
+			issues = append(issues, store.Issue{Module: p.Consumer, Kind: "narrow_dependency",
+				Message: fmt.Sprintf("uses %d of %d symbols of %s", len(p.Symbols), p.API, p.Provider)})
+			narrowed = append(narrowed, n)
+		}
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i, n := range narrowed {
+		report.Pairs[n].Fingerprint = issues[i].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return apiusage.WriteMarkdown(w, report, *narrow, *consolidate)
+		case "json":
+			return apiusage.WriteJSON(w, report, *narrow, *consolidate)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return export.record("api-usage", projectRoot, func(s *metrics.Set) {
+		for _, p := range report.Pairs {
+			s.Add("api_usage_symbols", "Distinct public symbols a module uses of a module it imports.", float64(len(p.Symbols)),
+				"module", p.Consumer, "provider", p.Provider)
+		}
+	}, issues)
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/audit"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/backup"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+const auditUsage = "usage: umbratool audit <verify | list> [flags]"
+
+// auditKeyEnv holds the key audit records are signed with.
+const auditKeyEnv = "UMBRATOOL_AUDIT_KEY"
+
+func init() {
+	register(command{
+		name:    "audit",
+		summary: "Verify or list the audit log of the runs that changed the tree (verify, list)",
+		run:     runAudit,
+	})
+}
+
+// invocation is the command line of the running command, after its name,
+// as audit records give it.
+var invocation []string
+
+// auditRun records an apply-mode run of a mutating command in the audit
+// log. It is begun before the run writes anything, to see the commit the
+// run started from.
+type auditRun struct {
+	root, tool, before string
+	// backup is the run's backup directory, relative to the root, or
+	// absolute when it is outside it.
+	backup string
+}
+
+// setBackup sets the run's backup directory from its absolute path.
+func (a *auditRun) setBackup(dir string) {
+	a.backup = dir
+	if rel, err := filepath.Rel(a.root, dir); err == nil && !strings.HasPrefix(rel, "..") {
+		a.backup = rel
+	}
+}
+
+func beginAudit(root, tool string) *auditRun {
+	return &auditRun{root: root, tool: tool, before: store.GitSHA(root)}
+}
+
+// done appends the record of the completed run to the audit log, with
+// the files it wrote, moved or deleted, given relative to the root or
+// absolute. A run that touched nothing is not recorded.
+func (a *auditRun) done(files []string) error {
+	return a.record(files, nil)
+}
+
+// failed records a run that stopped with cause after touching files, and
+// returns cause.
+func (a *auditRun) failed(files []string, cause error) error {
+	if err := a.record(files, cause); err != nil {
+		return fmt.Errorf("%w; %v", cause, err)
+	}
+	return cause
+}
+
+func (a *auditRun) record(files []string, cause error) error {
+	if len(files) == 0 {
+		return nil
+	}
+	r := audit.Record{
+		User:      audit.User(a.root),
+		Tool:      a.tool,
+		Args:      invocation,
+		GitBefore: a.before,
+		GitAfter:  store.GitSHA(a.root),
+		Backup:    filepath.ToSlash(a.backup),
+		Outcome:   audit.OutcomeCompleted,
+	}
+	switch {
+	case errors.Is(cause, context.Canceled) || errors.Is(cause, context.DeadlineExceeded):
+		r.Outcome, r.Error = audit.OutcomeCancelled, cause.Error()
+	case cause != nil:
+		r.Outcome, r.Error = audit.OutcomeFailed, cause.Error()
+	}
+	seen := make(map[string]bool)
+	for _, f := range files {
+		if filepath.IsAbs(f) {
+			if rel, err := filepath.Rel(a.root, f); err == nil {
+				f = rel
+			}
+		}
+		f = filepath.ToSlash(filepath.Clean(f))
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+		file := audit.File{Path: f}
+		if sum, err := backup.HashFile(filepath.Join(a.root, filepath.FromSlash(f))); err == nil {
+			file.SHA256 = sum
+		}
+		r.Files = append(r.Files, file)
+	}
+	sort.Slice(r.Files, func(i, j int) bool { return r.Files[i].Path < r.Files[j].Path })
+	if _, err := audit.Append(a.root, r, []byte(os.Getenv(auditKeyEnv))); err != nil {
+		return fmt.Errorf("recording the run in %s: %w", audit.LogFile, err)
+	}
+	return nil
+}
+
+// packageBuild returns the BUILD file of the package containing file, for
+// runs that add the files they write to a target.
+func packageBuild(root, file string) string {
+	dir := path.Dir(file)
+	for _, name := range []string{"BUILD.bazel", "BUILD"} {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(dir), name)); err == nil {
+			return path.Join(dir, name)
+		}
+	}
+	return path.Join(dir, "BUILD.bazel")
+}
+
+func runAudit(args []string) error {
+	if len(args) == 0 {
+		return errors.New(auditUsage)
+	}
+	report := args[0]
+
+	fs := newFlagSet("audit " + report)
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	requireSigned := fs.Bool("require-signed", false, "With verify, fail on records that are not signed")
+	tool := fs.String("tool", "", "With list, restrict to runs of one command, e.g. rewrite-imports")
+	file := fs.String("file", "", "With list, restrict to runs touching this file, relative to the project root")
+	limit := fs.Int("limit", 20, "With list, the most recent runs shown; 0 for all")
+	jsonOut := fs.Bool("json", false, "Print results as JSON")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+
+	switch report {
+	case "verify":
+		key := []byte(os.Getenv(auditKeyEnv))
+		v, err := audit.Verify(projectRoot, key, *requireSigned)
+		if err != nil {
+			return err
+		}
+		if *jsonOut {
+			if err := printJSON(v, nil); err != nil {
+				return err
+			}
+		} else {
+			for _, p := range v.Problems {
+				if p.Seq > 0 {
+					fmt.Printf("%s: record %d %s\n", audit.LogFile, p.Seq, p.Message)
+				} else {
+					fmt.Printf("%s: %s\n", audit.LogFile, p.Message)
+				}
+			}
+			signed := fmt.Sprintf("%d signed", v.Signed)
+			if v.Unverified > 0 {
+				signed += fmt.Sprintf(", %d unverified ($%s is not set)", v.Unverified, auditKeyEnv)
+			}
+			head := v.Head
+			if head == "" {
+				head = "none"
+			}
+			fmt.Printf("%d records, %s, head %s\n", v.Records, signed, head)
+		}
+		if len(v.Problems) > 0 {
+			return errCheckFailed
+		}
+		return nil
+
+	case "list":
+		records, err := audit.Load(projectRoot)
+		if err != nil {
+			return err
+		}
+		want := filepath.ToSlash(filepath.Clean(*file))
+		var kept []audit.Record
+		for i := len(records) - 1; i >= 0 && (*limit <= 0 || len(kept) < *limit); i-- {
+			r := records[i]
+			if *tool != "" && r.Tool != *tool {
+				continue
+			}
+			if *file != "" && !touches(r, want) {
+				continue
+			}
+			kept = append(kept, r)
+		}
+		if *jsonOut {
+			if kept == nil {
+				kept = []audit.Record{}
+			}
+			return printJSON(kept, nil)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		defer tw.Flush()
+		fmt.Fprintln(tw, "SEQ\tTIME\tUSER\tTOOL\tOUTCOME\tFILES\tCOMMIT\tARGS")
+		for _, r := range kept {
+			outcome := r.Outcome
+			if outcome == "" {
+				outcome = "-"
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n", r.Seq, r.Time.Local().Format("2006-01-02 15:04"), r.User, r.Tool, outcome, len(r.Files), shortSHA(r.GitBefore), strings.Join(r.Args, " "))
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown report %q\n%s", report, auditUsage)
+	}
+}
+
+func touches(r audit.Record, path string) bool {
+	for _, f := range r.Files {
+		if f.Path == path {
+			return true
+		}
+	}
+	return false
+}
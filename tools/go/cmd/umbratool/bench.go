@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/bench"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "bench",
+		summary: "Benchmark the analyzers' hot paths on the tree and fail on slowdowns against a baseline",
+		run:     runBench,
+	})
+}
+
+func runBench(args []string) error {
+	fs := newFlagSet("bench")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	only := fs.String("bench", "", "Comma-separated benchmarks to run (default: all)")
+	count := fs.Int("count", 3, "Runs of each benchmark; the fastest is kept")
+	baselinePath := fs.String("baseline", "", "JSON report of an earlier run to compare against, relative to the project root")
+	maxSlowdown := fs.Float64("max-slowdown", 25, "Percentage a benchmark may be slower than its baseline before the check fails")
+	list := fs.Bool("list", false, "List the benchmarks and exit")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *list {
+		for _, c := range bench.Cases {
+			fmt.Printf("%-16s %s\n", c.Name, c.Description)
+		}
+		return nil
+	}
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	var baseline []bench.Result
+	if *baselinePath != "" {
+		if baseline, err = bench.LoadResults(rootPath(projectRoot, *baselinePath)); err != nil {
+			return err
+		}
+	}
+	results, err := bench.Run(projectRoot, splitList(*only), *count)
+	if err != nil {
+		return err
+	}
+	regressions := bench.Compare(results, baseline, *maxSlowdown)
+
+	issues := make([]store.Issue, 0, len(regressions))
+	for _, r := range regressions {
+		issues = append(issues, store.Issue{Kind: "bench_regression",
+			Message: fmt.Sprintf("%s is %.0f%% slower than its baseline", r.Name, r.Slowdown), Symbol: r.Name})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for n := range regressions {
+		regressions[n].Fingerprint = issues[n].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return bench.WriteMarkdown(w, results, regressions, *maxSlowdown)
+		case "json":
+			return bench.WriteJSON(w, results, regressions)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("bench", projectRoot, func(s *metrics.Set) {
+		for _, r := range results {
+			s.Gauge("bench_ns_per_op", "Nanoseconds per run of each analyzer benchmark.", float64(r.NsPerOp), "bench", r.Name)
+			s.Gauge("bench_allocs_per_op", "Allocations per run of each analyzer benchmark.", float64(r.AllocsPerOp), "bench", r.Name)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if len(regressions) > 0 {
+		fmt.Fprintf(os.Stderr, "bench: %d benchmarks more than %.0f%% slower than the baseline\n", len(regressions), *maxSlowdown)
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/binsize"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "binary-size",
+		summary: "Attribute the symbol sizes of built archives and frameworks to modules and files, and compare with an earlier build",
+		run:     runBinarySize,
+	})
+}
+
+func runBinarySize(args []string) error {
+	fs := newFlagSet("binary-size")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	bins := fs.String("bin", "bazel-bin", "Comma-separated archives, frameworks or directories to search for them, relative to the project root")
+	targets := fs.String("targets", "", "Comma-separated target or module names to measure (default: every binary found)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories holding the sources object files are matched to")
+	baselinePath := fs.String("baseline", "", "JSON report of an earlier build to compare against, relative to the project root")
+	nm := fs.String("nm", "nm", "nm command to list symbols with")
+	sizeCommand := fs.String("size", "size", "size command to read section totals with")
+	top := fs.Int("top", 25, "Number of files and symbols listed in the Markdown report")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	var baseline *binsize.Report
+	if *baselinePath != "" {
+		if baseline, err = binsize.LoadReport(rootPath(projectRoot, *baselinePath)); err != nil {
+			return err
+		}
+	}
+	report, err := binsize.Measure(projectRoot, binsize.Options{
+		Paths:   splitList(*bins),
+		Targets: splitList(*targets),
+		Dirs:    scopeList(*dirs),
+		Rules:   rules,
+		NM:      *nm,
+		Size:    *sizeCommand,
+	})
+	if err != nil {
+		return err
+	}
+	var deltas []binsize.Delta
+	if baseline != nil {
+		deltas = binsize.Compare(report, baseline)
+		if deltas == nil {
+			deltas = []binsize.Delta{}
+		}
+	}
+
+	var issues []store.Issue
+	for _, d := range deltas {
+		if d.Change() > 0 {
+			issues = append(issues, store.Issue{Module: d.Module, Kind: "binary_growth", Message: fmt.Sprintf("%s grew by %d bytes to %d since the baseline", d.Module, d.Change(), d.After)})
+		}
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	i := 0
+	for n, d := range deltas {
+		if d.Change() > 0 {
+			deltas[n].Fingerprint = issues[i].Fingerprint
+			i++
+		}
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return binsize.WriteMarkdown(w, report, deltas, *top)
+		case "json":
+			return binsize.WriteJSON(w, report, deltas)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return export.record("binary-size", projectRoot, func(s *metrics.Set) {
+		for _, b := range report.Binaries {
+			s.Gauge("binary_bytes", "Section totals of each measured binary.", float64(b.Text), "binary", b.Path, "section", "text")
+			s.Gauge("binary_bytes", "Section totals of each measured binary.", float64(b.Data), "binary", b.Path, "section", "data")
+			s.Gauge("binary_bytes", "Section totals of each measured binary.", float64(b.BSS), "binary", b.Path, "section", "bss")
+		}
+		for _, m := range report.Modules {
+			s.Gauge("module_symbol_bytes", "Symbol sizes attributed to each module.", float64(m.Size), "module", m.Name)
+		}
+	}, issues)
+}
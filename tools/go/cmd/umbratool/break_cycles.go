@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/cyclebreak"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/health"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "break-cycles",
+		summary: "Suggest the imports to cut and the declarations to move or hide behind protocols to break each import cycle",
+		run:     runBreakCycles,
+	})
+}
+
+func runBreakCycles(args []string) error {
+	fs := newFlagSet("break-cycles")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	scope := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories scanned for imports")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	strict := fs.Bool("strict", false, "Exit non-zero when there is an import cycle")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	files, err := imports.ScanTree(projectRoot, scopeList(*scope)...)
+	if err != nil {
+		return err
+	}
+	plans, err := cyclebreak.Suggest(projectRoot, rules, files, health.FindCycles(rules, files))
+	if err != nil {
+		return err
+	}
+
+	var issues []store.Issue
+	for _, p := range plans {
+		for _, e := range p.Cut {
+			issues = append(issues, store.Issue{Module: e.From, File: e.Uses[0].File, Line: e.Uses[0].Line, Kind: cyclebreak.IssueCut,
+				Message: fmt.Sprintf("cut %s (%d files) to break %s", e, e.Files(), p.Cycle)})
+		}
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	i := 0
+	for _, p := range plans {
+		for n := range p.Cut {
+			p.Cut[n].Fingerprint = issues[i].Fingerprint
+			i++
+		}
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return cyclebreak.WriteMarkdown(w, plans)
+		case "json":
+			return cyclebreak.WriteJSON(w, plans)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("break-cycles", projectRoot, func(s *metrics.Set) {
+		for _, p := range plans {
+			s.Gauge("cycle_break_files", "Files to change to break each import cycle.", float64(p.Files()), "cycle", p.Cycle.String())
+		}
+		s.Gauge("dependency_cycles", "Import cycles between modules.", float64(len(plans)))
+	}, issues)
+	if err != nil {
+		return err
+	}
+	if *strict && len(plans) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
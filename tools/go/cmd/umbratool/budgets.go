@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/budget"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/complexity"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "budgets",
+		summary: "Check file and module sizes against the budgets of umbratool.yaml, failing only on regressions",
+		run:     runBudgets,
+	})
+}
+
+func runBudgets(args []string) error {
+	fs := newFlagSet("budgets")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	configPath := fs.String("config", "umbratool.yaml", "Config holding the budgets section, relative to the project root")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to check")
+	baselinePath := fs.String("baseline", budget.BaselineFile, "Baseline of accepted violations, relative to the project root")
+	update := fs.Bool("update-baseline", false, "Accept every current violation into the baseline at its current size")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	config, err := budget.LoadConfig(rootPath(projectRoot, *configPath))
+	if err != nil {
+		return err
+	}
+	sizes, err := complexity.Count(projectRoot, scopeList(*dirs)...)
+	if err != nil {
+		return err
+	}
+	violations := budget.Check(sizes, config)
+
+	baselineFile := rootPath(projectRoot, *baselinePath)
+	if *update {
+		if err := budget.NewBaseline(violations).Write(baselineFile); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %d accepted violations to %s\n", len(violations), *baselinePath)
+		return nil
+	}
+	baseline, err := budget.LoadBaseline(baselineFile)
+	if err != nil {
+		return err
+	}
+	resolved := baseline.Apply(violations)
+
+	var issues []store.Issue
+	var regressed []int
+	for n, v := range violations {
+		if !v.Regression() {
+			continue
+		}
+		file := ""
+		if v.Kind == budget.KindFileLines {
+			file = v.Path
+		}
+		issues = append(issues, store.Issue{Module: v.Module, File: file, Kind: v.Kind,
+			Message: fmt.Sprintf("%s is %d over its budget of %d", v.Path, v.Value-v.Limit, v.Limit), Symbol: v.Path})
+		regressed = append(regressed, n)
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i, n := range regressed {
+		violations[n].Fingerprint = issues[i].Fingerprint
+	}
+	regressions := budget.Regressions(violations)
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return budget.WriteMarkdown(w, config, violations, resolved)
+		case "json":
+			return budget.WriteJSON(w, violations, resolved)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("budgets", projectRoot, func(s *metrics.Set) {
+		for _, v := range violations {
+			s.Add("budget_excess", "Size over budget per module by budget.", float64(v.Value-v.Limit), "module", v.Module, "budget", v.Kind)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if len(regressions) > 0 {
+		fmt.Fprintf(os.Stderr, "budgets: %d regressions over budget\n", len(regressions))
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildtimes"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/complexity"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/granularity"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "build-times",
+		summary: "Record per-target build times from a Bazel profile and flag targets slower than in earlier runs",
+		run:     runBuildTimes,
+	})
+}
+
+func runBuildTimes(args []string) error {
+	fs := newFlagSet("build-times")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to count the targets' code in")
+	profile := fs.String("profile", "", "Bazel build profile (--profile output, gzipped or not) to read the times from")
+	results := fs.String("results", "results.db", "Result store to read earlier build-times runs from (optional)")
+	runs := fs.Int("runs", 5, "Number of earlier runs whose median time is each target's baseline")
+	maxSlowdown := fs.Float64("max-slowdown", 25, "Percentage a target may be slower than its baseline before it regresses")
+	minSeconds := fs.Float64("min-seconds", 1, "Least slowdown in seconds that counts as a regression")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	strict := fs.Bool("strict", false, "Exit non-zero when any target regresses")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *profile == "" {
+		return errors.New("--profile is required")
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	times, err := granularity.LoadProfile(rootPath(projectRoot, *profile))
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	counts, err := complexity.Count(projectRoot, scopeList(*dirs)...)
+	if err != nil {
+		return err
+	}
+	targets := buildtimes.Measure(counts, rules, times)
+
+	history, err := storedBuildTimes(resultPath(projectRoot, *results), *runs)
+	if err != nil {
+		return err
+	}
+	opts := buildtimes.Options{MaxSlowdown: *maxSlowdown, MinSeconds: *minSeconds}
+	regressions := buildtimes.Compare(targets, history, opts)
+
+	issues := make([]store.Issue, 0, len(regressions))
+	for _, r := range regressions {
+		issues = append(issues, store.Issue{Module: r.Module, File: r.File, Kind: buildtimes.IssueRegression, Message: r.Message()})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for n := range regressions {
+		regressions[n].Fingerprint = issues[n].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return buildtimes.WriteMarkdown(w, targets, history, regressions, opts)
+		case "json":
+			return buildtimes.WriteJSON(w, targets, history, regressions)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record(buildtimes.Tool, projectRoot, func(s *metrics.Set) {
+		buildtimes.Fill(s, targets)
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if *strict && len(regressions) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// storedBuildTimes returns the last runs build-times runs in the store at
+// path, newest first, or nil when there is no store.
+func storedBuildTimes(path string, runs int) ([]buildtimes.Snapshot, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+	db, err := store.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return buildtimes.Load(runContext, db, runs)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/bzlmod"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "bzlmod-migrate",
+		summary: "Plan the move of WORKSPACE repository rules to MODULE.bazel, and apply it",
+		run:     runBzlmodMigrate,
+	})
+}
+
+func runBzlmodMigrate(args []string) error {
+	fs := newFlagSet("bzlmod-migrate")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	candidate := fs.String("candidate", "", "Write the candidate MODULE.bazel to this file, e.g. MODULE.bazel.candidate")
+	apply := fs.Bool("apply", false, "Append the migrated declarations to MODULE.bazel, then report what is left")
+	prune := fs.Bool("prune", false, "With --apply, also delete the migrated statements and their loads from WORKSPACE")
+	dryRun := fs.Bool("dry-run", false, "With --apply, print the changes as a unified diff instead of writing them")
+	keepBackup := fs.Bool("backup", true, "With --apply, back up the changed files first, for the restore command")
+	strict := fs.Bool("strict", false, "Fail while WORKSPACE declares repositories MODULE.bazel does not")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	report, err := bzlmod.Inventory(projectRoot)
+	if err != nil {
+		return err
+	}
+	if *candidate != "" {
+		if err := atomicfile.WriteFile(rootPath(projectRoot, *candidate), []byte(report.Candidate), 0o644); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "bzlmod-migrate: wrote the candidate to %s\n", *candidate)
+	}
+	if *apply {
+		plan, err := bzlmod.Plan(projectRoot, report, *prune)
+		if err != nil {
+			return err
+		}
+		for _, w := range plan.Warnings {
+			fmt.Fprintf(os.Stderr, "bzlmod-migrate: %s\n", w)
+		}
+		if _, err := applyRewrite(projectRoot, "bzlmod-migrate", plan, *dryRun, *keepBackup, nil); err != nil || *dryRun {
+			return err
+		}
+		if report, err = bzlmod.Inventory(projectRoot); err != nil {
+			return err
+		}
+	}
+
+	var issues []store.Issue
+	for _, e := range report.Entries {
+		if e.Status != bzlmod.StatusUnsupported {
+			continue
+		}
+		what := e.Kind
+		if e.Name != "" {
+			what += " @" + e.Name
+		}
+		issues = append(issues, store.Issue{File: e.File, Line: e.Line, Kind: "workspace_rule", Message: fmt.Sprintf("%s lacks bzlmod support: %s", what, e.Note)})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	i := 0
+	for n, e := range report.Entries {
+		if e.Status == bzlmod.StatusUnsupported {
+			report.Entries[n].Fingerprint = issues[i].Fingerprint
+			i++
+		}
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return bzlmod.WriteMarkdown(w, report)
+		case "json":
+			return bzlmod.WriteJSON(w, report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("bzlmod-migrate", projectRoot, func(s *metrics.Set) {
+		for _, status := range bzlmod.Statuses {
+			s.Gauge("workspace_statements", "WORKSPACE statements by how they move to MODULE.bazel.", float64(report.Count(status)), "status", status)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if pending := len(report.Pending()); *strict && pending > 0 {
+		fmt.Fprintf(os.Stderr, "bzlmod-migrate: %d statements of %s are not in MODULE.bazel\n", pending, report.Workspace)
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/changelog"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+const changelogUsage = "usage: umbratool changelog <add | assemble> [flags]"
+
+func init() {
+	register(command{
+		name:    "changelog",
+		summary: "Record structural changes in changelog.d and merge them into CHANGELOG.md (add, assemble)",
+		run:     runChangelog,
+	})
+}
+
+func runChangelog(args []string) error {
+	if len(args) == 0 {
+		return errors.New(changelogUsage)
+	}
+	switch args[0] {
+	case "add":
+		return runChangelogAdd(args[1:])
+	case "assemble":
+		return runChangelogAssemble(args[1:])
+	default:
+		return fmt.Errorf("unknown changelog action %q; %s", args[0], changelogUsage)
+	}
+}
+
+// notesValue collects a repeatable string flag.
+type notesValue []string
+
+func (n *notesValue) String() string { return strings.Join(*n, "; ") }
+
+func (n *notesValue) Set(s string) error {
+	*n = append(*n, s)
+	return nil
+}
+
+func runChangelogAdd(args []string) error {
+	fs := newFlagSet("changelog add")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dir := fs.String("dir", changelog.Dir, "Fragment directory, relative to the project root")
+	tool := fs.String("tool", "", "Tool that made the change, e.g. security_module_consolidator (required)")
+	kind := fs.String("kind", "", "Change kind: "+strings.Join(changelog.Kinds, ", ")+" (required)")
+	summary := fs.String("summary", "", "One-line Markdown description of the change (required)")
+	modules := fs.String("modules", "", "Comma-separated modules the change affects")
+	var migration notesValue
+	fs.Var(&migration, "migration", "Migration note for dependent code; repeat for several")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	path, err := changelog.Write(rootPath(projectRoot, *dir), changelog.Fragment{
+		Tool:      *tool,
+		Kind:      *kind,
+		Summary:   *summary,
+		Modules:   splitList(*modules),
+		Migration: migration,
+	})
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(projectRoot, path); err == nil {
+		path = rel
+	}
+	fmt.Printf("Wrote %s\n", filepath.ToSlash(path))
+	return nil
+}
+
+func runChangelogAssemble(args []string) error {
+	fs := newFlagSet("changelog assemble")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dir := fs.String("dir", changelog.Dir, "Fragment directory, relative to the project root")
+	file := fs.String("changelog", "CHANGELOG.md", "Changelog to update, relative to the project root")
+	version := fs.String("version", "", "Release version heading the new section (required)")
+	date := fs.String("date", "", "Release date as YYYY-MM-DD (default: today)")
+	dryRun := fs.Bool("dry-run", false, "Print the section instead of updating the changelog")
+	keep := fs.Bool("keep", false, "Keep the fragments after merging them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *version == "" {
+		return errors.New("--version is required")
+	}
+	released := time.Now()
+	if *date != "" {
+		var err error
+		if released, err = time.Parse("2006-01-02", *date); err != nil {
+			return fmt.Errorf("--date: %w", err)
+		}
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	frags, err := changelog.Load(rootPath(projectRoot, *dir))
+	if err != nil {
+		return err
+	}
+	if len(frags) == 0 {
+		fmt.Println("No changelog fragments to assemble")
+		return nil
+	}
+
+	var section bytes.Buffer
+	if err := changelog.WriteSection(&section, *version, released, frags); err != nil {
+		return err
+	}
+	if *dryRun {
+		_, err := os.Stdout.Write(section.Bytes())
+		return err
+	}
+
+	run := beginAudit(projectRoot, "changelog")
+	path := rootPath(projectRoot, *file)
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := atomicfile.WriteFile(path, changelog.Insert(existing, section.Bytes()), 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Added %d changes to %s under %s\n", len(frags), *file, *version)
+
+	touched := []string{path}
+	if !*keep {
+		for _, f := range frags {
+			if err := os.Remove(f.File); err != nil {
+				return run.failed(touched, err)
+			}
+			touched = append(touched, f.File)
+		}
+	}
+	return run.done(touched)
+}
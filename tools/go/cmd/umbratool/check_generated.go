@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/generated"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/owners"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// generators are the umbratool generators check-generated can rerun, by
+// the tool their markers name.
+var generators = map[string]generated.Generator{
+	owners.Tool: regenerateCodeowners,
+}
+
+func init() {
+	register(command{
+		name:    "check-generated",
+		summary: "Detect hand edits to generated files and regions, or regenerate them with a three-way merge",
+		run:     runCheckGenerated,
+	})
+}
+
+func runCheckGenerated(args []string) error {
+	fs := newFlagSet("check-generated")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "", "Comma-separated scopes (sources, tests, testsupport) or directories to search (default: the whole tree)")
+	regenerate := fs.Bool("regenerate", false, "Rewrite regions umbratool generates, merging hand edits into the new content")
+	overwrite := fs.Bool("overwrite", false, "With --regenerate, discard hand edits instead of merging them")
+	stamp := fs.Bool("stamp", false, "Stamp the files given as arguments with a checksum header instead of checking")
+	tool := fs.String("tool", "", "With --stamp, the generator that wrote the files")
+	source := fs.String("source", "", "With --stamp, the input the files were generated from")
+	format := fs.String("format", "text", "Output format: text or json")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	strict := fs.Bool("strict", false, "Exit non-zero on warnings as well as errors")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	if *stamp {
+		return stampGenerated(projectRoot, *tool, *source, fs.Args())
+	}
+	if *overwrite && !*regenerate {
+		return errors.New("--overwrite requires --regenerate")
+	}
+
+	report, err := generated.Check(projectRoot, generated.Options{
+		Dirs:       scopeList(*dirs),
+		Generators: generators,
+		Regenerate: *regenerate,
+		Overwrite:  *overwrite,
+	})
+	if err != nil {
+		return err
+	}
+
+	regions, errs, warnings := 0, 0, 0
+	for _, f := range report.Files {
+		regions += len(f.Regions)
+	}
+	for _, i := range report.Issues {
+		if i.Severity == "error" {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+	stored := make([]store.Issue, 0, len(report.Issues))
+	for _, i := range report.Issues {
+		stored = append(stored, store.Issue{Module: i.Module, File: i.File, Line: i.Line, Kind: i.Kind, Message: i.Message})
+	}
+	if err := annotateIssues(projectRoot, stored); err != nil {
+		return err
+	}
+	for n := range report.Issues {
+		report.Issues[n].Fingerprint = stored[n].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "text":
+			for _, rel := range report.Regenerated {
+				fmt.Fprintf(w, "regenerated %s\n", rel)
+			}
+			for _, i := range report.Issues {
+				fmt.Fprintf(w, "%s:%d: %s: %s\n", i.File, i.Line, i.Severity, i.Message)
+			}
+			_, err := fmt.Fprintf(w, "%d generated regions in %d files: %d errors, %d warnings\n", regions, len(report.Files), errs, warnings)
+			return err
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("check-generated", projectRoot, func(s *metrics.Set) {
+		for _, i := range report.Issues {
+			s.Add("generated_region_issues", "Hand-edited, stale or unstamped generated regions per module by kind.", 1, "module", i.Module, "kind", i.Kind)
+		}
+	}, stored)
+	if err != nil {
+		return err
+	}
+
+	if errs > 0 || (*strict && warnings > 0) {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// stampGenerated gives the output of a generator outside umbratool, such
+// as error_migrator, a checksum header.
+func stampGenerated(root, tool, source string, files []string) error {
+	if tool == "" || len(files) == 0 {
+		return errors.New("--stamp needs --tool and the files to stamp")
+	}
+	run := beginAudit(root, "check-generated")
+	for i, f := range files {
+		if err := runContext.Err(); err != nil {
+			return run.failed(files[:i], fmt.Errorf("%w after stamping %d of %d files", err, i, len(files)))
+		}
+		comment := generated.CommentFor(f)
+		if comment == "" {
+			return run.failed(files[:i], fmt.Errorf("%s: no line comment syntax known for this file type", f))
+		}
+		full := rootPath(root, f)
+		data, err := os.ReadFile(full)
+		if err != nil {
+			return run.failed(files[:i], err)
+		}
+		info, err := os.Stat(full)
+		if err != nil {
+			return run.failed(files[:i], err)
+		}
+		if err := atomicfile.WriteFile(full, []byte(generated.Stamp(string(data), comment, tool, source)), info.Mode().Perm()); err != nil {
+			return run.failed(files[:i], err)
+		}
+		fmt.Printf("stamped %s\n", f)
+	}
+	return run.done(files)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/header"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "check-headers",
+		summary: "Verify (and optionally fix) the standard file header",
+		run:     runCheckHeaders,
+	})
+}
+
+func runCheckHeaders(args []string) error {
+	fs := newFlagSet("check-headers")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	templatePath := fs.String("template", "", "Header template file (default: built-in UmbraCore header)")
+	marker := fs.String("marker", header.DefaultMarker, "Regular expression a compliant header must match")
+	exts := fs.String("ext", ".swift", "Comma-separated file extensions to check")
+	exclude := fs.String("exclude", "", "Additional comma-separated path globs to exclude")
+	fix := fs.Bool("fix", false, "Insert or update headers in non-compliant files")
+	jsonOut := fs.Bool("json", false, "Print results as JSON")
+	lint := addSwiftLintFlags(fs, false)
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+
+	checker := header.NewChecker()
+	checker.Extensions = splitList(*exts)
+	checker.Excludes = append(checker.Excludes, splitList(*exclude)...)
+	if checker.Marker, err = regexp.Compile(*marker); err != nil {
+		return fmt.Errorf("invalid --marker: %w", err)
+	}
+	if *templatePath != "" {
+		tmpl, err := os.ReadFile(*templatePath)
+		if err != nil {
+			return err
+		}
+		checker.Template = string(tmpl)
+	}
+
+	var results []header.Result
+	if fs.NArg() == 0 {
+		if results, err = checker.Check(projectRoot); err != nil {
+			return err
+		}
+	}
+	for _, arg := range fs.Args() {
+		rel := path.Clean(filepath.ToSlash(arg))
+		content, err := os.ReadFile(filepath.Join(projectRoot, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		if r, ok := checker.CheckFile(rel, string(content)); ok {
+			results = append(results, r)
+		}
+	}
+
+	var failing []header.Result
+	for _, r := range results {
+		if r.Status != header.StatusOK {
+			failing = append(failing, r)
+		}
+	}
+
+	issues := make([]store.Issue, 0, len(failing))
+	for _, r := range failing {
+		issues = append(issues, store.Issue{Module: r.Module, File: r.File, Kind: "header-" + string(r.Status), Message: r.Detail})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for n := range failing {
+		failing[n].Fingerprint = issues[n].Fingerprint
+	}
+	err = export.record("check-headers", projectRoot, func(s *metrics.Set) {
+		for _, r := range results {
+			s.Add("header_files", "Checked files per module and header status.", 1, "module", r.Module, "status", string(r.Status))
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if *fix {
+		run := beginAudit(projectRoot, "check-headers")
+		touched := make([]string, 0, len(failing))
+		for _, r := range failing {
+			if err := runContext.Err(); err != nil {
+				return run.failed(touched, fmt.Errorf("%w after fixing %d of %d files", err, len(touched), len(failing)))
+			}
+			if err := checker.Fix(projectRoot, r); err != nil {
+				return run.failed(touched, fmt.Errorf("fixing %s: %w", r.File, err))
+			}
+			fmt.Printf("fixed %s (%s)\n", r.File, r.Status)
+			touched = append(touched, r.File)
+		}
+		fmt.Printf("%d of %d files updated\n", len(failing), len(results))
+
+		if _, err := lint.fixTouched(projectRoot, touched); err != nil {
+			return run.failed(touched, err)
+		}
+		return run.done(touched)
+	}
+
+	if *jsonOut {
+		err = writeOutput("", func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(failing)
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, r := range failing {
+			if r.Detail != "" {
+				fmt.Printf("%s: %s header (%s)\n", r.File, r.Status, r.Detail)
+			} else {
+				fmt.Printf("%s: %s header\n", r.File, r.Status)
+			}
+		}
+		fmt.Printf("%d of %d files non-compliant\n", len(failing), len(results))
+	}
+
+	if len(failing) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/generated"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modules"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/owners"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "codeowners",
+		summary: "Generate or validate CODEOWNERS from the module ownership manifest",
+		run:     runCodeowners,
+	})
+}
+
+func runCodeowners(args []string) error {
+	fs := newFlagSet("codeowners")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	manifestPath := fs.String("manifest", "owners.yaml", "Ownership manifest, relative to the project root")
+	file := fs.String("file", ".github/CODEOWNERS", "CODEOWNERS file, relative to the project root")
+	write := fs.Bool("write", false, "Write the generated block into the CODEOWNERS file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+
+	manifest, assignments, issues, err := resolveOwners(projectRoot, *manifestPath)
+	if err != nil {
+		return err
+	}
+	block := owners.Render(assignments, *manifestPath)
+
+	codeownersPath := rootPath(projectRoot, *file)
+	existing, err := os.ReadFile(codeownersPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if *write {
+		if err := os.MkdirAll(filepath.Dir(codeownersPath), 0o755); err != nil {
+			return err
+		}
+		run := beginAudit(projectRoot, "codeowners")
+		if err := atomicfile.WriteFile(codeownersPath, []byte(owners.Splice(string(existing), block)), 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("wrote %d module entries to %s\n", len(assignments), *file)
+		if err := run.done([]string{codeownersPath}); err != nil {
+			return err
+		}
+	} else {
+		issues = append(issues, owners.Validate(string(existing), assignments, manifest, modules.DefaultScopes)...)
+	}
+
+	for _, issue := range issues {
+		fmt.Printf("%s: %s\n", issue.Kind, issue.Message)
+	}
+	if len(issues) > 0 {
+		fmt.Printf("%d ownership issues found\n", len(issues))
+		return errCheckFailed
+	}
+	return nil
+}
+
+// resolveOwners assigns the workspace's modules their owners from the
+// manifest.
+func resolveOwners(root, manifestPath string) (*owners.Manifest, []owners.Assignment, []owners.Issue, error) {
+	manifest, err := owners.LoadManifest(rootPath(root, manifestPath))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	mods, err := modules.Discover(root)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	assignments, issues := manifest.Resolve(mods)
+	return manifest, assignments, issues, nil
+}
+
+// regenerateCodeowners renders the CODEOWNERS block for check-generated
+// from the manifest its markers name.
+func regenerateCodeowners(root string, r generated.Region) (string, error) {
+	_, assignments, _, err := resolveOwners(root, r.Source)
+	if err != nil {
+		return "", err
+	}
+	return owners.Entries(assignments), nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/apidump"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "compat-check",
+		summary: "Compare the public API with another revision's and fail on breaking changes",
+		run:     runCompatCheck,
+	})
+}
+
+func runCompatCheck(args []string) error {
+	fs := newFlagSet("compat-check")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	base := fs.String("base", "origin/main", "Git revision to compare against")
+	head := fs.String("head", "", "Git revision to check (default: the work tree)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to compare")
+	allow := fs.Bool("allow-breaking", false, "Report breaking changes without failing, as the api-break pull request label does")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	emit := fs.String("emit", "", "Print diagnostics instead of the report: "+emitFormats)
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	baseAPI, baseVersion, err := dumpAPI(projectRoot, *base, scopeList(*dirs))
+	if err != nil {
+		return err
+	}
+	headAPI, headVersion, err := dumpAPI(projectRoot, *head, scopeList(*dirs))
+	if err != nil {
+		return err
+	}
+	headName := *head
+	if headName == "" {
+		headName = "work tree"
+	}
+	report := apidump.NewReport(*base, headName, baseAPI, headAPI, baseVersion, headVersion)
+
+	var issues []store.Issue
+	var broken []int
+	for n, c := range report.Changes {
+		if !c.Breaking {
+			continue
+		}
+		d := c.New
+		if d == nil {
+			d = c.Old
+		}
+		issues = append(issues, store.Issue{Module: c.Module, File: d.File, Line: d.Line, Kind: "api-break", Message: c.Name + " " + c.Reason, Symbol: c.Name})
+		broken = append(broken, n)
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i, n := range broken {
+		report.Changes[n].Fingerprint = issues[i].Fingerprint
+	}
+
+	if *emit != "" {
+		err = writeOutput(*output, func(w io.Writer) error {
+			var diags []diagnostic
+			for _, c := range report.Breaking() {
+				d := c.New
+				if d == nil {
+					d = c.Old
+				}
+				diags = append(diags, diagnostic{File: d.File, Line: d.Line, Severity: "error", Title: "compat-check: " + c.Kind, Message: c.Module + "." + c.Name + " " + c.Reason})
+			}
+			return writeDiagnostics(w, *emit, projectRoot, diags)
+		})
+	} else {
+		err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+			switch *format {
+			case "markdown":
+				return apidump.WriteReportMarkdown(w, report)
+			case "json":
+				return apidump.WriteReportJSON(w, report)
+			default:
+				return fmt.Errorf("unknown format %q", *format)
+			}
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	breaking := report.Breaking()
+	err = export.record("compat-check", projectRoot, func(s *metrics.Set) {
+		for _, c := range report.Changes {
+			s.Add("api_changes", "Public API changes against the base revision per module.", 1, "module", c.Module, "kind", c.Kind, "breaking", fmt.Sprint(c.Breaking))
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if len(breaking) > 0 && !report.Bumped && !*allow {
+		fmt.Fprintf(os.Stderr, "compat-check: %d breaking API changes against %s need a %s release; bump the version in MODULE.bazel or label the pull request api-break\n", len(breaking), *base, report.Required)
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/bazel"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftdiag"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "compiler-warnings",
+		summary: "Build with --keep_going (or read a build log) and group the swiftc warnings by module and kind",
+		run:     runCompilerWarnings,
+	})
+}
+
+func runCompilerWarnings(args []string) error {
+	fs := newFlagSet("compiler-warnings")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	logPath := fs.String("log", "", "Read diagnostics from this build log instead of building; - for stdin")
+	targets := fs.String("targets", "//Sources/...", "Comma-separated target patterns to build")
+	ratchet := fs.Bool("ratchet", false, "Fail when a module has more warnings than in the newest run in --store")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *ratchet && *export.store == "" {
+		return errors.New("--ratchet needs --store")
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	buildLog, err := readBuildLog(bazelRunner(projectRoot), *logPath, splitList(*targets))
+	if err != nil {
+		return err
+	}
+	diags, err := swiftdiag.Parse(bytes.NewReader(buildLog), swiftdiag.Options{Root: projectRoot, Rules: rules})
+	if err != nil {
+		return err
+	}
+
+	// The previous counts are read before this run is recorded over them.
+	var previous map[string]int
+	if *ratchet {
+		if previous, err = storedIssueCounts(*export.store, "compiler-warnings"); err != nil {
+			return err
+		}
+	}
+	summaries := swiftdiag.Summarise(diags, previous)
+
+	// Only warnings are recorded as issues, so that the ratchet compares
+	// like with like; errors fail the build on their own.
+	var issues []store.Issue
+	for _, d := range diags {
+		if d.Severity == swiftdiag.SeverityWarning {
+			issues = append(issues, store.Issue{Module: d.Module, File: d.File, Line: d.Line, Kind: d.Kind, Message: d.Message})
+		}
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	i := 0
+	for n, d := range diags {
+		if d.Severity == swiftdiag.SeverityWarning {
+			diags[n].Fingerprint = issues[i].Fingerprint
+			i++
+		}
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return swiftdiag.WriteMarkdown(w, diags, summaries)
+		case "json":
+			return swiftdiag.WriteJSON(w, diags, summaries)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("compiler-warnings", projectRoot, func(s *metrics.Set) {
+		for _, sum := range summaries {
+			for kind, n := range sum.ByKind {
+				s.Add("compiler_warnings", "Swift compiler warnings per module by kind.", float64(n), "module", sum.Module, "kind", kind)
+			}
+			s.Add("compiler_errors", "Swift compiler errors per module.", float64(sum.Errors), "module", sum.Module)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if increases := swiftdiag.Increases(summaries); len(increases) > 0 {
+		fmt.Fprintf(os.Stderr, "compiler-warnings: %d modules have more warnings than in the previous run\n", len(increases))
+		return errCheckFailed
+	}
+	return nil
+}
+
+// readBuildLog returns the log at path, or builds targets with runner and
+// --keep_going and returns what Bazel printed. A failing build is not an
+// error here: its diagnostics are what the report is for.
+func readBuildLog(runner *bazel.Runner, path string, targets []string) ([]byte, error) {
+	switch path {
+	case "":
+		args := append([]string{"build"}, targets...)
+		args = append(args, "--keep_going", "--color=no", "--curses=no")
+		out, err := runner.Combined(runContext, args...)
+		if err != nil && len(out) == 0 {
+			return nil, err
+		}
+		return out, nil
+	case "-":
+		return io.ReadAll(os.Stdin)
+	default:
+		return os.ReadFile(path)
+	}
+}
+
+// storedIssueCounts returns the issues per module of the newest run of
+// tool in the store at path, or nil when there is none, in which case a
+// ratchet has nothing to compare against.
+func storedIssueCounts(path, tool string) (map[string]int, error) {
+	path, err := outPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+	db, err := store.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	counts, ok, err := db.LatestIssueCounts(runContext, tool)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/complexity"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/jsonl"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "complexity",
+		summary: "Report lines of code and cyclomatic complexity per module, file and function",
+		run:     runComplexity,
+	})
+}
+
+func runComplexity(args []string) error {
+	fs := newFlagSet("complexity")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to analyse")
+	srcsManifest := fs.String("srcs-manifest", "", "File listing the Swift files to analyse, one per line, instead of walking --scope (as a Bazel aspect writes it)")
+	top := fs.Int("top", 25, "Number of files and functions listed in the Markdown report")
+	maxFunction := fs.Int("max-function", 0, "Fail when any function is more complex than this (0 disables)")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown, json or jsonl")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := analysisRoot(*root, *srcsManifest)
+	if err != nil {
+		return err
+	}
+
+	analyse := complexity.AnalyseEach
+	if *srcsManifest != "" {
+		paths, err := readManifest(*srcsManifest)
+		if err != nil {
+			return err
+		}
+		analyse = func(root string, _ []string, each func(complexity.File) error) (*complexity.Report, error) {
+			return complexity.AnalyseFilesEach(root, paths, each)
+		}
+	}
+
+	annotations, err := newAnnotator(projectRoot)
+	if err != nil {
+		return err
+	}
+	var report *complexity.Report
+	if *format == "jsonl" {
+		err = writeJSONL(fs, projectRoot, *output, func(w *jsonl.Writer) (any, error) {
+			blocking := 0
+			var err error
+			report, err = analyse(projectRoot, scopeList(*dirs), func(f complexity.File) error {
+				if err := w.Write("file", f); err != nil {
+					return err
+				}
+				for _, fn := range f.Functions {
+					if *maxFunction > 0 && fn.Complexity > *maxFunction {
+						issue := complexityIssue(f.Path, fn, *maxFunction)
+						annotations.annotate(&issue)
+						record := issueRecord{Issue: issue, Blocking: annotations.blocking(issue, true)}
+						if record.Blocking {
+							blocking++
+						}
+						if err := w.Write("issue", record); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range report.Modules {
+				if err := w.Write("module", m); err != nil {
+					return nil, err
+				}
+			}
+			return struct {
+				Modules  int `json:"modules"`
+				Files    int `json:"files"`
+				Blocking int `json:"blocking"`
+			}{len(report.Modules), len(report.Files), blocking}, nil
+		})
+	} else {
+		if report, err = analyse(projectRoot, scopeList(*dirs), nil); err != nil {
+			return err
+		}
+		if err := fingerprintFunctions(projectRoot, report, *maxFunction); err != nil {
+			return err
+		}
+		err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+			switch *format {
+			case "markdown":
+				return complexity.WriteMarkdown(w, report, *top)
+			case "json":
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			default:
+				return fmt.Errorf("unknown format %q", *format)
+			}
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	var issues []store.Issue
+	if *maxFunction > 0 {
+		for _, fn := range complexity.TopFunctions(report.Files, 0) {
+			if fn.Complexity <= *maxFunction {
+				break
+			}
+			issues = append(issues, complexityIssue(fn.File, fn.Function, *maxFunction))
+		}
+	}
+
+	err = export.record("complexity", projectRoot, func(s *metrics.Set) {
+		for _, m := range report.Modules {
+			s.Gauge("loc", "Lines per module by kind.", float64(m.Code), "module", m.Name, "kind", "code")
+			s.Gauge("loc", "Lines per module by kind.", float64(m.Comments), "module", m.Name, "kind", "comment")
+			s.Gauge("loc", "Lines per module by kind.", float64(m.Blank), "module", m.Name, "kind", "blank")
+			s.Gauge("swift_files", "Swift files per module.", float64(m.Files), "module", m.Name)
+			s.Gauge("functions", "Functions per module.", float64(m.Functions), "module", m.Name)
+			s.Gauge("complexity_total", "Summed cyclomatic complexity per module.", float64(m.Complexity), "module", m.Name)
+			s.Gauge("complexity_max", "Highest function cyclomatic complexity per module.", float64(m.MaxComplexity), "module", m.Name)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		fmt.Fprintf(os.Stderr, "%s:%d: %s\n", issue.File, issue.Line, issue.Message)
+	}
+	if len(issues) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// fingerprintFunctions sets the fingerprints of the functions in report
+// more complex than max, the ones reported as issues.
+func fingerprintFunctions(root string, report *complexity.Report, max int) error {
+	if max <= 0 {
+		return nil
+	}
+	var issues []store.Issue
+	var over []*complexity.Function
+	for _, f := range report.Files {
+		for n, fn := range f.Functions {
+			if fn.Complexity > max {
+				issues = append(issues, complexityIssue(f.Path, fn, max))
+				over = append(over, &f.Functions[n])
+			}
+		}
+	}
+	if err := annotateIssues(root, issues); err != nil {
+		return err
+	}
+	for i, fn := range over {
+		fn.Fingerprint = issues[i].Fingerprint
+	}
+	return nil
+}
+
+// complexityIssue reports fn, in the file at path, as more complex than
+// max.
+func complexityIssue(path string, fn complexity.Function, max int) store.Issue {
+	return store.Issue{
+		Module:  workspace.ModuleForPath(path),
+		File:    path,
+		Line:    fn.Line,
+		Kind:    "complexity",
+		Message: fmt.Sprintf("%s has complexity %d (max %d)", fn.Name, fn.Complexity, max),
+		Symbol:  fn.Name,
+	}
+}
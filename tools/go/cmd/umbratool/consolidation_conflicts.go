@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/consolidation"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "consolidation-conflicts",
+		summary: "Report the duplicate files and types that block merging modules, side by side with a suggested resolution",
+		run:     runConsolidationConflicts,
+	})
+}
+
+func runConsolidationConflicts(args []string) error {
+	fs := newFlagSet("consolidation-conflicts")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	sources := fs.String("sources", "", "Comma-separated modules being merged into the target (required)")
+	target := fs.String("target", "", "Module the sources are merged into (required)")
+	scope := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories scanned for Swift files")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown, html or json")
+	maxLines := fs.Int("max-lines", 200, "Most lines shown side by side per pair of copies; 0 shows them all")
+	strict := fs.Bool("strict", false, "Exit non-zero when there is a conflict")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *sources == "" || *target == "" {
+		return errors.New("--sources and --target are required")
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	files, err := imports.ScanTree(projectRoot, scopeList(*scope)...)
+	if err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	opts := consolidation.Options{Sources: splitList(*sources), Target: *target, MaxLines: *maxLines}
+	conflicts, err := consolidation.Find(projectRoot, rules, paths, opts)
+	if err != nil {
+		return err
+	}
+
+	var issues []store.Issue
+	for _, c := range conflicts {
+		for _, p := range c.Pairs {
+			issues = append(issues, store.Issue{Module: p.Right.Module, File: p.Right.File, Line: p.Right.Line, Kind: c.Kind,
+				Message: fmt.Sprintf("%s also in %s (%s): %s", c.Name, c.Left.Module, c.Left.File, p.Resolution), Symbol: c.Name})
+		}
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	i := 0
+	for _, c := range conflicts {
+		for n := range c.Pairs {
+			c.Pairs[n].Fingerprint = issues[i].Fingerprint
+			i++
+		}
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return consolidation.WriteMarkdown(w, conflicts, opts)
+		case "html":
+			return consolidation.WriteHTML(w, conflicts, opts)
+		case "json":
+			return consolidation.WriteJSON(w, conflicts)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("consolidation-conflicts", projectRoot, func(s *metrics.Set) {
+		files, types := 0, 0
+		for _, c := range conflicts {
+			if c.Kind == consolidation.ConflictFile {
+				files++
+			} else {
+				types++
+			}
+		}
+		s.Gauge("consolidation_conflicts", "Conflicts blocking the merge of modules.", float64(files), "kind", consolidation.ConflictFile, "target", *target)
+		s.Gauge("consolidation_conflicts", "Conflicts blocking the merge of modules.", float64(types), "kind", consolidation.ConflictType, "target", *target)
+	}, issues)
+	if err != nil {
+		return err
+	}
+	if *strict && len(conflicts) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/crashlog"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errorreport"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "crashes",
+		summary: "Summarise XPC service crash reports per module and error domain, linked to the error type catalogue",
+		run:     runCrashes,
+	})
+}
+
+func runCrashes(args []string) error {
+	fs := newFlagSet("crashes")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	paths := fs.String("crashes", "", "Comma-separated .ips or .crash files, or directories holding them (required)")
+	processes := fs.String("process", "", "Comma-separated process names to keep, e.g. UmbraCryptoService (default: every process)")
+	dsyms := fs.String("dsyms", "", "Directory of .dSYM bundles to symbolicate unsymbolicated frames with atos")
+	scopes := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories holding the crashing code and its error types")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *paths == "" {
+		return errors.New("--crashes is required")
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	var files []string
+	for _, p := range splitList(*paths) {
+		files = append(files, rootPath(projectRoot, p))
+	}
+	opts := crashlog.Options{Paths: files, Processes: splitList(*processes)}
+	if *dsyms != "" {
+		opts.DSYMs = rootPath(projectRoot, *dsyms)
+	}
+	crashes, skipped, err := crashlog.Load(opts)
+	if err != nil {
+		return err
+	}
+	attributor, err := crashlog.NewAttributor(projectRoot, scopeList(*scopes), rules)
+	if err != nil {
+		return err
+	}
+	catalogue, err := errorreport.Analyse(projectRoot, scopeList(*scopes)...)
+	if err != nil {
+		return err
+	}
+	report := crashlog.Summarise(crashes, attributor, crashlog.NewClassifier(catalogue))
+	report.Skipped = skipped
+
+	var issues []store.Issue
+	var blamed []*crashlog.Crash
+	for _, c := range report.Crashes {
+		frame, ok := c.Blamed()
+		if !ok {
+			continue
+		}
+		message := fmt.Sprintf("%s crashed with %s", c.Process, c.Exception)
+		if c.Domain != "" {
+			message += " on " + c.Domain
+		}
+		issue := store.Issue{Module: c.Module, File: frame.File, Kind: "crash", Message: message, Symbol: frame.Symbol}
+		if frame.File != "" {
+			issue.Line = frame.SourceLine
+		}
+		issues = append(issues, issue)
+		blamed = append(blamed, c)
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for n, c := range blamed {
+		c.Fingerprint = issues[n].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return crashlog.WriteMarkdown(w, report)
+		case "json":
+			return crashlog.WriteJSON(w, report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return export.record("crashes", projectRoot, func(s *metrics.Set) {
+		for _, m := range report.Modules {
+			s.Gauge("module_crashes", "Crashes blamed on each module.", float64(m.Crashes), "module", m.Name)
+		}
+		for _, d := range report.Domains {
+			s.Gauge("error_domain_crashes", "Crashes naming each error domain.", float64(d.Crashes), "domain", d.Name)
+		}
+		s.Gauge("unattributed_crashes", "Crashes with no frame in the tree's modules.", float64(report.Unattributed))
+	}, issues)
+}
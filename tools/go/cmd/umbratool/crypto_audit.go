@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/cryptoaudit"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "crypto-audit",
+		summary: "Report direct keychain and low-level crypto API usage outside the approved security modules",
+		run:     runCryptoAudit,
+	})
+}
+
+func runCryptoAudit(args []string) error {
+	fs := newFlagSet("crypto-audit")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to scan")
+	approved := fs.String("approved", strings.Join(cryptoaudit.DefaultApproved, ","), "Comma-separated modules allowed to use the APIs")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	strict := fs.Bool("strict", false, "Exit non-zero when any usage is outside the approved modules")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	opts := cryptoaudit.Options{Dirs: scopeList(*dirs), Approved: splitList(*approved)}
+	usages, err := cryptoaudit.Scan(projectRoot, opts)
+	if err != nil {
+		return err
+	}
+
+	var issues []store.Issue
+	var unapproved []int
+	for n, u := range usages {
+		if !u.Approved {
+			issues = append(issues, store.Issue{Module: u.Module, File: u.File, Line: u.Line, Kind: u.Category,
+				Message: u.API + " used outside the approved security modules", Symbol: u.API})
+			unapproved = append(unapproved, n)
+		}
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i, n := range unapproved {
+		usages[n].Fingerprint = issues[i].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return cryptoaudit.WriteMarkdown(w, usages, opts.Approved)
+		case "json":
+			return cryptoaudit.WriteJSON(w, usages)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	violations := cryptoaudit.Violations(usages)
+	err = export.record("crypto-audit", projectRoot, func(s *metrics.Set) {
+		for _, u := range usages {
+			s.Add("crypto_api_usages", "Direct keychain and crypto API usages per module by category.", 1,
+				"module", u.Module, "category", u.Category, "approved", strconv.FormatBool(u.Approved))
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if *strict && len(violations) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/deprecation"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "deprecations",
+		summary: "Track uses of deprecated symbols by sunset milestone, failing once a milestone has passed",
+		run:     runDeprecations,
+	})
+}
+
+func runDeprecations(args []string) error {
+	fs := newFlagSet("deprecations")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", workspace.AllScopes, "Comma-separated scopes (sources, tests, testsupport) or directories to scan")
+	version := fs.String("version", "", "Current release, which release milestones are compared against (default: the version in MODULE.bazel)")
+	today := fs.String("today", "", "Date milestones are compared against, as YYYY-MM-DD (default: today)")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	date := reportTime()
+	if *today != "" {
+		if date, err = time.Parse("2006-01-02", *today); err != nil {
+			return fmt.Errorf("--today: %w", err)
+		}
+	}
+	if *version == "" {
+		if *version, err = deprecation.ModuleVersion(projectRoot); err != nil {
+			return err
+		}
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	symbols, err := deprecation.Scan(projectRoot, deprecation.Options{Dirs: scopeList(*dirs), Version: *version, Today: date, Rules: rules})
+	if err != nil {
+		return err
+	}
+
+	var issues []store.Issue
+	for _, s := range symbols {
+		if !s.Past {
+			continue
+		}
+		for _, u := range s.Uses {
+			issues = append(issues, store.Issue{Module: u.Module, File: u.File, Line: u.Line, Kind: "past_sunset",
+				Message: s.Name + " is past its sunset " + s.Sunset, Symbol: s.Name})
+		}
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	i := 0
+	for _, s := range symbols {
+		if !s.Past {
+			continue
+		}
+		for n := range s.Uses {
+			s.Uses[n].Fingerprint = issues[i].Fingerprint
+			i++
+		}
+	}
+
+	day := date.Format("2006-01-02")
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return deprecation.WriteMarkdown(w, symbols, *version, day)
+		case "json":
+			return deprecation.WriteJSON(w, symbols, *version, day)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("deprecations", projectRoot, func(s *metrics.Set) {
+		for _, sym := range symbols {
+			s.Add("deprecated_symbols", "Deprecated symbols per module by sunset milestone.", 1,
+				"module", sym.Module, "sunset", sym.Sunset, "past", strconv.FormatBool(sym.Past))
+			for _, u := range sym.Uses {
+				s.Add("deprecated_uses", "Uses of deprecated symbols per module by sunset milestone.", 1,
+					"module", u.Module, "sunset", sym.Sunset, "past", strconv.FormatBool(sym.Past))
+			}
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if len(issues) > 0 {
+		fmt.Fprintf(os.Stderr, "deprecations: %d uses of symbols past their sunset\n", len(issues))
+		return errCheckFailed
+	}
+	return nil
+}
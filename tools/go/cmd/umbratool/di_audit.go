@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/diaudit"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "di-audit",
+		summary: "Cross-reference service container registrations against resolutions",
+		run:     runDIAudit,
+	})
+}
+
+func runDIAudit(args []string) error {
+	fs := newFlagSet("di-audit")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to scan")
+	receivers := fs.String("receivers", diaudit.DefaultReceivers, "Regular expression the receiver of an audited register or resolve call must match")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	strict := fs.Bool("strict", false, "Exit non-zero when a resolution has no registration or a dependency names an unknown identifier")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	receiverPattern, err := regexp.Compile(*receivers)
+	if err != nil {
+		return fmt.Errorf("--receivers: %w", err)
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	report, err := diaudit.Scan(projectRoot, diaudit.Options{Dirs: scopeList(*dirs), Receivers: receiverPattern})
+	if err != nil {
+		return err
+	}
+
+	issues := make([]store.Issue, 0, len(report.Findings))
+	for _, f := range report.Findings {
+		issues = append(issues, store.Issue{Module: f.Module, File: f.File, Line: f.Line, Kind: f.Kind, Message: f.Message})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for n := range report.Findings {
+		report.Findings[n].Fingerprint = issues[n].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return diaudit.WriteMarkdown(w, report)
+		case "json":
+			return diaudit.WriteJSON(w, report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("di-audit", projectRoot, func(s *metrics.Set) {
+		for _, c := range report.Calls {
+			s.Add("di_calls", "Service container calls per module by kind.", 1, "module", c.Module, "kind", c.Kind)
+		}
+		for _, f := range report.Findings {
+			s.Add("di_findings", "Service registration audit findings per module by kind.", 1, "module", f.Module, "kind", f.Kind)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if *strict && len(report.Failures()) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
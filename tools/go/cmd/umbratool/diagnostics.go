@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// diagnostic is one finding rendered for an IDE or CI annotation.
+type diagnostic struct {
+	// File is relative to the project root.
+	File     string
+	Line     int
+	Severity string // "warning" or "error"
+	Title    string
+	Message  string
+}
+
+// emitFormats lists the --emit values writeDiagnostics understands.
+const emitFormats = "xcode or github"
+
+// writeDiagnostics writes diags in the given format: "xcode" prints
+// "path:line: warning: message" with absolute paths, which Xcode shows
+// inline when a run-script phase prints it; "github" prints workflow
+// commands that GitHub Actions turns into annotations.
+func writeDiagnostics(w io.Writer, format, projectRoot string, diags []diagnostic) error {
+	for _, d := range diags {
+		var err error
+		switch format {
+		case "xcode":
+			_, err = fmt.Fprintf(w, "%s:%d: %s: %s\n", filepath.Join(projectRoot, d.File), d.Line, d.Severity, d.Message)
+		case "github":
+			_, err = fmt.Fprintf(w, "::%s file=%s,line=%d,title=%s::%s\n", d.Severity, escapeProperty(d.File), d.Line, escapeProperty(d.Title), escapeData(d.Message))
+		default:
+			return fmt.Errorf("unknown emit format %q (want %s)", format, emitFormats)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeData escapes a GitHub workflow command message.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a GitHub workflow command property value.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
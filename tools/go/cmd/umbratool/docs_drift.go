@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/deprecation"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/docdrift"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/importrewrite"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moves"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "docs-drift",
+		summary: "Report references in the Markdown docs to modules, directories and types that were renamed, moved or removed",
+		run:     runDocsDrift,
+	})
+}
+
+func runDocsDrift(args []string) error {
+	fs := newFlagSet("docs-drift")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	docs := fs.String("docs", "docs", "Comma-separated directories of Markdown docs to check")
+	scope := fs.String("scope", workspace.AllScopes, "Comma-separated scopes (sources, tests, testsupport) or directories whose declarations the docs are checked against")
+	maps := fs.String("map", "", "Comma-separated rewrite-imports mapping files recording renamed modules")
+	ignore := fs.String("ignore", "", "Comma-separated names not to check, such as third-party types")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	strict := fs.Bool("strict", false, "Exit non-zero when any reference has drifted")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	opts := docdrift.Options{Docs: splitList(*docs), Dirs: scopeList(*scope), Rules: rules, Ignore: splitList(*ignore)}
+	for _, file := range splitList(*maps) {
+		c, err := importrewrite.LoadConfig(rootPath(projectRoot, file))
+		if err != nil {
+			return err
+		}
+		opts.Rewrites = append(opts.Rewrites, c.Rewrites...)
+	}
+	if opts.Deprecated, err = deprecation.Scan(projectRoot, deprecation.Options{Dirs: opts.Dirs, Today: reportTime(), Rules: rules}); err != nil {
+		return err
+	}
+	if opts.Moves, err = moves.Load(projectRoot); err != nil {
+		return err
+	}
+	report, err := docdrift.Check(projectRoot, opts)
+	if err != nil {
+		return err
+	}
+
+	issues := make([]store.Issue, 0, len(report.Drift))
+	for _, d := range report.Drift {
+		message := fmt.Sprintf("%s %s %s: %s", d.Kind, d.Ref, d.Drift, d.Reason)
+		issues = append(issues, store.Issue{Module: workspace.ModuleForPath(d.Doc), File: d.Doc, Line: d.Line, Kind: "docs_" + d.Drift, Message: message, Symbol: d.Ref})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for n := range report.Drift {
+		report.Drift[n].Fingerprint = issues[n].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return docdrift.WriteMarkdown(w, report)
+		case "json":
+			return docdrift.WriteJSON(w, report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("docs-drift", projectRoot, func(s *metrics.Set) {
+		s.Gauge("docs_references", "References to the tree's code in the Markdown docs.", float64(report.References))
+		for _, d := range report.Drift {
+			s.Add("docs_drift", "Doc references to renamed, moved, deprecated or removed code by kind.", 1, "drift", d.Drift)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+	if *strict && len(report.Drift) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/clones"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "duplicate-code",
+		summary: "Find duplicated and near-duplicated code blocks across modules, with module pairs and similarity scores",
+		run:     runDuplicateCode,
+	})
+}
+
+func runDuplicateCode(args []string) error {
+	fs := newFlagSet("duplicate-code")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to compare")
+	minTokens := fs.Int("min-tokens", clones.DefaultMinTokens, "Smallest block compared, in tokens")
+	similarity := fs.Float64("similarity", clones.DefaultSimilarity, "Smallest similarity reported, from 0 to 1")
+	sameModule := fs.Bool("same-module", false, "Also report clones between files of the same module")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	strict := fs.Bool("strict", false, "Exit non-zero when any clone is found")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *minTokens < clones.ShingleSize {
+		return fmt.Errorf("--min-tokens must be at least %d", clones.ShingleSize)
+	}
+	if *similarity <= 0 || *similarity > 1 {
+		return errors.New("--similarity must be above 0 and at most 1")
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	report, err := clones.Find(projectRoot, clones.Options{Dirs: scopeList(*dirs), Rules: rules, MinTokens: *minTokens, Similarity: *similarity, SameModule: *sameModule})
+	if err != nil {
+		return err
+	}
+
+	issues := make([]store.Issue, 0, len(report.Clones))
+	for _, c := range report.Clones {
+		message := fmt.Sprintf("%s copy of %s:%d-%d in %s, %.0f%% similar", c.Kind, c.B.File, c.B.Line, c.B.EndLine, c.B.Module, c.Similarity*100)
+		issues = append(issues, store.Issue{Module: c.A.Module, File: c.A.File, Line: c.A.Line, Kind: "duplicate_block", Message: message})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for n := range report.Clones {
+		report.Clones[n].Fingerprint = issues[n].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return clones.WriteMarkdown(w, report)
+		case "json":
+			return clones.WriteJSON(w, report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("duplicate-code", projectRoot, func(s *metrics.Set) {
+		s.Gauge("code_blocks", "Code blocks compared for clones.", float64(report.Blocks))
+		for _, p := range report.Pairs {
+			s.Gauge("duplicate_blocks", "Clones between two modules.", float64(p.Clones), "module", p.A, "other", p.B)
+			s.Gauge("duplicate_tokens", "Tokens of duplicated code between two modules.", float64(p.Tokens), "module", p.A, "other", p.B)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+	if *strict && len(report.Clones) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/entitlements"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "entitlements",
+		summary: "Validate .entitlements files against the entitlements policy",
+		run:     runEntitlements,
+	})
+}
+
+func runEntitlements(args []string) error {
+	fs := newFlagSet("entitlements")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	policyPath := fs.String("policy", entitlements.PolicyFile, "Policy file, relative to the project root")
+	format := fs.String("format", "text", "Output format: text or json")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	strict := fs.Bool("strict", false, "Exit non-zero on warnings as well as errors")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	policy, err := entitlements.LoadPolicy(rootPath(projectRoot, *policyPath))
+	if err != nil {
+		return err
+	}
+	report, err := entitlements.Check(projectRoot, policy)
+	if err != nil {
+		return err
+	}
+
+	errs, warnings := 0, 0
+	for _, i := range report.Issues {
+		if i.Severity == "error" {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+	stored := make([]store.Issue, 0, len(report.Issues))
+	for _, i := range report.Issues {
+		stored = append(stored, store.Issue{Module: i.Module, File: i.File, Kind: i.Kind, Message: i.Message})
+	}
+	if err := annotateIssues(projectRoot, stored); err != nil {
+		return err
+	}
+	for n := range report.Issues {
+		report.Issues[n].Fingerprint = stored[n].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "text":
+			for _, f := range report.Files {
+				kind := f.Type
+				if kind == "" {
+					kind = "unclassified"
+				}
+				fmt.Fprintf(w, "%s (%s): %s\n", f.Path, kind, strings.Join(f.Entitlements, ", "))
+			}
+			for _, i := range report.Issues {
+				fmt.Fprintf(w, "%s: %s: %s\n", i.File, i.Severity, i.Message)
+			}
+			_, err := fmt.Fprintf(w, "%d entitlements files: %d errors, %d warnings\n", len(report.Files), errs, warnings)
+			return err
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("entitlements", projectRoot, func(s *metrics.Set) {
+		for _, i := range report.Issues {
+			s.Add("entitlement_issues", "Entitlements policy violations per module by kind.", 1, "module", i.Module, "kind", i.Kind)
+		}
+	}, stored)
+	if err != nil {
+		return err
+	}
+
+	if errs > 0 || (*strict && warnings > 0) {
+		return errCheckFailed
+	}
+	return nil
+}
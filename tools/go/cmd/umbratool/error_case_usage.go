@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errorreport"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errorusage"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "error-case-usage",
+		summary: "Count where each error enum case is constructed and matched, and flag unused cases in the migration plan",
+		run:     runErrorCaseUsage,
+	})
+}
+
+func runErrorCaseUsage(args []string) error {
+	fs := newFlagSet("error-case-usage")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	scopes := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories holding the error enums")
+	dirs := fs.String("search", "Sources,Tests", "Comma-separated top-level directories searched for uses of the cases")
+	planPath := fs.String("plan", "", "Record the unused cases in this error_migrator migration config, e.g. tools/error_migrator/migration_config.json")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	analysis, err := errorreport.Analyse(projectRoot, scopeList(*scopes)...)
+	if err != nil {
+		return err
+	}
+	report, err := errorusage.Count(projectRoot, analysis, splitList(*dirs))
+	if err != nil {
+		return err
+	}
+
+	var issues []store.Issue
+	for _, e := range report.Enums {
+		for _, c := range e.Cases {
+			if c.Unused {
+				issues = append(issues, store.Issue{Module: e.Module, File: e.File, Line: e.Line, Kind: "unused-error-case", Message: e.Name + "." + c.Name + " is never constructed or matched", Symbol: e.Name + "." + c.Name})
+			}
+		}
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	i := 0
+	for _, e := range report.Enums {
+		for n, c := range e.Cases {
+			if c.Unused {
+				e.Cases[n].Fingerprint = issues[i].Fingerprint
+				i++
+			}
+		}
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return errorusage.WriteMarkdown(w, report)
+		case "json":
+			return errorusage.WriteJSON(w, report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if *planPath != "" {
+		file := rootPath(projectRoot, *planPath)
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		annotated, err := report.Annotate(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *planPath, err)
+		}
+		run := beginAudit(projectRoot, "error-case-usage")
+		if err := atomicfile.WriteFile(file, annotated, 0o644); err != nil {
+			return err
+		}
+		if err := run.done([]string{file}); err != nil {
+			return err
+		}
+	}
+
+	return export.record("error-case-usage", projectRoot, func(s *metrics.Set) {
+		for _, e := range report.Enums {
+			for _, c := range e.Cases {
+				s.Add("error_cases", "Cases of error enums per module.", 1, "module", e.Module)
+				if c.Unused {
+					s.Add("unused_error_cases", "Error enum cases never constructed or matched, per module.", 1, "module", e.Module)
+				}
+			}
+		}
+	}, issues)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errormapper"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errorreport"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "error-mapper-check",
+		summary: "Check that the central error mapper covers every case of the error enums it maps",
+		run:     runErrorMapperCheck,
+	})
+}
+
+func runErrorMapperCheck(args []string) error {
+	fs := newFlagSet("error-mapper-check")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	mappers := fs.String("mapper", "Sources/ErrorHandling/Mapping/SecurityErrorMapper.swift", "Comma-separated mapper files to check")
+	enums := fs.String("enums", "SecurityError", "Comma-separated error enum names whose variants the mappers must cover")
+	scope := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories searched for the enums")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	emit := fs.String("emit", "", "Print diagnostics instead of the report: "+emitFormats)
+	strict := fs.Bool("strict", false, "Exit non-zero when cases are unmapped or stale (emitted as errors)")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+
+	coverage, issues, err := checkErrorMapper(projectRoot, splitList(*mappers), splitList(*enums), scopeList(*scope))
+	if err != nil {
+		return err
+	}
+
+	stored := make([]store.Issue, 0, len(issues))
+	for _, i := range issues {
+		stored = append(stored, store.Issue{Module: workspace.ModuleForPath(i.File), File: i.File, Line: i.Line, Kind: i.Kind, Message: i.Message})
+	}
+	if err := annotateIssues(projectRoot, stored); err != nil {
+		return err
+	}
+	for n := range issues {
+		issues[n].Fingerprint = stored[n].Fingerprint
+	}
+
+	if *emit != "" {
+		err = writeOutput(*output, func(w io.Writer) error {
+			severity := "warning"
+			if *strict {
+				severity = "error"
+			}
+			diags := make([]diagnostic, 0, len(issues))
+			for _, i := range issues {
+				diags = append(diags, diagnostic{File: i.File, Line: i.Line, Severity: severity, Title: "error-mapper-check: " + i.Kind, Message: i.Message})
+			}
+			return writeDiagnostics(w, *emit, projectRoot, diags)
+		})
+	} else {
+		err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+			switch *format {
+			case "markdown":
+				return errormapper.WriteMarkdown(w, coverage)
+			case "json":
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				return enc.Encode(struct {
+					Coverage []errormapper.Coverage `json:"coverage"`
+					Issues   []errormapper.Issue    `json:"issues"`
+				}{coverage, issues})
+			default:
+				return fmt.Errorf("unknown format %q", *format)
+			}
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	err = export.record("error-mapper-check", projectRoot, func(s *metrics.Set) {
+		for _, c := range coverage {
+			s.Gauge("error_mapper_unmapped_cases", "Enum cases a mapper switch does not name.", float64(len(c.Unmapped)), "function", c.Switch.Function, "enum", c.Enum.Name)
+			s.Gauge("error_mapper_stale_cases", "Cases a mapper switch names that the enum no longer declares.", float64(len(c.Stale)), "function", c.Switch.Function, "enum", c.Enum.Name)
+		}
+	}, stored)
+	if err != nil {
+		return err
+	}
+
+	if *strict && len(issues) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// checkErrorMapper checks the switches of the mapper files against the
+// enums found below the scope directories.
+func checkErrorMapper(projectRoot string, mappers, enums, scope []string) ([]errormapper.Coverage, []errormapper.Issue, error) {
+	report, err := errorreport.Analyse(projectRoot, scope...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return checkMappers(projectRoot, report, mappers, enums)
+}
+
+// checkMappers checks the switches of the mapper files against the enums
+// of an error report already made.
+func checkMappers(projectRoot string, report *errorreport.Report, mappers, enums []string) ([]errormapper.Coverage, []errormapper.Issue, error) {
+	variants := errormapper.Enums(report, enums)
+	if len(variants) == 0 {
+		return nil, nil, fmt.Errorf("no enum named %s found", strings.Join(enums, ","))
+	}
+
+	var switches []errormapper.Switch
+	for _, rel := range mappers {
+		found, err := errormapper.ParseSwitches(projectRoot, rel)
+		if err != nil {
+			return nil, nil, err
+		}
+		switches = append(switches, found...)
+	}
+	coverage, issues := errormapper.Check(switches, variants)
+	return coverage, issues, nil
+}
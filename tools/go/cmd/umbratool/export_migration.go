@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/deprecation"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/importrewrite"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/migration"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "export-migration",
+		summary: "Package import rewrites, type renames and deprecations as a script downstream codebases run",
+		run:     runExportMigration,
+	})
+}
+
+func runExportMigration(args []string) error {
+	fs := newFlagSet("export-migration")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	maps := fs.String("map", "", "Comma-separated rewrite-imports mapping files, applied in order")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories scanned for deprecations")
+	version := fs.String("version", "", "UmbraCore version migrated to (default: the version in MODULE.bazel)")
+	output := fs.String("output", "", "Output file (default: stdout)")
+	format := fs.String("format", "script", "Output format: script (Python 3), json or markdown")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	if *version == "" {
+		if *version, err = deprecation.ModuleVersion(projectRoot); err != nil {
+			return err
+		}
+	}
+	var configs []*importrewrite.Config
+	for _, file := range splitList(*maps) {
+		c, err := importrewrite.LoadConfig(rootPath(projectRoot, file))
+		if err != nil {
+			return err
+		}
+		configs = append(configs, c)
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	symbols, err := deprecation.Scan(projectRoot, deprecation.Options{Dirs: scopeList(*dirs), Version: *version, Today: reportTime(), Rules: rules})
+	if err != nil {
+		return err
+	}
+	manifest, err := migration.Build(projectRoot, *version, configs, symbols, rules)
+	if err != nil {
+		return err
+	}
+	if manifest.Empty() {
+		return errors.New("nothing to migrate: pass --map or deprecate symbols first")
+	}
+
+	return writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "script":
+			return migration.WriteScript(w, manifest)
+		case "json":
+			return migration.WriteJSON(w, manifest)
+		case "markdown":
+			return migration.WriteMarkdown(w, manifest)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+}
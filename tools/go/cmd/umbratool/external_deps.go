@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/extdeps"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "external-deps",
+		summary: "Audit the external repos of MODULE.bazel and WORKSPACE for unused deps, undeclared repos and version skew",
+		run:     runExternalDeps,
+	})
+}
+
+func runExternalDeps(args []string) error {
+	fs := newFlagSet("external-deps")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	graph := fs.String("graph", "", "Read resolved module versions from this output of bazel mod graph --output=json (default: MODULE.bazel.lock, when present)")
+	resolve := fs.Bool("resolve", false, "Run bazel mod graph for the resolved module versions")
+	allow := fs.String("allow", "", "Comma-separated repositories accepted as unused, e.g. toolchain modules nothing names")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	strict := fs.Bool("strict", false, "Exit non-zero when a repository is unused or undeclared, or a version is skewed")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *graph != "" && *resolve {
+		return fmt.Errorf("--graph and --resolve are mutually exclusive")
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	opts := extdeps.Options{Allow: splitList(*allow)}
+	switch {
+	case *graph != "":
+		data, err := os.ReadFile(rootPath(projectRoot, *graph))
+		if err != nil {
+			return err
+		}
+		if opts.Resolved, err = extdeps.ParseGraph(data); err != nil {
+			return fmt.Errorf("%s: %w", *graph, err)
+		}
+		opts.Resolution = *graph
+	case *resolve:
+		out, err := bazelRunner(projectRoot).Run(runContext, "mod", "graph", "--output=json")
+		if err != nil {
+			return err
+		}
+		if opts.Resolved, err = extdeps.ParseGraph(out); err != nil {
+			return fmt.Errorf("bazel mod graph: %w", err)
+		}
+		opts.Resolution = "bazel mod graph"
+	default:
+		if opts.Resolved, err = extdeps.LoadLockfile(projectRoot); err != nil {
+			return err
+		}
+		if opts.Resolved != nil {
+			opts.Resolution = "MODULE.bazel.lock"
+		}
+	}
+	report, err := extdeps.Analyse(projectRoot, opts)
+	if err != nil {
+		return err
+	}
+
+	var issues []store.Issue
+	var unusedAt []int
+	for n, r := range report.Repos {
+		if r.Unused() {
+			issues = append(issues, store.Issue{File: r.File, Line: r.Line, Kind: extdeps.IssueUnused, Message: fmt.Sprintf("@%s (%s) is declared but no label names it", r.Name, r.Kind), Symbol: r.Name})
+			unusedAt = append(unusedAt, n)
+		}
+	}
+	for _, ref := range report.Undeclared {
+		issues = append(issues, store.Issue{Module: workspace.ModuleForPath(ref.File), File: ref.File, Line: ref.Line, Kind: extdeps.IssueUndeclared, Message: fmt.Sprintf("@%s is named but neither MODULE.bazel nor WORKSPACE declares it", ref.Repo)})
+	}
+	for _, s := range report.Skew {
+		issues = append(issues, store.Issue{File: s.File, Line: s.Line, Kind: extdeps.IssueSkew, Message: s.Message()})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i, n := range unusedAt {
+		report.Repos[n].Fingerprint = issues[i].Fingerprint
+	}
+	for i := range report.Undeclared {
+		report.Undeclared[i].Fingerprint = issues[len(unusedAt)+i].Fingerprint
+	}
+	for i := range report.Skew {
+		report.Skew[i].Fingerprint = issues[len(unusedAt)+len(report.Undeclared)+i].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return extdeps.WriteMarkdown(w, report)
+		case "json":
+			return extdeps.WriteJSON(w, report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	unused := report.Unused()
+	err = export.record("external-deps", projectRoot, func(s *metrics.Set) {
+		s.Gauge("external_repos", "External repositories declared in MODULE.bazel and WORKSPACE.", float64(len(report.Repos)))
+		s.Gauge("unused_external_repos", "Declared external repositories no label names.", float64(len(unused)))
+		s.Gauge("undeclared_external_repos", "Labels naming external repositories nothing declares.", float64(len(report.Undeclared)))
+		s.Gauge("external_version_skew", "Declared external versions other than the resolved ones.", float64(len(report.Skew)))
+	}, issues)
+	if err != nil {
+		return err
+	}
+	if *strict && len(issues) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
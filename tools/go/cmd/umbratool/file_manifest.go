@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/header"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/jsonl"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "file-manifest",
+		summary: "List each module's Swift files with the creation date and description of their headers",
+		run:     runFileManifest,
+	})
+}
+
+func runFileManifest(args []string) error {
+	fs := newFlagSet("file-manifest")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to scan")
+	exclude := fs.String("exclude", "", "Additional comma-separated path globs to exclude")
+	strict := fs.Bool("strict", false, "Fail when a header names another module or file")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown, json or jsonl")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	checker := header.NewChecker()
+	checker.Excludes = append(checker.Excludes, splitList(*exclude)...)
+	annotations, err := newAnnotator(projectRoot)
+	if err != nil {
+		return err
+	}
+	var manifest []header.ModuleManifest
+	if *format == "jsonl" {
+		err = writeJSONL(fs, projectRoot, *output, func(w *jsonl.Writer) (any, error) {
+			files, blocking := 0, 0
+			var err error
+			manifest, err = checker.ManifestEach(projectRoot, scopeList(*dirs), rules, func(m header.Metadata) error {
+				files++
+				issues := headerIssues(m)
+				for i := range issues {
+					annotations.annotate(&issues[i])
+				}
+				fingerprintHeader(&m, issues)
+				if err := w.Write("file", m); err != nil {
+					return err
+				}
+				for _, issue := range issues {
+					record := issueRecord{Issue: issue, Blocking: annotations.blocking(issue, *strict)}
+					if record.Blocking {
+						blocking++
+					}
+					if err := w.Write("issue", record); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range manifest {
+				record := struct {
+					Module    string `json:"module"`
+					Files     int    `json:"files"`
+					Headers   int    `json:"headers"`
+					Described int    `json:"described"`
+				}{m.Module, len(m.Files), m.Headers, m.Described}
+				if err := w.Write("module", record); err != nil {
+					return nil, err
+				}
+			}
+			return struct {
+				Modules  int `json:"modules"`
+				Files    int `json:"files"`
+				Blocking int `json:"blocking"`
+			}{len(manifest), files, blocking}, nil
+		})
+	} else {
+		if manifest, err = checker.Manifest(projectRoot, scopeList(*dirs), rules); err != nil {
+			return err
+		}
+		for _, m := range manifest {
+			for i := range m.Files {
+				issues := headerIssues(m.Files[i])
+				for j := range issues {
+					annotations.annotate(&issues[j])
+				}
+				fingerprintHeader(&m.Files[i], issues)
+			}
+		}
+		err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+			switch *format {
+			case "markdown":
+				return header.WriteManifestMarkdown(w, manifest)
+			case "json":
+				return header.WriteManifestJSON(w, manifest)
+			default:
+				return fmt.Errorf("unknown format %q", *format)
+			}
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	var issues []store.Issue
+	for _, m := range manifest {
+		for _, f := range m.Files {
+			issues = append(issues, headerIssues(f)...)
+		}
+	}
+	err = export.record("file-manifest", projectRoot, func(s *metrics.Set) {
+		for _, m := range manifest {
+			s.Gauge("manifest_files", "Swift files per module.", float64(len(m.Files)), "module", m.Module)
+			s.Gauge("manifest_described_files", "Swift files per module whose header describes them.", float64(m.Described), "module", m.Module)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if *strict && len(issues) > 0 {
+		fmt.Fprintf(os.Stderr, "file-manifest: %d headers name another module or file\n", len(issues))
+		return errCheckFailed
+	}
+	return nil
+}
+
+// headerIssues reports the module and file names f's header gets wrong.
+func headerIssues(f header.Metadata) []store.Issue {
+	var issues []store.Issue
+	if f.ModuleMismatch {
+		issues = append(issues, store.Issue{Module: f.Module, File: f.File, Line: 1, Kind: "header_module_mismatch",
+			Message: "header names module " + f.HeaderModule})
+	}
+	if f.FileMismatch {
+		issues = append(issues, store.Issue{Module: f.Module, File: f.File, Line: 1, Kind: "header_file_mismatch",
+			Message: "header names file " + f.HeaderFile})
+	}
+	return issues
+}
+
+// fingerprintHeader copies the fingerprints of issues, as headerIssues
+// made them for f, onto f.
+func fingerprintHeader(f *header.Metadata, issues []store.Issue) {
+	for _, issue := range issues {
+		switch issue.Kind {
+		case "header_module_mismatch":
+			f.ModuleFingerprint = issue.Fingerprint
+		case "header_file_mismatch":
+			f.FileFingerprint = issue.Fingerprint
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/bazel"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+func init() {
+	if n, err := strconv.Atoi(os.Getenv("UMBRATOOL_JOBS")); err == nil {
+		pool.SetJobs(n)
+	}
+	// run passes its Bazel limits on to its steps, which share them.
+	if n, err := strconv.Atoi(os.Getenv("UMBRATOOL_BAZEL_JOBS")); err == nil {
+		bazel.DefaultJobs = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("UMBRATOOL_BAZEL_INTERVAL")); err == nil {
+		bazel.DefaultInterval = d
+	}
+}
+
+var (
+	bazelOnce   sync.Once
+	bazelShared *bazel.Runner
+)
+
+// bazelRunner returns the process-wide Bazel runner, built on first use
+// from --bazel-jobs and --bazel-interval, so that every Bazel process a
+// command starts counts against the one limit. Under run it also shares
+// the limit with the other steps, through $UMBRATOOL_BAZEL_SHARED.
+func bazelRunner(root string) *bazel.Runner {
+	bazelOnce.Do(func() {
+		bazelShared = bazel.NewRunnerWithLimits(root, bazel.DefaultJobs, bazel.DefaultInterval)
+		bazelShared.Shared = os.Getenv("UMBRATOOL_BAZEL_SHARED")
+	})
+	return bazelShared
+}
+
+// newFlagSet returns a flag set for the named command with the flags every
+// command shares already registered. The shared flags may also be given
+// before the command name.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Var(jobsValue{}, "jobs", "Maximum concurrent workers (default: number of CPUs, or $UMBRATOOL_JOBS)")
+	fs.IntVar(&bazel.DefaultJobs, "bazel-jobs", bazel.DefaultJobs, "Maximum concurrent Bazel processes")
+	fs.DurationVar(&bazel.DefaultInterval, "bazel-interval", bazel.DefaultInterval, "Minimum delay between Bazel process launches")
+	fs.BoolVar(&walker.FollowSymlinks, "follow-symlinks", walker.FollowSymlinks, "Descend into symlinked directories when scanning the tree")
+	fs.StringVar(&outDir, "out-dir", outDir, "Directory relative report, metrics and result store paths are written below (default: $UMBRATOOL_OUT_DIR, $TEST_UNDECLARED_OUTPUTS_DIR under bazel test, or the working directory)")
+	fs.Var(cpuProfileValue{}, "cpuprofile", "Write a CPU profile of the command to this file, for go tool pprof")
+	fs.StringVar(&memProfile, "memprofile", memProfile, "Write a memory profile to this file when the command finishes, for go tool pprof")
+	return fs
+}
+
+// jobsValue adapts the process-wide worker limit to flag.Value.
+type jobsValue struct{}
+
+func (jobsValue) String() string {
+	return strconv.Itoa(pool.Jobs())
+}
+
+func (jobsValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	pool.SetJobs(n)
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "fmt-build",
+		summary: "Normalise BUILD files: buildifier formatting and de-duplicated deps",
+		run:     runFmtBuild,
+	})
+}
+
+func runFmtBuild(args []string) error {
+	fs := newFlagSet("fmt-build")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	check := fs.Bool("check", false, "List files that need formatting instead of rewriting them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		if files, err = buildfile.FindAll(projectRoot); err != nil {
+			return err
+		}
+	}
+
+	run := beginAudit(projectRoot, "fmt-build")
+	var formatted []string
+	var changed, failed int
+	for _, rel := range files {
+		rel = filepath.ToSlash(rel)
+		f, err := buildfile.Load(rootPath(projectRoot, rel), buildfile.PackageOf(rel))
+		if err != nil {
+			fmt.Printf("skipping %s: %v\n", rel, err)
+			failed++
+			continue
+		}
+
+		f.Normalise()
+		if !f.Changed() {
+			continue
+		}
+		changed++
+
+		if *check {
+			fmt.Printf("%s: needs formatting\n", rel)
+			continue
+		}
+		if err := runContext.Err(); err != nil {
+			return run.failed(formatted, fmt.Errorf("%w before formatting %s", err, rel))
+		}
+		if err := f.Save(); err != nil {
+			return run.failed(formatted, err)
+		}
+		formatted = append(formatted, f.Path)
+		fmt.Printf("formatted %s\n", rel)
+	}
+
+	verb := "formatted"
+	if *check {
+		verb = "need formatting"
+	}
+	fmt.Printf("%d of %d BUILD files %s", changed, len(files), verb)
+	if failed > 0 {
+		fmt.Printf(" (%d could not be parsed)", failed)
+	}
+	fmt.Println()
+
+	if err := run.done(formatted); err != nil {
+		return err
+	}
+	if *check && changed > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errorreport"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "generate-error-report",
+		summary: "Write the Error Analysis Report consumed by error_migrator",
+		run:     runGenerateErrorReport,
+	})
+}
+
+func runGenerateErrorReport(args []string) error {
+	fs := newFlagSet("generate-error-report")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	scopes := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories holding modules")
+	output := fs.String("output", "", "Report file, e.g. error_analysis_report.md (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+
+	report, err := errorreport.Analyse(projectRoot, scopeList(*scopes)...)
+	if err != nil {
+		return err
+	}
+	report.Generated = reportTime()
+
+	var issues []store.Issue
+	for _, d := range report.Duplicates {
+		for _, m := range d.Modules {
+			issues = append(issues, store.Issue{Module: m, Kind: "duplicated-error-type", Message: d.Name + " is also defined in other modules", Symbol: d.Name})
+		}
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	rest := issues
+	for i, d := range report.Duplicates {
+		for _, issue := range rest[:len(d.Modules)] {
+			report.Duplicates[i].Fingerprints = append(report.Duplicates[i].Fingerprints, issue.Fingerprint)
+		}
+		rest = rest[len(d.Modules):]
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return errorreport.WriteMarkdown(w, report)
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return export.record("generate-error-report", projectRoot, func(s *metrics.Set) {
+		for _, m := range report.Modules {
+			s.Gauge("error_definitions", "Error types defined per module.", float64(m.ErrorDefinitions), "module", m.Name)
+		}
+		for _, def := range report.Definitions {
+			s.Add("error_references", "Files referencing each module's error types.", float64(len(def.ReferencedFiles)), "module", def.Module)
+		}
+		s.Gauge("duplicated_error_types", "Error type names defined in more than one module.", float64(len(report.Duplicates)))
+	}, issues)
+}
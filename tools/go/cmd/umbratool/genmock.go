@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/mockgen"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/protocols"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testhelpers"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "genmock",
+		summary: "Generate a call-recording mock of a Swift protocol into its module's test support",
+		run:     runGenmock,
+	})
+}
+
+func runGenmock(args []string) error {
+	fs := newFlagSet("genmock")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories searched for the protocol")
+	module := fs.String("module", "", "Module of the protocol, when several declare one of the name")
+	name := fs.String("name", "", "Mock class name (default: Mock and the protocol's name, less a Protocol suffix)")
+	aliases := fs.String("typealias", "", "Comma-separated Name=Type bindings of the protocol's associated types")
+	dest := fs.String("dest", testhelpers.DefaultDest, "Test-support directory; the mock goes in the protocol module's directory under it")
+	force := fs.Bool("force", false, "Overwrite a mock edited by hand")
+	dryRun := fs.Bool("dry-run", false, "Print the mock instead of writing it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: umbratool genmock [flags] <Protocol>")
+	}
+	opts := mockgen.Options{Name: *name, TypeAliases: map[string]string{}}
+	for _, binding := range splitList(*aliases) {
+		alias, typ, ok := strings.Cut(binding, "=")
+		if !ok || strings.TrimSpace(alias) == "" || strings.TrimSpace(typ) == "" {
+			return fmt.Errorf("--typealias: want Name=Type, got %q", binding)
+		}
+		opts.TypeAliases[strings.TrimSpace(alias)] = strings.TrimSpace(typ)
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	ix, err := protocols.Build(projectRoot, scopeList(*dirs)...)
+	if err != nil {
+		return err
+	}
+	decl, err := mockgen.Resolve(ix, fs.Arg(0), *module)
+	if err != nil {
+		return err
+	}
+	mock, err := mockgen.Generate(projectRoot, ix, decl, opts)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		fmt.Print(mock.Source)
+		return nil
+	}
+
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	run := beginAudit(projectRoot, "genmock")
+	placed, err := mockgen.Write(projectRoot, path.Join(*dest, decl.Module), mock, rules, *force)
+	if err != nil {
+		var written []string
+		if placed != nil {
+			written = []string{placed.File}
+		}
+		return run.failed(written, err)
+	}
+	if err := run.done([]string{placed.File, packageBuild(projectRoot, placed.File)}); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s (target %s)\n", placed.File, placed.Target)
+	if placed.Created {
+		fmt.Printf("created %s\n", path.Join(path.Dir(placed.File), "BUILD.bazel"))
+	}
+	for _, dep := range placed.Deps {
+		fmt.Printf("added dep %s\n", dep)
+	}
+	for _, s := range mock.Skipped {
+		fmt.Printf("skipped %s; implement it by hand\n", s)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/godeps"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "go-deps",
+		summary: "Report which Go tools use which third-party modules and flag unused go.mod requirements",
+		run:     runGoDeps,
+	})
+}
+
+func runGoDeps(args []string) error {
+	fs := newFlagSet("go-deps")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown, dot or json")
+	strict := fs.Bool("strict", false, "Exit non-zero when a go.mod file disagrees with the imports")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	report, err := godeps.Analyse(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	issues := make([]store.Issue, 0, len(report.Issues))
+	for _, i := range report.Issues {
+		issues = append(issues, store.Issue{Module: workspace.ModuleForPath(i.File), File: i.File, Line: i.Line, Kind: i.Kind, Message: i.Message})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i := range report.Issues {
+		report.Issues[i].Fingerprint = issues[i].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return godeps.WriteMarkdown(w, report)
+		case "dot":
+			return godeps.WriteDot(w, report)
+		case "json":
+			return godeps.WriteJSON(w, report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("go-deps", projectRoot, func(s *metrics.Set) {
+		for _, m := range report.Modules {
+			s.Gauge("go_module_requirements", "Requirements per go.mod file.", float64(len(m.ModFile.Requires)), "item", m.ModFile.Module)
+			for _, t := range m.Tools {
+				s.Gauge("go_tool_dependencies", "Third-party modules each Go tool uses.", float64(len(t.Modules)), "item", t.Package)
+			}
+		}
+		for _, i := range report.Issues {
+			s.Add("go_module_issues", "go.mod requirements that disagree with the imports, by kind.", 1, "item", i.File, "kind", i.Kind)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if *strict && len(report.Issues) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
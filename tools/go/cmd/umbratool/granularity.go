@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/complexity"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/granularity"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "granularity",
+		summary: "Suggest merging tiny targets into their only dependent and splitting large ones by subdirectory",
+		run:     runGranularity,
+	})
+}
+
+func runGranularity(args []string) error {
+	fs := newFlagSet("granularity")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to measure")
+	profile := fs.String("profile", "", "Bazel build profile (--profile output, gzipped or not) to estimate times from")
+	small := fs.Int("small", 200, "Most lines of code of a target suggested for merging")
+	large := fs.Int("large", 5000, "Fewest lines of code of a target suggested for splitting")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	counts, err := complexity.Count(projectRoot, scopeList(*dirs)...)
+	if err != nil {
+		return err
+	}
+	opts := granularity.Options{Rules: rules, Small: *small, Large: *large}
+	if *profile != "" {
+		if opts.Times, err = granularity.LoadProfile(rootPath(projectRoot, *profile)); err != nil {
+			return err
+		}
+	}
+	advice := granularity.Advise(counts, opts)
+
+	var issues []store.Issue
+	var saving time.Duration
+	for _, m := range advice.Merges {
+		issues = append(issues, store.Issue{Module: m.Module, File: m.File, Kind: "merge-candidate", Message: m.Label + " into " + m.Into, Symbol: m.Label})
+		saving += m.Saving
+	}
+	for _, s := range advice.Splits {
+		issues = append(issues, store.Issue{Module: s.Module, File: s.File, Kind: "split-candidate", Message: fmt.Sprintf("%s into %d parts", s.Label, len(s.Parts)), Symbol: s.Label})
+		saving += s.Saving
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i := range advice.Merges {
+		advice.Merges[i].Fingerprint = issues[i].Fingerprint
+	}
+	for i := range advice.Splits {
+		advice.Splits[i].Fingerprint = issues[len(advice.Merges)+i].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return granularity.WriteMarkdown(w, advice, opts)
+		case "json":
+			return granularity.WriteJSON(w, advice)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return export.record("granularity", projectRoot, func(s *metrics.Set) {
+		s.Gauge("granularity_merge_candidates", "Tiny targets with a single dependent.", float64(len(advice.Merges)))
+		s.Gauge("granularity_split_candidates", "Large targets that split along their subdirectories.", float64(len(advice.Splits)))
+		if advice.Profiled {
+			s.Gauge("granularity_estimated_saving_seconds", "Action time the suggested merges and splits would save, estimated from the build profile.", saving.Seconds())
+		}
+	}, issues)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildtimes"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/health"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "health",
+		summary: "Grade every module A–F from complexity, tests, dependencies, dead code and migration",
+		run:     runHealth,
+	})
+}
+
+func runHealth(args []string) error {
+	fs := newFlagSet("health")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	weightSpec := fs.String("weights", "", "Comma-separated factor=weight overrides, e.g. complexity=2,deadcode=0")
+	planPath := fs.String("plan", "refactoring_plan.yaml", "Refactoring plan for the migration factor, relative to the project root (optional)")
+	results := fs.String("results", "results.db", "Result store to read the latest unused-targets and build-times runs from, relative to the project root (optional)")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	weights, err := health.ParseWeights(*weightSpec)
+	if err != nil {
+		return err
+	}
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+
+	deadCode, err := storedDeadCode(rootPath(projectRoot, *results))
+	if err != nil {
+		return err
+	}
+	in, err := health.Gather(projectRoot, rootPath(projectRoot, *planPath), deadCode)
+	if err != nil {
+		return err
+	}
+	if in.BuildRegressions, err = storedBuildRegressions(rootPath(projectRoot, *results)); err != nil {
+		return err
+	}
+	mods, err := health.Score(projectRoot, in, weights)
+	if err != nil {
+		return err
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return health.WriteMarkdown(w, mods, weights, in.Cycles)
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(mods)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	cycles := make([]store.Issue, 0, len(in.Cycles))
+	for _, c := range in.Cycles {
+		cycles = append(cycles, store.Issue{Module: c.Modules[0], Kind: health.IssueCycle, Message: c.String()})
+	}
+	return export.record("health", projectRoot, func(s *metrics.Set) {
+		for _, m := range mods {
+			s.Gauge("health_score", "Weighted module health score (0-100).", m.Score, "module", m.Name)
+			for factor, score := range m.Scores {
+				s.Gauge("health_factor_score", "Module health score per factor (0-100).", score, "module", m.Name, "factor", factor)
+			}
+		}
+		s.Gauge("dependency_cycles", "Import cycles between modules.", float64(len(in.Cycles)))
+	}, cycles)
+}
+
+// storedDeadCode returns the unused targets per module from the newest
+// unused-targets run in the store at path, or nil when there is none.
+func storedDeadCode(path string) (map[string]int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+	db, err := store.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	counts, ok, err := db.LatestIssueCounts(runContext, "unused-targets")
+	if err != nil || !ok {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// storedBuildRegressions returns the regressions the newest build-times
+// run in the store at path recorded, by module, or nil when there is none.
+func storedBuildRegressions(path string) (map[string][]string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+	db, err := store.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	runs, err := db.Runs(runContext, buildtimes.Tool, 1)
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	issues, err := db.RunIssues(runContext, runs[0].ID, buildtimes.IssueRegression)
+	if err != nil {
+		return nil, err
+	}
+	regressions := make(map[string][]string)
+	for _, i := range issues {
+		regressions[i.Module] = append(regressions[i.Module], i.Message)
+	}
+	return regressions, nil
+}
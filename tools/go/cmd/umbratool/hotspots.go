@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/hotspots"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "hotspots",
+		summary: "List the files and modules changed most often while being most complex, with their churn trend",
+		run:     runHotspots,
+	})
+}
+
+func runHotspots(args []string) error {
+	fs := newFlagSet("hotspots")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to analyse")
+	days := fs.Int("window", 90, "Days of history in each window")
+	until := fs.String("until", "", "Last day of the current window (YYYY-MM-DD; default: today)")
+	top := fs.Int("top", 25, "Number of files listed in the Markdown report and recorded by --store")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *days <= 0 {
+		return errors.New("--window must be at least one day")
+	}
+	end := reportTime()
+	if *until != "" {
+		day, err := time.Parse("2006-01-02", *until)
+		if err != nil {
+			return fmt.Errorf("--until: %w", err)
+		}
+		end = day.Add(24 * time.Hour)
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	report, err := hotspots.Analyse(projectRoot, hotspots.Options{Dirs: scopeList(*dirs), Rules: rules, Until: end.UTC(), Window: time.Duration(*days) * 24 * time.Hour})
+	if err != nil {
+		return err
+	}
+
+	files := report.Files
+	if *top > 0 && len(files) > *top {
+		files = files[:*top]
+	}
+	issues := make([]store.Issue, 0, len(files))
+	for _, f := range files {
+		if f.Score == 0 {
+			continue
+		}
+		message := fmt.Sprintf("hotspot score %d: %d commits in %d days (%s) at complexity %d", f.Score, f.Commits, *days, f.Trend, f.Complexity)
+		issues = append(issues, store.Issue{Module: f.Module, File: f.Path, Kind: "hotspot", Message: message})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	i := 0
+	for n, f := range files {
+		if f.Score != 0 {
+			files[n].Fingerprint = issues[i].Fingerprint
+			i++
+		}
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return hotspots.WriteMarkdown(w, report, *top)
+		case "json":
+			return hotspots.WriteJSON(w, report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return export.record("hotspots", projectRoot, func(s *metrics.Set) {
+		s.Gauge("window_commits", "Commits changing the analysed files per window.", float64(report.Current.Commits), "window", "current")
+		s.Gauge("window_commits", "Commits changing the analysed files per window.", float64(report.Previous.Commits), "window", "previous")
+		for _, m := range report.Modules {
+			s.Gauge("module_commits", "Commits changing a module's files in the current window.", float64(m.Commits), "module", m.Name)
+			s.Gauge("hotspot_score", "Sum of commits times complexity over a module's files.", float64(m.Score), "module", m.Name)
+		}
+	}, issues)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/impact"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/protocols"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "impacted-tests",
+		summary: "Select the swift_test targets a change can break, following changed protocols to their conformers",
+		run:     runImpactedTests,
+	})
+}
+
+func runImpactedTests(args []string) error {
+	fs := newFlagSet("impacted-tests")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	since := fs.String("changed-since", "", "Git ref the change is measured from, through its merge base with HEAD, e.g. origin/main")
+	dirs := fs.String("scope", workspace.AllScopes, "Comma-separated scopes (sources, tests, testsupport) or directories indexed for protocols and conformers")
+	useBazel := fs.Bool("bazel", false, "Ask bazel query for the tests depending on the changed targets, instead of reading the deps of the BUILD files")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown, json, or labels (one test per line, for bazel test)")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *since == "" {
+		return errors.New("--changed-since is required")
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	base, changes, err := impact.Changed(projectRoot, *since)
+	if err != nil {
+		return err
+	}
+	ix, err := protocols.Build(projectRoot, scopeList(*dirs)...)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	report := impact.Select(changes, ix, rules)
+	report.Since, report.Base = *since, base
+	if *useBazel {
+		if report.Tests, err = impact.QueryTests(runContext, bazelRunner(projectRoot), report.Seeds); err != nil {
+			return err
+		}
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return impact.WriteMarkdown(w, report)
+		case "json":
+			return impact.WriteJSON(w, report)
+		case "labels":
+			return impact.WriteLabels(w, report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return export.record("impacted-tests", projectRoot, func(s *metrics.Set) {
+		s.Gauge("changed_files", "Files changed since the merge base.", float64(len(report.Changes)))
+		s.Gauge("changed_protocols", "Protocols whose declaration or extensions changed.", float64(len(report.Protocols)))
+		s.Gauge("changed_targets", "Targets compiling a changed file or a conformer of a changed protocol.", float64(len(report.Seeds)))
+		s.Gauge("impacted_tests", "Test targets selected to run.", float64(len(report.Tests)))
+	}, nil)
+}
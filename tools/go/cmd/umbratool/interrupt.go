@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+)
+
+// runContext is cancelled by the first SIGINT or SIGTERM. Commands pass it
+// to whatever can stop part-way, and check it between the files they
+// edit, so an interrupted run finishes the file in hand and no more; the
+// worker pool stops at it by itself. A second signal kills the process.
+var runContext = context.Background()
+
+// exitCancelled is the status of a run stopped by a signal, as a shell
+// reports a process killed by SIGINT.
+const exitCancelled = 130
+
+// interrupted is set once a signal has cancelled runContext.
+var interrupted atomic.Bool
+
+// trapInterrupts installs runContext. The returned function stops
+// trapping.
+func trapInterrupts() func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	runContext = ctx
+	pool.SetContext(ctx)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		// Let the next signal through, for a run that will not stop.
+		signal.Stop(signals)
+		interrupted.Store(true)
+		fmt.Fprintf(os.Stderr, "umbratool: %v; finishing the work in hand (interrupt again to abort)\n", sig)
+		cancel()
+	}()
+	return func() { signal.Stop(signals) }
+}
+
+// cancelled reports whether err comes from runContext being cancelled by
+// a signal.
+func cancelled(err error) bool {
+	return interrupted.Load() && errors.Is(err, context.Canceled)
+}
+
+// uninterrupted returns a context for the writes that record what a run
+// has done, which should still happen once it is interrupted.
+func uninterrupted() context.Context {
+	return context.WithoutCancel(runContext)
+}
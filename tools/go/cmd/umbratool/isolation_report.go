@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/isolation"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "isolation-report",
+		summary: "Map the actor and @MainActor service types, their calling modules and accesses made without await",
+		run:     runIsolationReport,
+	})
+}
+
+func runIsolationReport(args []string) error {
+	fs := newFlagSet("isolation-report")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	services := fs.String("services", strings.Join(isolation.DefaultServices, ","), "Comma-separated directories whose isolated types are mapped")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories searched for callers")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	strict := fs.Bool("strict", false, "Exit non-zero when any isolated member is accessed without await")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	report, err := isolation.Scan(projectRoot, isolation.Options{Services: splitList(*services), Dirs: scopeList(*dirs), Rules: rules})
+	if err != nil {
+		return err
+	}
+
+	unawaited := report.Unawaited()
+	issues := make([]store.Issue, 0, len(unawaited))
+	for _, a := range unawaited {
+		issues = append(issues, store.Issue{Module: a.Module, File: a.File, Line: a.Line, Kind: "unawaited_access",
+			Message: a.Type + "." + a.Member + " is isolated but accessed without await", Symbol: a.Type + "." + a.Member})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	i := 0
+	for n, a := range report.Accesses {
+		if !a.Awaited {
+			report.Accesses[n].Fingerprint = issues[i].Fingerprint
+			i++
+		}
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return isolation.WriteMarkdown(w, report)
+		case "json":
+			return isolation.WriteJSON(w, report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("isolation-report", projectRoot, func(s *metrics.Set) {
+		for _, a := range report.Accesses {
+			s.Add("isolated_accesses", "Accesses to isolated service members per calling module.", 1,
+				"module", a.Module, "type", a.Type, "awaited", strconv.FormatBool(a.Awaited))
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if *strict && len(unawaited) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
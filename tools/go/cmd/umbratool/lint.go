@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftlint"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "lint",
+		summary: "Run SwiftLint and report structured violations",
+		run:     runLint,
+	})
+}
+
+// swiftLintFlags are the SwiftLint options shared by every command that
+// rewrites Swift files.
+type swiftLintFlags struct {
+	enabled *bool
+	config  *string
+}
+
+func addSwiftLintFlags(fs *flag.FlagSet, enabledByDefault bool) swiftLintFlags {
+	return swiftLintFlags{
+		enabled: fs.Bool("swiftlint", enabledByDefault, "Run swiftlint --fix on touched files and report remaining violations"),
+		config:  fs.String("swiftlint-config", "", "SwiftLint configuration file (default: SwiftLint's own lookup)"),
+	}
+}
+
+// swiftLintCache returns the SwiftLint cache directory below --out-dir, or
+// "" for SwiftLint's own default when no --out-dir is set. SwiftLint runs
+// in the project root, so the path is absolute.
+func swiftLintCache() (string, error) {
+	if outDir == "" {
+		return "", nil
+	}
+	return filepath.Abs(filepath.Join(outDir, "swiftlint-cache"))
+}
+
+// fixTouched autocorrects the files a command modified and prints the
+// violations that remain in them. It is a no-op when SwiftLint is disabled
+// or there is nothing to lint.
+func (f swiftLintFlags) fixTouched(root string, touched []string) ([]swiftlint.Violation, error) {
+	if !*f.enabled || len(touched) == 0 {
+		return nil, nil
+	}
+
+	cache, err := swiftLintCache()
+	if err != nil {
+		return nil, err
+	}
+	opts := swiftlint.Options{Dir: root, Config: *f.config, Files: touched, CachePath: cache}
+	if !swiftlint.Available(opts) {
+		fmt.Println("swiftlint not found on PATH; skipping lint of touched files")
+		return nil, nil
+	}
+
+	violations, err := swiftlint.Fix(runContext, opts)
+	if err != nil {
+		return nil, err
+	}
+	violations = swiftlint.Filter(violations, touched)
+	printViolations(violations)
+	return violations, nil
+}
+
+func printViolations(violations []swiftlint.Violation) {
+	for _, v := range violations {
+		fmt.Printf("%s:%d:%d: %s: %s (%s)\n", v.File, v.Line, v.Character, v.Severity, v.Reason, v.RuleID)
+	}
+	errs, warnings := swiftlint.Counts(violations)
+	fmt.Printf("SwiftLint: %d errors, %d warnings\n", errs, warnings)
+}
+
+func runLint(args []string) error {
+	fs := newFlagSet("lint")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	config := fs.String("swiftlint-config", "", "SwiftLint configuration file (default: SwiftLint's own lookup)")
+	fix := fs.Bool("fix", false, "Autocorrect before reporting")
+	jsonOut := fs.Bool("json", false, "Print violations as JSON")
+	strict := fs.Bool("strict", false, "Exit non-zero on warnings as well as errors")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+
+	cache, err := swiftLintCache()
+	if err != nil {
+		return err
+	}
+	opts := swiftlint.Options{Dir: projectRoot, Config: *config, Files: fs.Args(), CachePath: cache}
+	lint := swiftlint.Lint
+	if *fix {
+		lint = swiftlint.Fix
+	}
+	violations, err := lint(runContext, opts)
+	if err != nil {
+		return err
+	}
+	if len(opts.Files) > 0 {
+		violations = swiftlint.Filter(violations, opts.Files)
+	}
+
+	issues := make([]store.Issue, 0, len(violations))
+	for _, v := range violations {
+		issues = append(issues, store.Issue{Module: workspace.ModuleForPath(v.File), File: v.File, Line: v.Line, Kind: v.RuleID, Message: v.Reason})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i := range violations {
+		violations[i].Fingerprint = issues[i].Fingerprint
+	}
+
+	if *jsonOut {
+		err := writeOutput("", func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(violations)
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		printViolations(violations)
+	}
+
+	err = export.record("lint", projectRoot, func(s *metrics.Set) {
+		for _, v := range violations {
+			s.Add("swiftlint_violations", "SwiftLint violations per module and severity.", 1, "module", workspace.ModuleForPath(v.File), "severity", v.Severity)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	errs, warnings := swiftlint.Counts(violations)
+	if errs > 0 || (*strict && warnings > 0) {
+		return errCheckFailed
+	}
+	return nil
+}
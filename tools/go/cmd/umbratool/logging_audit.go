@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/changelog"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/logaudit"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "logging-audit",
+		summary: "Find print, NSLog, SwiftyBeaver and os_log calls bypassing LoggingWrapper, and rewrite the mechanical ones",
+		run:     runLoggingAudit,
+	})
+}
+
+func runLoggingAudit(args []string) error {
+	fs := newFlagSet("logging-audit")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to audit")
+	exempt := fs.String("exempt", strings.Join(logaudit.DefaultExempt, ","), "Comma-separated modules left out of the audit, the logging stack itself")
+	printLevel := fs.String("print-level", "debug", "Wrapper level print calls are rewritten to: "+strings.Join(logaudit.Levels, ", "))
+	fix := fs.Bool("fix", false, "Rewrite the mechanical call sites to the wrapper, then report what is left")
+	deps := fs.Bool("deps", true, "With --fix, add LoggingWrapper to the deps of the Bazel targets whose files are rewritten")
+	dryRun := fs.Bool("dry-run", false, "With --fix, print the changes as a unified diff instead of writing them")
+	keepBackup := fs.Bool("backup", true, "With --fix, back up the changed files first, for the restore command")
+	noteChange := fs.Bool("changelog", true, "With --fix, record the applied change as a fragment in changelog.d, for changelog assemble")
+	lint := addSwiftLintFlags(fs, false)
+	strict := fs.Bool("strict", false, "Fail when a call bypasses the wrapper")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	opts := logaudit.Options{
+		Dirs:       scopeList(*dirs),
+		Rules:      rules,
+		Exempt:     splitList(*exempt),
+		PrintLevel: *printLevel,
+	}
+	if opts.Exempt == nil {
+		opts.Exempt = []string{}
+	}
+	report, err := logaudit.Audit(projectRoot, opts)
+	if err != nil {
+		return err
+	}
+	if *fix {
+		plan, err := logaudit.Fix(projectRoot, report, rules, *deps)
+		if err != nil {
+			return err
+		}
+		for _, w := range plan.Warnings {
+			fmt.Fprintf(os.Stderr, "logging-audit: %s\n", w)
+		}
+		var change *changelog.Fragment
+		if *noteChange {
+			edits := 0
+			for _, site := range report.Sites {
+				if site.Fix != "" {
+					edits++
+				}
+			}
+			sites := "call sites"
+			if edits == 1 {
+				sites = "call site"
+			}
+			change = &changelog.Fragment{
+				Kind:    changelog.KindOther,
+				Summary: fmt.Sprintf("Moved %d logging %s onto %s", edits, sites, logaudit.Wrapper),
+			}
+		}
+		touched, err := applyRewrite(projectRoot, "logging-audit", plan, *dryRun, *keepBackup, change)
+		if err != nil || *dryRun {
+			return err
+		}
+		if _, err := lint.fixTouched(projectRoot, touched); err != nil {
+			return err
+		}
+		if report, err = logaudit.Audit(projectRoot, opts); err != nil {
+			return err
+		}
+	}
+
+	issues := make([]store.Issue, 0, len(report.Sites))
+	for _, s := range report.Sites {
+		message := fmt.Sprintf("%s bypasses %s; use %s", s.Kind, logaudit.Wrapper, s.Fix)
+		if s.Fix == "" {
+			message = fmt.Sprintf("%s bypasses %s and %s", s.Kind, logaudit.Wrapper, s.Manual)
+		}
+		issues = append(issues, store.Issue{Module: s.Module, File: s.File, Line: s.Line, Kind: "legacy_logging", Message: message})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i := range report.Sites {
+		report.Sites[i].Fingerprint = issues[i].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return logaudit.WriteMarkdown(w, report)
+		case "json":
+			return logaudit.WriteJSON(w, report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("logging-audit", projectRoot, func(s *metrics.Set) {
+		for _, m := range report.Modules {
+			for _, k := range logaudit.Kinds {
+				if n := m.Legacy[k]; n > 0 {
+					s.Gauge("legacy_logging_calls", "Logging calls bypassing LoggingWrapper per module by kind.", float64(n), "module", m.Name, "kind", k)
+				}
+			}
+			s.Gauge("wrapper_logging_calls", "Calls of the LoggingWrapper facade per module.", float64(m.Wrapper), "module", m.Name)
+			s.Gauge("logging_migrated_ratio", "Share of each module's logging calls going through LoggingWrapper.", m.Migrated(), "module", m.Name)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if *strict && len(report.Sites) > 0 {
+		fmt.Fprintf(os.Stderr, "logging-audit: %d calls bypass %s\n", len(report.Sites), logaudit.Wrapper)
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Command umbratool is the entry point for the shared UmbraCore analysis and
+// maintenance commands.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command is a single umbratool subcommand.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{}
+
+// register adds cmd to the command table. It is called from the init
+// function of each command's file.
+func register(cmd command) {
+	if _, dup := commands[cmd.name]; dup {
+		panic("umbratool: duplicate command " + cmd.name)
+	}
+	commands[cmd.name] = cmd
+}
+
+// errCheckFailed is returned by check-style commands whose findings should
+// fail the build. The findings themselves have already been reported.
+var errCheckFailed = errors.New("check failed")
+
+func main() {
+	global := newFlagSet("umbratool")
+	global.Usage = usage
+	if err := global.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+
+	args := global.Args()
+	if len(args) == 0 || args[0] == "help" {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "umbratool: unknown command %q\n\n", args[0])
+		usage()
+		os.Exit(2)
+	}
+
+	invocation = args[1:]
+	stopTrapping := trapInterrupts()
+	err := cmd.run(args[1:])
+	stopTrapping()
+	if perr := stopProfiles(); perr != nil {
+		fmt.Fprintf(os.Stderr, "umbratool %s: profile: %v\n", cmd.name, perr)
+	}
+	err = settle(cmd.name, err)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		if cancelled(err) {
+			fmt.Fprintf(os.Stderr, "umbratool %s: cancelled: %v\n", cmd.name, err)
+			os.Exit(exitCancelled)
+		}
+		if !errors.Is(err, errCheckFailed) {
+			fmt.Fprintf(os.Stderr, "umbratool %s: %v\n", cmd.name, err)
+		}
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: umbratool [--jobs N] [--cpuprofile FILE] [--memprofile FILE] <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-24s %s\n", name, commands[name].summary)
+	}
+}
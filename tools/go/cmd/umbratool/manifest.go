@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// readManifest returns the files listed in a sources manifest, one
+// slash-separated path per line, as the aspects in //tools/go:analyzers.bzl
+// write them. Blank lines are skipped.
+func readManifest(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, path.Clean(filepath.ToSlash(line)))
+		}
+	}
+	return paths, nil
+}
+
+// analysisRoot resolves --root. A run given a sources manifest, as a Bazel
+// action is, defaults to the working directory instead: the execroot the
+// manifest's paths are relative to, which has no workspace marker to find.
+func analysisRoot(root, manifest string) (string, error) {
+	if root == "" && manifest != "" {
+		return os.Getwd()
+	}
+	return workspace.ResolveRoot(root)
+}
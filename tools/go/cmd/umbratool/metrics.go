@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/github"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/maturity"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moves"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// resultFlags are the analyzer options for exporting a run's results.
+type resultFlags struct {
+	metricsOut  *string
+	store       *string
+	githubPR    *int
+	githubRepo  *string
+	githubToken *string
+}
+
+func addResultFlags(fs *flag.FlagSet) resultFlags {
+	pr, _ := strconv.Atoi(os.Getenv("UMBRATOOL_GITHUB_PR"))
+	return resultFlags{
+		metricsOut:  fs.String("metrics-out", "", "Also write gauge metrics in OpenMetrics text format to this file, e.g. metrics.prom"),
+		store:       fs.String("store", "", "Also append this run's metrics and issues to a SQLite result store, e.g. results.db"),
+		githubPR:    fs.Int("github-pr", pr, "Also publish the issues on this GitHub pull request as a comment and check run annotations (default: $UMBRATOOL_GITHUB_PR)"),
+		githubRepo:  fs.String("github-repo", "", "Repository of --github-pr as owner/name (default: $GITHUB_REPOSITORY)"),
+		githubToken: fs.String("github-token", "", "Token for --github-pr; prefer setting $GITHUB_TOKEN"),
+	}
+}
+
+// record fills a metric set and writes it to --metrics-out, then appends
+// it with issues to --store and publishes the issues on --github-pr.
+// Relative --metrics-out and --store paths are below --out-dir. Issues are
+// annotated with their tiers and fingerprints first, and tallied for the
+// exit status main settles.
+func (f resultFlags) record(tool, root string, fill func(s *metrics.Set), issues []store.Issue) error {
+	a, err := newAnnotator(root)
+	if err != nil {
+		return err
+	}
+	for i := range issues {
+		a.annotate(&issues[i])
+		verdict.issues++
+		switch a.tiers.PolicyOf(issues[i].Tier) {
+		case maturity.Report:
+		case maturity.Fail:
+			verdict.blocking++
+			verdict.failing++
+		default:
+			verdict.blocking++
+		}
+	}
+	if *f.githubPR > 0 {
+		if err := f.publish(tool, root, issues); err != nil {
+			return err
+		}
+	}
+	if *f.metricsOut == "" && *f.store == "" {
+		return nil
+	}
+	s := metrics.NewSet()
+	fill(s)
+
+	if *f.metricsOut != "" {
+		path, err := outPath(*f.metricsOut)
+		if err != nil {
+			return err
+		}
+		if err := s.WriteFile(path); err != nil {
+			return err
+		}
+	}
+	if *f.store == "" {
+		return nil
+	}
+
+	path, err := outPath(*f.store)
+	if err != nil {
+		return err
+	}
+	db, err := store.Open(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if _, err := db.Record(uninterrupted(), tool, store.GitSHA(root), time.Now(), s.Samples(), issues); err != nil {
+		return fmt.Errorf("recording results in %s: %w", *f.store, err)
+	}
+	return nil
+}
+
+// publish posts issues on --github-pr. The token, repository and API root
+// default to the variables GitHub Actions sets.
+func (f resultFlags) publish(tool, root string, issues []store.Issue) error {
+	token, repo := *f.githubToken, *f.githubRepo
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if repo == "" {
+		repo = os.Getenv("GITHUB_REPOSITORY")
+	}
+	if token == "" || repo == "" {
+		return errors.New("--github-pr needs a token and a repository: set $GITHUB_TOKEN and $GITHUB_REPOSITORY, or --github-token and --github-repo")
+	}
+	client := github.NewClient(os.Getenv("GITHUB_API_URL"), token, repo)
+	ctx, cancel := context.WithTimeout(runContext, 2*time.Minute)
+	defer cancel()
+	if err := github.Publish(ctx, client, *f.githubPR, tool, store.GitSHA(root), issues); err != nil {
+		return fmt.Errorf("publishing to pull request %d: %w", *f.githubPR, err)
+	}
+	return nil
+}
+
+// annotator sets the tier and fingerprint of the issues found in the tree
+// at root: the tier umbratool.yaml gives the owning module, and the
+// fingerprint of the issue with its file traced back through moves.log to
+// the path it was added under.
+type annotator struct {
+	tiers  *maturity.Config
+	origin func(string) string
+}
+
+func newAnnotator(root string) (*annotator, error) {
+	tiers, err := maturity.LoadConfig(filepath.Join(root, "umbratool.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	entries, err := moves.Load(root)
+	if err != nil {
+		return nil, err
+	}
+	return &annotator{tiers: tiers, origin: func(p string) string { return moves.Origin(entries, p) }}, nil
+}
+
+// annotateIssues annotates issues as record does, so that an analyzer can
+// give their fingerprints in its own report before the run is recorded.
+func annotateIssues(root string, issues []store.Issue) error {
+	a, err := newAnnotator(root)
+	if err != nil {
+		return err
+	}
+	for i := range issues {
+		a.annotate(&issues[i])
+	}
+	return nil
+}
+
+func (a *annotator) annotate(i *store.Issue) {
+	if i.Tier == "" {
+		module := i.Module
+		if module == "" && i.File != "" {
+			module = workspace.ModuleForPath(i.File)
+		}
+		i.Tier = a.tiers.Tier(module)
+	}
+	if i.Fingerprint == "" {
+		i.Fingerprint = store.Fingerprint(*i, a.origin)
+	}
+}
+
+// blocking reports whether an annotated issue fails the run, given
+// whether the analyzer alone would fail on it.
+func (a *annotator) blocking(i store.Issue, strict bool) bool {
+	switch a.tiers.PolicyOf(i.Tier) {
+	case maturity.Report:
+		return false
+	case maturity.Fail:
+		return true
+	}
+	return strict
+}
+
+// verdict tallies the issues recorded by this process: those failing the
+// run under their tiers' policies when the analyzer does, and those
+// failing it regardless.
+var verdict struct{ issues, blocking, failing int }
+
+// settle applies the maturity policies to the outcome of a command. A
+// check failing only on issues in tiers that report them passes, and one
+// passing with issues in tiers that fail on them fails.
+func settle(name string, err error) error {
+	switch {
+	case errors.Is(err, errCheckFailed) && verdict.issues > 0 && verdict.blocking == 0:
+		fmt.Fprintf(os.Stderr, "umbratool %s: passing: the %d issues are all in modules whose tiers only report them\n", name, verdict.issues)
+		return nil
+	case err == nil && verdict.failing > 0:
+		fmt.Fprintf(os.Stderr, "umbratool %s: failing: %d issues are in modules whose tiers fail on any issue\n", name, verdict.failing)
+		return errCheckFailed
+	}
+	return err
+}
+
+func boolGauge(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "module-index",
+		summary: "Write swift_modules.json mapping each module_name to its label and source directory",
+		run:     runModuleIndex,
+	})
+}
+
+func runModuleIndex(args []string) error {
+	fs := newFlagSet("module-index")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	output := fs.String("output", moduleindex.File, "Index file, relative to the project root")
+	check := fs.Bool("check", false, "Exit non-zero if the index is missing or out of date instead of writing it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+
+	ix, skipped, err := moduleindex.Build(projectRoot)
+	if err != nil {
+		return err
+	}
+	for _, rel := range skipped {
+		fmt.Fprintf(os.Stderr, "skipping %s: does not parse\n", rel)
+	}
+	data, err := ix.Marshal()
+	if err != nil {
+		return err
+	}
+
+	path := rootPath(projectRoot, *output)
+	if *check {
+		current, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if !bytes.Equal(current, data) {
+			fmt.Fprintf(os.Stderr, "%s is out of date; run umbratool module-index\n", *output)
+			return errCheckFailed
+		}
+		return nil
+	}
+	if err := atomicfile.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Indexed %d Swift rules from %d BUILD files in %s\n", len(ix.Modules), len(ix.BuildFiles), *output)
+	return nil
+}
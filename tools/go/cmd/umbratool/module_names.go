@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "module-names",
+		summary: "Report Swift rules that compile to the same module_name",
+		run:     runModuleNames,
+	})
+}
+
+func runModuleNames(args []string) error {
+	fs := newFlagSet("module-names")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "text", "Report format: text or json")
+	strict := fs.Bool("strict", false, "Exit non-zero when module names collide")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+
+	rules, skipped, err := modulenames.Scan(projectRoot)
+	if err != nil {
+		return err
+	}
+	for _, rel := range skipped {
+		fmt.Fprintf(os.Stderr, "skipping %s: does not parse\n", rel)
+	}
+	collisions := modulenames.FindCollisions(rules)
+
+	var issues []store.Issue
+	for _, c := range collisions {
+		for _, r := range c.Rules[1:] {
+			issues = append(issues, store.Issue{
+				Module:  workspace.ModuleForPath(r.File),
+				File:    r.File,
+				Line:    r.Line,
+				Kind:    "module-name-collision",
+				Message: fmt.Sprintf("%s reuses module_name %q; suggest %q", r.Label, c.ModuleName, c.Suggestions[r.Label]),
+			})
+		}
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	i := 0
+	for n, c := range collisions {
+		collisions[n].Fingerprints = make(map[string]string, len(c.Rules)-1)
+		for _, r := range c.Rules[1:] {
+			collisions[n].Fingerprints[r.Label] = issues[i].Fingerprint
+			i++
+		}
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "text":
+			if len(collisions) == 0 {
+				_, err := fmt.Fprintf(w, "%d Swift rules, no module_name collisions\n", len(rules))
+				return err
+			}
+			_, err := io.WriteString(w, modulenames.Format(collisions))
+			return err
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(collisions)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("module-names", projectRoot, func(s *metrics.Set) {
+		s.Gauge("swift_rules", "Swift rules found in BUILD files.", float64(len(rules)))
+		s.Gauge("module_name_collisions", "Module names produced by more than one Swift rule.", float64(len(collisions)))
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if *strict && len(collisions) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/objcbridge"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/protocols"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "objc-bridge",
+		summary: "Match Objective-C header protocols with their Swift counterparts and compare selectors",
+		run:     runObjCBridge,
+	})
+}
+
+func runObjCBridge(args []string) error {
+	fs := newFlagSet("objc-bridge")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to analyse")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	strict := fs.Bool("strict", false, "Exit non-zero when any bridging issue is found")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	scope := scopeList(*dirs)
+	objc, err := objcbridge.Scan(projectRoot, scope...)
+	if err != nil {
+		return err
+	}
+	ix, err := protocols.Build(projectRoot, scope...)
+	if err != nil {
+		return err
+	}
+	report := objcbridge.Compare(objc, ix)
+
+	stored := make([]store.Issue, 0, len(report.Issues))
+	for _, i := range report.Issues {
+		stored = append(stored, store.Issue{Module: i.Module, File: i.File, Line: i.Line, Kind: i.Kind, Message: i.Message})
+	}
+	if err := annotateIssues(projectRoot, stored); err != nil {
+		return err
+	}
+	for n := range report.Issues {
+		report.Issues[n].Fingerprint = stored[n].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return objcbridge.WriteMarkdown(w, report)
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("objc-bridge", projectRoot, func(s *metrics.Set) {
+		counts := make(map[[2]string]int)
+		for _, i := range report.Issues {
+			counts[[2]string{i.Module, i.Kind}]++
+		}
+		for key, n := range counts {
+			s.Gauge("objc_bridge_issues", "Objective-C bridging issues per module by kind.", float64(n), "module", key[0], "kind", key[1])
+		}
+	}, stored)
+	if err != nil {
+		return err
+	}
+
+	if *strict && len(report.Issues) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/objcsurface"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "objc-surface",
+		summary: "Report the @objc, dynamic and NSObject declarations of the modules built with library evolution",
+		run:     runObjCSurface,
+	})
+}
+
+func runObjCSurface(args []string) error {
+	fs := newFlagSet("objc-surface")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to scan")
+	modules := fs.String("modules", "", "Comma-separated module globs to report (default: the modules built with library evolution)")
+	all := fs.Bool("all", false, "Report every module, whether or not it is built with library evolution")
+	ratchet := fs.Bool("ratchet", false, "Fail when a module's surface is larger than in the newest run in --store")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *ratchet && *export.store == "" {
+		return errors.New("--ratchet needs --store")
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	var selected map[string]bool
+	switch globs := splitList(*modules); {
+	case *all:
+	case len(globs) > 0:
+		selected = make(map[string]bool)
+		for _, r := range rules {
+			for _, g := range globs {
+				if walker.Match(g, r.ModuleName) {
+					selected[r.ModuleName] = true
+				}
+			}
+		}
+	default:
+		if selected, err = objcsurface.Evolving(projectRoot); err != nil {
+			return err
+		}
+	}
+	findings, err := objcsurface.Scan(projectRoot, objcsurface.Options{Dirs: scopeList(*dirs), Rules: rules, Modules: selected})
+	if err != nil {
+		return err
+	}
+
+	// The previous totals are read before this run is recorded over them.
+	var previous map[string]int
+	if *ratchet {
+		if previous, err = storedIssueCounts(*export.store, "objc-surface"); err != nil {
+			return err
+		}
+	}
+	summaries := objcsurface.Summarise(findings, previous)
+
+	issues := make([]store.Issue, 0, len(findings))
+	for _, f := range findings {
+		issues = append(issues, store.Issue{Module: f.Module, File: f.File, Line: f.Line, Kind: "objc_surface_" + f.Kind, Message: f.Name, Symbol: f.Name})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i := range findings {
+		findings[i].Fingerprint = issues[i].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return objcsurface.WriteMarkdown(w, findings, summaries)
+		case "json":
+			return objcsurface.WriteJSON(w, findings, summaries)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("objc-surface", projectRoot, func(s *metrics.Set) {
+		for _, sum := range summaries {
+			for kind, n := range sum.ByKind {
+				s.Add("objc_surface", "Declarations tied to the Objective-C runtime per module by kind.", float64(n), "module", sum.Module, "kind", kind)
+			}
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if grown := objcsurface.Increases(summaries); len(grown) > 0 {
+		fmt.Fprintf(os.Stderr, "objc-surface: %d modules have a larger Objective-C surface than in the previous run\n", len(grown))
+		return errCheckFailed
+	}
+	return nil
+}
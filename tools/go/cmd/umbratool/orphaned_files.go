@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moves"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/orphans"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "orphaned-files",
+		summary: "Find Swift files that no Bazel target's srcs capture",
+		run:     runOrphanedFiles,
+	})
+}
+
+func runOrphanedFiles(args []string) error {
+	fs := newFlagSet("orphaned-files")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", workspace.AllScopes, "Comma-separated scopes (sources, tests, testsupport) or directories to scan")
+	allow := fs.String("allow", "", "Comma-separated globs of files kept out of the build on purpose")
+	fix := fs.String("fix", "", "Fix the orphans: add (to the nearest target's srcs) or attic (move them below --attic)")
+	attic := fs.String("attic", "attic", "Directory, relative to the project root, that --fix attic moves files into")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	strict := fs.Bool("strict", false, "Exit non-zero when orphaned files are found")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *fix != "" && *fix != "add" && *fix != "attic" {
+		return fmt.Errorf("unknown --fix %q: want add or attic", *fix)
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	found, err := orphans.Scan(projectRoot, orphans.Options{Dirs: scopeList(*dirs), Allow: splitList(*allow)})
+	if err != nil {
+		return err
+	}
+
+	switch *fix {
+	case "add":
+		run := beginAudit(projectRoot, "orphaned-files")
+		n, saved, err := orphans.AddToTargets(projectRoot, found)
+		if err != nil {
+			return run.failed(saved, err)
+		}
+		fmt.Fprintf(os.Stderr, "orphaned-files: added %d files to their nearest target\n", n)
+		if err := run.done(saved); err != nil {
+			return err
+		}
+	case "attic":
+		run := beginAudit(projectRoot, "orphaned-files")
+		n, err := orphans.MoveToAttic(moves.NewMover(projectRoot, "orphaned-files"), *attic, found)
+		var moved []string
+		for _, o := range found {
+			if dest, ok := strings.CutPrefix(o.Fix, "moved to "); ok {
+				moved = append(moved, o.File, dest)
+			}
+		}
+		if err != nil {
+			return run.failed(moved, err)
+		}
+		fmt.Fprintf(os.Stderr, "orphaned-files: moved %d files below %s\n", n, *attic)
+		if err := run.done(moved); err != nil {
+			return err
+		}
+	}
+
+	issues := make([]store.Issue, 0, len(found))
+	for _, o := range found {
+		issues = append(issues, store.Issue{Module: workspace.ModuleForPath(o.File), File: o.File, Kind: "orphaned-file", Message: o.Cause + ": " + o.Detail})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i := range found {
+		found[i].Fingerprint = issues[i].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return orphans.WriteMarkdown(w, found)
+		case "json":
+			return orphans.WriteJSON(w, found)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("orphaned-files", projectRoot, func(s *metrics.Set) {
+		counts := make(map[string]int)
+		for _, o := range found {
+			counts[o.Cause]++
+		}
+		for cause, n := range counts {
+			s.Add("orphaned_files", "Swift files no target's srcs capture, by likely cause.", float64(n), "cause", cause)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if *strict && len(found) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
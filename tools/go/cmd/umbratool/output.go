@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/jsonl"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/provenance"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// outDir is --out-dir, the directory relative output paths are written
+// below; empty for the working directory. Under bazel test it defaults to
+// the directory Bazel declares for the test's outputs, so nothing is
+// written into the sandboxed source tree.
+var outDir = defaultOutDir()
+
+func defaultOutDir() string {
+	if dir := os.Getenv("UMBRATOOL_OUT_DIR"); dir != "" {
+		return dir
+	}
+	return os.Getenv("TEST_UNDECLARED_OUTPUTS_DIR")
+}
+
+// outPath resolves an output file against --out-dir, creating the
+// directory it goes in. Empty, "-" (stdout) and absolute paths are
+// returned unchanged.
+func outPath(p string) (string, error) {
+	if p == "" || p == "-" || filepath.IsAbs(p) || outDir == "" {
+		return p, nil
+	}
+	p = filepath.Join(outDir, p)
+	return p, os.MkdirAll(filepath.Dir(p), 0o755)
+}
+
+// resultPath resolves a file an earlier run wrote, such as a result store:
+// below --out-dir when one is set, otherwise against the project root.
+func resultPath(root, p string) string {
+	if outDir != "" && !filepath.IsAbs(p) {
+		return filepath.Join(outDir, p)
+	}
+	return rootPath(root, p)
+}
+
+// writeOutput calls write with the file at path, resolved against
+// --out-dir, or with stdout when path is empty or "-".
+func writeOutput(path string, write func(w io.Writer) error) error {
+	if path == "" || path == "-" {
+		return write(os.Stdout)
+	}
+
+	path, err := outPath(path)
+	if err != nil {
+		return err
+	}
+	f, err := atomicfile.Create(path, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Abort()
+		return err
+	}
+	return f.Close()
+}
+
+// writeReport is writeOutput for analyzer reports. Markdown and JSON
+// reports are stamped with the provenance of the run fs was parsed for
+// over the tree at root; other formats are written as they are.
+func writeReport(fs *flag.FlagSet, root, path, format string, write func(w io.Writer) error) error {
+	if format != "markdown" && format != "json" {
+		return writeOutput(path, write)
+	}
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+	stamp := provenance.New(fs, root, reportTime())
+	report := buf.Bytes()
+	if format == "markdown" {
+		report = provenance.StampMarkdown(report, stamp)
+	} else {
+		var err error
+		if report, err = provenance.StampJSON(report, stamp); err != nil {
+			return err
+		}
+	}
+	return writeOutput(path, func(w io.Writer) error {
+		_, err := w.Write(report)
+		return err
+	})
+}
+
+// writeJSONL writes a JSON Lines report to path, as writeOutput does.
+// stream writes the records as the analysis produces them and returns the
+// summary, which is written last, stamped with the provenance of the run fs
+// was parsed for. Written to stdout, the records reach a consumer while the
+// analysis is still running.
+func writeJSONL(fs *flag.FlagSet, root, path string, stream func(w *jsonl.Writer) (any, error)) error {
+	return writeOutput(path, func(w io.Writer) error {
+		records := jsonl.NewWriter(w)
+		summary, err := stream(records)
+		if err != nil {
+			return err
+		}
+		return records.Summary(provenance.New(fs, root, reportTime()), summary)
+	})
+}
+
+// issueRecord is the JSON Lines record of an issue. Blocking is set on an
+// issue that fails the run, so that CI reading the records can stop at the
+// first one.
+type issueRecord struct {
+	store.Issue
+	Blocking bool `json:"blocking"`
+}
+
+// reportTime is the time reports are stamped with: $SOURCE_DATE_EPOCH when
+// it is set, so that a hermetic action reproduces its output exactly, and
+// otherwise now.
+func reportTime() time.Time {
+	if sec, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(sec, 0)
+	}
+	return time.Now()
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// scopeList resolves a --scope flag value, naming scopes such as sources
+// and tests or directories, to the directories it covers.
+func scopeList(s string) []string {
+	return workspace.ScopeDirs(splitList(s))
+}
+
+// rootPath resolves p against the project root unless it is already
+// absolute.
+func rootPath(root, p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(root, p)
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/deprecation"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errormapper"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errorreport"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/importrewrite"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/migration"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pipeline"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "pipeline",
+		summary: "Chain scan, plan and apply stages in one process, sharing what each has parsed",
+		run:     runPipeline,
+	})
+}
+
+// pipelineStage is one stage of the pipeline command. run parses its own
+// flags, reads and fills the model, and returns the artifact --save
+// persists, or nil. top is the pipeline's flag set, which the reports a
+// stage writes are stamped with.
+type pipelineStage struct {
+	summary string
+	run     func(top *flag.FlagSet, m *pipeline.Model, args []string) (any, error)
+}
+
+// pipelineStages are the stages by name.
+var pipelineStages = map[string]pipelineStage{
+	"scan-errors":        {"Find the error types declared below --scope", stageScanErrors},
+	"error-mapper-check": {"Check the error mappers against the error types scan-errors found", stageErrorMapperCheck},
+	"scan-deprecations":  {"Find the deprecated symbols below --scope and their uses", stageScanDeprecations},
+	"plan-migration":     {"Plan the import rewrites of --map and the migration of the deprecations scanned", stagePlanMigration},
+	"apply":              {"Write the rewrites plan-migration planned, or print them with --dry-run", stageApply},
+	"export-migration":   {"Write the migration plan-migration planned for downstream codebases", stageExportMigration},
+}
+
+func runPipeline(args []string) error {
+	fs := newFlagSet("pipeline")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	save := fs.String("save", "", "Directory each stage's artifact is written to as JSON, for audit")
+	list := fs.Bool("list", false, "List the stages and exit")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *list {
+		for _, name := range stageNames() {
+			fmt.Printf("%-20s %s\n", name, pipelineStages[name].summary)
+		}
+		return nil
+	}
+	stages, err := pipeline.Split(fs.Args())
+	if err != nil {
+		return err
+	}
+	if len(stages) == 0 {
+		return fmt.Errorf(`usage: umbratool pipeline [flags] <stage> [flags] "|" <stage> [flags]... (stages: %s)`, strings.Join(stageNames(), ", "))
+	}
+	for _, s := range stages {
+		if _, ok := pipelineStages[s[0]]; !ok {
+			return fmt.Errorf("unknown stage %q (stages: %s)", s[0], strings.Join(stageNames(), ", "))
+		}
+	}
+	if *save != "" && (outDir == "" || filepath.IsAbs(*save)) {
+		if err := os.MkdirAll(*save, 0o755); err != nil {
+			return err
+		}
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	m := &pipeline.Model{Root: projectRoot}
+	for i, s := range stages {
+		artifact, err := pipelineStages[s[0]].run(fs, m, s[1:])
+		if err != nil {
+			if errors.Is(err, errCheckFailed) {
+				return err
+			}
+			return fmt.Errorf("%s: %w", s[0], err)
+		}
+		if *save == "" || artifact == nil {
+			continue
+		}
+		file := filepath.Join(*save, fmt.Sprintf("%02d-%s.json", i+1, s[0]))
+		err = writeReport(fs, projectRoot, file, "json", func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(artifact)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func stageScanErrors(_ *flag.FlagSet, m *pipeline.Model, args []string) (any, error) {
+	fs := newFlagSet("scan-errors")
+	scope := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to scan")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	report, err := errorreport.Analyse(m.Root, scopeList(*scope)...)
+	if err != nil {
+		return nil, err
+	}
+	m.Errors = report
+	fmt.Fprintf(os.Stderr, "scan-errors: %d error types in %d modules, %d names declared more than once\n", len(report.Definitions), len(report.Modules), len(report.Duplicates))
+	return report, nil
+}
+
+func stageErrorMapperCheck(top *flag.FlagSet, m *pipeline.Model, args []string) (any, error) {
+	fs := newFlagSet("error-mapper-check")
+	mappers := fs.String("mapper", "Sources/ErrorHandling/Mapping/SecurityErrorMapper.swift", "Comma-separated mapper files to check")
+	enums := fs.String("enums", "SecurityError", "Comma-separated error enum names whose variants the mappers must cover")
+	output := fs.String("output", "", "Report file (default: none)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	strict := fs.Bool("strict", false, "Stop the pipeline when cases are unmapped or stale")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := pipeline.Need(m.Errors != nil, "error-mapper-check", "scan-errors"); err != nil {
+		return nil, err
+	}
+	coverage, issues, err := checkMappers(m.Root, m.Errors, splitList(*mappers), splitList(*enums))
+	if err != nil {
+		return nil, err
+	}
+	artifact := struct {
+		Coverage []errormapper.Coverage `json:"coverage"`
+		Issues   []errormapper.Issue    `json:"issues"`
+	}{coverage, issues}
+
+	if *output != "" {
+		err = writeReport(top, m.Root, *output, *format, func(w io.Writer) error {
+			switch *format {
+			case "markdown":
+				return errormapper.WriteMarkdown(w, coverage)
+			case "json":
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				return enc.Encode(artifact)
+			default:
+				return fmt.Errorf("unknown format %q", *format)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, i := range issues {
+		fmt.Fprintf(os.Stderr, "error-mapper-check: %s:%d: %s\n", i.File, i.Line, i.Message)
+	}
+	fmt.Fprintf(os.Stderr, "error-mapper-check: %d switches checked, %d issues\n", len(coverage), len(issues))
+	if *strict && len(issues) > 0 {
+		return nil, errCheckFailed
+	}
+	return artifact, nil
+}
+
+func stageScanDeprecations(_ *flag.FlagSet, m *pipeline.Model, args []string) (any, error) {
+	fs := newFlagSet("scan-deprecations")
+	scope := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to scan")
+	version := fs.String("version", "", "Current release (default: the version in MODULE.bazel)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	var err error
+	if *version == "" {
+		if *version, err = deprecation.ModuleVersion(m.Root); err != nil {
+			return nil, err
+		}
+	}
+	rules, err := m.Rules()
+	if err != nil {
+		return nil, err
+	}
+	symbols, err := deprecation.Scan(m.Root, deprecation.Options{Dirs: scopeList(*scope), Version: *version, Today: reportTime(), Rules: rules})
+	if err != nil {
+		return nil, err
+	}
+	if symbols == nil {
+		// Non-nil tells plan-migration the scan ran.
+		symbols = []deprecation.Symbol{}
+	}
+	m.Symbols, m.Version = symbols, *version
+	uses := 0
+	for _, s := range symbols {
+		uses += len(s.Uses)
+	}
+	fmt.Fprintf(os.Stderr, "scan-deprecations: %d deprecated symbols with %d uses\n", len(symbols), uses)
+	return symbols, nil
+}
+
+func stagePlanMigration(_ *flag.FlagSet, m *pipeline.Model, args []string) (any, error) {
+	fs := newFlagSet("plan-migration")
+	maps := fs.String("map", "", "Comma-separated rewrite-imports mapping files, applied in order")
+	scope := fs.String("scope", workspace.AllScopes, "Comma-separated scopes (sources, tests, testsupport) or directories to rewrite")
+	modules := fs.String("modules", "", "Comma-separated module globs to limit every rewrite to (default: all modules)")
+	deps := fs.Bool("deps", true, "Also update the deps of the Bazel targets whose files are rewritten")
+	version := fs.String("version", "", "UmbraCore version migrated to (default: the release scan-deprecations compared against, or the version in MODULE.bazel)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	// Mappings apply in order either way, so several files plan as one.
+	combined := &importrewrite.Config{}
+	m.Configs = nil
+	for _, file := range splitList(*maps) {
+		c, err := importrewrite.LoadConfig(rootPath(m.Root, file))
+		if err != nil {
+			return nil, err
+		}
+		m.Configs = append(m.Configs, c)
+		combined.Rewrites = append(combined.Rewrites, c.Rewrites...)
+	}
+	if len(m.Configs) == 0 && m.Symbols == nil {
+		return nil, errors.New("nothing to plan: pass --map or run scan-deprecations first")
+	}
+
+	rules, err := m.Rules()
+	if err != nil {
+		return nil, err
+	}
+	m.Plan, err = importrewrite.Rewrite(m.Root, combined, importrewrite.Options{
+		Dirs:    scopeList(*scope),
+		Rules:   rules,
+		Modules: splitList(*modules),
+		Deps:    *deps,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range m.Plan.Warnings {
+		fmt.Fprintf(os.Stderr, "plan-migration: %s\n", w)
+	}
+
+	switch {
+	case *version != "":
+	case m.Version != "":
+		*version = m.Version
+	default:
+		if *version, err = deprecation.ModuleVersion(m.Root); err != nil {
+			return nil, err
+		}
+	}
+	if m.Manifest, err = migration.Build(m.Root, *version, m.Configs, m.Symbols, rules); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "plan-migration: %d Swift files and %d BUILD files to rewrite\n", len(m.Plan.Swift), len(m.Plan.Build))
+	return struct {
+		Plan     *importrewrite.Plan `json:"plan"`
+		Manifest *migration.Manifest `json:"manifest"`
+	}{m.Plan, m.Manifest}, nil
+}
+
+func stageApply(_ *flag.FlagSet, m *pipeline.Model, args []string) (any, error) {
+	fs := newFlagSet("apply")
+	dryRun := fs.Bool("dry-run", false, "Print the changes as a unified diff instead of writing them")
+	keepBackup := fs.Bool("backup", true, "Back up the changed files first, for the restore command")
+	lint := addSwiftLintFlags(fs, false)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := pipeline.Need(m.Plan != nil, "apply", "plan-migration"); err != nil {
+		return nil, err
+	}
+	touched, err := applyRewrite(m.Root, "pipeline", m.Plan, *dryRun, *keepBackup, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := lint.fixTouched(m.Root, touched); err != nil {
+		return nil, err
+	}
+
+	type applied struct {
+		Path  string   `json:"path"`
+		Edits []string `json:"edits"`
+	}
+	artifact := struct {
+		DryRun  bool      `json:"dryRun"`
+		Changes []applied `json:"changes"`
+	}{DryRun: *dryRun, Changes: []applied{}}
+	for _, c := range append(append([]importrewrite.Change(nil), m.Plan.Swift...), m.Plan.Build...) {
+		artifact.Changes = append(artifact.Changes, applied{c.Path, c.Edits})
+	}
+	return artifact, nil
+}
+
+func stageExportMigration(top *flag.FlagSet, m *pipeline.Model, args []string) (any, error) {
+	fs := newFlagSet("export-migration")
+	output := fs.String("output", "", "Output file (default: stdout)")
+	format := fs.String("format", "script", "Output format: script (Python 3), json or markdown")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := pipeline.Need(m.Manifest != nil, "export-migration", "plan-migration"); err != nil {
+		return nil, err
+	}
+	if m.Manifest.Empty() {
+		return nil, errors.New("nothing to migrate: pass --map to plan-migration or run scan-deprecations first")
+	}
+	err := writeReport(top, m.Root, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "script":
+			return migration.WriteScript(w, m.Manifest)
+		case "json":
+			return migration.WriteJSON(w, m.Manifest)
+		case "markdown":
+			return migration.WriteMarkdown(w, m.Manifest)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	return nil, err
+}
+
+// stageNames returns the names of the pipeline stages, sorted.
+func stageNames() []string {
+	names := make([]string, 0, len(pipelineStages))
+	for name := range pipelineStages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
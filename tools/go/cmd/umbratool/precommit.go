@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/header"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/precommit"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "precommit",
+		summary: "Run the fast checks on the files staged for commit, printing a fix for each failure",
+		run:     runPrecommit,
+	})
+}
+
+func runPrecommit(args []string) error {
+	fs := newFlagSet("precommit")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	checks := fs.String("checks", "headers,imports,error-mapper,gazelle", "Comma-separated checks to run: headers, imports, error-mapper and gazelle")
+	mappers := fs.String("mapper", "Sources/ErrorHandling/Mapping/SecurityErrorMapper.swift", "Comma-separated mapper files error-mapper checks when they, or their enums, are staged")
+	enums := fs.String("enums", "SecurityError", "Comma-separated error enum names whose variants the mappers must cover")
+	scope := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories searched for the enums")
+	gazelleBinary := fs.String("gazelle-binary", "bazel-bin/tools/gazelle/gazelle_binary_/gazelle_binary", "Built gazelle binary, relative to the project root; the gazelle check is skipped when it is missing")
+	gazelleTarget := fs.String("gazelle-target", "//tools/gazelle:gazelle", "Gazelle rule whose arguments the binary is run with")
+	format := fs.String("format", "text", "Output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	enabled := splitList(*checks)
+	for _, c := range enabled {
+		if !slices.Contains(precommit.Checks, c) {
+			return fmt.Errorf("unknown check %q", c)
+		}
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	files, err := precommit.ReadStaged(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	failures := []precommit.Failure{}
+	for _, check := range precommit.Checks {
+		if !slices.Contains(enabled, check) || len(files) == 0 {
+			continue
+		}
+		var found []precommit.Failure
+		switch check {
+		case precommit.CheckHeaders:
+			found = precommit.Headers(header.NewChecker(), files)
+		case precommit.CheckImports:
+			rules, err := moduleindex.Rules(projectRoot)
+			if err != nil {
+				return err
+			}
+			if found, err = precommit.Imports(rules, files); err != nil {
+				return err
+			}
+		case precommit.CheckErrorMapper:
+			if !precommit.TouchesErrorMapper(files, splitList(*mappers), splitList(*enums)) {
+				continue
+			}
+			_, issues, err := checkErrorMapper(projectRoot, splitList(*mappers), splitList(*enums), scopeList(*scope))
+			if err != nil {
+				return err
+			}
+			found = precommit.ErrorMapper(issues)
+		case precommit.CheckGazelle:
+			g, err := precommit.LoadGazelle(projectRoot, *gazelleTarget, *gazelleBinary)
+			if errors.Is(err, precommit.ErrNoGazelle) {
+				fmt.Fprintf(os.Stderr, "precommit: skipping gazelle: %v\n", err)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if found, err = g.Diff(runContext, projectRoot, precommit.TouchedDirs(files)); err != nil {
+				return err
+			}
+		}
+		failures = append(failures, found...)
+	}
+	elapsed := time.Since(start)
+
+	err = writeOutput("", func(w io.Writer) error {
+		switch *format {
+		case "text":
+			for _, f := range failures {
+				loc := f.File
+				if f.Line > 0 {
+					loc = fmt.Sprintf("%s:%d", f.File, f.Line)
+				}
+				fmt.Fprintf(w, "%s: %s: %s\n  fix: %s\n", loc, f.Check, f.Message, f.Fix)
+			}
+			_, err := fmt.Fprintf(w, "precommit: %d failures in %d staged files (%s)\n", len(failures), len(files), elapsed.Round(time.Millisecond))
+			return err
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(struct {
+				Files    int                 `json:"files"`
+				Failures []precommit.Failure `json:"failures"`
+				Elapsed  string              `json:"elapsed"`
+			}{len(files), failures, elapsed.Round(time.Millisecond).String()})
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if len(failures) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// cpuProfile is the file --cpuprofile writes to while the command runs.
+var cpuProfile *os.File
+
+// memProfile is --memprofile, the file the heap profile is written to when
+// the command has finished.
+var memProfile string
+
+// cpuProfileValue starts the CPU profile as soon as --cpuprofile is parsed,
+// so a command's own flag parsing is the only thing it misses.
+type cpuProfileValue struct{}
+
+func (cpuProfileValue) String() string {
+	if cpuProfile == nil {
+		return ""
+	}
+	return cpuProfile.Name()
+}
+
+func (cpuProfileValue) Set(p string) error {
+	if cpuProfile != nil {
+		return fmt.Errorf("CPU profile already written to %s", cpuProfile.Name())
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	cpuProfile = f
+	return nil
+}
+
+// stopProfiles finishes the CPU profile and writes the heap profile, if
+// either was asked for.
+func stopProfiles() error {
+	if cpuProfile != nil {
+		pprof.StopCPUProfile()
+		if err := cpuProfile.Close(); err != nil {
+			return err
+		}
+	}
+	if memProfile == "" {
+		return nil
+	}
+	f, err := os.Create(memProfile)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	if err := pprof.Lookup("allocs").WriteTo(f, 0); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
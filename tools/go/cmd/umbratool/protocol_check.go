@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/jsonl"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/protocols"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "protocol-check",
+		summary: "Check that protocol conformances implement every requirement, or report protocol coverage",
+		run:     runProtocolCheck,
+	})
+}
+
+func runProtocolCheck(args []string) error {
+	fs := newFlagSet("protocol-check")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", workspace.AllScopes, "Comma-separated scopes (sources, tests, testsupport) or directories to analyse")
+	srcsManifest := fs.String("srcs-manifest", "", "File listing the Swift files to check, one per line, instead of walking --scope (as a Bazel aspect writes it)")
+	depsManifest := fs.String("deps-manifest", "", "With --srcs-manifest, a file listing the Swift files of the dependencies, indexed to resolve their protocols but not checked")
+	configPath := fs.String("config", "protocolanalyzer.yaml", "Issue filter config, relative to the project root (optional)")
+	platform := fs.String("platform", "", "Only check code compiled for this platform, e.g. macOS or iOS (default: all branches)")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown, json or jsonl")
+	report := fs.String("report", "conformance", "Report: conformance (missing requirements) or coverage (conformers per protocol)")
+	strict := fs.Bool("strict", false, "Exit non-zero when error-severity issues (conformance) or dead protocols (coverage) are found")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *depsManifest != "" && *srcsManifest == "" {
+		return errors.New("--deps-manifest needs --srcs-manifest")
+	}
+	if *srcsManifest != "" && *report == "coverage" {
+		return errors.New("the coverage report needs every conformer; drop --srcs-manifest")
+	}
+
+	projectRoot, err := analysisRoot(*root, *srcsManifest)
+	if err != nil {
+		return err
+	}
+
+	config, err := protocols.LoadConfig(rootPath(projectRoot, *configPath))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var ix *protocols.Index
+	var checked map[string]bool
+	if *srcsManifest != "" {
+		paths, err := readManifest(*srcsManifest)
+		if err != nil {
+			return err
+		}
+		checked = make(map[string]bool, len(paths))
+		for _, p := range paths {
+			checked[p] = true
+		}
+		if *depsManifest != "" {
+			deps, err := readManifest(*depsManifest)
+			if err != nil {
+				return err
+			}
+			for _, p := range deps {
+				if !checked[p] {
+					paths = append(paths, p)
+				}
+			}
+		}
+		if ix, err = protocols.BuildFiles(projectRoot, paths); err != nil {
+			return err
+		}
+	} else if ix, err = protocols.Build(projectRoot, scopeList(*dirs)...); err != nil {
+		return err
+	}
+	opts := protocols.Options{Platform: *platform}
+	switch *report {
+	case "conformance":
+	case "coverage":
+		return protocolCoverage(fs, ix, opts, config, projectRoot, *output, *format, *strict, export)
+	default:
+		return fmt.Errorf("unknown report %q", *report)
+	}
+	issues := config.Apply(protocols.Check(ix, opts))
+	if checked != nil {
+		kept := issues[:0]
+		for _, i := range issues {
+			if checked[i.File] {
+				kept = append(kept, i)
+			}
+		}
+		issues = kept
+	}
+
+	annotations, err := newAnnotator(projectRoot)
+	if err != nil {
+		return err
+	}
+	failed := false
+	stored := make([]store.Issue, 0, len(issues))
+	for n, i := range issues {
+		issue := store.Issue{Module: i.Module, File: i.File, Line: i.Line, Kind: i.Kind, Message: i.Message, Symbol: protocolSymbol(i.Type, i.Protocol)}
+		annotations.annotate(&issue)
+		stored = append(stored, issue)
+		issues[n].Fingerprint = issue.Fingerprint
+		failed = failed || i.Severity == "error"
+	}
+
+	if *format == "jsonl" {
+		err = writeJSONL(fs, projectRoot, *output, func(w *jsonl.Writer) (any, error) {
+			summary := struct {
+				Issues   int `json:"issues"`
+				Blocking int `json:"blocking"`
+			}{Issues: len(issues)}
+			for n, i := range issues {
+				blocking := annotations.blocking(stored[n], *strict && i.Severity == "error")
+				if blocking {
+					summary.Blocking++
+				}
+				record := struct {
+					protocols.Issue
+					Tier     string `json:"tier,omitempty"`
+					Blocking bool   `json:"blocking"`
+				}{i, stored[n].Tier, blocking}
+				if err := w.Write("issue", record); err != nil {
+					return nil, err
+				}
+			}
+			return summary, nil
+		})
+	} else {
+		err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+			switch *format {
+			case "markdown":
+				return protocols.WriteMarkdown(w, ix, issues, opts)
+			case "json":
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				return enc.Encode(issues)
+			default:
+				return fmt.Errorf("unknown format %q", *format)
+			}
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	err = export.record("protocol-check", projectRoot, func(s *metrics.Set) {
+		counts := make(map[[2]string]int)
+		for _, i := range issues {
+			counts[[2]string{i.Module, i.Kind}]++
+		}
+		for key, n := range counts {
+			s.Gauge("protocol_issues", "Protocol conformance issues per module by kind.", float64(n), "module", key[0], "kind", key[1])
+		}
+	}, stored)
+	if err != nil {
+		return err
+	}
+
+	if *strict && failed {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// protocolCoverage writes the coverage report: the conformers of every
+// protocol, with dead and single-conformer protocols called out.
+func protocolCoverage(fs *flag.FlagSet, ix *protocols.Index, opts protocols.Options, config *protocols.Config, projectRoot, output, format string, strict bool, export resultFlags) error {
+	coverage := protocols.ComputeCoverage(ix, opts, config)
+
+	annotations, err := newAnnotator(projectRoot)
+	if err != nil {
+		return err
+	}
+	dead := 0
+	var stored []store.Issue
+	annotated := make([]*store.Issue, len(coverage))
+	for n, c := range coverage {
+		var issue store.Issue
+		switch c.Status {
+		case protocols.CoverageDead:
+			dead++
+			issue = store.Issue{Module: c.Module, File: c.File, Line: c.Line, Kind: "dead_protocol",
+				Message: c.Protocol + " has no conformers", Symbol: c.Protocol}
+		case protocols.CoverageSingle:
+			issue = store.Issue{Module: c.Module, File: c.File, Line: c.Line, Kind: "single_conformer",
+				Message: c.Protocol + " is only adopted by " + c.Conformers[0].Type, Symbol: c.Protocol}
+		default:
+			continue
+		}
+		annotations.annotate(&issue)
+		annotated[n] = &issue
+		coverage[n].Fingerprint = issue.Fingerprint
+		stored = append(stored, issue)
+	}
+
+	if format == "jsonl" {
+		err = writeJSONL(fs, projectRoot, output, func(w *jsonl.Writer) (any, error) {
+			counts := make(map[string]int)
+			for n, c := range coverage {
+				counts[c.Status]++
+				record := struct {
+					protocols.Coverage
+					Tier     string `json:"tier,omitempty"`
+					Blocking bool   `json:"blocking"`
+				}{Coverage: c}
+				if issue := annotated[n]; issue != nil {
+					record.Tier = issue.Tier
+					record.Blocking = annotations.blocking(*issue, strict && c.Status == protocols.CoverageDead)
+				}
+				if err := w.Write("protocol", record); err != nil {
+					return nil, err
+				}
+			}
+			return struct {
+				Protocols int            `json:"protocols"`
+				Statuses  map[string]int `json:"statuses"`
+			}{len(coverage), counts}, nil
+		})
+	} else {
+		err = writeReport(fs, projectRoot, output, format, func(w io.Writer) error {
+			switch format {
+			case "markdown":
+				return protocols.WriteCoverageMarkdown(w, coverage, opts)
+			case "json":
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				return enc.Encode(coverage)
+			default:
+				return fmt.Errorf("unknown format %q", format)
+			}
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	err = export.record("protocol-coverage", projectRoot, func(s *metrics.Set) {
+		counts := make(map[[2]string]int)
+		for _, c := range coverage {
+			counts[[2]string{c.Module, c.Status}]++
+		}
+		for key, n := range counts {
+			s.Gauge("protocols", "Protocols per module by coverage status.", float64(n), "module", key[0], "status", key[1])
+		}
+	}, stored)
+	if err != nil {
+		return err
+	}
+
+	if strict && dead > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// protocolSymbol names what a conformance issue is about: the conforming
+// type's adoption of the protocol, or the protocol alone.
+func protocolSymbol(typ, protocol string) string {
+	if typ == "" {
+		return protocol
+	}
+	return typ + ": " + protocol
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+const queryUsage = "usage: umbratool query <runs | trend | regressions | issues> [flags]"
+
+func init() {
+	register(command{
+		name:    "query",
+		summary: "Canned reports over the SQLite result store (runs, trend, regressions, issues)",
+		run:     runQuery,
+	})
+}
+
+func runQuery(args []string) error {
+	if len(args) == 0 {
+		return errors.New(queryUsage)
+	}
+	report := args[0]
+
+	fs := newFlagSet("query " + report)
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dbPath := fs.String("store", "results.db", "SQLite result store, relative to --out-dir when set, otherwise to the project root")
+	tool := fs.String("tool", "", "Restrict to runs of one analyzer, e.g. complexity (required for issues)")
+	metric := fs.String("metric", "", "Metric name without the umbracore_ prefix, or \"issues\" (required for trend)")
+	module := fs.String("module", "", "Restrict a trend to one module")
+	from := fs.String("from", "", "Base commit (SHA or prefix) for regressions and issues")
+	to := fs.String("to", "", "Compared commit (SHA or prefix) for regressions and issues")
+	limit := fs.Int("limit", 20, "Maximum runs (runs, trend) or rows (regressions, issues) shown; 0 for all")
+	jsonOut := fs.Bool("json", false, "Print results as JSON")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	path := resultPath(projectRoot, *dbPath)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("result store: %w", err)
+	}
+
+	db, err := store.Open(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := runContext
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer tw.Flush()
+
+	switch report {
+	case "runs":
+		runs, err := db.Runs(ctx, *tool, *limit)
+		if err != nil || *jsonOut {
+			return printJSON(runs, err)
+		}
+		fmt.Fprintln(tw, "ID\tTOOL\tTIMESTAMP\tCOMMIT\tMETRICS\tISSUES")
+		for _, r := range runs {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%d\n", r.ID, r.Tool, r.Timestamp.Local().Format("2006-01-02 15:04"), shortSHA(r.GitSHA), r.Metrics, r.Issues)
+		}
+
+	case "trend":
+		if *metric == "" {
+			return errors.New("trend requires --metric")
+		}
+		points, err := db.Trend(ctx, *tool, *metric, *module, *limit)
+		if err != nil || *jsonOut {
+			return printJSON(points, err)
+		}
+		fmt.Fprintln(tw, "TIMESTAMP\tCOMMIT\tTOOL\tMODULE\tVALUE")
+		for _, p := range points {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%g\n", p.Timestamp.Local().Format("2006-01-02 15:04"), shortSHA(p.GitSHA), p.Tool, p.Module, p.Value)
+		}
+
+	case "regressions":
+		if *from == "" || *to == "" {
+			return errors.New("regressions requires --from and --to")
+		}
+		deltas, err := db.Regressions(ctx, *from, *to, *metric)
+		if err != nil {
+			return err
+		}
+		if *tool != "" {
+			kept := deltas[:0]
+			for _, d := range deltas {
+				if d.Tool == *tool {
+					kept = append(kept, d)
+				}
+			}
+			deltas = kept
+		}
+		if *limit > 0 && len(deltas) > *limit {
+			deltas = deltas[:*limit]
+		}
+		if *jsonOut {
+			return printJSON(deltas, nil)
+		}
+		fmt.Fprintln(tw, "TOOL\tMETRIC\tMODULE\tFROM\tTO\tCHANGE")
+		for _, d := range deltas {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%g\t%g\t%+g\n", d.Tool, d.Metric, d.Module, d.From, d.To, d.Change)
+		}
+
+	case "issues":
+		if *tool == "" {
+			return errors.New("issues requires --tool")
+		}
+		if (*from == "") != (*to == "") {
+			return errors.New("issues compares two commits: give both --from and --to, or neither")
+		}
+		var changes []store.IssueChange
+		if *from != "" {
+			if changes, err = db.IssueChanges(ctx, *tool, *from, *to); err != nil {
+				return err
+			}
+		} else {
+			runs, err := db.Runs(ctx, *tool, 1)
+			if err != nil {
+				return err
+			}
+			if len(runs) == 0 {
+				return fmt.Errorf("no %s runs recorded", *tool)
+			}
+			issues, err := db.RunIssues(ctx, runs[0].ID, "")
+			if err != nil {
+				return err
+			}
+			for _, i := range issues {
+				changes = append(changes, store.IssueChange{Issue: i})
+			}
+		}
+		if *limit > 0 && len(changes) > *limit {
+			changes = changes[:*limit]
+		}
+		if *jsonOut {
+			return printJSON(changes, nil)
+		}
+		header := "FINGERPRINT\tKIND\tLOCATION\tMESSAGE"
+		if *from != "" {
+			header = "CHANGE\t" + header
+		}
+		fmt.Fprintln(tw, header)
+		for _, c := range changes {
+			loc := c.File
+			if c.Line > 0 {
+				loc = fmt.Sprintf("%s:%d", c.File, c.Line)
+			}
+			if *from != "" {
+				fmt.Fprintf(tw, "%s\t", c.Change)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Fingerprint, c.Kind, loc, c.Message)
+		}
+
+	default:
+		return fmt.Errorf("unknown report %q\n%s", report, queryUsage)
+	}
+	return nil
+}
+
+func printJSON(v any, err error) error {
+	if err != nil {
+		return err
+	}
+	return writeOutput("", func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	})
+}
+
+// shortSHA abbreviates a recorded commit, keeping any "-dirty" suffix.
+func shortSHA(sha string) string {
+	base, suffix, dirty := strings.Cut(sha, "-")
+	if len(base) > 12 {
+		base = base[:12]
+	}
+	if dirty {
+		return base + "-" + suffix
+	}
+	return base
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/progress"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "refactor-progress",
+		summary: "Report per-item status of the machine-readable refactoring plan",
+		run:     runRefactorProgress,
+	})
+}
+
+func runRefactorProgress(args []string) error {
+	fs := newFlagSet("refactor-progress")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	planPath := fs.String("plan", "refactoring_plan.yaml", "Refactoring plan, relative to the project root")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+
+	plan, err := progress.LoadPlan(rootPath(projectRoot, *planPath))
+	if err != nil {
+		return err
+	}
+
+	statuses, err := progress.Measure(projectRoot, plan)
+	if err != nil {
+		return err
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return progress.WriteMarkdown(w, plan.Title, statuses)
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(statuses)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return export.record("refactor-progress", projectRoot, func(s *metrics.Set) {
+		for _, st := range statuses {
+			for _, state := range []progress.State{progress.StateDone, progress.StateInProgress, progress.StateNotStarted} {
+				s.Gauge("refactor_item_state", "1 for the current state of each refactoring plan item.", boolGauge(st.State == state), "item", st.Item.ID, "state", string(state))
+			}
+			s.Gauge("refactor_remaining_modules", "Source modules of a plan item that still exist.", float64(len(st.RemainingModules)), "item", st.Item.ID)
+			s.Gauge("refactor_importing_files", "Files still importing a plan item's source modules.", float64(st.ImportingFiles), "item", st.Item.ID)
+			s.Gauge("refactor_build_references", "BUILD files still depending on a plan item's source modules.", float64(st.BuildReferences), "item", st.Item.ID)
+		}
+	}, nil)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/refactorqueue"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "refactor-queue",
+		summary: "Rank files for refactoring by complexity, duplication, fan-in, untested methods and churn",
+		run:     runRefactorQueue,
+	})
+}
+
+func runRefactorQueue(args []string) error {
+	fs := newFlagSet("refactor-queue")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to rank")
+	weightSpec := fs.String("weights", "", "Comma-separated factor=weight overrides, e.g. churn=3,tests=0")
+	since := fs.String("since", "", "Count churn from this date (YYYY-MM-DD; default: the whole history)")
+	top := fs.Int("top", 25, "Number of candidates listed in the Markdown report and recorded by --store")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	weights, err := refactorqueue.ParseWeights(*weightSpec)
+	if err != nil {
+		return err
+	}
+	var from time.Time
+	if *since != "" {
+		if from, err = time.Parse("2006-01-02", *since); err != nil {
+			return fmt.Errorf("--since: %w", err)
+		}
+	}
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	in, err := refactorqueue.Gather(projectRoot, refactorqueue.Options{Dirs: scopeList(*dirs), Rules: rules, Since: from})
+	if err != nil {
+		return err
+	}
+	queue := refactorqueue.Rank(in, weights)
+
+	head := queue
+	if *top > 0 && len(head) > *top {
+		head = head[:*top]
+	}
+	issues := make([]store.Issue, 0, len(head))
+	for _, c := range head {
+		var why []string
+		for _, f := range c.Why(2) {
+			why = append(why, fmt.Sprintf("%s +%.1f", f, c.Points[f]))
+		}
+		message := fmt.Sprintf("refactoring priority %.1f (rank %d): %s", c.Priority, c.Rank, strings.Join(why, ", "))
+		issues = append(issues, store.Issue{Module: c.Module, File: c.File, Kind: "refactor_candidate", Message: message})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i := range head {
+		head[i].Fingerprint = issues[i].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return refactorqueue.WriteMarkdown(w, queue, weights, *top)
+		case "json":
+			return refactorqueue.WriteJSON(w, queue)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return export.record("refactor-queue", projectRoot, func(s *metrics.Set) {
+		s.Gauge("refactor_candidates", "Files with branching functions or duplicated blocks.", float64(len(queue)))
+		for _, c := range head {
+			s.Gauge("refactor_priority", "Weighted refactoring priority of the top candidates (0-100).", c.Priority, "module", c.Module, "file", c.File)
+		}
+	}, issues)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/apidump"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/release"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/secrets"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/worktree"
+)
+
+func init() {
+	register(command{
+		name:    "release-check",
+		summary: "Run the blocking release gates against a release branch and write a signed-off Markdown checklist",
+		run:     runReleaseCheck,
+	})
+}
+
+func runReleaseCheck(args []string) error {
+	fs := newFlagSet("release-check")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	branch := fs.String("branch", "", "Release branch or other revision to check, in a temporary worktree (default: the work tree)")
+	base := fs.String("base", "origin/main", "Git revision whose public API the release must stay compatible with, such as the last release tag")
+	gates := fs.String("gates", strings.Join(release.Gates, ","), "Comma-separated gates to run")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories the gates check")
+	mappers := fs.String("mapper", "Sources/ErrorHandling/Mapping/SecurityErrorMapper.swift", "Comma-separated mapper files the error-mapper gate checks")
+	enums := fs.String("enums", "SecurityError", "Comma-separated error enum names whose variants the mappers must cover")
+	signer := fs.String("signer", "", "Name to sign the checklist off with when every gate passes")
+	output := fs.String("output", "", "Checklist file (default: stdout)")
+	format := fs.String("format", "markdown", "Checklist format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	selected, err := release.ParseGates(splitList(*gates))
+	if err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	checked := release.Checklist{Branch: *branch, Commit: store.GitSHA(projectRoot), Checked: reportTime()}
+	gateRoot := projectRoot
+	if *branch == "" {
+		checked.Branch = "work tree"
+	} else {
+		tree, err := worktree.Checkout(projectRoot, *branch)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := tree.Remove(); err != nil {
+				fmt.Fprintf(os.Stderr, "umbratool: removing the worktree of %s: %v\n", *branch, err)
+			}
+		}()
+		gateRoot, checked.Commit = tree.Root, tree.Commit
+	}
+
+	scope := scopeList(*dirs)
+	var (
+		rules []modulenames.Rule
+		files []imports.File
+	)
+	// importGraph reads the rules and imports the layering and foundation
+	// gates share, once.
+	importGraph := func() error {
+		if rules != nil {
+			return nil
+		}
+		var err error
+		if files, err = imports.ScanTree(gateRoot, scope...); err != nil {
+			return err
+		}
+		rules, err = moduleindex.Rules(gateRoot)
+		return err
+	}
+	for _, gate := range selected {
+		var r release.Result
+		switch gate {
+		case release.GateCompat:
+			checked.Base = *base
+			r, err = compatGate(gateRoot, *base, checked.Branch, scope)
+		case release.GateLayering:
+			if err = importGraph(); err == nil {
+				r = release.Layering(rules, files)
+			}
+		case release.GateFoundation:
+			if err = importGraph(); err == nil {
+				r = release.Foundation(rules, files)
+			}
+		case release.GateSecrets:
+			r, err = secretsGate(gateRoot)
+		case release.GateErrorMapper:
+			r, err = errorMapperGate(gateRoot, splitList(*mappers), splitList(*enums), scope)
+		}
+		if err != nil {
+			r = release.Failed(gate, err)
+		}
+		checked.Results = append(checked.Results, r)
+	}
+	checked.SignOff(*signer)
+
+	var issues []store.Issue
+	for _, r := range checked.Results {
+		if !r.Passed {
+			issues = append(issues, store.Issue{Kind: "release-gate", Message: r.Gate + ": " + r.Summary, Symbol: r.Gate})
+		}
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	i := 0
+	for n, r := range checked.Results {
+		if !r.Passed {
+			checked.Results[n].Fingerprint = issues[i].Fingerprint
+			i++
+		}
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return release.WriteMarkdown(w, &checked)
+		case "json":
+			return release.WriteJSON(w, &checked)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("release-check", projectRoot, func(s *metrics.Set) {
+		for _, r := range checked.Results {
+			passed := 0.0
+			if r.Passed {
+				passed = 1
+			}
+			s.Gauge("release_gate_passed", "Whether each release gate passed (1) or failed (0).", passed, "gate", r.Gate)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if checked.Failures() > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// compatGate compares the public API below scope with base's. Breaking
+// changes fail the gate unless the version says a release that allows
+// them.
+func compatGate(root, base, head string, scope []string) (release.Result, error) {
+	baseAPI, baseVersion, err := dumpAPI(root, base, scope)
+	if err != nil {
+		return release.Result{}, err
+	}
+	headAPI, headVersion, err := dumpAPI(root, "", scope)
+	if err != nil {
+		return release.Result{}, err
+	}
+	report := apidump.NewReport(base, head, baseAPI, headAPI, baseVersion, headVersion)
+	breaking := report.Breaking()
+	summary := fmt.Sprintf("%d changes, %d breaking, version %s to %s", len(report.Changes), len(breaking), baseVersion, headVersion)
+	if report.Bumped {
+		return release.NewResult(release.GateCompat, summary+", bumped for a "+report.Required+" release", nil), nil
+	}
+	var findings []string
+	for _, c := range breaking {
+		findings = append(findings, fmt.Sprintf("%s.%s %s", c.Module, c.Name, c.Reason))
+	}
+	return release.NewResult(release.GateCompat, summary, findings), nil
+}
+
+// secretsGate scans root for secrets its baseline does not accept.
+func secretsGate(root string) (release.Result, error) {
+	baseline, err := secrets.LoadBaseline(rootPath(root, secrets.BaselineFile))
+	if err != nil {
+		return release.Result{}, err
+	}
+	found, err := secrets.Scan(root, secrets.Options{})
+	if err != nil {
+		return release.Result{}, err
+	}
+	fresh := baseline.Filter(found)
+	var findings []string
+	for _, f := range fresh {
+		findings = append(findings, fmt.Sprintf("%s:%d: %s: %s", f.File, f.Line, f.Rule, f.Match))
+	}
+	return release.NewResult(release.GateSecrets, fmt.Sprintf("%d findings, %d accepted by the baseline", len(found), len(found)-len(fresh)), findings), nil
+}
+
+// errorMapperGate checks the mappers against the enums found below scope.
+func errorMapperGate(root string, mappers, enums, scope []string) (release.Result, error) {
+	coverage, issues, err := checkErrorMapper(root, mappers, enums, scope)
+	if err != nil {
+		return release.Result{}, err
+	}
+	var findings []string
+	for _, i := range issues {
+		findings = append(findings, fmt.Sprintf("%s:%d: %s", i.File, i.Line, i.Message))
+	}
+	return release.NewResult(release.GateErrorMapper, fmt.Sprintf("%d mapper switches checked", len(coverage)), findings), nil
+}
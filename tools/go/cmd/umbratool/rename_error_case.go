@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/caserename"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/changelog"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errorreport"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "rename-error-case",
+		summary: "Rename an error enum case, or map it onto another case, and record the rename in the migration plan",
+		run:     runRenameErrorCase,
+	})
+}
+
+func runRenameErrorCase(args []string) error {
+	fs := newFlagSet("rename-error-case")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	enum := fs.String("enum", "", "Error enum declaring the case (required)")
+	module := fs.String("module", "", "Module declaring the enum, when several modules declare one of that name")
+	file := fs.String("file", "", "File declaring the enum, when several files declare one of that name")
+	from := fs.String("from", "", "Case to rename (required)")
+	to := fs.String("to", "", "New name of the case, or with --map the case to map it onto (required)")
+	mapCase := fs.Bool("map", false, "Fold the case into the existing case --to, removing its declaration")
+	dirs := fs.String("scope", workspace.AllScopes, "Comma-separated scopes (sources, tests, testsupport) or directories to rename in")
+	planPath := fs.String("plan", "", "Record the rename in this error_migrator migration config, e.g. tools/error_migrator/migration_config.json")
+	dryRun := fs.Bool("dry-run", false, "Print the changes as a unified diff instead of writing them")
+	keepBackup := fs.Bool("backup", true, "Back up the changed files first, for the restore command")
+	noteChange := fs.Bool("changelog", true, "Record the applied change as a fragment in changelog.d, for changelog assemble")
+	lint := addSwiftLintFlags(fs, false)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *enum == "" || *from == "" || *to == "" {
+		return errors.New("--enum, --from and --to are required")
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	scope := scopeList(*dirs)
+	analysis, err := errorreport.Analyse(projectRoot, scope...)
+	if err != nil {
+		return err
+	}
+	plan, err := caserename.Rename(projectRoot, analysis, caserename.Options{Enum: *enum, From: *from, To: *to, Module: *module, File: *file, Map: *mapCase, Dirs: scope})
+	if err != nil {
+		return err
+	}
+	for _, w := range plan.Warnings {
+		fmt.Fprintf(os.Stderr, "rename-error-case: %s\n", w)
+	}
+
+	var change *changelog.Fragment
+	if *noteChange {
+		change = &changelog.Fragment{
+			Kind:      changelog.KindErrorMigration,
+			Summary:   fmt.Sprintf("Renamed error case `%s.%s` to `%s`", *enum, *from, *to),
+			Migration: []string{fmt.Sprintf("Replace `.%s` with `.%s` in constructions and patterns of `%s`", *from, *to, *enum)},
+		}
+		if *mapCase {
+			change.Summary = fmt.Sprintf("Folded error case `%s.%s` into `%s`", *enum, *from, *to)
+			change.Migration = append(change.Migration, fmt.Sprintf("A `switch` over `%s` no longer has a `.%s` branch; its values are matched by `.%s`", *enum, *from, *to))
+		}
+	}
+	touched, err := applyRewrite(projectRoot, "rename-error-case", plan, *dryRun, *keepBackup, change)
+	if err != nil {
+		return err
+	}
+	if *planPath != "" && *dryRun {
+		fmt.Printf("Would record %s.%s → %s in %s\n", *enum, *from, *to, *planPath)
+	} else if *planPath != "" {
+		file := rootPath(projectRoot, *planPath)
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		recorded, err := caserename.Record(data, *enum, *from, *to)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *planPath, err)
+		}
+		if err := atomicfile.WriteFile(file, recorded, 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("Recorded %s.%s → %s in %s\n", *enum, *from, *to, *planPath)
+	}
+	_, err = lint.fixTouched(projectRoot, touched)
+	return err
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/changelog"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/protorename"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "rename-protocol",
+		summary: "Rename a Swift protocol with its conformances, type annotations, generic constraints and comments",
+		run:     runRenameProtocol,
+	})
+}
+
+func runRenameProtocol(args []string) error {
+	fs := newFlagSet("rename-protocol")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	from := fs.String("from", "", "Protocol to rename (required)")
+	to := fs.String("to", "", "New name, which must not be declared yet (required)")
+	dirs := fs.String("scope", workspace.AllScopes, "Comma-separated scopes (sources, tests, testsupport) or directories to rename in")
+	dryRun := fs.Bool("dry-run", false, "Print the changes as a unified diff instead of writing them")
+	keepBackup := fs.Bool("backup", true, "Back up the changed files first, for the restore command")
+	noteChange := fs.Bool("changelog", true, "Record the applied change as a fragment in changelog.d, for changelog assemble")
+	lint := addSwiftLintFlags(fs, false)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" {
+		return errors.New("--from and --to are required")
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	plan, err := protorename.Rename(projectRoot, protorename.Options{From: *from, To: *to, Dirs: scopeList(*dirs), Rules: rules})
+	if err != nil {
+		return err
+	}
+	for _, w := range plan.Warnings {
+		fmt.Fprintf(os.Stderr, "rename-protocol: %s\n", w)
+	}
+
+	var change *changelog.Fragment
+	if *noteChange {
+		change = &changelog.Fragment{
+			Kind:      changelog.KindRename,
+			Summary:   fmt.Sprintf("Renamed protocol `%s` to `%s`", *from, *to),
+			Migration: []string{fmt.Sprintf("Replace `%s` with `%s` in conformances, type annotations and generic constraints", *from, *to)},
+		}
+	}
+	touched, err := applyRewrite(projectRoot, "rename-protocol", plan, *dryRun, *keepBackup, change)
+	if err != nil {
+		return err
+	}
+	_, err = lint.fixTouched(projectRoot, touched)
+	return err
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/reportdiff"
+)
+
+func init() {
+	register(command{
+		name:    "report-diff",
+		summary: "Compare two JSON reports from one analyzer: new and resolved issues and changed figures",
+		run:     runReportDiff,
+	})
+}
+
+func runReportDiff(args []string) error {
+	fs := newFlagSet("report-diff")
+	threshold := fs.Float64("threshold", 5, "Smallest change, in percent, for a figure to be listed")
+	limit := fs.Int("limit", 50, "Maximum number of figure changes listed in the Markdown report (0 for all)")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	strict := fs.Bool("strict", false, "Exit non-zero when the newer report has new issues")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("usage: umbratool report-diff [flags] <old.json> <new.json>")
+	}
+
+	before, err := reportdiff.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	after, err := reportdiff.Load(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	diff, err := reportdiff.Compare(before, after, *threshold)
+	if err != nil {
+		return err
+	}
+
+	err = writeReport(fs, ".", *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return reportdiff.WriteMarkdown(w, diff, *limit)
+		case "json":
+			return reportdiff.WriteJSON(w, diff)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if *strict && len(diff.Added) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
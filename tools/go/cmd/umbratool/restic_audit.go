@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/resticaudit"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "restic-audit",
+		summary: "Check restic invocations and the arguments built for them against the restic policy",
+		run:     runResticAudit,
+	})
+}
+
+func runResticAudit(args []string) error {
+	fs := newFlagSet("restic-audit")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to analyse")
+	policyPath := fs.String("policy", resticaudit.PolicyFile, "Policy file, relative to the project root (default policy when absent)")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	strict := fs.Bool("strict", false, "Exit non-zero when any call site breaks the policy")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	policy, err := resticaudit.LoadPolicy(rootPath(projectRoot, *policyPath))
+	if os.IsNotExist(err) {
+		policy, err = &resticaudit.DefaultPolicy, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	report, err := resticaudit.Scan(projectRoot, policy, scopeList(*dirs)...)
+	if err != nil {
+		return err
+	}
+
+	stored := make([]store.Issue, 0, len(report.Issues))
+	for _, i := range report.Issues {
+		stored = append(stored, store.Issue{Module: i.Module, File: i.File, Line: i.Line, Kind: i.Kind, Message: i.Message})
+	}
+	if err := annotateIssues(projectRoot, stored); err != nil {
+		return err
+	}
+	for n := range report.Issues {
+		report.Issues[n].Fingerprint = stored[n].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return resticaudit.WriteMarkdown(w, report)
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("restic-audit", projectRoot, func(s *metrics.Set) {
+		for _, i := range report.Issues {
+			s.Add("restic_policy_issues", "Restic invocation policy violations per module by kind.", 1, "module", i.Module, "kind", i.Kind)
+		}
+		s.Gauge("restic_call_sites", "Process call sites launching restic.", float64(len(report.Sites)))
+	}, stored)
+	if err != nil {
+		return err
+	}
+
+	if *strict && len(report.Issues) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/backup"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "restore",
+		summary: "Restore workspace files from a tool backup directory",
+		run:     runRestore,
+	})
+}
+
+func runRestore(args []string) error {
+	fs := newFlagSet("restore")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	from := fs.String("from", "", "Backup directory to restore from, below --out-dir when one is set (required)")
+	apply := fs.Bool("apply", false, "Restore files (default: only report what would be restored)")
+	force := fs.Bool("force", false, "Also overwrite files modified since the backup was taken")
+	verbose := fs.Bool("verbose", false, "List unchanged files too")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" {
+		return errors.New("--from is required")
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	dir, err := filepath.Abs(backupDir(projectRoot, *from))
+	if err != nil {
+		return err
+	}
+
+	items, err := backup.Plan(projectRoot, dir)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[backup.Action]int)
+	for _, item := range items {
+		counts[item.Action]++
+		if item.Action != backup.ActionUnchanged || *verbose {
+			fmt.Printf("%-9s %s (%s)\n", item.Action, item.Path, item.Reason)
+		}
+	}
+	fmt.Printf("\n%d files: %d to restore, %d modified since backup, %d unchanged, %d corrupt\n",
+		len(items), counts[backup.ActionRestore], counts[backup.ActionConflict], counts[backup.ActionUnchanged], counts[backup.ActionCorrupt])
+
+	if !*apply {
+		fmt.Println("Dry run: pass --apply to restore")
+		return nil
+	}
+
+	run := beginAudit(projectRoot, "restore")
+	run.setBackup(dir)
+	var files []string
+	for _, item := range items {
+		if item.Action == backup.ActionRestore || (*force && item.Action == backup.ActionConflict) {
+			files = append(files, item.Path)
+		}
+	}
+	restored, err := backup.Apply(projectRoot, dir, items, *force)
+	if err != nil {
+		// Apply restores the files in order and stops at the one it failed
+		// on, which may be half written.
+		return run.failed(files[:min(restored+1, len(files))], err)
+	}
+	fmt.Printf("Restored %d files\n", restored)
+	if err := run.done(files); err != nil {
+		return err
+	}
+
+	if counts[backup.ActionConflict] > 0 && !*force {
+		fmt.Printf("%d modified files were left untouched; pass --force to overwrite them\n", counts[backup.ActionConflict])
+		return errCheckFailed
+	}
+	if counts[backup.ActionCorrupt] > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// backupDir resolves the --from directory: below --out-dir, where the
+// mutating commands write their backups when one is set, falling back to
+// the project root for backups taken without it.
+func backupDir(root, from string) string {
+	dir := resultPath(root, from)
+	if outDir == "" || filepath.IsAbs(from) {
+		return dir
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return rootPath(root, from)
+	}
+	return dir
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/backup"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/changelog"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/importrewrite"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textdiff"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "rewrite-imports",
+		summary: "Rewrite Swift imports and Bazel deps from one module to another, following a mapping file",
+		run:     runRewriteImports,
+	})
+}
+
+func runRewriteImports(args []string) error {
+	fs := newFlagSet("rewrite-imports")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	mapPath := fs.String("map", "", "Mapping file listing the rewrites (required)")
+	dirs := fs.String("scope", workspace.AllScopes, "Comma-separated scopes (sources, tests, testsupport) or directories to rewrite")
+	modules := fs.String("modules", "", "Comma-separated module globs to limit every rewrite to (default: all modules)")
+	deps := fs.Bool("deps", true, "Also update the deps of the Bazel targets whose files are rewritten")
+	dryRun := fs.Bool("dry-run", false, "Print the changes as a unified diff instead of writing them")
+	keepBackup := fs.Bool("backup", true, "Back up the changed files first, for the restore command")
+	noteChange := fs.Bool("changelog", true, "Record the applied change as a fragment in changelog.d, for changelog assemble")
+	lint := addSwiftLintFlags(fs, false)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *mapPath == "" {
+		return errors.New("--map is required")
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	config, err := importrewrite.LoadConfig(rootPath(projectRoot, *mapPath))
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	plan, err := importrewrite.Rewrite(projectRoot, config, importrewrite.Options{
+		Dirs:    scopeList(*dirs),
+		Rules:   rules,
+		Modules: splitList(*modules),
+		Deps:    *deps,
+	})
+	if err != nil {
+		return err
+	}
+	for _, w := range plan.Warnings {
+		fmt.Fprintf(os.Stderr, "rewrite-imports: %s\n", w)
+	}
+
+	var change *changelog.Fragment
+	if *noteChange {
+		change = importsChange(config)
+	}
+	touched, err := applyRewrite(projectRoot, "rewrite-imports", plan, *dryRun, *keepBackup, change)
+	if err != nil {
+		return err
+	}
+	_, err = lint.fixTouched(projectRoot, touched)
+	return err
+}
+
+// importsChange describes the rewrites of config for the changelog: the
+// imports of each module moved to the module taking it over.
+func importsChange(config *importrewrite.Config) *changelog.Fragment {
+	f := &changelog.Fragment{Kind: changelog.KindConsolidation}
+	var moves []string
+	for _, m := range config.Rewrites {
+		moves = append(moves, fmt.Sprintf("`%s` to `%s`", m.From, m.To))
+		f.Modules = append(f.Modules, m.From, m.To)
+		note := fmt.Sprintf("Replace `import %s` with `import %s`", m.From, m.To)
+		if len(m.KeepIfUsing) > 0 {
+			note += fmt.Sprintf(", and keep importing `%s` where `%s` is used", m.From, strings.Join(m.KeepIfUsing, "`, `"))
+		}
+		f.Migration = append(f.Migration, note)
+	}
+	f.Summary = "Moved the imports of " + strings.Join(moves, ", ")
+	return f
+}
+
+// applyRewrite writes the changes of plan, backing the files up first
+// for the restore command when keepBackup is set, or with dryRun prints
+// them as a unified diff instead. tool names the backup, which is written
+// below --out-dir when one is set and in the project root otherwise. A
+// non-nil change is recorded as a changelog fragment once the plan is
+// written, with the modules of the rewritten Swift files when it names
+// none. It returns the Swift files written.
+func applyRewrite(projectRoot, tool string, plan *importrewrite.Plan, dryRun, keepBackup bool, change *changelog.Fragment) ([]string, error) {
+	changes := append(plan.Swift, plan.Build...)
+	if dryRun {
+		for _, c := range changes {
+			fmt.Print(textdiff.Unified(c.Path, c.Before, c.After, 3))
+		}
+		fmt.Printf("%d Swift files and %d BUILD files would change\n", len(plan.Swift), len(plan.Build))
+		return nil, nil
+	}
+	if len(changes) == 0 {
+		fmt.Println("Nothing to rewrite")
+		return nil, nil
+	}
+
+	run := beginAudit(projectRoot, tool)
+	var b *backup.Backup
+	from := ""
+	if keepBackup {
+		name := backup.DirName(tool, time.Now())
+		dir, err := filepath.Abs(resultPath(projectRoot, name))
+		if err != nil {
+			return nil, err
+		}
+		if b, err = backup.New(projectRoot, dir, tool); err != nil {
+			return nil, err
+		}
+		run.setBackup(b.Dir)
+		from = run.backup
+		if outDir != "" {
+			from = name
+		}
+	}
+	written, applyErr := plan.Apply(runContext, projectRoot, b)
+	if b != nil {
+		if err := b.Close(); err != nil && applyErr == nil {
+			applyErr = err
+		}
+	}
+	if applyErr != nil {
+		// Apply has put back what it wrote, but a run that got that far
+		// is still recorded, with why it stopped.
+		return nil, run.failed(written, applyErr)
+	}
+
+	touched := make([]string, 0, len(plan.Swift))
+	for _, c := range changes {
+		fmt.Printf("rewrote %s (%s)\n", c.Path, strings.Join(c.Edits, "; "))
+	}
+	for _, c := range plan.Swift {
+		touched = append(touched, c.Path)
+	}
+	fmt.Printf("%d Swift files and %d BUILD files rewritten\n", len(plan.Swift), len(plan.Build))
+	if b != nil {
+		fmt.Printf("Backup in %s; undo with: umbratool restore --from %s --apply\n", run.backup, from)
+	}
+	if change != nil {
+		f := *change
+		f.Tool = tool
+		if len(f.Modules) == 0 {
+			f.Modules = changedModules(plan.Swift)
+		}
+		path, err := changelog.Write(filepath.Join(projectRoot, changelog.Dir), f)
+		if err != nil {
+			return nil, run.failed(written, fmt.Errorf("recording the change in %s: %w", changelog.Dir, err))
+		}
+		written = append(written, path)
+		if rel, err := filepath.Rel(projectRoot, path); err == nil {
+			path = rel
+		}
+		fmt.Printf("Recorded the change in %s\n", filepath.ToSlash(path))
+	}
+	return touched, run.done(written)
+}
+
+// changedModules returns the sorted modules of the changed files.
+func changedModules(changes []importrewrite.Change) []string {
+	seen := make(map[string]bool)
+	var modules []string
+	for _, c := range changes {
+		if m := workspace.ModuleForPath(c.Path); m != "" && !seen[m] {
+			seen[m] = true
+			modules = append(modules, m)
+		}
+	}
+	sort.Strings(modules)
+	return modules
+}
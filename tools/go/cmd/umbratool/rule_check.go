@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/rulepack"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "rule-check",
+		summary: "Check sources against the pattern rules of the YAML rule packs in rules/",
+		run:     runRuleCheck,
+	})
+}
+
+func runRuleCheck(args []string) error {
+	fs := newFlagSet("rule-check")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dir := fs.String("rules", rulepack.DefaultDir, "Directory of rule packs, relative to the project root")
+	only := fs.String("only", "", "Comma-separated rule IDs to check (default: every rule)")
+	scope := fs.String("scope", workspace.AllScopes, "Comma-separated scopes (sources, tests, testsupport) or directories to check")
+	fix := fs.Bool("fix", false, "Apply the fixes of rules that have one")
+	strict := fs.Bool("strict", false, "Fail on warnings as well as errors")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	emit := fs.String("emit", "", "Print diagnostics instead of the report: "+emitFormats)
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := rulepack.Load(projectRoot, *dir)
+	if err != nil {
+		return err
+	}
+	if ids := splitList(*only); len(ids) > 0 {
+		var selected []*rulepack.Rule
+		for _, id := range ids {
+			found := false
+			for _, r := range rules {
+				if r.ID == id {
+					selected = append(selected, r)
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("--only: no rule %s in %s", id, *dir)
+			}
+		}
+		rules = selected
+	}
+	modules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	run := beginAudit(projectRoot, "rule-check")
+	findings, err := rulepack.Check(runContext, projectRoot, rules, rulepack.Options{
+		Dirs:    scopeList(*scope),
+		Modules: modules,
+		Fix:     *fix,
+	})
+	var fixed []string
+	for _, f := range findings {
+		if f.Fixed {
+			fixed = append(fixed, f.File)
+		}
+	}
+	if err != nil {
+		return run.failed(fixed, err)
+	}
+	if err := run.done(fixed); err != nil {
+		return err
+	}
+
+	issues := make([]store.Issue, 0, len(findings))
+	failing := 0
+	for _, f := range findings {
+		if f.Fixed {
+			continue
+		}
+		issues = append(issues, store.Issue{Module: f.Module, File: f.File, Line: f.Line, Kind: f.Rule, Message: f.Message})
+		if f.Severity == rulepack.SeverityError || (*strict && f.Severity == rulepack.SeverityWarning) {
+			failing++
+		}
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	i := 0
+	for n, f := range findings {
+		if !f.Fixed {
+			findings[n].Fingerprint = issues[i].Fingerprint
+			i++
+		}
+	}
+
+	if *emit != "" {
+		err = writeOutput(*output, func(w io.Writer) error {
+			diags := make([]diagnostic, 0, len(findings))
+			for _, f := range findings {
+				if f.Fixed {
+					continue
+				}
+				severity := "warning"
+				if f.Severity == rulepack.SeverityError {
+					severity = "error"
+				}
+				diags = append(diags, diagnostic{File: f.File, Line: f.Line, Severity: severity, Title: "rule-check: " + f.Rule, Message: f.Message})
+			}
+			return writeDiagnostics(w, *emit, projectRoot, diags)
+		})
+	} else {
+		err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+			switch *format {
+			case "markdown":
+				return rulepack.WriteMarkdown(w, rules, findings)
+			case "json":
+				return rulepack.WriteJSON(w, rules, findings)
+			default:
+				return fmt.Errorf("unknown format %q", *format)
+			}
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	err = export.record("rule-check", projectRoot, func(s *metrics.Set) {
+		for _, f := range findings {
+			if !f.Fixed {
+				s.Add("rule_findings", "Rule pack findings per module by rule.", 1, "module", f.Module, "rule", f.Rule)
+			}
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if failing > 0 {
+		fmt.Fprintf(os.Stderr, "rule-check: %d failing findings\n", failing)
+		return errCheckFailed
+	}
+	return nil
+}
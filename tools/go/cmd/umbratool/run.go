@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/bazel"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/notify"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/tasks"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "run",
+		summary: "Run named task pipelines from umbratool.yaml",
+		run:     runTasks,
+	})
+}
+
+func runTasks(args []string) error {
+	fs := newFlagSet("run")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	configPath := fs.String("config", "umbratool.yaml", "Task definitions, relative to the project root")
+	list := fs.Bool("list", false, "List the defined tasks and exit")
+	dryRun := fs.Bool("dry-run", false, "Print the steps in execution order without running them")
+	webhook := fs.String("notify-webhook", os.Getenv("UMBRATOOL_NOTIFY_WEBHOOK"), "Post a summary of the run to this Slack-compatible webhook when it crosses a threshold (default: $UMBRATOOL_NOTIFY_WEBHOOK)")
+	notifyStore := fs.String("notify-store", "results.db", "Result store the tasks record into, which the summary is read from, relative to --out-dir when set, otherwise to the project root")
+	var thresholds notify.Thresholds
+	fs.Float64Var(&thresholds.ScoreDelta, "notify-score-delta", 5, "Notify when a module's health score moves by this many points (0 disables)")
+	fs.IntVar(&thresholds.Cycles, "notify-cycles", 1, "Notify when there are this many new dependency cycles (0 disables)")
+	fs.IntVar(&thresholds.DoneItems, "notify-done-items", 1, "Notify when this many refactoring plan items are newly done (0 disables)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	config, err := tasks.LoadConfig(rootPath(projectRoot, *configPath))
+	if err != nil {
+		return err
+	}
+
+	if *list {
+		for _, name := range config.Names() {
+			t := config.Tasks[name]
+			fmt.Printf("%s: %s\n", name, strings.Join(append(append([]string(nil), t.Needs...), t.Run...), ", "))
+		}
+		return nil
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: umbratool run [flags] <task>... (tasks: %s)", strings.Join(config.Names(), ", "))
+	}
+
+	nodes, err := tasks.Plan(config, fs.Args(), func(name string) bool {
+		_, ok := commands[name]
+		return ok && name != "run"
+	})
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		for _, n := range nodes {
+			fmt.Printf("%s", n.Name)
+			if len(n.Needs) > 0 {
+				fmt.Printf(" (after %s)", strings.Join(n.Needs, ", "))
+			}
+			fmt.Println()
+			for _, step := range n.Steps {
+				fmt.Printf("  umbratool %s\n", strings.Join(step, " "))
+			}
+		}
+		return nil
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	// The steps run in the project root, so pass them --out-dir as an
+	// absolute path.
+	env := os.Environ()
+	var dir string
+	if outDir != "" {
+		if dir, err = filepath.Abs(outDir); err != nil {
+			return err
+		}
+		env = append(env, "UMBRATOOL_OUT_DIR="+dir)
+	}
+	// The steps share one Bazel limit, kept in files in the output
+	// directory, or in a temporary one when there is none.
+	var shared string
+	if dir != "" {
+		shared = filepath.Join(dir, "bazel-shared")
+		err = os.MkdirAll(shared, 0o755)
+	} else {
+		shared, err = os.MkdirTemp("", "umbratool-bazel-")
+		defer os.RemoveAll(shared)
+	}
+	if err != nil {
+		return err
+	}
+	env = append(env,
+		"UMBRATOOL_BAZEL_SHARED="+shared,
+		"UMBRATOOL_BAZEL_JOBS="+strconv.Itoa(bazel.DefaultJobs),
+		"UMBRATOOL_BAZEL_INTERVAL="+bazel.DefaultInterval.String(),
+	)
+	started := time.Now().Truncate(time.Second)
+	failed, skipped := tasks.Execute(runContext, nodes, pool.Jobs(), func(args []string) ([]byte, error) {
+		cmd := exec.CommandContext(runContext, self, args...)
+		cmd.Dir = projectRoot
+		cmd.Env = env
+		// Pass an interrupt on rather than killing the step, so it can
+		// finish the file in hand as this process does. Windows has no
+		// interrupt to send, so there the step is killed.
+		cmd.Cancel = func() error {
+			if err := cmd.Process.Signal(os.Interrupt); err != nil {
+				return cmd.Process.Kill()
+			}
+			return nil
+		}
+		return cmd.CombinedOutput()
+	}, func(r tasks.Result) {
+		if r.Err == tasks.ErrSkipped {
+			fmt.Printf("--- %s: %v\n", r.Task, r.Err)
+			return
+		}
+		if r.Err == tasks.ErrCancelled {
+			fmt.Printf("--- %s: umbratool %s: %v\n", r.Task, strings.Join(r.Args, " "), r.Err)
+			return
+		}
+		status := "ok"
+		if r.Err != nil {
+			status = "FAIL: " + r.Err.Error()
+		}
+		fmt.Printf("--- %s: umbratool %s: %s\n", r.Task, strings.Join(r.Args, " "), status)
+		os.Stdout.Write(r.Output)
+	})
+
+	interrupted := runContext.Err() != nil
+	if *webhook != "" {
+		title := "umbratool run " + strings.Join(fs.Args(), " ")
+		if interrupted {
+			title += " (cancelled)"
+		}
+		if err := notifyRun(*webhook, resultPath(projectRoot, *notifyStore), title, started, thresholds, failed); err != nil {
+			fmt.Fprintf(os.Stderr, "umbratool run: notification not sent: %v\n", err)
+		}
+	}
+
+	if interrupted {
+		return fmt.Errorf("%w; failed or unfinished tasks: %s", context.Canceled, strings.Join(failed, ", "))
+	}
+	if len(failed) > 0 || len(skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "failed tasks: %s", strings.Join(failed, ", "))
+		if len(skipped) > 0 {
+			fmt.Fprintf(os.Stderr, "; skipped: %s", strings.Join(skipped, ", "))
+		}
+		fmt.Fprintln(os.Stderr)
+		return errCheckFailed
+	}
+	return nil
+}
+
+// notifyRun posts the summary of the runs recorded in the store at path
+// since started, if it crosses a threshold or tasks failed.
+func notifyRun(webhook, path, title string, started time.Time, thresholds notify.Thresholds, failed []string) error {
+	summary := &notify.Summary{}
+	if _, err := os.Stat(path); err == nil {
+		db, err := store.Open(path)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		if summary, err = notify.Build(uninterrupted(), db, started); err != nil {
+			return err
+		}
+	}
+	summary.Failed = failed
+
+	reasons := summary.Reasons(thresholds)
+	if len(reasons) == 0 {
+		fmt.Println("--- notification: nothing crossed a threshold")
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(uninterrupted(), time.Minute)
+	defer cancel()
+	if err := notify.Send(ctx, webhook, summary.Payload(title, reasons)); err != nil {
+		return err
+	}
+	fmt.Printf("--- notification sent: %s\n", strings.Join(reasons, ", "))
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/satellite"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "scaffold-repo",
+		summary: "Write the skeleton of a satellite repository depending on UmbraCore",
+		run:     runScaffoldRepo,
+	})
+}
+
+func runScaffoldRepo(args []string) error {
+	fs := newFlagSet("scaffold-repo")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	name := fs.String("name", "", "Repository name, e.g. UmbraBackup (required)")
+	bazelName := fs.String("bazel-name", "", "Bazel module name (default: the repository name in snake case)")
+	modules := fs.String("modules", "", "Comma-separated Swift modules to start with, each with a test bundle")
+	dir := fs.String("dir", "", "Directory to write the repository to (default: ../<name> beside the project root)")
+	repoURL := fs.String("repo-url", "", "Where the repository is hosted (default: "+satellite.DefaultOrg+"<name>)")
+	commit := fs.String("umbracore-commit", "", "UmbraCore commit to pin with a git_override (default: the checkout's HEAD; none to leave it out)")
+	force := fs.Bool("force", false, "Write into a directory that is not empty, overwriting the skeleton's files")
+	dryRun := fs.Bool("dry-run", false, "List the files instead of writing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errors.New("--name is required")
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	pin := *commit
+	switch pin {
+	case "none":
+		pin = ""
+	case "":
+		if out, err := exec.Command("git", "-C", projectRoot, "rev-parse", "HEAD").Output(); err == nil {
+			pin = strings.TrimSpace(string(out))
+		}
+	}
+	files, err := satellite.Plan(projectRoot, satellite.Options{
+		Name:      *name,
+		BazelName: *bazelName,
+		Modules:   splitList(*modules),
+		RepoURL:   *repoURL,
+		Commit:    pin,
+	})
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(filepath.Dir(projectRoot), *name)
+	if *dir != "" {
+		target = rootPath(projectRoot, *dir)
+	}
+	if *dryRun {
+		for _, f := range files {
+			fmt.Printf("%s (%d bytes)\n", filepath.Join(target, filepath.FromSlash(f.Path)), len(f.Content))
+		}
+		return nil
+	}
+	if err := satellite.Write(target, files, *force); err != nil {
+		return err
+	}
+	for _, f := range files {
+		fmt.Printf("wrote %s\n", filepath.Join(target, filepath.FromSlash(f.Path)))
+	}
+	if pin == "" {
+		fmt.Printf("%d files written; pin UmbraCore in %s before building\n", len(files), filepath.Join(target, "MODULE.bazel"))
+	} else {
+		fmt.Printf("%d files written; UmbraCore pinned at %s\n", len(files), pin)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/apidump"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testgen"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testmap"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "scaffold-tests",
+		summary: "Write skipped XCTest skeletons for the public methods no test refers to",
+		run:     runScaffoldTests,
+	})
+}
+
+func runScaffoldTests(args []string) error {
+	fs := newFlagSet("scaffold-tests")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories whose public API is covered")
+	modules := fs.String("modules", "", "Comma-separated modules to scaffold (default: all)")
+	force := fs.Bool("force", false, "Overwrite scaffolds edited by hand")
+	dryRun := fs.Bool("dry-run", false, "List the scaffolds and their tests instead of writing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	api, err := apidump.Dump(projectRoot, apidump.Options{Dirs: scopeList(*dirs), Rules: rules})
+	if err != nil {
+		return err
+	}
+	tests, err := testmap.Build(projectRoot)
+	if err != nil {
+		return err
+	}
+	scaffolds, err := testgen.Plan(projectRoot, api, tests, splitList(*modules))
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		for _, s := range scaffolds {
+			note := ""
+			if s.New {
+				note = ", new bundle"
+			}
+			fmt.Printf("%s (%d untested%s)\n", path.Join(s.Dir, s.FileName()), len(s.Gaps), note)
+			for _, g := range s.Gaps {
+				fmt.Printf("  %s: %s\n", g.Test, g.Name)
+			}
+		}
+		return nil
+	}
+
+	run := beginAudit(projectRoot, "scaffold-tests")
+	var written []string
+	failed, gaps := 0, 0
+	for _, s := range scaffolds {
+		placed, err := testgen.Write(projectRoot, s, rules, *force)
+		if err != nil {
+			if placed != nil {
+				written = append(written, placed.File)
+			}
+			fmt.Fprintf(os.Stderr, "umbratool scaffold-tests: %s: %v\n", s.Module, err)
+			failed++
+			continue
+		}
+		gaps += len(s.Gaps)
+		written = append(written, placed.File, packageBuild(projectRoot, placed.File))
+		fmt.Printf("wrote %s (%d untested, target %s)\n", placed.File, len(s.Gaps), placed.Target)
+		if placed.Created {
+			fmt.Printf("created %s\n", path.Join(s.Dir, "BUILD.bazel"))
+		}
+		if placed.Dep != "" {
+			fmt.Printf("added dep %s\n", placed.Dep)
+		}
+	}
+	fmt.Printf("%d untested methods scaffolded in %d modules\n", gaps, len(scaffolds)-failed)
+	if failed > 0 {
+		return run.failed(written, fmt.Errorf("%d of %d scaffolds not written", failed, len(scaffolds)))
+	}
+	return run.done(written)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/secrets"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "secret-scan",
+		summary: "Find hard-coded secrets and credentials not accepted by the baseline",
+		run:     runSecretScan,
+	})
+}
+
+func runSecretScan(args []string) error {
+	fs := newFlagSet("secret-scan")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	baselinePath := fs.String("baseline", secrets.BaselineFile, "Baseline of accepted findings, relative to the project root")
+	staged := fs.Bool("staged", false, "Scan only the staged content of files staged for commit (for pre-commit hooks)")
+	update := fs.Bool("update-baseline", false, "Accept every current finding into the baseline, keeping recorded reasons")
+	minEntropy := fs.Float64("min-entropy", secrets.DefaultMinEntropy, "Entropy in bits per character above which long literals are reported")
+	format := fs.String("format", "text", "Output format: text or json")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *update && (*staged || fs.NArg() > 0) {
+		return errors.New("--update-baseline needs a full scan; drop --staged and the file arguments")
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	baselineFile := rootPath(projectRoot, *baselinePath)
+	baseline, err := secrets.LoadBaseline(baselineFile)
+	if err != nil {
+		return err
+	}
+	findings, err := secrets.Scan(projectRoot, secrets.Options{Files: fs.Args(), Staged: *staged, MinEntropy: *minEntropy})
+	if err != nil {
+		return err
+	}
+
+	if *update {
+		updated := baseline.Update(findings)
+		if err := updated.Write(baselineFile); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %d accepted findings to %s\n", len(updated.Allow), *baselinePath)
+		return nil
+	}
+
+	fresh := baseline.Filter(findings)
+	issues := make([]store.Issue, 0, len(fresh))
+	for _, f := range fresh {
+		issues = append(issues, store.Issue{Module: f.Module, File: f.File, Line: f.Line, Kind: f.Rule, Message: "possible secret " + f.Match, Symbol: f.Fingerprint})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i := range fresh {
+		fresh[i].IssueFingerprint = issues[i].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "text":
+			for _, f := range fresh {
+				fmt.Fprintf(w, "%s:%d: %s: %s (fingerprint %s)\n", f.File, f.Line, f.Rule, f.Match, f.Fingerprint)
+			}
+			_, err := fmt.Fprintf(w, "%d findings, %d accepted by the baseline, %d new\n", len(findings), len(findings)-len(fresh), len(fresh))
+			return err
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			if fresh == nil {
+				fresh = []secrets.Finding{}
+			}
+			return enc.Encode(fresh)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("secret-scan", projectRoot, func(s *metrics.Set) {
+		for _, f := range fresh {
+			s.Add("secret_findings", "Suspected secrets not accepted by the baseline, per module by rule.", 1, "module", f.Module, "rule", f.Rule)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if len(fresh) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
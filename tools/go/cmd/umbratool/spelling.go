@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/spelling"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "spelling",
+		summary: "Flag American spellings in docs, doc comments and public identifiers",
+		run:     runSpelling,
+	})
+}
+
+func runSpelling(args []string) error {
+	fs := newFlagSet("spelling")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	configPath := fs.String("config", "", "JSON word-list configuration (words to add, words to ignore)")
+	scope := fs.String("scope", "swift-docs,markdown", "Comma-separated scopes: swift-docs, markdown, go, identifiers")
+	jsonOut := fs.Bool("json", false, "Print findings as JSON")
+	failOnFindings := fs.Bool("strict", false, "Exit non-zero when any finding is reported")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+
+	dict := spelling.DefaultDictionary()
+	if *configPath != "" {
+		cfg, err := spelling.LoadConfig(*configPath)
+		if err != nil {
+			return fmt.Errorf("loading word list: %w", err)
+		}
+		dict.Apply(cfg)
+	}
+
+	var sc spelling.Scope
+	for _, s := range splitList(*scope) {
+		switch s {
+		case "swift-docs":
+			sc.SwiftDocs = true
+		case "markdown":
+			sc.Markdown = true
+		case "go":
+			sc.GoComments = true
+		case "identifiers":
+			sc.Identifiers = true
+		default:
+			return fmt.Errorf("unknown scope %q", s)
+		}
+	}
+
+	findings, err := dict.Scan(projectRoot, sc)
+	if err != nil {
+		return err
+	}
+
+	issues := make([]store.Issue, 0, len(findings))
+	for _, f := range findings {
+		issues = append(issues, store.Issue{Module: workspace.ModuleForPath(f.File), File: f.File, Line: f.Line, Kind: f.Kind, Message: f.Word + " -> " + f.Suggestion, Symbol: f.Word})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i := range findings {
+		findings[i].Fingerprint = issues[i].Fingerprint
+	}
+	err = export.record("spelling", projectRoot, func(s *metrics.Set) {
+		for _, f := range findings {
+			s.Add("spelling_findings", "American spellings per module and kind.", 1, "module", workspace.ModuleForPath(f.File), "kind", f.Kind)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if *jsonOut {
+		err = writeOutput("", func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(findings)
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, f := range findings {
+			if f.Identifier != "" {
+				fmt.Printf("%s:%d: %s: %s uses %q; consider %s\n", f.File, f.Line, f.Kind, f.Identifier, f.Word, f.Suggestion)
+			} else {
+				fmt.Printf("%s:%d: %s: %q -> %q\n", f.File, f.Line, f.Kind, f.Word, f.Suggestion)
+			}
+		}
+		fmt.Printf("%d American spellings found\n", len(findings))
+	}
+
+	if *failOnFindings && len(findings) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
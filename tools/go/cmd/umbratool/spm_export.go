@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/spm"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "spm-export",
+		summary: "Generate a Package.swift mirroring the Swift rules, reporting Bazel settings it cannot express",
+		run:     runSPMExport,
+	})
+}
+
+func runSPMExport(args []string) error {
+	fs := newFlagSet("spm-export")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	output := fs.String("output", "", "Package.swift to write (default: stdout, as if at the project root); its directory must hold every exported target")
+	perModule := fs.String("per-module", "", "Write one package per module below this directory instead, linking back to the sources")
+	name := fs.String("name", "UmbraCore", "Package name")
+	targets := fs.String("targets", "", "Comma-separated label patterns of the rules to export with their deps, e.g. //Sources/Core:Core or //Sources/Security/... (default: every Swift rule)")
+	macOS := fs.String("macos", "14.7", "Minimum macOS version of the package")
+	toolsVersion := fs.String("tools-version", "5.9", "swift-tools-version the manifest declares")
+	format := fs.String("format", "swift", "Output format: swift (the manifest) or json (the exported targets and losses)")
+	allowLosses := fs.Bool("allow-losses", false, "Exit zero even when Bazel settings are not exported")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "swift" && *format != "json" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if *perModule != "" && *format != "swift" {
+		return fmt.Errorf("--per-module writes manifests; it cannot be combined with --format %s", *format)
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	pkg, err := spm.Export(projectRoot, spm.Options{Name: *name, ToolsVersion: *toolsVersion, MacOS: *macOS, Targets: splitList(*targets)})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *perModule != "":
+		dir, err := outPath(*perModule)
+		if err != nil {
+			return err
+		}
+		written, err := pkg.WriteModules(projectRoot, dir)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "spm-export: wrote %d packages below %s\n", len(written), dir)
+	case *format == "json":
+		err := writeOutput(*output, func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(pkg)
+		})
+		if err != nil {
+			return err
+		}
+	default:
+		dir, err := packageDir(projectRoot, *output)
+		if err != nil {
+			return err
+		}
+		manifest, err := pkg.Manifest(dir)
+		if err != nil {
+			return err
+		}
+		err = writeOutput(*output, func(w io.Writer) error {
+			_, err := w.Write(manifest)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	printLosses("spm-export", len(pkg.Targets), pkg.Losses)
+	if len(pkg.Losses) > 0 && !*allowLosses {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// printLosses lists the Bazel settings an export left out on stderr.
+func printLosses(command string, targets int, losses []spm.Loss) {
+	for _, l := range losses {
+		attr := ""
+		if l.Attribute != "" {
+			attr = l.Attribute + ": "
+		}
+		fmt.Fprintf(os.Stderr, "%s:%d: %s: %s%s\n", l.File, l.Line, l.Label, attr, l.Message)
+	}
+	fmt.Fprintf(os.Stderr, "%s: %d targets, %d Bazel settings not exported\n", command, targets, len(losses))
+}
+
+// packageDir returns the directory of the manifest at output, relative to
+// the project root, or the root itself for stdout.
+func packageDir(root, output string) (string, error) {
+	if output == "" || output == "-" {
+		return ".", nil
+	}
+	file, err := outPath(output)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
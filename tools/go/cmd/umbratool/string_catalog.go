@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/l10n"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "string-catalog",
+		summary: "Extract user-facing strings and list those missing from the localisation catalogues",
+		run:     runStringCatalog,
+	})
+}
+
+func runStringCatalog(args []string) error {
+	fs := newFlagSet("string-catalog")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to scan")
+	properties := fs.String("properties", "", "Comma-separated String properties whose literals are user-facing (default: the LocalizedError properties)")
+	sinks := fs.String("sinks", "", "Comma-separated extra calls and properties taking user-facing text, such as 'uiLog.info(_:)' or '.title'")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "csv", "Report format: csv, markdown or json")
+	strict := fs.Bool("strict", false, "Exit non-zero when any user-facing string is unlocalised")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	opts := l10n.Options{Dirs: scopeList(*dirs), Properties: splitList(*properties)}
+	if extra := splitList(*sinks); len(extra) > 0 {
+		for _, s := range append(append([]string(nil), l10n.DefaultSinks...), extra...) {
+			sink, err := l10n.ParseSink(s)
+			if err != nil {
+				return err
+			}
+			opts.Sinks = append(opts.Sinks, sink)
+		}
+	}
+
+	strs, err := l10n.Extract(projectRoot, opts)
+	if err != nil {
+		return err
+	}
+	catalogs, err := l10n.LoadCatalogs(projectRoot)
+	if err != nil {
+		return err
+	}
+	strs = l10n.Compare(strs, catalogs)
+
+	unlocalised := l10n.Unlocalised(strs)
+	issues := make([]store.Issue, 0, len(unlocalised))
+	for _, s := range unlocalised {
+		issues = append(issues, store.Issue{Module: s.Module, File: s.File, Line: s.Line, Kind: s.Status,
+			Message: fmt.Sprintf("%s string %q is not localised", s.Context, s.Key), Symbol: s.Key})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	// Unlocalised sorts its copies, so the fingerprints go back by
+	// position in the file.
+	type at struct {
+		file string
+		line int
+		key  string
+	}
+	fingerprints := make(map[at]string, len(issues))
+	for n, s := range unlocalised {
+		fingerprints[at{s.File, s.Line, s.Key}] = issues[n].Fingerprint
+	}
+	for n, s := range strs {
+		if s.Status != l10n.StatusLocalised {
+			strs[n].Fingerprint = fingerprints[at{s.File, s.Line, s.Key}]
+		}
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "csv":
+			return l10n.WriteCSV(w, strs)
+		case "markdown":
+			return l10n.WriteMarkdown(w, strs, catalogs)
+		case "json":
+			return l10n.WriteJSON(w, strs, catalogs)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("string-catalog", projectRoot, func(s *metrics.Set) {
+		for _, str := range strs {
+			s.Add("user_facing_strings", "User-facing string literals per module by kind and localisation status.", 1,
+				"module", str.Module, "kind", str.Kind, "status", str.Status)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if *strict && len(unlocalised) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/tagpolicy"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "tag-policy",
+		summary: "Check Bazel target tags and .bazelrc tag filters against the tag policy, optionally adding required tags",
+		run:     runTagPolicy,
+	})
+}
+
+func runTagPolicy(args []string) error {
+	fs := newFlagSet("tag-policy")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	policyPath := fs.String("policy", tagpolicy.PolicyFile, "Policy file, relative to the project root")
+	bazelrc := fs.String("bazelrc", ".bazelrc", "Comma-separated bazelrc files whose tag filters are checked, relative to the project root")
+	fix := fs.Bool("fix", false, "Add missing required tags to the BUILD files, then report what is left")
+	format := fs.String("format", "text", "Output format: text or json")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	strict := fs.Bool("strict", false, "Exit non-zero on warnings as well as errors")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	policy, err := tagpolicy.LoadPolicy(rootPath(projectRoot, *policyPath))
+	if err != nil {
+		return err
+	}
+	rcs := splitList(*bazelrc)
+	report, err := tagpolicy.Check(projectRoot, policy, rcs)
+	if err != nil {
+		return err
+	}
+	if *fix {
+		run := beginAudit(projectRoot, "tag-policy")
+		added, fixed, err := tagpolicy.Fix(projectRoot, report.Issues)
+		if err != nil {
+			return run.failed(fixed, err)
+		}
+		fmt.Fprintf(os.Stderr, "added %d required tags\n", added)
+		if err := run.done(fixed); err != nil {
+			return err
+		}
+		if report, err = tagpolicy.Check(projectRoot, policy, rcs); err != nil {
+			return err
+		}
+	}
+
+	errs, warnings := 0, 0
+	for _, i := range report.Issues {
+		if i.Severity == "error" {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+	stored := make([]store.Issue, 0, len(report.Issues))
+	for _, i := range report.Issues {
+		stored = append(stored, store.Issue{Module: i.Module, File: i.File, Line: i.Line, Kind: i.Kind, Message: i.Message})
+	}
+	if err := annotateIssues(projectRoot, stored); err != nil {
+		return err
+	}
+	for n := range report.Issues {
+		report.Issues[n].Fingerprint = stored[n].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "text":
+			for _, f := range report.Filters {
+				fmt.Fprintf(w, "%s:%d: %s=%s leaves out %d of %d targets\n", f.File, f.Line, f.Option(), strings.Join(f.Tags, ","), f.Excluded, f.Targets)
+			}
+			for _, i := range report.Issues {
+				fmt.Fprintf(w, "%s:%d: %s: %s\n", i.File, i.Line, i.Severity, i.Message)
+			}
+			_, err := fmt.Fprintf(w, "%d targets, %d tag filters: %d errors, %d warnings\n", len(report.Targets), len(report.Filters), errs, warnings)
+			return err
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("tag-policy", projectRoot, func(s *metrics.Set) {
+		for _, i := range report.Issues {
+			s.Add("tag_policy_issues", "Tag policy violations per module by kind.", 1, "module", i.Module, "kind", i.Kind)
+		}
+	}, stored)
+	if err != nil {
+		return err
+	}
+
+	if errs > 0 || (*strict && warnings > 0) {
+		return errCheckFailed
+	}
+	return nil
+}
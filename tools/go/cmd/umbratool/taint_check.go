@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/taintflow"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "taint-check",
+		summary: "Flag user input and network data reaching keychain, crypto or file APIs without validation, for security review",
+		run:     runTaintCheck,
+	})
+}
+
+func runTaintCheck(args []string) error {
+	fs := newFlagSet("taint-check")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories to scan")
+	sanitisers := fs.String("sanitisers", strings.Join(taintflow.DefaultSanitisers, ","), "Comma-separated name prefixes of the validation helpers")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	opts := taintflow.Options{Dirs: scopeList(*dirs), Sanitisers: splitList(*sanitisers)}
+	findings, err := taintflow.Scan(projectRoot, opts)
+	if err != nil {
+		return err
+	}
+
+	issues := make([]store.Issue, 0, len(findings))
+	for _, f := range findings {
+		issues = append(issues, store.Issue{Module: f.Module, File: f.File, Line: f.Line, Kind: "tainted-flow",
+			Message: fmt.Sprintf("%s data from line %d reaches %s (low confidence)", f.Source, f.Origin, f.API)})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i := range findings {
+		findings[i].Fingerprint = issues[i].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return taintflow.WriteMarkdown(w, findings, opts.Sanitisers)
+		case "json":
+			return taintflow.WriteJSON(w, findings)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return export.record("taint-check", projectRoot, func(s *metrics.Set) {
+		for _, f := range findings {
+			s.Add("tainted_flows", "Unvalidated flows of user input or network data into keychain, crypto and file APIs per module.", 1,
+				"module", f.Module, "source", f.Source, "sink", f.Sink)
+		}
+	}, issues)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testmap"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testresults"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "test-health",
+		summary: "Summarise xcresult/xctest results per module using the test map",
+		run:     runTestHealth,
+	})
+}
+
+func runTestHealth(args []string) error {
+	fs := newFlagSet("test-health")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	slow := fs.Duration("slow", 2*time.Second, "Flag tests taking at least this long (0 disables)")
+	failOnFailures := fs.Bool("strict", false, "Exit non-zero when any module has failing tests")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("usage: umbratool test-health [flags] <.xcresult | test.log | bazel-testlogs dir>...")
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+
+	tm, err := testmap.Build(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	var cases []testresults.Case
+	for _, input := range fs.Args() {
+		found, err := testresults.Load(input)
+		if err != nil {
+			return err
+		}
+		cases = append(cases, found...)
+	}
+
+	health := testresults.Summarise(cases, tm, *slow)
+	var issues []store.Issue
+	for _, h := range health {
+		for _, c := range h.Failures {
+			issues = append(issues, store.Issue{Module: h.Module, Kind: "test-failure", Message: c.Bundle + "." + c.Suite + "." + c.Name + ": " + c.Message, Symbol: c.Bundle + "." + c.Suite + "." + c.Name})
+		}
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	i := 0
+	for _, h := range health {
+		for n := range h.Failures {
+			h.Failures[n].Fingerprint = issues[i].Fingerprint
+			i++
+		}
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return testresults.WriteMarkdown(w, health)
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(health)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("test-health", projectRoot, func(s *metrics.Set) {
+		for _, h := range health {
+			s.Gauge("tests", "Test cases per module and result.", float64(h.Passed), "module", h.Module, "status", testresults.StatusPassed)
+			s.Gauge("tests", "Test cases per module and result.", float64(h.Failed), "module", h.Module, "status", testresults.StatusFailed)
+			s.Gauge("tests", "Test cases per module and result.", float64(h.Skipped), "module", h.Module, "status", testresults.StatusSkipped)
+			s.Gauge("test_duration_seconds", "Total test duration per module.", h.Duration.Seconds(), "module", h.Module)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if *failOnFailures {
+		for _, h := range health {
+			if h.State == testresults.HealthFailing {
+				return errCheckFailed
+			}
+		}
+	}
+	return nil
+}
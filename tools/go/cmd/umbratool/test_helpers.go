@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testhelpers"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "test-helpers",
+		summary: "Find test-support code in production modules and plan its move under Tests/TestSupport",
+		run:     runTestHelpers,
+	})
+}
+
+func runTestHelpers(args []string) error {
+	fs := newFlagSet("test-helpers")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	dirs := fs.String("scope", "sources", "Comma-separated scopes (sources, tests, testsupport) or directories of production code")
+	tests := fs.String("tests", "Tests", "Comma-separated top-level directories of tests, searched for uses of production types")
+	dest := fs.String("dest", testhelpers.DefaultDest, "Test-support directory the move plan leads to")
+	planPath := fs.String("plan", "", "Also write the move plan for the restructurer to this JSON file")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	report, err := testhelpers.Scan(projectRoot, testhelpers.Options{
+		Dirs:     scopeList(*dirs),
+		TestDirs: splitList(*tests),
+		Rules:    rules,
+		Dest:     *dest,
+	})
+	if err != nil {
+		return err
+	}
+
+	issues := make([]store.Issue, 0, len(report.Findings))
+	for _, f := range report.Findings {
+		issues = append(issues, store.Issue{Module: f.Module, File: f.File, Line: f.Line, Kind: f.Kind, Message: f.Message()})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i := range report.Findings {
+		report.Findings[i].Fingerprint = issues[i].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return testhelpers.WriteMarkdown(w, report)
+		case "json":
+			return testhelpers.WriteJSON(w, report)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if *planPath != "" {
+		if err := testhelpers.WritePlan(rootPath(projectRoot, *planPath), report.Moves); err != nil {
+			return err
+		}
+	}
+
+	return export.record("test-helpers", projectRoot, func(s *metrics.Set) {
+		for _, f := range report.Findings {
+			s.Add("test_helpers", "Test-support findings in production modules per module by kind.", 1, "module", f.Module, "kind", f.Kind)
+		}
+	}, issues)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/todo"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// defaultCriticalModules are the modules where stale debt fails CI.
+const defaultCriticalModules = "SecurityProtocolsCore,SecurityBridge,UmbraKeychainService,UmbraCryptoService,XPCProtocolsCore,CoreErrors"
+
+func init() {
+	register(command{
+		name:    "todo-scan",
+		summary: "Report TODO/FIXME/HACK comment debt by module and age",
+		run:     runTodoScan,
+	})
+}
+
+func runTodoScan(args []string) error {
+	fs := newFlagSet("todo-scan")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	critical := fs.String("critical", defaultCriticalModules, "Comma-separated list of critical modules")
+	noBlame := fs.Bool("no-blame", false, "Skip git blame attribution")
+	limit := fs.Int("limit", 100, "Maximum number of items listed in the Markdown report (0 for all)")
+	maxAge := fs.Int("max-age-days", 0, "Fail when critical modules contain items older than this many days (0 disables)")
+	maxStale := fs.Int("max-stale", 0, "Number of stale critical items tolerated before failing")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+
+	items, err := todo.Scan(projectRoot, todo.Options{
+		Blame:           !*noBlame,
+		CriticalModules: splitList(*critical),
+		Now:             reportTime(),
+	})
+	if err != nil {
+		return err
+	}
+
+	issues := make([]store.Issue, 0, len(items))
+	for _, item := range items {
+		issues = append(issues, store.Issue{Module: item.Module, File: item.File, Line: item.Line, Kind: item.Tag, Message: item.Text})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].Fingerprint = issues[i].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return todo.WriteMarkdown(w, items, *limit)
+		case "json":
+			return todo.WriteJSON(w, items)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("todo-scan", projectRoot, func(s *metrics.Set) {
+		for _, item := range items {
+			s.Add("todo_items", "Debt comments per module and tag.", 1, "module", item.Module, "tag", item.Tag)
+		}
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if *maxAge > 0 {
+		stale := todo.Stale(items, *maxAge)
+		if len(stale) > *maxStale {
+			fmt.Fprintf(os.Stderr, "%d items in critical modules are older than %d days (allowed: %d):\n", len(stale), *maxAge, *maxStale)
+			for _, item := range stale {
+				fmt.Fprintf(os.Stderr, "  %s:%d: %s %s (%d days)\n", item.File, item.Line, item.Tag, item.Text, item.AgeDays)
+			}
+			return errCheckFailed
+		}
+	}
+	return nil
+}
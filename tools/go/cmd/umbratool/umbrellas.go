@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/umbrella"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "umbrellas",
+		summary: "Regenerate the deps of the aggregation targets of umbratool.yaml from their predicates",
+		run:     runUmbrellas,
+	})
+}
+
+func runUmbrellas(args []string) error {
+	fs := newFlagSet("umbrellas")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	configPath := fs.String("config", "umbratool.yaml", "Config holding the umbrellas section, relative to the project root")
+	targets := fs.String("targets", "", "Comma-separated umbrella labels to update (default: all)")
+	check := fs.Bool("check", false, "List umbrellas whose deps are out of date instead of rewriting them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	config, err := umbrella.LoadConfig(rootPath(projectRoot, *configPath))
+	if err != nil {
+		return err
+	}
+	if len(config.Umbrellas) == 0 {
+		fmt.Printf("%s declares no umbrellas\n", *configPath)
+		return nil
+	}
+
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	plan, err := umbrella.Update(projectRoot, config.Umbrellas, rules, splitList(*targets))
+	if err != nil {
+		return err
+	}
+	stale := 0
+	var updated []string
+	for _, r := range plan.Results {
+		if !r.Stale() {
+			continue
+		}
+		stale++
+		updated = append(updated, r.File)
+		var edits []string
+		for _, label := range r.Missing {
+			edits = append(edits, "+"+label)
+		}
+		for _, label := range r.Unexpected {
+			edits = append(edits, "-"+label)
+		}
+		if r.Unsorted {
+			edits = append(edits, "sorted")
+		}
+		if *check {
+			fmt.Printf("%s: out of date (%s)\n", r.Target, strings.Join(edits, ", "))
+		} else {
+			fmt.Printf("updated %s in %s (%s)\n", r.Target, r.File, strings.Join(edits, ", "))
+		}
+	}
+
+	if *check {
+		fmt.Printf("%d of %d umbrellas out of date\n", stale, len(plan.Results))
+		if stale > 0 {
+			fmt.Fprintln(os.Stderr, "umbrellas: run umbratool umbrellas to update them")
+			return errCheckFailed
+		}
+		return nil
+	}
+	run := beginAudit(projectRoot, "umbrellas")
+	if saved, err := plan.Save(runContext); err != nil {
+		return run.failed(saved, err)
+	}
+	fmt.Printf("%d of %d umbrellas updated\n", stale, len(plan.Results))
+	return run.done(updated)
+}
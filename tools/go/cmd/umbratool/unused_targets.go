@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/unused"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "unused-targets",
+		summary: "Find swift_library targets that nothing depends on",
+		run:     runUnusedTargets,
+	})
+}
+
+func runUnusedTargets(args []string) error {
+	fs := newFlagSet("unused-targets")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	scope := fs.String("scope", "//...", "Query scope; rules outside it do not count as users")
+	kinds := fs.String("kinds", "swift_library", "Comma-separated library rule kinds to check")
+	allowlist := fs.String("allowlist", "unused_targets_allowlist.txt", "File of entry-point label patterns never reported, relative to the project root")
+	allow := fs.String("allow", "", "Additional comma-separated label patterns to allow")
+	transitive := fs.Bool("transitive", false, "Also report libraries used only by other unused libraries")
+	output := fs.String("output", "", "Report file (default: stdout)")
+	format := fs.String("format", "markdown", "Report format: markdown or json")
+	strict := fs.Bool("strict", false, "Exit non-zero when unused targets are found")
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+
+	patterns, err := unused.LoadAllowlist(rootPath(projectRoot, *allowlist))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	patterns = append(patterns, splitList(*allow)...)
+
+	targets, err := unused.Query(runContext, bazelRunner(projectRoot), *scope)
+	if err != nil {
+		return err
+	}
+
+	findings := unused.Find(targets, unused.Options{
+		LibraryKinds: splitList(*kinds),
+		Allow:        patterns,
+		Transitive:   *transitive,
+	})
+
+	issues := make([]store.Issue, 0, len(findings))
+	for _, f := range findings {
+		issues = append(issues, store.Issue{Module: workspace.ModuleForPath(f.Package + "/BUILD.bazel"), File: f.Package, Kind: "unused-target", Message: f.Label, Symbol: f.Label})
+	}
+	if err := annotateIssues(projectRoot, issues); err != nil {
+		return err
+	}
+	for i := range findings {
+		findings[i].Fingerprint = issues[i].Fingerprint
+	}
+
+	err = writeReport(fs, projectRoot, *output, *format, func(w io.Writer) error {
+		switch *format {
+		case "markdown":
+			return unused.WriteMarkdown(w, findings)
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(findings)
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = export.record("unused-targets", projectRoot, func(s *metrics.Set) {
+		s.Gauge("unused_targets", "Library targets without reverse dependencies.", float64(len(findings)))
+	}, issues)
+	if err != nil {
+		return err
+	}
+
+	if *strict && len(findings) > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
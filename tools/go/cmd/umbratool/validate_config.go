@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/configschema"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "validate-config",
+		summary: "Check the tools' config files against their published JSON Schemas",
+		run:     runValidateConfig,
+	})
+}
+
+func runValidateConfig(args []string) error {
+	fs := newFlagSet("validate-config")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	kind := fs.String("kind", "", "Kind of the files named, for those kept outside the usual places (see --list)")
+	list := fs.Bool("list", false, "List the kinds of config file and where each is kept, and exit")
+	schemaDir := fs.String("write-schemas", "", "Write the JSON Schemas into this directory, for editors, and exit")
+	output := fs.String("output", "", "Problem list file (default: stdout)")
+	emit := fs.String("emit", "", "Print diagnostics instead of the problem list: "+emitFormats)
+	export := addResultFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *list {
+		for _, k := range configschema.Kinds {
+			where := strings.Join(k.Files, ", ")
+			if where == "" {
+				where = "any file with a top-level " + k.Key + " key"
+			}
+			fmt.Printf("%-20s %s\n", k.Name, where)
+		}
+		return nil
+	}
+	if *schemaDir != "" {
+		return writeSchemas(*schemaDir)
+	}
+	if *kind != "" {
+		if _, ok := configschema.Lookup(*kind); !ok {
+			return fmt.Errorf("--kind: unknown config kind %q (see --list)", *kind)
+		}
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	files := fs.Args()
+	if len(files) == 0 {
+		if *kind != "" {
+			return errors.New("--kind needs the files to check")
+		}
+		if files, err = findConfigs(projectRoot); err != nil {
+			return err
+		}
+	}
+
+	var diags []diagnostic
+	var lines []string
+	invalid := 0
+	for _, name := range files {
+		file := rootPath(projectRoot, name)
+		rel := filepath.ToSlash(name)
+		if r, err := filepath.Rel(projectRoot, file); err == nil && !strings.HasPrefix(r, "..") {
+			rel = filepath.ToSlash(r)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		k, ok := configschema.Lookup(*kind)
+		if *kind == "" {
+			if k, ok = configschema.Detect(rel, data); !ok {
+				return fmt.Errorf("%s: cannot tell what kind of config this is; pass --kind (see --list)", rel)
+			}
+		}
+		problems, err := configschema.Check(k.Name, data)
+		if err != nil {
+			return err
+		}
+		if len(problems) > 0 {
+			invalid++
+		}
+		for _, p := range problems {
+			lines = append(lines, rel+":"+p.String())
+			message := p.Message
+			if p.Path != "" {
+				message = p.Path + ": " + message
+			}
+			diags = append(diags, diagnostic{File: rel, Line: p.Line, Severity: "error", Title: "validate-config: " + k.Name, Message: message})
+		}
+	}
+
+	err = writeOutput(*output, func(w io.Writer) error {
+		if *emit != "" {
+			return writeDiagnostics(w, *emit, projectRoot, diags)
+		}
+		for _, line := range lines {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintf(w, "%d of %d config files invalid\n", invalid, len(files))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	issues := make([]store.Issue, 0, len(diags))
+	for _, d := range diags {
+		issues = append(issues, store.Issue{File: d.File, Line: d.Line, Kind: "invalid-config", Message: d.Message})
+	}
+	err = export.record("validate-config", projectRoot, func(s *metrics.Set) {
+		s.Gauge("config_files_checked", "Config files checked against their schemas", float64(len(files)))
+		s.Gauge("config_problems", "Schema problems found in config files", float64(len(diags)))
+	}, issues)
+	if err != nil {
+		return err
+	}
+	if invalid > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// findConfigs returns the config files of the project kept where their
+// kinds are, relative to projectRoot and sorted.
+func findConfigs(projectRoot string) ([]string, error) {
+	var files []string
+	for _, k := range configschema.Kinds {
+		for _, pattern := range k.Files {
+			matches, err := filepath.Glob(filepath.Join(projectRoot, filepath.FromSlash(pattern)))
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range matches {
+				rel, err := filepath.Rel(projectRoot, m)
+				if err != nil {
+					return nil, err
+				}
+				files = append(files, filepath.ToSlash(rel))
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// writeSchemas publishes every kind's schema into dir.
+func writeSchemas(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, k := range configschema.Kinds {
+		data, err := configschema.Schema(k.Name)
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(dir, k.SchemaFile())
+		if err := atomicfile.WriteFile(file, data, 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("wrote %s\n", file)
+	}
+	return nil
+}
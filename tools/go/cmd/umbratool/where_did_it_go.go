@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moves"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+func init() {
+	register(command{
+		name:    "where-did-it-go",
+		summary: "Look up where a moved file went, from moves.log and git's rename history",
+		run:     runWhereDidItGo,
+	})
+}
+
+func runWhereDidItGo(args []string) error {
+	fs := newFlagSet("where-did-it-go")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	noGit := fs.Bool("no-git", false, "Only consult moves.log, not git's rename history")
+	format := fs.String("format", "text", "Output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: umbratool where-did-it-go [flags] <old-path>")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	old := fs.Arg(0)
+	if filepath.IsAbs(old) {
+		if old, err = filepath.Rel(projectRoot, old); err != nil {
+			return err
+		}
+	}
+
+	entries, err := moves.Load(projectRoot)
+	if err != nil {
+		return err
+	}
+	if !*noGit {
+		// Moves made by hand, or before moves.log, are only in git. A
+		// committed move the log has already taken the file past no
+		// longer matches its path, so merging the two is safe. Commit
+		// times have whole seconds; within one, the log's moves go first.
+		renames, err := moves.GitRenames(projectRoot)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, renames...)
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Time.Truncate(time.Second).Before(entries[j].Time.Truncate(time.Second))
+		})
+	}
+	hops := moves.Trace(entries, old)
+	if len(hops) == 0 {
+		return fmt.Errorf("no recorded move of %s", filepath.ToSlash(old))
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(hops)
+	}
+	fmt.Println(hops[0].Old)
+	for _, h := range hops {
+		how := "renamed"
+		if h.Git {
+			how = "git mv"
+		}
+		if h.Tool == "git" {
+			fmt.Printf("  → %s  (commit %s, %s)\n", h.New, h.Run, h.Time.Format("2006-01-02"))
+			continue
+		}
+		fmt.Printf("  → %s  (%s, run %s, %s, %s)\n", h.New, h.Tool, h.Run, how, h.Time.Format("2006-01-02 15:04"))
+	}
+	last := hops[len(hops)-1].New
+	if _, err := os.Stat(filepath.Join(projectRoot, filepath.FromSlash(last))); err != nil {
+		fmt.Printf("%s no longer exists\n", last)
+	}
+	return nil
+}
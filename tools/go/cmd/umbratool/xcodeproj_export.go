@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/spm"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/xcodeproj"
+)
+
+func init() {
+	register(command{
+		name:    "xcodeproj-export",
+		summary: "Write an XcodeGen spec for chosen modules and their dependencies, to debug a slice of the tree in Xcode",
+		run:     runXcodeprojExport,
+	})
+}
+
+func runXcodeprojExport(args []string) error {
+	fs := newFlagSet("xcodeproj-export")
+	root := fs.String("root", "", "Project root (default: discovered from the working directory)")
+	modules := fs.String("modules", "", "Comma-separated module names to include, with everything they depend on")
+	output := fs.String("output", "", "project.yml to write (default: stdout, as if at the project root)")
+	name := fs.String("name", "", "Project name (default: UmbraCore- and the first module)")
+	bundlePrefix := fs.String("bundle-prefix", "com.umbracore", "Prefix of the targets' bundle identifiers")
+	macOS := fs.String("macos", "14.7", "macOS deployment target")
+	generate := fs.Bool("generate", false, "Run xcodegen on the spec afterwards, writing the .xcodeproj next to it; needs --output")
+	allowLosses := fs.Bool("allow-losses", false, "Exit zero even when Bazel settings are not exported")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	wanted := splitList(*modules)
+	if len(wanted) == 0 {
+		return errors.New("--modules is required")
+	}
+	if *generate && (*output == "" || *output == "-") {
+		return errors.New("--generate needs --output")
+	}
+	if *name == "" {
+		*name = "UmbraCore-" + wanted[0]
+	}
+
+	projectRoot, err := workspace.ResolveRoot(*root)
+	if err != nil {
+		return err
+	}
+	rules, err := moduleindex.Rules(projectRoot)
+	if err != nil {
+		return err
+	}
+	var labels []string
+	for _, m := range wanted {
+		found := false
+		for _, r := range rules {
+			if r.ModuleName == m {
+				labels = append(labels, r.Label)
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("no Swift rule compiles module %s", m)
+		}
+	}
+
+	pkg, err := spm.Export(projectRoot, spm.Options{Name: *name, MacOS: *macOS, Targets: labels})
+	if err != nil {
+		return err
+	}
+	dir, err := packageDir(projectRoot, *output)
+	if err != nil {
+		return err
+	}
+	base, err := filepath.Rel(filepath.Join(projectRoot, filepath.FromSlash(dir)), projectRoot)
+	if err != nil {
+		return err
+	}
+	spec, err := xcodeproj.Spec(pkg, xcodeproj.Options{Name: *name, BundleIDPrefix: *bundlePrefix, Base: filepath.ToSlash(base)})
+	if err != nil {
+		return err
+	}
+	err = writeOutput(*output, func(w io.Writer) error {
+		_, err := w.Write(spec)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	losses := xcodeproj.Losses(pkg)
+	printLosses("xcodeproj-export", len(pkg.Targets), losses)
+
+	if *generate {
+		file, err := outPath(*output)
+		if err != nil {
+			return err
+		}
+		cmd := exec.Command("xcodegen", "generate", "--spec", file)
+		cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("xcodegen: %w", err)
+		}
+	}
+	if len(losses) > 0 && !*allowLosses {
+		return errCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,521 @@
+// Package apidump extracts the public API of the Swift modules, the
+// declarations that other modules and downstream code can use, and
+// compares the APIs of two revisions.
+package apidump
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+var (
+	// declPattern matches the start of a declaration: its attributes,
+	// modifiers and keyword.
+	declPattern  = regexp.MustCompile(`^((?:@\w+(?:\([^)]*\))?\s*)*)((?:(?:public|open|package|internal|fileprivate|private|final|static|class|override|required|convenience|mutating|nonmutating|lazy|weak|unowned|indirect|dynamic|nonisolated|distributed|optional|prefix|postfix|infix|\w+\(set\))\s+)*)(func|var|let|class|struct|enum|protocol|typealias|associatedtype|case|init|actor|subscript|extension|macro)\b`)
+	funcName     = regexp.MustCompile("^\\s*(`?[A-Za-z_]\\w*`?|[^\\s(<]+)")
+	plainName    = regexp.MustCompile("^\\s*`?([A-Za-z_]\\w*)")
+	extendedName = regexp.MustCompile(`^\s*([A-Za-z_][\w.]*)`)
+	attrsOnly    = regexp.MustCompile(`^\s*(?:@\w+(?:\([^)]*\))?\s*)+$`)
+	available    = regexp.MustCompile(`@available\s*\([^)]*\)\s*`)
+	accessors    = regexp.MustCompile(`^\{\s*((?:(?:get|set|async|throws|mutating|nonmutating)\s*)+)\}`)
+	spaceBefore  = regexp.MustCompile(`\s+([,:.)\]])`)
+	spaceAfter   = regexp.MustCompile(`([(\[])\s+`)
+	// A declaration goes on to the next line when the line ends with an
+	// unfinished clause, or the next begins with one or with the body.
+	unfinished    = []string{"->", "where", "&", ",", ":", ".", "="}
+	continuations = []string{"->", "throws", "rethrows", "async", "where", "&", ",", ":", ".", "{", ")", "]", "="}
+)
+
+var typeKinds = map[string]bool{"class": true, "struct": true, "enum": true, "protocol": true, "actor": true}
+
+// Decl is one public declaration.
+type Decl struct {
+	Module string `json:"module"`
+	// Name is qualified by the enclosing types, with the argument labels
+	// of functions, initialisers and subscripts, as in
+	// "SecureBytes.init(bytes:)". A conformance added by an extension is
+	// named "Type: Protocol".
+	Name string `json:"name"`
+	// Kind is the declaring keyword, or "conformance".
+	Kind string `json:"kind"`
+	// Signature is the declaration up to its body, without @available
+	// attributes and with the spacing normalised.
+	Signature string `json:"signature"`
+	// Requirement is set for the members of protocols, which conforming
+	// types must implement.
+	Requirement bool   `json:"requirement,omitempty"`
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+
+	// extends is the type an extension declaring the member extends.
+	extends string
+}
+
+// API is the public API of the modules below some directories.
+type API struct {
+	// Decls are sorted by module, name and signature.
+	Decls []Decl `json:"decls"`
+}
+
+// Options configures a dump.
+type Options struct {
+	// Dirs are the top-level directories dumped (default "Sources").
+	Dirs []string
+	// Rules name the module of each file; files outside every rule fall
+	// back to their top-level directory.
+	Rules []modulenames.Rule
+}
+
+type file struct {
+	module string
+	decls  []Decl
+	// types are the types the file declares, by qualified name, and
+	// whether they are public.
+	types map[string]bool
+}
+
+// scope is a body the declarations of a file are nested in.
+type scope struct {
+	// name is the qualified name of the type, or the type extended.
+	name string
+	kind string
+	// api is set when members can be public API, and implicit when
+	// members without an access level are.
+	api, implicit bool
+	// opaque bodies, of functions, properties and closures, declare
+	// nothing public.
+	opaque  bool
+	extends string
+}
+
+// Dump extracts the public API of the modules below opts.Dirs: the
+// public and open declarations at the top level and in public types,
+// the members of public protocols and public extensions, and the
+// conformances extensions add to public types.
+func Dump(root string, opts Options) (*API, error) {
+	dirs := opts.Dirs
+	if len(dirs) == 0 {
+		dirs = []string{"Sources"}
+	}
+	index := &moduleindex.Index{Modules: opts.Rules}
+
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	files, err := pool.Map(paths, func(rel string) (*file, error) {
+		module := workspace.ModuleForPath(rel)
+		if r, ok := index.ForPath(rel); ok {
+			module = r.ModuleName
+		}
+		return readFile(root, rel, module)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// An extension of a type the module declares internal adds nothing
+	// public, whatever its members say.
+	types := make(map[string]map[string]bool)
+	for _, f := range files {
+		if types[f.module] == nil {
+			types[f.module] = make(map[string]bool)
+		}
+		for name, public := range f.types {
+			types[f.module][name] = types[f.module][name] || public
+		}
+	}
+	api := &API{Decls: []Decl{}}
+	for _, f := range files {
+		for _, d := range f.decls {
+			if public, declared := types[f.module][d.extends]; declared && !public {
+				continue
+			}
+			api.Decls = append(api.Decls, d)
+		}
+	}
+	sort.Slice(api.Decls, func(i, j int) bool {
+		a, b := api.Decls[i], api.Decls[j]
+		if a.Module != b.Module {
+			return a.Module < b.Module
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Signature < b.Signature
+	})
+	// Both branches of an #if declare the same thing.
+	out := api.Decls[:0]
+	for i, d := range api.Decls {
+		if i > 0 && d.Module == out[len(out)-1].Module && d.Name == out[len(out)-1].Name && d.Signature == out[len(out)-1].Signature {
+			continue
+		}
+		out = append(out, d)
+	}
+	api.Decls = out
+	return api, nil
+}
+
+func readFile(root, rel, module string) (*file, error) {
+	fh, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	var lines []string
+	inComment := false
+	scanner := textscan.NewScanner(fh)
+	for scanner.Scan() {
+		var code string
+		code, inComment = swiftsrc.StripComments(scanner.Text(), inComment)
+		lines = append(lines, code)
+	}
+	if err := textscan.Check(rel, len(lines), scanner.Err()); err != nil {
+		return nil, err
+	}
+
+	f := &file{module: module, types: make(map[string]bool)}
+	stack := []scope{{api: true}}
+	headerEnd := -1
+	var bodyAt [2]int
+	var body scope
+	attrs := ""
+	for i, line := range lines {
+		top := stack[len(stack)-1]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case i <= headerEnd || top.opaque || trimmed == "" || strings.HasPrefix(trimmed, "#"):
+		case attrsOnly.MatchString(trimmed):
+			attrs += trimmed + " "
+		default:
+			col := len(line) - len(strings.TrimLeft(line, " \t"))
+			if m := declPattern.FindStringSubmatchIndex(line[col:]); m != nil {
+				d := declaration{
+					attrs:     attrs + line[col+m[2]:col+m[3]],
+					modifiers: strings.Fields(line[col+m[4] : col+m[5]]),
+					kind:      line[col+m[6] : col+m[7]],
+				}
+				var bodyOK bool
+				d.text, headerEnd, bodyAt, bodyOK = header(lines, i, col+m[7], d.kind)
+				decls, s := f.declare(top, d, rel, i+1)
+				f.decls = append(f.decls, decls...)
+				if !bodyOK {
+					bodyAt = [2]int{-1, -1}
+				} else {
+					body = s
+				}
+			}
+			attrs = ""
+		}
+		for c := 0; c < len(line); c++ {
+			switch line[c] {
+			case '{':
+				if bodyAt == [2]int{i, c} {
+					stack = append(stack, body)
+				} else {
+					stack = append(stack, scope{opaque: true})
+				}
+			case '}':
+				if len(stack) > 1 {
+					stack = stack[:len(stack)-1]
+				}
+			}
+		}
+	}
+	return f, nil
+}
+
+// declaration is a declaration as read, before it is named.
+type declaration struct {
+	attrs     string
+	modifiers []string
+	kind      string
+	// text follows the keyword, up to the body.
+	text string
+}
+
+func (d declaration) access() string {
+	for _, m := range d.modifiers {
+		switch m {
+		case "public", "open", "package", "internal", "fileprivate", "private":
+			return m
+		}
+	}
+	return ""
+}
+
+func (d declaration) signature(text string) string {
+	s := strings.TrimSpace(available.ReplaceAllString(d.attrs, "") + strings.Join(d.modifiers, " ") + " " + d.kind + text)
+	return normalise(s)
+}
+
+// declare returns what d declares in top on line no, and the scope of its
+// body.
+func (f *file) declare(top scope, d declaration, rel string, no int) ([]Decl, scope) {
+	access := d.access()
+	public := top.api && (access == "public" || access == "open" || access == "" && (top.implicit || d.kind == "case" && top.kind == "enum"))
+	qualify := func(name string) string {
+		if top.name == "" {
+			return name
+		}
+		return top.name + "." + name
+	}
+	decl := func(name, signature string) Decl {
+		return Decl{Module: f.module, Name: name, Kind: d.kind, Signature: signature, Requirement: top.kind == "protocol", File: rel, Line: no, extends: top.extends}
+	}
+
+	switch d.kind {
+	case "extension":
+		m := extendedName.FindStringSubmatch(d.text)
+		if m == nil || top.name != "" {
+			return nil, scope{opaque: true}
+		}
+		extended := m[1]
+		s := scope{name: extended, kind: d.kind, api: true, implicit: access == "public", extends: extended}
+		var out []Decl
+		if head, inherited := splitInheritance(d.text[len(m[0]):]); head == "" || strings.HasPrefix(head, "<") {
+			for _, p := range inherited {
+				c := Decl{Module: f.module, Name: extended + ": " + p, Kind: "conformance", Signature: "extension " + extended + ": " + p, File: rel, Line: no, extends: extended}
+				out = append(out, c)
+			}
+		}
+		return out, s
+	case "case":
+		if top.kind != "enum" {
+			return nil, scope{opaque: true}
+		}
+		var out []Decl
+		for _, element := range splitTop(d.text) {
+			element, _, _ = strings.Cut(element, "=")
+			m := plainName.FindStringSubmatch(element)
+			if m != nil && public {
+				out = append(out, decl(qualify(m[1]), normalise("case "+strings.TrimSpace(element))))
+			}
+		}
+		return out, scope{opaque: true}
+	case "func", "init", "subscript":
+		name := d.kind
+		if d.kind == "func" {
+			m := funcName.FindStringSubmatch(d.text)
+			if m == nil {
+				return nil, scope{opaque: true}
+			}
+			name = strings.Trim(m[1], "`")
+		}
+		name += "(" + labels(d.text, d.kind == "subscript") + ")"
+		if !public {
+			return nil, scope{opaque: true}
+		}
+		return []Decl{decl(qualify(name), d.signature(d.text))}, scope{opaque: true}
+	}
+
+	m := plainName.FindStringSubmatch(d.text)
+	if m == nil {
+		return nil, scope{opaque: true}
+	}
+	name := qualify(m[1])
+	var out []Decl
+	if public {
+		out = append(out, decl(name, d.signature(d.text)))
+	}
+	if !typeKinds[d.kind] {
+		return out, scope{opaque: true}
+	}
+	f.types[name] = public
+	return out, scope{name: name, kind: d.kind, api: public, implicit: d.kind == "protocol", extends: top.extends}
+}
+
+// header returns the text of the declaration whose keyword ends at column
+// col of lines[i], up to its body or its end; the index of its last line;
+// and, when a body follows, the position of the brace opening the body.
+// The accessors of a protocol's property requirement are kept.
+func header(lines []string, i, col int, kind string) (string, int, [2]int, bool) {
+	var b strings.Builder
+	depth := 0
+	for j := i; j < len(lines) && j < i+50; j++ {
+		line := lines[j]
+		c := 0
+		if j == i {
+			c = col
+		}
+		for ; c < len(line); c++ {
+			switch ch := line[c]; ch {
+			case '(', '[':
+				depth++
+			case ')', ']':
+				depth--
+			case '{':
+				if depth == 0 {
+					if kind == "var" || kind == "subscript" {
+						if m := accessors.FindStringSubmatch(line[c:]); m != nil {
+							b.WriteString(" { " + strings.Join(strings.Fields(m[1]), " ") + " }")
+						}
+					}
+					return b.String(), j, [2]int{j, c}, true
+				}
+			case ';':
+				if depth == 0 {
+					return b.String(), j, [2]int{}, false
+				}
+			case '=':
+				prev := byte(' ')
+				if c > 0 {
+					prev = line[c-1]
+				}
+				if depth == 0 && (kind == "var" || kind == "let") && !strings.ContainsRune("=!<>", rune(prev)) && (c+1 == len(line) || line[c+1] != '=') {
+					return b.String(), j, [2]int{}, false
+				}
+			}
+			b.WriteByte(line[c])
+		}
+		if depth <= 0 && (kind == "case" || !continues(b.String(), lines[j+1:])) {
+			return b.String(), j, [2]int{}, false
+		}
+		b.WriteByte(' ')
+	}
+	return b.String(), min(i+50, len(lines)) - 1, [2]int{}, false
+}
+
+// continues reports whether a declaration reading text at the end of a
+// line goes on to the next line of code.
+func continues(text string, rest []string) bool {
+	text = strings.TrimSpace(text)
+	for _, c := range unfinished {
+		if strings.HasSuffix(text, c) {
+			return true
+		}
+	}
+	for _, line := range rest {
+		next := strings.TrimSpace(line)
+		if next == "" {
+			continue
+		}
+		for _, c := range continuations {
+			if strings.HasPrefix(next, c) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// labels returns the argument labels of the parameter list following the
+// name and generic parameters at the start of text, as "label:" each.
+// Subscript parameters have no label unless they name one apart.
+func labels(text string, subscript bool) string {
+	open := strings.IndexByte(text, '(')
+	if open < 0 {
+		return ""
+	}
+	depth := 0
+	end := len(text)
+	for i := open; i < len(text); i++ {
+		if text[i] == '(' {
+			depth++
+		} else if text[i] == ')' {
+			if depth--; depth == 0 {
+				end = i
+				break
+			}
+		}
+	}
+	var b strings.Builder
+	for _, param := range splitTop(text[open+1 : end]) {
+		before, _, ok := strings.Cut(param, ":")
+		fields := strings.Fields(before)
+		if !ok || len(fields) == 0 {
+			continue
+		}
+		label := fields[0]
+		if subscript && len(fields) == 1 {
+			label = "_"
+		}
+		b.WriteString(strings.Trim(label, "`") + ":")
+	}
+	return b.String()
+}
+
+// splitTop splits s at the commas outside brackets.
+func splitTop(s string) []string {
+	var out []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '<':
+			depth++
+		case ')', ']', '>':
+			if i == 0 || s[i-1] != '-' {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				out = append(out, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(out, s[start:])
+}
+
+// splitInheritance splits what follows a type's name into the text before
+// its inheritance clause and the types the clause names, dropping any
+// where clause.
+func splitInheritance(rest string) (string, []string) {
+	rest, _, _ = strings.Cut(rest, " where ")
+	head, clause, ok := strings.Cut(rest, ":")
+	if !ok {
+		return strings.TrimSpace(head), nil
+	}
+	var inherited []string
+	for _, t := range splitTop(clause) {
+		if t = strings.TrimSpace(t); t != "" {
+			inherited = append(inherited, normalise(t))
+		}
+	}
+	return strings.TrimSpace(head), inherited
+}
+
+// normalise collapses the spacing of a signature, so that reformatting a
+// declaration does not change it.
+func normalise(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	s = spaceAfter.ReplaceAllString(spaceBefore.ReplaceAllString(s, "$1"), "$1")
+	s = strings.NewReplacer(",", ", ", ":", ": ", "->", " -> ").Replace(s)
+	s = strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "[: ]", "[:]")
+	// Spaces around the = of a default value are style too.
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '=' && (i == 0 || !strings.ContainsRune("=!<>", rune(s[i-1]))) && (i+1 == len(s) || s[i+1] != '=') {
+			b.WriteString(" = ")
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,242 @@
+package apidump
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Change kinds.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Release levels, from the least to the most a change needs.
+const (
+	BumpNone  = "none"
+	BumpPatch = "patch"
+	BumpMinor = "minor"
+	BumpMajor = "major"
+)
+
+var typeHeader = regexp.MustCompile(`^(.*?\b(?:class|struct|enum|protocol|actor)\s+\w+(?:<[^>]*>)?)(?::\s*(.*?))?((?:\s+where\s+.*)?)$`)
+
+// Change is a difference between the APIs of two revisions.
+type Change struct {
+	Kind   string `json:"kind"`
+	Module string `json:"module"`
+	Name   string `json:"name"`
+	// Breaking is set for changes that can stop code using the API from
+	// compiling; Reason says why a change is or is not.
+	Breaking bool   `json:"breaking"`
+	Reason   string `json:"reason"`
+	Old      *Decl  `json:"old,omitempty"`
+	New      *Decl  `json:"new,omitempty"`
+	// Fingerprint identifies a breaking change's issue across runs.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Compare returns the changes from base to head, sorted by module and
+// name. The members of a type added or removed are not listed apart from
+// the type.
+func Compare(base, head *API) []Change {
+	key := func(d Decl) string { return d.Module + "\x00" + d.Name }
+	group := func(api *API) map[string][]Decl {
+		m := make(map[string][]Decl)
+		for _, d := range api.Decls {
+			m[key(d)] = append(m[key(d)], d)
+		}
+		return m
+	}
+	old, cur := group(base), group(head)
+	keys := make([]string, 0, len(old)+len(cur))
+	for k := range old {
+		keys = append(keys, k)
+	}
+	for k := range cur {
+		if _, ok := old[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	// ownerMoved reports whether a type enclosing d is only in one of the
+	// revisions, and so reported in its place.
+	ownerMoved := func(d Decl) bool {
+		for _, owner := range owners(d.Name) {
+			k := d.Module + "\x00" + owner
+			if (len(old[k]) == 0) != (len(cur[k]) == 0) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var changes []Change
+	for _, k := range keys {
+		before, after := old[k], cur[k]
+		// Overloads sharing labels pair up by signature.
+		removed := slices.DeleteFunc(slices.Clone(before), func(d Decl) bool { return hasSignature(after, d.Signature) })
+		added := slices.DeleteFunc(slices.Clone(after), func(d Decl) bool { return hasSignature(before, d.Signature) })
+		if len(removed) == 1 && len(added) == 1 {
+			changes = append(changes, changed(removed[0], added[0]))
+			continue
+		}
+		for _, d := range removed {
+			if !ownerMoved(d) {
+				changes = append(changes, Change{Kind: Removed, Module: d.Module, Name: d.Name, Breaking: true, Reason: "removes the declaration", Old: &d})
+			}
+		}
+		for _, d := range added {
+			if !ownerMoved(d) {
+				changes = append(changes, addition(d))
+			}
+		}
+	}
+	return changes
+}
+
+func hasSignature(decls []Decl, signature string) bool {
+	return slices.ContainsFunc(decls, func(d Decl) bool { return d.Signature == signature })
+}
+
+// owners returns the types enclosing the declaration name, outermost
+// first.
+func owners(name string) []string {
+	name, _, _ = strings.Cut(name, "(")
+	name, _, _ = strings.Cut(name, ": ")
+	parts := strings.Split(name, ".")
+	var out []string
+	for i := 1; i < len(parts); i++ {
+		out = append(out, strings.Join(parts[:i], "."))
+	}
+	return out
+}
+
+func addition(d Decl) Change {
+	c := Change{Kind: Added, Module: d.Module, Name: d.Name, Reason: "adds a declaration", New: &d}
+	switch {
+	case d.Requirement:
+		c.Breaking, c.Reason = true, "adds a protocol requirement, which types conforming elsewhere must implement"
+	case d.Kind == "case":
+		c.Breaking, c.Reason = true, "adds an enum case, which breaks exhaustive switches elsewhere"
+	case d.Kind == "conformance":
+		c.Reason = "adds a conformance"
+	}
+	return c
+}
+
+func changed(o, n Decl) Change {
+	c := Change{Kind: Changed, Module: n.Module, Name: n.Name, Breaking: true, Reason: "changes the signature", Old: &o, New: &n}
+	switch {
+	case strings.Replace(o.Signature, "open ", "public ", 1) == n.Signature && o.Signature != n.Signature:
+		c.Reason = "narrows open to public, so other modules can no longer subclass or override it"
+	case strings.Replace(n.Signature, "open ", "public ", 1) == o.Signature && o.Signature != n.Signature:
+		c.Breaking, c.Reason = false, "widens public to open"
+	case typeKinds[o.Kind] && o.Kind == n.Kind:
+		oh, oi, ow := inheritance(o.Signature)
+		nh, ni, nw := inheritance(n.Signature)
+		if oh != nh || ow != nw || !subset(oi, ni) {
+			break
+		}
+		if o.Kind == "protocol" {
+			c.Reason = "adds an inherited protocol, which types conforming elsewhere must adopt"
+		} else {
+			c.Breaking, c.Reason = false, "adds conformances"
+		}
+	}
+	return c
+}
+
+// inheritance splits a type's signature into its head, the types its
+// inheritance clause names and its where clause.
+func inheritance(signature string) (string, []string, string) {
+	m := typeHeader.FindStringSubmatch(signature)
+	if m == nil {
+		return signature, nil, ""
+	}
+	var inherited []string
+	if m[2] != "" {
+		for _, t := range splitTop(m[2]) {
+			inherited = append(inherited, strings.TrimSpace(t))
+		}
+	}
+	return m[1], inherited, m[3]
+}
+
+func subset(a, b []string) bool {
+	for _, s := range a {
+		if !slices.Contains(b, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// Required returns the release the changes need past version, semver
+// style: a major one for breaking changes and a minor one for additions.
+// Before 1.0, breaking changes need a minor release and additions a
+// patch.
+func Required(changes []Change, version string) string {
+	bump := BumpNone
+	for _, c := range changes {
+		if c.Breaking {
+			bump = BumpMajor
+			break
+		}
+		bump = BumpMinor
+	}
+	if major, _, _, ok := parseVersion(version); ok && major == 0 {
+		switch bump {
+		case BumpMajor:
+			bump = BumpMinor
+		case BumpMinor:
+			bump = BumpPatch
+		}
+	}
+	return bump
+}
+
+// Bumped reports whether head is at least the release bump past base.
+// Versions that do not parse have not been bumped.
+func Bumped(base, head, bump string) bool {
+	if bump == BumpNone {
+		return true
+	}
+	bMajor, bMinor, bPatch, ok1 := parseVersion(base)
+	hMajor, hMinor, hPatch, ok2 := parseVersion(head)
+	if !ok1 || !ok2 {
+		return false
+	}
+	switch bump {
+	case BumpMajor:
+		return hMajor > bMajor
+	case BumpMinor:
+		return hMajor > bMajor || hMajor == bMajor && hMinor > bMinor
+	default:
+		return hMajor > bMajor || hMajor == bMajor && (hMinor > bMinor || hMinor == bMinor && hPatch > bPatch)
+	}
+}
+
+// parseVersion parses "major.minor.patch", allowing a leading v, a
+// missing patch and a pre-release or build suffix.
+func parseVersion(v string) (int, int, int, bool) {
+	v, _, _ = strings.Cut(strings.TrimPrefix(v, "v"), "-")
+	v, _, _ = strings.Cut(v, "+")
+	parts := strings.Split(v, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, 0, 0, false
+	}
+	var n [3]int
+	for i, p := range parts {
+		var err error
+		if n[i], err = strconv.Atoi(p); err != nil {
+			return 0, 0, 0, false
+		}
+	}
+	return n[0], n[1], n[2], true
+}
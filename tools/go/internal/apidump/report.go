@@ -0,0 +1,125 @@
+package apidump
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Report is the compatibility of one revision's API with another's.
+type Report struct {
+	Base        string   `json:"base"`
+	Head        string   `json:"head"`
+	BaseVersion string   `json:"baseVersion"`
+	HeadVersion string   `json:"headVersion"`
+	Changes     []Change `json:"changes"`
+	// Required is the release the changes need, and Bumped whether the
+	// head version is that far past the base version.
+	Required string `json:"required"`
+	Bumped   bool   `json:"bumped"`
+}
+
+// NewReport compares the API of base, at version baseVersion, with that of
+// head.
+func NewReport(base, head string, baseAPI, headAPI *API, baseVersion, headVersion string) *Report {
+	r := &Report{Base: base, Head: head, BaseVersion: baseVersion, HeadVersion: headVersion, Changes: Compare(baseAPI, headAPI)}
+	if r.Changes == nil {
+		r.Changes = []Change{}
+	}
+	r.Required = Required(r.Changes, baseVersion)
+	r.Bumped = Bumped(baseVersion, headVersion, r.Required)
+	return r
+}
+
+// Breaking returns the breaking changes.
+func (r *Report) Breaking() []Change {
+	var out []Change
+	for _, c := range r.Changes {
+		if c.Breaking {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// WriteJSON writes the API as indented JSON.
+func WriteJSON(w io.Writer, api *API) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(api)
+}
+
+// WriteMarkdown lists the API by module.
+func WriteMarkdown(w io.Writer, api *API) error {
+	var b strings.Builder
+	b.WriteString("# Public API\n")
+	for i, d := range api.Decls {
+		if i == 0 || d.Module != api.Decls[i-1].Module {
+			fmt.Fprintf(&b, "\n## %s\n\n", d.Module)
+		}
+		fmt.Fprintf(&b, "- `%s`\n", d.Signature)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteReportJSON writes the report as indented JSON.
+func WriteReportJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteReportMarkdown writes the breaking changes and then the others.
+func WriteReportMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	breaking := len(r.Breaking())
+	fmt.Fprintf(&b, "# API Compatibility\n\n`%s` (%s) to `%s` (%s): %d breaking and %d other changes", r.Base, versionOrUnknown(r.BaseVersion), r.Head, versionOrUnknown(r.HeadVersion), breaking, len(r.Changes)-breaking)
+	switch {
+	case r.Required == BumpNone:
+		b.WriteString(".\n")
+	case r.Bumped:
+		fmt.Fprintf(&b, ", which need a %s release; the version has been bumped.\n", r.Required)
+	default:
+		fmt.Fprintf(&b, ", which need a %s release.\n", r.Required)
+	}
+	section := func(title string, breaking bool) {
+		first := true
+		for _, c := range r.Changes {
+			if c.Breaking != breaking {
+				continue
+			}
+			if first {
+				fmt.Fprintf(&b, "\n## %s\n\n| Module | Declaration | Change |\n|--------|-------------|--------|\n", title)
+				first = false
+			}
+			change := c.Reason
+			switch {
+			case c.Old != nil && c.New != nil:
+				change += ": " + code(c.Old.Signature) + " becomes " + code(c.New.Signature)
+			case c.Old != nil:
+				change += ": " + code(c.Old.Signature)
+			default:
+				change += ": " + code(c.New.Signature)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", c.Module, code(c.Name), change)
+		}
+	}
+	section("Breaking Changes", true)
+	section("Other Changes", false)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func versionOrUnknown(v string) string {
+	if v == "" {
+		return "unversioned"
+	}
+	return v
+}
+
+// code quotes s for a table cell.
+func code(s string) string {
+	return "`" + strings.ReplaceAll(s, "|", "\\|") + "`"
+}
@@ -0,0 +1,301 @@
+// Package apiusage measures how much of each module's public API the
+// modules importing it use: for every consumer and provider pair, the
+// distinct provider declarations the consumer's sources reference. Few
+// symbols suggest the dependency could be narrowed to an interface or
+// dropped; many suggest the two modules belong together.
+package apiusage
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+var (
+	identPattern = regexp.MustCompile(`[A-Za-z_]\w*`)
+	// declPattern matches a named declaration, capturing its access level
+	// and name.
+	declPattern    = regexp.MustCompile(`^\s*(?:@\w+(?:\([^)]*\))?\s+)*(?:(public|open|package|internal|fileprivate|private)\s+)?(?:(?:final|static|indirect|nonisolated|dynamic|distributed)\s+)*(?:class|struct|enum|protocol|actor|typealias|func|let|var|macro)\s+([A-Za-z_]\w*)`)
+	exportedImport = regexp.MustCompile(`@_exported\s+import\b`)
+)
+
+// Options configures a scan.
+type Options struct {
+	// Dirs are the top-level directories scanned (default "Sources").
+	Dirs []string
+	// Rules name the module of each file; files outside every rule fall
+	// back to their top-level directory.
+	Rules []modulenames.Rule
+}
+
+// Pair is the use one module makes of another it imports.
+type Pair struct {
+	Consumer string `json:"consumer"`
+	Provider string `json:"provider"`
+	// Symbols are the distinct provider declarations the consumer
+	// references, sorted.
+	Symbols []string `json:"symbols"`
+	// Files is the number of consumer files that see the provider, by
+	// importing it or a module re-exporting it.
+	Files int `json:"files"`
+	// API is the number of public declarations of the provider.
+	API int `json:"api"`
+	// Fingerprint identifies a narrowing candidate's issue; other pairs
+	// have none.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Share is the fraction of the provider's API the consumer uses.
+func (p Pair) Share() float64 {
+	if p.API == 0 {
+		return 0
+	}
+	return float64(len(p.Symbols)) / float64(p.API)
+}
+
+// Report holds every dependency between scanned modules.
+type Report struct {
+	// Modules are the scanned modules, sorted.
+	Modules []string `json:"modules"`
+	// Pairs are sorted by consumer and provider.
+	Pairs []Pair `json:"pairs"`
+}
+
+type file struct {
+	rel, module string
+	// imports are the modules the file imports; exports those it
+	// re-exports with @_exported.
+	imports, exports []string
+	// idents are the identifiers of the file outside import lines, and
+	// qualified the names it qualifies with a module, by module.
+	idents    map[string]bool
+	qualified map[string]map[string]bool
+	// public and declared are the file's top-level declarations: the
+	// public and open ones, and all of them.
+	public, declared []string
+}
+
+// Scan measures the dependencies between the modules below opts.Dirs.
+// Only top-level declarations count: types, protocols, typealiases,
+// global functions and constants. A name several visible providers
+// declare is counted for each, unless the consumer qualifies it or
+// declares it itself.
+func Scan(root string, opts Options) (*Report, error) {
+	dirs := opts.Dirs
+	if len(dirs) == 0 {
+		dirs = []string{"Sources"}
+	}
+	index := &moduleindex.Index{Modules: opts.Rules}
+
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	files, err := pool.Map(paths, func(rel string) (*file, error) {
+		f, err := readFile(root, rel)
+		if err != nil {
+			return nil, err
+		}
+		if r, ok := index.ForPath(rel); ok {
+			f.module = r.ModuleName
+		}
+		return f, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	api := make(map[string]map[string]bool)
+	declared := make(map[string]map[string]bool)
+	exports := make(map[string]map[string]bool)
+	set := func(m map[string]map[string]bool, module string) map[string]bool {
+		if m[module] == nil {
+			m[module] = make(map[string]bool)
+		}
+		return m[module]
+	}
+	for _, f := range files {
+		set(api, f.module)
+		for _, name := range f.public {
+			api[f.module][name] = true
+		}
+		for _, name := range f.declared {
+			set(declared, f.module)[name] = true
+		}
+		for _, mod := range f.exports {
+			set(exports, f.module)[mod] = true
+		}
+	}
+
+	type key struct{ consumer, provider string }
+	pairs := make(map[key]*Pair)
+	for _, f := range files {
+		for _, provider := range visible(f.imports, exports) {
+			// A module re-exporting another is its facade, not a consumer.
+			if provider == f.module || api[provider] == nil || exports[f.module][provider] {
+				continue
+			}
+			k := key{f.module, provider}
+			p := pairs[k]
+			if p == nil {
+				p = &Pair{Consumer: f.module, Provider: provider, Symbols: []string{}, API: len(api[provider])}
+				pairs[k] = p
+			}
+			p.Files++
+			for name := range f.idents {
+				qualified := f.qualified[provider][name]
+				if api[provider][name] && (qualified || !declared[f.module][name]) {
+					p.Symbols = append(p.Symbols, name)
+				}
+			}
+		}
+	}
+
+	r := &Report{}
+	for module := range api {
+		r.Modules = append(r.Modules, module)
+	}
+	sort.Strings(r.Modules)
+	for _, p := range pairs {
+		sort.Strings(p.Symbols)
+		p.Symbols = compact(p.Symbols)
+		r.Pairs = append(r.Pairs, *p)
+	}
+	sort.Slice(r.Pairs, func(i, j int) bool {
+		if r.Pairs[i].Consumer != r.Pairs[j].Consumer {
+			return r.Pairs[i].Consumer < r.Pairs[j].Consumer
+		}
+		return r.Pairs[i].Provider < r.Pairs[j].Provider
+	})
+	return r, nil
+}
+
+// visible returns the imported modules and, transitively, the modules
+// they re-export.
+func visible(imported []string, exports map[string]map[string]bool) []string {
+	seen := make(map[string]bool)
+	var out []string
+	queue := append([]string(nil), imported...)
+	for len(queue) > 0 {
+		mod := queue[0]
+		queue = queue[1:]
+		if seen[mod] {
+			continue
+		}
+		seen[mod] = true
+		out = append(out, mod)
+		for re := range exports[mod] {
+			queue = append(queue, re)
+		}
+	}
+	return out
+}
+
+func compact(sorted []string) []string {
+	out := sorted[:0]
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func readFile(root, rel string) (*file, error) {
+	fh, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	f := &file{rel: rel, module: workspace.ModuleForPath(rel), idents: make(map[string]bool), qualified: make(map[string]map[string]bool)}
+	inComment := false
+	depth := 0
+	scanner := textscan.NewScanner(fh)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		var code string
+		code, inComment = swiftsrc.StripComments(scanner.Text(), inComment)
+		if mod, ok := imports.ParseLine(code); ok {
+			f.imports = append(f.imports, mod)
+			if exportedImport.MatchString(code) {
+				f.exports = append(f.exports, mod)
+			}
+			continue
+		}
+		if depth == 0 {
+			if m := declPattern.FindStringSubmatch(code); m != nil {
+				f.declared = append(f.declared, m[2])
+				if m[1] == "public" || m[1] == "open" {
+					f.public = append(f.public, m[2])
+				}
+			}
+		}
+		locs := identPattern.FindAllStringIndex(code, -1)
+		for i, loc := range locs {
+			name := code[loc[0]:loc[1]]
+			f.idents[name] = true
+			// "Module.Name" qualifies Name, unless Module is itself a
+			// member.
+			if i+1 < len(locs) && locs[i+1][0] == loc[1]+1 && code[loc[1]] == '.' && (loc[0] == 0 || code[loc[0]-1] != '.') {
+				if f.qualified[name] == nil {
+					f.qualified[name] = make(map[string]bool)
+				}
+				f.qualified[name][code[locs[i+1][0]:locs[i+1][1]]] = true
+			}
+		}
+		depth += strings.Count(code, "{") - strings.Count(code, "}")
+	}
+	return f, textscan.Check(rel, lineNo, scanner.Err())
+}
+
+// Narrow returns the dependencies using at most max of the provider's
+// symbols, fewest first.
+func (r *Report) Narrow(max int) []Pair {
+	var out []Pair
+	for _, p := range r.Pairs {
+		if len(p.Symbols) <= max {
+			out = append(out, p)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return len(out[i].Symbols) < len(out[j].Symbols) })
+	return out
+}
+
+// Heavy returns the dependencies using at least min of the provider's
+// symbols, most first.
+func (r *Report) Heavy(min int) []Pair {
+	var out []Pair
+	for _, p := range r.Pairs {
+		if len(p.Symbols) >= min {
+			out = append(out, p)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return len(out[i].Symbols) > len(out[j].Symbols) })
+	return out
+}
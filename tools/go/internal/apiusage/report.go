@@ -0,0 +1,102 @@
+package apiusage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes the narrowing and consolidation candidates, then
+// the heatmap of every dependency: one row per consumer, one column per
+// provider, each cell the number of provider symbols used.
+func WriteMarkdown(w io.Writer, r *Report, narrow, consolidate int) error {
+	narrowed, heavy := r.Narrow(narrow), r.Heavy(consolidate)
+
+	var b strings.Builder
+	b.WriteString("# Module API Usage\n\n")
+	fmt.Fprintf(&b, "**%d modules, %d dependencies, %d narrowing candidates, %d consolidation candidates**\n",
+		len(r.Modules), len(r.Pairs), len(narrowed), len(heavy))
+
+	if len(narrowed) > 0 {
+		b.WriteString("\n## Narrowing Candidates\n\n")
+		fmt.Fprintf(&b, "Dependencies using at most %d of the provider's symbols: an interface could replace them, or, with none used, the import can go.\n\n", narrow)
+		b.WriteString("| Consumer | Provider | Symbols | Files | Used |\n")
+		b.WriteString("|----------|----------|---------|-------|------|\n")
+		for _, p := range narrowed {
+			fmt.Fprintf(&b, "| %s | %s | %d of %d | %d | %s |\n", p.Consumer, p.Provider, len(p.Symbols), p.API, p.Files, names(p.Symbols))
+		}
+	}
+
+	if len(heavy) > 0 {
+		b.WriteString("\n## Consolidation Candidates\n\n")
+		fmt.Fprintf(&b, "Dependencies using at least %d of the provider's symbols.\n\n", consolidate)
+		b.WriteString("| Consumer | Provider | Symbols | Share | Files |\n")
+		b.WriteString("|----------|----------|---------|-------|-------|\n")
+		for _, p := range heavy {
+			fmt.Fprintf(&b, "| %s | %s | %d of %d | %.0f%% | %d |\n", p.Consumer, p.Provider, len(p.Symbols), p.API, 100*p.Share(), p.Files)
+		}
+	}
+
+	if len(r.Pairs) > 0 {
+		consumers, providers, cells := matrix(r)
+		b.WriteString("\n## Heatmap\n\n")
+		b.WriteString("Symbols each consumer (row) uses of each provider (column); blank where it does not import the provider.\n\n")
+		b.WriteString("| Consumer | " + strings.Join(providers, " | ") + " |\n")
+		b.WriteString("|----------" + strings.Repeat("|---", len(providers)) + "|\n")
+		for _, c := range consumers {
+			row := make([]string, len(providers))
+			for i, p := range providers {
+				if n, ok := cells[[2]string{c, p}]; ok {
+					row[i] = fmt.Sprint(n)
+				}
+			}
+			fmt.Fprintf(&b, "| %s | %s |\n", c, strings.Join(row, " | "))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report, narrow, consolidate int) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		*Report
+		Narrow      []Pair `json:"narrow"`
+		Consolidate []Pair `json:"consolidate"`
+	}{r, r.Narrow(narrow), r.Heavy(consolidate)})
+}
+
+// matrix returns the consumers and providers that take part in a
+// dependency, and the symbol count of each pair.
+func matrix(r *Report) (consumers, providers []string, cells map[[2]string]int) {
+	cells = make(map[[2]string]int)
+	isConsumer, isProvider := make(map[string]bool), make(map[string]bool)
+	for _, p := range r.Pairs {
+		cells[[2]string{p.Consumer, p.Provider}] = len(p.Symbols)
+		isConsumer[p.Consumer], isProvider[p.Provider] = true, true
+	}
+	for _, m := range r.Modules {
+		if isConsumer[m] {
+			consumers = append(consumers, m)
+		}
+		if isProvider[m] {
+			providers = append(providers, m)
+		}
+	}
+	return consumers, providers, cells
+}
+
+func names(symbols []string) string {
+	if len(symbols) == 0 {
+		return "none"
+	}
+	quoted := make([]string, len(symbols))
+	for i, s := range symbols {
+		quoted[i] = "`" + s + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
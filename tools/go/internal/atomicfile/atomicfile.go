@@ -0,0 +1,80 @@
+// Package atomicfile writes files by renaming a finished temporary file
+// over them, so a reader, or a run interrupted part-way, sees either the
+// old content or the new and never a truncated file.
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// File is a file being written in place of another. Its content replaces
+// the target only on Close.
+type File struct {
+	*os.File
+	target string
+}
+
+// Create starts writing a replacement for name, whose permission bits it
+// gets. If name is a symbolic link, the file it points to is replaced.
+// Devices such as /dev/null and named pipes cannot be replaced and are
+// written directly.
+func Create(name string, perm os.FileMode) (*File, error) {
+	if target, err := filepath.EvalSymlinks(name); err == nil {
+		name = target
+	}
+	if info, err := os.Stat(name); err == nil && !info.Mode().IsRegular() {
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_TRUNC, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &File{File: f}, nil
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return &File{File: f, target: name}, nil
+}
+
+// Close finishes the file and moves it over the target.
+func (f *File) Close() error {
+	if f.target == "" {
+		return f.File.Close()
+	}
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), f.target); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// Abort discards the file, leaving the target as it was.
+func (f *File) Abort() {
+	f.File.Close()
+	if f.target != "" {
+		os.Remove(f.Name())
+	}
+}
+
+// WriteFile is os.WriteFile, replacing name atomically.
+func WriteFile(name string, data []byte, perm os.FileMode) error {
+	f, err := Create(name, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Abort()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,319 @@
+// Package audit keeps the audit log of the tool runs that change the
+// tree: audit.log at the project root, one JSON record per line. Each
+// record holds the hash of the one before it, so that editing, deleting
+// or reordering a record breaks the chain, and is signed with an HMAC
+// when a key is given, so that a record cannot be forged without it.
+package audit
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+)
+
+// LogFile is the audit log, relative to the project root.
+const LogFile = "audit.log"
+
+// lockFile is held while a record is chained and appended, so that runs
+// appending at the same time do not both follow the same record.
+const lockFile = LogFile + ".lock"
+
+const (
+	// lockWait is how long Append waits for another run's lock.
+	lockWait = 10 * time.Second
+	// lockStale is the age past which a lock is taken to be left by a run
+	// that died holding it; appending takes milliseconds.
+	lockStale = 30 * time.Second
+)
+
+// File is a file a run wrote, moved or deleted.
+type File struct {
+	// Path is slash-separated and relative to the project root.
+	Path string `json:"path"`
+	// SHA256 is the hash of the file after the run, or empty when the
+	// run deleted or moved it away.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// Outcomes of a run.
+const (
+	OutcomeCompleted = "completed"
+	OutcomeFailed    = "failed"
+	// OutcomeCancelled is a run stopped by an interrupt or a deadline.
+	OutcomeCancelled = "cancelled"
+)
+
+// Record is one audited run.
+type Record struct {
+	// Seq numbers the records from 1.
+	Seq  int       `json:"seq"`
+	Time time.Time `json:"time"`
+	// User is the git identity of whoever ran the tool, as "Name
+	// <email>", or their login name outside git.
+	User  string   `json:"user"`
+	Tool  string   `json:"tool"`
+	Args  []string `json:"args"`
+	Files []File   `json:"files"`
+	// GitBefore and GitAfter are the commit before and after the run,
+	// suffixed -dirty when the work tree had uncommitted changes.
+	GitBefore string `json:"gitBefore"`
+	GitAfter  string `json:"gitAfter"`
+	// Backup is the backup directory of the run, for restore, if any.
+	Backup string `json:"backup,omitempty"`
+	// Outcome is how the run ended, and Error why it did not complete.
+	// Records written before outcomes were recorded have neither.
+	Outcome string `json:"outcome,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// Prev is the hash of the record before, empty for the first.
+	Prev string `json:"prev"`
+	// KeyID identifies the key the record is signed with, and Signature
+	// is the HMAC-SHA256 of Hash with it; both are empty when unsigned.
+	KeyID     string `json:"keyId,omitempty"`
+	Hash      string `json:"hash"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// digest returns the hash of r: the SHA-256 of its JSON encoding with
+// Hash and Signature left out.
+func (r Record) digest() string {
+	r.Hash, r.Signature = "", ""
+	data, _ := json.Marshal(r)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// KeyID returns the identifier records signed with key carry: the start
+// of the key's hash, which tells keys apart without revealing them.
+func KeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+func sign(key []byte, hash string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(hash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Append chains r to the log below root and appends it, signed with key
+// unless key is empty. Its Seq, Prev, KeyID, Hash and Signature are set
+// here, and its Time when zero. The log is locked from reading its last
+// record to writing r.
+func Append(root string, r Record, key []byte) (Record, error) {
+	unlock, err := lock(root)
+	if err != nil {
+		return r, err
+	}
+	defer unlock()
+
+	records, err := Load(root)
+	if err != nil {
+		return r, err
+	}
+	r.Seq, r.Prev = 1, ""
+	if n := len(records); n > 0 {
+		r.Seq, r.Prev = records[n-1].Seq+1, records[n-1].Hash
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now().UTC()
+	}
+	r.KeyID, r.Signature = "", ""
+	if len(key) > 0 {
+		r.KeyID = KeyID(key)
+	}
+	r.Hash = r.digest()
+	if len(key) > 0 {
+		r.Signature = sign(key, r.Hash)
+	}
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return r, err
+	}
+	f, err := os.OpenFile(filepath.Join(root, LogFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return r, err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return r, err
+	}
+	return r, f.Close()
+}
+
+// lock creates the lock file below root, waiting up to lockWait for
+// another run to remove it, and returns the function removing it.
+func lock(root string) (func(), error) {
+	file := filepath.Join(root, lockFile)
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(file)
+				return nil, err
+			}
+			return func() { os.Remove(file) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(file); err == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(file)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is held by another run; remove it if no umbratool run is in progress", lockFile)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Load reads the audit log below root, oldest record first. A missing log
+// has no records.
+func Load(root string) ([]Record, error) {
+	f, err := os.Open(filepath.Join(root, LogFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := textscan.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", LogFile, lineNo, err)
+		}
+		records = append(records, r)
+	}
+	return records, textscan.Check(LogFile, lineNo, scanner.Err())
+}
+
+// User returns the git identity configured for root, falling back to the
+// login name.
+func User(root string) string {
+	get := func(key string) string {
+		out, _ := exec.Command("git", "-C", root, "config", "--get", key).Output()
+		return strings.TrimSpace(string(out))
+	}
+	name, email := get("user.name"), get("user.email")
+	switch {
+	case name != "" && email != "":
+		return name + " <" + email + ">"
+	case name != "" || email != "":
+		return name + email
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// Problem is a record that fails verification.
+type Problem struct {
+	// Seq is the record's number, or 0 for a problem with the log as a
+	// whole.
+	Seq     int    `json:"seq"`
+	Message string `json:"message"`
+}
+
+// Verification is the outcome of Verify.
+type Verification struct {
+	Records int `json:"records"`
+	// Signed counts the records whose signature was checked with the key,
+	// and Unverified those signed when no key was given to check them.
+	Signed     int       `json:"signed"`
+	Unverified int       `json:"unverified"`
+	Head       string    `json:"head"`
+	Problems   []Problem `json:"problems"`
+}
+
+// Verify checks the chain of the log below root: that the records are
+// numbered in order, that each one's hash matches its content and names
+// the record before it, and that the log starts with the records
+// committed at HEAD, so none were rewritten after being committed. Signed
+// records must carry the signature of key, and without a key they fail as
+// unverified, since a forged signature would pass unseen. With
+// requireSigned every record must be signed.
+func Verify(root string, key []byte, requireSigned bool) (*Verification, error) {
+	records, err := Load(root)
+	if err != nil {
+		return nil, err
+	}
+	v := &Verification{Records: len(records), Problems: []Problem{}}
+	prev := ""
+	for i, r := range records {
+		problem := func(format string, args ...any) {
+			v.Problems = append(v.Problems, Problem{Seq: r.Seq, Message: fmt.Sprintf(format, args...)})
+		}
+		if r.Seq != i+1 {
+			problem("is record %d of the log", i+1)
+		}
+		if r.Prev != prev {
+			problem("follows %s, but the record before it hashes to %s", short(r.Prev), short(prev))
+		}
+		if d := r.digest(); r.Hash != d {
+			problem("was edited: its content hashes to %s, not %s", short(d), short(r.Hash))
+		}
+		switch {
+		case r.Signature == "":
+			if requireSigned {
+				problem("is not signed")
+			}
+		case len(key) == 0:
+			v.Unverified++
+			problem("is signed with key %s, but no key was given to check it", r.KeyID)
+		case r.KeyID != KeyID(key):
+			problem("is signed with key %s, not %s", r.KeyID, KeyID(key))
+		case !hmac.Equal([]byte(r.Signature), []byte(sign(key, r.Hash))):
+			problem("has a forged signature")
+		default:
+			v.Signed++
+		}
+		prev = r.Hash
+	}
+	v.Head = prev
+
+	if committed, err := exec.Command("git", "-C", root, "show", "HEAD:./"+LogFile).Output(); err == nil {
+		current, err := os.ReadFile(filepath.Join(root, LogFile))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if !bytes.HasPrefix(current, committed) {
+			v.Problems = append(v.Problems, Problem{Message: LogFile + " no longer starts with the records committed at HEAD"})
+		}
+	}
+	return v, nil
+}
+
+func short(hash string) string {
+	if hash == "" {
+		return "nothing"
+	}
+	return hash[:min(12, len(hash))]
+}
@@ -0,0 +1,151 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestConcurrentAppendsKeepTheChain appends from many goroutines at once;
+// without the lock, several would follow the same record.
+func TestConcurrentAppendsKeepTheChain(t *testing.T) {
+	// Appends only interleave when they can run in parallel.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+	root := t.TempDir()
+	const runs = 64
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make(chan error, runs)
+	for range runs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := Append(root, Record{Tool: "fmt-build", Files: []File{{Path: "BUILD.bazel"}}}, []byte("key"))
+			errs <- err
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	v, err := Verify(root, []byte("key"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Records != runs || len(v.Problems) > 0 {
+		t.Fatalf("%d records, problems %+v", v.Records, v.Problems)
+	}
+}
+
+// TestAppendWaitsForTheLock holds the lock while a record is appended, and
+// checks that nothing is written until it is released.
+func TestAppendWaitsForTheLock(t *testing.T) {
+	root := t.TempDir()
+	unlock, err := lock(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() {
+		_, err := Append(root, Record{Tool: "fmt-build"}, nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Append returned %v while the lock was held", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if records, err := Load(root); err != nil || len(records) > 0 {
+		t.Fatalf("log has %d records while locked (%v)", len(records), err)
+	}
+	unlock()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if records, err := Load(root); err != nil || len(records) != 1 {
+		t.Fatalf("log has %d records after unlocking (%v)", len(records), err)
+	}
+}
+
+// TestStaleLockIsTakenOver leaves a lock as a run that died would, and
+// checks that Append does not wait for it.
+func TestStaleLockIsTakenOver(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, lockFile)
+	if err := os.WriteFile(file, []byte("1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * lockStale)
+	if err := os.Chtimes(file, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Append(root, Record{Tool: "fmt-build"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("lock left behind after appending: %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	signed := t.TempDir()
+	for range 2 {
+		if _, err := Append(signed, Record{Tool: "fmt-build"}, []byte("key")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unsigned := t.TempDir()
+	if _, err := Append(unsigned, Record{Tool: "fmt-build"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	edited := t.TempDir()
+	if _, err := Append(edited, Record{Tool: "fmt-build"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(edited, LogFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = bytes.Replace(data, []byte("fmt-build"), []byte("umbrellas"), 1)
+	if err := os.WriteFile(filepath.Join(edited, LogFile), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name, root    string
+		key           string
+		requireSigned bool
+		problems      int
+		verified      int
+		unverified    int
+	}{
+		{"signed with the key", signed, "key", true, 0, 2, 0},
+		{"signed without a key", signed, "", false, 2, 0, 2},
+		{"signed with another key", signed, "other", false, 2, 0, 0},
+		{"unsigned", unsigned, "", false, 0, 0, 0},
+		{"unsigned when required", unsigned, "key", true, 1, 0, 0},
+		{"edited", edited, "", false, 1, 0, 0},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			v, err := Verify(c.root, []byte(c.key), c.requireSigned)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(v.Problems) != c.problems || v.Signed != c.verified || v.Unverified != c.unverified {
+				t.Errorf("got %d problems, %d verified, %d unverified; want %d, %d, %d: %+v",
+					len(v.Problems), v.Signed, v.Unverified, c.problems, c.verified, c.unverified, v.Problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+// Package backup creates timestamped backup directories with a hash
+// manifest and restores workspaces from them.
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+)
+
+// ManifestName is the manifest file written at the root of a backup
+// directory.
+const ManifestName = "backup_manifest.json"
+
+// Entry records one backed-up file.
+type Entry struct {
+	// Path is slash-separated and relative to both the workspace root and
+	// the backup directory.
+	Path string `json:"path"`
+	// SHA256 is the hash of the file as it was backed up.
+	SHA256 string `json:"sha256"`
+	// ResultSHA256 is the hash of the workspace file after the tool
+	// changed it, or empty if the tool deleted it or never recorded it.
+	ResultSHA256 string `json:"resultSha256,omitempty"`
+}
+
+// Manifest describes a backup directory.
+type Manifest struct {
+	Tool    string    `json:"tool"`
+	Created time.Time `json:"created"`
+	Files   []Entry   `json:"files"`
+}
+
+// Backup is a backup directory being written by a mutating tool.
+type Backup struct {
+	Root string
+	Dir  string
+
+	manifest Manifest
+	index    map[string]int
+}
+
+// New creates dir (typically "<tool>_backup_<timestamp>") and returns a
+// Backup for files under root.
+func New(root, dir, tool string) (*Backup, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Backup{
+		Root:     root,
+		Dir:      dir,
+		manifest: Manifest{Tool: tool, Created: time.Now().UTC()},
+		index:    make(map[string]int),
+	}, nil
+}
+
+// DirName returns the conventional backup directory name for tool.
+func DirName(tool string, t time.Time) string {
+	return fmt.Sprintf("%s_backup_%s", tool, t.Format("20060102_150405"))
+}
+
+// Save copies the workspace file rel into the backup before it is
+// modified. Saving the same file twice keeps the first copy.
+func (b *Backup) Save(rel string) error {
+	rel = filepath.ToSlash(rel)
+	if _, ok := b.index[rel]; ok {
+		return nil
+	}
+
+	sum, err := copyFile(filepath.Join(b.Root, rel), filepath.Join(b.Dir, rel))
+	if err != nil {
+		return err
+	}
+	b.index[rel] = len(b.manifest.Files)
+	b.manifest.Files = append(b.manifest.Files, Entry{Path: rel, SHA256: sum})
+	return nil
+}
+
+// RecordResult records the hash of rel after the tool has changed it, so a
+// later restore can tell whether anyone edited the file since.
+func (b *Backup) RecordResult(rel string) error {
+	rel = filepath.ToSlash(rel)
+	i, ok := b.index[rel]
+	if !ok {
+		return fmt.Errorf("%s was not backed up", rel)
+	}
+
+	sum, err := HashFile(filepath.Join(b.Root, rel))
+	if errors.Is(err, os.ErrNotExist) {
+		b.manifest.Files[i].ResultSHA256 = ""
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	b.manifest.Files[i].ResultSHA256 = sum
+	return nil
+}
+
+// Close writes the manifest.
+func (b *Backup) Close() error {
+	sort.Slice(b.manifest.Files, func(i, j int) bool { return b.manifest.Files[i].Path < b.manifest.Files[j].Path })
+	data, err := json.MarshalIndent(b.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(filepath.Join(b.Dir, ManifestName), append(data, '\n'), 0o644)
+}
+
+// LoadManifest reads the manifest of a backup directory. It returns nil
+// without error for legacy backups that have no manifest.
+func LoadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", ManifestName, err)
+	}
+	return &m, nil
+}
+
+// HashFile returns the hex SHA-256 of the file at path.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyFile copies src to dst, creating parent directories and preserving
+// the permission bits, and returns the SHA-256 of the copied content.
+func copyFile(src, dst string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", err
+	}
+
+	out, err := atomicfile.Create(dst, info.Mode().Perm())
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, h), in); err != nil {
+		out.Abort()
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
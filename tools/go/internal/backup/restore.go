@@ -0,0 +1,149 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// Action is what a restore will do with one file.
+type Action string
+
+const (
+	// ActionUnchanged means the workspace already matches the backup.
+	ActionUnchanged Action = "unchanged"
+	// ActionRestore means the file can be restored safely: it is missing or
+	// still exactly as the backing-up tool left it.
+	ActionRestore Action = "restore"
+	// ActionConflict means the file was edited after the backup was taken
+	// and is only overwritten with force.
+	ActionConflict Action = "conflict"
+	// ActionCorrupt means the backup copy no longer matches its manifest
+	// hash and is never restored.
+	ActionCorrupt Action = "corrupt"
+)
+
+// Item is the planned restore of one file.
+type Item struct {
+	Path   string `json:"path"`
+	Action Action `json:"action"`
+	Reason string `json:"reason"`
+	hash   string
+}
+
+// Plan compares the backup in dir with the workspace at root. It fails,
+// before anything is restored, when the manifest names a path that is
+// absolute or leads outside the workspace.
+func Plan(root, dir string) ([]Item, error) {
+	manifest, err := LoadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]Entry)
+	if manifest != nil {
+		for _, e := range manifest.Files {
+			if err := checkPath(e.Path); err != nil {
+				return nil, fmt.Errorf("%s: %w", filepath.Join(dir, ManifestName), err)
+			}
+			entries[e.Path] = e
+		}
+	} else {
+		// Legacy backups have no manifest: every file in the directory is a
+		// backed-up workspace file at the same relative path.
+		skip := append(append([]string(nil), walker.DefaultSkipDirs...), filepath.Base(dir))
+		err := walker.Walk(dir, walker.Options{SkipDirs: skip}, func(rel string) error {
+			entries[rel] = Entry{Path: rel}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	items := make([]Item, 0, len(entries))
+	for _, e := range entries {
+		item, err := planFile(root, dir, e, manifest != nil)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Path < items[j].Path })
+	return items, nil
+}
+
+// checkPath rejects a manifest path that would lead outside the workspace
+// or the backup directory, as a hand-edited or corrupt manifest may hold.
+func checkPath(p string) error {
+	native := filepath.FromSlash(p)
+	clean := path.Clean(filepath.ToSlash(p))
+	switch {
+	case p == "" || clean == ".":
+		return fmt.Errorf("entry with an empty path")
+	case path.IsAbs(clean) || filepath.IsAbs(native) || filepath.VolumeName(native) != "":
+		return fmt.Errorf("entry %q is absolute", p)
+	case clean == ".." || strings.HasPrefix(clean, "../"):
+		return fmt.Errorf("entry %q leads outside the workspace", p)
+	}
+	return nil
+}
+
+func planFile(root, dir string, e Entry, haveManifest bool) (Item, error) {
+	item := Item{Path: e.Path}
+
+	backupHash, err := HashFile(filepath.Join(dir, filepath.FromSlash(e.Path)))
+	if err != nil {
+		item.Action, item.Reason = ActionCorrupt, "backup copy unreadable: "+err.Error()
+		return item, nil
+	}
+	item.hash = backupHash
+	if haveManifest && backupHash != e.SHA256 {
+		item.Action, item.Reason = ActionCorrupt, "backup copy does not match manifest hash"
+		return item, nil
+	}
+
+	current, err := HashFile(filepath.Join(root, filepath.FromSlash(e.Path)))
+	switch {
+	case os.IsNotExist(err):
+		item.Action, item.Reason = ActionRestore, "missing from workspace"
+	case err != nil:
+		return item, err
+	case current == backupHash:
+		item.Action, item.Reason = ActionUnchanged, "workspace matches backup"
+	case haveManifest && e.ResultSHA256 != "" && current == e.ResultSHA256:
+		item.Action, item.Reason = ActionRestore, "unchanged since the tool modified it"
+	case haveManifest && e.ResultSHA256 != "":
+		item.Action, item.Reason = ActionConflict, "modified since the backup was taken"
+	default:
+		item.Action, item.Reason = ActionConflict, "differs from backup; no record of the tool's result"
+	}
+	return item, nil
+}
+
+// Apply restores the planned items. Conflicts are restored only with
+// force; corrupt backups are never restored. Every restored file is
+// re-hashed to verify the copy.
+func Apply(root, dir string, items []Item, force bool) (restored int, err error) {
+	for _, item := range items {
+		if item.Action != ActionRestore && !(force && item.Action == ActionConflict) {
+			continue
+		}
+
+		dst := filepath.Join(root, filepath.FromSlash(item.Path))
+		sum, err := copyFile(filepath.Join(dir, filepath.FromSlash(item.Path)), dst)
+		if err != nil {
+			return restored, fmt.Errorf("restoring %s: %w", item.Path, err)
+		}
+		if written, err := HashFile(dst); err != nil || written != item.hash || sum != item.hash {
+			return restored, fmt.Errorf("restoring %s: hash verification failed", item.Path)
+		}
+		restored++
+	}
+	return restored, nil
+}
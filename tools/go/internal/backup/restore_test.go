@@ -0,0 +1,47 @@
+package backup
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testfixture"
+)
+
+// TestPlanRejectsEscapingPaths writes a manifest entry for each way out of
+// the workspace and checks that Plan refuses it, so Apply never runs.
+func TestPlanRejectsEscapingPaths(t *testing.T) {
+	for _, bad := range []string{"../outside.swift", "Sources/../../outside.swift", "/etc/outside.swift", ".."} {
+		t.Run(bad, func(t *testing.T) {
+			root := testfixture.Write(t, map[string]string{
+				"Sources/Core/Kept.swift":        "struct Kept {}\n",
+				"backup/Sources/Core/Kept.swift": "struct Kept {}\n",
+			})
+			dir := filepath.Join(root, "backup")
+			sum, err := HashFile(filepath.Join(dir, "Sources/Core/Kept.swift"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			manifest, err := json.Marshal(Manifest{Tool: "test", Files: []Entry{
+				{Path: "Sources/Core/Kept.swift", SHA256: sum},
+				{Path: bad, SHA256: sum},
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, ManifestName), manifest, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			items, err := Plan(root, dir)
+			if err == nil {
+				t.Fatalf("Plan accepted %q: %+v", bad, items)
+			}
+			if !strings.Contains(err.Error(), ManifestName) {
+				t.Errorf("error %q does not name the manifest", err)
+			}
+		})
+	}
+}
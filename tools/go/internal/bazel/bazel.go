@@ -0,0 +1,157 @@
+// Package bazel runs Bazel subprocesses through a shared, rate-limited
+// pool. Bazel serialises commands on its server anyway, so launching one
+// bazelisk per target concurrently only thrashes the machine.
+package bazel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Runner limits how many Bazel processes run at once and how quickly new
+// ones are started.
+type Runner struct {
+	// Binary is the executable to run, "bazelisk" or "bazel".
+	Binary string
+	// Dir is the workspace directory commands run in.
+	Dir string
+	// Shared, when set, is a directory the Runner shares with Runners in
+	// other processes, so that the limits hold across all of them rather
+	// than in each alone. They must be given the same limits.
+	Shared string
+
+	slots    chan struct{}
+	interval time.Duration
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// Default settings, overridden by the --bazel-jobs and --bazel-interval
+// flags.
+var (
+	DefaultJobs     = 1
+	DefaultInterval = 200 * time.Millisecond
+)
+
+// NewRunnerWithLimits returns a Runner for the workspace at dir allowing
+// jobs concurrent processes started at least interval apart. It prefers
+// bazelisk when it is on PATH.
+func NewRunnerWithLimits(dir string, jobs int, interval time.Duration) *Runner {
+	if jobs < 1 {
+		jobs = 1
+	}
+	binary := "bazel"
+	if _, err := exec.LookPath("bazelisk"); err == nil {
+		binary = "bazelisk"
+	}
+	return &Runner{Binary: binary, Dir: dir, slots: make(chan struct{}, jobs), interval: interval}
+}
+
+// acquire waits for a slot and for the launch interval to pass, and
+// returns the function releasing the slot.
+func (r *Runner) acquire(ctx context.Context) (func(), error) {
+	select {
+	case r.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if r.Shared == "" {
+		if err := r.wait(ctx); err != nil {
+			<-r.slots
+			return nil, err
+		}
+		return func() { <-r.slots }, nil
+	}
+
+	release, err := r.takeSlot(ctx)
+	if err == nil {
+		err = r.waitShared(ctx)
+		if err != nil {
+			release()
+		}
+	}
+	if err != nil {
+		<-r.slots
+		return nil, err
+	}
+	return func() {
+		release()
+		<-r.slots
+	}, nil
+}
+
+// Run executes the Bazel command and returns its stdout. Stderr is
+// included in the error when the command fails.
+func (r *Runner) Run(ctx context.Context, args ...string) ([]byte, error) {
+	release, err := r.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	cmd := exec.CommandContext(ctx, r.Binary, args...)
+	cmd.Dir = r.Dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return stdout.Bytes(), fmt.Errorf("%s %s: %w\n%s", r.Binary, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// Combined executes the Bazel command and returns its stdout and stderr
+// interleaved, as a terminal would show them. The output is returned
+// even when the command fails, for builds run with --keep_going whose
+// failures are what the caller wants to read.
+func (r *Runner) Combined(ctx context.Context, args ...string) ([]byte, error) {
+	release, err := r.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	cmd := exec.CommandContext(ctx, r.Binary, args...)
+	cmd.Dir = r.Dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return out, fmt.Errorf("%s %s: %w", r.Binary, strings.Join(args, " "), err)
+	}
+	return out, nil
+}
+
+// Query runs "bazel query" with the given expression and extra flags and
+// returns the non-empty output lines.
+func (r *Runner) Query(ctx context.Context, expr string, flags ...string) ([]string, error) {
+	args := append([]string{"query", expr, "--noshow_progress"}, flags...)
+	out, err := r.Run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// wait enforces the minimum interval between process launches.
+func (r *Runner) wait(ctx context.Context) error {
+	r.mu.Lock()
+	delay := time.Until(r.last.Add(r.interval))
+	if delay < 0 {
+		delay = 0
+	}
+	r.last = time.Now().Add(delay)
+	r.mu.Unlock()
+	return sleep(ctx, delay)
+}
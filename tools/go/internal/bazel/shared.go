@@ -0,0 +1,136 @@
+package bazel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// slotStale is the age past which a slot or launch lock is taken to be
+	// left by a process that died holding it. A process running Bazel
+	// touches its slot every slotRefresh, however long the command takes.
+	slotStale   = 30 * time.Second
+	slotRefresh = 5 * time.Second
+	// slotPoll is how often a process waiting for a slot looks again.
+	slotPoll = 20 * time.Millisecond
+)
+
+// takeSlot creates one of the slot files in r.Shared, waiting until one of
+// them is free, and returns the function removing it again.
+func (r *Runner) takeSlot(ctx context.Context) (func(), error) {
+	for {
+		for n := range cap(r.slots) {
+			file := filepath.Join(r.Shared, fmt.Sprintf("bazel-slot-%d", n))
+			ok, err := create(file)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			stop, done := make(chan struct{}), make(chan struct{})
+			go func() {
+				defer close(done)
+				t := time.NewTicker(slotRefresh)
+				defer t.Stop()
+				for {
+					select {
+					case now := <-t.C:
+						os.Chtimes(file, now, now)
+					case <-stop:
+						return
+					}
+				}
+			}()
+			return func() {
+				close(stop)
+				<-done
+				os.Remove(file)
+			}, nil
+		}
+		if err := sleep(ctx, slotPoll); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// waitShared enforces the minimum interval between launches across the
+// processes sharing r.Shared, which keep the time the next launch may
+// start in a file there.
+func (r *Runner) waitShared(ctx context.Context) error {
+	lock := filepath.Join(r.Shared, "bazel-launch.lock")
+	for {
+		ok, err := create(lock)
+		if err != nil {
+			return err
+		}
+		if ok {
+			break
+		}
+		if err := sleep(ctx, slotPoll); err != nil {
+			return err
+		}
+	}
+
+	file := filepath.Join(r.Shared, "bazel-launch")
+	var next time.Time
+	if data, err := os.ReadFile(file); err == nil {
+		next, _ = time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+	}
+	delay := max(time.Until(next), 0)
+	start := time.Now().Add(delay)
+	err := os.WriteFile(file, []byte(start.Add(r.interval).Format(time.RFC3339Nano)+"\n"), 0o644)
+	os.Remove(lock)
+	if err != nil {
+		return err
+	}
+	return sleep(ctx, delay)
+}
+
+// create creates file, holding this process's id, and reports whether it
+// did; false means another process holds it. A file older than slotStale
+// is removed and created afresh.
+func create(file string) (bool, error) {
+	for {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(file)
+				return false, err
+			}
+			return true, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return false, err
+		}
+		info, err := os.Stat(file)
+		if err != nil || time.Since(info.ModTime()) <= slotStale {
+			return false, nil
+		}
+		os.Remove(file)
+	}
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,39 @@
+package bazel
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+)
+
+// Runners sharing a directory hold the jobs limit between them, as the
+// steps of run do from separate processes. Each command fails if another
+// is running at the same time.
+func TestSharedLimit(t *testing.T) {
+	dir, shared := t.TempDir(), t.TempDir()
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 2 {
+		r := NewRunnerWithLimits(dir, 1, 0)
+		r.Binary, r.Shared = "sh", shared
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := r.Run(context.Background(), "-c", "mkdir busy && sleep 0.02 && rmdir busy")
+				errs <- err
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if entries, _ := os.ReadDir(shared); len(entries) != 1 || entries[0].Name() != "bazel-launch" {
+		t.Errorf("left in the shared directory: %v", entries)
+	}
+}
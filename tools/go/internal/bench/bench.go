@@ -0,0 +1,282 @@
+// Package bench benchmarks the analyzers' hot paths on a project tree, so
+// a slowdown of the tools themselves is caught before it reaches CI.
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/complexity"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/health"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// Case is one benchmark. Setup prepares its inputs from the tree at root,
+// outside the timed loop, and returns the operation to time.
+type Case struct {
+	Name        string
+	Description string
+	Setup       func(root string) (func() error, error)
+}
+
+// Cases are the benchmarks, cheapest first.
+var Cases = []Case{
+	{"walk", "Walk Sources for Swift files", setupWalk},
+	{"strip-comments", "Strip comments from every line of Sources, read beforehand", setupStrip},
+	{"count-lines", "Count the lines of Sources as budgets does", setupCount},
+	{"imports", "Scan the imports of Sources", setupImports},
+	{"complexity", "Measure lines and function complexity of Sources", setupComplexity},
+	{"metrics", "Fill and write the complexity metric set, analysed beforehand", setupMetrics},
+	{"health-score", "Score every module from inputs gathered beforehand", setupHealthScore},
+}
+
+// Result is the measurement of one case, averaged over its iterations.
+type Result struct {
+	Name        string `json:"name"`
+	Iterations  int    `json:"iterations"`
+	NsPerOp     int64  `json:"nsPerOp"`
+	BytesPerOp  int64  `json:"bytesPerOp"`
+	AllocsPerOp int64  `json:"allocsPerOp"`
+}
+
+// Run runs the named cases (every case when names is empty) count times
+// each on root and keeps the fastest run of each, the one least disturbed
+// by other load on the machine.
+func Run(root string, names []string, count int) ([]Result, error) {
+	selected, err := selectCases(names)
+	if err != nil {
+		return nil, err
+	}
+	count = max(count, 1)
+
+	var out []Result
+	for _, c := range selected {
+		op, err := c.Setup(root)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Name, err)
+		}
+		var best Result
+		for range count {
+			r, err := measure(op)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", c.Name, err)
+			}
+			if best.Iterations == 0 || r.NsPerOp < best.NsPerOp {
+				best = r
+			}
+		}
+		best.Name = c.Name
+		out = append(out, best)
+	}
+	return out, nil
+}
+
+func selectCases(names []string) ([]Case, error) {
+	if len(names) == 0 {
+		return Cases, nil
+	}
+	var out []Case
+	for _, name := range names {
+		found := false
+		for _, c := range Cases {
+			if c.Name == name {
+				out = append(out, c)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown benchmark %q", name)
+		}
+	}
+	return out, nil
+}
+
+// measure times op, repeating it with growing iteration counts until a
+// run takes a second, as go test -bench does, and stops at the first
+// error. The Benchmark functions in the packages' tests time the same
+// paths on fixture trees; this times them on the project tree without
+// linking the testing package into the binary.
+func measure(op func() error) (Result, error) {
+	n := 1
+	for {
+		elapsed, r, err := run(op, n)
+		if err != nil {
+			return Result{}, err
+		}
+		if elapsed >= time.Second || n >= 1e9 {
+			return r, nil
+		}
+		// Aim for a second from the rate so far, growing by at least a
+		// fifth and at most a hundredfold.
+		next := int64(n) * 100
+		if elapsed > 0 {
+			next = min(next, int64(time.Second)*int64(n)/int64(elapsed)*6/5)
+		}
+		n = int(min(max(next, int64(n)+int64(n)/5, int64(n)+1), 1e9))
+	}
+}
+
+// run calls op n times and returns the time taken and the per-call cost.
+func run(op func() error, n int) (time.Duration, Result, error) {
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for range n {
+		if err := op(); err != nil {
+			return 0, Result{}, err
+		}
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return elapsed, Result{
+		Iterations:  n,
+		NsPerOp:     elapsed.Nanoseconds() / int64(n),
+		BytesPerOp:  int64(after.TotalAlloc-before.TotalAlloc) / int64(n),
+		AllocsPerOp: int64(after.Mallocs-before.Mallocs) / int64(n),
+	}, nil
+}
+
+func swiftFiles(root string) ([]string, error) {
+	var paths []string
+	err := walker.Walk(filepath.Join(root, "Sources"), walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+		paths = append(paths, rel)
+		return nil
+	})
+	return paths, err
+}
+
+func setupWalk(root string) (func() error, error) {
+	return func() error {
+		_, err := swiftFiles(root)
+		return err
+	}, nil
+}
+
+func setupStrip(root string) (func() error, error) {
+	paths, err := swiftFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	var contents []string
+	for _, rel := range paths {
+		data, err := os.ReadFile(filepath.Join(root, "Sources", rel))
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, string(data))
+	}
+	return func() error {
+		for _, content := range contents {
+			inComment := false
+			for line := range strings.Lines(content) {
+				_, inComment = swiftsrc.StripComments(line, inComment)
+			}
+		}
+		return nil
+	}, nil
+}
+
+func setupCount(root string) (func() error, error) {
+	return func() error {
+		_, err := complexity.Count(root)
+		return err
+	}, nil
+}
+
+func setupImports(root string) (func() error, error) {
+	return func() error {
+		_, err := imports.ScanTree(root, "Sources")
+		return err
+	}, nil
+}
+
+func setupComplexity(root string) (func() error, error) {
+	return func() error {
+		_, err := complexity.Analyse(root)
+		return err
+	}, nil
+}
+
+func setupMetrics(root string) (func() error, error) {
+	report, err := complexity.Analyse(root)
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		s := metrics.NewSet()
+		for _, m := range report.Modules {
+			s.Gauge("module_lines_of_code", "Lines of code per module.", float64(m.Code), "module", m.Name)
+			s.Gauge("module_complexity", "Total cyclomatic complexity per module.", float64(m.Complexity), "module", m.Name)
+		}
+		for _, f := range report.Files {
+			s.Gauge("file_complexity", "Total cyclomatic complexity per file.", float64(f.Complexity), "module", f.Module, "file", f.Path)
+		}
+		return s.Write(io.Discard)
+	}, nil
+}
+
+func setupHealthScore(root string) (func() error, error) {
+	in, err := health.Gather(root, filepath.Join(root, "refactoring_plan.yaml"), nil)
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		_, err := health.Score(root, in, health.DefaultWeights)
+		return err
+	}, nil
+}
+
+// Regression is a case that got slower than its baseline allows.
+type Regression struct {
+	Name        string  `json:"name"`
+	Baseline    int64   `json:"baselineNsPerOp"`
+	Current     int64   `json:"nsPerOp"`
+	Slowdown    float64 `json:"slowdown"`
+	Fingerprint string  `json:"fingerprint,omitempty"`
+}
+
+// LoadResults reads the results of an earlier run from its JSON report.
+func LoadResults(file string) ([]Result, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var report struct {
+		Results []Result `json:"results"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return report.Results, nil
+}
+
+// Compare returns the cases of results more than maxSlowdown percent
+// slower than the same case in baseline. Cases missing from baseline are
+// new and never regress.
+func Compare(results, baseline []Result, maxSlowdown float64) []Regression {
+	before := make(map[string]int64, len(baseline))
+	for _, r := range baseline {
+		before[r.Name] = r.NsPerOp
+	}
+	var out []Regression
+	for _, r := range results {
+		ns, ok := before[r.Name]
+		if !ok || ns <= 0 {
+			continue
+		}
+		if slowdown := 100 * float64(r.NsPerOp-ns) / float64(ns); slowdown > maxSlowdown {
+			out = append(out, Regression{Name: r.Name, Baseline: ns, Current: r.NsPerOp, Slowdown: slowdown})
+		}
+	}
+	return out
+}
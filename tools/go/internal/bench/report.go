@@ -0,0 +1,71 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteMarkdown writes the regressions against the baseline, when there is
+// one, then every result.
+func WriteMarkdown(w io.Writer, results []Result, regressions []Regression, maxSlowdown float64) error {
+	var b strings.Builder
+	b.WriteString("# Analyzer Benchmarks\n\n")
+	fmt.Fprintf(&b, "**%d benchmarks, %d more than %.0f%% slower than the baseline**\n", len(results), len(regressions), maxSlowdown)
+
+	if len(regressions) > 0 {
+		b.WriteString("\n## Regressions\n\n")
+		b.WriteString("| Benchmark | Baseline | Now | Slowdown |\n")
+		b.WriteString("|-----------|----------|-----|----------|\n")
+		for _, r := range regressions {
+			fmt.Fprintf(&b, "| %s | %s | %s | %+.0f%% |\n", r.Name, duration(r.Baseline), duration(r.Current), r.Slowdown)
+		}
+	}
+
+	b.WriteString("\n## Results\n\n")
+	b.WriteString("| Benchmark | Time/op | Allocated/op | Allocations/op | Iterations |\n")
+	b.WriteString("|-----------|---------|--------------|----------------|------------|\n")
+	for _, r := range results {
+		fmt.Fprintf(&b, "| %s | %s | %s | %d | %d |\n", r.Name, duration(r.NsPerOp), size(r.BytesPerOp), r.AllocsPerOp, r.Iterations)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the results, which a later run reads back as its
+// baseline, and the regressions as indented JSON.
+func WriteJSON(w io.Writer, results []Result, regressions []Regression) error {
+	if regressions == nil {
+		regressions = []Regression{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Results     []Result     `json:"results"`
+		Regressions []Regression `json:"regressions"`
+	}{results, regressions})
+}
+
+func duration(ns int64) string {
+	d := time.Duration(ns)
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	}
+	return d.Round(100 * time.Nanosecond).String()
+}
+
+func size(bytes int64) string {
+	switch {
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", bytes)
+}
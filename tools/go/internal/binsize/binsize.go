@@ -0,0 +1,542 @@
+// Package binsize measures built archives and frameworks with nm and
+// size, and attributes their symbols back to the modules and files they
+// come from. A Swift symbol names its module in its mangling, so code one
+// module specialises or inlines from another is counted against the
+// module it comes from; other symbols count against the module whose
+// target built the binary. Within an archive, an object file is matched
+// to the Swift file it was compiled from by name.
+package binsize
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// Options configures Measure.
+type Options struct {
+	// Paths are the archives, frameworks and directories to measure,
+	// relative to the project root; directories are searched for .a
+	// files and .framework bundles.
+	Paths []string
+	// Targets, when set, restrict the binaries to those built by the
+	// targets with these names or module names.
+	Targets []string
+	// Dirs are the top-level directories holding the Swift sources that
+	// object files are matched to.
+	Dirs []string
+	// Rules map targets and files to their modules.
+	Rules []modulenames.Rule
+	// NM and Size are the commands run; they default to nm and size.
+	NM, Size string
+}
+
+// Binary is one measured archive or framework binary.
+type Binary struct {
+	Path   string `json:"path"`
+	Label  string `json:"label,omitempty"`
+	Module string `json:"module"`
+	// Text, Data and BSS are the section totals size reports.
+	Text int64 `json:"text"`
+	Data int64 `json:"data"`
+	BSS  int64 `json:"bss"`
+}
+
+// Total is the binary's size in memory.
+func (b Binary) Total() int64 {
+	return b.Text + b.Data + b.BSS
+}
+
+// Symbol is a defined symbol and where it is attributed.
+type Symbol struct {
+	Name   string `json:"name"`
+	Module string `json:"module"`
+	// File is the Swift file the symbol's object was compiled from, when
+	// the symbol belongs to the module that compiled it.
+	File   string `json:"file,omitempty"`
+	Binary string `json:"binary"`
+	Kind   string `json:"kind"`
+	Size   int64  `json:"size"`
+	// Estimated is set when nm gave no size, as for Mach-O, and the size
+	// is the distance to the next symbol of the object.
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// Module is the symbol size attributed to one module.
+type Module struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	Symbols int    `json:"symbols"`
+}
+
+// File is the symbol size attributed to one Swift file.
+type File struct {
+	Path   string `json:"path"`
+	Module string `json:"module"`
+	Size   int64  `json:"size"`
+}
+
+// Report is the outcome of Measure.
+type Report struct {
+	Binaries []Binary `json:"binaries"`
+	// Modules and Files are sorted largest first; Symbols too.
+	Modules []Module `json:"modules"`
+	Files   []File   `json:"files"`
+	Symbols []Symbol `json:"symbols"`
+	// Estimated is set when any symbol's size is estimated.
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// Measure runs nm and size on the binaries below opts.Paths of root.
+func Measure(root string, opts Options) (*Report, error) {
+	if opts.NM == "" {
+		opts.NM = "nm"
+	}
+	if opts.Size == "" {
+		opts.Size = "size"
+	}
+	ix := &moduleindex.Index{Modules: opts.Rules}
+	known := make(map[string]bool, len(opts.Rules))
+	for _, r := range opts.Rules {
+		known[r.ModuleName] = true
+	}
+
+	binaries, err := find(root, opts.Paths)
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool, len(opts.Targets))
+	for _, t := range opts.Targets {
+		wanted[t] = true
+	}
+	sources, err := sourceFiles(root, opts.Dirs, ix)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	modules := make(map[string]*Module)
+	files := make(map[string]*File)
+	for _, bin := range binaries {
+		b := Binary{Path: bin.rel}
+		b.Label, b.Module = target(bin.rel, bin.name, ix)
+		if len(wanted) > 0 && !wanted[bin.name] && !wanted[b.Module] {
+			continue
+		}
+		if err := sectionSizes(root, opts.Size, &b); err != nil {
+			return nil, err
+		}
+		symbols, err := symbolSizes(root, opts.NM, bin.rel)
+		if err != nil {
+			return nil, err
+		}
+		report.Binaries = append(report.Binaries, b)
+
+		for _, s := range symbols {
+			s.Binary = b.Path
+			s.Module = b.Module
+			if m := mangledModule(s.Name); known[m] {
+				s.Module = m
+			}
+			if s.Module == b.Module {
+				s.File = sources[b.Module][objectBase(s.File)]
+			} else {
+				s.File = ""
+			}
+			report.Symbols = append(report.Symbols, s)
+			report.Estimated = report.Estimated || s.Estimated
+
+			m := modules[s.Module]
+			if m == nil {
+				m = &Module{Name: s.Module}
+				modules[s.Module] = m
+			}
+			m.Size += s.Size
+			m.Symbols++
+			if s.File != "" {
+				f := files[s.File]
+				if f == nil {
+					f = &File{Path: s.File, Module: s.Module}
+					files[s.File] = f
+				}
+				f.Size += s.Size
+			}
+		}
+	}
+	if len(report.Binaries) == 0 {
+		return nil, fmt.Errorf("no archives or frameworks found in %s", strings.Join(opts.Paths, ", "))
+	}
+
+	for _, m := range modules {
+		report.Modules = append(report.Modules, *m)
+	}
+	for _, f := range files {
+		report.Files = append(report.Files, *f)
+	}
+	sort.Slice(report.Modules, func(i, j int) bool {
+		a, b := report.Modules[i], report.Modules[j]
+		return a.Size > b.Size || a.Size == b.Size && a.Name < b.Name
+	})
+	sort.Slice(report.Files, func(i, j int) bool {
+		a, b := report.Files[i], report.Files[j]
+		return a.Size > b.Size || a.Size == b.Size && a.Path < b.Path
+	})
+	sort.SliceStable(report.Symbols, func(i, j int) bool { return report.Symbols[i].Size > report.Symbols[j].Size })
+	return report, nil
+}
+
+// binary is an archive or a framework's binary, and the target name its
+// path implies.
+type binary struct {
+	rel, name string
+}
+
+// find returns the binaries at or below paths, sorted by path. Bazel's
+// output directories are symlinks, so each path is resolved first.
+func find(root string, paths []string) ([]binary, error) {
+	var out []binary
+	for _, p := range paths {
+		base := filepath.Join(root, filepath.FromSlash(p))
+		resolved, err := filepath.EvalSymlinks(base)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s does not exist; build the targets first", p)
+		}
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(resolved)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() || strings.HasSuffix(resolved, ".framework") {
+			if b, ok := classify(p, resolved, info.IsDir()); ok {
+				out = append(out, b)
+				continue
+			}
+			return nil, fmt.Errorf("%s is not an archive or a framework", p)
+		}
+		err = filepath.WalkDir(resolved, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(resolved, file)
+			rel = path.Join(p, filepath.ToSlash(rel))
+			if d.IsDir() && strings.HasSuffix(d.Name(), ".framework") {
+				if b, ok := classify(rel, file, true); ok {
+					out = append(out, b)
+				}
+				return filepath.SkipDir
+			}
+			if d.IsDir() && (strings.HasSuffix(d.Name(), "_objs") || d.Name() == "external") {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() {
+				if b, ok := classify(rel, file, false); ok {
+					out = append(out, b)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].rel < out[j].rel })
+	return out, nil
+}
+
+// classify recognises libName.a archives and Name.framework bundles,
+// whose binary is Name inside them.
+func classify(rel, file string, dir bool) (binary, bool) {
+	name := path.Base(rel)
+	switch {
+	case dir && strings.HasSuffix(name, ".framework"):
+		name = strings.TrimSuffix(name, ".framework")
+		if _, err := os.Stat(filepath.Join(file, name)); err != nil {
+			return binary{}, false
+		}
+		return binary{rel: path.Join(rel, name), name: name}, true
+	case !dir && strings.HasSuffix(name, ".a"):
+		return binary{rel: rel, name: strings.TrimPrefix(strings.TrimSuffix(name, ".a"), "lib")}, true
+	}
+	return binary{}, false
+}
+
+// target returns the label of the target that built the binary rel,
+// found from the package its path mirrors below bazel-bin, and that
+// target's module. A binary no rule builds is its own module.
+func target(rel, name string, ix *moduleindex.Index) (string, string) {
+	dir := path.Dir(rel)
+	if strings.HasSuffix(dir, ".framework") {
+		dir = path.Dir(dir)
+	}
+	for parts := strings.Split(dir, "/"); len(parts) > 0; parts = parts[1:] {
+		label := "//" + strings.Join(parts, "/") + ":" + name
+		for _, r := range ix.Modules {
+			if r.Label == label {
+				return label, r.ModuleName
+			}
+		}
+	}
+	for _, r := range ix.Named(name) {
+		return r.Label, r.ModuleName
+	}
+	return "", name
+}
+
+// sourceFiles indexes the Swift files below dirs by module and then by
+// base name, the name their object files are given.
+func sourceFiles(root string, dirs []string, ix *moduleindex.Index) (map[string]map[string]string, error) {
+	out := make(map[string]map[string]string)
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			rel = path.Clean(filepath.ToSlash(filepath.Join(dir, rel)))
+			r, ok := ix.ForPath(rel)
+			if !ok {
+				return nil
+			}
+			if out[r.ModuleName] == nil {
+				out[r.ModuleName] = make(map[string]string)
+			}
+			out[r.ModuleName][strings.TrimSuffix(path.Base(rel), ".swift")] = rel
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// objectBase strips an object file's name, "Foo.swift.o" or "Foo.o", to
+// the base name of its source.
+func objectBase(member string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(path.Base(member), ".o"), ".swift")
+}
+
+// mangledModule returns the module a Swift symbol's mangling names, as
+// CoreDTOs in $s8CoreDTOs..., or "" for any other symbol.
+func mangledModule(name string) string {
+	name = strings.TrimPrefix(name, "_")
+	rest, ok := strings.CutPrefix(name, "$s")
+	if !ok {
+		return ""
+	}
+	n := 0
+	for n < len(rest) && rest[n] >= '0' && rest[n] <= '9' {
+		n++
+	}
+	length, err := strconv.Atoi(rest[:n])
+	if err != nil || n+length > len(rest) {
+		return ""
+	}
+	return rest[n : n+length]
+}
+
+// sectionSizes fills b's section totals from size's Berkeley format,
+// summing the objects of an archive.
+func sectionSizes(root, command string, b *Binary) error {
+	out, err := run(command, filepath.Join(root, filepath.FromSlash(b.Path)))
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) == 0 {
+		return nil
+	}
+	columns := strings.Fields(lines[0])
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		for i, name := range columns {
+			if i >= len(fields) {
+				break
+			}
+			n, err := strconv.ParseInt(fields[i], 10, 64)
+			if err != nil {
+				continue
+			}
+			// Mach-O size has __TEXT, __DATA and __OBJC columns.
+			switch strings.ToLower(strings.Trim(name, "_")) {
+			case "text":
+				b.Text += n
+			case "data", "objc":
+				b.Data += n
+			case "bss":
+				b.BSS += n
+			}
+		}
+	}
+	return nil
+}
+
+// symbolSizes lists the defined symbols of the binary rel with nm's
+// portable format, "lib.a[Foo.o]: name T value size", in decimal. File
+// holds the object file until Measure matches it to a source. Symbols
+// after a line longer than textscan.MaxLine are warned about and left out.
+func symbolSizes(root, command, rel string) ([]Symbol, error) {
+	out, err := run(command, "-P", "-A", "-t", "d", filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, err
+	}
+	type entry struct {
+		sym   Symbol
+		value int64
+		sized bool
+	}
+	objects := make(map[string][]*entry)
+	var order []string
+	scanner := textscan.NewScanner(bytes.NewReader(out))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		prefix, rest, ok := strings.Cut(scanner.Text(), ": ")
+		fields := strings.Fields(rest)
+		if !ok || len(fields) < 3 || !defined(fields[1]) {
+			continue
+		}
+		object := ""
+		if i := strings.LastIndex(prefix, "["); i >= 0 && strings.HasSuffix(prefix, "]") {
+			object = prefix[i+1 : len(prefix)-1]
+		}
+		value, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		e := &entry{sym: Symbol{Name: fields[0], Kind: fields[1], File: object}, value: value}
+		if len(fields) > 3 {
+			if e.sym.Size, err = strconv.ParseInt(fields[3], 10, 64); err == nil && e.sym.Size > 0 {
+				e.sized = true
+			}
+		}
+		key := object + "\x00" + strings.ToUpper(fields[1])
+		if objects[key] == nil {
+			order = append(order, key)
+		}
+		objects[key] = append(objects[key], e)
+	}
+	if err := textscan.Check(command+" output for "+rel, lineNo, scanner.Err()); err != nil {
+		return nil, err
+	}
+
+	var symbols []Symbol
+	for _, key := range order {
+		entries := objects[key]
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].value < entries[j].value })
+		for i, e := range entries {
+			if !e.sized && i+1 < len(entries) {
+				e.sym.Size, e.sym.Estimated = entries[i+1].value-e.value, true
+			}
+			if e.sym.Size > 0 {
+				symbols = append(symbols, e.sym)
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// defined reports whether an nm symbol type is a definition in code or
+// data, rather than an undefined, weak-undefined or debugging symbol.
+func defined(kind string) bool {
+	switch strings.ToUpper(kind) {
+	case "T", "D", "B", "R", "S":
+		return true
+	}
+	return false
+}
+
+func run(command string, args ...string) ([]byte, error) {
+	cmd := exec.Command(command, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && stderr.Len() > 0 {
+			return nil, fmt.Errorf("%s: %s", command, strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("%s: %w", command, err)
+	}
+	return out, nil
+}
+
+// Delta is a module's size change from a baseline.
+type Delta struct {
+	Module string `json:"module"`
+	Before int64  `json:"before"`
+	After  int64  `json:"after"`
+	// Fingerprint is set on growth, which is reported as an issue.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Change is the growth in bytes, negative for savings.
+func (d Delta) Change() int64 {
+	return d.After - d.Before
+}
+
+// LoadReport reads an earlier run's JSON report.
+func LoadReport(file string) (*Report, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var envelope struct {
+		Report *Report `json:"report"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if envelope.Report == nil {
+		return nil, fmt.Errorf("%s is not a binary-size JSON report", file)
+	}
+	return envelope.Report, nil
+}
+
+// Compare returns the modules whose size differs between baseline and
+// report, largest change first; modules in only one have a size of 0 in
+// the other.
+func Compare(report, baseline *Report) []Delta {
+	before := make(map[string]int64)
+	for _, m := range baseline.Modules {
+		before[m.Name] = m.Size
+	}
+	var out []Delta
+	for _, m := range report.Modules {
+		if m.Size != before[m.Name] {
+			out = append(out, Delta{Module: m.Name, Before: before[m.Name], After: m.Size})
+		}
+		delete(before, m.Name)
+	}
+	for name, size := range before {
+		out = append(out, Delta{Module: name, Before: size})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := abs(out[i].Change()), abs(out[j].Change())
+		return a > b || a == b && out[i].Module < out[j].Module
+	})
+	return out
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,124 @@
+package binsize
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes the binaries' section totals, the size per module,
+// the largest files and symbols, and the changes since the baseline when
+// there is one (deltas is nil without one).
+func WriteMarkdown(w io.Writer, r *Report, deltas []Delta, top int) error {
+	var b strings.Builder
+	b.WriteString("# Binary Size\n\n")
+
+	var text, data, bss, symbols int64
+	for _, bin := range r.Binaries {
+		text, data, bss = text+bin.Text, data+bin.Data, bss+bin.BSS
+	}
+	for _, m := range r.Modules {
+		symbols += m.Size
+	}
+	fmt.Fprintf(&b, "%s: %s of text, %s of data and %s of bss. Their symbols add up to %s.", plural(len(r.Binaries), "binary", "binaries"), size(text), size(data), size(bss), size(symbols))
+	if r.Estimated {
+		b.WriteString(" nm gave no symbol sizes, so each is estimated as the distance to the next symbol of its object file.")
+	}
+	b.WriteString("\n")
+
+	if deltas != nil {
+		b.WriteString("\n## Changes Since Baseline\n\n")
+		if len(deltas) == 0 {
+			b.WriteString("No module changed size.\n")
+		} else {
+			var total int64
+			for _, d := range deltas {
+				total += d.Change()
+			}
+			fmt.Fprintf(&b, "%s changed size, %s in total.\n\n", plural(len(deltas), "module", "modules"), change(total))
+			b.WriteString("| Module | Before | After | Change |\n|--------|--------|-------|--------|\n")
+			for _, d := range deltas {
+				fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", d.Module, size(d.Before), size(d.After), change(d.Change()))
+			}
+		}
+	}
+
+	b.WriteString("\n## Modules\n\n| Module | Size | Share | Symbols |\n|--------|------|-------|---------|\n")
+	for _, m := range r.Modules {
+		share := 0.0
+		if symbols > 0 {
+			share = 100 * float64(m.Size) / float64(symbols)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %.1f%% | %d |\n", m.Name, size(m.Size), share, m.Symbols)
+	}
+
+	if len(r.Files) > 0 {
+		b.WriteString("\n## Largest Files\n\n| File | Module | Size |\n|------|--------|------|\n")
+		for _, f := range limit(r.Files, top) {
+			fmt.Fprintf(&b, "| `%s` | %s | %s |\n", f.Path, f.Module, size(f.Size))
+		}
+	}
+
+	if len(r.Symbols) > 0 {
+		b.WriteString("\n## Largest Symbols\n\n| Symbol | Module | File | Size |\n|--------|--------|------|------|\n")
+		for _, s := range limit(r.Symbols, top) {
+			file := "–"
+			if s.File != "" {
+				file = "`" + s.File + "`"
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", s.Name, s.Module, file, size(s.Size))
+		}
+	}
+
+	b.WriteString("\n## Binaries\n\n| Binary | Module | Text | Data | BSS | Total |\n|--------|--------|------|------|-----|-------|\n")
+	for _, bin := range r.Binaries {
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s | %s |\n", bin.Path, bin.Module, size(bin.Text), size(bin.Data), size(bin.BSS), size(bin.Total()))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report, which a later run reads back as its
+// baseline, and the changes since the baseline as indented JSON.
+func WriteJSON(w io.Writer, r *Report, deltas []Delta) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Report *Report `json:"report"`
+		Deltas []Delta `json:"deltas,omitempty"`
+	}{r, deltas})
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+func limit[T any](items []T, n int) []T {
+	if n > 0 && len(items) > n {
+		return items[:n]
+	}
+	return items
+}
+
+func size(bytes int64) string {
+	switch {
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", bytes)
+}
+
+// change renders a size change with its sign, "+1.2 KB" or "-300 B".
+func change(bytes int64) string {
+	if bytes < 0 {
+		return "-" + size(-bytes)
+	}
+	return "+" + size(bytes)
+}
@@ -0,0 +1,82 @@
+package budget
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+)
+
+// BaselineFile is the default baseline, relative to the project root.
+const BaselineFile = ".budgets-baseline.json"
+
+// Baseline lists the violations accepted when budgets were introduced or
+// last tightened.
+type Baseline struct {
+	Accept []Accepted `json:"accept"`
+}
+
+// Accepted is one accepted violation at the size it had then.
+type Accepted struct {
+	Kind  string `json:"kind"`
+	Path  string `json:"path"`
+	Value int    `json:"value"`
+}
+
+// LoadBaseline reads a baseline file. A missing file is an empty baseline.
+func LoadBaseline(file string) (*Baseline, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return &Baseline{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Apply records on each violation the value the baseline accepts, and
+// returns the accepted entries no longer over budget.
+func (b *Baseline) Apply(violations []Violation) []Accepted {
+	type key struct{ kind, path string }
+	accepted := make(map[key]Accepted, len(b.Accept))
+	for _, a := range b.Accept {
+		accepted[key{a.Kind, a.Path}] = a
+	}
+	for i, v := range violations {
+		k := key{v.Kind, v.Path}
+		if a, ok := accepted[k]; ok {
+			violations[i].Baselined = a.Value
+			delete(accepted, k)
+		}
+	}
+	var resolved []Accepted
+	for _, a := range b.Accept {
+		if _, ok := accepted[key{a.Kind, a.Path}]; ok {
+			resolved = append(resolved, a)
+		}
+	}
+	return resolved
+}
+
+// NewBaseline returns a baseline accepting exactly the given violations.
+func NewBaseline(violations []Violation) *Baseline {
+	b := &Baseline{Accept: []Accepted{}}
+	for _, v := range violations {
+		b.Accept = append(b.Accept, Accepted{Kind: v.Kind, Path: v.Path, Value: v.Value})
+	}
+	return b
+}
+
+// Write saves the baseline as indented JSON.
+func (b *Baseline) Write(file string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(file, append(data, '\n'), 0o644)
+}
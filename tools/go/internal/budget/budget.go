@@ -0,0 +1,179 @@
+// Package budget checks file and module sizes against the budgets of
+// umbratool.yaml. Violations already present are recorded in a baseline,
+// so that only regressions fail: new violations, and baselined ones that
+// have grown.
+package budget
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/complexity"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/configschema"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// Budget kinds.
+const (
+	KindFileLines   = "fileLines"
+	KindModuleFiles = "moduleFiles"
+	KindModuleLines = "moduleLines"
+)
+
+// Limits are the budgets; zero inherits the enclosing limit and a
+// negative value lifts it.
+type Limits struct {
+	// FileLines caps the lines of a file, blank and comment lines
+	// included.
+	FileLines int `yaml:"fileLines"`
+	// ModuleFiles caps the Swift files of a module.
+	ModuleFiles int `yaml:"moduleFiles"`
+	// ModuleLines caps the lines of code of a module, blank and comment
+	// lines excluded.
+	ModuleLines int `yaml:"moduleLines"`
+}
+
+// Override replaces limits for the files matching Path or the modules
+// matching Module, both walker.Match globs.
+type Override struct {
+	Path   string `yaml:"path"`
+	Module string `yaml:"module"`
+	Limits `yaml:",inline"`
+}
+
+// Config is the budgets section of umbratool.yaml:
+//
+//	budgets:
+//	  fileLines: 600
+//	  moduleFiles: 80
+//	  moduleLines: 12000
+//	  overrides:
+//	    - path: Sources/SecurityImplementation/**
+//	      fileLines: 900
+//	    - module: UmbraMocks
+//	      moduleLines: -1
+//
+// Overrides apply in order, so a later one wins over an earlier one.
+type Config struct {
+	Limits    `yaml:",inline"`
+	Overrides []Override `yaml:"overrides"`
+}
+
+// LoadConfig reads the budgets section of a config file. A file without
+// one has no budgets.
+func LoadConfig(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := configschema.Validate(configschema.KindUmbratool, file, data); err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Budgets Config `yaml:"budgets"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for i, o := range doc.Budgets.Overrides {
+		if (o.Path == "") == (o.Module == "") {
+			return nil, fmt.Errorf("%s: budget override %d: set exactly one of path and module", file, i+1)
+		}
+	}
+	return &doc.Budgets, nil
+}
+
+// forFile returns the file line limit of rel.
+func (c *Config) forFile(rel string) int {
+	limit := c.FileLines
+	for _, o := range c.Overrides {
+		if o.Path != "" && o.FileLines != 0 && walker.Match(o.Path, rel) {
+			limit = o.FileLines
+		}
+	}
+	return limit
+}
+
+// forModule returns the module limits of name.
+func (c *Config) forModule(name string) Limits {
+	limits := c.Limits
+	for _, o := range c.Overrides {
+		if o.Module == "" || !walker.Match(o.Module, name) {
+			continue
+		}
+		if o.ModuleFiles != 0 {
+			limits.ModuleFiles = o.ModuleFiles
+		}
+		if o.ModuleLines != 0 {
+			limits.ModuleLines = o.ModuleLines
+		}
+	}
+	return limits
+}
+
+// Violation is a file or module over its budget.
+type Violation struct {
+	Kind string `json:"kind"`
+	// Path is the file, or the module name for module budgets.
+	Path  string `json:"path"`
+	Value int    `json:"value"`
+	Limit int    `json:"limit"`
+	// Module is the module of the file or the module itself.
+	Module string `json:"module"`
+	// Baselined is the value the baseline accepts, or zero for a new
+	// violation.
+	Baselined int `json:"baselined,omitempty"`
+	// Fingerprint identifies a regression's issue across runs.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Regression reports whether v fails the check: new, or grown since it
+// was baselined.
+func (v Violation) Regression() bool {
+	return v.Baselined == 0 || v.Value > v.Baselined
+}
+
+func exceeds(value, limit int) bool {
+	return limit > 0 && value > limit
+}
+
+// Check returns the files and modules of report over their budgets,
+// sorted by kind and path.
+func Check(report *complexity.Report, c *Config) []Violation {
+	var out []Violation
+	for _, f := range report.Files {
+		if limit := c.forFile(f.Path); exceeds(f.Lines, limit) {
+			out = append(out, Violation{Kind: KindFileLines, Path: f.Path, Value: f.Lines, Limit: limit, Module: f.Module})
+		}
+	}
+	for _, m := range report.Modules {
+		limits := c.forModule(m.Name)
+		if exceeds(m.Files, limits.ModuleFiles) {
+			out = append(out, Violation{Kind: KindModuleFiles, Path: m.Name, Value: m.Files, Limit: limits.ModuleFiles, Module: m.Name})
+		}
+		if exceeds(m.Code, limits.ModuleLines) {
+			out = append(out, Violation{Kind: KindModuleLines, Path: m.Name, Value: m.Code, Limit: limits.ModuleLines, Module: m.Name})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Path < out[j].Path
+	})
+	return out
+}
+
+// Regressions returns the violations that fail the check.
+func Regressions(violations []Violation) []Violation {
+	var out []Violation
+	for _, v := range violations {
+		if v.Regression() {
+			out = append(out, v)
+		}
+	}
+	return out
+}
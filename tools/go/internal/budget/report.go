@@ -0,0 +1,88 @@
+package budget
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var kindTitles = map[string]string{
+	KindFileLines:   "lines",
+	KindModuleFiles: "files",
+	KindModuleLines: "lines of code",
+}
+
+// WriteMarkdown writes the regressions, then the baselined violations and
+// the baseline entries now within budget.
+func WriteMarkdown(w io.Writer, c *Config, violations []Violation, resolved []Accepted) error {
+	regressions := Regressions(violations)
+
+	var b strings.Builder
+	b.WriteString("# Size Budgets\n\n")
+	fmt.Fprintf(&b, "Budgets: %s lines per file, %s files and %s lines of code per module.\n\n",
+		limit(c.FileLines), limit(c.ModuleFiles), limit(c.ModuleLines))
+	fmt.Fprintf(&b, "**%d over budget, %d regressions, %d baselined violations resolved**\n",
+		len(violations), len(regressions), len(resolved))
+
+	if len(regressions) > 0 {
+		b.WriteString("\n## Regressions\n\n")
+		b.WriteString("| Budget | Path | Size | Limit | Baselined |\n")
+		b.WriteString("|--------|------|------|-------|-----------|\n")
+		for _, v := range regressions {
+			baselined := "new"
+			if v.Baselined > 0 {
+				baselined = fmt.Sprint(v.Baselined)
+			}
+			fmt.Fprintf(&b, "| %s | `%s` | %d | %d | %s |\n", v.Kind, v.Path, v.Value, v.Limit, baselined)
+		}
+	}
+
+	var kept []Violation
+	for _, v := range violations {
+		if !v.Regression() {
+			kept = append(kept, v)
+		}
+	}
+	if len(kept) > 0 {
+		b.WriteString("\n## Baselined\n\n")
+		b.WriteString("| Budget | Path | Size | Limit | Baselined |\n")
+		b.WriteString("|--------|------|------|-------|-----------|\n")
+		for _, v := range kept {
+			fmt.Fprintf(&b, "| %s | `%s` | %d | %d | %d |\n", v.Kind, v.Path, v.Value, v.Limit, v.Baselined)
+		}
+	}
+
+	if len(resolved) > 0 {
+		b.WriteString("\n## Resolved\n\nThese are within budget now; `--update-baseline` drops them.\n\n")
+		for _, a := range resolved {
+			fmt.Fprintf(&b, "- `%s` %s (baselined at %d %s)\n", a.Path, a.Kind, a.Value, kindTitles[a.Kind])
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func limit(n int) string {
+	if n <= 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(n)
+}
+
+// WriteJSON writes the violations and resolved entries as indented JSON.
+func WriteJSON(w io.Writer, violations []Violation, resolved []Accepted) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if violations == nil {
+		violations = []Violation{}
+	}
+	if resolved == nil {
+		resolved = []Accepted{}
+	}
+	return enc.Encode(struct {
+		Violations []Violation `json:"violations"`
+		Resolved   []Accepted  `json:"resolved"`
+	}{violations, resolved})
+}
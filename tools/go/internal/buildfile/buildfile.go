@@ -0,0 +1,440 @@
+// Package buildfile edits Bazel BUILD files through the buildtools parser
+// so that every tool produces consistently formatted, de-duplicated output
+// instead of splicing strings into the file.
+package buildfile
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// File is a parsed BUILD file.
+type File struct {
+	// Path is the file's location on disk.
+	Path string
+	// Package is the Bazel package the file belongs to, e.g. "Sources/Core".
+	Package string
+
+	syntax   *build.File
+	original []byte
+}
+
+// Load parses the BUILD file at filename. pkg is its Bazel package path,
+// used to normalise relative labels.
+func Load(filename, pkg string) (*File, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(filename, pkg, data)
+}
+
+// Parse parses BUILD file content.
+func Parse(filename, pkg string, data []byte) (*File, error) {
+	syntax, err := build.ParseBuild(filename, data)
+	if err != nil {
+		return nil, err
+	}
+	return &File{Path: filename, Package: filepath.ToSlash(pkg), syntax: syntax, original: data}, nil
+}
+
+// New parses data as the content of a BUILD file not yet written, which
+// Save then writes whether or not it was edited.
+func New(filename, pkg string, data []byte) (*File, error) {
+	f, err := Parse(filename, pkg, data)
+	if err != nil {
+		return nil, err
+	}
+	f.original = nil
+	return f, nil
+}
+
+// Rule returns the rule called name, or nil.
+func (f *File) Rule(name string) *build.Rule {
+	return f.syntax.RuleNamed(name)
+}
+
+// Rules returns every rule of the given kind ("" for all rules).
+func (f *File) Rules(kind string) []*build.Rule {
+	return f.syntax.Rules(kind)
+}
+
+// TestOnly reports whether the rule called name sets testonly = True.
+func (f *File) TestOnly(name string) bool {
+	rule := f.Rule(name)
+	if rule == nil {
+		return false
+	}
+	ident, ok := rule.Attr("testonly").(*build.Ident)
+	return ok && ident.Name == "True"
+}
+
+// Syntax exposes the underlying buildtools syntax tree for edits not
+// covered by this package.
+func (f *File) Syntax() *build.File {
+	return f.syntax
+}
+
+// AddDep adds label to the attr list (usually "deps") of target unless an
+// equivalent label is already present. It reports whether the file changed.
+func (f *File) AddDep(target, attr, label string) (bool, error) {
+	list, err := f.list(target, attr, true)
+	if err != nil {
+		return false, err
+	}
+	if f.indexOf(list, label) >= 0 {
+		return false, nil
+	}
+	list.List = append(list.List, &build.StringExpr{Value: label})
+	return true, nil
+}
+
+// RemoveDep removes every entry equivalent to label from the attr list of
+// target, reporting whether the file changed.
+func (f *File) RemoveDep(target, attr, label string) (bool, error) {
+	list, err := f.list(target, attr, false)
+	if err != nil || list == nil {
+		return false, err
+	}
+
+	changed := false
+	for i := f.indexOf(list, label); i >= 0; i = f.indexOf(list, label) {
+		f.removeAt(list, i, nil)
+		changed = true
+	}
+	return changed, nil
+}
+
+// CommentOutDep replaces the entry for label in the attr list of target with
+// a comment, keeping a record of the dependency (and why it was disabled)
+// in the file. It reports whether the file changed.
+func (f *File) CommentOutDep(target, attr, label, reason string) (bool, error) {
+	list, err := f.list(target, attr, false)
+	if err != nil || list == nil {
+		return false, err
+	}
+
+	i := f.indexOf(list, label)
+	if i < 0 {
+		return false, nil
+	}
+
+	comment := fmt.Sprintf("# %q,", label)
+	if reason != "" {
+		comment += "  # " + reason
+	}
+	f.removeAt(list, i, []build.Comment{{Token: comment}})
+	return true, nil
+}
+
+// EnsureGlob makes the attr (usually "srcs") of target a glob containing
+// patterns. An explicit list is replaced; an existing glob gains any missing
+// patterns. It reports whether the file changed.
+func (f *File) EnsureGlob(target, attr string, patterns []string) (bool, error) {
+	rule := f.Rule(target)
+	if rule == nil {
+		return false, fmt.Errorf("%s: no rule named %q", f.Path, target)
+	}
+
+	if call, ok := rule.Attr(attr).(*build.CallExpr); ok {
+		if ident, ok := call.X.(*build.Ident); ok && ident.Name == "glob" && len(call.List) > 0 {
+			list, ok := call.List[0].(*build.ListExpr)
+			if !ok {
+				return false, fmt.Errorf("%s: %s.%s glob has a non-literal pattern list", f.Path, target, attr)
+			}
+			changed := false
+			for _, p := range patterns {
+				if !containsString(list, p) {
+					list.List = append(list.List, &build.StringExpr{Value: p})
+					changed = true
+				}
+			}
+			return changed, nil
+		}
+	}
+
+	values := make([]build.Expr, 0, len(patterns))
+	for _, p := range patterns {
+		values = append(values, &build.StringExpr{Value: p})
+	}
+	rule.SetAttr(attr, &build.CallExpr{
+		X:    &build.Ident{Name: "glob"},
+		List: []build.Expr{&build.ListExpr{List: values}},
+	})
+	return true, nil
+}
+
+// AddSource adds src, a path relative to the package, to the attr list
+// (usually "srcs") of target. A plain list gains the entry; a glob or a
+// sum ending in a glob becomes glob(...) + [src]. It reports whether the
+// file changed.
+func (f *File) AddSource(target, attr, src string) (bool, error) {
+	rule := f.Rule(target)
+	if rule == nil {
+		return false, fmt.Errorf("%s: no rule named %q", f.Path, target)
+	}
+
+	switch v := rule.Attr(attr).(type) {
+	case nil, *build.ListExpr:
+		return f.AddDep(target, attr, src)
+	case *build.BinaryExpr:
+		if list, ok := v.Y.(*build.ListExpr); ok && v.Op == "+" {
+			if f.indexOf(list, src) >= 0 {
+				return false, nil
+			}
+			list.List = append(list.List, &build.StringExpr{Value: src})
+			return true, nil
+		}
+	case *build.CallExpr:
+	default:
+		return false, fmt.Errorf("%s: %s.%s is neither a list nor a glob", f.Path, target, attr)
+	}
+	rule.SetAttr(attr, &build.BinaryExpr{
+		X:  rule.Attr(attr),
+		Op: "+",
+		Y:  &build.ListExpr{List: []build.Expr{&build.StringExpr{Value: src}}},
+	})
+	return true, nil
+}
+
+// AddTag adds tag to the tags of target unless it carries it already,
+// reporting whether the file changed. Unlike AddDep it compares tags as
+// plain strings and keeps a new list on one line.
+func (f *File) AddTag(target, tag string) (bool, error) {
+	rule := f.Rule(target)
+	if rule == nil {
+		return false, fmt.Errorf("%s: no rule named %q", f.Path, target)
+	}
+
+	switch v := rule.Attr("tags").(type) {
+	case nil:
+		rule.SetAttr("tags", &build.ListExpr{List: []build.Expr{&build.StringExpr{Value: tag}}})
+	case *build.ListExpr:
+		if containsString(v, tag) {
+			return false, nil
+		}
+		v.List = append(v.List, &build.StringExpr{Value: tag})
+	default:
+		return false, fmt.Errorf("%s: %s.tags is not a plain list", f.Path, target)
+	}
+	return true, nil
+}
+
+// Normalise removes duplicate entries (by normalised label) from every
+// deps-like list in the file and reports whether anything was removed.
+// Ordering is left to the buildtools rewriter applied by Format.
+func (f *File) Normalise() bool {
+	changed := false
+	for _, rule := range f.syntax.Rules("") {
+		for _, attr := range []string{"deps", "data", "exports", "runtime_deps", "implementation_deps"} {
+			list, ok := rule.Attr(attr).(*build.ListExpr)
+			if !ok {
+				continue
+			}
+			seen := make(map[string]bool)
+			for i := 0; i < len(list.List); i++ {
+				s, ok := list.List[i].(*build.StringExpr)
+				if !ok {
+					continue
+				}
+				key := f.normalise(s.Value)
+				if seen[key] {
+					f.removeAt(list, i, nil)
+					i--
+					changed = true
+					continue
+				}
+				seen[key] = true
+			}
+		}
+	}
+	return changed
+}
+
+// Format returns the canonical buildifier formatting of the file.
+func (f *File) Format() []byte {
+	return build.Format(f.syntax)
+}
+
+// Changed reports whether the formatted file differs from what was loaded.
+func (f *File) Changed() bool {
+	return !bytes.Equal(f.Format(), f.original)
+}
+
+// Save writes the formatted file back to Path if its content changed.
+func (f *File) Save() error {
+	out := f.Format()
+	if bytes.Equal(out, f.original) {
+		return nil
+	}
+	if err := atomicfile.WriteFile(f.Path, out, 0o644); err != nil {
+		return err
+	}
+	f.original = out
+	return nil
+}
+
+func (f *File) list(target, attr string, create bool) (*build.ListExpr, error) {
+	rule := f.Rule(target)
+	if rule == nil {
+		return nil, fmt.Errorf("%s: no rule named %q", f.Path, target)
+	}
+
+	switch v := rule.Attr(attr).(type) {
+	case nil:
+		if !create {
+			return nil, nil
+		}
+		list := &build.ListExpr{ForceMultiLine: true}
+		rule.SetAttr(attr, list)
+		return list, nil
+	case *build.ListExpr:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("%s: %s.%s is not a plain list", f.Path, target, attr)
+	}
+}
+
+func (f *File) indexOf(list *build.ListExpr, label string) int {
+	want := f.normalise(label)
+	for i, e := range list.List {
+		if s, ok := e.(*build.StringExpr); ok && f.normalise(s.Value) == want {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeAt deletes list element i, carrying its own comments and extra
+// onto the following element (or the end of the list) so nothing
+// hand-written is lost.
+func (f *File) removeAt(list *build.ListExpr, i int, extra []build.Comment) {
+	removed := list.List[i].Comment()
+	carried := append(append([]build.Comment(nil), removed.Before...), extra...)
+	list.List = append(list.List[:i], list.List[i+1:]...)
+	if len(carried) == 0 {
+		return
+	}
+
+	if i < len(list.List) {
+		next := list.List[i].Comment()
+		next.Before = append(carried, next.Before...)
+		return
+	}
+	list.End.Before = append(list.End.Before, carried...)
+	list.ForceMultiLine = true
+}
+
+// normalise expands a label to its canonical //pkg:name form so that
+// "//Sources/Core", "//Sources/Core:Core" and ":Core" inside Sources/Core
+// compare equal.
+func (f *File) normalise(label string) string {
+	return NormaliseLabel(f.Package, label)
+}
+
+// NormaliseLabel expands label relative to pkg into //pkg:name form.
+// External repository prefixes are kept.
+func NormaliseLabel(pkg, label string) string {
+	repo := ""
+	if strings.HasPrefix(label, "@") {
+		idx := strings.Index(label, "//")
+		if idx < 0 {
+			return label
+		}
+		repo, label = label[:idx], label[idx:]
+	}
+
+	switch {
+	case strings.HasPrefix(label, ":"):
+		label = "//" + pkg + label
+	case !strings.HasPrefix(label, "//"):
+		label = "//" + pkg + ":" + label
+	}
+	if !strings.Contains(label, ":") {
+		label += ":" + path.Base(label)
+	}
+	return repo + label
+}
+
+func containsString(list *build.ListExpr, value string) bool {
+	for _, e := range list.List {
+		if s, ok := e.(*build.StringExpr); ok && s.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// GlobArgs returns the include and exclude patterns of a glob call.
+// Patterns that are not string literals are left out.
+func GlobArgs(call *build.CallExpr) (include, exclude []string) {
+	values := func(x build.Expr) []string {
+		var out []string
+		if l, ok := x.(*build.ListExpr); ok {
+			for _, v := range l.List {
+				if s, ok := v.(*build.StringExpr); ok {
+					out = append(out, s.Value)
+				}
+			}
+		}
+		return out
+	}
+	for i, arg := range call.List {
+		if a, ok := arg.(*build.AssignExpr); ok {
+			if id, ok := a.LHS.(*build.Ident); ok {
+				switch id.Name {
+				case "include":
+					include = values(a.RHS)
+				case "exclude":
+					exclude = values(a.RHS)
+				}
+			}
+			continue
+		}
+		if i == 0 {
+			include = values(arg)
+		}
+	}
+	return include, exclude
+}
+
+// Compiles reports whether the attr list of rule (usually "srcs") names
+// src, a path relative to the package, or holds a glob capturing it.
+func Compiles(rule *build.Rule, attr, src string) bool {
+	var found bool
+	var visit func(build.Expr)
+	visit = func(x build.Expr) {
+		switch x := x.(type) {
+		case *build.BinaryExpr:
+			visit(x.X)
+			visit(x.Y)
+		case *build.ListExpr:
+			found = found || containsString(x, src)
+		case *build.CallExpr:
+			if id, ok := x.X.(*build.Ident); ok && id.Name == "glob" {
+				include, exclude := GlobArgs(x)
+				found = found || matchAny(include, src) && !matchAny(exclude, src)
+			}
+		}
+	}
+	visit(rule.Attr(attr))
+	return found
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if walker.Match(p, rel) {
+			return true
+		}
+	}
+	return false
+}
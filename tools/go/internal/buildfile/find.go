@@ -0,0 +1,37 @@
+package buildfile
+
+import (
+	"path"
+	"sort"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// IsBuildFile reports whether name is a Bazel BUILD file name.
+func IsBuildFile(name string) bool {
+	return name == "BUILD.bazel" || name == "BUILD"
+}
+
+// FindAll returns the slash-separated paths, relative to root, of every
+// BUILD file in the workspace.
+func FindAll(root string) ([]string, error) {
+	var files []string
+	err := walker.Walk(root, walker.Options{}, func(rel string) error {
+		if IsBuildFile(path.Base(rel)) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// PackageOf returns the Bazel package of a BUILD file path relative to the
+// workspace root.
+func PackageOf(rel string) string {
+	dir := path.Dir(rel)
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
@@ -0,0 +1,260 @@
+// Package buildtimes tracks the action time of each Swift target across
+// nightly builds. Each run records the times from a Bazel profile with the
+// targets' code and deps; a target much slower than it was in the runs
+// before is a regression, and the change in its code and deps since the
+// last run suggests why.
+package buildtimes
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/complexity"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+)
+
+// Tool is the name runs are recorded under in the result store.
+const Tool = "build-times"
+
+// IssueRegression is the kind of the issue recorded for a regression.
+const IssueRegression = "build_time_regression"
+
+// Metrics recorded per target.
+const (
+	MetricSeconds = "target_build_seconds"
+	MetricCode    = "target_code_lines"
+	MetricDep     = "target_dep"
+)
+
+// Target is one profiled target.
+type Target struct {
+	Label  string `json:"label"`
+	Module string `json:"module"`
+	// File is the BUILD file declaring Label.
+	File    string  `json:"file,omitempty"`
+	Seconds float64 `json:"seconds"`
+	Code    int     `json:"code"`
+	// Deps are the target's workspace deps, sorted.
+	Deps []string `json:"deps,omitempty"`
+}
+
+// Measure returns the targets of rules that the profile has times for,
+// slowest first, with their code counted from counts.
+func Measure(counts *complexity.Report, rules []modulenames.Rule, times map[string]time.Duration) []Target {
+	index := &moduleindex.Index{Modules: rules}
+	code := make(map[string]int)
+	for _, f := range counts.Files {
+		if r, ok := index.ForPath(f.Path); ok {
+			code[r.Label] += f.Code
+		}
+	}
+	known := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		known[r.Label] = true
+	}
+
+	var targets []Target
+	for _, r := range rules {
+		t, ok := times[r.Label]
+		if !ok {
+			continue
+		}
+		target := Target{Label: r.Label, Module: r.ModuleName, File: r.File, Seconds: t.Seconds(), Code: code[r.Label]}
+		for _, dep := range r.Deps {
+			if known[dep] && dep != r.Label && !slices.Contains(target.Deps, dep) {
+				target.Deps = append(target.Deps, dep)
+			}
+		}
+		sort.Strings(target.Deps)
+		targets = append(targets, target)
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		if targets[i].Seconds != targets[j].Seconds {
+			return targets[i].Seconds > targets[j].Seconds
+		}
+		return targets[i].Label < targets[j].Label
+	})
+	return targets
+}
+
+// Fill adds the targets' samples to s, for Load to read back.
+func Fill(s *metrics.Set, targets []Target) {
+	for _, t := range targets {
+		s.Gauge(MetricSeconds, "Action time of each profiled target in seconds.", t.Seconds, "module", t.Module, "target", t.Label)
+		s.Gauge(MetricCode, "Lines of code of each profiled target.", float64(t.Code), "module", t.Module, "target", t.Label)
+		for _, dep := range t.Deps {
+			s.Gauge(MetricDep, "Workspace deps of each profiled target, one sample per dep.", 1, "module", t.Module, "target", t.Label, "dep", dep)
+		}
+	}
+}
+
+// Snapshot is one recorded run's targets, by label.
+type Snapshot struct {
+	RunID   int64             `json:"runId"`
+	GitSHA  string            `json:"gitSha"`
+	Targets map[string]Target `json:"-"`
+}
+
+// Load reads the last limit runs of Tool from db, newest first.
+func Load(ctx context.Context, db *store.DB, limit int) ([]Snapshot, error) {
+	runs, err := db.Runs(ctx, Tool, limit)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, run := range runs {
+		snap := Snapshot{RunID: run.ID, GitSHA: run.GitSHA, Targets: make(map[string]Target)}
+		samples, err := db.RunSamples(ctx, run.ID, MetricSeconds)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range samples {
+			label := s.Label("target")
+			snap.Targets[label] = Target{Label: label, Module: s.Label("module"), Seconds: s.Value}
+		}
+		for _, metric := range []string{MetricCode, MetricDep} {
+			samples, err := db.RunSamples(ctx, run.ID, metric)
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range samples {
+				t, ok := snap.Targets[s.Label("target")]
+				if !ok {
+					continue
+				}
+				if metric == MetricCode {
+					t.Code = int(s.Value)
+				} else {
+					t.Deps = append(t.Deps, s.Label("dep"))
+				}
+				snap.Targets[t.Label] = t
+			}
+		}
+		snapshots = append(snapshots, snap)
+	}
+	return snapshots, nil
+}
+
+// Options configures the comparison.
+type Options struct {
+	// MaxSlowdown is the percentage a target may be slower than its
+	// baseline before it counts as a regression.
+	MaxSlowdown float64
+	// MinSeconds is the least slowdown that counts, so that the noise in
+	// small targets' times does not.
+	MinSeconds float64
+}
+
+// Regression is a target slower than its baseline by more than the
+// options allow.
+type Regression struct {
+	Label   string  `json:"label"`
+	Module  string  `json:"module"`
+	File    string  `json:"file,omitempty"`
+	Seconds float64 `json:"seconds"`
+	// Baseline is the median of the target's times in the compared runs.
+	Baseline float64 `json:"baselineSeconds"`
+	Slowdown float64 `json:"slowdownPercent"`
+	// CodeGrowth and NewDeps are the changes since the latest compared
+	// run that recorded the target.
+	CodeGrowth int      `json:"codeGrowth"`
+	NewDeps    []string `json:"newDeps,omitempty"`
+	// GrownDeps are deps whose code grew since that run.
+	GrownDeps   []string `json:"grownDeps,omitempty"`
+	Fingerprint string   `json:"fingerprint,omitempty"`
+}
+
+// Causes describes the likely causes of the regression.
+func (r Regression) Causes() []string {
+	var causes []string
+	if r.CodeGrowth > 0 {
+		causes = append(causes, fmt.Sprintf("%+d lines of code", r.CodeGrowth))
+	}
+	if len(r.NewDeps) > 0 {
+		causes = append(causes, fmt.Sprintf("new deps %s", joinLabels(r.NewDeps)))
+	}
+	if len(r.GrownDeps) > 0 {
+		causes = append(causes, fmt.Sprintf("larger deps %s", joinLabels(r.GrownDeps)))
+	}
+	return causes
+}
+
+// Message is the regression as one line, as recorded in the store.
+func (r Regression) Message() string {
+	msg := fmt.Sprintf("%s took %.1fs, %.0f%% over its %.1fs baseline", r.Label, r.Seconds, r.Slowdown, r.Baseline)
+	if causes := r.Causes(); len(causes) > 0 {
+		return msg + "; likely causes: " + joinList(causes)
+	}
+	return msg + "; its code and deps are unchanged"
+}
+
+// Compare returns the targets slower than the median of their times in
+// history by more than opts allow, largest slowdown first. Targets no run
+// in history recorded have no baseline and are left out.
+func Compare(targets []Target, history []Snapshot, opts Options) []Regression {
+	current := make(map[string]Target, len(targets))
+	for _, t := range targets {
+		current[t.Label] = t
+	}
+
+	regressions := []Regression{}
+	for _, t := range targets {
+		var times []float64
+		var last *Target
+		for i := range history {
+			if old, ok := history[i].Targets[t.Label]; ok {
+				times = append(times, old.Seconds)
+				if last == nil {
+					last = &old
+				}
+			}
+		}
+		if len(times) == 0 {
+			continue
+		}
+		baseline := median(times)
+		if t.Seconds-baseline < opts.MinSeconds || t.Seconds <= baseline*(1+opts.MaxSlowdown/100) {
+			continue
+		}
+		r := Regression{Label: t.Label, Module: t.Module, File: t.File, Seconds: t.Seconds, Baseline: baseline, CodeGrowth: t.Code - last.Code}
+		if baseline > 0 {
+			r.Slowdown = math.Round((t.Seconds/baseline-1)*1000) / 10
+		}
+		for _, dep := range t.Deps {
+			if !slices.Contains(last.Deps, dep) {
+				r.NewDeps = append(r.NewDeps, dep)
+				continue
+			}
+			for i := range history {
+				if old, ok := history[i].Targets[dep]; ok {
+					if now, ok := current[dep]; ok && now.Code > old.Code {
+						r.GrownDeps = append(r.GrownDeps, dep)
+					}
+					break
+				}
+			}
+		}
+		regressions = append(regressions, r)
+	}
+	sort.SliceStable(regressions, func(i, j int) bool {
+		return regressions[i].Slowdown > regressions[j].Slowdown
+	})
+	return regressions
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,90 @@
+package buildtimes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// slowest is the number of targets the Markdown report lists by time.
+const slowest = 20
+
+// WriteMarkdown writes the regressions with their likely causes, then the
+// slowest targets.
+func WriteMarkdown(w io.Writer, targets []Target, history []Snapshot, regressions []Regression, opts Options) error {
+	var b strings.Builder
+	b.WriteString("# Build Times\n\n")
+	total := 0.0
+	for _, t := range targets {
+		total += t.Seconds
+	}
+	fmt.Fprintf(&b, "%d targets profiled, %.1fs of actions in all. ", len(targets), total)
+	if len(history) == 0 {
+		b.WriteString("No earlier runs are recorded to compare with.\n")
+	} else {
+		fmt.Fprintf(&b, "Compared with the median of %d earlier runs; a target more than %g%% and %gs slower regresses.\n", len(history), opts.MaxSlowdown, opts.MinSeconds)
+	}
+
+	if len(history) > 0 {
+		fmt.Fprintf(&b, "\n## Regressions\n\n")
+		if len(regressions) == 0 {
+			b.WriteString("None.\n")
+		} else {
+			b.WriteString("| Target | Module | Time | Baseline | Slowdown | Likely causes |\n")
+			b.WriteString("|--------|--------|------|----------|----------|---------------|\n")
+			for _, r := range regressions {
+				causes := joinList(r.Causes())
+				if causes == "" {
+					causes = "code and deps unchanged"
+				}
+				fmt.Fprintf(&b, "| `%s` | %s | %.1fs | %.1fs | +%.0f%% | %s |\n", r.Label, r.Module, r.Seconds, r.Baseline, r.Slowdown, causes)
+			}
+		}
+	}
+
+	if len(targets) > 0 {
+		b.WriteString("\n## Slowest Targets\n\n")
+		b.WriteString("| Target | Module | Time | Code | Deps |\n")
+		b.WriteString("|--------|--------|------|------|------|\n")
+		for i, t := range targets {
+			if i == slowest {
+				break
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %.1fs | %d | %d |\n", t.Label, t.Module, t.Seconds, t.Code, len(t.Deps))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the targets, the runs compared with and the
+// regressions as indented JSON.
+func WriteJSON(w io.Writer, targets []Target, history []Snapshot, regressions []Regression) error {
+	if targets == nil {
+		targets = []Target{}
+	}
+	if history == nil {
+		history = []Snapshot{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Targets     []Target     `json:"targets"`
+		Compared    []Snapshot   `json:"compared"`
+		Regressions []Regression `json:"regressions"`
+	}{targets, history, regressions})
+}
+
+func joinLabels(labels []string) string {
+	quoted := make([]string, len(labels))
+	for i, l := range labels {
+		quoted[i] = "`" + l + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func joinList(items []string) string {
+	return strings.Join(items, "; ")
+}
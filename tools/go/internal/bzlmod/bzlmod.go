@@ -0,0 +1,580 @@
+// Package bzlmod plans the move of a WORKSPACE's repository rules to
+// MODULE.bazel. It sorts each WORKSPACE statement into those a registry
+// module, a module extension or use_repo_rule replaces, the setup macros
+// bzlmod no longer needs, and those it cannot express, and writes the
+// lines a candidate MODULE.bazel gains.
+package bzlmod
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/extdeps"
+)
+
+// Statuses of a WORKSPACE statement.
+const (
+	// StatusDeclared is a repository MODULE.bazel already declares.
+	StatusDeclared = "declared"
+	// StatusModule is a repository a registry module replaces.
+	StatusModule = "bazel_dep"
+	// StatusExtension is a repository a module extension's tag replaces.
+	StatusExtension = "extension"
+	// StatusRepoRule is a repository MODULE.bazel can still fetch with
+	// use_repo_rule, for want of a registry module.
+	StatusRepoRule = "use_repo_rule"
+	// StatusToolchains is a register_toolchains or
+	// register_execution_platforms call MODULE.bazel takes as it is.
+	StatusToolchains = "toolchains"
+	// StatusSetup is a macro setting up a module's own dependencies,
+	// which bzlmod resolves by itself.
+	StatusSetup = "setup"
+	// StatusUnused is a repository no label names.
+	StatusUnused = "unused"
+	// StatusUnsupported is a statement a person has to migrate.
+	StatusUnsupported = "unsupported"
+)
+
+// Statuses lists the statuses in report order.
+var Statuses = []string{StatusModule, StatusExtension, StatusRepoRule, StatusToolchains, StatusSetup, StatusUnused, StatusDeclared, StatusUnsupported}
+
+// Entry is one statement of the WORKSPACE file.
+type Entry struct {
+	// Kind is the rule or macro called, as http_archive.
+	Kind string `json:"kind"`
+	// Name is the repository's name, empty for macros.
+	Name   string `json:"name,omitempty"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Status string `json:"status"`
+	// Module is the module replacing the repository, or providing the
+	// extension that does.
+	Module  string `json:"module,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Uses counts the labels naming the repository.
+	Uses int `json:"uses"`
+	// Note says why a statement is unsupported, or what to check in its
+	// migration.
+	Note string `json:"note,omitempty"`
+	// Fingerprint is set on unsupported statements, which are reported as
+	// issues.
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	stmt build.Expr
+	call *build.CallExpr
+	// label is the .bzl file a use_repo_rule loads the rule from.
+	label string
+	// override is the override a bazel_dep needs to fetch what WORKSPACE
+	// fetched.
+	override string
+	ext      *extension
+}
+
+// Pending reports whether MODULE.bazel still lacks what the entry
+// declares.
+func (e Entry) Pending() bool {
+	switch e.Status {
+	case StatusDeclared, StatusSetup, StatusUnused:
+		return false
+	}
+	return true
+}
+
+// Report is the outcome of an inventory.
+type Report struct {
+	// Workspace is the WORKSPACE file read, empty when there is none.
+	Workspace string  `json:"workspace,omitempty"`
+	Entries   []Entry `json:"entries"`
+	// Additions are the lines migrating the pending entries, and
+	// Candidate is MODULE.bazel with them appended.
+	Additions string `json:"additions,omitempty"`
+	Candidate string `json:"candidate,omitempty"`
+
+	module *moduleFile
+	file   *build.File
+}
+
+// Count returns the number of entries with the status.
+func (r *Report) Count(status string) int {
+	n := 0
+	for _, e := range r.Entries {
+		if e.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Pending returns the entries MODULE.bazel still lacks.
+func (r *Report) Pending() []Entry {
+	var pending []Entry
+	for _, e := range r.Entries {
+		if e.Pending() {
+			pending = append(pending, e)
+		}
+	}
+	return pending
+}
+
+// load is a symbol a load statement brings in.
+type load struct {
+	label, symbol string
+}
+
+// Inventory reads the WORKSPACE file and MODULE.bazel at root and sorts
+// the WORKSPACE statements by how they migrate.
+func Inventory(root string) (*Report, error) {
+	report := &Report{Entries: []Entry{}}
+	mod, err := readModule(root)
+	if err != nil {
+		return nil, err
+	}
+	report.module = mod
+	for _, name := range []string{"WORKSPACE.bazel", "WORKSPACE"} {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if report.file, err = build.ParseWorkspace(name, data); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		report.Workspace = name
+		break
+	}
+	if report.file == nil {
+		report.Candidate = mod.text
+		return report, nil
+	}
+
+	// extdeps counts the labels naming each repository and reads the
+	// version each fetches.
+	audit, err := extdeps.Analyse(root, extdeps.Options{})
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]extdeps.Repo)
+	for _, r := range audit.Repos {
+		if r.File == report.Workspace {
+			known[r.Name] = r
+		}
+	}
+
+	loads := make(map[string]load)
+	var macros []Entry
+	workspaceName := ""
+	for _, stmt := range report.file.Stmt {
+		line := lineOf(stmt)
+		switch x := stmt.(type) {
+		case *build.CommentBlock:
+		case *build.LoadStmt:
+			for i, to := range x.To {
+				loads[to.Name] = load{label: x.Module.Value, symbol: x.From[i].Name}
+			}
+		case *build.CallExpr:
+			e := Entry{Kind: callName(x), File: report.Workspace, Line: line, stmt: stmt, call: x}
+			rule := report.file.Rule(x)
+			if e.Kind == "maybe" && len(x.List) > 0 {
+				if id, ok := x.List[0].(*build.Ident); ok {
+					e.Kind = id.Name
+				}
+			}
+			switch e.Kind {
+			case "workspace":
+				workspaceName = rule.AttrString("name")
+				continue
+			case "register_toolchains", "register_execution_platforms":
+				e.Status = StatusToolchains
+				if mod.calls[callKey(x)] {
+					e.Status = StatusDeclared
+				}
+				report.Entries = append(report.Entries, e)
+				continue
+			}
+			e.Name = rule.AttrString("name")
+			if e.Name == "" {
+				macros = append(macros, e)
+				continue
+			}
+			repo := known[e.Name]
+			e.Version, e.Uses = repo.Version, repo.Uses
+			report.classify(root, &e, rule, loads)
+			report.Entries = append(report.Entries, e)
+		default:
+			report.Entries = append(report.Entries, Entry{Kind: "statement", File: report.Workspace, Line: line, Status: StatusUnsupported, Note: "only calls move to MODULE.bazel; inline it", stmt: stmt})
+		}
+	}
+	// Macros come last: whether one is a module's setup depends on how
+	// the repository it is loaded from migrates.
+	for _, e := range macros {
+		report.classifyMacro(&e, loads)
+		report.Entries = append(report.Entries, e)
+	}
+	sort.SliceStable(report.Entries, func(i, j int) bool { return report.Entries[i].Line < report.Entries[j].Line })
+	for i := range report.Entries {
+		e := &report.Entries[i]
+		if e.Status == StatusExtension && !report.dependsOn(e.Module) {
+			e.Note = strings.TrimPrefix(e.Note+"; add a bazel_dep on "+e.Module+" for its extension", "; ")
+		}
+	}
+
+	if report.Additions, err = report.additions(); err != nil {
+		return nil, err
+	}
+	report.Candidate = mod.text
+	if report.Candidate == "" {
+		report.Candidate = fmt.Sprintf("module(name = %q)\n", moduleName(root, workspaceName))
+	}
+	if report.Additions != "" {
+		report.Candidate = strings.TrimRight(report.Candidate, "\n") + "\n\n" + report.Additions
+	}
+	return report, nil
+}
+
+// classify sorts a repository rule.
+func (r *Report) classify(root string, e *Entry, rule *build.Rule, loads map[string]load) {
+	mod := r.module
+	if module := modules[e.Name]; mod.names[e.Name] || module != "" && mod.apparent[module] != "" {
+		e.Status, e.Module = StatusDeclared, module
+		return
+	}
+	if e.Kind == "bind" {
+		e.Status = StatusUnsupported
+		e.Note = fmt.Sprintf("bind has no bzlmod equivalent; replace //external:%s with an alias target", e.Name)
+		return
+	}
+	if e.Uses == 0 {
+		e.Status = StatusUnused
+		e.Note = "no label names it; delete it rather than migrate it"
+		return
+	}
+
+	// A local repository that is a module itself becomes a dependency
+	// with a local_path_override.
+	if e.Kind == "local_repository" {
+		dir := rule.AttrString("path")
+		if module := localModule(root, dir); module != "" {
+			e.Status, e.Module = StatusModule, module
+			e.override = fmt.Sprintf("local_path_override(module_name = %q, path = %q)\n", module, dir)
+			return
+		}
+	}
+	if module := modules[e.Name]; module != "" {
+		e.Status, e.Module = StatusModule, module
+		var notes []string
+		if commit := rule.AttrString("commit"); commit != "" && rule.AttrString("remote") != "" {
+			e.override = fmt.Sprintf("git_override(module_name = %q, remote = %q, commit = %q)\n", module, rule.AttrString("remote"), commit)
+		} else if e.Version == "" {
+			notes = append(notes, "set the version: WORKSPACE pins no release")
+		}
+		if rule.Attr("patches") != nil {
+			notes = append(notes, "carry its patches over with single_version_override")
+		}
+		e.Note = strings.Join(notes, "; ")
+		return
+	}
+	if ext, ok := extensions[e.Kind]; ok {
+		e.Status, e.Module, e.ext = StatusExtension, ext.module, &ext
+		var notes, left []string
+		for _, a := range rule.AttrKeys() {
+			if a != "name" && ext.tagAttr(a) == "" {
+				left = append(left, a)
+			}
+		}
+		if len(left) > 0 {
+			notes = append(notes, fmt.Sprintf("%s.%s takes no %s; see %s", ext.name, ext.tag, strings.Join(left, ", "), ext.docs))
+		}
+		for _, a := range ext.required {
+			if rule.Attr(a) == nil {
+				notes = append(notes, fmt.Sprintf("%s.%s needs %s", ext.name, ext.tag, ext.tagAttr(a)))
+			}
+		}
+		e.Note = strings.Join(notes, "; ")
+		return
+	}
+
+	label, native := repoRules[e.Kind]
+	if l, ok := loads[e.Kind]; ok {
+		label, native = l.label, false
+		if l.symbol != e.Kind {
+			e.Status = StatusUnsupported
+			e.Note = fmt.Sprintf("%s is loaded as %s; load it under its own name", l.symbol, e.Kind)
+			return
+		}
+	}
+	switch {
+	case label == "":
+		e.Status = StatusUnsupported
+		e.Note = e.Kind + " is neither loaded nor a rule bzlmod knows"
+	case native || labelRepo(label) == "bazel_tools" || labelRepo(label) == "" || r.migrates(labelRepo(label)):
+		e.Status, e.label = StatusRepoRule, label
+		e.Note = "no registry module replaces it"
+	default:
+		e.Status = StatusUnsupported
+		e.Note = fmt.Sprintf("%s comes from @%s, which has no module; fetch that first", e.Kind, labelRepo(label))
+	}
+}
+
+// classifyMacro sorts a call declaring no repository of its own.
+func (r *Report) classifyMacro(e *Entry, loads map[string]load) {
+	if e.Kind == "go_register_toolchains" {
+		e.Module = "rules_go"
+		switch {
+		case r.module.extVars["rules_go//go:extensions.bzl%go_sdk"] != "":
+			e.Status = StatusDeclared
+		default:
+			e.Status = StatusExtension
+			if v := r.file.Rule(e.call).AttrString("version"); v != "" {
+				e.Version = v
+			} else {
+				e.Note = "go_sdk.download needs the Go version"
+			}
+		}
+		return
+	}
+	l, ok := loads[e.Kind]
+	repo := labelRepo(l.label)
+	switch {
+	case !ok:
+		e.Status = StatusUnsupported
+		e.Note = e.Kind + " is not loaded and bzlmod has no equivalent"
+	case repo != "" && r.migrates(repo):
+		e.Status, e.Module = StatusSetup, r.moduleOf(repo)
+		e.Note = "the module sets up its own dependencies; drop the call"
+	case repo == "":
+		e.Status = StatusUnsupported
+		e.Note = "this repository's macro fetches repositories; call their rules from a module extension"
+	default:
+		e.Status = StatusUnsupported
+		e.Note = fmt.Sprintf("comes from @%s, which has no module; write a module extension calling it", repo)
+	}
+}
+
+// migrates reports whether the repository named repo is, or will be, a
+// module the root module depends on.
+func (r *Report) migrates(repo string) bool {
+	if r.module.names[repo] {
+		return true
+	}
+	for _, e := range r.Entries {
+		if e.Name == repo && e.Module != "" && (e.Status == StatusModule || e.Status == StatusDeclared) {
+			return true
+		}
+	}
+	return false
+}
+
+// dependsOn reports whether the root module depends, or will depend, on
+// module.
+func (r *Report) dependsOn(module string) bool {
+	if r.module.apparent[module] != "" {
+		return true
+	}
+	for _, e := range r.Entries {
+		if e.Module == module && (e.Status == StatusModule || e.Status == StatusDeclared) {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleOf returns the module behind a repository name.
+func (r *Report) moduleOf(repo string) string {
+	if m := r.module.modules[repo]; m != "" {
+		return m
+	}
+	for _, e := range r.Entries {
+		if e.Name == repo && e.Module != "" {
+			return e.Module
+		}
+	}
+	return modules[repo]
+}
+
+// moduleName returns a module name for a tree without MODULE.bazel: the
+// workspace's name, or else the directory's, made valid.
+func moduleName(root, workspace string) string {
+	name := workspace
+	if name == "" {
+		name = filepath.Base(root)
+	}
+	var b strings.Builder
+	for _, c := range strings.ToLower(name) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9' && b.Len() > 0, (c == '.' || c == '-' || c == '_') && b.Len() > 0:
+			b.WriteRune(c)
+		case b.Len() > 0:
+			b.WriteByte('_')
+		}
+	}
+	if s := strings.TrimRight(b.String(), "._-"); s != "" {
+		return s
+	}
+	return "main"
+}
+
+// localModule returns the name of the module at dir, relative to root
+// unless absolute, or "" when it is not one.
+func localModule(root, dir string) string {
+	if dir == "" {
+		return ""
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "MODULE.bazel"))
+	if err != nil {
+		return ""
+	}
+	f, err := build.ParseModule("MODULE.bazel", data)
+	if err != nil {
+		return ""
+	}
+	for _, rule := range f.Rules("module") {
+		return rule.AttrString("name")
+	}
+	return ""
+}
+
+// moduleFile is what an inventory needs of MODULE.bazel.
+type moduleFile struct {
+	text string
+	// apparent maps modules onto the names labels use for them, and
+	// modules maps those names back.
+	apparent map[string]string
+	modules  map[string]string
+	// names are the repository names it declares.
+	names map[string]bool
+	// extVars and ruleVars map extensions, as "module//file%name", and
+	// repository rules, as "label%rule", onto the variables holding them.
+	extVars  map[string]string
+	ruleVars map[string]string
+	// calls are its register_toolchains and register_execution_platforms
+	// calls, as callKey returns them.
+	calls map[string]bool
+}
+
+func readModule(root string) (*moduleFile, error) {
+	const name = "MODULE.bazel"
+	mod := &moduleFile{apparent: map[string]string{}, modules: map[string]string{}, names: map[string]bool{}, extVars: map[string]string{}, ruleVars: map[string]string{}, calls: map[string]bool{}}
+	data, err := os.ReadFile(filepath.Join(root, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return mod, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	mod.text = string(data)
+	f, err := build.ParseModule(name, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	var extensions [][2]string
+	for _, stmt := range f.Stmt {
+		if assign, ok := stmt.(*build.AssignExpr); ok {
+			v, ok1 := assign.LHS.(*build.Ident)
+			call, ok2 := assign.RHS.(*build.CallExpr)
+			if !ok1 || !ok2 || len(call.List) < 2 {
+				continue
+			}
+			label, symbol := stringValue(call.List[0]), stringValue(call.List[1])
+			switch callName(call) {
+			case "use_extension":
+				extensions = append(extensions, [2]string{v.Name, label + "%" + symbol})
+			case "use_repo_rule":
+				mod.ruleVars[label+"%"+symbol] = v.Name
+			}
+			continue
+		}
+		call, ok := stmt.(*build.CallExpr)
+		if !ok {
+			continue
+		}
+		rule := f.Rule(call)
+		switch kind := callName(call); kind {
+		case "bazel_dep":
+			module := rule.AttrString("name")
+			repo := rule.AttrString("repo_name")
+			if repo == "" {
+				repo = module
+			}
+			mod.apparent[module], mod.modules[repo] = repo, module
+			mod.names[repo] = true
+		case "use_repo":
+			for _, arg := range call.List[1:] {
+				if s := stringValue(arg); s != "" {
+					mod.names[s] = true
+				} else if kw, ok := arg.(*build.AssignExpr); ok {
+					if id, ok := kw.LHS.(*build.Ident); ok {
+						mod.names[id.Name] = true
+					}
+				}
+			}
+		case "register_toolchains", "register_execution_platforms":
+			mod.calls[callKey(call)] = true
+		default:
+			// A use_repo_rule rule declares the repository it names.
+			if n := rule.AttrString("name"); n != "" && kind != "module" {
+				mod.names[n] = true
+			}
+		}
+	}
+	// Extensions are keyed by module, whatever name the file uses for it.
+	for _, ext := range extensions {
+		label, symbol, _ := strings.Cut(ext[1], "%")
+		repo := labelRepo(label)
+		if m := mod.modules[repo]; m != "" {
+			repo = m
+		}
+		_, file, _ := strings.Cut(label, "//")
+		mod.extVars[repo+"//"+file+"%"+symbol] = ext[0]
+	}
+	return mod, nil
+}
+
+// labelRepo returns the repository of a label, "" for the main one.
+func labelRepo(label string) string {
+	label = strings.TrimPrefix(strings.TrimPrefix(label, "@"), "@")
+	if repo, _, ok := strings.Cut(label, "//"); ok {
+		return repo
+	}
+	return ""
+}
+
+// callKey returns a call's function and arguments, leaving out the
+// comments the formatted call would carry.
+func callKey(call *build.CallExpr) string {
+	args := make([]string, len(call.List))
+	for i, arg := range call.List {
+		args[i] = build.FormatString(arg)
+	}
+	return callName(call) + "(" + strings.Join(args, ", ") + ")"
+}
+
+func callName(call *build.CallExpr) string {
+	if id, ok := call.X.(*build.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+func stringValue(e build.Expr) string {
+	if s, ok := e.(*build.StringExpr); ok {
+		return s.Value
+	}
+	return ""
+}
+
+func lineOf(e build.Expr) int {
+	start, _ := e.Span()
+	return start.Line
+}
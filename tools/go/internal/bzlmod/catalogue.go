@@ -0,0 +1,95 @@
+package bzlmod
+
+// modules maps the names WORKSPACE files conventionally give repositories
+// onto the registry modules replacing them. The candidate keeps the
+// WORKSPACE name as the module's repo_name, so labels need not change.
+var modules = map[string]string{
+	"aspect_bazel_lib":                         "aspect_bazel_lib",
+	"aspect_rules_js":                          "aspect_rules_js",
+	"bazel_features":                           "bazel_features",
+	"bazel_gazelle":                            "gazelle",
+	"bazel_skylib":                             "bazel_skylib",
+	"build_bazel_apple_support":                "apple_support",
+	"build_bazel_rules_apple":                  "rules_apple",
+	"build_bazel_rules_nodejs":                 "rules_nodejs",
+	"build_bazel_rules_swift":                  "rules_swift",
+	"buildifier_prebuilt":                      "buildifier_prebuilt",
+	"cgrindel_bazel_starlib":                   "cgrindel_bazel_starlib",
+	"com_github_bazelbuild_buildtools":         "buildtools",
+	"com_github_buildbuddy_io_rules_xcodeproj": "rules_xcodeproj",
+	"com_github_grpc_grpc":                     "grpc",
+	"com_google_absl":                          "abseil-cpp",
+	"com_google_googletest":                    "googletest",
+	"com_google_protobuf":                      "protobuf",
+	"io_bazel_rules_go":                        "rules_go",
+	"io_bazel_stardoc":                         "stardoc",
+	"net_zlib":                                 "zlib",
+	"platforms":                                "platforms",
+	"rules_apple":                              "rules_apple",
+	"rules_cc":                                 "rules_cc",
+	"rules_foreign_cc":                         "rules_foreign_cc",
+	"rules_java":                               "rules_java",
+	"rules_jvm_external":                       "rules_jvm_external",
+	"rules_license":                            "rules_license",
+	"rules_pkg":                                "rules_pkg",
+	"rules_proto":                              "rules_proto",
+	"rules_python":                             "rules_python",
+	"rules_shell":                              "rules_shell",
+	"rules_swift":                              "rules_swift",
+	"rules_swift_package_manager":              "rules_swift_package_manager",
+	"zlib":                                     "zlib",
+}
+
+// extension is a module extension whose tags replace a WORKSPACE rule.
+type extension struct {
+	module, file, name, tag string
+	// attrs pairs the rule's attributes with the tag's, in tag order.
+	attrs [][2]string
+	// required are the rule attributes without which the tag fails.
+	required []string
+	docs     string
+}
+
+// tagAttr returns the tag attribute a rule attribute becomes, or "".
+func (x extension) tagAttr(attr string) string {
+	for _, a := range x.attrs {
+		if a[0] == attr {
+			return a[1]
+		}
+	}
+	return ""
+}
+
+// extensions maps WORKSPACE rules onto the extensions replacing them.
+var extensions = map[string]extension{
+	"go_repository": {
+		module: "gazelle", file: "//:extensions.bzl", name: "go_deps", tag: "module",
+		attrs:    [][2]string{{"importpath", "path"}, {"sum", "sum"}, {"version", "version"}},
+		required: []string{"importpath", "version"},
+		docs:     "go_deps.gazelle_override and go_deps.module_override",
+	},
+	"maven_install": {
+		module: "rules_jvm_external", file: "//:extensions.bzl", name: "maven", tag: "install",
+		attrs:    [][2]string{{"name", "name"}, {"artifacts", "artifacts"}, {"repositories", "repositories"}, {"maven_install_json", "lock_file"}, {"fetch_sources", "fetch_sources"}},
+		required: []string{"artifacts"},
+		docs:     "the maven.artifact and maven.override tags",
+	},
+	"pip_parse": {
+		module: "rules_python", file: "//python/extensions:pip.bzl", name: "pip", tag: "parse",
+		attrs:    [][2]string{{"name", "hub_name"}, {"python_version", "python_version"}, {"requirements_lock", "requirements_lock"}},
+		required: []string{"python_version", "requirements_lock"},
+		docs:     "the pip.override tag",
+	},
+}
+
+// repoRules maps the rules Bazel itself provides onto the files
+// use_repo_rule loads them from.
+var repoRules = map[string]string{
+	"http_archive":         "@bazel_tools//tools/build_defs/repo:http.bzl",
+	"http_file":            "@bazel_tools//tools/build_defs/repo:http.bzl",
+	"http_jar":             "@bazel_tools//tools/build_defs/repo:http.bzl",
+	"git_repository":       "@bazel_tools//tools/build_defs/repo:git.bzl",
+	"new_git_repository":   "@bazel_tools//tools/build_defs/repo:git.bzl",
+	"local_repository":     "@bazel_tools//tools/build_defs/repo:local.bzl",
+	"new_local_repository": "@bazel_tools//tools/build_defs/repo:local.bzl",
+}
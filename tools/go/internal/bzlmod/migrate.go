@@ -0,0 +1,249 @@
+package bzlmod
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/importrewrite"
+)
+
+// additions returns the MODULE.bazel lines declaring the pending
+// entries, formatted, or "" when none are pending.
+func (r *Report) additions() (string, error) {
+	mod := r.module
+	apparent := func(module string) string {
+		if a := mod.apparent[module]; a != "" {
+			return a
+		}
+		for _, e := range r.Entries {
+			if e.Status == StatusModule && e.Module == module {
+				return e.Name
+			}
+		}
+		return module
+	}
+
+	var deps, exts, rules, toolchains strings.Builder
+	type group struct {
+		v, decl string
+		tags    []string
+		repos   []string
+	}
+	groups := make(map[string]*group)
+	var order []string
+	extGroup := func(module, file, name string) *group {
+		key := module + file + "%" + name
+		if g, ok := groups[key]; ok {
+			return g
+		}
+		g := &group{v: mod.extVars[key]}
+		if g.v == "" {
+			g.v = name
+			g.decl = fmt.Sprintf("%s = use_extension(%q, %q)\n", name, "@"+apparent(module)+file, name)
+		}
+		groups[key] = g
+		order = append(order, key)
+		return g
+	}
+	declaredRules := make(map[string]bool)
+
+	for _, e := range r.Entries {
+		switch e.Status {
+		case StatusModule:
+			fmt.Fprintf(&deps, "bazel_dep(name = %q", e.Module)
+			if e.Version != "" {
+				fmt.Fprintf(&deps, ", version = %q", e.Version)
+			}
+			if e.Name != e.Module {
+				fmt.Fprintf(&deps, ", repo_name = %q", e.Name)
+			}
+			deps.WriteString(")\n" + e.override)
+		case StatusExtension:
+			if e.ext == nil {
+				// go_register_toolchains becomes the Go SDK extension.
+				g := extGroup("rules_go", "//go:extensions.bzl", "go_sdk")
+				if e.Version == "host" {
+					g.tags = append(g.tags, g.v+".host()")
+				} else {
+					g.tags = append(g.tags, fmt.Sprintf("%s.download(version = %q)", g.v, e.Version))
+				}
+				continue
+			}
+			g := extGroup(e.ext.module, e.ext.file, e.ext.name)
+			rule := r.file.Rule(e.call)
+			var args []string
+			for _, a := range e.ext.attrs {
+				if v := rule.Attr(a[0]); v != nil {
+					args = append(args, a[1]+" = "+build.FormatString(v))
+				}
+			}
+			g.tags = append(g.tags, fmt.Sprintf("%s.%s(%s)", g.v, e.ext.tag, strings.Join(args, ", ")))
+			g.repos = append(g.repos, e.Name)
+		case StatusRepoRule:
+			key := e.label + "%" + e.Kind
+			v := mod.ruleVars[key]
+			if v == "" {
+				v = e.Kind
+				if !declaredRules[key] {
+					fmt.Fprintf(&rules, "%s = use_repo_rule(%q, %q)\n", v, e.label, e.Kind)
+					declaredRules[key] = true
+				}
+			}
+			// maybe(rule, ...) calls the rule with the remaining arguments.
+			call := *e.call
+			call.X = &build.Ident{Name: v}
+			if callName(e.call) == "maybe" {
+				call.List = call.List[1:]
+			}
+			rules.WriteString(build.FormatString(&call) + "\n")
+		case StatusToolchains:
+			toolchains.WriteString(build.FormatString(e.call) + "\n")
+		}
+	}
+	for _, key := range order {
+		g := groups[key]
+		if exts.Len() > 0 {
+			exts.WriteString("\n")
+		}
+		exts.WriteString(g.decl)
+		for _, t := range g.tags {
+			exts.WriteString(t + "\n")
+		}
+		if len(g.repos) > 0 {
+			fmt.Fprintf(&exts, "use_repo(%s", g.v)
+			for _, repo := range g.repos {
+				fmt.Fprintf(&exts, ", %q", repo)
+			}
+			exts.WriteString(")\n")
+		}
+	}
+
+	var sections []string
+	for _, s := range []struct{ comment, body string }{
+		{"Registry modules replacing WORKSPACE repositories", deps.String()},
+		{"Module extensions replacing WORKSPACE rules", exts.String()},
+		{"Repositories with no registry module", rules.String()},
+		{"Toolchains registered in WORKSPACE", toolchains.String()},
+	} {
+		if s.body != "" {
+			sections = append(sections, "# "+s.comment+".\n"+s.body)
+		}
+	}
+	if len(sections) == 0 {
+		return "", nil
+	}
+	text := fmt.Sprintf("# Migrated from %s by umbratool bzlmod-migrate.\n\n%s", r.Workspace, strings.Join(sections, "\n"))
+	f, err := build.ParseModule("MODULE.bazel", []byte(text))
+	if err != nil {
+		return "", fmt.Errorf("the lines migrating %s do not parse: %w", r.Workspace, err)
+	}
+	return string(build.Format(f)), nil
+}
+
+// Plan returns the changes migrating the pending entries: the additions
+// appended to MODULE.bazel and, with prune set, the migrated statements
+// and the loads they leave unused taken out of the WORKSPACE file.
+// Nothing is written; see importrewrite.Plan.Apply.
+func Plan(root string, r *Report, prune bool) (*importrewrite.Plan, error) {
+	plan := &importrewrite.Plan{}
+	if r.file == nil {
+		return plan, nil
+	}
+	if _, err := os.Stat(filepath.Join(root, "MODULE.bazel")); errors.Is(err, os.ErrNotExist) {
+		return nil, errors.New("there is no MODULE.bazel to migrate into; write the candidate with --candidate MODULE.bazel first")
+	} else if err != nil {
+		return nil, err
+	}
+	if r.Additions != "" {
+		var edits []string
+		for _, status := range []string{StatusModule, StatusExtension, StatusRepoRule, StatusToolchains} {
+			if n := r.Count(status); n > 0 {
+				edits = append(edits, fmt.Sprintf("+%d %s", n, status))
+			}
+		}
+		plan.Build = append(plan.Build, importrewrite.Change{Path: "MODULE.bazel", Edits: edits, Before: r.module.text, After: r.Candidate})
+	}
+	if !prune {
+		return plan, nil
+	}
+
+	drop := make(map[build.Expr]bool)
+	for _, e := range r.Entries {
+		if e.Status != StatusUnsupported && e.stmt != nil {
+			drop[e.stmt] = true
+		}
+	}
+	if len(drop) == 0 {
+		return plan, nil
+	}
+	used := make(map[string]bool)
+	for _, stmt := range r.file.Stmt {
+		if _, ok := stmt.(*build.LoadStmt); ok || drop[stmt] {
+			continue
+		}
+		build.Walk(stmt, func(x build.Expr, _ []build.Expr) {
+			if id, ok := x.(*build.Ident); ok {
+				used[id.Name] = true
+			}
+		})
+	}
+	pruned := *r.file
+	pruned.Stmt = nil
+	loads := 0
+	for _, stmt := range r.file.Stmt {
+		if drop[stmt] {
+			continue
+		}
+		if l, ok := stmt.(*build.LoadStmt); ok {
+			kept := *l
+			kept.From, kept.To = nil, nil
+			for i, to := range l.To {
+				if used[to.Name] {
+					kept.From = append(kept.From, l.From[i])
+					kept.To = append(kept.To, to)
+				}
+			}
+			if len(kept.To) == 0 {
+				loads++
+				continue
+			}
+			stmt = &kept
+		}
+		pruned.Stmt = append(pruned.Stmt, stmt)
+	}
+	edits := []string{fmt.Sprintf("-%d statements", len(drop))}
+	if loads > 0 {
+		edits = append(edits, fmt.Sprintf("-%d loads", loads))
+	}
+	before, err := os.ReadFile(filepath.Join(root, r.Workspace))
+	if err != nil {
+		return nil, err
+	}
+	plan.Build = append(plan.Build, importrewrite.Change{Path: r.Workspace, Edits: edits, Before: string(before), After: string(build.Format(&pruned))})
+	if onlyWorkspace(pruned.Stmt) {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s declares nothing else once pruned; delete it and build with --noenable_workspace", r.Workspace))
+	}
+	return plan, nil
+}
+
+// onlyWorkspace reports whether stmts hold nothing but comments and the
+// workspace() call.
+func onlyWorkspace(stmts []build.Expr) bool {
+	for _, stmt := range stmts {
+		switch x := stmt.(type) {
+		case *build.CommentBlock:
+		case *build.CallExpr:
+			if callName(x) != "workspace" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package bzlmod
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// statusTitles describe the statuses for the summary table.
+var statusTitles = map[string]string{
+	StatusModule:      "Replaced by a registry module",
+	StatusExtension:   "Replaced by a module extension",
+	StatusRepoRule:    "Fetched with use_repo_rule",
+	StatusToolchains:  "Toolchains registered as they are",
+	StatusSetup:       "Setup macros bzlmod no longer needs",
+	StatusUnused:      "Unused, to delete",
+	StatusDeclared:    "Already in MODULE.bazel",
+	StatusUnsupported: "Lacking bzlmod support",
+}
+
+// WriteMarkdown writes the inventory, the statements a person has to
+// migrate and the lines MODULE.bazel gains.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	b.WriteString("# Bzlmod Migration\n\n")
+	if r.Workspace == "" {
+		b.WriteString("There is no WORKSPACE file: every repository comes from MODULE.bazel.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	pending, unsupported := len(r.Pending()), r.Count(StatusUnsupported)
+	switch {
+	case len(r.Entries) == 0:
+		fmt.Fprintf(&b, "%s declares no repositories.\n", r.Workspace)
+	case pending == 0:
+		fmt.Fprintf(&b, "MODULE.bazel declares everything %s does; prune it with `--apply --prune`.\n", r.Workspace)
+	default:
+		fmt.Fprintf(&b, "MODULE.bazel lacks %s of %s, %d of them without bzlmod support.\n", plural(pending, "statement", "statements"), r.Workspace, unsupported)
+	}
+
+	if len(r.Entries) > 0 {
+		b.WriteString("\n| Status | Statements |\n|--------|------------|\n")
+		for _, s := range Statuses {
+			if n := r.Count(s); n > 0 {
+				fmt.Fprintf(&b, "| %s | %d |\n", statusTitles[s], n)
+			}
+		}
+		b.WriteString("\n## Inventory\n\n| Line | Rule | Repository | Uses | Status | Module | Version | Note |\n|------|------|------------|------|--------|--------|---------|------|\n")
+		for _, e := range r.Entries {
+			name, uses := "", ""
+			if e.Name != "" {
+				name, uses = "`@"+e.Name+"`", fmt.Sprint(e.Uses)
+			}
+			fmt.Fprintf(&b, "| %d | `%s` | %s | %s | %s | %s | %s | %s |\n", e.Line, e.Kind, name, uses, e.Status, e.Module, e.Version, e.Note)
+		}
+	}
+
+	if unsupported > 0 {
+		fmt.Fprintf(&b, "\n## Lacking bzlmod Support\n\nThese stay in %s until migrated by hand.\n\n", r.Workspace)
+		for _, e := range r.Entries {
+			if e.Status != StatusUnsupported {
+				continue
+			}
+			what := "`" + e.Kind + "`"
+			if e.Name != "" {
+				what += " `@" + e.Name + "`"
+			}
+			fmt.Fprintf(&b, "- %s:%d: %s: %s\n", r.Workspace, e.Line, what, e.Note)
+		}
+	}
+
+	if r.Additions != "" {
+		fmt.Fprintf(&b, "\n## MODULE.bazel Additions\n\n`--apply` appends these lines to MODULE.bazel; `--candidate` writes the whole file.\n\n```starlark\n%s```\n", r.Additions)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
@@ -0,0 +1,601 @@
+// Package caserename renames a case of an error enum, or maps it onto
+// another case of the enum, across the tree: the declaration, the
+// constructions and patterns naming it, and the string literals spelling
+// it, which is how cases are looked up by name. Each rename is recorded
+// in error_migrator's migration plan, whose alias generation emits
+// compatibility shims for the case names that are gone.
+package caserename
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errorreport"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errorusage"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/importrewrite"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftlex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// PlanKey is the key of the migration plan under which Record keeps the
+// renamed cases of each error.
+const PlanKey = "renamedCases"
+
+var identPattern = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+
+// typeKeywords open the declarations whose bodies enclosing tracks.
+var typeKeywords = map[string]bool{"enum": true, "extension": true, "struct": true, "class": true, "actor": true, "protocol": true}
+
+// Options configures a rename.
+type Options struct {
+	// Enum is the error enum, From its case and To the case's new name.
+	Enum, From, To string
+	// Module and File pick the definition of Enum when several modules,
+	// or several files of one, declare one.
+	Module, File string
+	// Map folds From into the existing case To instead of renaming it:
+	// the declaration of From is removed and its uses become uses of To.
+	Map bool
+	// Dirs are the top-level directories whose Swift files are rewritten.
+	Dirs []string
+}
+
+// entry is one case declared by a case statement of the enum, as token
+// indices into the file's code.
+type entry struct {
+	keyword, name, end int
+	// payload is the associated values as written, without spaces, e.g.
+	// "(code:Int)".
+	payload string
+	raw     bool
+}
+
+// edit replaces the span [start, end) of a file with text.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// Rename plans renaming opts.From of the error enum opts.Enum found in r
+// in the Swift files below opts.Dirs of root. Uses are rewritten in the
+// enum's own file and in the files r finds referring to the enum; others
+// that might be uses are reported as warnings and left alone, as is an
+// implicit ".From" when another error enum has a case of that name.
+// Nothing is written; see importrewrite.Plan.Apply.
+func Rename(root string, r *errorreport.Report, opts Options) (*importrewrite.Plan, error) {
+	for _, name := range []string{opts.Enum, opts.From, opts.To} {
+		if !identPattern.MatchString(name) {
+			return nil, fmt.Errorf("%q is not a Swift identifier", name)
+		}
+	}
+	if opts.From == opts.To {
+		return nil, fmt.Errorf("%s.%s is already called %s", opts.Enum, opts.From, opts.To)
+	}
+	def, err := find(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(def.Cases, opts.From) {
+		return nil, fmt.Errorf("%s in %s has no case %s", opts.Enum, def.Module, opts.From)
+	}
+	switch has := slices.Contains(def.Cases, opts.To); {
+	case has && !opts.Map:
+		return nil, fmt.Errorf("%s already has a case %s; pass --map to fold %s into it", opts.Enum, opts.To, opts.From)
+	case !has && opts.Map:
+		return nil, fmt.Errorf("%s has no case %s to map %s onto", opts.Enum, opts.To, opts.From)
+	}
+	visible := map[string]bool{def.File: true}
+	for _, f := range def.ReferencedFiles {
+		visible[f] = true
+	}
+	var others []string
+	for _, d := range r.Definitions {
+		if d.Enum && (d.Name != def.Name || d.Module != def.Module) && slices.Contains(d.Cases, opts.From) {
+			others = append(others, d.Name)
+		}
+	}
+	others = slices.Compact(others)
+
+	var paths []string
+	for _, dir := range opts.Dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !slices.Contains(paths, def.File) {
+		paths = append(paths, def.File)
+	}
+	sort.Strings(paths)
+
+	type fileResult struct {
+		change   *importrewrite.Change
+		warnings []string
+	}
+	results, err := pool.Map(paths, func(rel string) (fileResult, error) {
+		data, err := os.ReadFile(filepath.Join(root, rel))
+		if err != nil {
+			return fileResult{}, err
+		}
+		src := string(data)
+		if !strings.Contains(src, opts.From) {
+			return fileResult{}, nil
+		}
+		toks := swiftlex.Code(src)
+		var edits []edit
+		var warnings []string
+		if rel == def.File {
+			if edits, warnings, err = declaration(src, toks, def, opts); err != nil {
+				return fileResult{}, err
+			}
+		}
+		found, foundWarnings := uses(rel, src, toks, def, visible[rel], others, opts)
+		edits = append(edits, found...)
+		warnings = append(warnings, foundWarnings...)
+		if len(edits) == 0 {
+			return fileResult{warnings: warnings}, nil
+		}
+
+		sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+		var b strings.Builder
+		last := 0
+		for _, e := range edits {
+			b.WriteString(src[last:e.start])
+			b.WriteString(e.text)
+			last = e.end
+		}
+		b.WriteString(src[last:])
+		verb := "→"
+		if opts.Map {
+			verb = "mapped to"
+		}
+		change := &importrewrite.Change{Path: rel, Edits: []string{fmt.Sprintf("%s.%s %s %s", opts.Enum, opts.From, verb, opts.To)}, Before: src, After: b.String()}
+		return fileResult{change: change, warnings: warnings}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &importrewrite.Plan{}
+	for _, res := range results {
+		if res.change != nil {
+			plan.Swift = append(plan.Swift, *res.change)
+		}
+		plan.Warnings = append(plan.Warnings, res.warnings...)
+	}
+	return plan, nil
+}
+
+// find returns the definition of opts.Enum, which must be unique once
+// opts.Module is taken into account.
+func find(r *errorreport.Report, opts Options) (*errorreport.Definition, error) {
+	var found []*errorreport.Definition
+	var at []string
+	for i := range r.Definitions {
+		d := &r.Definitions[i]
+		if d.Enum && d.Name == opts.Enum && (opts.Module == "" || d.Module == opts.Module) && (opts.File == "" || d.File == path.Clean(opts.File)) {
+			found = append(found, d)
+			at = append(at, fmt.Sprintf("%s (%s:%d)", d.Module, d.File, d.Line))
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no error enum %s matches", opts.Enum)
+	case 1:
+		return found[0], nil
+	default:
+		return nil, fmt.Errorf("%s is declared in %s; pass --module or --file to pick one", opts.Enum, strings.Join(at, ", "))
+	}
+}
+
+// declaration returns the edits to the case declaration of From in the
+// enum's own file: the name is replaced, or with Map the case is removed
+// after checking its associated values match To's.
+func declaration(src string, toks []swiftlex.Token, def *errorreport.Definition, opts Options) ([]edit, []string, error) {
+	lists, inherits := enumCases(toks, def)
+	var from, to *entry
+	var list []entry
+	for _, l := range lists {
+		for i := range l {
+			switch strings.Trim(toks[l[i].name].Text, "`") {
+			case opts.From:
+				from, list = &l[i], l
+			case opts.To:
+				to = &l[i]
+			}
+		}
+	}
+	if from == nil {
+		return nil, nil, fmt.Errorf("%s:%d: cannot find the declaration of %s.%s", def.File, def.Line, opts.Enum, opts.From)
+	}
+
+	var warnings []string
+	// A String raw value or synthesized Codable conformance encodes the
+	// case by name, unless the case keeps an explicit raw value.
+	encoded := slices.ContainsFunc(inherits, func(name string) bool {
+		return name == "String" || name == "Codable" || name == "Decodable" || name == "Encodable"
+	})
+	if encoded && (opts.Map || !from.raw) {
+		warnings = append(warnings, fmt.Sprintf("%s:%d: %s is encoded by name or raw value, so values encoded as %s no longer decode", def.File, toks[from.name].Line, opts.Enum, opts.From))
+	}
+	if !opts.Map {
+		name := toks[from.name]
+		return []edit{{name.Offset, name.Offset + len(name.Text), opts.To}}, warnings, nil
+	}
+	if to != nil && from.payload != to.payload {
+		return nil, nil, fmt.Errorf("cannot map %s.%s onto %s: their associated values differ, %q and %q", opts.Enum, opts.From, opts.To, from.payload, to.payload)
+	}
+
+	end := func(e entry) int { return toks[e.end].Offset + len(toks[e.end].Text) }
+	i := slices.IndexFunc(list, func(e entry) bool { return e.name == from.name })
+	switch {
+	case len(list) > 1 && i < len(list)-1:
+		return []edit{{toks[from.name].Offset, toks[list[i+1].name].Offset, ""}}, warnings, nil
+	case len(list) > 1:
+		return []edit{{end(list[i-1]), end(*from), ""}}, warnings, nil
+	}
+	start, stop, whole := wholeLines(src, toks[from.keyword].Offset, end(*from))
+	if whole {
+		// Remove the doc comment above the case too.
+		for start > 0 {
+			prev := strings.LastIndexByte(src[:start-1], '\n') + 1
+			if !strings.HasPrefix(strings.TrimSpace(src[prev:start]), "///") {
+				break
+			}
+			start = prev
+		}
+	}
+	return []edit{{start, stop, ""}}, warnings, nil
+}
+
+// wholeLines widens the span [start, stop) of src to the lines it is on
+// when it has them to itself, but for a trailing comment, and reports
+// whether it did.
+func wholeLines(src string, start, stop int) (int, int, bool) {
+	lineStart := strings.LastIndexByte(src[:start], '\n') + 1
+	lineEnd := len(src)
+	if n := strings.IndexByte(src[stop:], '\n'); n >= 0 {
+		lineEnd = stop + n + 1
+	}
+	rest := strings.TrimSpace(src[stop:lineEnd])
+	if strings.TrimSpace(src[lineStart:start]) != "" || rest != "" && !strings.HasPrefix(rest, "//") {
+		return start, stop, false
+	}
+	return lineStart, lineEnd, true
+}
+
+// enumCases returns the case statements of the enum def declares in
+// toks, each as the entries it declares, and the names in the enum's
+// inheritance list.
+func enumCases(toks []swiftlex.Token, def *errorreport.Definition) ([][]entry, []string) {
+	start := -1
+	for i := 0; i+1 < len(toks); i++ {
+		if toks[i].Is(swiftlex.Ident, "enum") && toks[i].Line == def.Line && toks[i+1].Is(swiftlex.Ident, def.Name) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil, nil
+	}
+	var inherits []string
+	j := start + 2
+	for ; j < len(toks) && !toks[j].Is(swiftlex.Punct, "{"); j++ {
+		if toks[j].Kind == swiftlex.Ident {
+			inherits = append(inherits, toks[j].Text)
+		}
+	}
+	var lists [][]entry
+	for depth := 0; j < len(toks); j++ {
+		switch t := toks[j]; {
+		case t.Is(swiftlex.Punct, "{"):
+			depth++
+		case t.Is(swiftlex.Punct, "}"):
+			if depth--; depth == 0 {
+				return lists, inherits
+			}
+		case depth == 1 && t.Is(swiftlex.Ident, "case"):
+			list := caseList(toks, j)
+			if len(list) > 0 {
+				lists = append(lists, list)
+				j = list[len(list)-1].end
+			}
+		}
+	}
+	return lists, inherits
+}
+
+// caseList reads the entries of the case statement whose keyword is
+// toks[k], such as "case invalidKey(String), expired = 2".
+func caseList(toks []swiftlex.Token, k int) []entry {
+	var list []entry
+	for i := k + 1; i < len(toks) && toks[i].Kind == swiftlex.Ident; {
+		e := entry{keyword: k, name: i, end: i}
+		i++
+		if i < len(toks) && toks[i].Is(swiftlex.Punct, "(") {
+			var b strings.Builder
+			for depth := 0; i < len(toks); i++ {
+				b.WriteString(toks[i].Text)
+				if toks[i].Is(swiftlex.Punct, "(") {
+					depth++
+				} else if toks[i].Is(swiftlex.Punct, ")") {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+			e.payload, e.end = b.String(), i
+			i++
+		}
+		if i < len(toks) && toks[i].Is(swiftlex.Punct, "=") {
+			e.raw = true
+			if i++; i < len(toks) && toks[i].Is(swiftlex.Punct, "-") {
+				i++
+			}
+			e.end = i
+			i++
+		}
+		list = append(list, e)
+		if i >= len(toks) || !toks[i].Is(swiftlex.Punct, ",") {
+			break
+		}
+		i++
+	}
+	return list
+}
+
+// uses returns the edits renaming the uses of From in the file rel: the
+// members ".From" and "Enum.From", and the string literals reading
+// "From". Uses are only rewritten in a file that can see the enum. An
+// implicit ".From" is taken to be the enum's inside the enum and its
+// extensions, and another enum's inside that one; elsewhere it is only
+// rewritten when no other error enum has a case of that name.
+func uses(rel, src string, toks []swiftlex.Token, def *errorreport.Definition, visible bool, others []string, opts Options) ([]edit, []string) {
+	var edits []edit
+	var warnings []string
+	inside := enclosing(toks)
+	// removed is the end of the last span removed; uses inside it go
+	// with it.
+	removed := 0
+	for i, t := range toks {
+		if t.Offset < removed {
+			continue
+		}
+		if t.Kind == swiftlex.String && visible && t.Text == `"`+opts.From+`"` {
+			edits = append(edits, edit{t.Offset, t.Offset + len(t.Text), `"` + opts.To + `"`})
+			continue
+		}
+		if t.Kind != swiftlex.Ident || strings.Trim(t.Text, "`") != opts.From || i == 0 || !toks[i-1].Is(swiftlex.Punct, ".") {
+			continue
+		}
+		dot := i - 1
+		implicit := dot == 0 || toks[dot].Space || toks[dot-1].Kind == swiftlex.Punct && !strings.ContainsAny(toks[dot-1].Text, ")]?!>")
+		if !implicit {
+			q := toks[dot-1]
+			if q.Kind != swiftlex.Ident || q.Text != def.Name && !(q.Text == "Self" && inside[i] == def.Name) {
+				// A member of another type or of a value.
+				continue
+			}
+		}
+		switch owner := inside[i]; {
+		case !implicit && !visible:
+			warnings = append(warnings, fmt.Sprintf("%s:%d: %s.%s is used here, but the file does not refer to %s's module; left alone", rel, t.Line, opts.Enum, opts.From, def.Module))
+			continue
+		case !implicit || owner == def.Name:
+		case slices.Contains(others, owner):
+			continue
+		case !visible:
+			if len(others) == 0 {
+				warnings = append(warnings, fmt.Sprintf("%s:%d: .%s may be %s.%s, but the file does not refer to %s; left alone", rel, t.Line, opts.From, opts.Enum, opts.From, opts.Enum))
+			}
+			continue
+		case len(others) > 0:
+			warnings = append(warnings, fmt.Sprintf("%s:%d: .%s may be a case of %s; left alone", rel, t.Line, opts.From, strings.Join(others, " or ")))
+			continue
+		}
+		if opts.Map && inPattern(toks, dot) {
+			// The case is gone, so a switch no longer needs to match it:
+			// its values are now matched as To.
+			if start, stop, ok := unmatch(src, toks, i); ok {
+				edits = append(edits, edit{start, stop, ""})
+				removed = stop
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("%s:%d: this pattern now matches .%s, including values that were .%s before", rel, t.Line, opts.To, opts.To))
+		}
+		edits = append(edits, edit{t.Offset, t.Offset + len(t.Text), opts.To})
+	}
+	return edits, warnings
+}
+
+// unmatch returns the span to remove so that the switch case label
+// holding toks[i] in a pattern no longer matches it: the pattern, when
+// the label lists others, or else the whole branch. It reports false for
+// patterns outside switch labels, for labels with a where clause and for
+// branches running into a compiler directive, which are left to the
+// caller.
+func unmatch(src string, toks []swiftlex.Token, i int) (int, int, bool) {
+	k, depth := i-1, 0
+	for ; k >= 0 && !(depth == 0 && toks[k].Is(swiftlex.Ident, "case")); k-- {
+		switch t := toks[k]; {
+		case t.Is(swiftlex.Punct, ")") || t.Is(swiftlex.Punct, "]"):
+			depth++
+		case t.Is(swiftlex.Punct, "(") || t.Is(swiftlex.Punct, "["):
+			depth--
+		case depth == 0 && t.Kind == swiftlex.Punct && strings.ContainsAny(t.Text, ":={};"):
+			return 0, 0, false
+		}
+	}
+	if k <= 0 {
+		return 0, 0, false
+	}
+	if p := toks[k-1]; !p.Is(swiftlex.Punct, "{") && !p.Is(swiftlex.Punct, "}") && p.EndLine() == toks[k].Line {
+		// An if, guard or for case pattern, not a switch label.
+		return 0, 0, false
+	}
+
+	// Split the label into its patterns, up to the colon ending it.
+	type span struct{ first, last int }
+	var patterns []span
+	at := -1
+	colon := -1
+	first := k + 1
+	depth = 0
+	for j := k + 1; j < len(toks) && colon < 0; j++ {
+		switch t := toks[j]; {
+		case t.Is(swiftlex.Punct, "(") || t.Is(swiftlex.Punct, "["):
+			depth++
+		case t.Is(swiftlex.Punct, ")") || t.Is(swiftlex.Punct, "]"):
+			depth--
+		case depth == 0 && t.Is(swiftlex.Ident, "where"):
+			return 0, 0, false
+		case depth == 0 && (t.Is(swiftlex.Punct, ",") || t.Is(swiftlex.Punct, ":")):
+			if first <= i && i < j {
+				at = len(patterns)
+			}
+			patterns = append(patterns, span{first, j - 1})
+			first = j + 1
+			if t.Text == ":" {
+				colon = j
+			}
+		}
+	}
+	if colon < 0 || at < 0 {
+		return 0, 0, false
+	}
+	end := func(j int) int { return toks[j].Offset + len(toks[j].Text) }
+	switch {
+	case len(patterns) > 1 && at < len(patterns)-1:
+		return toks[patterns[at].first].Offset, toks[patterns[at+1].first].Offset, true
+	case len(patterns) > 1:
+		return end(patterns[at-1].last), end(patterns[at].last), true
+	}
+
+	// The branch runs to the next label or the end of the switch.
+	last := colon
+	depth = 0
+	for j := colon + 1; j < len(toks); j++ {
+		t := toks[j]
+		if depth == 0 && (t.Is(swiftlex.Punct, "}") || (t.Is(swiftlex.Ident, "case") || t.Is(swiftlex.Ident, "default") || t.Is(swiftlex.Attribute, "@unknown")) && toks[j-1].EndLine() < t.Line) {
+			break
+		}
+		switch {
+		case t.Kind == swiftlex.Directive:
+			return 0, 0, false
+		case t.Is(swiftlex.Punct, "{") || t.Is(swiftlex.Punct, "(") || t.Is(swiftlex.Punct, "["):
+			depth++
+		case t.Is(swiftlex.Punct, "}") || t.Is(swiftlex.Punct, ")") || t.Is(swiftlex.Punct, "]"):
+			depth--
+		}
+		last = j
+	}
+	start, stop, _ := wholeLines(src, toks[k].Offset, end(last))
+	return start, stop, true
+}
+
+// enclosing returns, for each token, the name of the innermost type or
+// extension whose body it is in, or "" outside them all. An extension of
+// "Outer.Inner" is named "Inner".
+func enclosing(toks []swiftlex.Token) []string {
+	type body struct {
+		depth int
+		name  string
+	}
+	names := make([]string, len(toks))
+	var open []body
+	pending := ""
+	depth := 0
+	for i, t := range toks {
+		switch {
+		case t.Kind == swiftlex.Ident && typeKeywords[t.Text] && i+1 < len(toks) && toks[i+1].Kind == swiftlex.Ident:
+			name := toks[i+1].Text
+			for j := i + 2; j+1 < len(toks) && toks[j].Is(swiftlex.Punct, ".") && toks[j+1].Kind == swiftlex.Ident; j += 2 {
+				name = toks[j+1].Text
+			}
+			if name[0] >= 'A' && name[0] <= 'Z' {
+				pending = name
+			}
+		case t.Is(swiftlex.Punct, "{"):
+			depth++
+			if pending != "" {
+				open = append(open, body{depth, pending})
+				pending = ""
+			}
+		case t.Is(swiftlex.Punct, "}"):
+			if len(open) > 0 && open[len(open)-1].depth == depth {
+				open = open[:len(open)-1]
+			}
+			depth--
+		}
+		if len(open) > 0 {
+			names[i] = open[len(open)-1].name
+		}
+	}
+	return names
+}
+
+// inPattern reports whether the member whose dot is toks[dot] is part of
+// a pattern: it comes after a case or catch keyword, and before the
+// colon, equals sign, brace or where clause that ends the pattern.
+func inPattern(toks []swiftlex.Token, dot int) bool {
+	for j := dot - 1; j >= 0; j-- {
+		t := toks[j]
+		switch {
+		case t.Is(swiftlex.Ident, "case") || t.Is(swiftlex.Ident, "catch"):
+			return true
+		case t.Is(swiftlex.Ident, "where") || t.Kind == swiftlex.Punct && strings.ContainsAny(t.Text, ":={};"):
+			return false
+		case toks[j+1].Line > t.EndLine() && !t.Is(swiftlex.Punct, ","):
+			return false
+		}
+	}
+	return false
+}
+
+// Record returns the migration plan in data with enum's case from
+// recorded under PlanKey as renamed to to. Cases renamed to from before
+// are now renamed to to, so the plan maps every old name to the current
+// one. The plan's other keys keep their order and values.
+func Record(data []byte, enum, from, to string) ([]byte, error) {
+	var p struct {
+		RenamedCases map[string]map[string]string `json:"renamedCases"`
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	if p.RenamedCases == nil {
+		p.RenamedCases = make(map[string]map[string]string)
+	}
+	cases := p.RenamedCases[enum]
+	if cases == nil {
+		cases = make(map[string]string)
+		p.RenamedCases[enum] = cases
+	}
+	for old, current := range cases {
+		if current == from {
+			cases[old] = to
+		}
+	}
+	cases[from] = to
+	for old, current := range cases {
+		if old == current {
+			delete(cases, old)
+		}
+	}
+	return errorusage.SetPlanKey(data, PlanKey, p.RenamedCases)
+}
@@ -0,0 +1,100 @@
+package caserename
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errorreport"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/importrewrite"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testfixture"
+)
+
+const keyError = "public enum KeyError: Error {\n    case invalid(String)\n    case expired\n}\n"
+
+var invalid = Options{Enum: "KeyError", From: "invalid", To: "malformed", Dirs: []string{"Sources"}}
+
+// plan renames KeyError.invalid in the tree, and returns the plan and the
+// new content of each file it changes.
+func plan(t *testing.T, files map[string]string) (*importrewrite.Plan, map[string]string) {
+	t.Helper()
+	root := testfixture.Write(t, files)
+	report, err := errorreport.Analyse(root, "Sources")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Rename(root, report, invalid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := make(map[string]string)
+	for _, c := range p.Swift {
+		after[c.Path] = c.After
+	}
+	return p, after
+}
+
+func TestRename(t *testing.T) {
+	for name, tc := range map[string]struct {
+		src, want string
+	}{
+		"implicit member": {
+			src:  "let e: KeyError = .invalid(\"x\")\n",
+			want: "let e: KeyError = .malformed(\"x\")\n",
+		},
+		"qualified by the enum": {
+			src:  "func f() throws { throw KeyError.invalid(\"x\") }\n",
+			want: "func f() throws { throw KeyError.malformed(\"x\") }\n",
+		},
+		"pattern binding the payload": {
+			src:  "func f(_ e: KeyError) {\n    switch e {\n    case .invalid(let reason): print(reason)\n    case .expired: break\n    }\n}\n",
+			want: "func f(_ e: KeyError) {\n    switch e {\n    case .malformed(let reason): print(reason)\n    case .expired: break\n    }\n}\n",
+		},
+		"name in a string": {
+			src:  "let e: KeyError? = nil\nlet name = \"invalid\"\nlet other = \"invalid input\"\n",
+			want: "let e: KeyError? = nil\nlet name = \"malformed\"\nlet other = \"invalid input\"\n",
+		},
+		"member of another type": {
+			src:  "let e: KeyError? = nil\nlet v = Validator.invalid\nlet w = value.invalid\n",
+			want: "let e: KeyError? = nil\nlet v = Validator.invalid\nlet w = value.invalid\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, after := plan(t, map[string]string{
+				"Sources/Keys/KeyError.swift": keyError,
+				"Sources/Keys/Use.swift":      tc.src,
+			})
+			if want := strings.Replace(keyError, "case invalid", "case malformed", 1); after["Sources/Keys/KeyError.swift"] != want {
+				t.Errorf("declaration: got %q, want %q", after["Sources/Keys/KeyError.swift"], want)
+			}
+			got, ok := after["Sources/Keys/Use.swift"]
+			if !ok {
+				got = tc.src
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// Another error enum with a case of the same name keeps it, and an
+// implicit member that could be either is left alone with a warning.
+func TestRenameLeavesOtherEnums(t *testing.T) {
+	const netError = "public enum NetError: Error {\n    case invalid\n    case offline\n\n    static let known: [NetError] = [.invalid, .offline]\n}\n"
+	const use = "import Keys\n\nlet k = KeyError.invalid(\"x\")\nlet n = NetError.invalid\nlet m: NetError = .invalid\n"
+	p, after := plan(t, map[string]string{
+		"Sources/Keys/KeyError.swift": keyError,
+		"Sources/Net/NetError.swift":  netError,
+		"Sources/App/Use.swift":       use,
+	})
+	if got, ok := after["Sources/Net/NetError.swift"]; ok {
+		t.Errorf("NetError.swift changed to %q", got)
+	}
+	if got, want := after["Sources/App/Use.swift"], "import Keys\n\nlet k = KeyError.malformed(\"x\")\nlet n = NetError.invalid\nlet m: NetError = .invalid\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if want := "Sources/App/Use.swift:5: .invalid may be a case of NetError; left alone"; !slices.Contains(p.Warnings, want) {
+		t.Errorf("got warnings %q, want %q among them", p.Warnings, want)
+	}
+}
@@ -0,0 +1,217 @@
+// Package changelog writes the fragments that mutating tools leave in
+// changelog.d/ when they change the module structure, and assembles them
+// into a release section of CHANGELOG.md.
+package changelog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/configschema"
+)
+
+// Dir is the fragment directory, relative to the project root.
+const Dir = "changelog.d"
+
+// Kinds of structural change.
+const (
+	KindConsolidation  = "consolidation"
+	KindRename         = "rename"
+	KindRemoval        = "removal"
+	KindErrorMigration = "error-migration"
+	KindOther          = "other"
+)
+
+// Kinds lists every kind in section order.
+var Kinds = []string{KindConsolidation, KindRename, KindRemoval, KindErrorMigration, KindOther}
+
+var headings = map[string]string{
+	KindConsolidation:  "Module consolidations",
+	KindRename:         "Renames",
+	KindRemoval:        "Removals",
+	KindErrorMigration: "Error migrations",
+	KindOther:          "Other structural changes",
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Fragment describes one structural change.
+type Fragment struct {
+	Tool    string    `yaml:"tool"`
+	Kind    string    `yaml:"kind"`
+	Created time.Time `yaml:"created"`
+	// Summary is one line of Markdown describing the change.
+	Summary string `yaml:"summary"`
+	// Modules are the modules the change affects.
+	Modules []string `yaml:"modules,omitempty"`
+	// Migration holds Markdown notes for code depending on the modules,
+	// such as the imports to replace.
+	Migration []string `yaml:"migration,omitempty"`
+
+	// File is the fragment's path, set by Load.
+	File string `yaml:"-"`
+}
+
+// Validate checks that f can be written and assembled.
+func (f Fragment) Validate() error {
+	if f.Tool == "" {
+		return fmt.Errorf("fragment has no tool")
+	}
+	if _, ok := headings[f.Kind]; !ok {
+		return fmt.Errorf("unknown change kind %q (want one of %s)", f.Kind, strings.Join(Kinds, ", "))
+	}
+	if strings.TrimSpace(f.Summary) == "" {
+		return fmt.Errorf("fragment has no summary")
+	}
+	if strings.Contains(f.Summary, "\n") {
+		return fmt.Errorf("summary must be a single line")
+	}
+	return nil
+}
+
+// Write validates f and writes it to a new file in dir, named after its
+// time, tool and summary so that fragments from parallel branches do not
+// conflict. It returns the file's path.
+func Write(dir string, f Fragment) (string, error) {
+	if f.Created.IsZero() {
+		f.Created = time.Now().UTC()
+	}
+	if err := f.Validate(); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return "", err
+	}
+	slug := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(f.Summary), "-"), "-")
+	if len(slug) > 40 {
+		slug = strings.TrimRight(slug[:40], "-")
+	}
+	base := f.Created.UTC().Format("20060102_150405") + "-" + f.Tool + "-" + slug
+	for n := 1; ; n++ {
+		name := base + ".yaml"
+		if n > 1 {
+			name = fmt.Sprintf("%s-%d.yaml", base, n)
+		}
+		path := filepath.Join(dir, name)
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err := file.Write(data); err != nil {
+			file.Close()
+			return "", err
+		}
+		return path, file.Close()
+	}
+}
+
+// Load reads every fragment in dir, oldest first. A missing directory has
+// no fragments.
+func Load(dir string) ([]Fragment, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var frags []Fragment
+	for _, e := range entries {
+		if e.IsDir() || (filepath.Ext(e.Name()) != ".yaml" && filepath.Ext(e.Name()) != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := configschema.Validate(configschema.KindChangelogFragment, path, data); err != nil {
+			return nil, err
+		}
+		var f Fragment
+		if err := yaml.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if err := f.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		f.File = path
+		frags = append(frags, f)
+	}
+	sort.SliceStable(frags, func(i, j int) bool {
+		if !frags[i].Created.Equal(frags[j].Created) {
+			return frags[i].Created.Before(frags[j].Created)
+		}
+		return frags[i].File < frags[j].File
+	})
+	return frags, nil
+}
+
+// WriteSection renders the fragments as the CHANGELOG.md section of a
+// release, grouped by kind and ending in a blank line.
+func WriteSection(w io.Writer, version string, date time.Time, frags []Fragment) error {
+	fmt.Fprintf(w, "## %s (%s)\n", version, date.Format("2006-01-02"))
+
+	byKind := make(map[string][]Fragment)
+	for _, f := range frags {
+		byKind[f.Kind] = append(byKind[f.Kind], f)
+	}
+	for _, kind := range Kinds {
+		if len(byKind[kind]) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n### %s\n\n", headings[kind])
+		for _, f := range byKind[kind] {
+			fmt.Fprintf(w, "- %s", f.Summary)
+			if len(f.Modules) > 0 {
+				fmt.Fprintf(w, " (%s)", strings.Join(f.Modules, ", "))
+			}
+			fmt.Fprintln(w)
+			for _, note := range f.Migration {
+				fmt.Fprintf(w, "  - Migration: %s\n", note)
+			}
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// Insert adds section to the existing changelog text: after its "# "
+// title, or at the top when it has none.
+func Insert(changelog, section []byte) []byte {
+	if len(changelog) == 0 {
+		return append([]byte("# Changelog\n\n"), section...)
+	}
+	if !bytes.HasPrefix(changelog, []byte("# ")) {
+		return append(section, changelog...)
+	}
+
+	end := bytes.IndexByte(changelog, '\n')
+	if end < 0 {
+		return append(append(changelog, "\n\n"...), section...)
+	}
+	rest := bytes.TrimLeft(changelog[end+1:], "\n")
+	var out []byte
+	out = append(out, changelog[:end+1]...)
+	out = append(out, '\n')
+	out = append(out, section...)
+	return append(out, rest...)
+}
@@ -0,0 +1,105 @@
+// Package churn counts the commits that changed each file, following the
+// file back through the renames git detects, so a file moved during the
+// consolidation keeps the history of its old path.
+package churn
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ErrNoRepository is returned by Log outside a git work tree.
+var ErrNoRepository = errors.New("not inside a git work tree")
+
+// Commit is one commit and the files it changed.
+type Commit struct {
+	SHA  string
+	Time time.Time
+	// Files are the changed files under their current paths relative to
+	// root. Files deleted since keep their last path.
+	Files []string
+}
+
+// Log returns the commits from since to until that changed files below
+// root, newest first; a zero since starts at the first commit and a zero
+// until ends at the last. Renames after until are followed too, so every
+// path is the file's current one.
+func Log(root string, since, until time.Time) ([]Commit, error) {
+	if out, err := exec.Command("git", "-C", root, "rev-parse", "--is-inside-work-tree").Output(); err != nil || strings.TrimSpace(string(out)) != "true" {
+		return nil, ErrNoRepository
+	}
+	args := []string{"-C", root, "log", "-M", "--name-status", "--relative", "--format=commit %H %cI"}
+	if !since.IsZero() {
+		args = append(args, "--since="+since.Format(time.RFC3339))
+	}
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+
+	// git log lists the newest commit first, so a rename is seen before
+	// the older commits that changed the file under its old name.
+	current := make(map[string]string)
+	var commits []Commit
+	var c *Commit
+	var seen map[string]bool
+	flush := func() {
+		if c != nil && len(c.Files) > 0 && (until.IsZero() || !c.Time.After(until)) {
+			commits = append(commits, *c)
+		}
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if rest, ok := strings.CutPrefix(line, "commit "); ok {
+			flush()
+			sha, date, _ := strings.Cut(rest, " ")
+			when, _ := time.Parse(time.RFC3339, date)
+			c, seen = &Commit{SHA: sha[:min(len(sha), 12)], Time: when.UTC()}, make(map[string]bool)
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if c == nil || len(fields) < 2 {
+			continue
+		}
+		p := fields[len(fields)-1]
+		if now, ok := current[p]; ok {
+			p = now
+		}
+		if strings.HasPrefix(fields[0], "R") && len(fields) == 3 {
+			current[fields[1]] = p
+		}
+		if !seen[p] {
+			seen[p] = true
+			c.Files = append(c.Files, p)
+		}
+	}
+	flush()
+	return commits, nil
+}
+
+// Count returns the number of commits that changed each file.
+func Count(commits []Commit) map[string]int {
+	counts := make(map[string]int)
+	for _, c := range commits {
+		for _, f := range c.Files {
+			counts[f]++
+		}
+	}
+	return counts
+}
+
+// Commits returns the number of commits since since that changed each
+// file, keyed by its current path relative to root; a zero since counts
+// the whole history. Outside a git work tree it returns nil.
+func Commits(root string, since time.Time) (map[string]int, error) {
+	commits, err := Log(root, since, time.Time{})
+	if errors.Is(err, ErrNoRepository) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Count(commits), nil
+}
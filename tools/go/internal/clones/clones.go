@@ -0,0 +1,422 @@
+// Package clones finds duplicated and near-duplicated blocks of Swift code
+// in different files, such as the copies the isolation files and module
+// consolidation left behind. Blocks are the brace-delimited bodies of
+// declarations and statements, with the header before the brace. Each is
+// compared as the set of its shingles, the runs of ShingleSize tokens it
+// contains, after identifiers, string literals and numbers are normalised,
+// so a copy with renamed variables or changed messages still matches.
+// Candidate pairs come from MinHash signatures; their similarity is the
+// exact Jaccard index of the two shingle sets.
+package clones
+
+import (
+	"hash/fnv"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftlex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// ShingleSize is the number of tokens in a shingle.
+const ShingleSize = 5
+
+// Defaults for Options.MinTokens and Options.Similarity.
+const (
+	DefaultMinTokens  = 60
+	DefaultSimilarity = 0.8
+)
+
+// Clone kinds.
+const (
+	// KindExact is a copy with the same tokens.
+	KindExact = "exact"
+	// KindRenamed is a copy differing only in identifiers and literals.
+	KindRenamed = "renamed"
+	// KindNear is a copy with edits beyond those.
+	KindNear = "near"
+)
+
+const (
+	// bands and rows split the MinHash signature: two blocks become a
+	// candidate pair when all rows of some band agree, which happens for
+	// 98% of pairs 0.8 similar and 67% of pairs 0.6 similar.
+	bands = 8
+	rows  = 4
+	// minVariety is the share of a block's shingles that must differ from
+	// each other. Tables repeating one short pattern, such as a switch
+	// mapping every case to a string, fall below it: every enum has
+	// them, so they are alike without being copies.
+	minVariety = 0.3
+)
+
+// keywords keep their text when tokens are normalised; every other
+// identifier becomes the same token.
+var keywords = func() map[string]bool {
+	m := make(map[string]bool)
+	for _, k := range strings.Fields(`
+		actor any as associatedtype async await break case catch class continue
+		convenience default defer deinit didSet do else enum extension
+		fallthrough false fileprivate final for func get guard if import in
+		indirect init inout internal is lazy let mutating nil nonisolated open
+		override package private protocol public repeat required rethrows
+		return self Self set some static struct subscript super switch throw
+		throws true try typealias unowned var weak where while willSet
+	`) {
+		m[k] = true
+	}
+	return m
+}()
+
+// Options configures a scan.
+type Options struct {
+	// Dirs are the top-level directories whose Swift files are compared.
+	Dirs []string
+	// Rules map files to their modules.
+	Rules []modulenames.Rule
+	// MinTokens is the smallest block compared, in tokens.
+	MinTokens int
+	// Similarity is the smallest Jaccard index reported, from 0 to 1.
+	Similarity float64
+	// SameModule also compares files of the same module.
+	SameModule bool
+}
+
+// Block is a span of code in one file.
+type Block struct {
+	Module  string `json:"module"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	EndLine int    `json:"endLine"`
+	Tokens  int    `json:"tokens"`
+}
+
+// Clone is a pair of similar blocks. A sorts before B by file.
+type Clone struct {
+	A           Block   `json:"a"`
+	B           Block   `json:"b"`
+	Similarity  float64 `json:"similarity"`
+	Kind        string  `json:"kind"`
+	Fingerprint string  `json:"fingerprint,omitempty"`
+}
+
+// ModulePair totals the clones between two modules, or within one.
+type ModulePair struct {
+	A      string `json:"a"`
+	B      string `json:"b"`
+	Clones int    `json:"clones"`
+	// Tokens is the size of the duplicated code, counting the smaller
+	// block of each clone.
+	Tokens int `json:"tokens"`
+}
+
+// Report holds the clones found.
+type Report struct {
+	Files      int     `json:"files"`
+	Blocks     int     `json:"blocks"`
+	MinTokens  int     `json:"minTokens"`
+	Similarity float64 `json:"similarity"`
+	// Clones are sorted by similarity and then size, largest first.
+	Clones []Clone `json:"clones"`
+	// Pairs are sorted by duplicated tokens, most first.
+	Pairs []ModulePair `json:"modulePairs"`
+}
+
+// block is a Block being compared.
+type block struct {
+	Block
+	// start and end are token indices into the file's code.
+	file, start, end int
+	shingles         []uint64
+	raw, normalised  uint64
+	signature        [bands * rows]uint64
+}
+
+// Find compares the blocks of the Swift files below opts.Dirs of root.
+func Find(root string, opts Options) (*Report, error) {
+	ix := &moduleindex.Index{Modules: opts.Rules}
+	var paths []string
+	for _, dir := range opts.Dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	perFile, err := pool.Map(paths, func(rel string) ([]*block, error) {
+		data, err := os.ReadFile(filepath.Join(root, rel))
+		if err != nil {
+			return nil, err
+		}
+		module := workspace.ModuleForPath(rel)
+		if rule, ok := ix.ForPath(rel); ok {
+			module = rule.ModuleName
+		}
+		return blocks(rel, module, string(data), opts.MinTokens), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var all []*block
+	for i, bs := range perFile {
+		for _, b := range bs {
+			b.file = i
+			all = append(all, b)
+		}
+	}
+
+	report := &Report{Files: len(paths), Blocks: len(all), MinTokens: opts.MinTokens, Similarity: opts.Similarity, Clones: []Clone{}, Pairs: []ModulePair{}}
+	type pair struct{ a, b int }
+	seen := make(map[pair]bool)
+	var found []pair
+	var similarity []float64
+	for band := 0; band < bands; band++ {
+		buckets := make(map[uint64][]int)
+		for i, b := range all {
+			h := fnv.New64a()
+			for _, v := range b.signature[band*rows : (band+1)*rows] {
+				h.Write(uint64Bytes(v))
+			}
+			key := h.Sum64()
+			buckets[key] = append(buckets[key], i)
+		}
+		for _, members := range buckets {
+			for x := 0; x < len(members); x++ {
+				for y := x + 1; y < len(members); y++ {
+					p := pair{members[x], members[y]}
+					a, b := all[p.a], all[p.b]
+					if a.file == b.file || !opts.SameModule && a.Module == b.Module || seen[p] {
+						continue
+					}
+					seen[p] = true
+					if s := jaccard(a.shingles, b.shingles); s >= opts.Similarity {
+						found = append(found, p)
+						similarity = append(similarity, s)
+					}
+				}
+			}
+		}
+	}
+
+	// Report the outermost pair of each nest: blocks inside a reported
+	// pair of blocks are part of that clone.
+	order := make([]int, len(found))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		pi, pj := found[order[i]], found[order[j]]
+		si := all[pi.a].Tokens + all[pi.b].Tokens
+		sj := all[pj.a].Tokens + all[pj.b].Tokens
+		if si != sj {
+			return si > sj
+		}
+		return pi.a < pj.a || pi.a == pj.a && pi.b < pj.b
+	})
+	var kept []pair
+	for _, n := range order {
+		p := found[n]
+		a, b := all[p.a], all[p.b]
+		nested := slices.ContainsFunc(kept, func(k pair) bool {
+			return contains(all[k.a], a) && contains(all[k.b], b) || contains(all[k.a], b) && contains(all[k.b], a)
+		})
+		if nested {
+			continue
+		}
+		kept = append(kept, p)
+		if b.File < a.File {
+			a, b = b, a
+		}
+		c := Clone{A: a.Block, B: b.Block, Similarity: float64(int(similarity[n]*1000+0.5)) / 1000, Kind: KindNear}
+		switch {
+		case a.raw == b.raw:
+			c.Kind = KindExact
+		case a.normalised == b.normalised:
+			c.Kind = KindRenamed
+		}
+		report.Clones = append(report.Clones, c)
+	}
+	sort.SliceStable(report.Clones, func(i, j int) bool {
+		a, b := report.Clones[i], report.Clones[j]
+		if a.Similarity != b.Similarity {
+			return a.Similarity > b.Similarity
+		}
+		if a.A.Tokens+a.B.Tokens != b.A.Tokens+b.B.Tokens {
+			return a.A.Tokens+a.B.Tokens > b.A.Tokens+b.B.Tokens
+		}
+		if a.A.File != b.A.File {
+			return a.A.File < b.A.File
+		}
+		return a.A.Line < b.A.Line
+	})
+
+	pairs := make(map[[2]string]*ModulePair)
+	for _, c := range report.Clones {
+		key := [2]string{c.A.Module, c.B.Module}
+		if key[1] < key[0] {
+			key[0], key[1] = key[1], key[0]
+		}
+		mp := pairs[key]
+		if mp == nil {
+			mp = &ModulePair{A: key[0], B: key[1]}
+			pairs[key] = mp
+		}
+		mp.Clones++
+		mp.Tokens += min(c.A.Tokens, c.B.Tokens)
+	}
+	for _, mp := range pairs {
+		report.Pairs = append(report.Pairs, *mp)
+	}
+	sort.Slice(report.Pairs, func(i, j int) bool {
+		a, b := report.Pairs[i], report.Pairs[j]
+		if a.Tokens != b.Tokens {
+			return a.Tokens > b.Tokens
+		}
+		if a.A != b.A {
+			return a.A < b.A
+		}
+		return a.B < b.B
+	})
+	return report, nil
+}
+
+// blocks returns the blocks of at least minTokens tokens in one file.
+func blocks(rel, module, src string, minTokens int) []*block {
+	toks := swiftlex.Code(src)
+	norm := make([]uint64, len(toks))
+	raw := make([]uint64, len(toks))
+	for i, t := range toks {
+		raw[i] = hashString(t.Text)
+		norm[i] = raw[i]
+		switch {
+		case t.Kind == swiftlex.Ident && !keywords[t.Text]:
+			norm[i] = hashString("$id")
+		case t.Kind == swiftlex.String:
+			norm[i] = hashString("$str")
+		case t.Kind == swiftlex.Number:
+			norm[i] = hashString("$num")
+		}
+	}
+	// windows[i] hashes the shingle starting at token i.
+	var windows []uint64
+	for i := 0; i+ShingleSize <= len(toks); i++ {
+		h := fnv.New64a()
+		for _, v := range norm[i : i+ShingleSize] {
+			h.Write(uint64Bytes(v))
+		}
+		windows = append(windows, h.Sum64())
+	}
+
+	var out []*block
+	var open []int
+	boundary := -1
+	for i, t := range toks {
+		switch {
+		case t.Is(swiftlex.Punct, "{"):
+			open = append(open, boundary+1)
+			boundary = i
+		case t.Is(swiftlex.Punct, "}"):
+			boundary = i
+			if len(open) == 0 {
+				continue
+			}
+			start := open[len(open)-1]
+			open = open[:len(open)-1]
+			if i-start+1 < minTokens || i-start+1 < ShingleSize {
+				continue
+			}
+			b := &block{Block: Block{Module: module, File: rel, Line: toks[start].Line, EndLine: t.EndLine(), Tokens: i - start + 1}, start: start, end: i}
+			b.shingles = slices.Clone(windows[start : i-ShingleSize+2])
+			slices.Sort(b.shingles)
+			b.shingles = slices.Compact(b.shingles)
+			if float64(len(b.shingles)) < minVariety*float64(i-start-ShingleSize+2) {
+				continue
+			}
+			b.raw, b.normalised = hashAll(raw[start:i+1]), hashAll(norm[start:i+1])
+			for j := range b.signature {
+				b.signature[j] = ^uint64(0)
+				for _, s := range b.shingles {
+					b.signature[j] = min(b.signature[j], mix(s^seeds[j]))
+				}
+			}
+			out = append(out, b)
+		case t.Is(swiftlex.Punct, ";"):
+			boundary = i
+		}
+	}
+	return out
+}
+
+// contains reports whether outer spans inner in the same file.
+func contains(outer, inner *block) bool {
+	return outer.file == inner.file && outer.start <= inner.start && inner.end <= outer.end
+}
+
+// jaccard returns the Jaccard index of two sorted sets.
+func jaccard(a, b []uint64) float64 {
+	common := 0
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			common++
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// seeds make the MinHash functions differ from each other.
+var seeds = func() [bands * rows]uint64 {
+	var s [bands * rows]uint64
+	for i := range s {
+		s[i] = mix(uint64(i) + 1)
+	}
+	return s
+}()
+
+// mix is the splitmix64 finalizer, a cheap hash of a 64-bit value.
+func mix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+func hashAll(vs []uint64) uint64 {
+	h := fnv.New64a()
+	for _, v := range vs {
+		h.Write(uint64Bytes(v))
+	}
+	return h.Sum64()
+}
+
+func uint64Bytes(v uint64) []byte {
+	return []byte{byte(v), byte(v >> 8), byte(v >> 16), byte(v >> 24), byte(v >> 32), byte(v >> 40), byte(v >> 48), byte(v >> 56)}
+}
@@ -0,0 +1,52 @@
+package clones
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes the module pairs and then every clone.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	b.WriteString("# Code Clones\n\n")
+	fmt.Fprintf(&b, "%d blocks of at least %d tokens in %d files compared: %d clones at least %.0f%% similar.\n",
+		r.Blocks, r.MinTokens, r.Files, len(r.Clones), r.Similarity*100)
+	if len(r.Clones) == 0 {
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	b.WriteString("\n## Module Pairs\n\n")
+	b.WriteString("| Modules | Clones | Duplicated tokens |\n")
+	b.WriteString("|---------|--------|-------------------|\n")
+	for _, p := range r.Pairs {
+		modules := p.A + " ↔ " + p.B
+		if p.A == p.B {
+			modules = p.A
+		}
+		fmt.Fprintf(&b, "| %s | %d | %d |\n", modules, p.Clones, p.Tokens)
+	}
+
+	b.WriteString("\n## Clones\n\n")
+	b.WriteString("| Similarity | Kind | First | Second | Tokens |\n")
+	b.WriteString("|------------|------|-------|--------|--------|\n")
+	for _, c := range r.Clones {
+		fmt.Fprintf(&b, "| %.0f%% | %s | %s | %s | %d / %d |\n", c.Similarity*100, c.Kind, location(c.A), location(c.B), c.A.Tokens, c.B.Tokens)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// location renders a block as "Module: file:line-endLine".
+func location(b Block) string {
+	return fmt.Sprintf("%s: `%s:%d-%d`", b.Module, b.File, b.Line, b.EndLine)
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,418 @@
+// Package complexity measures lines of code and the cyclomatic complexity
+// of Swift functions, per file and per module.
+package complexity
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftlex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+var (
+	// decisionWords are the keywords of the branch points counted by
+	// cyclomatic complexity, and decisionOperators its operators; a
+	// ternary ? is told from an optional's by the spaces around it.
+	decisionWords     = map[string]bool{"if": true, "guard": true, "for": true, "while": true, "case": true, "catch": true}
+	decisionOperators = map[string]bool{"&&": true, "||": true, "??": true}
+	// declWords start declarations that show a pending function had no
+	// body, as in protocol requirements.
+	declWords = map[string]bool{"var": true, "let": true, "case": true, "typealias": true, "associatedtype": true}
+)
+
+// Function is one function, initialiser or subscript with a body.
+type Function struct {
+	Name       string `json:"name"`
+	Line       int    `json:"line"`
+	Complexity int    `json:"complexity"`
+	// Fingerprint is set on a function over --max-function, which is
+	// reported as an issue.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// File holds the measurements of one Swift file.
+type File struct {
+	Path     string `json:"path"`
+	Module   string `json:"module"`
+	Lines    int    `json:"lines"`
+	Code     int    `json:"code"`
+	Comments int    `json:"comments"`
+	Blank    int    `json:"blank"`
+	// Complexity is the sum of the file's function complexities.
+	Complexity    int        `json:"complexity"`
+	MaxComplexity int        `json:"maxComplexity"`
+	Functions     []Function `json:"functions"`
+	// Partial is set when a line too long to analyse stopped the scan. The
+	// remaining lines are counted as code, and complexity covers only the
+	// lines before it.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// Module aggregates the files of one module.
+type Module struct {
+	Name          string `json:"name"`
+	Files         int    `json:"files"`
+	Lines         int    `json:"lines"`
+	Code          int    `json:"code"`
+	Comments      int    `json:"comments"`
+	Blank         int    `json:"blank"`
+	Functions     int    `json:"functions"`
+	Complexity    int    `json:"complexity"`
+	MaxComplexity int    `json:"maxComplexity"`
+}
+
+// Report is the result of an analysis.
+type Report struct {
+	Modules []Module `json:"modules"`
+	Files   []File   `json:"files"`
+}
+
+// Analyse measures every Swift file below the given top-level directories
+// of root (default "Sources").
+func Analyse(root string, dirs ...string) (*Report, error) {
+	paths, err := swiftFiles(root, dirs)
+	if err != nil {
+		return nil, err
+	}
+	return AnalyseFiles(root, paths)
+}
+
+// Count is Analyse for tools that need only the line counts: it skips
+// the function and complexity analysis, so the files' Functions are empty.
+func Count(root string, dirs ...string) (*Report, error) {
+	paths, err := swiftFiles(root, dirs)
+	if err != nil {
+		return nil, err
+	}
+	return report(root, paths, CountFile, nil)
+}
+
+// AnalyseFiles builds the report for the given Swift files, relative to
+// root, such as those a Bazel aspect lists for one target.
+func AnalyseFiles(root string, paths []string) (*Report, error) {
+	return report(root, paths, AnalyseFile, nil)
+}
+
+// AnalyseEach is Analyse calling each with every file, in path order, as
+// soon as it is measured, so that the files can be streamed out.
+func AnalyseEach(root string, dirs []string, each func(File) error) (*Report, error) {
+	paths, err := swiftFiles(root, dirs)
+	if err != nil {
+		return nil, err
+	}
+	return AnalyseFilesEach(root, paths, each)
+}
+
+// AnalyseFilesEach is AnalyseFiles calling each with every file, in path
+// order, as soon as it is measured.
+func AnalyseFilesEach(root string, paths []string, each func(File) error) (*Report, error) {
+	return report(root, paths, AnalyseFile, each)
+}
+
+func swiftFiles(root string, dirs []string) ([]string, error) {
+	if len(dirs) == 0 {
+		dirs = []string{"Sources"}
+	}
+
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+func report(root string, paths []string, measure func(root, rel string) (File, error), each func(File) error) (*Report, error) {
+	paths = slices.Clone(paths)
+	sort.Strings(paths)
+	if each == nil {
+		each = func(File) error { return nil }
+	}
+	files, err := pool.Stream(paths, func(rel string) (File, error) {
+		return measure(root, rel)
+	}, each)
+	if err != nil {
+		return nil, err
+	}
+
+	byModule := make(map[string]*Module)
+	var names []string
+	for _, f := range files {
+		m, ok := byModule[f.Module]
+		if !ok {
+			m = &Module{Name: f.Module}
+			byModule[f.Module] = m
+			names = append(names, f.Module)
+		}
+		m.Files++
+		m.Lines += f.Lines
+		m.Code += f.Code
+		m.Comments += f.Comments
+		m.Blank += f.Blank
+		m.Functions += len(f.Functions)
+		m.Complexity += f.Complexity
+		m.MaxComplexity = max(m.MaxComplexity, f.MaxComplexity)
+	}
+
+	sort.Strings(names)
+	report := &Report{Files: files}
+	for _, name := range names {
+		report.Modules = append(report.Modules, *byModule[name])
+	}
+	return report, nil
+}
+
+// AnalyseFile measures one file, given relative to root.
+func AnalyseFile(root, rel string) (File, error) {
+	return measure(root, rel, true)
+}
+
+// CountFile counts the lines of one file, given relative to root, as
+// AnalyseFile does but without looking for functions.
+func CountFile(root, rel string) (File, error) {
+	return measure(root, rel, false)
+}
+
+func measure(root, rel string, functions bool) (File, error) {
+	file := File{Path: rel, Module: workspace.ModuleForPath(rel)}
+	content, err := readFile(root, rel)
+	if err != nil {
+		return file, err
+	}
+	src, long := beforeLongLine(content)
+	toks := swiftlex.Tokens(src)
+	countLines(src, toks, &file)
+	if functions {
+		file.Functions = findFunctions(toks)
+	}
+	if long {
+		partial(rel, content, &file)
+	}
+
+	sort.Slice(file.Functions, func(i, j int) bool { return file.Functions[i].Line < file.Functions[j].Line })
+	for _, fn := range file.Functions {
+		file.Complexity += fn.Complexity
+		file.MaxComplexity = max(file.MaxComplexity, fn.Complexity)
+	}
+	return file, nil
+}
+
+// beforeLongLine returns the lines of content before the first longer
+// than textscan.MaxLine, and whether there is one.
+func beforeLongLine(content string) (string, bool) {
+	for offset := 0; offset < len(content); {
+		end := strings.IndexByte(content[offset:], '\n')
+		if end < 0 {
+			end = len(content) - offset
+		}
+		if end > textscan.MaxLine {
+			return content[:offset], true
+		}
+		offset += end + 1
+	}
+	return content, false
+}
+
+// countLines counts the lines of src as blank, comment-only or code. A
+// line is code when any token but a comment is on it, so the lines inside
+// a multi-line string literal are code.
+func countLines(src string, toks []swiftlex.Token, file *File) {
+	file.Lines = textscan.Count(src)
+	code := make([]bool, file.Lines+2)
+	for _, t := range toks {
+		if t.Kind != swiftlex.Comment {
+			for l := t.Line; l <= t.EndLine(); l++ {
+				code[l] = true
+			}
+		}
+	}
+	lineNo := 0
+	for rest := src; rest != ""; {
+		line, after, _ := strings.Cut(rest, "\n")
+		rest = after
+		lineNo++
+		switch {
+		case code[lineNo]:
+			file.Code++
+		case strings.TrimSpace(line) == "":
+			file.Blank++
+		default:
+			file.Comments++
+		}
+	}
+}
+
+// findFunctions returns the functions, initialisers, deinitialisers and
+// subscripts with a body among toks, with their complexity: one more than
+// their branch points, those of closures within them included.
+func findFunctions(toks []swiftlex.Token) []Function {
+	type frame struct {
+		fn    Function
+		depth int
+	}
+	var (
+		functions []Function
+		stack     []*frame
+		pending   *Function
+		depth     int
+	)
+	for i, t := range toks {
+		if t.Kind == swiftlex.Comment {
+			continue
+		}
+		if name, ok := functionStart(toks, i); ok {
+			pending = &Function{Name: name, Line: t.Line, Complexity: 1}
+			continue
+		}
+		switch {
+		case t.Is(swiftlex.Punct, "{"):
+			depth++
+			if pending != nil {
+				stack = append(stack, &frame{fn: *pending, depth: depth})
+				pending = nil
+			}
+		case t.Is(swiftlex.Punct, "}"):
+			pending = nil
+			depth--
+			for len(stack) > 0 && depth < stack[len(stack)-1].depth {
+				functions = append(functions, stack[len(stack)-1].fn)
+				stack = stack[:len(stack)-1]
+			}
+		case pending != nil:
+			// The function's signature, or a requirement without a body.
+			if t.Kind == swiftlex.Ident && declWords[t.Text] {
+				pending = nil
+			}
+		case len(stack) > 0 && decides(toks, i):
+			stack[len(stack)-1].fn.Complexity++
+		}
+	}
+	for _, fr := range stack {
+		functions = append(functions, fr.fn)
+	}
+	return functions
+}
+
+// functionStart reports whether toks[i] starts a function-like
+// declaration, and returns its name.
+func functionStart(toks []swiftlex.Token, i int) (string, bool) {
+	t := toks[i]
+	if t.Kind != swiftlex.Ident || i > 0 && toks[i-1].Is(swiftlex.Punct, ".") {
+		return "", false
+	}
+	next := func(k int) swiftlex.Token {
+		for j := i + 1; j < len(toks); j++ {
+			if toks[j].Kind != swiftlex.Comment {
+				if k--; k == 0 {
+					return toks[j]
+				}
+			}
+		}
+		return swiftlex.Token{}
+	}
+	opens := func(n swiftlex.Token) bool {
+		return n.Is(swiftlex.Punct, "(") || n.Kind == swiftlex.Punct && strings.HasPrefix(n.Text, "<")
+	}
+	switch t.Text {
+	case "func":
+		if n := next(1); n.Kind == swiftlex.Ident || n.Kind == swiftlex.Punct && n.Text != "(" {
+			return n.Text, true
+		}
+	case "init":
+		n := next(1)
+		if n.Is(swiftlex.Punct, "?") || n.Is(swiftlex.Punct, "!") {
+			n = next(2)
+		}
+		if opens(n) {
+			return "init", true
+		}
+	case "deinit":
+		return "deinit", true
+	case "subscript":
+		if opens(next(1)) {
+			return "subscript", true
+		}
+	}
+	return "", false
+}
+
+// decides reports whether toks[i] is a branch point.
+func decides(toks []swiftlex.Token, i int) bool {
+	t := toks[i]
+	switch t.Kind {
+	case swiftlex.Ident:
+		return decisionWords[t.Text] && (i == 0 || !toks[i-1].Is(swiftlex.Punct, "."))
+	case swiftlex.Punct:
+		return decisionOperators[t.Text] || t.Text == "?" && t.Space && i+1 < len(toks) && toks[i+1].Space
+	}
+	return false
+}
+
+// readFile reads a whole file at once: splitting it in memory is several
+// times faster than scanning it line by line on the largest files.
+func readFile(root, rel string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(root, rel))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// partial counts the lines of content not yet analysed as code, so the
+// file's size is still right when a line longer than textscan.MaxLine
+// cuts its analysis short.
+func partial(rel, content string, file *File) {
+	textscan.Warn(rel, file.Lines)
+	total := textscan.Count(content)
+	file.Code += total - file.Lines
+	file.Lines = total
+	file.Partial = true
+}
+
+// TopFiles returns the n files with the highest total complexity.
+func TopFiles(files []File, n int) []File {
+	sorted := append([]File(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Complexity > sorted[j].Complexity })
+	if n > 0 && len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// Ranked is a function together with the file declaring it.
+type Ranked struct {
+	Function
+	File string `json:"file"`
+}
+
+// TopFunctions returns the n most complex functions.
+func TopFunctions(files []File, n int) []Ranked {
+	var all []Ranked
+	for _, f := range files {
+		for _, fn := range f.Functions {
+			all = append(all, Ranked{Function: fn, File: f.Path})
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Complexity > all[j].Complexity })
+	if n > 0 && len(all) > n {
+		all = all[:n]
+	}
+	return all
+}
@@ -0,0 +1,29 @@
+package complexity
+
+import (
+	"testing"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testfixture"
+)
+
+func BenchmarkAnalyse(b *testing.B) {
+	root := testfixture.SwiftTree(b, 8, 40)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Analyse(root); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCount counts lines without measuring functions, as budgets
+// does.
+func BenchmarkCount(b *testing.B) {
+	root := testfixture.SwiftTree(b, 8, 40)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Count(root); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package complexity
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteMarkdown writes the per-module table followed by the top most
+// complex files and functions.
+func WriteMarkdown(w io.Writer, r *Report, top int) error {
+	mods := append([]Module(nil), r.Modules...)
+	sort.SliceStable(mods, func(i, j int) bool { return mods[i].Complexity > mods[j].Complexity })
+
+	var total Module
+	for _, m := range mods {
+		total.Files += m.Files
+		total.Code += m.Code
+		total.Functions += m.Functions
+		total.Complexity += m.Complexity
+	}
+
+	var b strings.Builder
+	b.WriteString("# Code Complexity Report\n\n")
+	fmt.Fprintf(&b, "**%d files, %d lines of code, %d functions, total complexity %d**\n\n", total.Files, total.Code, total.Functions, total.Complexity)
+
+	b.WriteString("## Modules\n\n")
+	b.WriteString("| Module | Files | Code | Comments | Functions | Complexity | Max |\n")
+	b.WriteString("|--------|-------|------|----------|-----------|------------|-----|\n")
+	for _, m := range mods {
+		fmt.Fprintf(&b, "| %s | %d | %d | %d | %d | %d | %d |\n", m.Name, m.Files, m.Code, m.Comments, m.Functions, m.Complexity, m.MaxComplexity)
+	}
+
+	fmt.Fprintf(&b, "\n## Top %d Most Complex Files\n\n", top)
+	b.WriteString("| File | Code | Functions | Complexity | Max |\n")
+	b.WriteString("|------|------|-----------|------------|-----|\n")
+	for _, f := range TopFiles(r.Files, top) {
+		fmt.Fprintf(&b, "| %s | %d | %d | %d | %d |\n", f.Path, f.Code, len(f.Functions), f.Complexity, f.MaxComplexity)
+	}
+
+	fmt.Fprintf(&b, "\n## Top %d Most Complex Functions\n\n", top)
+	b.WriteString("| Function | Location | Complexity |\n")
+	b.WriteString("|----------|----------|------------|\n")
+	for _, fn := range TopFunctions(r.Files, top) {
+		fmt.Fprintf(&b, "| `%s` | %s:%d | %d |\n", fn.Name, fn.File, fn.Line, fn.Complexity)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
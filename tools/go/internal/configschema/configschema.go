@@ -0,0 +1,228 @@
+// Package configschema publishes a JSON Schema for each kind of config
+// file the tools read, and checks configs against them as they are
+// loaded. yaml.v3 ignores keys it does not know, so a misspelt key
+// silently keeps its default and a tool runs half configured; checked
+// against its schema, the config fails before the run with the line,
+// column and path of the mistake.
+package configschema
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+//go:embed schemas/*.schema.json
+var schemas embed.FS
+
+// Kinds of config file.
+const (
+	KindUmbratool         = "umbratool"
+	KindRulePack          = "rule-pack"
+	KindRefactoringPlan   = "refactoring-plan"
+	KindOwners            = "owners"
+	KindEntitlements      = "entitlements-policy"
+	KindRestic            = "restic-policy"
+	KindProtocolAnalyzer  = "protocol-analyzer"
+	KindRewriteMapping    = "rewrite-mapping"
+	KindChangelogFragment = "changelog-fragment"
+	KindSpelling          = "spelling"
+	KindTagPolicy         = "tag-policy"
+)
+
+// Kind describes one kind of config file.
+type Kind struct {
+	Name string
+	// Files are walker.Match globs of where files of the kind are kept,
+	// relative to the project root; empty when they have no fixed place.
+	Files []string
+	// Key is a top-level key that only files of the kind have, which
+	// identifies one kept elsewhere.
+	Key string
+}
+
+// SchemaFile is the file name of the kind's schema.
+func (k Kind) SchemaFile() string {
+	return k.Name + ".schema.json"
+}
+
+// Kinds lists every kind of config file.
+var Kinds = []Kind{
+	{Name: KindUmbratool, Files: []string{"umbratool.yaml"}},
+	{Name: KindRulePack, Files: []string{"rules/*.yaml", "rules/*.yml"}},
+	{Name: KindRefactoringPlan, Files: []string{"refactoring_plan.yaml"}},
+	{Name: KindOwners, Files: []string{"owners.yaml"}},
+	{Name: KindEntitlements, Files: []string{"entitlements_policy.yaml"}},
+	{Name: KindRestic, Files: []string{"restic_policy.yaml"}},
+	{Name: KindProtocolAnalyzer, Files: []string{"protocolanalyzer.yaml"}},
+	{Name: KindRewriteMapping, Key: "rewrites"},
+	{Name: KindChangelogFragment, Files: []string{"changelog.d/*.yaml", "changelog.d/*.yml"}},
+	{Name: KindSpelling, Key: "words"},
+	{Name: KindTagPolicy, Files: []string{"tag_policy.yaml"}},
+}
+
+// Lookup returns the kind called name.
+func Lookup(name string) (Kind, bool) {
+	for _, k := range Kinds {
+		if k.Name == name {
+			return k, true
+		}
+	}
+	return Kind{}, false
+}
+
+// Detect works out the kind of the config at rel, relative to the project
+// root: from where it is kept, or failing that from its top-level keys.
+func Detect(rel string, data []byte) (Kind, bool) {
+	rel = path.Clean(rel)
+	for _, k := range Kinds {
+		for _, pattern := range k.Files {
+			if walker.Match(pattern, rel) {
+				return k, true
+			}
+		}
+	}
+	var top map[string]yaml.Node
+	if yaml.Unmarshal(data, &top) != nil {
+		return Kind{}, false
+	}
+	for _, k := range Kinds {
+		if _, ok := top[k.Key]; k.Key != "" && ok {
+			return k, true
+		}
+	}
+	return Kind{}, false
+}
+
+// Schema returns the JSON Schema of kind, as published.
+func Schema(kind string) ([]byte, error) {
+	k, ok := Lookup(kind)
+	if !ok {
+		return nil, fmt.Errorf("unknown config kind %q", kind)
+	}
+	return schemas.ReadFile("schemas/" + k.SchemaFile())
+}
+
+// Problem is one place a config departs from its schema.
+type Problem struct {
+	Line int `json:"line"`
+	// Column is 0 for a syntax error, which yaml.v3 only places on a line.
+	Column int `json:"column,omitempty"`
+	// Path locates the value, as in "budgets.overrides[0].fileLines";
+	// empty for the document itself.
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// String renders p as "line:column: path: message", leaving out what is
+// not known.
+func (p Problem) String() string {
+	at := fmt.Sprint(p.Line)
+	if p.Column > 0 {
+		at += fmt.Sprintf(":%d", p.Column)
+	}
+	if p.Path == "" {
+		return at + ": " + p.Message
+	}
+	return at + ": " + p.Path + ": " + p.Message
+}
+
+// Error lists the problems of one config file.
+type Error struct {
+	File     string
+	Problems []Problem
+}
+
+func (e *Error) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = e.File + ":" + p.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+var (
+	compileOnce sync.Once
+	compiled    map[string]*schema
+	compileErr  error
+)
+
+// Check returns the problems of data, a config of kind, in file order.
+func Check(kind string, data []byte) ([]Problem, error) {
+	compileOnce.Do(func() {
+		compiled = make(map[string]*schema)
+		for _, k := range Kinds {
+			data, err := Schema(k.Name)
+			if err != nil {
+				compileErr = err
+				return
+			}
+			s, err := parseSchema(data)
+			if err != nil {
+				compileErr = fmt.Errorf("%s: %w", k.SchemaFile(), err)
+				return
+			}
+			compiled[k.Name] = s
+		}
+	})
+	if compileErr != nil {
+		return nil, compileErr
+	}
+	s, ok := compiled[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown config kind %q", kind)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return []Problem{syntaxProblem(err)}, nil
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	c := &checker{root: s}
+	c.check(s, doc.Content[0], "")
+	sort.SliceStable(c.problems, func(i, j int) bool {
+		a, b := c.problems[i], c.problems[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return c.problems, nil
+}
+
+// Validate checks data, the config of kind read from file, returning an
+// *Error listing its problems when it has any.
+func Validate(kind, file string, data []byte) error {
+	problems, err := Check(kind, data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	if len(problems) > 0 {
+		return &Error{File: file, Problems: problems}
+	}
+	return nil
+}
+
+// syntaxProblem turns a yaml.v3 parse error, "yaml: line 3: ...", into a
+// problem on that line.
+func syntaxProblem(err error) Problem {
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	p := Problem{Line: 1, Message: msg}
+	if rest, ok := strings.CutPrefix(msg, "line "); ok {
+		var line int
+		if n, _ := fmt.Sscanf(rest, "%d:", &line); n == 1 {
+			_, p.Message, _ = strings.Cut(rest, ": ")
+			p.Line = line
+		}
+	}
+	return p
+}
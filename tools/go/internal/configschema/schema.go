@@ -0,0 +1,316 @@
+package configschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schema is the subset of JSON Schema the published schemas use: types,
+// enums, patterns, object properties, list items and local references.
+// Annotations such as description and format are not checked.
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Defs                 map[string]*schema `json:"$defs"`
+	Type                 types              `json:"type"`
+	Enum                 []string           `json:"enum"`
+	Pattern              string             `json:"pattern"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties *additional        `json:"additionalProperties"`
+	PropertyNames        *schema            `json:"propertyNames"`
+	Required             []string           `json:"required"`
+	Items                *schema            `json:"items"`
+	MinItems             int                `json:"minItems"`
+
+	re *regexp.Regexp
+}
+
+// types is a schema's type, one name or a list of them.
+type types []string
+
+func (t *types) UnmarshalJSON(data []byte) error {
+	var one string
+	if json.Unmarshal(data, &one) == nil {
+		*t = types{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// additional is additionalProperties: false closes an object, and a
+// schema applies to every key Properties does not list.
+type additional struct {
+	closed bool
+	schema *schema
+}
+
+func (a *additional) UnmarshalJSON(data []byte) error {
+	var allowed bool
+	if json.Unmarshal(data, &allowed) == nil {
+		a.closed = !allowed
+		return nil
+	}
+	return json.Unmarshal(data, &a.schema)
+}
+
+// parseSchema decodes a schema and compiles its patterns.
+func parseSchema(data []byte) (*schema, error) {
+	var s schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, s.compile()
+}
+
+func (s *schema) compile() error {
+	if s == nil {
+		return nil
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return err
+		}
+		s.re = re
+	}
+	subs := []*schema{s.PropertyNames, s.Items}
+	if s.AdditionalProperties != nil {
+		subs = append(subs, s.AdditionalProperties.schema)
+	}
+	for _, m := range []map[string]*schema{s.Defs, s.Properties} {
+		for _, sub := range m {
+			subs = append(subs, sub)
+		}
+	}
+	for _, sub := range subs {
+		if err := sub.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checker walks a document against a schema, collecting problems.
+type checker struct {
+	root     *schema
+	problems []Problem
+}
+
+func (c *checker) report(n *yaml.Node, at, format string, args ...any) {
+	c.problems = append(c.problems, Problem{Line: n.Line, Column: n.Column, Path: at, Message: fmt.Sprintf(format, args...)})
+}
+
+// check checks node n, found at path at, against s. A null value is
+// always accepted, since it leaves the setting at its default.
+func (c *checker) check(s *schema, n *yaml.Node, at string) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if ref := s.Ref; ref != "" {
+		name, ok := strings.CutPrefix(ref, "#/$defs/")
+		def := c.root.Defs[name]
+		if !ok || def == nil {
+			c.report(n, at, "schema reference %s cannot be resolved", ref)
+			return
+		}
+		c.check(def, n, at)
+	}
+	got := typeOf(n)
+	if got == "null" {
+		return
+	}
+	if len(s.Type) > 0 && !s.Type.accept(got) {
+		c.report(n, at, "want %s, got %s", s.Type.describe(), article(got))
+		return
+	}
+	if len(s.Enum) > 0 {
+		if n.Kind != yaml.ScalarNode || !slices.Contains(s.Enum, n.Value) {
+			what := strconv.Quote(n.Value)
+			if n.Kind != yaml.ScalarNode {
+				what = article(got)
+			}
+			c.report(n, at, "%s is not one of %s", what, list(s.Enum))
+			return
+		}
+	}
+	if s.re != nil && n.Kind == yaml.ScalarNode && !s.re.MatchString(n.Value) {
+		c.report(n, at, "%q does not match %s", n.Value, s.Pattern)
+	}
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		c.checkMapping(s, n, at)
+	case yaml.SequenceNode:
+		if len(n.Content) < s.MinItems {
+			c.report(n, at, "want at least %d entries, got %d", s.MinItems, len(n.Content))
+		}
+		if s.Items != nil {
+			for i, item := range n.Content {
+				c.check(s.Items, item, at+"["+strconv.Itoa(i)+"]")
+			}
+		}
+	}
+}
+
+func (c *checker) checkMapping(s *schema, n *yaml.Node, at string) {
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if key.Value == "<<" {
+			// Merge keys are the document's business, not the schema's.
+			continue
+		}
+		keyAt := join(at, key.Value)
+		if seen[key.Value] {
+			c.report(key, keyAt, "duplicate key")
+			continue
+		}
+		seen[key.Value] = true
+		if s.PropertyNames != nil {
+			c.check(s.PropertyNames, key, keyAt)
+		}
+		if sub, ok := s.Properties[key.Value]; ok {
+			c.check(sub, value, keyAt)
+			continue
+		}
+		switch a := s.AdditionalProperties; {
+		case a == nil:
+		case a.closed:
+			msg := "unknown key"
+			if s := suggest(key.Value, s.Properties); s != "" {
+				msg += " (did you mean " + s + "?)"
+			}
+			c.report(key, keyAt, "%s", msg)
+		case a.schema != nil:
+			c.check(a.schema, value, keyAt)
+		}
+	}
+	for _, name := range s.Required {
+		if !seen[name] {
+			c.report(n, at, "missing required key %s", name)
+		}
+	}
+}
+
+// typeOf names the JSON type of n.
+func typeOf(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch n.ShortTag() {
+	case "!!null":
+		return "null"
+	case "!!bool":
+		return "boolean"
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	}
+	return "string"
+}
+
+// accept reports whether a value of type got satisfies t. A number is
+// accepted as a string, since yaml.v3 decodes it as one and a version
+// such as 1.10 is a string however it is written.
+func (t types) accept(got string) bool {
+	for _, want := range t {
+		switch {
+		case want == got,
+			want == "number" && got == "integer",
+			want == "string" && (got == "integer" || got == "number"):
+			return true
+		}
+	}
+	return false
+}
+
+func (t types) describe() string {
+	names := make([]string, len(t))
+	for i, name := range t {
+		names[i] = article(name)
+	}
+	return strings.Join(names, " or ")
+}
+
+// article names a JSON type as a YAML reader knows it.
+func article(name string) string {
+	switch name {
+	case "object":
+		return "a mapping"
+	case "array":
+		return "a list"
+	case "integer":
+		return "an integer"
+	}
+	return "a " + name
+}
+
+// join appends key to path at, in brackets unless it is a plain name.
+func join(at, key string) string {
+	if !plainKey.MatchString(key) {
+		return at + "[" + strconv.Quote(key) + "]"
+	}
+	if at == "" {
+		return key
+	}
+	return at + "." + key
+}
+
+var plainKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// suggest returns the property closest to key, when one is close enough
+// to be a likely misspelling.
+func suggest(key string, properties map[string]*schema) string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// Allow one edit for every three characters, and at least one.
+	best, bestDistance := "", len(key)/3+2
+	for _, name := range names {
+		if d := distance(strings.ToLower(key), strings.ToLower(name)); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// distance is the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// list joins values as "a, b or c".
+func list(values []string) string {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return strings.Join(values[:len(values)-1], ", ") + " or " + values[len(values)-1]
+}
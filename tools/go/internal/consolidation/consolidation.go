@@ -0,0 +1,364 @@
+// Package consolidation finds what stands in the way of merging modules
+// into one: Swift files that would share a name in the merged module, and
+// types more than one of the modules declare. For each conflict it sets
+// the copies side by side, compares their members and suggests how to
+// resolve it.
+package consolidation
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/protocols"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textdiff"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Conflict kinds, which are also the kinds of the issues recorded.
+const (
+	// ConflictFile is two files with the same name, which Swift does not
+	// allow in one module.
+	ConflictFile = "duplicate_file"
+	// ConflictType is a top-level type declared in more than one module.
+	ConflictType = "duplicate_type"
+)
+
+// Suggested resolutions.
+const (
+	// ResolveDropDuplicate deletes a copy identical to the kept one.
+	ResolveDropDuplicate = "drop-duplicate"
+	// ResolveRename renames the source module's copy: the file, or a type
+	// that is a different kind of declaration from the kept one.
+	ResolveRename = "rename"
+	// ResolveKeepSuperset keeps the copy with every member of the other.
+	ResolveKeepSuperset = "keep-superset"
+	// ResolveMerge merges copies whose members do not overlap.
+	ResolveMerge = "merge"
+	// ResolveReconcile is for copies declaring the same member
+	// differently, which only a person can settle.
+	ResolveReconcile = "reconcile"
+)
+
+// Copy is one module's declaration of a conflicting file or type.
+type Copy struct {
+	Module string `json:"module"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	// Kind is the declaration's kind, e.g. "struct"; empty for files.
+	Kind string `json:"kind,omitempty"`
+	text string
+	decl *protocols.Decl
+}
+
+// Change is a member both copies declare, differently.
+type Change struct {
+	Member string `json:"member"`
+	Left   string `json:"left"`
+	Right  string `json:"right"`
+}
+
+// Members compares the members and conformances of two copies of a type.
+// Members are matched by kind and signature.
+type Members struct {
+	Common    []string `json:"common"`
+	Changed   []Change `json:"changed"`
+	OnlyLeft  []string `json:"onlyLeft"`
+	OnlyRight []string `json:"onlyRight"`
+}
+
+// Pair compares a further copy with the one a conflict keeps.
+type Pair struct {
+	Right Copy           `json:"right"`
+	Rows  []textdiff.Row `json:"rows"`
+	// Omitted counts the rows past Options.MaxLines.
+	Omitted int `json:"omitted,omitempty"`
+	// Members is nil for file conflicts.
+	Members     *Members `json:"members,omitempty"`
+	Resolution  string   `json:"resolution"`
+	Reason      string   `json:"reason"`
+	Fingerprint string   `json:"fingerprint,omitempty"`
+}
+
+// Conflict is a file name or type found in more than one module. Left is
+// the copy to keep: the target module's, or else the first source's.
+type Conflict struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Left  Copy   `json:"left"`
+	Pairs []Pair `json:"pairs"`
+}
+
+// Options names the modules being merged.
+type Options struct {
+	// Sources are the modules merged into Target, in order of preference.
+	Sources []string
+	Target  string
+	// MaxLines caps the rows of each side-by-side comparison; 0 shows
+	// them all.
+	MaxLines int
+}
+
+// privatePattern matches a declaration only its own file sees, which
+// cannot clash with another file's.
+var privatePattern = regexp.MustCompile(`^\s*(?:@[A-Za-z_]\w*(?:\([^)]*\))?\s+)*(?:private|fileprivate)\s`)
+
+// Find returns the conflicts between the modules, files first, each sorted
+// by name. paths are the Swift files to consider, relative to root; the
+// rules place them in modules.
+func Find(root string, rules []modulenames.Rule, paths []string, opts Options) ([]Conflict, error) {
+	rank := map[string]int{opts.Target: 0}
+	for i, m := range opts.Sources {
+		if _, ok := rank[m]; !ok {
+			rank[m] = i + 1
+		}
+	}
+	index := &moduleindex.Index{Modules: rules}
+	moduleOf := make(map[string]string)
+	var files []string
+	for _, rel := range paths {
+		module := workspace.ModuleForPath(rel)
+		if r, ok := index.ForPath(rel); ok {
+			module = r.ModuleName
+		}
+		if _, ok := rank[module]; ok {
+			moduleOf[rel] = module
+			files = append(files, rel)
+		}
+	}
+	// Copies sort by module preference, then path, so the first is kept.
+	byRank := func(copies []Copy) {
+		sort.SliceStable(copies, func(i, j int) bool {
+			if rank[copies[i].Module] != rank[copies[j].Module] {
+				return rank[copies[i].Module] < rank[copies[j].Module]
+			}
+			return copies[i].File < copies[j].File
+		})
+	}
+	texts := make(map[string][]string)
+	read := func(rel string) ([]string, error) {
+		if lines, ok := texts[rel]; ok {
+			return lines, nil
+		}
+		data, err := os.ReadFile(filepath.Join(root, rel))
+		if err != nil {
+			return nil, err
+		}
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		texts[rel] = lines
+		return lines, nil
+	}
+
+	var conflicts []Conflict
+	byName := make(map[string][]Copy)
+	for _, rel := range files {
+		byName[path.Base(rel)] = append(byName[path.Base(rel)], Copy{Module: moduleOf[rel], File: rel, Line: 1})
+	}
+	for _, name := range sortedKeys(byName) {
+		copies := byName[name]
+		modules := make(map[string]bool)
+		for _, c := range copies {
+			modules[c.Module] = true
+		}
+		// Two copies in one module already clash, merged or not.
+		if len(modules) < 2 {
+			continue
+		}
+		byRank(copies)
+		for i := range copies {
+			lines, err := read(copies[i].File)
+			if err != nil {
+				return nil, err
+			}
+			copies[i].text = strings.Join(lines, "\n")
+		}
+		conflicts = append(conflicts, conflict(ConflictFile, name, copies, opts))
+	}
+
+	ix, err := protocols.BuildFiles(root, files)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string][]Copy)
+	for _, d := range ix.Decls {
+		if d.Kind == protocols.KindExtension || strings.Contains(d.Name, ".") {
+			continue
+		}
+		lines, err := read(d.File)
+		if err != nil {
+			return nil, err
+		}
+		if d.Line > len(lines) || privatePattern.MatchString(lines[d.Line-1]) {
+			continue
+		}
+		types[d.Name] = append(types[d.Name], Copy{Module: moduleOf[d.File], File: d.File, Line: d.Line, Kind: d.Kind, text: extent(lines, d.Line), decl: d})
+	}
+	for _, name := range sortedKeys(types) {
+		copies := types[name]
+		byRank(copies)
+		// A module declaring a type twice does so under different
+		// platform guards; its first copy stands for it.
+		seen := make(map[string]bool)
+		var kept []Copy
+		for _, c := range copies {
+			if !seen[c.Module] {
+				seen[c.Module] = true
+				kept = append(kept, c)
+			}
+		}
+		if len(kept) > 1 {
+			conflicts = append(conflicts, conflict(ConflictType, name, kept, opts))
+		}
+	}
+	return conflicts, nil
+}
+
+// conflict compares every further copy with the first.
+func conflict(kind, name string, copies []Copy, opts Options) Conflict {
+	c := Conflict{Kind: kind, Name: name, Left: copies[0]}
+	for _, right := range copies[1:] {
+		p := Pair{Right: right, Rows: textdiff.SideBySide(c.Left.text, right.text)}
+		// Number the rows as the files do, not from the declaration.
+		for i := range p.Rows {
+			if p.Rows[i].LeftLine > 0 {
+				p.Rows[i].LeftLine += c.Left.Line - 1
+			}
+			if p.Rows[i].RightLine > 0 {
+				p.Rows[i].RightLine += right.Line - 1
+			}
+		}
+		if opts.MaxLines > 0 && len(p.Rows) > opts.MaxLines {
+			p.Omitted = len(p.Rows) - opts.MaxLines
+			p.Rows = p.Rows[:opts.MaxLines]
+		}
+		if kind == ConflictType {
+			p.Members = compare(c.Left.decl, right.decl)
+		}
+		p.Resolution, p.Reason = resolve(kind, c.Left, right, p.Members)
+		c.Pairs = append(c.Pairs, p)
+	}
+	return c
+}
+
+// resolve suggests how to settle a pair, and why.
+func resolve(kind string, left, right Copy, m *Members) (string, string) {
+	if normalise(left.text) == normalise(right.text) {
+		return ResolveDropDuplicate, "the copies are identical but for whitespace; delete the " + right.Module + " copy"
+	}
+	if kind == ConflictFile {
+		base := strings.TrimSuffix(path.Base(right.File), ".swift")
+		return ResolveRename, "the files differ; rename the " + right.Module + " copy to " + base + "+" + right.Module + ".swift"
+	}
+	switch {
+	case left.Kind != right.Kind:
+		return ResolveRename, "one is a " + left.Kind + " and the other a " + right.Kind + ", so they are likely different things; rename the " + right.Module + " copy"
+	case len(m.Changed) > 0:
+		return ResolveReconcile, "the copies declare " + plural(len(m.Changed), "member") + " differently; settle each before merging"
+	case len(m.OnlyRight) == 0:
+		return ResolveKeepSuperset, "the " + left.Module + " copy has every member of the " + right.Module + " copy; delete the " + right.Module + " copy"
+	case len(m.OnlyLeft) == 0:
+		return ResolveKeepSuperset, "the " + right.Module + " copy has every member of the " + left.Module + " copy; keep it in place of the " + left.Module + " copy"
+	}
+	return ResolveMerge, "each copy declares members the other lacks; merge them into the " + left.Module + " copy"
+}
+
+// compare diffs the conformances and members of two declarations.
+func compare(left, right *protocols.Decl) *Members {
+	l, r := memberMap(left), memberMap(right)
+	m := &Members{}
+	for _, key := range sortedKeys(l) {
+		other, ok := r[key]
+		switch {
+		case !ok:
+			m.OnlyLeft = append(m.OnlyLeft, key)
+		case normalise(l[key]) == normalise(other):
+			m.Common = append(m.Common, key)
+		default:
+			m.Changed = append(m.Changed, Change{Member: key, Left: strings.TrimSpace(l[key]), Right: strings.TrimSpace(other)})
+		}
+	}
+	for _, key := range sortedKeys(r) {
+		if _, ok := l[key]; !ok {
+			m.OnlyRight = append(m.OnlyRight, key)
+		}
+	}
+	return m
+}
+
+// memberMap returns a declaration's conformances and members, keyed by
+// how the report names them, with their text.
+func memberMap(d *protocols.Decl) map[string]string {
+	out := make(map[string]string, len(d.Inherits)+len(d.Members))
+	for _, name := range d.Inherits {
+		out["conforms to "+name] = name
+	}
+	for _, mem := range d.Members {
+		key := mem.Kind + " " + mem.Signature
+		if mem.Static {
+			key = "static " + key
+		}
+		if _, dup := out[key]; !dup {
+			out[key] = mem.Text
+		}
+	}
+	return out
+}
+
+// extent returns the declaration starting on line start, counting from 1,
+// through the brace closing its body.
+func extent(lines []string, start int) string {
+	inComment := false
+	for _, line := range lines[:start-1] {
+		_, inComment = swiftsrc.StripComments(line, inComment)
+	}
+	depth := 0
+	opened := false
+	end := start
+	for i := start - 1; i < len(lines); i++ {
+		var code string
+		code, inComment = swiftsrc.StripComments(lines[i], inComment)
+		for _, r := range code {
+			switch r {
+			case '{':
+				depth++
+				opened = true
+			case '}':
+				depth--
+			}
+		}
+		end = i + 1
+		if opened && depth <= 0 {
+			break
+		}
+	}
+	return strings.Join(lines[start-1:end], "\n")
+}
+
+// normalise collapses runs of whitespace, so that copies differing only in
+// indentation compare equal.
+func normalise(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
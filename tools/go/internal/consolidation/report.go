@@ -0,0 +1,224 @@
+package consolidation
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textdiff"
+)
+
+// marks label row kinds in the Markdown report's side-by-side tables.
+var marks = map[string]string{
+	textdiff.RowSame:    "",
+	textdiff.RowChanged: "~",
+	textdiff.RowLeft:    "-",
+	textdiff.RowRight:   "+",
+}
+
+// WriteMarkdown writes a summary table of the conflicts and then, for each
+// pair of copies, the suggested resolution, the member comparison and the
+// copies side by side.
+func WriteMarkdown(w io.Writer, conflicts []Conflict, opts Options) error {
+	var b strings.Builder
+	b.WriteString("# Consolidation Conflicts\n\n")
+	fmt.Fprintf(&b, "Merging %s into %s.\n\n", strings.Join(opts.Sources, ", "), opts.Target)
+	files, types := count(conflicts)
+	fmt.Fprintf(&b, "**%d conflicts: %s, %s**\n", len(conflicts), plural(files, "duplicate file"), plural(types, "duplicate type"))
+	if len(conflicts) == 0 {
+		b.WriteString("\nThe modules can be merged without conflicts.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	b.WriteString("\n| Conflict | Kept | Other copies | Resolution |\n")
+	b.WriteString("|----------|------|--------------|------------|\n")
+	for _, c := range conflicts {
+		var others, resolutions []string
+		for _, p := range c.Pairs {
+			others = append(others, p.Right.Module)
+			resolutions = append(resolutions, p.Resolution)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", title(c), c.Left.Module, strings.Join(others, ", "), strings.Join(resolutions, ", "))
+	}
+
+	for _, c := range conflicts {
+		fmt.Fprintf(&b, "\n## %s\n", title(c))
+		for _, p := range c.Pairs {
+			fmt.Fprintf(&b, "\n### %s and %s\n\n", c.Left.Module, p.Right.Module)
+			fmt.Fprintf(&b, "`%s:%d` and `%s:%d`.\n\n", c.Left.File, c.Left.Line, p.Right.File, p.Right.Line)
+			fmt.Fprintf(&b, "**Resolution: %s.** %s.\n", p.Resolution, capitalise(p.Reason))
+			if m := p.Members; m != nil {
+				fmt.Fprintf(&b, "\n%s in common.\n", plural(len(m.Common), "member"))
+				if len(m.Changed) > 0 {
+					fmt.Fprintf(&b, "\n| Declared differently | %s | %s |\n", c.Left.Module, p.Right.Module)
+					b.WriteString("|----------------------|---|---|\n")
+					for _, ch := range m.Changed {
+						fmt.Fprintf(&b, "| %s | %s | %s |\n", ch.Member, code(ch.Left), code(ch.Right))
+					}
+				}
+				only(&b, c.Left.Module, m.OnlyLeft)
+				only(&b, p.Right.Module, m.OnlyRight)
+			}
+
+			fmt.Fprintf(&b, "\n| | Line | %s | Line | %s |\n", c.Left.Module, p.Right.Module)
+			b.WriteString("|-|------|---|------|---|\n")
+			for _, r := range p.Rows {
+				fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", marks[r.Kind], lineNo(r.LeftLine), code(r.Left), lineNo(r.RightLine), code(r.Right))
+			}
+			if p.Omitted > 0 {
+				fmt.Fprintf(&b, "\n%s more not shown.\n", plural(p.Omitted, "line"))
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the conflicts as indented JSON.
+func WriteJSON(w io.Writer, conflicts []Conflict) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(conflicts)
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"kind":       kind,
+	"plural":     plural,
+	"capitalise": capitalise,
+	"lineNo":     lineNo,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Consolidation Conflicts</title>
+<style>
+body { font-family: -apple-system, Helvetica, sans-serif; margin: 2em; color: #1d1d1f; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #d2d2d7; padding: 2px 8px; text-align: left; vertical-align: top; }
+table.code td { font-family: Menlo, monospace; font-size: 12px; white-space: pre; border: none; }
+table.code td.n { color: #86868b; text-align: right; }
+tr.changed td { background: #fff4ce; }
+tr.left td.l, tr.left td.ln { background: #ffe3e3; }
+tr.right td.r, tr.right td.rn { background: #e3f9e5; }
+.resolution { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Consolidation Conflicts</h1>
+<p>Merging {{.Sources}} into {{.Target}}.</p>
+<p><strong>{{len .Conflicts}} conflicts: {{plural .Files "duplicate file"}}, {{plural .Types "duplicate type"}}</strong></p>
+{{- if not .Conflicts}}
+<p>The modules can be merged without conflicts.</p>
+{{- end}}
+{{- range .Conflicts}}{{$c := .}}
+<h2>{{kind .}} <code>{{.Name}}</code></h2>
+{{- range .Pairs}}{{$p := .}}
+<h3>{{$c.Left.Module}} and {{.Right.Module}}</h3>
+<p><code>{{$c.Left.File}}:{{$c.Left.Line}}</code> and <code>{{.Right.File}}:{{.Right.Line}}</code>.</p>
+<p><span class="resolution">Resolution: {{.Resolution}}.</span> {{capitalise .Reason}}.</p>
+{{- with .Members}}
+<p>{{plural (len .Common) "member"}} in common.</p>
+{{- if .Changed}}
+<table>
+<tr><th>Declared differently</th><th>{{$c.Left.Module}}</th><th>{{$p.Right.Module}}</th></tr>
+{{- range .Changed}}
+<tr><td>{{.Member}}</td><td><code>{{.Left}}</code></td><td><code>{{.Right}}</code></td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .OnlyLeft}}
+<p>Only in {{$c.Left.Module}}:</p>
+<ul>{{range .OnlyLeft}}<li><code>{{.}}</code></li>{{end}}</ul>
+{{- end}}
+{{- if .OnlyRight}}
+<p>Only in {{$p.Right.Module}}:</p>
+<ul>{{range .OnlyRight}}<li><code>{{.}}</code></li>{{end}}</ul>
+{{- end}}
+{{- end}}
+<table class="code">
+<tr><th></th><th>{{$c.Left.Module}}</th><th></th><th>{{.Right.Module}}</th></tr>
+{{- range .Rows}}
+<tr class="{{.Kind}}"><td class="n ln">{{lineNo .LeftLine}}</td><td class="l">{{.Left}}</td><td class="n rn">{{lineNo .RightLine}}</td><td class="r">{{.Right}}</td></tr>
+{{- end}}
+</table>
+{{- if .Omitted}}
+<p>{{plural .Omitted "line"}} more not shown.</p>
+{{- end}}
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes the Markdown report's content as a standalone page,
+// with the side-by-side rows coloured by kind.
+func WriteHTML(w io.Writer, conflicts []Conflict, opts Options) error {
+	files, types := count(conflicts)
+	return htmlReport.Execute(w, struct {
+		Sources, Target string
+		Files, Types    int
+		Conflicts       []Conflict
+	}{strings.Join(opts.Sources, ", "), opts.Target, files, types, conflicts})
+}
+
+func count(conflicts []Conflict) (files, types int) {
+	for _, c := range conflicts {
+		if c.Kind == ConflictFile {
+			files++
+		} else {
+			types++
+		}
+	}
+	return files, types
+}
+
+func title(c Conflict) string {
+	return kind(c) + " `" + c.Name + "`"
+}
+
+// kind words what a conflict is over, e.g. "Struct".
+func kind(c Conflict) string {
+	if c.Kind == ConflictFile {
+		return "File"
+	}
+	return capitalise(c.Left.Kind)
+}
+
+func only(b *strings.Builder, module string, members []string) {
+	if len(members) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\nOnly in %s:\n\n", module)
+	for _, m := range members {
+		fmt.Fprintf(b, "- `%s`\n", m)
+	}
+}
+
+// code renders a line of Swift as a table cell.
+func code(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return ""
+	}
+	s = strings.ReplaceAll(s, "|", `\|`)
+	if strings.Contains(s, "`") {
+		return "`` " + s + " ``"
+	}
+	return "`" + s + "`"
+}
+
+func lineNo(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprint(n)
+}
+
+func capitalise(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
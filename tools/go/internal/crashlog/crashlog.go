@@ -0,0 +1,454 @@
+// Package crashlog reads the crash reports of the XPC services, places
+// the frames of each crashed thread in the tree's modules and files, and
+// summarises the crashes per module and per error domain. A domain is the
+// catalogued error type the crash reason names, as in the fatal error of
+// a failed try!, or else the NSError domain it names, so the error
+// consolidation can weigh the types that actually fail in the field.
+package crashlog
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errorreport"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// Extensions are the crash report files Load reads.
+var Extensions = []string{".ips", ".crash"}
+
+// Options configures Load.
+type Options struct {
+	// Paths are crash reports and directories holding them.
+	Paths []string
+	// Processes, when set, keep only the crashes of these processes.
+	Processes []string
+	// DSYMs is a directory of Name.dSYM bundles that unsymbolicated
+	// frames are looked up in with atos; empty skips symbolication.
+	DSYMs string
+}
+
+// Load parses the crash reports at or below opts.Paths, oldest first, and
+// symbolicates their frames when opts.DSYMs is set. Files that are not
+// crash reports, such as other .ips diagnostics, are skipped.
+func Load(opts Options) ([]*Crash, []string, error) {
+	var files []string
+	for _, p := range opts.Paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		err = walker.Walk(p, walker.Options{Extensions: Extensions}, func(rel string) error {
+			files = append(files, filepath.Join(p, rel))
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	wanted := make(map[string]bool, len(opts.Processes))
+	for _, p := range opts.Processes {
+		wanted[p] = true
+	}
+	var crashes []*Crash
+	var skipped []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		c, err := Parse(filepath.ToSlash(file), data)
+		if err != nil {
+			skipped = append(skipped, err.Error())
+			continue
+		}
+		if len(wanted) > 0 && !wanted[c.Process] {
+			continue
+		}
+		if opts.DSYMs != "" {
+			if err := symbolicate(c, opts.DSYMs); err != nil {
+				return nil, nil, err
+			}
+		}
+		crashes = append(crashes, c)
+	}
+	sort.SliceStable(crashes, func(i, j int) bool { return crashes[i].Time.Before(crashes[j].Time) })
+	return crashes, skipped, nil
+}
+
+// atosLine reads atos output, "Foo.bar() (in UmbraCryptoService) (Foo.swift:42)".
+var atosLine = regexp.MustCompile(`^(.*?) \(in [^)]+\)(?: \(([^()]+):(\d+)\))?`)
+
+// symbolicate fills in the symbols of c's unsymbolicated frames whose
+// image has a dSYM in dir, running atos once per image.
+func symbolicate(c *Crash, dir string) error {
+	byImage := make(map[string][]int)
+	var images []string
+	for i, f := range c.Frames {
+		if f.Symbol != "" || f.Image == "" || f.ImageBase == 0 {
+			continue
+		}
+		if byImage[f.Image] == nil {
+			images = append(images, f.Image)
+		}
+		byImage[f.Image] = append(byImage[f.Image], i)
+	}
+	for _, image := range images {
+		dwarf := filepath.Join(dir, image+".dSYM", "Contents", "Resources", "DWARF", image)
+		if _, err := os.Stat(dwarf); err != nil {
+			continue
+		}
+		frames := byImage[image]
+		args := []string{"-o", dwarf, "-l", fmt.Sprintf("0x%x", c.Frames[frames[0]].ImageBase)}
+		for _, i := range frames {
+			args = append(args, fmt.Sprintf("0x%x", c.Frames[i].Address))
+		}
+		out, err := exec.Command("atos", args...).Output()
+		if err != nil {
+			return fmt.Errorf("atos %s: %w", image, err)
+		}
+		for n, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			m := atosLine.FindStringSubmatch(line)
+			if n >= len(frames) || m == nil {
+				continue
+			}
+			f := &c.Frames[frames[n]]
+			f.Symbol = m[1]
+			if m[2] != "" {
+				f.SourceFile = path.Base(m[2])
+				f.SourceLine, _ = strconv.Atoi(m[3])
+			}
+		}
+	}
+	return nil
+}
+
+// Attributor places frames in the tree.
+type Attributor struct {
+	ix      *moduleindex.Index
+	modules map[string]bool
+	// files maps Swift file base names to their paths, or to "" when more
+	// than one file has the name.
+	files map[string]string
+}
+
+// NewAttributor indexes the Swift files below dirs of root.
+func NewAttributor(root string, dirs []string, rules []modulenames.Rule) (*Attributor, error) {
+	a := &Attributor{ix: &moduleindex.Index{Modules: rules}, modules: make(map[string]bool), files: make(map[string]string)}
+	for _, r := range rules {
+		a.modules[r.ModuleName] = true
+	}
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			rel = path.Clean(filepath.ToSlash(filepath.Join(dir, rel)))
+			name := path.Base(rel)
+			if _, seen := a.files[name]; seen {
+				a.files[name] = ""
+			} else {
+				a.files[name] = rel
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// Attribute fills in the module, and where it can the file, of every
+// frame of c. It tries in turn the module a Swift mangled symbol names,
+// the frame's source file when only one file in the tree has its name,
+// the module a demangled symbol is qualified by, and the image when it is
+// named after a module. Frames in system images stay unattributed.
+func (a *Attributor) Attribute(c *Crash) {
+	for i := range c.Frames {
+		f := &c.Frames[i]
+		if m := mangledModule(f.Symbol); a.modules[m] {
+			f.Module = m
+		}
+		if rel := a.files[f.SourceFile]; rel != "" {
+			if r, ok := a.ix.ForPath(rel); ok {
+				f.File = rel
+				if f.Module == "" {
+					f.Module = r.ModuleName
+				}
+			}
+		}
+		if f.Module == "" {
+			if m := qualifier(f.Symbol); a.modules[m] {
+				f.Module = m
+			}
+		}
+		if f.Module == "" && a.modules[f.Image] {
+			f.Module = f.Image
+		}
+	}
+}
+
+// Blamed returns the first frame of c placed in a module: the tree's code
+// closest to the crash.
+func (c *Crash) Blamed() (Frame, bool) {
+	for _, f := range c.Frames {
+		if f.Module != "" {
+			return f, true
+		}
+	}
+	return Frame{}, false
+}
+
+// mangledModule returns the module a Swift mangled symbol names, as
+// CoreDTOs in $s8CoreDTOs..., or "".
+func mangledModule(symbol string) string {
+	rest, ok := strings.CutPrefix(strings.TrimPrefix(symbol, "_"), "$s")
+	if !ok {
+		return ""
+	}
+	n := 0
+	for n < len(rest) && rest[n] >= '0' && rest[n] <= '9' {
+		n++
+	}
+	length, err := strconv.Atoi(rest[:n])
+	if err != nil || n+length > len(rest) {
+		return ""
+	}
+	return rest[n : n+length]
+}
+
+var qualified = regexp.MustCompile(`(?:^|in |static |\()([A-Za-z_]\w*)\.[A-Za-z_]`)
+
+// qualifier returns the first name qualifying a demangled symbol, the
+// module when the demangler kept it, as in "closure #1 in
+// CryptoService.encrypt(_:)".
+func qualifier(symbol string) string {
+	if m := qualified.FindStringSubmatch(symbol); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// Domain is the error domain of a crash: a catalogued error type, an
+// NSError domain, or neither.
+type Domain struct {
+	Name string `json:"name"`
+	// Definition is the catalogued type, when the domain is one.
+	Definition *errorreport.Definition `json:"definition,omitempty"`
+}
+
+var nsErrorDomain = regexp.MustCompile(`Error Domain=([^\s,]+)`)
+
+// Classifier finds crashes' error domains in an error catalogue.
+type Classifier struct {
+	defs    []errorreport.Definition
+	pattern *regexp.Regexp
+}
+
+// NewClassifier matches the error types of catalogue; a nil catalogue
+// leaves only NSError domains.
+func NewClassifier(catalogue *errorreport.Report) *Classifier {
+	c := &Classifier{}
+	if catalogue == nil || len(catalogue.Definitions) == 0 {
+		return c
+	}
+	c.defs = catalogue.Definitions
+	seen := make(map[string]bool)
+	var names []string
+	for _, d := range c.defs {
+		if !seen[d.Name] {
+			seen[d.Name] = true
+			names = append(names, regexp.QuoteMeta(d.Name))
+		}
+	}
+	// Longer names first, so SecurityErrorCode is not read as SecurityError.
+	// A match must be followed by a case or an initialiser, as Swift prints
+	// errors, so that a nested type named XPC does not match every "XPC"
+	// in a reason.
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	c.pattern = regexp.MustCompile(`(?:([A-Za-z_]\w*)\.)?\b(` + strings.Join(names, "|") + `)(?:\.[A-Za-z_]|\()`)
+	return c
+}
+
+// Classify returns the domain of crash, whose frames are attributed. A
+// type defined in several modules is resolved by the module qualifying
+// it in the reason, then by the blamed frame's module.
+func (c *Classifier) Classify(crash *Crash) Domain {
+	if c.pattern != nil {
+		if m := c.pattern.FindStringSubmatch(crash.Reason); m != nil {
+			blamed, _ := crash.Blamed()
+			var match *errorreport.Definition
+			for i := range c.defs {
+				d := &c.defs[i]
+				if d.Name != m[2] {
+					continue
+				}
+				if d.Module == m[1] || d.Module == blamed.Module || match == nil {
+					match = d
+				}
+				if d.Module == m[1] {
+					break
+				}
+			}
+			return Domain{Name: match.Module + "." + match.Name, Definition: match}
+		}
+	}
+	if m := nsErrorDomain.FindStringSubmatch(crash.Reason); m != nil {
+		return Domain{Name: strings.Trim(m[1], `"'`)}
+	}
+	return Domain{}
+}
+
+// Module summarises the crashes blamed on one module.
+type Module struct {
+	Name    string `json:"name"`
+	Crashes int    `json:"crashes"`
+	// Processes and Exceptions count the crashes per process and
+	// exception type.
+	Processes  map[string]int `json:"processes"`
+	Exceptions map[string]int `json:"exceptions"`
+	// TopFrame is the blamed frame seen most often, as "Foo.bar()".
+	TopFrame string `json:"topFrame,omitempty"`
+	// File is the blamed frame's file when it was found in the tree.
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+// ErrorDomain summarises the crashes naming one error domain.
+type ErrorDomain struct {
+	Name    string `json:"name"`
+	Crashes int    `json:"crashes"`
+	// Catalogued is set when the domain is an error type of the catalogue;
+	// File and Line are then where it is defined.
+	Catalogued bool     `json:"catalogued"`
+	File       string   `json:"file,omitempty"`
+	Line       int      `json:"line,omitempty"`
+	Modules    []string `json:"modules"`
+}
+
+// Report is the summary of a set of crashes.
+type Report struct {
+	Crashes []*Crash      `json:"crashes"`
+	Modules []Module      `json:"modules"`
+	Domains []ErrorDomain `json:"domains"`
+	// Unattributed counts the crashes with no frame in the tree.
+	Unattributed int `json:"unattributed"`
+	// Skipped lists the files that were not crash reports, with the reason.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// Summarise attributes and classifies crashes and summarises them per
+// module and per error domain, the most frequent first.
+func Summarise(crashes []*Crash, a *Attributor, c *Classifier) *Report {
+	r := &Report{Crashes: crashes}
+	modules := make(map[string]*Module)
+	frames := make(map[string]map[Frame]int)
+	domains := make(map[string]*ErrorDomain)
+	domainModules := make(map[string]map[string]bool)
+	for _, crash := range crashes {
+		a.Attribute(crash)
+		blamed, ok := crash.Blamed()
+		if ok {
+			crash.Module = blamed.Module
+			m := modules[blamed.Module]
+			if m == nil {
+				m = &Module{Name: blamed.Module, Processes: make(map[string]int), Exceptions: make(map[string]int)}
+				modules[blamed.Module] = m
+				frames[blamed.Module] = make(map[Frame]int)
+			}
+			m.Crashes++
+			m.Processes[crash.Process]++
+			m.Exceptions[crash.Exception]++
+			// The image base slides between launches; the offset into the
+			// image does not.
+			blamed.Address, blamed.ImageBase = blamed.Address-blamed.ImageBase, 0
+			frames[blamed.Module][blamed]++
+		} else {
+			r.Unattributed++
+		}
+
+		domain := c.Classify(crash)
+		if domain.Name == "" {
+			continue
+		}
+		crash.Domain = domain.Name
+		d := domains[domain.Name]
+		if d == nil {
+			d = &ErrorDomain{Name: domain.Name}
+			if def := domain.Definition; def != nil {
+				d.Catalogued, d.File, d.Line = true, def.File, def.Line
+			}
+			domains[domain.Name] = d
+			domainModules[domain.Name] = make(map[string]bool)
+		}
+		d.Crashes++
+		if crash.Module != "" {
+			domainModules[domain.Name][crash.Module] = true
+		}
+	}
+
+	for name, m := range modules {
+		var top Frame
+		seen := 0
+		for f, n := range frames[name] {
+			if n > seen || n == seen && frameName(f) < frameName(top) {
+				top, seen = f, n
+			}
+		}
+		m.TopFrame = frameName(top)
+		m.File, m.Line = top.File, top.SourceLine
+		if m.File == "" {
+			m.Line = 0
+		}
+		r.Modules = append(r.Modules, *m)
+	}
+	sort.Slice(r.Modules, func(i, j int) bool {
+		if r.Modules[i].Crashes != r.Modules[j].Crashes {
+			return r.Modules[i].Crashes > r.Modules[j].Crashes
+		}
+		return r.Modules[i].Name < r.Modules[j].Name
+	})
+	for name, d := range domains {
+		d.Modules = []string{}
+		for m := range domainModules[name] {
+			d.Modules = append(d.Modules, m)
+		}
+		sort.Strings(d.Modules)
+		r.Domains = append(r.Domains, *d)
+	}
+	sort.Slice(r.Domains, func(i, j int) bool {
+		if r.Domains[i].Crashes != r.Domains[j].Crashes {
+			return r.Domains[i].Crashes > r.Domains[j].Crashes
+		}
+		return r.Domains[i].Name < r.Domains[j].Name
+	})
+	return r
+}
+
+// frameName renders a frame by its symbol, or by its image and address
+// when it has none.
+func frameName(f Frame) string {
+	if f.Symbol != "" {
+		return f.Symbol
+	}
+	if f.Image == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s + 0x%x", f.Image, f.Address-f.ImageBase)
+}
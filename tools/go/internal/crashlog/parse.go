@@ -0,0 +1,275 @@
+package crashlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+)
+
+// Frame is one frame of the crashed thread.
+type Frame struct {
+	Image string `json:"image"`
+	// Symbol is empty for a frame the report left unsymbolicated.
+	Symbol string `json:"symbol,omitempty"`
+	// SourceFile and SourceLine locate the frame when the report or atos
+	// gives them; SourceFile is a base name such as "Foo.swift".
+	SourceFile string `json:"sourceFile,omitempty"`
+	SourceLine int    `json:"sourceLine,omitempty"`
+	Address    uint64 `json:"-"`
+	ImageBase  uint64 `json:"-"`
+	// Module and File are where Attribute places the frame in the tree.
+	Module string `json:"module,omitempty"`
+	File   string `json:"file,omitempty"`
+}
+
+// Crash is one parsed crash report.
+type Crash struct {
+	// Report is the crash report's path.
+	Report  string    `json:"report"`
+	Process string    `json:"process"`
+	Time    time.Time `json:"time"`
+	// Exception is the exception type with its signal, as
+	// "EXC_CRASH (SIGABRT)".
+	Exception string `json:"exception"`
+	// Reason collects the termination reason and the application
+	// specific information, where Swift writes its fatal errors.
+	Reason string  `json:"reason,omitempty"`
+	Frames []Frame `json:"frames"`
+	// Module and Domain are filled in by Summarise: the module of the
+	// blamed frame and the crash's error domain.
+	Module string `json:"module,omitempty"`
+	Domain string `json:"domain,omitempty"`
+	// Fingerprint is set by umbratool on a crash blamed on a frame in
+	// the tree, which is recorded as an issue.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Parse reads a crash report: an .ips file, whose JSON header line is
+// followed by a JSON body or, before macOS 12, by the text format, or a
+// text .crash file. A text report with a line longer than textscan.MaxLine
+// is warned about and read up to that line.
+func Parse(name string, data []byte) (*Crash, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		header, body, _ := bytes.Cut(trimmed, []byte("\n"))
+		var h ipsHeader
+		if err := json.Unmarshal(header, &h); err != nil {
+			return nil, fmt.Errorf("%s: header: %w", name, err)
+		}
+		if h.BugType != "" && !crashBugTypes[h.BugType] {
+			return nil, fmt.Errorf("%s: not a crash report (bug type %s)", name, h.BugType)
+		}
+		body = bytes.TrimSpace(body)
+		var c *Crash
+		var err error
+		if bytes.HasPrefix(body, []byte("{")) {
+			c, err = parseIPS(body)
+		} else {
+			c, err = parseText(name, body)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if c.Process == "" {
+			c.Process = h.Name
+		}
+		if c.Time.IsZero() {
+			c.Time = parseTime(h.Timestamp)
+		}
+		c.Report = name
+		return c, nil
+	}
+	c, err := parseText(name, trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	c.Report = name
+	return c, nil
+}
+
+// crashBugTypes are the .ips bug types of crash reports: 309 with a JSON
+// body, 109 with a text one. Others are hangs, stackshots and the like.
+var crashBugTypes = map[string]bool{"109": true, "309": true}
+
+type ipsHeader struct {
+	Name      string `json:"name"`
+	Timestamp string `json:"timestamp"`
+	BugType   string `json:"bug_type"`
+}
+
+type ipsBody struct {
+	ProcName    string `json:"procName"`
+	CaptureTime string `json:"captureTime"`
+	Exception   struct {
+		Type   string `json:"type"`
+		Signal string `json:"signal"`
+	} `json:"exception"`
+	Termination struct {
+		Indicator string   `json:"indicator"`
+		Reasons   []string `json:"reasons"`
+	} `json:"termination"`
+	ASI            map[string][]string `json:"asi"`
+	FaultingThread int                 `json:"faultingThread"`
+	Threads        []struct {
+		Triggered bool `json:"triggered"`
+		Frames    []struct {
+			ImageIndex     int    `json:"imageIndex"`
+			ImageOffset    uint64 `json:"imageOffset"`
+			Symbol         string `json:"symbol"`
+			SourceFile     string `json:"sourceFile"`
+			SourceLine     int    `json:"sourceLine"`
+			SymbolLocation uint64 `json:"symbolLocation"`
+		} `json:"frames"`
+	} `json:"threads"`
+	UsedImages []struct {
+		Name string `json:"name"`
+		Base uint64 `json:"base"`
+	} `json:"usedImages"`
+}
+
+func parseIPS(body []byte) (*Crash, error) {
+	var b ipsBody
+	if err := json.Unmarshal(body, &b); err != nil {
+		return nil, err
+	}
+	c := &Crash{Process: b.ProcName, Time: parseTime(b.CaptureTime), Exception: exception(b.Exception.Type, b.Exception.Signal)}
+	var reasons []string
+	if b.Termination.Indicator != "" {
+		reasons = append(reasons, b.Termination.Indicator)
+	}
+	reasons = append(reasons, b.Termination.Reasons...)
+	for _, lines := range b.ASI {
+		reasons = append(reasons, lines...)
+	}
+	c.Reason = strings.Join(reasons, "\n")
+
+	thread := b.FaultingThread
+	for i, t := range b.Threads {
+		if t.Triggered {
+			thread = i
+		}
+	}
+	if thread < 0 || thread >= len(b.Threads) {
+		return c, nil
+	}
+	for _, f := range b.Threads[thread].Frames {
+		frame := Frame{Symbol: f.Symbol, SourceFile: f.SourceFile, SourceLine: f.SourceLine}
+		if f.ImageIndex >= 0 && f.ImageIndex < len(b.UsedImages) {
+			img := b.UsedImages[f.ImageIndex]
+			frame.Image, frame.ImageBase, frame.Address = img.Name, img.Base, img.Base+f.ImageOffset
+		}
+		c.Frames = append(c.Frames, frame)
+	}
+	return c, nil
+}
+
+var (
+	crashedThread = regexp.MustCompile(`^Thread \d+ Crashed`)
+	// textFrame matches "1   UmbraCryptoService   0x0000000100a3c2f0 Foo.bar() + 120 (Foo.swift:42)".
+	textFrame = regexp.MustCompile(`^\d+\s+(\S+)\s+0x([0-9a-fA-F]+)\s+(.*)$`)
+	// textImage matches a "Binary Images:" line, "0x100a30000 - 0x100a5ffff UmbraCryptoService arm64 ...".
+	textImage  = regexp.MustCompile(`^\s*0x([0-9a-fA-F]+)\s+-\s+0x[0-9a-fA-F]+\s+\+?(\S+)`)
+	sourceSpot = regexp.MustCompile(`\s+\(([^()]+\.swift):(\d+)\)$`)
+)
+
+func parseText(name string, body []byte) (*Crash, error) {
+	c := &Crash{}
+	images := make(map[string]uint64)
+	var reasons []string
+	section := ""
+	scanner := textscan.NewScanner(bytes.NewReader(body))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		key, value, _ := strings.Cut(line, ":")
+		value = strings.TrimSpace(value)
+		switch {
+		case strings.TrimSpace(line) == "":
+			if section != "images" {
+				section = ""
+			}
+		case key == "Process":
+			c.Process = strings.TrimSpace(strings.Split(value, "[")[0])
+		case key == "Date/Time":
+			c.Time = parseTime(value)
+		case key == "Exception Type":
+			c.Exception = value
+		case key == "Termination Reason":
+			reasons = append(reasons, value)
+		case line == "Application Specific Information:":
+			section = "asi"
+		case crashedThread.MatchString(line):
+			section = "crashed"
+		case line == "Binary Images:":
+			section = "images"
+		case section == "asi":
+			reasons = append(reasons, strings.TrimSpace(line))
+		case section == "crashed":
+			if m := textFrame.FindStringSubmatch(line); m != nil {
+				c.Frames = append(c.Frames, textFrameOf(m))
+			}
+		case section == "images":
+			if m := textImage.FindStringSubmatch(line); m != nil {
+				base, _ := strconv.ParseUint(m[1], 16, 64)
+				images[m[2]] = base
+			}
+		}
+	}
+	if err := textscan.Check(name, lineNo, scanner.Err()); err != nil {
+		return nil, err
+	}
+	if c.Process == "" && len(c.Frames) == 0 {
+		return nil, fmt.Errorf("not a crash report")
+	}
+	c.Reason = strings.Join(reasons, "\n")
+	for i := range c.Frames {
+		c.Frames[i].ImageBase = images[c.Frames[i].Image]
+	}
+	return c, nil
+}
+
+// textFrameOf reads a frame line. An unsymbolicated frame shows the image
+// base and offset, "0x100a30000 + 49904", in place of a symbol.
+func textFrameOf(m []string) Frame {
+	f := Frame{Image: m[1]}
+	f.Address, _ = strconv.ParseUint(m[2], 16, 64)
+	rest := m[3]
+	if strings.HasPrefix(rest, "0x") {
+		return f
+	}
+	if s := sourceSpot.FindStringSubmatchIndex(rest); s != nil {
+		f.SourceFile = rest[s[2]:s[3]]
+		f.SourceLine, _ = strconv.Atoi(rest[s[4]:s[5]])
+		rest = rest[:s[0]]
+	}
+	if i := strings.LastIndex(rest, " + "); i >= 0 {
+		rest = rest[:i]
+	}
+	f.Symbol = strings.TrimSpace(rest)
+	return f
+}
+
+func exception(kind, signal string) string {
+	if signal == "" {
+		return kind
+	}
+	return fmt.Sprintf("%s (%s)", kind, signal)
+}
+
+// parseTime reads the timestamps crash reports use, such as
+// "2026-03-02 14:05:09.3310 +0000"; time.Parse accepts the fractional
+// seconds of any length.
+func parseTime(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04:05 -0700", strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
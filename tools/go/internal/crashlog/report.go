@@ -0,0 +1,114 @@
+package crashlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteMarkdown writes the crashes per module and per error domain, then
+// the crashes themselves, newest first.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	b.WriteString("# Crash Summary\n\n")
+	if len(r.Crashes) == 0 {
+		b.WriteString("No crash reports found.\n")
+		writeSkipped(&b, r.Skipped)
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	fmt.Fprintf(&b, "%s from %s to %s.", plural(len(r.Crashes), "crash", "crashes"), r.Crashes[0].Time.Format("2006-01-02"), r.Crashes[len(r.Crashes)-1].Time.Format("2006-01-02"))
+	if r.Unattributed > 0 {
+		fmt.Fprintf(&b, " %d had no frame in the tree's modules.", r.Unattributed)
+	}
+	b.WriteString("\n")
+
+	if len(r.Modules) > 0 {
+		b.WriteString("\n## Modules\n\nEach crash is blamed on the module of the first crashed-thread frame in the tree.\n\n")
+		b.WriteString("| Module | Crashes | Processes | Exceptions | Top frame |\n|--------|---------|-----------|------------|-----------|\n")
+		for _, m := range r.Modules {
+			top := "`" + m.TopFrame + "`"
+			if m.File != "" {
+				top += fmt.Sprintf(" (`%s:%d`)", m.File, m.Line)
+			}
+			fmt.Fprintf(&b, "| %s | %d | %s | %s | %s |\n", m.Name, m.Crashes, counted(m.Processes), counted(m.Exceptions), top)
+		}
+	}
+
+	if len(r.Domains) > 0 {
+		b.WriteString("\n## Error Domains\n\n| Domain | Crashes | Defined in | Modules |\n|--------|---------|------------|---------|\n")
+		for _, d := range r.Domains {
+			defined := "not catalogued"
+			if d.Catalogued {
+				defined = fmt.Sprintf("`%s:%d`", d.File, d.Line)
+			}
+			modules := strings.Join(d.Modules, ", ")
+			if modules == "" {
+				modules = "–"
+			}
+			fmt.Fprintf(&b, "| %s | %d | %s | %s |\n", d.Name, d.Crashes, defined, modules)
+		}
+	}
+
+	b.WriteString("\n## Crashes\n\n| Time | Process | Exception | Module | Domain | Report |\n|------|---------|-----------|--------|--------|--------|\n")
+	for i := len(r.Crashes) - 1; i >= 0; i-- {
+		c := r.Crashes[i]
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | `%s` |\n", c.Time.Format("2006-01-02 15:04"), c.Process, c.Exception, dash(c.Module), dash(c.Domain), c.Report)
+	}
+	writeSkipped(&b, r.Skipped)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func writeSkipped(b *strings.Builder, skipped []string) {
+	if len(skipped) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## Skipped\n\n%s could not be read as crash reports.\n\n", plural(len(skipped), "file", "files"))
+	for _, s := range skipped {
+		fmt.Fprintf(b, "- %s\n", s)
+	}
+}
+
+// counted renders counts as "UmbraCryptoService (3), UmbraKeychainService (1)".
+func counted(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s (%d)", name, counts[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "–"
+	}
+	return s
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
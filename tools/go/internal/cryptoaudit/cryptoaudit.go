@@ -0,0 +1,239 @@
+// Package cryptoaudit finds direct uses of the keychain, SecKey and
+// low-level CryptoKit and CommonCrypto APIs in Swift sources, so that
+// modules outside the sanctioned security layer can be moved onto it.
+package cryptoaudit
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// API categories.
+const (
+	CategoryKeychain     = "keychain"
+	CategorySecKey       = "seckey"
+	CategoryCryptoKit    = "cryptokit"
+	CategoryCommonCrypto = "commoncrypto"
+)
+
+// Categories lists every category in report order.
+var Categories = []string{CategoryKeychain, CategorySecKey, CategoryCryptoKit, CategoryCommonCrypto}
+
+// DefaultApproved are the modules allowed to call the audited APIs.
+var DefaultApproved = []string{"SecurityProtocolsCore", "SecurityBridge", "UmbraKeychainService"}
+
+var (
+	keychainPattern     = regexp.MustCompile(`\b(SecItem(?:Add|CopyMatching|Update|Delete)|SecKeychain[A-Z]\w*|SecAccess(?:Create|ControlCreateWithFlags))\b`)
+	secKeyPattern       = regexp.MustCompile(`\b(SecKey(?:Create\w*|Copy\w*|Encrypt\w*|Decrypt\w*|Sign\w*|Verify\w*)|SecRandomCopyBytes)\s*\(`)
+	commonCryptoPattern = regexp.MustCompile(`\b(CC(?:Crypt(?:or)?\w*|Hmac\w*|KeyDerivationPBKDF|RandomGenerateBytes)|CC_(?:SHA\d+|MD5)\w*)\b`)
+	// cryptoKitPattern is only applied to files importing CryptoKit, as
+	// names like SHA256 are common in other code too.
+	cryptoKitPattern = regexp.MustCompile(`\b(AES\.(?:GCM|KeyWrap)|ChaChaPoly|SHA(?:256|384|512)|Insecure\.(?:MD5|SHA1)|HMAC\s*<|HKDF\s*<|P(?:256|384|521)\.\w+|Curve25519\.\w+|SymmetricKey)`)
+)
+
+// frameworks maps the imports that count as usages to their category.
+var frameworks = map[string]string{
+	"CryptoKit":    CategoryCryptoKit,
+	"CommonCrypto": CategoryCommonCrypto,
+}
+
+// Usage is one use of an audited API.
+type Usage struct {
+	Module   string `json:"module"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Category string `json:"category"`
+	// API is the function, type or imported framework used.
+	API  string `json:"api"`
+	Text string `json:"text"`
+	// Approved is set in the approved modules.
+	Approved bool `json:"approved"`
+	// Fingerprint is set on the usages outside the approved modules,
+	// which are reported as issues.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Options configures a scan.
+type Options struct {
+	// Dirs are the top-level directories scanned (default "Sources").
+	Dirs []string
+	// Approved are the modules allowed to use the APIs.
+	Approved []string
+}
+
+// Scan returns every usage in the Swift files below opts.Dirs, sorted by
+// module, file and line.
+func Scan(root string, opts Options) ([]Usage, error) {
+	dirs := opts.Dirs
+	if len(dirs) == 0 {
+		dirs = []string{"Sources"}
+	}
+	approved := make(map[string]bool, len(opts.Approved))
+	for _, m := range opts.Approved {
+		approved[m] = true
+	}
+
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	perFile, err := pool.Map(paths, func(rel string) ([]Usage, error) {
+		return scanFile(root, rel)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var usages []Usage
+	for _, found := range perFile {
+		for _, u := range found {
+			u.Approved = approved[u.Module]
+			usages = append(usages, u)
+		}
+	}
+	sort.SliceStable(usages, func(i, j int) bool {
+		if usages[i].Module != usages[j].Module {
+			return usages[i].Module < usages[j].Module
+		}
+		return false
+	})
+	return usages, nil
+}
+
+func scanFile(root, rel string) ([]Usage, error) {
+	f, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	module := workspace.ModuleForPath(rel)
+	type line struct {
+		no         int
+		code, text string
+	}
+	var lines []line
+	cryptoKit := false
+	inComment := false
+	scanner := textscan.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		var code string
+		code, inComment = swiftsrc.StripComments(scanner.Text(), inComment)
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+		if mod, ok := imports.ParseLine(code); ok && mod == "CryptoKit" {
+			cryptoKit = true
+		}
+		lines = append(lines, line{lineNo, code, strings.TrimSpace(scanner.Text())})
+	}
+	if err := textscan.Check(rel, lineNo, scanner.Err()); err != nil {
+		return nil, err
+	}
+
+	var usages []Usage
+	for _, l := range lines {
+		seen := make(map[string]bool)
+		add := func(category, api string) {
+			if !seen[api] {
+				seen[api] = true
+				usages = append(usages, Usage{Module: module, File: rel, Line: l.no, Category: category, API: api, Text: l.text})
+			}
+		}
+		if mod, ok := imports.ParseLine(l.code); ok {
+			if category, audited := frameworks[mod]; audited {
+				add(category, "import "+mod)
+			}
+			continue
+		}
+		for _, m := range keychainPattern.FindAllStringSubmatch(l.code, -1) {
+			add(CategoryKeychain, m[1])
+		}
+		for _, m := range secKeyPattern.FindAllStringSubmatch(l.code, -1) {
+			add(CategorySecKey, m[1])
+		}
+		for _, m := range commonCryptoPattern.FindAllStringSubmatch(l.code, -1) {
+			add(CategoryCommonCrypto, m[1])
+		}
+		if cryptoKit {
+			for _, m := range cryptoKitPattern.FindAllStringSubmatch(l.code, -1) {
+				add(CategoryCryptoKit, strings.Join(strings.Fields(m[1]), ""))
+			}
+		}
+	}
+	return usages, nil
+}
+
+// ModuleSummary counts the usages in one module.
+type ModuleSummary struct {
+	Module     string         `json:"module"`
+	Approved   bool           `json:"approved"`
+	Count      int            `json:"count"`
+	ByCategory map[string]int `json:"byCategory"`
+}
+
+// Summarise groups usages by module: violating modules first, by
+// descending count, then the approved ones.
+func Summarise(usages []Usage) []ModuleSummary {
+	byModule := make(map[string]*ModuleSummary)
+	for _, u := range usages {
+		s, ok := byModule[u.Module]
+		if !ok {
+			s = &ModuleSummary{Module: u.Module, Approved: u.Approved, ByCategory: make(map[string]int)}
+			byModule[u.Module] = s
+		}
+		s.Count++
+		s.ByCategory[u.Category]++
+	}
+
+	summaries := make([]ModuleSummary, 0, len(byModule))
+	for _, s := range byModule {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if a.Approved != b.Approved {
+			return !a.Approved
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Module < b.Module
+	})
+	return summaries
+}
+
+// Violations returns the usages outside the approved modules.
+func Violations(usages []Usage) []Usage {
+	var out []Usage
+	for _, u := range usages {
+		if !u.Approved {
+			out = append(out, u)
+		}
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package cryptoaudit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes the per-module counts and then every violation,
+// grouped by module.
+func WriteMarkdown(w io.Writer, usages []Usage, approved []string) error {
+	violations := Violations(usages)
+
+	var b strings.Builder
+	b.WriteString("# Keychain and Crypto API Audit\n\n")
+	fmt.Fprintf(&b, "Approved modules: %s\n\n", strings.Join(approved, ", "))
+	fmt.Fprintf(&b, "**%d direct usages, %d outside the approved modules**\n", len(usages), len(violations))
+	if len(usages) == 0 {
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	b.WriteString("\n## Modules\n\n")
+	b.WriteString("| Module | Approved | Usages | Keychain | SecKey | CryptoKit | CommonCrypto |\n")
+	b.WriteString("|--------|----------|--------|----------|--------|-----------|--------------|\n")
+	for _, s := range Summarise(usages) {
+		fmt.Fprintf(&b, "| %s | %s | %d | %d | %d | %d | %d |\n", s.Module, yesNo(s.Approved), s.Count,
+			s.ByCategory[CategoryKeychain], s.ByCategory[CategorySecKey], s.ByCategory[CategoryCryptoKit], s.ByCategory[CategoryCommonCrypto])
+	}
+
+	if len(violations) > 0 {
+		b.WriteString("\n## Violations\n\nMove these calls behind the approved security modules.\n")
+		module := ""
+		for _, u := range violations {
+			if u.Module != module {
+				module = u.Module
+				fmt.Fprintf(&b, "\n### %s\n\n", module)
+			}
+			fmt.Fprintf(&b, "- `%s:%d` %s `%s`\n", u.File, u.Line, u.Category, u.API)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the module summaries and usages as indented JSON.
+func WriteJSON(w io.Writer, usages []Usage) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Modules []ModuleSummary `json:"modules"`
+		Usages  []Usage         `json:"usages"`
+	}{Summarise(usages), usages})
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
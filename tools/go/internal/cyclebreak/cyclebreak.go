@@ -0,0 +1,499 @@
+// Package cyclebreak turns the import cycles health finds into plans for
+// breaking them. For each cycle it finds the files behind every import
+// between its modules and the declarations they use, picks the set of
+// imports to cut that changes the fewest files, and suggests for each
+// used declaration whether to move it or hide it behind a protocol.
+package cyclebreak
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/health"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// IssueCut is the kind of the issue recorded for each import to cut.
+const IssueCut = "cycle_cut"
+
+// Suggested actions.
+const (
+	// ActionDropImport removes an import whose module's top-level
+	// declarations the file does not use. Members of extensions are not
+	// seen, so check the file builds without it.
+	ActionDropImport = "drop-import"
+	// ActionMove moves the declaration into the importing module.
+	ActionMove = "move"
+	// ActionExtractProtocol declares a protocol of what the importing
+	// module uses in that module, and has the declaration conform to it.
+	ActionExtractProtocol = "extract-protocol"
+)
+
+// maxExact is the most edges a cycle may have for the cheapest cut to be
+// searched exhaustively; larger cycles are cut greedily.
+const maxExact = 16
+
+// Use is one file's import of a module across a cycle edge.
+type Use struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	// Imported is the Swift module imported, and Symbols its public
+	// declarations the file refers to.
+	Imported string   `json:"imported"`
+	Symbols  []string `json:"symbols"`
+}
+
+// Edge is the import of one cycle member by another.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Uses []Use  `json:"uses"`
+	// Symbols are the distinct declarations of To that From uses.
+	Symbols []string `json:"symbols"`
+	// Fingerprint is that of the issue recorded for an edge in Cut.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Files is the number of files cutting the edge changes.
+func (e Edge) Files() int {
+	return len(e.Uses)
+}
+
+// String names the edge, e.g. "Services → UmbraSecurity".
+func (e Edge) String() string {
+	return e.From + " → " + e.To
+}
+
+// Action is the change suggested for one declaration used across a cut
+// edge, or for the imports that use none.
+type Action struct {
+	Action string `json:"action"`
+	// Symbol is the declaration, empty for ActionDropImport, with its
+	// kind and where it is declared.
+	Symbol string `json:"symbol,omitempty"`
+	Kind   string `json:"kind,omitempty"`
+	Module string `json:"module,omitempty"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	// Target is the module the declaration or protocol goes to.
+	Target string `json:"target,omitempty"`
+	// Drags are the other declarations of Module the declaration's file
+	// refers to, which a move takes along or leaves behind an import.
+	Drags []string `json:"drags,omitempty"`
+	// Files are the importing files the action changes.
+	Files []string `json:"files"`
+}
+
+// Plan is the suggested way to break one cycle.
+type Plan struct {
+	Cycle health.Cycle `json:"cycle"`
+	// Edges are every import between the cycle's members, fewest files
+	// first, and Cut the ones the plan removes.
+	Edges []Edge `json:"edges"`
+	Cut   []Edge `json:"cut"`
+	// Exact is false when the cycle had too many edges to search and Cut
+	// was chosen greedily, so a cheaper cut may exist.
+	Exact   bool     `json:"exact"`
+	Actions []Action `json:"actions"`
+}
+
+// Files is the number of files the plan changes.
+func (p Plan) Files() int {
+	n := 0
+	for _, e := range p.Cut {
+		n += e.Files()
+	}
+	return n
+}
+
+var (
+	identPattern = regexp.MustCompile(`[A-Za-z_]\w*`)
+	// declPattern matches a named top-level declaration, capturing its
+	// access level, kind and name.
+	declPattern = regexp.MustCompile(`^\s*(?:@\w+(?:\([^)]*\))?\s+)*(?:(public|open|package|internal|fileprivate|private)\s+)?(?:(?:final|static|indirect|nonisolated|dynamic|distributed)\s+)*(class|struct|enum|protocol|actor|typealias|func|let|var|macro)\s+([A-Za-z_]\w*)`)
+)
+
+// decl is a top-level declaration.
+type decl struct {
+	name, kind, file string
+	line             int
+	public           bool
+}
+
+// source is what a Swift file declares and refers to.
+type source struct {
+	rel    string
+	module string
+	decls  []decl
+	idents map[string]bool
+}
+
+// Suggest returns a plan for each cycle, in the cycles' order. rules and
+// files are those the cycles were found from.
+func Suggest(root string, rules []modulenames.Rule, files []imports.File, cycles []health.Cycle) ([]Plan, error) {
+	owner := make(map[string]string, len(rules))
+	for _, r := range rules {
+		owner[r.ModuleName] = workspace.ModuleForPath(r.File)
+	}
+	members := make(map[string]bool)
+	for _, c := range cycles {
+		for _, m := range c.Modules {
+			members[m] = true
+		}
+	}
+	var paths []string
+	for _, f := range files {
+		if members[f.Module] {
+			paths = append(paths, f.Path)
+		}
+	}
+	index := &moduleindex.Index{Modules: rules}
+	sources, err := pool.Map(paths, func(rel string) (*source, error) {
+		s, err := readSource(root, rel)
+		if err != nil {
+			return nil, err
+		}
+		if r, ok := index.ForPath(rel); ok {
+			s.module = r.ModuleName
+		}
+		return s, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	byPath := make(map[string]*source, len(sources))
+	// api holds each Swift module's public declarations by name, and
+	// declared every name a module declares.
+	api := make(map[string]map[string]decl)
+	declared := make(map[string]map[string]bool)
+	for _, s := range sources {
+		byPath[s.rel] = s
+		if api[s.module] == nil {
+			api[s.module] = make(map[string]decl)
+			declared[s.module] = make(map[string]bool)
+		}
+		for _, d := range s.decls {
+			declared[s.module][d.name] = true
+			if _, dup := api[s.module][d.name]; d.public && !dup {
+				api[s.module][d.name] = d
+			}
+		}
+	}
+
+	var plans []Plan
+	for _, c := range cycles {
+		in := make(map[string]bool, len(c.Modules))
+		for _, m := range c.Modules {
+			in[m] = true
+		}
+		edges := make(map[[2]string]*Edge)
+		for _, f := range files {
+			if !in[f.Module] {
+				continue
+			}
+			s := byPath[f.Path]
+			for _, imp := range f.Imports {
+				to, ok := owner[imp.Module]
+				if !ok || to == f.Module || !in[to] {
+					continue
+				}
+				k := [2]string{f.Module, to}
+				e := edges[k]
+				if e == nil {
+					e = &Edge{From: f.Module, To: to}
+					edges[k] = e
+				}
+				use := Use{File: f.Path, Line: imp.Line, Imported: imp.Module, Symbols: []string{}}
+				if s != nil {
+					for name := range s.idents {
+						if _, ok := api[imp.Module][name]; ok && !declared[s.module][name] {
+							use.Symbols = append(use.Symbols, name)
+						}
+					}
+				}
+				sort.Strings(use.Symbols)
+				e.Uses = append(e.Uses, use)
+			}
+		}
+
+		plan := Plan{Cycle: c}
+		for _, e := range edges {
+			seen := make(map[string]bool)
+			for _, u := range e.Uses {
+				for _, name := range u.Symbols {
+					if !seen[name] {
+						seen[name] = true
+						e.Symbols = append(e.Symbols, name)
+					}
+				}
+			}
+			sort.Strings(e.Symbols)
+			plan.Edges = append(plan.Edges, *e)
+		}
+		sort.Slice(plan.Edges, func(i, j int) bool {
+			if plan.Edges[i].Files() != plan.Edges[j].Files() {
+				return plan.Edges[i].Files() < plan.Edges[j].Files()
+			}
+			return plan.Edges[i].String() < plan.Edges[j].String()
+		})
+		plan.Cut, plan.Exact = cheapestCut(c.Modules, plan.Edges)
+		for _, e := range plan.Cut {
+			plan.Actions = append(plan.Actions, actions(e, api, byPath, index)...)
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// actions suggests how to remove the imports of edge e.
+func actions(e Edge, api map[string]map[string]decl, byPath map[string]*source, index *moduleindex.Index) []Action {
+	var drops []*Action
+	dropped := make(map[string]*Action)
+	byDecl := make(map[string]*Action)
+	var order []string
+	for _, u := range e.Uses {
+		if len(u.Symbols) == 0 {
+			a := dropped[u.Imported]
+			if a == nil {
+				a = &Action{Action: ActionDropImport, Module: u.Imported, Target: e.From}
+				dropped[u.Imported] = a
+				drops = append(drops, a)
+			}
+			a.Files = append(a.Files, u.File)
+			continue
+		}
+		target := e.From
+		if r, ok := index.ForPath(u.File); ok {
+			target = r.ModuleName
+		}
+		for _, name := range u.Symbols {
+			key := u.Imported + "." + name
+			a := byDecl[key]
+			if a == nil {
+				d := api[u.Imported][name]
+				a = &Action{Symbol: name, Kind: d.kind, Module: u.Imported, File: d.file, Line: d.line, Target: target}
+				a.Drags = drags(d, byPath[d.file], api[u.Imported])
+				a.Action = choose(d.kind, len(a.Drags) > 0)
+				byDecl[key] = a
+				order = append(order, key)
+			}
+			a.Files = append(a.Files, u.File)
+		}
+	}
+
+	// Dropping an unused import is the cheapest change; after it, the
+	// declarations used by the fewest files.
+	var out []Action
+	for _, a := range drops {
+		out = append(out, *a)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return len(byDecl[order[i]].Files) < len(byDecl[order[j]].Files)
+	})
+	for _, key := range order {
+		out = append(out, *byDecl[key])
+	}
+	return out
+}
+
+// choose picks the action for a declaration of kind. A protocol moves,
+// since what conforms to it can import it wherever it lands; a class or
+// actor stays and is hidden behind a protocol; anything else moves when
+// its file refers to nothing else of its module.
+func choose(kind string, drags bool) string {
+	switch kind {
+	case "protocol":
+		return ActionMove
+	case "class", "actor":
+		return ActionExtractProtocol
+	case "struct":
+		if drags {
+			return ActionExtractProtocol
+		}
+	}
+	return ActionMove
+}
+
+// drags returns the public declarations of the module, other than those
+// of d's own file, that the file refers to.
+func drags(d decl, s *source, api map[string]decl) []string {
+	if s == nil {
+		return nil
+	}
+	own := make(map[string]bool, len(s.decls))
+	for _, sd := range s.decls {
+		own[sd.name] = true
+	}
+	var out []string
+	for name := range s.idents {
+		if _, ok := api[name]; ok && !own[name] {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// cheapestCut returns the edges whose removal leaves the members without
+// a cycle, changing the fewest files. It searches every set of edges when
+// there are at most maxExact and reports true; otherwise it repeatedly
+// cuts the cheapest edge of a remaining cycle and reports false.
+func cheapestCut(members []string, edges []Edge) ([]Edge, bool) {
+	if len(edges) <= maxExact {
+		best, bestFiles := -1, 0
+		for mask := 0; mask < 1<<len(edges); mask++ {
+			files := 0
+			for i, e := range edges {
+				if mask&(1<<i) != 0 {
+					files += e.Files()
+				}
+			}
+			if best >= 0 && (files > bestFiles || files == bestFiles && bits(mask) >= bits(best)) {
+				continue
+			}
+			if !hasCycle(members, edges, mask) {
+				best, bestFiles = mask, files
+			}
+		}
+		var cut []Edge
+		for i, e := range edges {
+			if best&(1<<i) != 0 {
+				cut = append(cut, e)
+			}
+		}
+		return cut, true
+	}
+
+	removed := make(map[int]bool)
+	for {
+		cycle := findCycle(members, edges, removed)
+		if cycle == nil {
+			break
+		}
+		cheapest := cycle[0]
+		for _, i := range cycle[1:] {
+			if edges[i].Files() < edges[cheapest].Files() {
+				cheapest = i
+			}
+		}
+		removed[cheapest] = true
+	}
+	var cut []Edge
+	for i, e := range edges {
+		if removed[i] {
+			cut = append(cut, e)
+		}
+	}
+	return cut, false
+}
+
+func bits(mask int) int {
+	n := 0
+	for ; mask != 0; mask &= mask - 1 {
+		n++
+	}
+	return n
+}
+
+// hasCycle reports whether the edges not in mask form a cycle.
+func hasCycle(members []string, edges []Edge, mask int) bool {
+	removed := make(map[int]bool)
+	for i := range edges {
+		if mask&(1<<i) != 0 {
+			removed[i] = true
+		}
+	}
+	return findCycle(members, edges, removed) != nil
+}
+
+// findCycle returns the indexes of the edges of a cycle among the edges
+// not removed, or nil when there is none.
+func findCycle(members []string, edges []Edge, removed map[int]bool) []int {
+	out := make(map[string][]int)
+	for i, e := range edges {
+		if !removed[i] {
+			out[e.From] = append(out[e.From], i)
+		}
+	}
+	const (
+		unvisited = iota
+		active
+		done
+	)
+	state := make(map[string]int)
+	var path []int
+	var visit func(n string) []int
+	visit = func(n string) []int {
+		state[n] = active
+		for _, i := range out[n] {
+			to := edges[i].To
+			switch state[to] {
+			case active:
+				// The cycle runs from the edge leaving to back round to i.
+				for j, p := range path {
+					if edges[p].From == to {
+						return append(append([]int(nil), path[j:]...), i)
+					}
+				}
+				return []int{i}
+			case unvisited:
+				path = append(path, i)
+				if c := visit(to); c != nil {
+					return c
+				}
+				path = path[:len(path)-1]
+			}
+		}
+		state[n] = done
+		return nil
+	}
+	for _, m := range members {
+		if state[m] == unvisited {
+			if c := visit(m); c != nil {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+func readSource(root, rel string) (*source, error) {
+	fh, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	s := &source{rel: rel, module: workspace.ModuleForPath(rel), idents: make(map[string]bool)}
+	inComment := false
+	depth := 0
+	lineNo := 0
+	scanner := textscan.NewScanner(fh)
+	for scanner.Scan() {
+		lineNo++
+		var code string
+		code, inComment = swiftsrc.StripComments(scanner.Text(), inComment)
+		if _, ok := imports.ParseLine(code); ok {
+			continue
+		}
+		if depth == 0 {
+			if m := declPattern.FindStringSubmatch(code); m != nil {
+				s.decls = append(s.decls, decl{name: m[3], kind: m[2], file: rel, line: lineNo, public: m[1] == "public" || m[1] == "open"})
+			}
+		}
+		for _, ident := range identPattern.FindAllString(code, -1) {
+			s.idents[ident] = true
+		}
+		depth += strings.Count(code, "{") - strings.Count(code, "}")
+	}
+	return s, textscan.Check(rel, lineNo, scanner.Err())
+}
@@ -0,0 +1,114 @@
+package cyclebreak
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxListed is how many symbols or files a table cell lists.
+const maxListed = 5
+
+// WriteMarkdown writes, per cycle, its edges with the cut marked, then the
+// suggested change for every declaration used across the cut.
+func WriteMarkdown(w io.Writer, plans []Plan) error {
+	var b strings.Builder
+	b.WriteString("# Import Cycle Breaking\n\n")
+	cuts, files := 0, 0
+	for _, p := range plans {
+		cuts += len(p.Cut)
+		files += p.Files()
+	}
+	fmt.Fprintf(&b, "**%d cycles, %d imports to cut, %d files to change**\n", len(plans), cuts, files)
+	if len(plans) == 0 {
+		b.WriteString("\nNo import cycles between modules.\n")
+	}
+
+	for _, p := range plans {
+		fmt.Fprintf(&b, "\n## %s\n\n", p.Cycle)
+		if len(p.Cycle.Modules) > len(p.Cycle.Path)-1 {
+			fmt.Fprintf(&b, "The cycle runs through %s.\n\n", strings.Join(p.Cycle.Modules, ", "))
+		}
+		cut := make([]string, 0, len(p.Cut))
+		for _, e := range p.Cut {
+			cut = append(cut, e.String())
+		}
+		fmt.Fprintf(&b, "Cutting %s breaks the cycle, changing %s", strings.Join(cut, " and "), countFiles(p.Files()))
+		if !p.Exact {
+			b.WriteString("; the cycle has too many imports to search them all, so a cheaper cut may exist")
+		}
+		b.WriteString(".\n\n")
+
+		b.WriteString("| Import | Files | Symbols used | Cut |\n")
+		b.WriteString("|--------|-------|--------------|-----|\n")
+		for _, e := range p.Edges {
+			mark := ""
+			for _, c := range p.Cut {
+				if c.From == e.From && c.To == e.To {
+					mark = "**yes**"
+				}
+			}
+			fmt.Fprintf(&b, "| %s | %d | %s | %s |\n", e, e.Files(), list(e.Symbols), mark)
+		}
+
+		if len(p.Actions) > 0 {
+			b.WriteString("\n| Change | Declared in | Files |\n")
+			b.WriteString("|--------|-------------|-------|\n")
+			for _, a := range p.Actions {
+				where := "-"
+				if a.File != "" {
+					where = fmt.Sprintf("`%s:%d`", a.File, a.Line)
+				}
+				fmt.Fprintf(&b, "| %s | %s | %s |\n", describe(a), where, list(a.Files))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the plans as indented JSON.
+func WriteJSON(w io.Writer, plans []Plan) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(plans)
+}
+
+// describe words an action for the report.
+func describe(a Action) string {
+	switch a.Action {
+	case ActionDropImport:
+		return fmt.Sprintf("Drop `import %s` from %s, which uses none of its top-level declarations", a.Module, a.Target)
+	case ActionExtractProtocol:
+		return fmt.Sprintf("Extract a protocol of what %s uses of %s `%s` into %s, and have `%s` conform to it", a.Target, a.Kind, a.Symbol, a.Target, a.Symbol)
+	}
+	msg := fmt.Sprintf("Move %s `%s` from %s to %s", a.Kind, a.Symbol, a.Module, a.Target)
+	if len(a.Drags) > 0 {
+		msg += fmt.Sprintf(", with or importing %s", list(a.Drags))
+	}
+	return msg
+}
+
+func countFiles(n int) string {
+	if n == 1 {
+		return "1 file"
+	}
+	return fmt.Sprintf("%d files", n)
+}
+
+func list(items []string) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	shown := make([]string, 0, maxListed)
+	for _, s := range items[:min(len(items), maxListed)] {
+		shown = append(shown, "`"+s+"`")
+	}
+	out := strings.Join(shown, ", ")
+	if more := len(items) - maxListed; more > 0 {
+		out += fmt.Sprintf(" and %d more", more)
+	}
+	return out
+}
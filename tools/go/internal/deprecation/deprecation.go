@@ -0,0 +1,515 @@
+// Package deprecation tracks the symbols Swift sources mark deprecated
+// with @available and the call sites still using them. A deprecation
+// names its sunset milestone in its message, as a date or a release:
+// "sunset: 2025-06-30", "removed in 0.2.0" or "until 2025-06". Uses of a
+// symbol past its sunset are what a release must not ship with.
+package deprecation
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// NoSunset groups the deprecations whose message names no milestone.
+const NoSunset = "none"
+
+var (
+	availableStart = regexp.MustCompile(`@available\s*\(`)
+	deprecatedArg  = regexp.MustCompile(`(?:^|,)\s*deprecated\b`)
+	messageArg     = regexp.MustCompile(`\bmessage\s*:\s*"((?:[^"\\]|\\.)*)"`)
+	renamedArg     = regexp.MustCompile(`\brenamed\s*:\s*"((?:[^"\\]|\\.)*)"`)
+	sunsetPattern  = regexp.MustCompile(`(?i)\b(?:sunset|remov(?:ed|al)\s+in|until)\s*:?\s*(v?\d+(?:\.\d+){1,2}|\d{4}-\d{2}(?:-\d{2})?)\b`)
+	attrsOnly      = regexp.MustCompile(`^\s*(?:@\w+(?:\([^)]*\))?\s*)+$`)
+	declPattern    = regexp.MustCompile(`^\s*(?:@\w+(?:\([^)]*\))?\s+)*(?:(?:public|private|fileprivate|internal|open|package|final|nonisolated|static|class|override|required|convenience|mutating|lazy|weak|indirect|dynamic|\w+\(set\))\s+)*(func|var|let|class|struct|enum|protocol|typealias|case|init|actor|subscript|extension)\b\s*(?:\(\s*([A-Za-z_]\w*)\s*:|([A-Za-z_]\w*))?`)
+	ownerPattern   = regexp.MustCompile(`\b(?:class|struct|enum|actor|protocol|extension)\s+([A-Z]\w*(?:\.[A-Z]\w*)*)`)
+	modulePattern  = regexp.MustCompile(`(?s)\bmodule\s*\([^)]*\bversion\s*=\s*"([^"]+)"`)
+)
+
+// Symbol is a deprecated declaration and its uses.
+type Symbol struct {
+	Name string `json:"name"`
+	// Kind is the declaring keyword: func, var, class, init and so on.
+	Kind    string `json:"kind"`
+	Module  string `json:"module"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Message string `json:"message,omitempty"`
+	Renamed string `json:"renamed,omitempty"`
+	// Sunset is the milestone from the message, or NoSunset.
+	Sunset string `json:"sunset"`
+	// Past is set once the sunset milestone has been reached.
+	Past bool `json:"past"`
+	// Tracked is unset for declarations whose uses cannot be told apart
+	// from others by name: unlabelled initialisers, subscripts and
+	// extensions.
+	Tracked bool  `json:"tracked"`
+	Uses    []Use `json:"uses"`
+}
+
+// Use is a reference to a deprecated symbol.
+type Use struct {
+	Module string `json:"module"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Text   string `json:"text"`
+	// Fingerprint is set on a use of a symbol past its sunset.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Group holds the deprecations sharing a sunset milestone.
+type Group struct {
+	Sunset  string   `json:"sunset"`
+	Past    bool     `json:"past"`
+	Uses    int      `json:"uses"`
+	Symbols []Symbol `json:"symbols"`
+}
+
+// Options configures a scan.
+type Options struct {
+	// Dirs are the top-level directories scanned (default "Sources").
+	Dirs []string
+	// Version is the current release, which release milestones are
+	// compared against; they do not pass when it is empty.
+	Version string
+	// Today is the date milestones are compared against.
+	Today time.Time
+	// Rules name the module of each file; files outside every rule fall
+	// back to their top-level directory.
+	Rules []modulenames.Rule
+}
+
+type line struct {
+	no         int
+	code       string // comments and strings removed
+	withString string // comments removed
+	text       string
+}
+
+type file struct {
+	rel, module string
+	imports     map[string]bool
+	lines       []line
+}
+
+// Scan returns the deprecated symbols below opts.Dirs with their uses,
+// sorted by file and line.
+func Scan(root string, opts Options) ([]Symbol, error) {
+	dirs := opts.Dirs
+	if len(dirs) == 0 {
+		dirs = []string{"Sources"}
+	}
+	index := &moduleindex.Index{Modules: opts.Rules}
+
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	files, err := pool.Map(paths, func(rel string) (*file, error) {
+		f, err := readFile(root, rel)
+		if err != nil {
+			return nil, err
+		}
+		if r, ok := index.ForPath(rel); ok {
+			f.module = r.ModuleName
+		}
+		return f, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var symbols []Symbol
+	for _, f := range files {
+		symbols = append(symbols, declarations(f)...)
+	}
+	for i := range symbols {
+		s := &symbols[i]
+		s.Past = past(s.Sunset, opts.Version, opts.Today)
+	}
+
+	perFile, err := pool.Map(files, func(f *file) ([][]Use, error) {
+		return uses(f, symbols), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, found := range perFile {
+		for i, u := range found {
+			symbols[i].Uses = append(symbols[i].Uses, u...)
+		}
+	}
+	return symbols, nil
+}
+
+func readFile(root, rel string) (*file, error) {
+	fh, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	f := &file{rel: rel, module: workspace.ModuleForPath(rel), imports: make(map[string]bool)}
+	inComment, inCode := false, false
+	scanner := textscan.NewScanner(fh)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		var withString, code string
+		withString, inComment = swiftsrc.RemoveComments(scanner.Text(), inComment)
+		code, inCode = swiftsrc.StripComments(scanner.Text(), inCode)
+		if strings.TrimSpace(withString) == "" {
+			continue
+		}
+		if mod, ok := imports.ParseLine(code); ok {
+			f.imports[mod] = true
+		}
+		f.lines = append(f.lines, line{lineNo, code, withString, strings.TrimSpace(scanner.Text())})
+	}
+	return f, textscan.Check(rel, lineNo, scanner.Err())
+}
+
+// declarations returns the symbols f deprecates. Members are named after
+// the type enclosing them.
+func declarations(f *file) []Symbol {
+	owners := enclosingTypes(f.lines)
+	var out []Symbol
+	for i := 0; i < len(f.lines); i++ {
+		loc := availableStart.FindStringIndex(f.lines[i].withString)
+		if loc == nil {
+			continue
+		}
+		args, end, off := attributeArgs(f.lines, i, loc[1])
+		if !deprecatedArg.MatchString(args) {
+			continue
+		}
+		s := Symbol{Module: f.module, File: f.rel, Sunset: NoSunset}
+		if m := messageArg.FindStringSubmatch(args); m != nil {
+			s.Message = m[1]
+			if sm := sunsetPattern.FindStringSubmatch(m[1]); sm != nil {
+				s.Sunset = strings.TrimPrefix(sm[1], "v")
+			}
+		}
+		if m := renamedArg.FindStringSubmatch(args); m != nil {
+			s.Renamed = m[1]
+		}
+
+		// The declaration follows the attribute, on its last line or
+		// after further attributes.
+		j, rest := end, f.lines[end].withString[off:]
+		for strings.TrimSpace(rest) == "" || attrsOnly.MatchString(rest) {
+			if j++; j == len(f.lines) {
+				break
+			}
+			rest = f.lines[j].withString
+		}
+		if j == len(f.lines) {
+			break
+		}
+		m := declPattern.FindStringSubmatch(rest)
+		if m == nil {
+			continue
+		}
+		owner := owners[j]
+		s.Kind, s.Line = m[1], f.lines[j].no
+		switch s.Kind {
+		case "init":
+			s.Name = owner + ".init"
+			if m[2] != "" && owner != "" {
+				s.Name, s.Tracked = owner+".init("+m[2]+":)", true
+			}
+		case "subscript":
+			s.Name = owner + ".subscript"
+		case "extension":
+			s.Name = m[3]
+		default:
+			s.Name, s.Tracked = m[3], m[3] != ""
+			if owner != "" {
+				s.Name = owner + "." + s.Name
+			}
+		}
+		out = append(out, s)
+		i = j
+	}
+	return out
+}
+
+// enclosingTypes returns, per line, the type whose body the line starts
+// in, or "" at the top level.
+func enclosingTypes(lines []line) []string {
+	type scope struct {
+		name  string
+		depth int
+	}
+	var stack []scope
+	out := make([]string, len(lines))
+	pending := ""
+	depth := 0
+	for i, l := range lines {
+		if len(stack) > 0 {
+			out[i] = stack[len(stack)-1].name
+		}
+		if m := ownerPattern.FindStringSubmatch(l.code); m != nil {
+			pending = m[1]
+		}
+		for _, c := range l.code {
+			switch c {
+			case '{':
+				depth++
+				if pending != "" {
+					stack = append(stack, scope{pending, depth})
+					pending = ""
+				}
+			case '}':
+				depth--
+				for len(stack) > 0 && stack[len(stack)-1].depth > depth {
+					stack = stack[:len(stack)-1]
+				}
+			}
+		}
+	}
+	return out
+}
+
+// attributeArgs returns the arguments of the attribute whose parenthesis
+// opens just before offset start of lines[i], the index of the line
+// closing it and the offset just after the closing parenthesis.
+func attributeArgs(lines []line, i, start int) (string, int, int) {
+	var b strings.Builder
+	depth := 1
+	inString := false
+	for n := i; n < len(lines); n++ {
+		text := lines[n].withString
+		k := 0
+		if n == i {
+			k = start
+		} else {
+			b.WriteByte(' ')
+		}
+		for ; k < len(text); k++ {
+			c := text[k]
+			switch {
+			case inString && c == '\\':
+				b.WriteByte(c)
+				k++
+				if k < len(text) {
+					b.WriteByte(text[k])
+				}
+				continue
+			case inString:
+				inString = c != '"'
+			case c == '"':
+				inString = true
+			case c == '(':
+				depth++
+			case c == ')':
+				depth--
+				if depth == 0 {
+					return b.String(), n, k + 1
+				}
+			}
+			b.WriteByte(c)
+		}
+	}
+	last := len(lines) - 1
+	return b.String(), last, len(lines[last].withString)
+}
+
+// usePattern matches references to s outside its declaration.
+func usePattern(s Symbol) *regexp.Regexp {
+	name := s.Name[strings.LastIndex(s.Name, ".")+1:]
+	switch s.Kind {
+	case "init":
+		owner, label, _ := strings.Cut(strings.TrimSuffix(s.Name, ":)"), ".init(")
+		return regexp.MustCompile(`(?:\b` + owner + `|\.init)\s*\(\s*` + label + `\s*:`)
+	case "func":
+		return regexp.MustCompile(`\b` + name + `\s*\(|\.` + name + `\b`)
+	case "var", "let", "case":
+		if strings.Contains(s.Name, ".") {
+			return regexp.MustCompile(`\.` + name + `\b`)
+		}
+		return regexp.MustCompile(`\b` + name + `\b`)
+	default:
+		return regexp.MustCompile(`\b` + name + `\b`)
+	}
+}
+
+// uses returns, per symbol, the lines of f referring to it. Only files of
+// the declaring module or importing it can see a symbol, and a type's own
+// body does not count.
+func uses(f *file, symbols []Symbol) [][]Use {
+	out := make([][]Use, len(symbols))
+	var owners []string
+	for i, s := range symbols {
+		if !s.Tracked || f.module != s.Module && !f.imports[s.Module] {
+			continue
+		}
+		re := usePattern(s)
+		name := s.Name[strings.LastIndex(s.Name, ".")+1:]
+		redecl := regexp.MustCompile(`\b(?:func|var|let|case|class|struct|enum|protocol|typealias|actor)\s+` + regexp.QuoteMeta(name) + `\b`)
+		for n, l := range f.lines {
+			if f.rel == s.File && l.no == s.Line || redecl.MatchString(l.code) || !re.MatchString(l.code) {
+				continue
+			}
+			if f.rel == s.File && !isMemberKind(s.Kind) {
+				if owners == nil {
+					owners = enclosingTypes(f.lines)
+				}
+				if owner := owners[n]; owner == s.Name || strings.HasPrefix(owner, s.Name+".") {
+					continue
+				}
+			}
+			out[i] = append(out[i], Use{Module: f.module, File: f.rel, Line: l.no, Text: l.text})
+		}
+	}
+	return out
+}
+
+func isMemberKind(kind string) bool {
+	switch kind {
+	case "func", "var", "let", "case", "init", "subscript":
+		return true
+	}
+	return false
+}
+
+// past reports whether the sunset milestone has been reached: a date once
+// it is over, a release once the current version is at least it.
+func past(sunset, version string, today time.Time) bool {
+	if sunset == NoSunset {
+		return false
+	}
+	if d, ok := milestoneEnd(sunset); ok {
+		return !today.IsZero() && today.After(d)
+	}
+	if version == "" {
+		return false
+	}
+	return compareVersions(strings.TrimPrefix(version, "v"), sunset) >= 0
+}
+
+// milestoneEnd returns the last moment of a date milestone.
+func milestoneEnd(s string) (time.Time, bool) {
+	if d, err := time.Parse("2006-01-02", s); err == nil {
+		return d.AddDate(0, 0, 1).Add(-time.Nanosecond), true
+	}
+	if d, err := time.Parse("2006-01", s); err == nil {
+		return d.AddDate(0, 1, 0).Add(-time.Nanosecond), true
+	}
+	return time.Time{}, false
+}
+
+func compareVersions(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			y, _ = strconv.Atoi(pb[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// Groups groups symbols by sunset milestone: milestones passed first,
+// then the upcoming ones, dates before releases, with the deprecations
+// naming none last.
+func Groups(symbols []Symbol) []Group {
+	byMilestone := make(map[string]*Group)
+	for _, s := range symbols {
+		g, ok := byMilestone[s.Sunset]
+		if !ok {
+			g = &Group{Sunset: s.Sunset, Past: s.Past}
+			byMilestone[s.Sunset] = g
+		}
+		g.Uses += len(s.Uses)
+		g.Symbols = append(g.Symbols, s)
+	}
+	groups := make([]Group, 0, len(byMilestone))
+	for _, g := range byMilestone {
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if a.Past != b.Past {
+			return a.Past
+		}
+		if (a.Sunset == NoSunset) != (b.Sunset == NoSunset) {
+			return b.Sunset == NoSunset
+		}
+		_, da := milestoneEnd(a.Sunset)
+		_, db := milestoneEnd(b.Sunset)
+		switch {
+		case da != db:
+			return da
+		case da:
+			return a.Sunset < b.Sunset
+		}
+		if c := compareVersions(a.Sunset, b.Sunset); c != 0 {
+			return c < 0
+		}
+		return a.Sunset < b.Sunset
+	})
+	return groups
+}
+
+// PastUses returns the uses of symbols past their sunset.
+func PastUses(symbols []Symbol) []Use {
+	var out []Use
+	for _, s := range symbols {
+		if s.Past {
+			out = append(out, s.Uses...)
+		}
+	}
+	return out
+}
+
+// ModuleVersion returns the version the root's MODULE.bazel declares, or
+// "" when it declares none.
+func ModuleVersion(root string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(root, "MODULE.bazel"))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if m := modulePattern.FindSubmatch(data); m != nil {
+		return string(m[1]), nil
+	}
+	return "", nil
+}
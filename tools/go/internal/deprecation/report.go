@@ -0,0 +1,73 @@
+package deprecation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes one section per sunset milestone, those passed
+// first, listing each deprecated symbol and the uses left.
+func WriteMarkdown(w io.Writer, symbols []Symbol, version, today string) error {
+	groups := Groups(symbols)
+	uses, past := 0, len(PastUses(symbols))
+	for _, s := range symbols {
+		uses += len(s.Uses)
+	}
+
+	var b strings.Builder
+	b.WriteString("# Deprecated API Usage\n\n")
+	fmt.Fprintf(&b, "Milestones compared against version %s and %s.\n\n", orDash(version), today)
+	fmt.Fprintf(&b, "**%d deprecated symbols, %d uses, %d past their sunset**\n", len(symbols), uses, past)
+
+	for _, g := range groups {
+		switch {
+		case g.Sunset == NoSunset:
+			b.WriteString("\n## No Sunset\n\n")
+		case g.Past:
+			fmt.Fprintf(&b, "\n## Sunset %s (passed)\n\n", g.Sunset)
+		default:
+			fmt.Fprintf(&b, "\n## Sunset %s\n\n", g.Sunset)
+		}
+		b.WriteString("| Symbol | Declared | Uses | Message |\n")
+		b.WriteString("|--------|----------|------|---------|\n")
+		for _, s := range g.Symbols {
+			count := fmt.Sprint(len(s.Uses))
+			if !s.Tracked {
+				count = "untracked"
+			}
+			fmt.Fprintf(&b, "| `%s` | `%s:%d` | %s | %s |\n", s.Name, s.File, s.Line, count, strings.ReplaceAll(s.Message, "|", `\|`))
+		}
+		for _, s := range g.Symbols {
+			if len(s.Uses) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "\n### %s\n\n", s.Name)
+			for _, u := range s.Uses {
+				fmt.Fprintf(&b, "- `%s:%d`\n", u.File, u.Line)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the milestone groups as indented JSON.
+func WriteJSON(w io.Writer, symbols []Symbol, version, today string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Version string  `json:"version"`
+		Today   string  `json:"today"`
+		Groups  []Group `json:"groups"`
+	}{version, today, Groups(symbols)})
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
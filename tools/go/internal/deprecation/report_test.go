@@ -0,0 +1,62 @@
+package deprecation
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testfixture"
+)
+
+const legacySource = `public struct Vault {
+    @available(*, deprecated, message: "removed in 0.2")
+    public func open() {}
+
+    @available(*, deprecated, message: "removed in 0.2.0")
+    public func close() {}
+
+    @available(*, deprecated, message: "sunset: 2025-06-30")
+    public func seal() {}
+
+    @available(*, deprecated, renamed: "unlock")
+    public func release() {}
+}
+`
+
+const callerSource = `import Legacy
+
+func run(v: Vault) {
+    v.open()
+    v.close()
+    v.seal()
+    v.release()
+}
+`
+
+// TestReportsAreReproducible renders the reports of one tree repeatedly:
+// the 0.2 and 0.2.0 milestones compare equal as versions, so their order
+// must not depend on how the groups came out of the map.
+func TestReportsAreReproducible(t *testing.T) {
+	root := testfixture.Write(t, map[string]string{
+		"Sources/Legacy/Vault.swift": legacySource,
+		"Sources/App/Run.swift":      callerSource,
+	})
+	opts := Options{Version: "0.1.0", Today: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	render := func() []byte {
+		symbols, err := Scan(root, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		if err := WriteMarkdown(&b, symbols, opts.Version, "2025-01-01"); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteJSON(&b, symbols, opts.Version, "2025-01-01"); err != nil {
+			t.Fatal(err)
+		}
+		return b.Bytes()
+	}
+
+	testfixture.Reproducible(t, render)
+}
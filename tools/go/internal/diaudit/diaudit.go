@@ -0,0 +1,509 @@
+// Package diaudit cross-references the services registered in a service
+// container against the ones resolved from it. ServiceContainer keys its
+// services by the static `serviceIdentifier` of their type, so a
+// resolution nothing registers only fails at run time, and a registration
+// nothing resolves is a service kept alive for no one.
+package diaudit
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Call kinds.
+const (
+	CallRegister    = "register"
+	CallResolve     = "resolve"
+	CallResolveByID = "resolveByID"
+)
+
+// Finding kinds.
+const (
+	// KindUnregistered is a resolution of a type or identifier no call
+	// registers.
+	KindUnregistered = "unregistered"
+	// KindUnresolved is a registered service nothing resolves, by type,
+	// identifier or as a dependency.
+	KindUnresolved = "unresolved"
+	// KindUnknownID is a dependency on an identifier no type declares.
+	KindUnknownID = "unknown_id"
+	// KindUntyped is a registration whose service type could not be
+	// inferred from the source; it takes no part in the cross-reference.
+	KindUntyped = "untyped_registration"
+)
+
+// DefaultReceivers matches the receivers whose register and resolve calls
+// are audited. Registries of error mappers and recovery handlers have the
+// same method names but nothing to do with the container.
+const DefaultReceivers = `(?i)container`
+
+var (
+	callPattern = regexp.MustCompile(`\b([A-Za-z_]\w*(?:\s*\.\s*[A-Za-z_]\w*)*)\s*\.\s*(register|resolve|resolveByID)\s*\(`)
+	// constructorPattern matches an argument that builds or fetches the
+	// service in place: `CryptoService(...)`, `KeyManager.shared`.
+	constructorPattern = regexp.MustCompile(`^(?:try\s+)?(?:await\s+)?((?:[A-Z]\w*\.)*[A-Z]\w*)\s*(?:\(|\.init\b|\.shared\b)`)
+	variablePattern    = regexp.MustCompile(`^(?:self\s*\.\s*)?([a-z_]\w*)$`)
+	metatypePattern    = regexp.MustCompile(`^((?:[A-Z]\w*\.)*[A-Z]\w*)\s*\.\s*self$`)
+	identifierRef      = regexp.MustCompile(`^((?:[A-Z]\w*\.)*[A-Z]\w*)\s*\.\s*serviceIdentifier$`)
+	stringLiteral      = regexp.MustCompile(`^"([^"\\]*)"$`)
+	declPattern        = regexp.MustCompile(`\b(?:class|struct|actor|enum|extension)\s+([A-Z]\w*)`)
+	idDeclPattern      = regexp.MustCompile(`\bstatic\s+(?:let|var)\s+serviceIdentifier\s*(?::\s*String\s*)?(?:=\s*"([^"\\]*)"|\{\s*(?:return\s+)?"([^"\\]*)"\s*\})`)
+)
+
+// Call is one register or resolve call on a container.
+type Call struct {
+	Module string `json:"module"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Kind   string `json:"kind"`
+	// Type is the service type, without module qualifiers, when it could
+	// be inferred.
+	Type string `json:"type,omitempty"`
+	// Identifier is the literal identifier of a resolveByID call.
+	Identifier string `json:"identifier,omitempty"`
+	// Dependencies are the identifiers and types a registration passes as
+	// dependencies; types are written as Type.serviceIdentifier.
+	Dependencies []string `json:"dependencies,omitempty"`
+	Text         string   `json:"text"`
+}
+
+// Declaration is the serviceIdentifier a type declares.
+type Declaration struct {
+	Type       string `json:"type"`
+	Identifier string `json:"identifier"`
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+}
+
+// Service summarises the calls for one service type.
+type Service struct {
+	Type          string `json:"type"`
+	Identifier    string `json:"identifier,omitempty"`
+	Registrations int    `json:"registrations"`
+	Resolutions   int    `json:"resolutions"`
+	// Dependents counts the registrations depending on the service.
+	Dependents int `json:"dependents"`
+}
+
+// Finding is one audit finding.
+type Finding struct {
+	Kind        string `json:"kind"`
+	Module      string `json:"module"`
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Type        string `json:"type,omitempty"`
+	Message     string `json:"message"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Report is the result of a scan.
+type Report struct {
+	Services     []Service     `json:"services"`
+	Declarations []Declaration `json:"declarations"`
+	Calls        []Call        `json:"calls"`
+	Findings     []Finding     `json:"findings"`
+}
+
+// Options configures a scan.
+type Options struct {
+	// Dirs are the top-level directories scanned (default "Sources").
+	Dirs []string
+	// Receivers matches the receivers audited (default DefaultReceivers).
+	Receivers *regexp.Regexp
+}
+
+type fileResult struct {
+	calls []Call
+	decls []Declaration
+}
+
+// Scan audits the Swift files below opts.Dirs.
+func Scan(root string, opts Options) (*Report, error) {
+	dirs := opts.Dirs
+	if len(dirs) == 0 {
+		dirs = []string{"Sources"}
+	}
+	receivers := opts.Receivers
+	if receivers == nil {
+		receivers = regexp.MustCompile(DefaultReceivers)
+	}
+
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	perFile, err := pool.Map(paths, func(rel string) (fileResult, error) {
+		return scanFile(root, rel, receivers)
+	})
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{}
+	for _, f := range perFile {
+		r.Calls = append(r.Calls, f.calls...)
+		r.Declarations = append(r.Declarations, f.decls...)
+	}
+	crossReference(r)
+	return r, nil
+}
+
+type line struct {
+	no         int
+	code, text string
+}
+
+func scanFile(root, rel string, receivers *regexp.Regexp) (fileResult, error) {
+	f, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return fileResult{}, err
+	}
+	defer f.Close()
+
+	var lines []line
+	inComment := false
+	scanner := textscan.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		var code string
+		code, inComment = swiftsrc.RemoveComments(scanner.Text(), inComment)
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+		lines = append(lines, line{lineNo, code, strings.TrimSpace(scanner.Text())})
+	}
+	if err := textscan.Check(rel, lineNo, scanner.Err()); err != nil {
+		return fileResult{}, err
+	}
+
+	module := workspace.ModuleForPath(rel)
+	var out fileResult
+	owner := ""
+	for i, l := range lines {
+		if m := declPattern.FindStringSubmatch(l.code); m != nil {
+			owner = m[1]
+		}
+		if m := idDeclPattern.FindStringSubmatch(l.code); m != nil && owner != "" {
+			out.decls = append(out.decls, Declaration{Type: owner, Identifier: m[1] + m[2], File: rel, Line: l.no})
+		}
+		for _, loc := range callPattern.FindAllStringSubmatchIndex(l.code, -1) {
+			receiver := l.code[loc[2]:loc[3]]
+			if !receivers.MatchString(lastComponent(receiver)) {
+				continue
+			}
+			c := Call{Module: module, File: rel, Line: l.no, Kind: l.code[loc[4]:loc[5]], Text: l.text}
+			args := splitArgs(callArgs(lines[i:], loc[1]))
+			switch c.Kind {
+			case CallRegister:
+				if len(args) > 0 {
+					c.Type = registeredType(lines[:i], args[0])
+				}
+				for _, a := range args[1:] {
+					if deps, ok := strings.CutPrefix(a, "dependencies:"); ok {
+						c.Dependencies = dependencyList(deps)
+					}
+				}
+			case CallResolve:
+				if len(args) > 0 {
+					if m := metatypePattern.FindStringSubmatch(args[0]); m != nil {
+						c.Type = lastComponent(m[1])
+					}
+				}
+			case CallResolveByID:
+				if len(args) > 0 {
+					if m := stringLiteral.FindStringSubmatch(args[0]); m != nil {
+						c.Identifier = m[1]
+					} else if m := identifierRef.FindStringSubmatch(args[0]); m != nil {
+						c.Type = lastComponent(m[1])
+					}
+				}
+			}
+			out.calls = append(out.calls, c)
+		}
+	}
+	return out, nil
+}
+
+// callArgs returns the text between the parenthesis opened just before
+// offset start of the first line and the one closing it, joining lines
+// for calls that span several.
+func callArgs(lines []line, start int) string {
+	var b strings.Builder
+	depth := 1
+	inString := false
+	for n, l := range lines {
+		code := l.code
+		if n == 0 {
+			code = code[start:]
+		} else {
+			b.WriteByte(' ')
+		}
+		for i := 0; i < len(code); i++ {
+			c := code[i]
+			switch {
+			case inString:
+				if c == '\\' {
+					b.WriteByte(c)
+					i++
+					if i < len(code) {
+						b.WriteByte(code[i])
+					}
+					continue
+				}
+				if c == '"' {
+					inString = false
+				}
+			case c == '"':
+				inString = true
+			case c == '(' || c == '[' || c == '{':
+				depth++
+			case c == ')' || c == ']' || c == '}':
+				depth--
+				if depth == 0 {
+					return b.String()
+				}
+			}
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// splitArgs splits an argument list at its top-level commas, dropping the
+// `_` label and surrounding space.
+func splitArgs(s string) []string {
+	var args []string
+	depth := 0
+	inString := false
+	begin := 0
+	add := func(a string) {
+		a = strings.TrimSpace(a)
+		a = strings.TrimSpace(strings.TrimPrefix(a, "_:"))
+		if a != "" {
+			args = append(args, a)
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			add(s[begin:i])
+			begin = i + 1
+		}
+	}
+	add(s[begin:])
+	return args
+}
+
+// registeredType infers the type of the service a register call passes,
+// either built in the argument or held by a variable declared earlier in
+// the file. It returns "" when neither applies.
+func registeredType(before []line, arg string) string {
+	if m := constructorPattern.FindStringSubmatch(arg); m != nil {
+		return lastComponent(m[1])
+	}
+	m := variablePattern.FindStringSubmatch(arg)
+	if m == nil {
+		return ""
+	}
+	decl := regexp.MustCompile(`\b(?:let|var)\s+` + m[1] + `\b\s*(?::\s*((?:[A-Z]\w*\.)*[A-Z]\w*)\s*)?(=\s*(.*))?`)
+	for i := len(before) - 1; i >= 0; i-- {
+		d := decl.FindStringSubmatch(before[i].code)
+		if d == nil {
+			continue
+		}
+		if d[1] != "" {
+			return lastComponent(d[1])
+		}
+		if c := constructorPattern.FindStringSubmatch(strings.TrimSpace(d[3])); c != nil {
+			return lastComponent(c[1])
+		}
+		return ""
+	}
+	return ""
+}
+
+// dependencyList parses an array literal of identifiers.
+func dependencyList(s string) []string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return nil
+	}
+	var deps []string
+	for _, e := range splitArgs(s[1 : len(s)-1]) {
+		if m := stringLiteral.FindStringSubmatch(e); m != nil {
+			deps = append(deps, m[1])
+		} else if m := identifierRef.FindStringSubmatch(e); m != nil {
+			deps = append(deps, lastComponent(m[1])+".serviceIdentifier")
+		}
+	}
+	return deps
+}
+
+func lastComponent(name string) string {
+	name = strings.Join(strings.Fields(name), "")
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// crossReference summarises r.Calls per service and fills in r.Findings.
+func crossReference(r *Report) {
+	idOf := make(map[string]string)
+	typeOf := make(map[string]string)
+	for _, d := range r.Declarations {
+		if _, dup := idOf[d.Type]; !dup {
+			idOf[d.Type] = d.Identifier
+			typeOf[d.Identifier] = d.Type
+		}
+	}
+	// key names a service by its identifier where known, so registering
+	// one type and resolving it by identifier match up.
+	key := func(typ string) string {
+		if id, ok := idOf[typ]; ok {
+			return "id:" + id
+		}
+		return "type:" + typ
+	}
+	depKey := func(dep string) (string, bool) {
+		if typ, ok := strings.CutSuffix(dep, ".serviceIdentifier"); ok {
+			return key(typ), true
+		}
+		if typ, ok := typeOf[dep]; ok {
+			return key(typ), true
+		}
+		return "id:" + dep, false
+	}
+
+	services := make(map[string]*Service)
+	service := func(k, typ, id string) *Service {
+		s, ok := services[k]
+		if !ok {
+			s = &Service{Type: typ, Identifier: id}
+			services[k] = s
+		}
+		if s.Type == "" {
+			s.Type = typ
+		}
+		return s
+	}
+	registered := make(map[string]bool)
+	for _, c := range r.Calls {
+		if c.Kind == CallRegister && c.Type != "" {
+			k := key(c.Type)
+			registered[k] = true
+			service(k, c.Type, idOf[c.Type]).Registrations++
+		}
+	}
+
+	for _, c := range r.Calls {
+		switch {
+		case c.Kind == CallRegister && c.Type == "":
+			r.Findings = append(r.Findings, Finding{Kind: KindUntyped, Module: c.Module, File: c.File, Line: c.Line,
+				Message: "cannot infer the type of the registered service"})
+		case c.Kind == CallRegister:
+			for _, dep := range c.Dependencies {
+				k, known := depKey(dep)
+				if !known && !registered[k] {
+					r.Findings = append(r.Findings, Finding{Kind: KindUnknownID, Module: c.Module, File: c.File, Line: c.Line, Type: c.Type,
+						Message: "depends on " + dep + ", which no service type declares"})
+					continue
+				}
+				service(k, typeOf[strings.TrimPrefix(k, "id:")], strings.TrimPrefix(k, "id:")).Dependents++
+			}
+		case c.Type != "" || c.Identifier != "":
+			var k, typ, name string
+			if c.Type != "" {
+				k, typ, name = key(c.Type), c.Type, c.Type
+			} else {
+				typ = typeOf[c.Identifier]
+				k, name = "id:"+c.Identifier, `"`+c.Identifier+`"`
+				if typ != "" {
+					k = key(typ)
+				}
+			}
+			service(k, typ, idOf[typ]).Resolutions++
+			if !registered[k] {
+				r.Findings = append(r.Findings, Finding{Kind: KindUnregistered, Module: c.Module, File: c.File, Line: c.Line, Type: typ,
+					Message: "resolves " + name + ", which nothing registers"})
+			}
+		}
+	}
+
+	firstRegistration := make(map[string]Call)
+	for _, c := range r.Calls {
+		if c.Kind == CallRegister && c.Type != "" {
+			if _, ok := firstRegistration[key(c.Type)]; !ok {
+				firstRegistration[key(c.Type)] = c
+			}
+		}
+	}
+	for k, s := range services {
+		if s.Registrations > 0 && s.Resolutions == 0 && s.Dependents == 0 {
+			c := firstRegistration[k]
+			r.Findings = append(r.Findings, Finding{Kind: KindUnresolved, Module: c.Module, File: c.File, Line: c.Line, Type: s.Type,
+				Message: s.Type + " is registered but never resolved"})
+		}
+		r.Services = append(r.Services, *s)
+	}
+	sort.Slice(r.Services, func(i, j int) bool {
+		if r.Services[i].Type != r.Services[j].Type {
+			return r.Services[i].Type < r.Services[j].Type
+		}
+		return r.Services[i].Identifier < r.Services[j].Identifier
+	})
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		a, b := r.Findings[i], r.Findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Message < b.Message
+	})
+}
+
+// Failures returns the findings that fail a run at run time: resolutions
+// nothing registers and dependencies on unknown identifiers.
+func (r *Report) Failures() []Finding {
+	var out []Finding
+	for _, f := range r.Findings {
+		if f.Kind == KindUnregistered || f.Kind == KindUnknownID {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package diaudit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes the per-service counts and then the findings,
+// failures first.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	failures := r.Failures()
+	registrations, resolutions := 0, 0
+	for _, c := range r.Calls {
+		if c.Kind == CallRegister {
+			registrations++
+		} else {
+			resolutions++
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("# Service Registration Audit\n\n")
+	fmt.Fprintf(&b, "**%d registrations, %d resolutions, %d services; %d findings, %d that fail at run time**\n",
+		registrations, resolutions, len(r.Services), len(r.Findings), len(failures))
+
+	if len(r.Services) > 0 {
+		b.WriteString("\n## Services\n\n")
+		b.WriteString("| Service | Identifier | Registrations | Resolutions | Dependents |\n")
+		b.WriteString("|---------|------------|---------------|-------------|------------|\n")
+		for _, s := range r.Services {
+			typ := s.Type
+			if typ == "" {
+				typ = "-"
+			}
+			id := "-"
+			if s.Identifier != "" {
+				id = "`" + s.Identifier + "`"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %d | %d | %d |\n", typ, id, s.Registrations, s.Resolutions, s.Dependents)
+		}
+	}
+
+	sections := []struct {
+		kind, title, advice string
+	}{
+		{KindUnregistered, "Unregistered Resolutions", "These throw at run time unless something outside the scan registers the service."},
+		{KindUnknownID, "Unknown Dependencies", ""},
+		{KindUnresolved, "Unresolved Registrations", "Nothing in the scan resolves these services or depends on them."},
+		{KindUntyped, "Untyped Registrations", "The service type could not be inferred, so these are left out of the cross-reference."},
+	}
+	for _, sec := range sections {
+		var found []Finding
+		for _, f := range r.Findings {
+			if f.Kind == sec.kind {
+				found = append(found, f)
+			}
+		}
+		if len(found) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", sec.title)
+		if sec.advice != "" {
+			b.WriteString(sec.advice + "\n\n")
+		}
+		for _, f := range found {
+			fmt.Fprintf(&b, "- `%s:%d` %s\n", f.File, f.Line, f.Message)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,39 @@
+package diaudit
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testfixture"
+)
+
+// Two registrations on one line that nothing resolves give two findings
+// at the same file and line, which only their messages tell apart.
+const setupSource = `import Core
+
+func setUp(container: ServiceContainer) {
+    container.register(CryptoService()); container.register(KeyManager())
+    container.register(LogService(), dependencies: ["com.umbra.missing"])
+    let vault = container.resolve(Vault.self)
+}
+`
+
+func TestReportsAreReproducible(t *testing.T) {
+	root := testfixture.Write(t, map[string]string{
+		"Sources/App/Setup.swift": setupSource,
+	})
+	testfixture.Reproducible(t, func() []byte {
+		r, err := Scan(root, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		if err := WriteMarkdown(&b, r); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteJSON(&b, r); err != nil {
+			t.Fatal(err)
+		}
+		return b.Bytes()
+	})
+}
@@ -0,0 +1,445 @@
+// Package docdrift cross-references the code the Markdown docs mention,
+// modules, source directories, types and enum cases, against what the tree
+// declares, and reports the references to symbols that have since been
+// renamed, moved, deprecated or removed.
+package docdrift
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/deprecation"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/importrewrite"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moves"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftlex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// Reference kinds.
+const (
+	RefModule = "module"
+	RefPath   = "path"
+	RefType   = "type"
+	RefMember = "member"
+)
+
+// Drift kinds.
+const (
+	DriftRenamed    = "renamed"
+	DriftMoved      = "moved"
+	DriftDeprecated = "deprecated"
+	DriftMissing    = "missing"
+)
+
+var (
+	inlineCode    = regexp.MustCompile("(`+)([^`]+?)`+")
+	sourcePath    = regexp.MustCompile(`\b((?:Sources|Tests)/[A-Za-z_][A-Za-z0-9_]*)`)
+	moduleHeading = regexp.MustCompile(`^#+\s+([A-Z][A-Za-z0-9_]*)\s+Module\s*$`)
+	fileName      = regexp.MustCompile(`^[\w./-]+\.(swift|md|bazel|bzl|yaml|yml|json|go|sh|py|txt|plist|entitlements|resolved|h|m)$`)
+	fence         = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([\\w+-]*)")
+)
+
+// Drift is one reference in the docs to something the tree no longer has
+// as written.
+type Drift struct {
+	Doc  string `json:"doc"`
+	Line int    `json:"line"`
+	// Ref is the reference as written, e.g. "SecurityProtocolsCore.SecurityProvider".
+	Ref   string `json:"ref"`
+	Kind  string `json:"kind"`
+	Drift string `json:"drift"`
+	// Suggestion is what the reference should now read, when known.
+	Suggestion string `json:"suggestion,omitempty"`
+	// Reason says how the drift was found.
+	Reason      string `json:"reason"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Report is the result of Check.
+type Report struct {
+	Docs       int     `json:"docs"`
+	References int     `json:"references"`
+	Drift      []Drift `json:"drift"`
+}
+
+// Options configures a check.
+type Options struct {
+	// Docs are the directories of Markdown docs checked (default "docs").
+	Docs []string
+	// Dirs are the top-level directories whose declarations the docs are
+	// checked against (default "Sources").
+	Dirs []string
+	// Rules name the module of each file.
+	Rules []modulenames.Rule
+	// Rewrites, Deprecated and Moves record where modules, types and
+	// source directories went: the rewrite-imports mappings, the
+	// deprecations of the tree and the moves.log entries.
+	Rewrites   []importrewrite.Mapping
+	Deprecated []deprecation.Symbol
+	Moves      []moves.Entry
+	// Ignore are further names not to check, such as third-party types.
+	Ignore []string
+}
+
+// checker holds what references are resolved against.
+type checker struct {
+	root   string
+	ix     *index
+	ignore map[string]bool
+	// top are the project root's entries, such as TestSupport, which docs
+	// name in code spans too.
+	top     map[string]bool
+	modules map[string]string // renamed module → new module
+	dirs    map[string]string // moved source directory → new directory
+	renamed map[string]string // deprecated type → new name
+	message map[string]string // deprecated type → deprecation message
+	seen    map[seenKey]bool
+	report  *Report
+}
+
+// Check scans the Markdown files below opts.Docs for references to the
+// tree's code and returns those that have drifted, sorted by doc and line.
+//
+// A reference is an import in a Swift code block, a Sources or Tests
+// directory in text or any code block, a "# Name Module" heading, or a
+// type, qualified type or enum case in inline code or a Swift code block.
+// Standard library and SDK names, and types that a code block declares
+// itself, are not references.
+func Check(root string, opts Options) (*Report, error) {
+	docs := opts.Docs
+	if len(docs) == 0 {
+		docs = []string{"docs"}
+	}
+	dirs := opts.Dirs
+	if len(dirs) == 0 {
+		dirs = []string{"Sources"}
+	}
+	ix, err := buildIndex(root, dirs, opts.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checker{
+		root:    root,
+		ix:      ix,
+		ignore:  make(map[string]bool),
+		top:     make(map[string]bool),
+		modules: make(map[string]string),
+		dirs:    make(map[string]string),
+		renamed: make(map[string]string),
+		message: make(map[string]string),
+		seen:    make(map[seenKey]bool),
+		report:  &Report{Drift: []Drift{}},
+	}
+	for _, name := range opts.Ignore {
+		c.ignore[name] = true
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		c.top[e.Name()] = true
+	}
+	for _, m := range opts.Rewrites {
+		c.modules[m.From] = m.To
+	}
+	for _, e := range opts.Moves {
+		oldDir, newDir := topDir(e.Old), topDir(e.New)
+		if oldDir != "" && newDir != "" && oldDir != newDir {
+			c.dirs[oldDir] = newDir
+		}
+	}
+	for _, s := range opts.Deprecated {
+		if !typeKinds[s.Kind] {
+			continue
+		}
+		if s.Renamed != "" {
+			c.renamed[s.Name] = s.Renamed
+		} else {
+			c.message[s.Name] = s.Message
+		}
+	}
+
+	var files []string
+	for _, dir := range docs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".md"}}, func(rel string) error {
+			files = append(files, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	for _, rel := range files {
+		if err := c.scanDoc(rel); err != nil {
+			return nil, err
+		}
+	}
+	c.report.Docs = len(files)
+
+	sort.SliceStable(c.report.Drift, func(i, j int) bool {
+		a, b := c.report.Drift[i], c.report.Drift[j]
+		if a.Doc != b.Doc {
+			return a.Doc < b.Doc
+		}
+		return a.Line < b.Line
+	})
+	return c.report, nil
+}
+
+var typeKinds = map[string]bool{"class": true, "struct": true, "enum": true, "protocol": true, "typealias": true, "actor": true}
+
+// topDir returns the Sources or Tests directory holding rel, e.g.
+// "Sources/Core" for "Sources/Core/Sources/Core.swift".
+func topDir(rel string) string {
+	parts := strings.SplitN(rel, "/", 3)
+	if len(parts) < 3 || parts[0] != "Sources" && parts[0] != "Tests" {
+		return ""
+	}
+	return parts[0] + "/" + parts[1]
+}
+
+func (c *checker) scanDoc(rel string) error {
+	f, err := os.Open(filepath.Join(c.root, rel))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var (
+		block     []string
+		blockLine int
+		lang      string
+		marker    string
+	)
+	scanner := textscan.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if m := fence.FindStringSubmatch(line); m != nil && (marker == "" || strings.HasPrefix(strings.TrimSpace(line), marker)) {
+			if marker == "" {
+				marker, lang, block, blockLine = m[1], strings.ToLower(m[2]), nil, lineNo+1
+			} else {
+				c.checkBlock(rel, blockLine, lang, block)
+				marker = ""
+			}
+			continue
+		}
+		if marker != "" {
+			block = append(block, line)
+			continue
+		}
+
+		c.checkPaths(rel, lineNo, line)
+		if m := moduleHeading.FindStringSubmatch(line); m != nil {
+			c.checkModule(rel, lineNo, m[1])
+		}
+		for _, m := range inlineCode.FindAllStringSubmatch(line, -1) {
+			span := strings.TrimSpace(m[2])
+			if fileName.MatchString(span) || strings.Contains(span, "/") {
+				continue
+			}
+			c.checkCode(rel, lineNo, swiftlex.Code(span), nil)
+		}
+	}
+	if err := textscan.Check(rel, lineNo, scanner.Err()); err != nil {
+		return err
+	}
+	if marker != "" {
+		c.checkBlock(rel, blockLine, lang, block)
+	}
+	return nil
+}
+
+// checkBlock checks a fenced code block whose first line is line. Only
+// Swift blocks are read as Swift; in others, often directory trees, only
+// source directories are references.
+func (c *checker) checkBlock(doc string, line int, lang string, block []string) {
+	for i, l := range block {
+		c.checkPaths(doc, line+i, l)
+	}
+	if lang != "swift" {
+		return
+	}
+	toks := swiftlex.Code(strings.Join(block, "\n"))
+	for i := range toks {
+		toks[i].Line += line - 1
+	}
+	local := make(map[string]bool)
+	for i, t := range toks {
+		if t.Kind == swiftlex.Ident && (typeWords[t.Text] && t.Text != "extension" || t.Text == "typealias") && i+1 < len(toks) {
+			local[toks[i+1].Text] = true
+		}
+		if t.Is(swiftlex.Ident, "import") && i+1 < len(toks) && toks[i+1].Kind == swiftlex.Ident && toks[i+1].Line == t.Line {
+			c.checkModule(doc, t.Line, toks[i+1].Text)
+		}
+	}
+	c.checkCode(doc, 0, toks, local)
+}
+
+// checkCode checks the qualified names among toks, which put line numbers
+// on them unless line is set.
+func (c *checker) checkCode(doc string, line int, toks []swiftlex.Token, local map[string]bool) {
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		if t.Kind != swiftlex.Ident || i > 0 && (toks[i-1].Is(swiftlex.Punct, ".") || toks[i-1].Is(swiftlex.Ident, "import")) {
+			continue
+		}
+		parts := []string{t.Text}
+		for i+2 < len(toks) && toks[i+1].Is(swiftlex.Punct, ".") && !toks[i+1].Space && toks[i+2].Kind == swiftlex.Ident && !toks[i+2].Space {
+			parts = append(parts, toks[i+2].Text)
+			i += 2
+		}
+		at := line
+		if at == 0 {
+			at = t.Line
+		}
+		if !local[parts[0]] {
+			c.checkName(doc, at, parts)
+		}
+	}
+}
+
+// checkName checks a name and the names qualified by it, e.g. the parts
+// of "SecurityProtocolsCore.SecurityProviderProtocol".
+func (c *checker) checkName(doc string, line int, parts []string) {
+	first := parts[0]
+	if !isTypeName(first) || c.ignore[first] || c.top[first] || systemModules[first] {
+		return
+	}
+	if c.ix.modules[first] || c.modules[first] != "" {
+		c.checkModule(doc, line, first)
+		if len(parts) > 1 && isTypeName(parts[1]) {
+			c.checkType(doc, line, first, parts[1:])
+		}
+		return
+	}
+	c.checkType(doc, line, "", parts)
+}
+
+// checkType checks a type, declared in module when that is set, and an
+// enum case or member after it.
+func (c *checker) checkType(doc string, line int, module string, parts []string) {
+	name := parts[0]
+	if c.ignore[name] || module == "" && isSystemType(name) {
+		return
+	}
+	ref := strings.Join(parts[:min(len(parts), 2)], ".")
+	if module != "" {
+		ref = module + "." + ref
+	}
+	c.report.References++
+	if to := c.renamed[name]; to != "" {
+		c.add(Drift{Doc: doc, Line: line, Ref: ref, Kind: RefType, Drift: DriftRenamed, Suggestion: to, Reason: "deprecated with renamed: " + to})
+		return
+	}
+	declared := c.ix.types[name]
+	switch {
+	case len(declared) == 0:
+		c.add(Drift{Doc: doc, Line: line, Ref: ref, Kind: RefType, Drift: DriftMissing, Reason: "no type " + name + " is declared"})
+		return
+	case module != "" && !declared[module] && c.modules[module] == "":
+		in := sortedKeys(declared)
+		c.add(Drift{Doc: doc, Line: line, Ref: ref, Kind: RefType, Drift: DriftMoved, Suggestion: in[0] + "." + name,
+			Reason: name + " is declared in " + strings.Join(in, ", ")})
+		return
+	}
+	if msg, ok := c.message[name]; ok {
+		c.add(Drift{Doc: doc, Line: line, Ref: ref, Kind: RefType, Drift: DriftDeprecated, Reason: deprecatedReason(msg)})
+		return
+	}
+	if len(parts) > 1 && c.ix.enums[name] && !isTypeName(parts[1]) {
+		c.report.References++
+		if !c.ix.members[name][parts[1]] {
+			c.add(Drift{Doc: doc, Line: line, Ref: ref, Kind: RefMember, Drift: DriftMissing, Reason: "enum " + name + " has no case or member " + parts[1]})
+		}
+	}
+}
+
+// checkModule checks a module name.
+func (c *checker) checkModule(doc string, line int, name string) {
+	if systemModules[name] || c.ignore[name] {
+		return
+	}
+	c.report.References++
+	switch {
+	case c.modules[name] != "":
+		c.add(Drift{Doc: doc, Line: line, Ref: name, Kind: RefModule, Drift: DriftRenamed, Suggestion: c.modules[name], Reason: "imports of " + name + " are rewritten to " + c.modules[name]})
+	case !c.ix.modules[name]:
+		d := Drift{Doc: doc, Line: line, Ref: name, Kind: RefModule, Drift: DriftMissing, Reason: "no target compiles module " + name}
+		for _, top := range []string{"Sources/", "Tests/"} {
+			if to := c.dirs[top+name]; to != "" {
+				d.Drift, d.Suggestion, d.Reason = DriftMoved, path.Base(to), "its sources moved to "+to
+			}
+		}
+		c.add(d)
+	}
+}
+
+// checkPaths checks the Sources and Tests directories line mentions.
+func (c *checker) checkPaths(doc string, line int, text string) {
+	for _, m := range sourcePath.FindAllStringSubmatch(text, -1) {
+		dir := m[1]
+		c.report.References++
+		if _, err := os.Stat(filepath.Join(c.root, filepath.FromSlash(dir))); err == nil {
+			continue
+		}
+		d := Drift{Doc: doc, Line: line, Ref: dir, Kind: RefPath, Drift: DriftMissing, Reason: "the directory does not exist"}
+		if to := c.dirs[dir]; to != "" {
+			d.Drift, d.Suggestion, d.Reason = DriftMoved, to, "moved to "+to+" in moves.log"
+		} else if to := c.modules[path.Base(dir)]; to != "" {
+			d.Drift, d.Suggestion, d.Reason = DriftRenamed, path.Dir(dir)+"/"+to, "imports of "+path.Base(dir)+" are rewritten to "+to
+		}
+		c.add(d)
+	}
+}
+
+type seenKey struct {
+	doc, ref string
+	line     int
+}
+
+// add records d once per doc, line and reference.
+func (c *checker) add(d Drift) {
+	key := seenKey{d.Doc, d.Ref, d.Line}
+	if c.seen[key] {
+		return
+	}
+	c.seen[key] = true
+	c.report.Drift = append(c.report.Drift, d)
+}
+
+func deprecatedReason(msg string) string {
+	if msg == "" {
+		return "deprecated"
+	}
+	return "deprecated: " + msg
+}
+
+// isTypeName reports whether s reads as a type or module name: an
+// identifier starting with a capital and not all capitals.
+func isTypeName(s string) bool {
+	return len(s) > 1 && s[0] >= 'A' && s[0] <= 'Z' && strings.ToUpper(s) != s
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
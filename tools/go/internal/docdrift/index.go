@@ -0,0 +1,203 @@
+package docdrift
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftlex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+var (
+	typeWords   = map[string]bool{"class": true, "struct": true, "enum": true, "protocol": true, "actor": true, "extension": true}
+	memberWords = map[string]bool{"func": true, "var": true, "let": true, "associatedtype": true}
+	// modifiers may follow "class", as in "class func", where it is not a
+	// type declaration.
+	modifiers = map[string]bool{"func": true, "var": true, "let": true, "subscript": true, "init": true, "static": true, "final": true, "override": true}
+)
+
+// index is what the tree declares, at every access level.
+type index struct {
+	modules map[string]bool
+	// types maps each declared type and typealias to the modules
+	// declaring it.
+	types map[string]map[string]bool
+	enums map[string]bool
+	// members maps each type, extended ones included, to the names its
+	// bodies declare: cases, functions, properties and nested types.
+	members map[string]map[string]bool
+}
+
+// decls is what one file declares.
+type decls struct {
+	module  string
+	types   []string
+	enums   []string
+	members [][2]string
+}
+
+func buildIndex(root string, dirs []string, rules []modulenames.Rule) (*index, error) {
+	ix := &index{
+		modules: make(map[string]bool),
+		types:   make(map[string]map[string]bool),
+		enums:   make(map[string]bool),
+		members: make(map[string]map[string]bool),
+	}
+	for _, r := range rules {
+		ix.modules[r.ModuleName] = true
+	}
+
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	modules := &moduleindex.Index{Modules: rules}
+	files, err := pool.Map(paths, func(rel string) (decls, error) {
+		data, err := os.ReadFile(filepath.Join(root, rel))
+		if err != nil {
+			return decls{}, err
+		}
+		d := declare(swiftlex.Code(string(data)))
+		d.module = workspace.ModuleForPath(rel)
+		if r, ok := modules.ForPath(rel); ok {
+			d.module = r.ModuleName
+		}
+		return d, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range files {
+		for _, t := range d.types {
+			if ix.types[t] == nil {
+				ix.types[t] = make(map[string]bool)
+			}
+			ix.types[t][d.module] = true
+		}
+		for _, e := range d.enums {
+			ix.enums[e] = true
+		}
+		for _, m := range d.members {
+			if ix.members[m[0]] == nil {
+				ix.members[m[0]] = make(map[string]bool)
+			}
+			ix.members[m[0]][m[1]] = true
+		}
+	}
+	return ix, nil
+}
+
+// declare returns the types toks declare, and the members declared
+// directly in the body of each type or extension. Bodies of functions,
+// properties and closures declare nothing.
+func declare(toks []swiftlex.Token) decls {
+	type frame struct {
+		// typ is the type whose body this is, empty for other bodies.
+		typ   string
+		depth int
+	}
+	var (
+		d       decls
+		stack   []frame
+		pending string
+		depth   int
+	)
+	owner := func() string {
+		if len(stack) == 0 {
+			return ""
+		}
+		return stack[len(stack)-1].typ
+	}
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		switch {
+		case t.Is(swiftlex.Punct, "{"):
+			depth++
+			stack = append(stack, frame{typ: pending, depth: depth})
+			pending = ""
+		case t.Is(swiftlex.Punct, "}"):
+			for len(stack) > 0 && stack[len(stack)-1].depth >= depth {
+				stack = stack[:len(stack)-1]
+			}
+			depth--
+			pending = ""
+		case t.Kind != swiftlex.Ident || i > 0 && toks[i-1].Is(swiftlex.Punct, "."):
+		case typeWords[t.Text] && i+1 < len(toks) && toks[i+1].Kind == swiftlex.Ident && !modifiers[toks[i+1].Text]:
+			name := toks[i+1].Text
+			// An extension of a nested type, as in "extension A.B".
+			for i+3 < len(toks) && toks[i+2].Is(swiftlex.Punct, ".") && toks[i+3].Kind == swiftlex.Ident {
+				i += 2
+				name = toks[i+1].Text
+			}
+			if t.Text != "extension" {
+				d.types = append(d.types, name)
+				if o := owner(); o != "" {
+					d.members = append(d.members, [2]string{o, name})
+				}
+			}
+			if t.Text == "enum" {
+				d.enums = append(d.enums, name)
+			}
+			pending = name
+			i++
+		case t.Text == "typealias" && i+1 < len(toks) && toks[i+1].Kind == swiftlex.Ident:
+			d.types = append(d.types, toks[i+1].Text)
+			if o := owner(); o != "" {
+				d.members = append(d.members, [2]string{o, toks[i+1].Text})
+			}
+		case owner() == "":
+		case t.Text == "case":
+			for _, name := range caseNames(toks, i+1) {
+				d.members = append(d.members, [2]string{owner(), name})
+			}
+		case memberWords[t.Text] && i+1 < len(toks) && toks[i+1].Kind == swiftlex.Ident:
+			d.members = append(d.members, [2]string{owner(), toks[i+1].Text})
+		}
+	}
+	return d
+}
+
+// caseNames returns the names of an enum case declaration whose first name
+// is toks[i], as in "case a, b(String), c = 2".
+func caseNames(toks []swiftlex.Token, i int) []string {
+	var names []string
+	for i < len(toks) && toks[i].Kind == swiftlex.Ident {
+		names = append(names, toks[i].Text)
+		line := toks[i].Line
+		i++
+		parens := 0
+		for ; i < len(toks); i++ {
+			t := toks[i]
+			if parens == 0 && (t.Is(swiftlex.Punct, ",") || t.Line != line || t.Is(swiftlex.Punct, "{") || t.Is(swiftlex.Punct, "}")) {
+				break
+			}
+			switch {
+			case t.Is(swiftlex.Punct, "("):
+				parens++
+			case t.Is(swiftlex.Punct, ")"):
+				parens--
+			}
+		}
+		if i >= len(toks) || !toks[i].Is(swiftlex.Punct, ",") {
+			break
+		}
+		i++
+	}
+	return names
+}
@@ -0,0 +1,42 @@
+package docdrift
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes the drift found in each doc as a table.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	b.WriteString("# Docs Drift\n\n")
+	fmt.Fprintf(&b, "%d references in %d docs checked: %d drifted.\n", r.References, r.Docs, len(r.Drift))
+	if len(r.Drift) == 0 {
+		b.WriteString("\nEvery reference matches the tree.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	for i, d := range r.Drift {
+		if i == 0 || d.Doc != r.Drift[i-1].Doc {
+			fmt.Fprintf(&b, "\n## %s\n\n", d.Doc)
+			b.WriteString("| Line | Reference | Kind | Drift | Suggestion | Reason |\n")
+			b.WriteString("|------|-----------|------|-------|------------|--------|\n")
+		}
+		suggestion := ""
+		if d.Suggestion != "" {
+			suggestion = "`" + d.Suggestion + "`"
+		}
+		fmt.Fprintf(&b, "| %d | `%s` | %s | %s | %s | %s |\n", d.Line, d.Ref, d.Kind, d.Drift, suggestion, strings.ReplaceAll(d.Reason, "|", `\|`))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,55 @@
+package docdrift
+
+import "strings"
+
+// systemModules are the Apple and Swift modules docs import besides the
+// workspace's.
+var systemModules = words(`
+	AppKit Combine CoreData CoreFoundation CryptoKit Darwin Dispatch
+	Foundation IOKit LocalAuthentication Network OSLog ObjectiveC Observation
+	Security ServiceManagement Swift SwiftUI UniformTypeIdentifiers XCTest
+	XPC os
+`)
+
+// systemTypes are the standard library and SDK types docs use without
+// qualification. SDK types with a framework prefix are told by
+// systemPrefixes instead.
+var systemTypes = words(`
+	AES AnyHashable AnyObject AnyPublisher Any Array AsyncStream
+	AsyncThrowingStream Bool Bundle Calendar Character CheckedContinuation
+	ChaChaPoly Codable Collection Comparable CustomStringConvertible Data Date
+	DateFormatter Decodable Decoder Dictionary DispatchQueue Double Encodable
+	Encoder Equatable Error FileHandle FileManager Float HMAC Hashable
+	ISO8601DateFormatter Identifiable Int Int16 Int32 Int64 Int8
+	JSONDecoder JSONEncoder Locale Logger MainActor Never Notification
+	NotificationCenter OSLog ObservableObject Optional PassthroughSubject Pipe
+	Process ProcessInfo PropertyListDecoder PropertyListEncoder Published
+	Result SHA256 SHA512 Self Sendable Sequence Set String Substring
+	SymmetricKey Task TimeInterval Type UInt UInt16 UInt32 UInt64 UInt8 URL
+	UUID Void
+`)
+
+// systemPrefixes start the names of Objective-C and C SDK types, as in
+// NSXPCConnection, SecKey and XCTestCase.
+var systemPrefixes = []string{"NS", "CF", "Sec", "XCT", "kSec", "Dispatch", "Unsafe"}
+
+func words(s string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+// isSystemType reports whether name is a standard library or SDK type.
+func isSystemType(name string) bool {
+	if systemTypes[name] {
+		return true
+	}
+	for _, p := range systemPrefixes {
+		if rest, ok := strings.CutPrefix(name, p); ok && rest != "" && rest[0] >= 'A' && rest[0] <= 'Z' {
+			return true
+		}
+	}
+	return false
+}
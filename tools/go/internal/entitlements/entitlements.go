@@ -0,0 +1,233 @@
+// Package entitlements validates the .entitlements files of the tree
+// against a policy: the entitlements each target type must and may have,
+// and the security-sensitive ones that need an allowlist entry.
+package entitlements
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/plist"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Issue kinds.
+const (
+	IssueMissing      = "missing"
+	IssueWrongValue   = "wrong_value"
+	IssueSensitive    = "sensitive"
+	IssueExtra        = "extra"
+	IssueUnclassified = "unclassified"
+	IssueInvalid      = "invalid"
+	IssueStaleAllow   = "stale_allowlist"
+)
+
+var severity = map[string]string{
+	IssueMissing:      "error",
+	IssueWrongValue:   "error",
+	IssueSensitive:    "error",
+	IssueInvalid:      "error",
+	IssueExtra:        "warning",
+	IssueUnclassified: "warning",
+	IssueStaleAllow:   "warning",
+}
+
+// Issue is one policy violation.
+type Issue struct {
+	Kind        string `json:"kind"`
+	Severity    string `json:"severity"`
+	Module      string `json:"module"`
+	File        string `json:"file"`
+	Entitlement string `json:"entitlement,omitempty"`
+	Message     string `json:"message"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// File is one entitlements file and the target type it was checked as.
+type File struct {
+	Path   string `json:"path"`
+	Module string `json:"module"`
+	// Type is empty for files whose type is unknown.
+	Type string `json:"type"`
+	// Entitlements are the file's keys, sorted.
+	Entitlements []string `json:"entitlements"`
+}
+
+// Report is the result of Check.
+type Report struct {
+	Files  []File  `json:"files"`
+	Issues []Issue `json:"issues"`
+}
+
+// Check validates every .entitlements file below root. A file's type is
+// the one the policy's targets assign, else the one the Info.plist beside
+// it implies (CFBundlePackageType XPC! for an XPC service, APPL for an
+// app, SMAuthorizedClients for a privileged helper), else test for files
+// below Tests.
+func Check(root string, policy *Policy) (Report, error) {
+	var paths []string
+	err := walker.Walk(root, walker.Options{Extensions: []string{".entitlements"}}, func(rel string) error {
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return Report{}, err
+	}
+	sort.Strings(paths)
+
+	report := Report{Files: []File{}, Issues: []Issue{}}
+	used := make(map[int]bool)
+	add := func(kind, rel, entitlement, format string, args ...any) {
+		report.Issues = append(report.Issues, Issue{Kind: kind, Severity: severity[kind], Module: workspace.ModuleForPath(rel),
+			File: rel, Entitlement: entitlement, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, rel := range paths {
+		dict, err := readDict(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			add(IssueInvalid, rel, "", "%v", err)
+			continue
+		}
+		f := File{Path: rel, Module: workspace.ModuleForPath(rel), Type: policy.targetType(rel), Entitlements: []string{}}
+		if f.Type == "" {
+			f.Type = inferType(root, rel)
+		}
+		for key := range dict {
+			f.Entitlements = append(f.Entitlements, key)
+		}
+		sort.Strings(f.Entitlements)
+		report.Files = append(report.Files, f)
+
+		for _, key := range f.Entitlements {
+			if !policy.sensitive(key) || dict[key] == false {
+				continue
+			}
+			if i, ok := policy.allowed(rel, key); ok {
+				used[i] = true
+			} else {
+				add(IssueSensitive, rel, key, "grants security-sensitive %s without an allowlist entry", key)
+			}
+		}
+
+		t, ok := policy.Types[f.Type]
+		if !ok {
+			add(IssueUnclassified, rel, "", "no target type; add its path to the policy's targets")
+			continue
+		}
+		for _, key := range sortedKeys(t.Required) {
+			want := t.Required[key]
+			got, present := dict[key]
+			switch {
+			case !present:
+				add(IssueMissing, rel, key, "lacks %s, which every %s target needs", key, f.Type)
+			case want != nil && !equal(want, got):
+				add(IssueWrongValue, rel, key, "%s is %v, want %v", key, got, want)
+			}
+		}
+		for _, key := range f.Entitlements {
+			// Sensitive entitlements are reported above, and ones set to
+			// false grant nothing.
+			if _, required := t.Required[key]; required || matchAny(t.Allowed, key) || policy.sensitive(key) || dict[key] == false {
+				continue
+			}
+			if _, ok := policy.allowed(rel, key); ok {
+				continue
+			}
+			add(IssueExtra, rel, key, "has %s, which the policy does not allow for %s targets", key, f.Type)
+		}
+	}
+
+	for i, a := range policy.Allowlist {
+		if !used[i] {
+			add(IssueStaleAllow, a.File, a.Entitlement, "allowlist entry for %s matches no sensitive entitlement", a.Entitlement)
+		}
+	}
+	sort.SliceStable(report.Issues, func(i, j int) bool { return report.Issues[i].File < report.Issues[j].File })
+	return report, nil
+}
+
+// readDict reads an entitlements file, whose top-level value must be a
+// dictionary.
+func readDict(file string) (map[string]any, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	v, err := plist.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("top-level value is not a dictionary")
+	}
+	return dict, nil
+}
+
+// inferType returns the target type of rel's Info.plist, or test for
+// files below Tests.
+func inferType(root, rel string) string {
+	if info, err := plist.DecodeDict(filepath.Join(root, filepath.FromSlash(path.Dir(rel)), "Info.plist")); err == nil {
+		if _, ok := info["SMAuthorizedClients"]; ok {
+			return TypeHelper
+		}
+		switch info["CFBundlePackageType"] {
+		case "XPC!":
+			return TypeXPCService
+		case "APPL":
+			return TypeApp
+		}
+	}
+	if strings.HasPrefix(rel, "Tests/") {
+		return TypeTest
+	}
+	return ""
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// equal compares a value from the policy with one from a property list.
+func equal(want, got any) bool {
+	switch w := want.(type) {
+	case int:
+		g, ok := got.(int64)
+		return ok && int64(w) == g
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(w) != len(g) {
+			return false
+		}
+		for i := range w {
+			if !equal(w[i], g[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok || len(w) != len(g) {
+			return false
+		}
+		for k, v := range w {
+			if !equal(v, g[k]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return want == got
+	}
+}
@@ -0,0 +1,81 @@
+package entitlements
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testfixture"
+)
+
+const policySource = `types:
+  xpc-service:
+    required:
+      com.apple.security.app-sandbox: true
+      com.apple.security.network.client: true
+    allowed: [com.apple.security.files.user-selected.read-only]
+  app:
+    required:
+      com.apple.security.app-sandbox: true
+targets:
+  - path: Sources/UmbraCryptoService
+    type: xpc-service
+  - path: Sources/UmbraApp
+    type: app
+sensitive: ["com.apple.security.cs.*"]
+allowlist:
+  - file: Sources/UmbraApp/Gone.entitlements
+    entitlement: com.apple.security.cs.allow-jit
+    reason: Removed since.
+`
+
+const serviceEntitlements = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>com.apple.security.cs.disable-library-validation</key>
+	<true/>
+	<key>com.apple.security.temporary-exception.mach-lookup.global-name</key>
+	<array><string>com.umbra.agent</string></array>
+	<key>com.apple.security.device.usb</key>
+	<true/>
+</dict>
+</plist>
+`
+
+func TestReportIsReproducible(t *testing.T) {
+	root := testfixture.Write(t, map[string]string{
+		PolicyFile: policySource,
+		"Sources/UmbraCryptoService/Resources/UmbraCryptoService.entitlements": serviceEntitlements,
+		"Sources/UmbraApp/UmbraApp.entitlements":                               serviceEntitlements,
+	})
+	testfixture.Reproducible(t, func() []byte {
+		policy, err := LoadPolicy(filepath.Join(root, PolicyFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		report, err := Check(root, policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	})
+}
+
+// A policy with several bad types names the same one on every load.
+func TestPolicyErrorIsReproducible(t *testing.T) {
+	root := testfixture.Write(t, map[string]string{
+		PolicyFile: "types:\n  helper:\n    allowed: [\"[\"]\n  app:\n    allowed: [\"[\"]\n  test:\n    allowed: [\"[\"]\n",
+	})
+	testfixture.Reproducible(t, func() []byte {
+		_, err := LoadPolicy(filepath.Join(root, PolicyFile))
+		if err == nil {
+			t.Fatal("LoadPolicy accepted bad patterns")
+		}
+		return []byte(err.Error())
+	})
+}
@@ -0,0 +1,160 @@
+package entitlements
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/configschema"
+)
+
+// Target types.
+const (
+	TypeApp        = "app"
+	TypeXPCService = "xpc-service"
+	TypeHelper     = "helper"
+	TypeTest       = "test"
+)
+
+// PolicyFile is the default policy, relative to the project root.
+const PolicyFile = "entitlements_policy.yaml"
+
+// Policy is the entitlements policy, read from entitlements_policy.yaml:
+//
+//	types:
+//	  xpc-service:
+//	    required:
+//	      com.apple.security.app-sandbox: true
+//	    allowed: [com.apple.security.network.client]
+//	targets:
+//	  - path: Sources/UmbraCryptoService
+//	    type: xpc-service
+//	sensitive: ["com.apple.security.cs.*", "com.apple.security.temporary-exception.*"]
+//	allowlist:
+//	  - file: Sources/UmbraCryptoService/Resources/UmbraCryptoService.entitlements
+//	    entitlement: com.apple.security.temporary-exception.files.home-relative-path.read-write
+//	    reason: Keys are kept in Application Support.
+//
+// Entitlement names in allowed and sensitive, and target paths, may be
+// path.Match globs; a target path also matches every file below it.
+type Policy struct {
+	Types     map[string]TypePolicy `yaml:"types"`
+	Targets   []Target              `yaml:"targets"`
+	Sensitive []string              `yaml:"sensitive"`
+	Allowlist []Allow               `yaml:"allowlist"`
+}
+
+// TypePolicy lists the entitlements of one target type.
+type TypePolicy struct {
+	// Required maps each entitlement a target must have to its expected
+	// value; a null value accepts any.
+	Required map[string]any `yaml:"required"`
+	// Allowed are the other entitlements a target may have.
+	Allowed []string `yaml:"allowed"`
+}
+
+// Target assigns a type to the entitlements files below a path, for
+// targets whose type cannot be inferred.
+type Target struct {
+	Path string `yaml:"path"`
+	Type string `yaml:"type"`
+}
+
+// Allow approves one security-sensitive entitlement in one file.
+type Allow struct {
+	File        string `yaml:"file"`
+	Entitlement string `yaml:"entitlement"`
+	Reason      string `yaml:"reason"`
+}
+
+// LoadPolicy reads and validates a policy file.
+func LoadPolicy(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := configschema.Validate(configschema.KindEntitlements, file, data); err != nil {
+		return nil, err
+	}
+
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for _, name := range sortedKeys(p.Types) {
+		for _, pattern := range p.Types[name].Allowed {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("%s: type %s: bad pattern %q: %w", file, name, pattern, err)
+			}
+		}
+	}
+	for _, t := range p.Targets {
+		if _, ok := p.Types[t.Type]; !ok {
+			return nil, fmt.Errorf("%s: target %s: unknown type %q", file, t.Path, t.Type)
+		}
+		if _, err := path.Match(t.Path, ""); err != nil {
+			return nil, fmt.Errorf("%s: target %s: bad pattern: %w", file, t.Path, err)
+		}
+	}
+	for _, pattern := range p.Sensitive {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s: bad sensitive pattern %q: %w", file, pattern, err)
+		}
+	}
+	for _, a := range p.Allowlist {
+		if a.File == "" || a.Entitlement == "" {
+			return nil, fmt.Errorf("%s: allowlist entries need a file and an entitlement", file)
+		}
+		if strings.TrimSpace(a.Reason) == "" {
+			return nil, fmt.Errorf("%s: allowlist entry for %s in %s has no reason", file, a.Entitlement, a.File)
+		}
+	}
+	return &p, nil
+}
+
+// targetType returns the type the policy assigns to rel, the longest
+// matching target path winning.
+func (p *Policy) targetType(rel string) string {
+	best, bestLen := "", -1
+	for _, t := range p.Targets {
+		if !matchPath(t.Path, rel) || len(t.Path) <= bestLen {
+			continue
+		}
+		best, bestLen = t.Type, len(t.Path)
+	}
+	return best
+}
+
+func (p *Policy) sensitive(entitlement string) bool {
+	return matchAny(p.Sensitive, entitlement)
+}
+
+// allowed returns the allowlist entry approving entitlement in rel.
+func (p *Policy) allowed(rel, entitlement string) (int, bool) {
+	for i, a := range p.Allowlist {
+		if matchPath(a.File, rel) && a.Entitlement == entitlement {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func matchPath(pattern, rel string) bool {
+	pattern = strings.TrimSuffix(pattern, "/")
+	if ok, _ := path.Match(pattern, rel); ok {
+		return true
+	}
+	return strings.HasPrefix(rel, pattern+"/")
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
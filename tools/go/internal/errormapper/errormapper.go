@@ -0,0 +1,277 @@
+// Package errormapper checks that the switch statements of a central error
+// mapper cover every case of the error enums they map, using the error
+// definitions found by the error analysis.
+package errormapper
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errorreport"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+)
+
+// Issue kinds.
+const (
+	// IssueUnmapped is an enum case no switch over the enum names.
+	IssueUnmapped = "unmapped"
+	// IssueStale is a switch case naming a case the enum no longer has.
+	IssueStale = "stale"
+)
+
+// Switch is one switch statement in a mapper file.
+type Switch struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"function"`
+	Subject  string `json:"subject"`
+	// SubjectType is the declared type of the subject, when it is a
+	// parameter of the enclosing function.
+	SubjectType string `json:"subjectType,omitempty"`
+	// Cases maps each case name matched to its line.
+	Cases      map[string]int `json:"cases"`
+	HasDefault bool           `json:"hasDefault"`
+}
+
+// Coverage is how one switch covers the enum it was matched to.
+type Coverage struct {
+	Switch Switch `json:"switch"`
+	// Enum is the error definition switched over.
+	Enum     errorreport.Definition `json:"enum"`
+	Unmapped []string               `json:"unmapped"`
+	Stale    []string               `json:"stale"`
+}
+
+// Issue is one unmapped or stale case.
+type Issue struct {
+	Kind        string `json:"kind"`
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Enum        string `json:"enum"`
+	Case        string `json:"case"`
+	Message     string `json:"message"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+var (
+	switchPattern = regexp.MustCompile(`(?:^|[^\w.])switch\s+([A-Za-z_][\w.]*)\s*\{`)
+	funcPattern   = regexp.MustCompile(`(?:^|\s)func\s+([^\s(<]+)\s*(?:<[^(]*>)?\s*\(([^)]*)`)
+	casePattern   = regexp.MustCompile(`^case\s+(.+?)\s*:(?:\s|$)`)
+	patternName   = regexp.MustCompile(`^(?:let\s+|var\s+)?(?:[A-Za-z_][\w.]*)?\.([A-Za-z_]\w*)`)
+)
+
+// ParseSwitches returns the switch statements of the Swift file rel.
+func ParseSwitches(root, rel string) ([]Switch, error) {
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	type open struct {
+		sw        *Switch
+		bodyDepth int
+	}
+	var (
+		switches  []*Switch
+		stack     []open
+		function  string
+		params    map[string]string
+		depth     int
+		inComment bool
+		lineNo    int
+	)
+	scanner := textscan.NewScanner(f)
+	for scanner.Scan() {
+		lineNo++
+		var code string
+		code, inComment = swiftsrc.StripComments(scanner.Text(), inComment)
+		code = strings.TrimSpace(code)
+
+		if m := funcPattern.FindStringSubmatch(code); m != nil {
+			function, params = m[1], parseParams(m[2])
+		}
+		if n := len(stack); n > 0 && depth == stack[n-1].bodyDepth {
+			sw := stack[n-1].sw
+			if m := casePattern.FindStringSubmatch(code); m != nil {
+				for _, name := range patternNames(m[1]) {
+					if _, seen := sw.Cases[name]; !seen {
+						sw.Cases[name] = lineNo
+					}
+				}
+			} else if strings.HasPrefix(code, "default:") || code == "default" {
+				sw.HasDefault = true
+			}
+		}
+		if m := switchPattern.FindStringSubmatch(code); m != nil {
+			sw := &Switch{File: rel, Line: lineNo, Function: function, Subject: m[1], SubjectType: params[m[1]], Cases: make(map[string]int)}
+			switches = append(switches, sw)
+			stack = append(stack, open{sw: sw, bodyDepth: depth + 1})
+		}
+
+		depth += strings.Count(code, "{") - strings.Count(code, "}")
+		for len(stack) > 0 && depth < stack[len(stack)-1].bodyDepth {
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	out := make([]Switch, 0, len(switches))
+	for _, sw := range switches {
+		out = append(out, *sw)
+	}
+	return out, textscan.Check(rel, lineNo, scanner.Err())
+}
+
+// parseParams maps the internal parameter names of a parameter list to
+// their types, e.g. "_ error: SecurityError" to {"error": "SecurityError"}.
+func parseParams(list string) map[string]string {
+	params := make(map[string]string)
+	for _, p := range strings.Split(list, ",") {
+		names, typ, ok := strings.Cut(p, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(names)
+		if len(fields) == 0 {
+			continue
+		}
+		typ = strings.TrimSpace(typ)
+		if i := strings.IndexAny(typ, " =<"); i >= 0 {
+			typ = typ[:i]
+		}
+		params[fields[len(fields)-1]] = strings.TrimSuffix(typ, "?")
+	}
+	return params
+}
+
+// patternNames returns the enum case names matched by a case label such as
+// "let .invalidKey(reason), .expired".
+func patternNames(label string) []string {
+	if i := strings.Index(label, " where "); i >= 0 {
+		label = label[:i]
+	}
+	var names []string
+	for _, part := range splitTopLevel(label) {
+		if m := patternName.FindStringSubmatch(strings.TrimSpace(part)); m != nil {
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// Check matches every switch to the enum among enums it most plausibly
+// switches over and reports the cases it misses and the cases it names
+// that the enum lacks. A switch whose subject is a parameter is only
+// matched to enums of the parameter's type name. Switches that match no
+// enum case at all are ignored.
+func Check(switches []Switch, enums []errorreport.Definition) ([]Coverage, []Issue) {
+	var coverage []Coverage
+	var issues []Issue
+	for _, sw := range switches {
+		enum, ok := match(sw, enums)
+		if !ok {
+			continue
+		}
+		known := make(map[string]bool, len(enum.Cases))
+		for _, c := range enum.Cases {
+			known[c] = true
+		}
+
+		c := Coverage{Switch: sw, Enum: enum}
+		for _, name := range enum.Cases {
+			if _, mapped := sw.Cases[name]; mapped {
+				continue
+			}
+			c.Unmapped = append(c.Unmapped, name)
+			msg := sw.Function + " does not map " + enum.Name + "." + name
+			if sw.HasDefault {
+				msg += "; it falls through to default"
+			}
+			issues = append(issues, Issue{Kind: IssueUnmapped, File: sw.File, Line: sw.Line, Enum: enum.Name, Case: name, Message: msg})
+		}
+		for name, line := range sw.Cases {
+			if !known[name] {
+				c.Stale = append(c.Stale, name)
+				issues = append(issues, Issue{Kind: IssueStale, File: sw.File, Line: line, Enum: enum.Name, Case: name,
+					Message: sw.Function + " maps ." + name + ", which " + enum.Name + " (" + enum.File + ") no longer declares"})
+			}
+		}
+		sort.Strings(c.Stale)
+		coverage = append(coverage, c)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Case < issues[j].Case
+	})
+	return coverage, issues
+}
+
+// match returns the enum sharing the most case names with sw.
+func match(sw Switch, enums []errorreport.Definition) (errorreport.Definition, bool) {
+	typeName := sw.SubjectType
+	if i := strings.LastIndex(typeName, "."); i >= 0 {
+		typeName = typeName[i+1:]
+	}
+
+	var best errorreport.Definition
+	bestOverlap := 0
+	for _, e := range enums {
+		if typeName != "" && e.Name != typeName {
+			continue
+		}
+		overlap := 0
+		for _, c := range e.Cases {
+			if _, ok := sw.Cases[c]; ok {
+				overlap++
+			}
+		}
+		if overlap > bestOverlap {
+			best, bestOverlap = e, overlap
+		}
+	}
+	return best, bestOverlap > 0
+}
+
+// Enums returns the enum definitions in r whose name is one of names.
+func Enums(r *errorreport.Report, names []string) []errorreport.Definition {
+	want := make(map[string]bool, len(names))
+	for _, n := range names {
+		want[n] = true
+	}
+	var enums []errorreport.Definition
+	for _, d := range r.Definitions {
+		if d.Enum && want[d.Name] && len(d.Cases) > 0 {
+			enums = append(enums, d)
+		}
+	}
+	return enums
+}
@@ -0,0 +1,64 @@
+package errormapper
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes one section per switch with its unmapped and stale
+// cases.
+func WriteMarkdown(w io.Writer, coverage []Coverage) error {
+	var b strings.Builder
+	b.WriteString("# Error Mapper Completeness Report\n\n")
+	if len(coverage) == 0 {
+		b.WriteString("No switch over a checked error enum was found.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	b.WriteString("| Switch | Enum | Cases | Mapped | Unmapped | Stale | Default |\n")
+	b.WriteString("|--------|------|-------|--------|----------|-------|---------|\n")
+	for _, c := range coverage {
+		mapped := len(c.Enum.Cases) - len(c.Unmapped)
+		fmt.Fprintf(&b, "| %s (%s:%d) | %s (%s) | %d | %d | %d | %d | %s |\n",
+			c.Switch.Function, c.Switch.File, c.Switch.Line, c.Enum.Name, c.Enum.File,
+			len(c.Enum.Cases), mapped, len(c.Unmapped), len(c.Stale), yesNo(c.Switch.HasDefault))
+	}
+
+	for _, c := range coverage {
+		if len(c.Unmapped) == 0 && len(c.Stale) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s (%s:%d)\n\n", c.Switch.Function, c.Switch.File, c.Switch.Line)
+		if len(c.Unmapped) > 0 {
+			fmt.Fprintf(&b, "Unmapped cases of %s", c.Enum.Name)
+			if c.Switch.HasDefault {
+				b.WriteString(" (handled by default)")
+			}
+			b.WriteString(":\n\n")
+			for _, name := range c.Unmapped {
+				fmt.Fprintf(&b, "- `.%s`\n", name)
+			}
+		}
+		if len(c.Stale) > 0 {
+			if len(c.Unmapped) > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "Mapped cases %s no longer declares:\n\n", c.Enum.Name)
+			for _, name := range c.Stale {
+				fmt.Fprintf(&b, "- `.%s` (line %d)\n", name, c.Switch.Cases[name])
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
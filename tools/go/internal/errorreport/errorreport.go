@@ -0,0 +1,344 @@
+// Package errorreport finds the Swift error types defined in the UmbraCore
+// modules and measures how widely each is used, producing the Error
+// Analysis Report consumed by error_migrator.
+package errorreport
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modules"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftlex"
+)
+
+var (
+	// typeKinds are the declarations that may define an error type.
+	typeKinds = map[string]bool{"enum": true, "struct": true, "class": true}
+	// modifiers may come before the kind, e.g. "public final class".
+	modifiers = map[string]bool{"public": true, "open": true, "package": true, "internal": true, "fileprivate": true, "private": true, "final": true, "indirect": true}
+)
+
+// Definition is one error type declared in a module. The JSON field names
+// match error_migrator's ErrorDefinition.
+type Definition struct {
+	Name   string   `json:"errorName"`
+	Module string   `json:"moduleName"`
+	File   string   `json:"filePath"`
+	Line   int      `json:"lineNumber"`
+	Public bool     `json:"isPublic"`
+	Enum   bool     `json:"isEnum"`
+	Cases  []string `json:"caseNames"`
+	// ImportedBy lists the other modules importing the defining module.
+	ImportedBy []string `json:"importedBy"`
+	// ReferencedFiles lists the files, other than the declaring one, that
+	// use the type and can see the defining module.
+	ReferencedFiles []string `json:"referencedFiles"`
+}
+
+// Module summarises one analysed module.
+type Module struct {
+	Name             string   `json:"name"`
+	Imports          []string `json:"imports"`
+	ImportedBy       []string `json:"importedBy"`
+	ErrorDefinitions int      `json:"errorDefinitions"`
+}
+
+// Duplicate is an error type name declared in more than one module.
+type Duplicate struct {
+	Name    string   `json:"errorName"`
+	Modules []string `json:"modules"`
+	// Fingerprints identify the issue raised in each of Modules, in the
+	// same order.
+	Fingerprints []string `json:"fingerprints,omitempty"`
+}
+
+// Report is the result of an analysis.
+type Report struct {
+	Generated       time.Time    `json:"generated"`
+	Modules         []Module     `json:"modules"`
+	Definitions     []Definition `json:"errorDefinitions"`
+	Duplicates      []Duplicate  `json:"duplicatedErrorTypes"`
+	TotalReferences int          `json:"totalErrorReferences"`
+}
+
+// sourceFile is the part of a Swift file the analysis needs.
+type sourceFile struct {
+	path        string
+	module      string
+	imports     map[string]bool
+	definitions []Definition
+	// names holds every capitalised identifier used in code, and qualified
+	// the "Module.Name" forms among them.
+	names     map[string]bool
+	qualified map[string]bool
+}
+
+// Analyse scans the modules below the given scope directories (default
+// "Sources") and returns the report.
+func Analyse(root string, scopes ...string) (*Report, error) {
+	if len(scopes) == 0 {
+		scopes = modules.DefaultScopes
+	}
+
+	mods, err := modules.Discover(root, scopes...)
+	if err != nil {
+		return nil, err
+	}
+	imported, err := imports.ScanTree(root, scopes...)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := pool.Map(imported, func(f imports.File) (sourceFile, error) {
+		return scanFile(root, f)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(mods))
+	for _, m := range mods {
+		known[m.Name] = true
+	}
+
+	importedBy := make(map[string]map[string]bool)
+	moduleImports := make(map[string]map[string]bool)
+	for _, f := range files {
+		for mod := range f.imports {
+			if !known[mod] || mod == f.module {
+				continue
+			}
+			add(importedBy, mod, f.module)
+			add(moduleImports, f.module, mod)
+		}
+	}
+
+	report := &Report{}
+	for _, f := range files {
+		report.Definitions = append(report.Definitions, f.definitions...)
+	}
+
+	for i := range report.Definitions {
+		def := &report.Definitions[i]
+		def.ImportedBy = sortedKeys(importedBy[def.Module])
+		for _, f := range files {
+			if f.path != def.File && references(f, def) {
+				def.ReferencedFiles = append(def.ReferencedFiles, f.path)
+			}
+		}
+		report.TotalReferences += len(def.ReferencedFiles)
+	}
+	sort.SliceStable(report.Definitions, func(i, j int) bool {
+		a, b := report.Definitions[i], report.Definitions[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Module < b.Module
+	})
+
+	definedIn := make(map[string]map[string]bool)
+	perModule := make(map[string]int)
+	for _, def := range report.Definitions {
+		add(definedIn, def.Name, def.Module)
+		perModule[def.Module]++
+	}
+	for _, name := range sortedKeys(definedIn) {
+		if len(definedIn[name]) > 1 {
+			report.Duplicates = append(report.Duplicates, Duplicate{Name: name, Modules: sortedKeys(definedIn[name])})
+		}
+	}
+
+	for _, m := range mods {
+		report.Modules = append(report.Modules, Module{
+			Name:             m.Name,
+			Imports:          sortedKeys(moduleImports[m.Name]),
+			ImportedBy:       sortedKeys(importedBy[m.Name]),
+			ErrorDefinitions: perModule[m.Name],
+		})
+	}
+	return report, nil
+}
+
+// references reports whether f uses def: the type is named qualified by its
+// module, or unqualified from a file that can see the module.
+func references(f sourceFile, def *Definition) bool {
+	if f.qualified[def.Module+"."+def.Name] {
+		return true
+	}
+	return f.names[def.Name] && (f.module == def.Module || f.imports[def.Module])
+}
+
+func scanFile(root string, imp imports.File) (sourceFile, error) {
+	f := sourceFile{
+		path:      imp.Path,
+		module:    imp.Module,
+		imports:   make(map[string]bool),
+		names:     make(map[string]bool),
+		qualified: make(map[string]bool),
+	}
+	for _, i := range imp.Imports {
+		f.imports[i.Module] = true
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, imp.Path))
+	if err != nil {
+		return f, err
+	}
+
+	var (
+		current   *Definition
+		bodyDepth int
+		depth     int
+	)
+	toks := swiftlex.Code(string(data))
+	for i, t := range toks {
+		switch {
+		case t.Is(swiftlex.Punct, "{"):
+			depth++
+		case t.Is(swiftlex.Punct, "}"):
+			depth--
+			if current != nil && depth < bodyDepth {
+				current = nil
+			}
+		case t.Kind != swiftlex.Ident:
+		case typeKinds[t.Text]:
+			if def, ok := definition(toks, i); ok {
+				def.Module, def.File = f.module, f.path
+				f.definitions = append(f.definitions, def)
+				current = &f.definitions[len(f.definitions)-1]
+				bodyDepth = depth + 1
+			}
+		case t.Text == "case" && current != nil && current.Enum && depth == bodyDepth && startsStatement(toks, i):
+			current.Cases = append(current.Cases, caseNames(toks, i+1)...)
+		case t.Text[0] >= 'A' && t.Text[0] <= 'Z':
+			f.names[t.Text] = true
+			if i >= 2 && toks[i-1].Is(swiftlex.Punct, ".") && toks[i-2].Kind == swiftlex.Ident {
+				f.qualified[toks[i-2].Text+"."+t.Text] = true
+			}
+		}
+	}
+	return f, nil
+}
+
+// definition reads the declaration whose kind is toks[i], e.g. the "enum"
+// of "public enum SecurityError: Error, Sendable {", and reports whether
+// it defines an error type.
+func definition(toks []swiftlex.Token, i int) (Definition, bool) {
+	def := Definition{Enum: toks[i].Text == "enum"}
+	for j := i - 1; j >= 0 && !startsStatement(toks, j+1); j-- {
+		switch {
+		case toks[j].Kind == swiftlex.Ident && modifiers[toks[j].Text]:
+			def.Public = def.Public || toks[j].Text == "public" || toks[j].Text == "open"
+		case toks[j].Kind == swiftlex.Attribute:
+		case toks[j].Is(swiftlex.Punct, ")"):
+			// An attribute's arguments, as in "@available(macOS 14, *)".
+			for j > 0 && !toks[j].Is(swiftlex.Punct, "(") {
+				j--
+			}
+		default:
+			return def, false
+		}
+	}
+	j := i + 1
+	if j >= len(toks) || toks[j].Kind != swiftlex.Ident {
+		return def, false
+	}
+	def.Name = toks[j].Text
+	def.Line = toks[i].Line
+	j++
+	if j < len(toks) && toks[j].Kind == swiftlex.Punct && strings.HasPrefix(toks[j].Text, "<") {
+		for nesting := 0; j < len(toks); j++ {
+			nesting += strings.Count(toks[j].Text, "<") - strings.Count(toks[j].Text, ">")
+			if nesting <= 0 {
+				j++
+				break
+			}
+		}
+	}
+	if j >= len(toks) || !toks[j].Is(swiftlex.Punct, ":") {
+		return def, false
+	}
+	var inherited []string
+	entry := ""
+	for j++; j < len(toks) && !toks[j].Is(swiftlex.Punct, "{") && !toks[j].Is(swiftlex.Ident, "where"); j++ {
+		if toks[j].Is(swiftlex.Punct, ",") {
+			inherited = append(inherited, entry)
+			entry = ""
+			continue
+		}
+		entry += toks[j].Text
+	}
+	return def, conformsToError(append(inherited, entry))
+}
+
+// startsStatement reports whether toks[i] is the first token of a
+// statement: it starts a line, or follows a semicolon or brace.
+func startsStatement(toks []swiftlex.Token, i int) bool {
+	if i == 0 || toks[i-1].EndLine() < toks[i].Line {
+		return true
+	}
+	prev := toks[i-1]
+	return prev.Is(swiftlex.Punct, ";") || prev.Is(swiftlex.Punct, "{") || prev.Is(swiftlex.Punct, "}") || prev.Is(swiftlex.Ident, "indirect")
+}
+
+// conformsToError reports whether an inheritance list names Error or a
+// protocol refining it by convention (LocalizedError, UmbraError, ...).
+func conformsToError(inherited []string) bool {
+	for _, t := range inherited {
+		if t == "Error" || strings.HasSuffix(t, "Error") || strings.HasSuffix(t, ".Error") {
+			return true
+		}
+	}
+	return false
+}
+
+// caseNames returns the names declared by the case list starting at
+// toks[i], such as "invalidKey(String), expired, custom(code: Int)". The
+// list ends with its statement.
+func caseNames(toks []swiftlex.Token, i int) []string {
+	var names []string
+	expectName := true
+	nesting := 0
+	for ; i < len(toks); i++ {
+		t := toks[i]
+		if nesting == 0 && !expectName && (startsStatement(toks, i) && !toks[i-1].Is(swiftlex.Punct, ",") || t.Is(swiftlex.Punct, "}")) {
+			break
+		}
+		switch {
+		case expectName:
+			if t.Kind != swiftlex.Ident {
+				return names
+			}
+			names = append(names, strings.Trim(t.Text, "`"))
+			expectName = false
+		case t.Is(swiftlex.Punct, "(") || t.Is(swiftlex.Punct, "["):
+			nesting++
+		case t.Is(swiftlex.Punct, ")") || t.Is(swiftlex.Punct, "]"):
+			nesting--
+		case t.Is(swiftlex.Punct, ",") && nesting == 0:
+			expectName = true
+		}
+	}
+	return names
+}
+
+func add(m map[string]map[string]bool, key, value string) {
+	if m[key] == nil {
+		m[key] = make(map[string]bool)
+	}
+	m[key][value] = true
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
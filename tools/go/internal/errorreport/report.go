@@ -0,0 +1,71 @@
+package errorreport
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes the report in the Markdown format parsed by
+// error_migrator: "### Name (Module)" headings under "## Error
+// Definitions" with File, Public, Cases and Referenced By entries, and a
+// "## Duplicated Error Types" section listing the defining modules.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	b.WriteString("# UmbraCore Error Analysis Report\n\n")
+	fmt.Fprintf(&b, "Report generated at %s\n\n", r.Generated.Format("2006-01-02 15:04:05"))
+
+	b.WriteString("## Summary\n\n")
+	fmt.Fprintf(&b, "- **Total Modules Analyzed**: %d\n", len(r.Modules))
+	fmt.Fprintf(&b, "- **Total Error Definitions**: %d\n", len(r.Definitions))
+	fmt.Fprintf(&b, "- **Total Error References**: %d\n\n", r.TotalReferences)
+
+	b.WriteString("## Modules\n\n")
+	for _, m := range r.Modules {
+		fmt.Fprintf(&b, "### %s\n", m.Name)
+		fmt.Fprintf(&b, "- **Error Definitions**: %d\n", m.ErrorDefinitions)
+		fmt.Fprintf(&b, "- **Imported By**: %s\n\n", countedList(m.ImportedBy))
+	}
+
+	b.WriteString("## Error Definitions\n\n")
+	for _, def := range r.Definitions {
+		fmt.Fprintf(&b, "### %s (%s)\n", def.Name, def.Module)
+		fmt.Fprintf(&b, "- **File**: %s:%d\n", def.File, def.Line)
+		fmt.Fprintf(&b, "- **Public**: %v\n", def.Public)
+		if len(def.Cases) > 0 {
+			b.WriteString("- **Cases**:\n")
+			for _, c := range def.Cases {
+				fmt.Fprintf(&b, "  - %s\n", c)
+			}
+		}
+		fmt.Fprintf(&b, "- **Imported By**: %s\n", countedList(def.ImportedBy))
+		fmt.Fprintf(&b, "- **Referenced By**: %d files\n", len(def.ReferencedFiles))
+		for _, f := range def.ReferencedFiles {
+			fmt.Fprintf(&b, "  - %s\n", f)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Duplicated Error Types\n\n")
+	if len(r.Duplicates) == 0 {
+		b.WriteString("No duplicated error types found.\n")
+	}
+	for _, d := range r.Duplicates {
+		fmt.Fprintf(&b, "- **%s** defined in %d modules:\n", d.Name, len(d.Modules))
+		for _, m := range d.Modules {
+			fmt.Fprintf(&b, "  - %s\n", m)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// countedList renders "2 modules (A, B)", keeping the entry on one line so
+// that error_migrator does not mistake the names for enum cases.
+func countedList(names []string) string {
+	if len(names) == 0 {
+		return "0 modules"
+	}
+	return fmt.Sprintf("%d modules (%s)", len(names), strings.Join(names, ", "))
+}
@@ -0,0 +1,226 @@
+// Package errorusage counts, for every case of the error enums the error
+// report finds, the places that construct it and the catch and switch
+// patterns that match it. A case neither constructed nor matched anywhere
+// can be dropped before the enums are consolidated, rather than carried
+// into CoreErrors.
+package errorusage
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errorreport"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+var (
+	// memberPattern matches the member of a reference such as ".name"
+	// or "Type.name".
+	memberPattern = regexp.MustCompile("\\.`?([A-Za-z_][A-Za-z0-9_]*)`?")
+	// qualifierPattern matches the identifier a dot follows.
+	qualifierPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*$`)
+	// patternKeyword matches the keywords that open a pattern.
+	patternKeyword = regexp.MustCompile(`\b(?:case|catch)\b`)
+)
+
+// Case is the usage of one enum case.
+type Case struct {
+	Name string `json:"name"`
+	// Constructions count the references outside patterns, such as
+	// ".caseName(" and "SomeError.caseName"; Matches the catch, switch
+	// and if-case patterns naming the case outside the enum's own file,
+	// whose switches every enum needs.
+	Constructions int  `json:"constructions"`
+	Matches       int  `json:"matches"`
+	Unused        bool `json:"unused,omitempty"`
+	// Fingerprint is set on an unused case, which is reported as an issue.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Enum is one error enum and the usage of its cases.
+type Enum struct {
+	Name   string `json:"name"`
+	Module string `json:"module"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Cases  []Case `json:"cases"`
+}
+
+// Report holds the case usage of every error enum.
+type Report struct {
+	// Enums are sorted by name and module.
+	Enums []Enum `json:"enums"`
+	// Files is the number of Swift files searched.
+	Files int `json:"files"`
+}
+
+// Unused returns the number of cases no code uses.
+func (r *Report) Unused() int {
+	n := 0
+	for _, e := range r.Enums {
+		for _, c := range e.Cases {
+			if c.Unused {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// ref is a reference to a case name found in one file.
+type ref struct {
+	// qualifier is the type named before the dot, empty for an implicit
+	// member such as ".caseName".
+	qualifier string
+	name      string
+	pattern   bool
+}
+
+// Count finds the enum definitions of r and counts the uses of their
+// cases in the Swift files below dirs. References are matched by name: a
+// qualified one counts for every enum of that name with the case, and an
+// implicit ".caseName" for every enum with the case, since the type it
+// resolves to is not known. A case is reported unused only when no
+// reference could be to it.
+func Count(root string, r *errorreport.Report, dirs []string) (*Report, error) {
+	var files []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, filepath.FromSlash(dir))
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			files = append(files, path.Join(dir, rel))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+
+	names := make(map[string]bool)
+	for _, def := range r.Definitions {
+		if def.Enum {
+			for _, c := range def.Cases {
+				names[c] = true
+			}
+		}
+	}
+	refs, err := pool.Map(files, func(rel string) ([]ref, error) {
+		return scanFile(root, rel, names)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Enums: []Enum{}, Files: len(files)}
+	type key struct{ qualifier, name string }
+	byKey := make(map[key][]*Case)
+	caseFile := make(map[*Case]string)
+	for _, def := range r.Definitions {
+		if !def.Enum || len(def.Cases) == 0 {
+			continue
+		}
+		e := Enum{Name: def.Name, Module: def.Module, File: def.File, Line: def.Line}
+		for _, c := range def.Cases {
+			e.Cases = append(e.Cases, Case{Name: c})
+		}
+		report.Enums = append(report.Enums, e)
+	}
+	for i := range report.Enums {
+		e := &report.Enums[i]
+		for j := range e.Cases {
+			c := &e.Cases[j]
+			byKey[key{e.Name, c.Name}] = append(byKey[key{e.Name, c.Name}], c)
+			byKey[key{"", c.Name}] = append(byKey[key{"", c.Name}], c)
+			caseFile[c] = e.File
+		}
+	}
+
+	for i, found := range refs {
+		for _, rf := range found {
+			for _, c := range byKey[key{rf.qualifier, rf.name}] {
+				switch {
+				case !rf.pattern:
+					c.Constructions++
+				case caseFile[c] != files[i]:
+					c.Matches++
+				}
+			}
+		}
+	}
+	for i := range report.Enums {
+		for j := range report.Enums[i].Cases {
+			c := &report.Enums[i].Cases[j]
+			c.Unused = c.Constructions == 0 && c.Matches == 0
+		}
+	}
+	sort.SliceStable(report.Enums, func(i, j int) bool {
+		a, b := report.Enums[i], report.Enums[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Module < b.Module
+	})
+	return report, nil
+}
+
+// scanFile returns the references in rel to members called one of names.
+func scanFile(root, rel string, names map[string]bool) ([]ref, error) {
+	fh, err := os.Open(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	var refs []ref
+	var lines int
+	inComment := false
+	scanner := textscan.NewScanner(fh)
+	for scanner.Scan() {
+		lines++
+		var code string
+		code, inComment = swiftsrc.StripComments(scanner.Text(), inComment)
+		for _, m := range memberPattern.FindAllStringSubmatchIndex(code, -1) {
+			name := code[m[2]:m[3]]
+			if !names[name] {
+				continue
+			}
+			before := code[:m[0]]
+			r := ref{name: name, pattern: inPattern(before)}
+			if q := qualifierPattern.FindString(before); q != "" {
+				if q[0] < 'A' || q[0] > 'Z' {
+					// A member of a value, not of a type.
+					continue
+				}
+				if q != "Self" {
+					r.qualifier = q
+				}
+			} else if strings.HasSuffix(before, ")") || strings.HasSuffix(before, "]") || strings.HasSuffix(before, "?") || strings.HasSuffix(before, "!") {
+				continue
+			}
+			refs = append(refs, r)
+		}
+	}
+	return refs, textscan.Check(rel, lines, scanner.Err())
+}
+
+// inPattern reports whether a reference following before on its line is
+// part of a pattern: it comes after a case or catch keyword, and before
+// the colon, equals sign, brace or where clause that ends the pattern.
+func inPattern(before string) bool {
+	loc := patternKeyword.FindAllStringIndex(before, -1)
+	if len(loc) == 0 {
+		return false
+	}
+	rest := before[loc[len(loc)-1][1]:]
+	return !strings.ContainsAny(rest, ":={") && !strings.Contains(rest, " where ")
+}
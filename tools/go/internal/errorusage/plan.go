@@ -0,0 +1,135 @@
+package errorusage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// PlanKey is the key of the migration plan under which Annotate records
+// the unused cases of each error it migrates.
+const PlanKey = "unusedCases"
+
+// plan is the part of error_migrator's migration config Annotate reads.
+// encoding/json matches keys regardless of case, as error_migrator does,
+// so "ErrorsToMigrate" is read too.
+type plan struct {
+	ErrorsToMigrate map[string][]string `json:"errorsToMigrate"`
+}
+
+// UnusedCases returns, for each error the plan in data migrates, the cases
+// no definition of it in the modules it is migrated from uses. A case
+// some of those definitions declare and others do not counts when every
+// definition declaring it leaves it unused.
+func (r *Report) UnusedCases(data []byte) (map[string][]string, error) {
+	var p plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	out := make(map[string][]string)
+	for name, modules := range p.ErrorsToMigrate {
+		from := make(map[string]bool, len(modules))
+		for _, m := range modules {
+			from[m] = true
+		}
+		used := make(map[string]bool)
+		unused := make(map[string]bool)
+		for _, e := range r.Enums {
+			if e.Name != name || !from[e.Module] {
+				continue
+			}
+			for _, c := range e.Cases {
+				if c.Unused {
+					unused[c.Name] = true
+				} else {
+					used[c.Name] = true
+				}
+			}
+		}
+		var cases []string
+		for c := range unused {
+			if !used[c] {
+				cases = append(cases, c)
+			}
+		}
+		if len(cases) > 0 {
+			sort.Strings(cases)
+			out[name] = cases
+		}
+	}
+	return out, nil
+}
+
+// Annotate returns the migration plan in data with its unused cases
+// recorded under PlanKey, replacing any recorded before, so that
+// error_migrator can leave them out of the consolidated enums. The
+// plan's other keys keep their order and values.
+func (r *Report) Annotate(data []byte) ([]byte, error) {
+	unused, err := r.UnusedCases(data)
+	if err != nil {
+		return nil, err
+	}
+	return SetPlanKey(data, PlanKey, unused)
+}
+
+// SetPlanKey returns the migration plan in data with v recorded under
+// key, replacing any value recorded before. The plan's other keys keep
+// their order and values.
+func SetPlanKey(data []byte, key string, v any) ([]byte, error) {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("migration plan is not a JSON object")
+	}
+	var b bytes.Buffer
+	b.WriteString("{")
+	written := false
+	entry := func(name string, raw []byte) error {
+		if b.Len() > 1 {
+			b.WriteString(",")
+		}
+		k, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		b.Write(k)
+		b.WriteString(":")
+		b.Write(raw)
+		return nil
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		name, _ := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		if name == key {
+			raw, written = value, true
+		}
+		if err := entry(name, raw); err != nil {
+			return nil, err
+		}
+	}
+	if !written {
+		if err := entry(key, value); err != nil {
+			return nil, err
+		}
+	}
+	b.WriteString("}")
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, b.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteString("\n")
+	return out.Bytes(), nil
+}
@@ -0,0 +1,65 @@
+package errorusage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes a summary table of the enums, then the unused
+// cases of each enum with any.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	b.WriteString("# Error Case Usage\n\n")
+	cases := 0
+	for _, e := range r.Enums {
+		cases += len(e.Cases)
+	}
+	fmt.Fprintf(&b, "%d error enums with %d cases, searched for in %d files; %d cases are unused.\n", len(r.Enums), cases, r.Files, r.Unused())
+	if len(r.Enums) == 0 {
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	b.WriteString("\n| Enum | Module | File | Cases | Constructions | Matches | Unused |\n")
+	b.WriteString("|------|--------|------|-------|---------------|---------|--------|\n")
+	for _, e := range r.Enums {
+		constructions, matches, unused := 0, 0, 0
+		for _, c := range e.Cases {
+			constructions += c.Constructions
+			matches += c.Matches
+			if c.Unused {
+				unused++
+			}
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s:%d | %d | %d | %d | %d |\n", e.Name, e.Module, e.File, e.Line, len(e.Cases), constructions, matches, unused)
+	}
+
+	for _, e := range r.Enums {
+		var unused []string
+		for _, c := range e.Cases {
+			if c.Unused {
+				unused = append(unused, c.Name)
+			}
+		}
+		if len(unused) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s (%s)\n\n", e.Name, e.Module)
+		fmt.Fprintf(&b, "Declared at %s:%d. Never constructed or matched:\n\n", e.File, e.Line)
+		for _, name := range unused {
+			fmt.Fprintf(&b, "- `.%s`\n", name)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
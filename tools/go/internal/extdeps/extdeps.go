@@ -0,0 +1,462 @@
+// Package extdeps audits the external repositories the workspace declares
+// in MODULE.bazel and WORKSPACE. It counts the labels in BUILD, .bzl and
+// .bazelrc files that name each repository, and reports the repositories
+// nothing names, the names nothing declares, and declared versions that
+// differ from the versions resolution picked.
+package extdeps
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// Issue kinds.
+const (
+	IssueUnused     = "unused-external-dep"
+	IssueUndeclared = "undeclared-external-repo"
+	IssueSkew       = "external-version-skew"
+)
+
+// Kinds of declaration besides the WORKSPACE repository rules, which keep
+// their rule's name.
+const (
+	KindBazelDep = "bazel_dep"
+	KindUseRepo  = "use_repo"
+)
+
+// Repo is one declared external repository.
+type Repo struct {
+	// Name is the name labels use for the repository: a bazel_dep's
+	// repo_name or module name, a use_repo name, or a WORKSPACE rule's name.
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// Module is the module a bazel_dep depends on, and Extension the
+	// extension that generates a use_repo repository.
+	Module    string `json:"module,omitempty"`
+	Extension string `json:"extension,omitempty"`
+	// Version is the version declared, and Resolved the version
+	// resolution picked, when it is known.
+	Version  string `json:"version,omitempty"`
+	Resolved string `json:"resolved,omitempty"`
+	Dev      bool   `json:"dev,omitempty"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	// Uses counts the labels naming the repository, in the files UsedIn.
+	Uses   int      `json:"uses"`
+	UsedIn []string `json:"usedIn,omitempty"`
+	// Allowed marks an unused repository the audit was told to accept.
+	Allowed bool `json:"allowed,omitempty"`
+	// Fingerprint identifies an unused repository's issue.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Unused reports whether the repository is declared but never named.
+func (r Repo) Unused() bool {
+	return r.Uses == 0 && !r.Allowed
+}
+
+// Reference is a label naming a repository nothing declares.
+type Reference struct {
+	Repo        string `json:"repo"`
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Text        string `json:"text"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Skew is a declared version that resolution did not pick.
+type Skew struct {
+	Repo     string `json:"repo"`
+	Declared string `json:"declared"`
+	Resolved string `json:"resolved"`
+	// Source is where the resolved version came from.
+	Source      string `json:"source"`
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Message describes the skew in one line.
+func (s Skew) Message() string {
+	if s.Resolved == "" {
+		return fmt.Sprintf("%s declares %s but %s does not resolve it", s.Repo, s.Declared, s.Source)
+	}
+	return fmt.Sprintf("%s declares %s but %s resolves %s", s.Repo, s.Declared, s.Source, s.Resolved)
+}
+
+// Report is the outcome of an audit.
+type Report struct {
+	// Module is the root module's name, empty without a module() call.
+	Module     string      `json:"module,omitempty"`
+	Repos      []Repo      `json:"repos"`
+	Undeclared []Reference `json:"undeclared"`
+	Packages   []Package   `json:"packages,omitempty"`
+	Skew       []Skew      `json:"skew"`
+	// Resolution names where the resolved module versions came from,
+	// empty when none were available.
+	Resolution string `json:"resolution,omitempty"`
+}
+
+// Unused returns the declared repositories nothing names.
+func (r *Report) Unused() []Repo {
+	var unused []Repo
+	for _, repo := range r.Repos {
+		if repo.Unused() {
+			unused = append(unused, repo)
+		}
+	}
+	return unused
+}
+
+// Options configures an audit.
+type Options struct {
+	// Resolved holds the version resolution picked for each module, as
+	// read by ParseGraph or ParseLockfile, and Resolution names where
+	// it came from.
+	Resolved   map[string]string
+	Resolution string
+	// Allow lists repositories accepted as unused, such as toolchain
+	// modules nothing names.
+	Allow []string
+}
+
+// implicit matches repositories Bazel provides without a declaration.
+var implicit = regexp.MustCompile(`^(?:bazel_tools|local_config_\w+|local_jdk|remotejdk\w*|remote_java_tools\w*|remote_coverage_tools)$`)
+
+// Analyse audits the external repositories of the workspace at root.
+func Analyse(root string, opts Options) (*Report, error) {
+	report := &Report{Repos: []Repo{}, Undeclared: []Reference{}, Skew: []Skew{}, Resolution: opts.Resolution}
+	if err := report.readModule(root); err != nil {
+		return nil, err
+	}
+	for _, name := range []string{"WORKSPACE.bazel", "WORKSPACE"} {
+		if err := report.readWorkspace(root, name); err != nil {
+			return nil, err
+		}
+	}
+
+	refs, err := scanReferences(root)
+	if err != nil {
+		return nil, err
+	}
+	// A name MODULE.bazel and WORKSPACE both declare is used by both.
+	byName := make(map[string][]int, len(report.Repos))
+	modules := make(map[string][]int)
+	for i, r := range report.Repos {
+		byName[r.Name] = append(byName[r.Name], i)
+		if r.Module != "" {
+			modules[r.Module] = append(modules[r.Module], i)
+		}
+	}
+	for _, ref := range refs {
+		repos, ok := byName[ref.Repo]
+		if !ok {
+			// A canonical name, @@rules_swift~ or @@rules_swift+, names
+			// the module's repository.
+			repos, ok = modules[strings.TrimRight(ref.Repo, "~+")]
+		}
+		if !ok {
+			if ref.Repo != report.Module && !implicit.MatchString(ref.Repo) {
+				report.Undeclared = append(report.Undeclared, ref)
+			}
+			continue
+		}
+		for _, i := range repos {
+			repo := &report.Repos[i]
+			repo.Uses++
+			repo.UsedIn = append(repo.UsedIn, ref.File)
+		}
+	}
+	for i := range report.Repos {
+		r := &report.Repos[i]
+		sort.Strings(r.UsedIn)
+		r.UsedIn = slices.Compact(r.UsedIn)
+		r.Allowed = r.Uses == 0 && slices.Contains(opts.Allow, r.Name)
+	}
+
+	report.moduleSkew(opts.Resolved, opts.Resolution)
+	report.workspaceSkew()
+	if report.Packages, err = swiftPackages(root); err != nil {
+		return nil, err
+	}
+	for _, p := range report.Packages {
+		if !p.Satisfied {
+			report.Skew = append(report.Skew, Skew{Repo: p.Repo, Declared: p.Requirement, Resolved: p.Resolved, Source: "Package.resolved", File: "Package.swift", Line: p.Line})
+		}
+	}
+	sort.SliceStable(report.Repos, func(i, j int) bool {
+		if report.Repos[i].File != report.Repos[j].File {
+			return report.Repos[i].File < report.Repos[j].File
+		}
+		return report.Repos[i].Line < report.Repos[j].Line
+	})
+	return report, nil
+}
+
+// readModule reads the declarations of MODULE.bazel: its bazel_deps and
+// the repositories use_repo brings in from module extensions.
+func (report *Report) readModule(root string) error {
+	const name = "MODULE.bazel"
+	f, err := parse(root, name, build.ParseModule)
+	if f == nil {
+		return err
+	}
+	// extensions maps the variables use_extension results are assigned
+	// to onto the extensions' names.
+	extensions := make(map[string]string)
+	for _, stmt := range f.Stmt {
+		if assign, ok := stmt.(*build.AssignExpr); ok {
+			if v, ok := assign.LHS.(*build.Ident); ok {
+				if call, ok := assign.RHS.(*build.CallExpr); ok && callName(call) == "use_extension" && len(call.List) >= 2 {
+					extensions[v.Name] = stringValue(call.List[1])
+				}
+			}
+			continue
+		}
+		call, ok := stmt.(*build.CallExpr)
+		if !ok {
+			continue
+		}
+		rule := f.Rule(call)
+		line := lineOf(call)
+		switch callName(call) {
+		case "module":
+			report.Module = rule.AttrString("repo_name")
+			if report.Module == "" {
+				report.Module = rule.AttrString("name")
+			}
+		case "bazel_dep":
+			module := rule.AttrString("name")
+			repoName := rule.AttrString("repo_name")
+			if repoName == "" {
+				repoName = module
+			}
+			report.Repos = append(report.Repos, Repo{Name: repoName, Kind: KindBazelDep, Module: module, Version: rule.AttrString("version"), Dev: rule.AttrLiteral("dev_dependency") == "True", File: name, Line: line})
+		case "use_repo":
+			if len(call.List) == 0 {
+				continue
+			}
+			ext := ""
+			if v, ok := call.List[0].(*build.Ident); ok {
+				ext = extensions[v.Name]
+				if ext == "" {
+					ext = v.Name
+				}
+			}
+			for _, arg := range call.List[1:] {
+				// An alias, use_repo(ext, alias = "repo"), declares the
+				// repository under the alias.
+				repoName := stringValue(arg)
+				if kw, ok := arg.(*build.AssignExpr); ok {
+					if id, ok := kw.LHS.(*build.Ident); ok {
+						repoName = id.Name
+					}
+				}
+				if repoName != "" {
+					report.Repos = append(report.Repos, Repo{Name: repoName, Kind: KindUseRepo, Extension: ext, File: name, Line: lineOf(arg)})
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// versionPattern finds a version in a tag, prefix or archive URL.
+var versionPattern = regexp.MustCompile(`\bv?(\d+(?:\.\d+)+)\b`)
+
+// readWorkspace reads the repository rules of a WORKSPACE file: every
+// top-level call with a name, apart from workspace() itself.
+func (report *Report) readWorkspace(root, name string) error {
+	f, err := parse(root, name, build.ParseWorkspace)
+	if f == nil {
+		return err
+	}
+	for _, rule := range f.Rules("") {
+		kind := rule.Kind()
+		repoName := rule.AttrString("name")
+		if repoName == "" || kind == "workspace" {
+			continue
+		}
+		report.Repos = append(report.Repos, Repo{Name: repoName, Kind: kind, Version: workspaceVersion(rule), File: name, Line: lineOf(rule.Call)})
+	}
+	return nil
+}
+
+// workspaceVersion guesses the version a repository rule fetches from its
+// tag or version, or the prefix or URL of its archive.
+func workspaceVersion(rule *build.Rule) string {
+	for _, attr := range []string{"version", "tag"} {
+		if v := rule.AttrString(attr); v != "" {
+			return strings.TrimPrefix(v, "v")
+		}
+	}
+	candidates := []string{rule.AttrString("strip_prefix"), rule.AttrString("url")}
+	candidates = append(candidates, rule.AttrStrings("urls")...)
+	for _, c := range candidates {
+		if m := versionPattern.FindStringSubmatch(c); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// moduleSkew compares each bazel_dep's version with the one resolution
+// picked. A higher resolved version means another module needs a newer
+// release than the one declared.
+func (report *Report) moduleSkew(resolved map[string]string, source string) {
+	if len(resolved) == 0 {
+		return
+	}
+	for i := range report.Repos {
+		r := &report.Repos[i]
+		if r.Kind != KindBazelDep {
+			continue
+		}
+		r.Resolved = resolved[r.Module]
+		if r.Version != "" && r.Resolved != "" && r.Resolved != r.Version {
+			report.Skew = append(report.Skew, Skew{Repo: r.Name, Declared: r.Version, Resolved: r.Resolved, Source: source, File: r.File, Line: r.Line})
+		}
+	}
+}
+
+// workspaceSkew flags a repository that WORKSPACE fetches at a version
+// other than the one MODULE.bazel declares for it.
+func (report *Report) workspaceSkew() {
+	declared := make(map[string]Repo)
+	for _, r := range report.Repos {
+		if r.Kind == KindBazelDep && r.Version != "" {
+			declared[r.Name] = r
+		}
+	}
+	for _, r := range report.Repos {
+		if !strings.HasPrefix(r.File, "WORKSPACE") || r.Version == "" {
+			continue
+		}
+		if dep, ok := declared[r.Name]; ok && dep.Version != r.Version {
+			report.Skew = append(report.Skew, Skew{Repo: r.Name, Declared: dep.Version, Resolved: r.Version, Source: r.File, File: dep.File, Line: dep.Line})
+		}
+	}
+}
+
+// labelRepo matches the repository of a label, @repo//pkg or @@repo~//pkg,
+// and of a bare repository label such as "@repo".
+var labelRepo = regexp.MustCompile(`(?:^|[^\w@.])@@?([A-Za-z][\w.+~-]*)(?://|["'])`)
+
+// scanReferences returns the labels naming a repository in the BUILD and
+// .bzl files, MODULE.bazel, the WORKSPACE files and .bazelrc, in file
+// order.
+func scanReferences(root string) ([]Reference, error) {
+	var files []string
+	err := walker.Walk(root, walker.Options{}, func(rel string) error {
+		base := path.Base(rel)
+		if buildfile.IsBuildFile(base) || strings.HasSuffix(base, ".bzl") || strings.HasPrefix(base, ".bazelrc") ||
+			(path.Dir(rel) == "." && (base == "MODULE.bazel" || strings.HasPrefix(base, "WORKSPACE"))) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var refs []Reference
+	for _, rel := range files {
+		found, err := scanFile(root, rel)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, found...)
+	}
+	return refs, nil
+}
+
+func scanFile(root, rel string) ([]Reference, error) {
+	f, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var refs []Reference
+	lineNo := 0
+	scanner := textscan.NewScanner(f)
+	for scanner.Scan() {
+		lineNo++
+		code := stripComment(scanner.Text())
+		for _, m := range labelRepo.FindAllStringSubmatch(code, -1) {
+			refs = append(refs, Reference{Repo: m[1], File: rel, Line: lineNo, Text: strings.TrimSpace(scanner.Text())})
+		}
+	}
+	return refs, textscan.Check(rel, lineNo, scanner.Err())
+}
+
+// stripComment drops a # comment from a Starlark or .bazelrc line, leaving
+// a # inside a string alone.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parse reads and parses the file name below root, returning a nil file
+// without an error when it does not exist.
+func parse(root, name string, parser func(string, []byte) (*build.File, error)) (*build.File, error) {
+	data, err := os.ReadFile(filepath.Join(root, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	f, err := parser(name, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return f, nil
+}
+
+func callName(call *build.CallExpr) string {
+	if id, ok := call.X.(*build.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+func stringValue(e build.Expr) string {
+	if s, ok := e.(*build.StringExpr); ok {
+		return s.Value
+	}
+	return ""
+}
+
+func lineOf(e build.Expr) int {
+	start, _ := e.Span()
+	return start.Line
+}
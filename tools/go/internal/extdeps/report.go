@@ -0,0 +1,116 @@
+package extdeps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxUsedIn is how many of the files naming a repository the markdown
+// report lists.
+const maxUsedIn = 3
+
+// WriteMarkdown writes the declared repositories with their uses, then the
+// unused repositories, the undeclared ones and the version skew.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	b.WriteString("# External Dependencies\n\n")
+	fmt.Fprintf(&b, "**%d repositories declared, %d unused, %d undeclared references, %d version skews**\n",
+		len(r.Repos), len(r.Unused()), len(r.Undeclared), len(r.Skew))
+	if r.Resolution != "" {
+		fmt.Fprintf(&b, "\nResolved versions from %s.\n", r.Resolution)
+	} else {
+		b.WriteString("\nNo resolved module versions: pass --graph or --resolve, or commit MODULE.bazel.lock, to check bazel_dep versions.\n")
+	}
+
+	if len(r.Repos) > 0 {
+		b.WriteString("\n| Repository | Declared by | Version | Resolved | Uses | Used in |\n")
+		b.WriteString("|------------|-------------|---------|----------|------|---------|\n")
+		for _, repo := range r.Repos {
+			declared := fmt.Sprintf("`%s:%d` %s", repo.File, repo.Line, repo.Kind)
+			if repo.Module != "" && repo.Module != repo.Name {
+				declared += " " + repo.Module
+			}
+			if repo.Extension != "" {
+				declared += " " + repo.Extension
+			}
+			if repo.Dev {
+				declared += " (dev)"
+			}
+			fmt.Fprintf(&b, "| `@%s` | %s | %s | %s | %d | %s |\n", repo.Name, declared, orDash(repo.Version), orDash(repo.Resolved), repo.Uses, usedIn(repo))
+		}
+	}
+
+	if unused := r.Unused(); len(unused) > 0 {
+		b.WriteString("\n## Unused\n\n")
+		b.WriteString("No label names these repositories. A module that only registers toolchains is used all the same; pass it to --allow.\n\n")
+		for _, repo := range unused {
+			fmt.Fprintf(&b, "- `@%s` (`%s:%d` %s)\n", repo.Name, repo.File, repo.Line, repo.Kind)
+		}
+	}
+
+	if len(r.Undeclared) > 0 {
+		b.WriteString("\n## Undeclared\n\n")
+		b.WriteString("| Repository | Where | Text |\n")
+		b.WriteString("|------------|-------|------|\n")
+		for _, ref := range r.Undeclared {
+			fmt.Fprintf(&b, "| `@%s` | `%s:%d` | `%s` |\n", ref.Repo, ref.File, ref.Line, strings.ReplaceAll(ref.Text, "|", `\|`))
+		}
+	}
+
+	if len(r.Packages) > 0 {
+		b.WriteString("\n## Swift Packages\n\n")
+		b.WriteString("| Package | Repository | Requirement | Resolved | Satisfied |\n")
+		b.WriteString("|---------|------------|-------------|----------|-----------|\n")
+		for _, p := range r.Packages {
+			satisfied := "yes"
+			if !p.Satisfied {
+				satisfied = "**no**"
+			}
+			fmt.Fprintf(&b, "| %s | `@%s` | %s | %s | %s |\n", p.Identity, p.Repo, p.Requirement, orDash(p.Resolved), satisfied)
+		}
+	}
+
+	if len(r.Skew) > 0 {
+		b.WriteString("\n## Version Skew\n\n")
+		for _, s := range r.Skew {
+			fmt.Fprintf(&b, "- `%s:%d` %s\n", s.File, s.Line, s.Message())
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func usedIn(repo Repo) string {
+	if len(repo.UsedIn) == 0 {
+		if repo.Allowed {
+			return "(allowed)"
+		}
+		return "-"
+	}
+	files := make([]string, 0, maxUsedIn)
+	for _, f := range repo.UsedIn[:min(len(repo.UsedIn), maxUsedIn)] {
+		files = append(files, "`"+f+"`")
+	}
+	list := strings.Join(files, ", ")
+	if more := len(repo.UsedIn) - maxUsedIn; more > 0 {
+		list += fmt.Sprintf(" and %d more", more)
+	}
+	return list
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,246 @@
+package extdeps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/spm"
+)
+
+// graphNode is a module in the output of bazel mod graph --output=json.
+type graphNode struct {
+	Key          string      `json:"key"`
+	Name         string      `json:"name"`
+	Version      string      `json:"version"`
+	Dependencies []graphNode `json:"dependencies"`
+	// Indirect dependencies are listed under the key of the module
+	// that brings them in, once it has been listed.
+	IndirectDependencies []graphNode `json:"indirectDependencies"`
+}
+
+// ParseGraph reads the resolved version of every module from the output
+// of bazel mod graph --output=json.
+func ParseGraph(data []byte) (map[string]string, error) {
+	var root graphNode
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	resolved := make(map[string]string)
+	var walk func(n graphNode)
+	walk = func(n graphNode) {
+		for _, deps := range [][]graphNode{n.Dependencies, n.IndirectDependencies} {
+			for _, d := range deps {
+				name, version := d.Name, d.Version
+				if name == "" {
+					name, version, _ = strings.Cut(d.Key, "@")
+				}
+				// An override resolves to no version, shown as "_".
+				if version != "" && version != "_" {
+					resolved[name] = version
+				}
+				walk(d)
+			}
+		}
+	}
+	walk(root)
+	return resolved, nil
+}
+
+// registryModule matches the registry URL of a module's MODULE.bazel, as
+// MODULE.bazel.lock records it.
+var registryModule = regexp.MustCompile(`/modules/([^/]+)/([^/]+)/MODULE\.bazel$`)
+
+// ParseLockfile reads resolved module versions from a MODULE.bazel.lock.
+// Older lockfiles record the resolved graph itself; newer ones record the
+// registry files of every version resolution looked at, of which the
+// highest is the one minimal version selection picked.
+func ParseLockfile(data []byte) (map[string]string, error) {
+	var lock struct {
+		RegistryFileHashes map[string]json.RawMessage `json:"registryFileHashes"`
+		ModuleDepGraph     map[string]struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"moduleDepGraph"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	resolved := make(map[string]string)
+	for key, m := range lock.ModuleDepGraph {
+		if key != "<root>" && m.Name != "" && m.Version != "" {
+			resolved[m.Name] = m.Version
+		}
+	}
+	if len(resolved) > 0 {
+		return resolved, nil
+	}
+	for url := range lock.RegistryFileHashes {
+		m := registryModule.FindStringSubmatch(url)
+		if m == nil {
+			continue
+		}
+		if v, ok := resolved[m[1]]; !ok || spm.VersionLess(v, m[2]) {
+			resolved[m[1]] = m[2]
+		}
+	}
+	return resolved, nil
+}
+
+// LoadLockfile reads the MODULE.bazel.lock below root, returning nil
+// without an error when there is none.
+func LoadLockfile(root string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(root, "MODULE.bazel.lock"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := ParseLockfile(data)
+	if err != nil {
+		return nil, fmt.Errorf("MODULE.bazel.lock: %w", err)
+	}
+	return resolved, nil
+}
+
+// Package is a Swift package Package.swift depends on, which the
+// swift_deps extension turns into a repository.
+type Package struct {
+	Identity string `json:"identity"`
+	// Repo is the repository rules_swift_package_manager names for it.
+	Repo string `json:"repo"`
+	URL  string `json:"url"`
+	// Requirement is the version requirement as Package.swift spells it,
+	// and Resolved the version Package.resolved pins.
+	Requirement string `json:"requirement"`
+	Resolved    string `json:"resolved,omitempty"`
+	// Satisfied reports whether the pin meets the requirement.
+	Satisfied bool `json:"satisfied"`
+	Line      int  `json:"line"`
+}
+
+var (
+	packageCall = regexp.MustCompile(`\.package\s*\((.*)\)`)
+	packageURL  = regexp.MustCompile(`url:\s*"([^"]+)"`)
+	// requirement patterns, each capturing the bounds it sets.
+	fromRequirement  = regexp.MustCompile(`(?:^|[,(]\s*)from:\s*"([^"]+)"`)
+	nextMajor        = regexp.MustCompile(`\.upToNextMajor\s*\(\s*from:\s*"([^"]+)"`)
+	nextMinor        = regexp.MustCompile(`\.upToNextMinor\s*\(\s*from:\s*"([^"]+)"`)
+	exactRequirement = regexp.MustCompile(`(?:exact:\s*|\.exact\s*\(\s*)"([^"]+)"`)
+	rangeRequirement = regexp.MustCompile(`"([^"]+)"\s*(\.\.<|\.\.\.)\s*"([^"]+)"`)
+	pinRequirement   = regexp.MustCompile(`\b(branch|revision):\s*"([^"]+)"`)
+)
+
+// swiftPackages reads the package dependencies of Package.swift and checks
+// each against its pin in Package.resolved.
+func swiftPackages(root string) ([]Package, error) {
+	data, err := os.ReadFile(filepath.Join(root, "Package.swift"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	pins, err := spm.LoadPins(filepath.Join(root, "Package.resolved"))
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []Package
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "//") {
+			continue
+		}
+		call := packageCall.FindStringSubmatch(line)
+		if call == nil {
+			continue
+		}
+		url := packageURL.FindStringSubmatch(call[1])
+		if url == nil {
+			continue
+		}
+		identity := strings.ToLower(strings.TrimSuffix(lastElement(url[1]), ".git"))
+		p := Package{Identity: identity, Repo: "swiftpkg_" + spm.RepoName(identity), URL: url[1], Line: i + 1}
+		for _, pin := range pins {
+			if pin.Identity == identity {
+				p.Resolved = pin.Version
+				if p.Resolved == "" {
+					p.Resolved = pin.Revision
+				}
+			}
+		}
+		p.Requirement, p.Satisfied = requirement(call[1], p.Resolved)
+		packages = append(packages, p)
+	}
+	return packages, nil
+}
+
+// lastElement returns the last element of a package URL.
+func lastElement(url string) string {
+	return url[strings.LastIndex(url, "/")+1:]
+}
+
+// requirement returns the requirement args spell and whether version
+// meets it. No requirement is met without a pin, and a branch or revision
+// requirement is met by any.
+func requirement(args, version string) (string, bool) {
+	var text string
+	met := true
+	if m := pinRequirement.FindStringSubmatch(args); m != nil {
+		text = m[1] + " " + m[2]
+	} else if m := exactRequirement.FindStringSubmatch(args); m != nil {
+		text, met = "exactly "+m[1], version == m[1]
+	} else if m := nextMinor.FindStringSubmatch(args); m != nil {
+		text, met = "up to next minor from "+m[1], within(version, m[1], bump(m[1], 1), false)
+	} else if m := nextMajor.FindStringSubmatch(args); m != nil {
+		text, met = "from "+m[1], within(version, m[1], bump(m[1], 0), false)
+	} else if m := fromRequirement.FindStringSubmatch(args); m != nil {
+		text, met = "from "+m[1], within(version, m[1], bump(m[1], 0), false)
+	} else if m := rangeRequirement.FindStringSubmatch(args); m != nil {
+		text, met = m[1]+m[2]+m[3], within(version, m[1], m[3], m[2] == "...")
+	} else {
+		text = requirementText(args)
+	}
+	return text, version != "" && met
+}
+
+// requirementText is the requirement of args when no pattern matches it.
+func requirementText(args string) string {
+	if _, after, ok := strings.Cut(args, ","); ok {
+		return strings.TrimSpace(after)
+	}
+	return "unspecified"
+}
+
+// within reports whether version lies in [lower, upper), or [lower, upper]
+// when closed.
+func within(version, lower, upper string, closed bool) bool {
+	if spm.VersionLess(version, lower) {
+		return false
+	}
+	if closed {
+		return !spm.VersionLess(upper, version)
+	}
+	return spm.VersionLess(version, upper)
+}
+
+// bump returns the version after v's field at index, with the fields
+// after it zeroed: 1.8.0 bumped at 0 is 2.0.0, at 1 is 1.9.0.
+func bump(v string, index int) string {
+	fields := strings.Split(v, ".")
+	for len(fields) <= index {
+		fields = append(fields, "0")
+	}
+	var n int
+	fmt.Sscan(fields[index], &n)
+	fields[index] = fmt.Sprint(n + 1)
+	for i := index + 1; i < len(fields); i++ {
+		fields[i] = "0"
+	}
+	return strings.Join(fields, ".")
+}
@@ -0,0 +1,248 @@
+package generated
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Issue kinds.
+const (
+	IssueEdited    = "edited"
+	IssueConflict  = "conflict"
+	IssueNoBase    = "no_base"
+	IssueInvalid   = "invalid"
+	IssueStale     = "stale"
+	IssueMerged    = "merged"
+	IssueUnstamped = "unstamped"
+)
+
+var severity = map[string]string{
+	IssueEdited:    "error",
+	IssueConflict:  "error",
+	IssueNoBase:    "error",
+	IssueInvalid:   "error",
+	IssueStale:     "warning",
+	IssueMerged:    "warning",
+	IssueUnstamped: "warning",
+}
+
+// Issue is one problem with a generated region.
+type Issue struct {
+	Kind        string `json:"kind"`
+	Severity    string `json:"severity"`
+	Module      string `json:"module"`
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Tool        string `json:"tool,omitempty"`
+	Message     string `json:"message"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// File is a file holding generated regions.
+type File struct {
+	Path    string   `json:"path"`
+	Regions []Region `json:"regions"`
+}
+
+// Report is the result of Check.
+type Report struct {
+	Files []File `json:"files"`
+	// Regenerated are the files Check rewrote.
+	Regenerated []string `json:"regenerated"`
+	Issues      []Issue  `json:"issues"`
+}
+
+// Generator renders the content region r should hold now.
+type Generator func(root string, r Region) (string, error)
+
+// Options controls Check.
+type Options struct {
+	// Dirs are the directories to search, relative to the root; empty
+	// searches the whole tree.
+	Dirs []string
+	// Generators maps a marker's tool, such as "umbratool codeowners", to
+	// the generator that renders its regions. Regions of other tools are
+	// only checked against their checksum.
+	Generators map[string]Generator
+	// Regenerate rewrites the regions Generators cover. Hand edits are
+	// merged three ways into the new content, with the version the
+	// generator last wrote as the base: its current output when that still
+	// matches the checksum, else the region as found in git history.
+	Regenerate bool
+	// Overwrite makes Regenerate discard hand edits instead of merging.
+	Overwrite bool
+}
+
+// Check finds the generated regions below root, reports those edited by
+// hand or out of date, and with opts.Regenerate rewrites them.
+func Check(root string, opts Options) (Report, error) {
+	dirs := opts.Dirs
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{}, func(rel string) error {
+			if CommentFor(rel) != "" {
+				paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			}
+			return nil
+		})
+		if err != nil {
+			return Report{}, err
+		}
+	}
+	sort.Strings(paths)
+
+	type result struct {
+		file        *File
+		regenerated bool
+		issues      []Issue
+	}
+	results, err := pool.Map(paths, func(rel string) (result, error) {
+		c := &checker{root: root, rel: rel, opts: opts}
+		file, regenerated, err := c.check()
+		return result{file, regenerated, c.issues}, err
+	})
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{Files: []File{}, Regenerated: []string{}, Issues: []Issue{}}
+	for i, r := range results {
+		if r.file != nil {
+			report.Files = append(report.Files, *r.file)
+		}
+		if r.regenerated {
+			report.Regenerated = append(report.Regenerated, paths[i])
+		}
+		report.Issues = append(report.Issues, r.issues...)
+	}
+	return report, nil
+}
+
+type checker struct {
+	root, rel string
+	opts      Options
+	issues    []Issue
+}
+
+func (c *checker) add(kind string, r Region, format string, args ...any) {
+	c.issues = append(c.issues, Issue{Kind: kind, Severity: severity[kind], Module: workspace.ModuleForPath(c.rel),
+		File: c.rel, Line: r.Line, Tool: r.Tool, Message: fmt.Sprintf(format, args...)})
+}
+
+// check returns the file's regions, or nil when it has none, and whether
+// it was rewritten.
+func (c *checker) check() (*File, bool, error) {
+	full := filepath.Join(c.root, filepath.FromSlash(c.rel))
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return nil, false, err
+	}
+	original := string(data)
+	if !strings.Contains(original, "Code generated by ") && !strings.Contains(original, " BEGIN ") {
+		return nil, false, nil
+	}
+	regions, err := Parse(original)
+	if err != nil {
+		c.add(IssueInvalid, Region{}, "%v", err)
+		return nil, false, nil
+	}
+	if len(regions) == 0 {
+		return nil, false, nil
+	}
+
+	// Rewrite from the last region back, so earlier offsets stay valid.
+	text := original
+	for i := len(regions) - 1; i >= 0; i-- {
+		if text, err = c.region(text, regions[i]); err != nil {
+			return nil, false, fmt.Errorf("%s:%d: %w", c.rel, regions[i].Line, err)
+		}
+	}
+	sort.Slice(c.issues, func(i, j int) bool { return c.issues[i].Line < c.issues[j].Line })
+
+	file := &File{Path: c.rel, Regions: regions}
+	if text == original {
+		return file, false, nil
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		return nil, false, err
+	}
+	return file, true, atomicfile.WriteFile(full, []byte(text), info.Mode().Perm())
+}
+
+// region checks r and returns text with r regenerated when that was asked
+// for.
+func (c *checker) region(text string, r Region) (string, error) {
+	generate := c.opts.Generators[r.Tool]
+	if !r.Stamped() && (generate == nil || !c.opts.Regenerate) {
+		c.add(IssueUnstamped, r, "the %s marker records no checksum; regenerate the region to stamp it", r.Tool)
+	}
+	if generate == nil {
+		if r.Edited() {
+			c.add(IssueEdited, r, "edited by hand since %s generated it", r.Tool)
+		}
+		return text, nil
+	}
+
+	fresh, err := generate(c.root, r)
+	if err != nil {
+		return text, err
+	}
+	if r.Kind == KindFence {
+		fresh = terminate(fresh)
+	}
+	if !c.opts.Regenerate {
+		switch {
+		case r.Edited():
+			c.add(IssueEdited, r, "edited by hand since %s generated it; move the change into %s, or merge it with --regenerate", r.Tool, r.Source)
+		case fresh != r.Content:
+			c.add(IssueStale, r, "out of date with %s; run check-generated --regenerate", r.Source)
+		}
+		return text, nil
+	}
+
+	if !r.Edited() || c.opts.Overwrite {
+		return Replace(text, r, fresh, Checksum(fresh)), nil
+	}
+	// While the inputs are unchanged the generator still renders the
+	// base; otherwise look for it in history.
+	base, ok := fresh, Checksum(fresh) == r.Checksum
+	if !ok {
+		if base, ok, err = Base(c.root, c.rel, r); err != nil {
+			return text, err
+		}
+	}
+	if !ok {
+		c.add(IssueNoBase, r, "edited by hand, and no recent commit holds the region as %s generated it; resolve it by hand or discard the edits with --overwrite", r.Tool)
+		return text, nil
+	}
+	merged, conflicts, err := Merge(r.Content, base, fresh)
+	if err != nil {
+		return text, err
+	}
+	// The checksum stays that of the generator's output, so the carried
+	// edits are still reported until they move into the generator.
+	text = Replace(text, r, merged, Checksum(fresh))
+	if conflicts {
+		c.add(IssueConflict, r, "hand edits conflict with the regenerated content; resolve the conflict markers")
+	} else {
+		c.add(IssueMerged, r, "hand edits merged into the regenerated content; move them into %s", r.Source)
+	}
+	return text, nil
+}
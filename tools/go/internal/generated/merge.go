@@ -0,0 +1,68 @@
+package generated
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// BaseDepth is how many commits touching a file Base searches.
+const BaseDepth = 50
+
+// Base returns the content r's generator last wrote: the region as found
+// in the most recent commit of rel where it still matches r's checksum.
+// ok is false when no such commit is among the last BaseDepth.
+func Base(root, rel string, r Region) (content string, ok bool, err error) {
+	out, err := exec.Command("git", "-C", root, "log", "--format=%H", "-n", fmt.Sprint(BaseDepth), "--", rel).Output()
+	if err != nil {
+		return "", false, fmt.Errorf("git log %s: %w", rel, err)
+	}
+	for _, sha := range strings.Fields(string(out)) {
+		old, err := exec.Command("git", "-C", root, "show", sha+":./"+rel).Output()
+		if err != nil {
+			continue
+		}
+		regions, err := Parse(string(old))
+		if err != nil {
+			continue
+		}
+		for _, o := range regions {
+			if o.Kind == r.Kind && o.Tool == r.Tool && Checksum(o.Content) == r.Checksum {
+				return o.Content, true, nil
+			}
+		}
+	}
+	return "", false, nil
+}
+
+// Merge merges the changes from base to current into regenerated with git
+// merge-file. conflicts reports whether the result holds conflict markers.
+func Merge(current, base, regenerated string) (merged string, conflicts bool, err error) {
+	dir, err := os.MkdirTemp("", "check-generated")
+	if err != nil {
+		return "", false, err
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{filepath.Join(dir, "current"), filepath.Join(dir, "base"), filepath.Join(dir, "regenerated")}
+	for i, content := range []string{current, base, regenerated} {
+		if err := os.WriteFile(files[i], []byte(content), 0o644); err != nil {
+			return "", false, err
+		}
+	}
+	args := append([]string{"merge-file", "-p", "-L", "current", "-L", "base", "-L", "regenerated"}, files...)
+	out, err := exec.Command("git", args...).Output()
+	var exit *exec.ExitError
+	switch {
+	case err == nil:
+		return string(out), false, nil
+	case errors.As(err, &exit) && exit.ExitCode() > 0 && exit.ExitCode() < 128:
+		// The exit status is the number of conflicts.
+		return string(out), true, nil
+	default:
+		return "", false, fmt.Errorf("git merge-file: %w", err)
+	}
+}
@@ -0,0 +1,185 @@
+// Package github publishes analyzer results on a GitHub pull request: a
+// summary comment that later runs update in place, and a check run whose
+// annotations show each issue on its line of the diff.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultAPI is the public GitHub REST API.
+const DefaultAPI = "https://api.github.com"
+
+// maxAnnotations is how many annotations the checks API takes per request.
+const maxAnnotations = 50
+
+// Client calls the GitHub REST API for one repository.
+type Client struct {
+	// API is the REST API root, e.g. DefaultAPI or a GitHub Enterprise
+	// Server's https://host/api/v3.
+	API   string
+	Token string
+	// Repo is "owner/name".
+	Repo string
+	HTTP *http.Client
+}
+
+// NewClient returns a client for repo, authenticated with token.
+func NewClient(api, token, repo string) *Client {
+	if api == "" {
+		api = DefaultAPI
+	}
+	return &Client{API: strings.TrimSuffix(api, "/"), Token: token, Repo: repo, HTTP: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// do sends in as the JSON body of a request to path, below the
+// repository, and decodes the response into out unless it is nil.
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.API+"/repos/"+c.Repo+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &e) != nil || e.Message == "" {
+			e.Message = strings.TrimSpace(string(data))
+		}
+		return fmt.Errorf("github: %s %s: %s: %s", method, path, resp.Status, e.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// PullHead returns the head commit of pull request pr.
+func (c *Client) PullHead(ctx context.Context, pr int) (string, error) {
+	var pull struct {
+		Head struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	}
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/pulls/%d", pr), nil, &pull); err != nil {
+		return "", err
+	}
+	return pull.Head.SHA, nil
+}
+
+// UpsertComment edits the first comment on pull request pr that contains
+// marker to read body, or adds body as a new comment if there is none.
+// It reports whether it added one.
+func (c *Client) UpsertComment(ctx context.Context, pr int, marker, body string) (bool, error) {
+	type comment struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
+	}
+	for page := 1; ; page++ {
+		var comments []comment
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/issues/%d/comments?per_page=100&page=%d", pr, page), nil, &comments); err != nil {
+			return false, err
+		}
+		for _, cm := range comments {
+			if strings.Contains(cm.Body, marker) {
+				return false, c.do(ctx, http.MethodPatch, fmt.Sprintf("/issues/comments/%d", cm.ID), map[string]string{"body": body}, nil)
+			}
+		}
+		if len(comments) < 100 {
+			break
+		}
+	}
+	return true, c.do(ctx, http.MethodPost, fmt.Sprintf("/issues/%d/comments", pr), map[string]string{"body": body}, nil)
+}
+
+// Annotation is one issue shown on a line of a check run's diff.
+type Annotation struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	// Level is "notice", "warning" or "failure".
+	Level   string `json:"annotation_level"`
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message"`
+	// RawDetails carries the issue's fingerprint, which identifies it
+	// across runs.
+	RawDetails string `json:"raw_details,omitempty"`
+}
+
+// CheckRun is a completed check run.
+type CheckRun struct {
+	Name    string
+	HeadSHA string
+	// Conclusion is "success", "neutral" or "failure".
+	Conclusion  string
+	Title       string
+	Summary     string
+	Annotations []Annotation
+}
+
+// CreateCheckRun creates run on its head commit. The checks API takes 50
+// annotations a request, so any beyond those are added by updating it.
+func (c *Client) CreateCheckRun(ctx context.Context, run CheckRun) error {
+	type output struct {
+		Title       string       `json:"title"`
+		Summary     string       `json:"summary"`
+		Annotations []Annotation `json:"annotations,omitempty"`
+	}
+	batch := func(i int) []Annotation {
+		return run.Annotations[i:min(i+maxAnnotations, len(run.Annotations))]
+	}
+
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	err := c.do(ctx, http.MethodPost, "/check-runs", map[string]any{
+		"name":       run.Name,
+		"head_sha":   run.HeadSHA,
+		"status":     "completed",
+		"conclusion": run.Conclusion,
+		"output":     output{run.Title, run.Summary, batch(0)},
+	}, &created)
+	if err != nil {
+		return err
+	}
+	for i := maxAnnotations; i < len(run.Annotations); i += maxAnnotations {
+		err := c.do(ctx, http.MethodPatch, fmt.Sprintf("/check-runs/%d", created.ID), map[string]any{
+			"output": output{run.Title, run.Summary, batch(i)},
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+)
+
+// commentIssues is how many issues the summary comment lists; the check
+// run's annotations show them all.
+const commentIssues = 25
+
+// Marker identifies tool's summary comment, so a later run edits it
+// instead of adding another.
+func Marker(tool string) string {
+	return fmt.Sprintf("<!-- umbratool:%s -->", tool)
+}
+
+// Summary renders tool's issues as the body of its pull request comment.
+func Summary(tool, commit string, issues []store.Issue) string {
+	var b strings.Builder
+	b.WriteString(Marker(tool) + "\n")
+	fmt.Fprintf(&b, "### umbratool %s\n\n", tool)
+	if len(issues) == 0 {
+		fmt.Fprintf(&b, "No issues at `%s`.\n", short(commit))
+		return b.String()
+	}
+
+	byKind := make(map[string]int)
+	modules := make(map[string]bool)
+	for _, i := range issues {
+		byKind[i.Kind]++
+		if i.Module != "" {
+			modules[i.Module] = true
+		}
+	}
+	kinds := make([]string, 0, len(byKind))
+	for k := range byKind {
+		kinds = append(kinds, k)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if byKind[kinds[i]] != byKind[kinds[j]] {
+			return byKind[kinds[i]] > byKind[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+
+	fmt.Fprintf(&b, "**%d issues in %d modules** at `%s`\n\n", len(issues), len(modules), short(commit))
+	b.WriteString("| Kind | Issues |\n|------|--------|\n")
+	for _, k := range kinds {
+		fmt.Fprintf(&b, "| %s | %d |\n", k, byKind[k])
+	}
+
+	b.WriteString("\n<details><summary>Issues</summary>\n\n")
+	for _, i := range issues[:min(commentIssues, len(issues))] {
+		loc := i.File
+		if i.Line > 0 {
+			loc = fmt.Sprintf("%s:%d", i.File, i.Line)
+		}
+		fmt.Fprintf(&b, "- `%s` %s: %s\n", loc, title(i), i.Message)
+	}
+	if len(issues) > commentIssues {
+		fmt.Fprintf(&b, "\n%d more are annotated on the diff.\n", len(issues)-commentIssues)
+	}
+	b.WriteString("\n</details>\n")
+	return b.String()
+}
+
+// title names the kind of an issue and, when it has one, the maturity
+// tier of its module, so reviewers can tell a frozen module's issues from
+// an experimental one's.
+func title(i store.Issue) string {
+	if i.Tier == "" {
+		return i.Kind
+	}
+	return i.Kind + " (" + i.Tier + ")"
+}
+
+func short(commit string) string {
+	sha, dirty := strings.CutSuffix(commit, "-dirty")
+	sha = sha[:min(12, len(sha))]
+	if dirty {
+		sha += "-dirty"
+	}
+	return sha
+}
+
+// Publish updates tool's summary comment on pull request pr and adds a
+// check run on its head commit, annotating every issue with a file and
+// line. commit is the analysed commit, as store.GitSHA gives it.
+func Publish(ctx context.Context, c *Client, pr int, tool, commit string, issues []store.Issue) error {
+	if _, err := c.UpsertComment(ctx, pr, Marker(tool), Summary(tool, commit, issues)); err != nil {
+		return err
+	}
+	head, err := c.PullHead(ctx, pr)
+	if err != nil {
+		return err
+	}
+
+	run := CheckRun{
+		Name:       "umbratool " + tool,
+		HeadSHA:    head,
+		Conclusion: "success",
+		Title:      "No issues",
+		Summary:    fmt.Sprintf("umbratool %s found no issues.", tool),
+	}
+	if len(issues) > 0 {
+		run.Conclusion = "neutral"
+		run.Title = fmt.Sprintf("%d issues", len(issues))
+		run.Summary = strings.TrimPrefix(Summary(tool, commit, issues), Marker(tool)+"\n")
+	}
+	for _, i := range issues {
+		if i.File == "" || i.Line <= 0 {
+			continue
+		}
+		annotation := Annotation{
+			Path:      i.File,
+			StartLine: i.Line,
+			EndLine:   i.Line,
+			Level:     "warning",
+			Title:     title(i),
+			Message:   i.Message,
+		}
+		if i.Fingerprint != "" {
+			annotation.RawDetails = "fingerprint " + i.Fingerprint
+		}
+		run.Annotations = append(run.Annotations, annotation)
+	}
+	return c.CreateCheckRun(ctx, run)
+}
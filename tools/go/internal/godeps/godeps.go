@@ -0,0 +1,314 @@
+package godeps
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// Issue kinds.
+const (
+	// IssueUnused is a direct requirement no package imports.
+	IssueUnused = "unused_requirement"
+	// IssueMissing is an import of a module the go.mod does not require.
+	IssueMissing = "missing_requirement"
+	// IssueIndirect is a requirement marked indirect that a package
+	// imports directly.
+	IssueIndirect = "marked_indirect"
+)
+
+// Issue is one mismatch between a go.mod file and the imports.
+type Issue struct {
+	Kind string `json:"kind"`
+	// File is the go.mod file, relative to the root.
+	File        string `json:"file"`
+	Line        int    `json:"line,omitempty"`
+	Path        string `json:"path"`
+	Message     string `json:"message"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Package is one Go package of a module.
+type Package struct {
+	Path string `json:"path"`
+	// Dir is the package directory, relative to the root.
+	Dir  string `json:"dir"`
+	Main bool   `json:"main"`
+	// Imports are the imports of the package's non-test files, sorted.
+	Imports []string `json:"imports"`
+	// TestImports are those only its _test.go files add.
+	TestImports []string `json:"testImports,omitempty"`
+}
+
+// Tool is a main package and the third-party modules it pulls in,
+// through the module's own packages.
+type Tool struct {
+	Name    string   `json:"name"`
+	Package string   `json:"package"`
+	Modules []string `json:"modules"`
+}
+
+// Module is one go.mod file and what imports its requirements.
+type Module struct {
+	// Dir is the go.mod file's directory, relative to the root.
+	Dir      string    `json:"dir"`
+	ModFile  *ModFile  `json:"modFile"`
+	Packages []Package `json:"packages"`
+	Tools    []Tool    `json:"tools"`
+	// Importers maps each requirement to the packages importing it,
+	// directly or, for test-only importers, from their tests.
+	Importers map[string][]string `json:"importers"`
+}
+
+// Report is the result of Analyse.
+type Report struct {
+	Modules []Module `json:"modules"`
+	Issues  []Issue  `json:"issues"`
+}
+
+// Analyse finds the go.mod files below root and the packages each one
+// holds: every directory with .go files up to the next go.mod below it.
+// vendor and testdata directories are skipped.
+func Analyse(root string) (Report, error) {
+	var modFiles, goFiles []string
+	err := walker.Walk(root, walker.Options{}, func(rel string) error {
+		rel = filepath.ToSlash(rel)
+		for _, part := range strings.Split(path.Dir(rel), "/") {
+			if part == "vendor" || part == "testdata" {
+				return nil
+			}
+		}
+		switch {
+		case path.Base(rel) == "go.mod":
+			modFiles = append(modFiles, rel)
+		case strings.HasSuffix(rel, ".go"):
+			goFiles = append(goFiles, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return Report{}, err
+	}
+	sort.Strings(modFiles)
+	sort.Strings(goFiles)
+
+	report := Report{Modules: []Module{}, Issues: []Issue{}}
+	byDir := make(map[string]*Module)
+	local := make(map[string]bool)
+	for _, rel := range modFiles {
+		mf, err := ParseModFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			return Report{}, err
+		}
+		report.Modules = append(report.Modules, Module{Dir: path.Dir(rel), ModFile: mf, Packages: []Package{}, Tools: []Tool{}, Importers: map[string][]string{}})
+		local[mf.Module] = true
+	}
+	for i := range report.Modules {
+		byDir[report.Modules[i].Dir] = &report.Modules[i]
+	}
+
+	packages := make(map[string]*Package)
+	var order []string
+	fset := token.NewFileSet()
+	for _, rel := range goFiles {
+		m := owner(byDir, path.Dir(rel))
+		if m == nil {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(root, filepath.FromSlash(rel)), nil, parser.ImportsOnly)
+		if err != nil {
+			return Report{}, err
+		}
+		dir := path.Dir(rel)
+		p := packages[dir]
+		if p == nil {
+			p = &Package{Path: importPath(m, dir), Dir: dir, Imports: []string{}}
+			packages[dir] = p
+			order = append(order, dir)
+		}
+		test := strings.HasSuffix(rel, "_test.go")
+		if f.Name.Name == "main" && !test {
+			p.Main = true
+		}
+		for _, spec := range f.Imports {
+			imp, _ := strconv.Unquote(spec.Path.Value)
+			if test {
+				p.TestImports = append(p.TestImports, imp)
+			} else {
+				p.Imports = append(p.Imports, imp)
+			}
+		}
+	}
+	for _, dir := range order {
+		p := packages[dir]
+		p.Imports = unique(p.Imports)
+		p.TestImports = subtract(unique(p.TestImports), p.Imports)
+		m := owner(byDir, dir)
+		m.Packages = append(m.Packages, *p)
+	}
+
+	for i := range report.Modules {
+		report.Issues = append(report.Issues, analyse(&report.Modules[i], local)...)
+	}
+	return report, nil
+}
+
+// owner returns the module whose go.mod is nearest above dir.
+func owner(byDir map[string]*Module, dir string) *Module {
+	for {
+		if m, ok := byDir[dir]; ok {
+			return m
+		}
+		if dir == "." {
+			return nil
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+func importPath(m *Module, dir string) string {
+	if dir == m.Dir {
+		return m.ModFile.Module
+	}
+	rel := strings.TrimPrefix(dir, m.Dir+"/")
+	if m.Dir == "." {
+		rel = dir
+	}
+	return m.ModFile.Module + "/" + rel
+}
+
+// analyse fills in the module's tools and importers and checks its
+// requirements.
+func analyse(m *Module, local map[string]bool) []Issue {
+	file := path.Join(m.Dir, "go.mod")
+	var issues []Issue
+	add := func(kind string, line int, p, format string, args ...any) {
+		issues = append(issues, Issue{Kind: kind, File: file, Line: line, Path: p, Message: fmt.Sprintf(format, args...)})
+	}
+
+	own := make(map[string]*Package, len(m.Packages))
+	for i := range m.Packages {
+		own[m.Packages[i].Path] = &m.Packages[i]
+	}
+	direct := make(map[string]bool)
+	missing := make(map[string]bool)
+	for _, p := range m.Packages {
+		for _, imp := range append(append([]string(nil), p.Imports...), p.TestImports...) {
+			if own[imp] != nil || isStd(imp) || within(imp, m.ModFile.Module) {
+				continue
+			}
+			req, ok := m.requirement(imp)
+			switch {
+			case ok:
+				direct[req] = true
+				m.Importers[req] = append(m.Importers[req], p.Path)
+			case !localModule(imp, local) && !missing[imp]:
+				missing[imp] = true
+				add(IssueMissing, 0, imp, "%s imports %s, which no requirement provides", p.Path, imp)
+			}
+		}
+	}
+	for req := range m.Importers {
+		m.Importers[req] = unique(m.Importers[req])
+	}
+
+	for _, r := range m.ModFile.Requires {
+		switch {
+		case !r.Indirect && !direct[r.Path]:
+			add(IssueUnused, r.Line, r.Path, "%s is required but no package imports it", r.Path)
+		case r.Indirect && direct[r.Path]:
+			add(IssueIndirect, r.Line, r.Path, "%s is marked indirect but %s imports it", r.Path, strings.Join(m.Importers[r.Path], ", "))
+		}
+	}
+
+	for _, p := range m.Packages {
+		if !p.Main {
+			continue
+		}
+		modules := make(map[string]bool)
+		seen := make(map[string]bool)
+		var visit func(p *Package)
+		visit = func(p *Package) {
+			if seen[p.Path] {
+				return
+			}
+			seen[p.Path] = true
+			for _, imp := range p.Imports {
+				if dep := own[imp]; dep != nil {
+					visit(dep)
+				} else if req, ok := m.requirement(imp); ok {
+					modules[req] = true
+				}
+			}
+		}
+		visit(own[p.Path])
+		tool := Tool{Name: path.Base(p.Dir), Package: p.Path, Modules: []string{}}
+		for mod := range modules {
+			tool.Modules = append(tool.Modules, mod)
+		}
+		sort.Strings(tool.Modules)
+		m.Tools = append(m.Tools, tool)
+	}
+	return issues
+}
+
+// requirement returns the requirement providing the package imp: the one
+// with the longest path that is imp or a prefix of it.
+func (m *Module) requirement(imp string) (string, bool) {
+	best := ""
+	for _, r := range m.ModFile.Requires {
+		if within(imp, r.Path) && len(r.Path) > len(best) {
+			best = r.Path
+		}
+	}
+	return best, best != ""
+}
+
+func localModule(imp string, local map[string]bool) bool {
+	for mod := range local {
+		if within(imp, mod) {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether the package imp belongs to the module mod.
+func within(imp, mod string) bool {
+	return imp == mod || strings.HasPrefix(imp, mod+"/")
+}
+
+// isStd reports whether imp is a standard library package: its first
+// element has no dot.
+func isStd(imp string) bool {
+	first, _, _ := strings.Cut(imp, "/")
+	return !strings.Contains(first, ".")
+}
+
+func unique(list []string) []string {
+	sort.Strings(list)
+	out := list[:0]
+	for i, s := range list {
+		if i == 0 || s != list[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func subtract(list, remove []string) []string {
+	var out []string
+	for _, s := range list {
+		if i := sort.SearchStrings(remove, s); i == len(remove) || remove[i] != s {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+// Package godeps maps the Go tools in the workspace to the third-party
+// modules they import, and checks go.mod requirements against those
+// imports.
+package godeps
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+)
+
+// Require is one requirement of a go.mod file.
+type Require struct {
+	Path     string `json:"path"`
+	Version  string `json:"version"`
+	Indirect bool   `json:"indirect"`
+	Line     int    `json:"line"`
+}
+
+// ModFile is the part of a go.mod file godeps needs.
+type ModFile struct {
+	Module   string    `json:"module"`
+	Go       string    `json:"go,omitempty"`
+	Requires []Require `json:"requires"`
+	// Replaced are the module paths with a replace directive.
+	Replaced []string `json:"replaced,omitempty"`
+}
+
+// ParseModFile reads the module, go, require and replace directives of a
+// go.mod file, in single-line and block form.
+func ParseModFile(file string) (*ModFile, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	mf := &ModFile{Requires: []Require{}}
+	block := ""
+	scanner := textscan.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		indirect := strings.Contains(line, "// indirect")
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			fields = append([]string{block}, fields...)
+		} else if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+
+		switch fields[0] {
+		case "module":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("%s:%d: module without a path", file, lineNo)
+			}
+			mf.Module = strings.Trim(fields[1], `"`)
+		case "go":
+			if len(fields) > 1 {
+				mf.Go = fields[1]
+			}
+		case "require":
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("%s:%d: malformed require", file, lineNo)
+			}
+			mf.Requires = append(mf.Requires, Require{Path: strings.Trim(fields[1], `"`), Version: fields[2], Indirect: indirect, Line: lineNo})
+		case "replace":
+			if len(fields) > 1 {
+				mf.Replaced = append(mf.Replaced, strings.Trim(fields[1], `"`))
+			}
+		}
+	}
+	if err := textscan.Check(file, lineNo, scanner.Err()); err != nil {
+		return nil, err
+	}
+	if mf.Module == "" {
+		return nil, fmt.Errorf("%s: no module directive", file)
+	}
+	return mf, nil
+}
@@ -0,0 +1,141 @@
+package godeps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteMarkdown writes, per module, the tools and the modules they use,
+// each requirement's importers, and the issues.
+func WriteMarkdown(w io.Writer, r Report) error {
+	var b strings.Builder
+	b.WriteString("# Go Tool Dependencies\n\n")
+	fmt.Fprintf(&b, "**%d modules, %d issues**\n", len(r.Modules), len(r.Issues))
+
+	for _, m := range r.Modules {
+		fmt.Fprintf(&b, "\n## %s\n\n", m.ModFile.Module)
+		fmt.Fprintf(&b, "`%s`, %d packages\n", relFile(m.Dir), len(m.Packages))
+
+		if len(m.Tools) > 0 {
+			b.WriteString("\n| Tool | Third-party modules |\n")
+			b.WriteString("|------|---------------------|\n")
+			for _, t := range m.Tools {
+				fmt.Fprintf(&b, "| %s | %s |\n", t.Name, codeList(t.Modules))
+			}
+		}
+
+		if len(m.ModFile.Requires) > 0 {
+			b.WriteString("\n| Requirement | Version | Imported by |\n")
+			b.WriteString("|-------------|---------|-------------|\n")
+			for _, req := range m.ModFile.Requires {
+				importers := "-"
+				if list := m.Importers[req.Path]; len(list) > 0 {
+					importers = codeList(trimModule(list, m.ModFile.Module))
+				} else if req.Indirect {
+					importers = "(indirect)"
+				}
+				fmt.Fprintf(&b, "| `%s` | %s | %s |\n", req.Path, req.Version, importers)
+			}
+		}
+	}
+
+	if len(r.Issues) > 0 {
+		b.WriteString("\n## Issues\n\n")
+		for _, i := range r.Issues {
+			loc := i.File
+			if i.Line > 0 {
+				loc = fmt.Sprintf("%s:%d", i.File, i.Line)
+			}
+			fmt.Fprintf(&b, "- `%s` %s: %s\n", loc, i.Kind, i.Message)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteDot writes a Graphviz graph with an edge from each tool to each
+// third-party module it uses. Requirements nothing imports are drawn
+// dashed.
+func WriteDot(w io.Writer, r Report) error {
+	var b strings.Builder
+	b.WriteString("digraph godeps {\n  rankdir=LR;\n  node [fontname=\"Helvetica\"];\n")
+	for i, m := range r.Modules {
+		fmt.Fprintf(&b, "  subgraph cluster_%d {\n    label=%q;\n", i, m.ModFile.Module)
+		for _, t := range m.Tools {
+			fmt.Fprintf(&b, "    %q [shape=box];\n", t.Package)
+		}
+		b.WriteString("  }\n")
+	}
+
+	unused := make(map[string]bool)
+	for _, i := range r.Issues {
+		if i.Kind == IssueUnused {
+			unused[i.Path] = true
+		}
+	}
+	var nodes []string
+	seen := make(map[string]bool)
+	for _, m := range r.Modules {
+		for _, req := range m.ModFile.Requires {
+			if !seen[req.Path] && (len(m.Importers[req.Path]) > 0 || unused[req.Path]) {
+				seen[req.Path] = true
+				nodes = append(nodes, req.Path)
+			}
+		}
+	}
+	sort.Strings(nodes)
+	for _, n := range nodes {
+		style := ""
+		if unused[n] {
+			style = ", style=dashed, color=red"
+		}
+		fmt.Fprintf(&b, "  %q [shape=ellipse%s];\n", n, style)
+	}
+	for _, m := range r.Modules {
+		for _, t := range m.Tools {
+			for _, mod := range t.Modules {
+				fmt.Fprintf(&b, "  %q -> %q;\n", t.Package, mod)
+			}
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func relFile(dir string) string {
+	if dir == "." {
+		return "go.mod"
+	}
+	return dir + "/go.mod"
+}
+
+// trimModule shortens package paths to their path within module.
+func trimModule(list []string, module string) []string {
+	out := make([]string, len(list))
+	for i, p := range list {
+		out[i] = strings.TrimPrefix(strings.TrimPrefix(p, module), "/")
+		if out[i] == "" {
+			out[i] = "."
+		}
+	}
+	return out
+}
+
+func codeList(list []string) string {
+	if len(list) == 0 {
+		return "-"
+	}
+	return "`" + strings.Join(list, "`, `") + "`"
+}
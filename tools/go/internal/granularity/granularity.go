@@ -0,0 +1,242 @@
+// Package granularity advises on the size of the workspace's Swift
+// targets. Tiny libraries with a single dependent add a target, a module
+// boundary and an action's fixed cost for no reuse, so they are candidates
+// for merging into that dependent. Very large libraries compile as one
+// action however many cores are free, so those whose sources fall into
+// several subdirectories are candidates for splitting along them.
+package granularity
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/complexity"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+)
+
+// maxShare is the largest share of a target's code one part of a split may
+// hold. Past it the other parts compile alongside too little to matter.
+const maxShare = 0.8
+
+// Options configures the advisor.
+type Options struct {
+	// Rules are the workspace's Swift rules.
+	Rules []modulenames.Rule
+	// Small is the most lines of code a merge candidate has.
+	Small int
+	// Large is the fewest lines of code a split candidate has.
+	Large int
+	// Times are the targets' action times from a build profile, by label.
+	// Without them the impact is estimated from lines of code alone.
+	Times map[string]time.Duration
+}
+
+// Merge suggests folding a tiny target into its only dependent.
+type Merge struct {
+	Label  string `json:"label"`
+	Module string `json:"module"`
+	// File is the BUILD file declaring Label.
+	File  string `json:"file"`
+	Code  int    `json:"code"`
+	Files int    `json:"files"`
+	// Into is the only target depending on Label.
+	Into string `json:"into"`
+	// MergedCode is the code of Into once Label is folded into it.
+	MergedCode int `json:"mergedCode"`
+	// Time is Label's action time in the profile.
+	Time time.Duration `json:"timeNs,omitempty"`
+	// Saving is the fixed cost of an action estimated from the profile,
+	// which the merge removes, at most Time.
+	Saving      time.Duration `json:"savingNs,omitempty"`
+	Fingerprint string        `json:"fingerprint,omitempty"`
+}
+
+// Part is one subdirectory of a split candidate.
+type Part struct {
+	// Dir is the subdirectory, relative to the target's source directory;
+	// "." holds the files directly in it.
+	Dir   string `json:"dir"`
+	Code  int    `json:"code"`
+	Files int    `json:"files"`
+}
+
+// Split suggests splitting a large target along its subdirectories.
+type Split struct {
+	Label     string `json:"label"`
+	Module    string `json:"module"`
+	File      string `json:"file"`
+	SourceDir string `json:"sourceDir"`
+	Code      int    `json:"code"`
+	Files     int    `json:"files"`
+	// Parts are the subdirectories, largest first.
+	Parts []Part `json:"parts"`
+	// LargestShare is the share of Code in the largest part, which bounds
+	// how much faster the parts compile side by side.
+	LargestShare float64 `json:"largestShare"`
+	// Time is Label's action time in the profile.
+	Time time.Duration `json:"timeNs,omitempty"`
+	// Saving is the time the parts would save compiling in parallel, at
+	// best: Time less the largest part's share of it.
+	Saving      time.Duration `json:"savingNs,omitempty"`
+	Fingerprint string        `json:"fingerprint,omitempty"`
+}
+
+// Advice is the advisor's result.
+type Advice struct {
+	Merges []Merge `json:"merges"`
+	Splits []Split `json:"splits"`
+	// Profiled is set when action times were available.
+	Profiled bool `json:"profiled"`
+}
+
+type size struct {
+	code, files int
+	// parts holds the code and files of each subdirectory.
+	parts map[string]*Part
+}
+
+// Advise measures the targets' code from counts and suggests merges and
+// splits. Test targets are never candidates, nor are merges into one.
+func Advise(counts *complexity.Report, opts Options) *Advice {
+	index := &moduleindex.Index{Modules: opts.Rules}
+	byLabel := make(map[string]modulenames.Rule, len(opts.Rules))
+	for _, r := range opts.Rules {
+		byLabel[r.Label] = r
+	}
+
+	sizes := make(map[string]*size)
+	for _, f := range counts.Files {
+		r, ok := index.ForPath(f.Path)
+		if !ok {
+			continue
+		}
+		s := sizes[r.Label]
+		if s == nil {
+			s = &size{parts: make(map[string]*Part)}
+			sizes[r.Label] = s
+		}
+		s.code += f.Code
+		s.files++
+		dir := "."
+		if rest, ok := strings.CutPrefix(f.Path, r.SourceDir+"/"); ok && strings.Contains(rest, "/") {
+			dir, _, _ = strings.Cut(rest, "/")
+		}
+		p := s.parts[dir]
+		if p == nil {
+			p = &Part{Dir: dir}
+			s.parts[dir] = p
+		}
+		p.Code += f.Code
+		p.Files++
+	}
+
+	dependents := make(map[string][]string)
+	for _, r := range opts.Rules {
+		for _, dep := range r.Deps {
+			if _, ok := byLabel[dep]; ok && dep != r.Label {
+				dependents[dep] = append(dependents[dep], r.Label)
+			}
+		}
+	}
+
+	fixed := fixedCost(sizes, opts.Times)
+	advice := &Advice{Merges: []Merge{}, Splits: []Split{}, Profiled: len(opts.Times) > 0}
+	for _, r := range opts.Rules {
+		s := sizes[r.Label]
+		if s == nil || isTest(r.Kind) {
+			continue
+		}
+		t := opts.Times[r.Label]
+		if users := dependents[r.Label]; s.code <= opts.Small && len(users) == 1 && !isTest(byLabel[users[0]].Kind) {
+			m := Merge{Label: r.Label, Module: r.ModuleName, File: r.File, Code: s.code, Files: s.files, Into: users[0], MergedCode: s.code, Time: t}
+			if into := sizes[users[0]]; into != nil {
+				m.MergedCode += into.code
+			}
+			if t > 0 {
+				m.Saving = min(fixed, t)
+			}
+			advice.Merges = append(advice.Merges, m)
+		}
+		if s.code >= opts.Large && len(s.parts) > 1 {
+			sp := Split{Label: r.Label, Module: r.ModuleName, File: r.File, SourceDir: r.SourceDir, Code: s.code, Files: s.files, Time: t}
+			for _, p := range s.parts {
+				sp.Parts = append(sp.Parts, *p)
+			}
+			sort.Slice(sp.Parts, func(i, j int) bool {
+				if sp.Parts[i].Code != sp.Parts[j].Code {
+					return sp.Parts[i].Code > sp.Parts[j].Code
+				}
+				return sp.Parts[i].Dir < sp.Parts[j].Dir
+			})
+			sp.LargestShare = float64(sp.Parts[0].Code) / float64(max(s.code, 1))
+			if sp.LargestShare > maxShare {
+				continue
+			}
+			if t > 0 {
+				sp.Saving = t - time.Duration(float64(t)*sp.LargestShare)
+			}
+			advice.Splits = append(advice.Splits, sp)
+		}
+	}
+	sort.Slice(advice.Merges, func(i, j int) bool {
+		a, b := advice.Merges[i], advice.Merges[j]
+		if a.Saving != b.Saving {
+			return a.Saving > b.Saving
+		}
+		if a.Code != b.Code {
+			return a.Code < b.Code
+		}
+		return a.Label < b.Label
+	})
+	sort.Slice(advice.Splits, func(i, j int) bool {
+		a, b := advice.Splits[i], advice.Splits[j]
+		if a.Saving != b.Saving {
+			return a.Saving > b.Saving
+		}
+		if a.Code != b.Code {
+			return a.Code > b.Code
+		}
+		return a.Label < b.Label
+	})
+	return advice
+}
+
+// fixedCost estimates what an action costs whatever the size of its
+// target: the intercept of a least-squares fit of the profiled targets'
+// times against their code. It is 0 with fewer than two profiled targets.
+func fixedCost(sizes map[string]*size, times map[string]time.Duration) time.Duration {
+	var n, sx, sy, sxx, sxy float64
+	for label, s := range sizes {
+		t := times[label]
+		if t <= 0 {
+			continue
+		}
+		x, y := float64(s.code), float64(t)
+		n++
+		sx += x
+		sy += y
+		sxx += x * x
+		sxy += x * y
+	}
+	if n < 2 || n*sxx == sx*sx {
+		return 0
+	}
+	slope := (n*sxy - sx*sy) / (n*sxx - sx*sx)
+	return time.Duration(max((sy-slope*sx)/n, 0))
+}
+
+// PartLabel suggests the label of the target a split part would become.
+func PartLabel(s Split, p Part) string {
+	if p.Dir == "." {
+		return s.Label
+	}
+	_, name, _ := strings.Cut(s.Label, ":")
+	return "//" + path.Join(s.SourceDir, p.Dir) + ":" + name + p.Dir
+}
+
+func isTest(kind string) bool {
+	return strings.Contains(kind, "test")
+}
@@ -0,0 +1,61 @@
+package granularity
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// traceEvent is the part of a Chrome trace event the profile reader uses.
+type traceEvent struct {
+	Phase    string  `json:"ph"`
+	Duration float64 `json:"dur"`
+	Args     struct {
+		Target string `json:"target"`
+	} `json:"args"`
+}
+
+// LoadProfile reads the profile Bazel writes with --profile, gzipped or
+// not, and returns the time of each target's actions, by label.
+func LoadProfile(file string) (map[string]time.Duration, error) {
+	fh, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	var r io.Reader = bufio.NewReader(fh)
+	if magic, err := r.(*bufio.Reader).Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var profile struct {
+		TraceEvents []traceEvent `json:"traceEvents"`
+	}
+	if err := json.NewDecoder(r).Decode(&profile); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	times := make(map[string]time.Duration)
+	for _, e := range profile.TraceEvents {
+		if e.Phase != "X" || e.Args.Target == "" {
+			continue
+		}
+		// Bazel 7 names main-repository targets @@//pkg:name.
+		label := strings.TrimLeft(e.Args.Target, "@")
+		if !strings.HasPrefix(label, "//") {
+			continue
+		}
+		times[label] += time.Duration(e.Duration * float64(time.Microsecond))
+	}
+	return times, nil
+}
@@ -0,0 +1,71 @@
+package granularity
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteMarkdown writes the merge and split candidates with their estimated
+// impact, and each split's parts.
+func WriteMarkdown(w io.Writer, a *Advice, opts Options) error {
+	var b strings.Builder
+	b.WriteString("# Target Granularity\n\n")
+	fmt.Fprintf(&b, "**%d merge and %d split candidates** (merge at most %d lines of code with one dependent, split at least %d).\n", len(a.Merges), len(a.Splits), opts.Small, opts.Large)
+	if !a.Profiled {
+		b.WriteString("\nNo build profile was given, so the impact is estimated from lines of code alone. Pass `--profile` with the output of `bazel build --profile=profile.json.gz` for times.\n")
+	}
+
+	b.WriteString("\n## Merge Candidates\n\n")
+	if len(a.Merges) == 0 {
+		b.WriteString("None.\n")
+	} else {
+		b.WriteString("Each merge removes a target and a module boundary")
+		if a.Profiled {
+			b.WriteString(", and with it the fixed cost of an action, estimated from the profile")
+		}
+		b.WriteString(".\n\n| Target | Lines | Files | Merge into | Lines after | Time | Saving |\n|--------|-------|-------|------------|-------------|------|--------|\n")
+		for _, m := range a.Merges {
+			fmt.Fprintf(&b, "| `%s` | %d | %d | `%s` | %d | %s | %s |\n", m.Label, m.Code, m.Files, m.Into, m.MergedCode, duration(m.Time), duration(m.Saving))
+		}
+	}
+
+	b.WriteString("\n## Split Candidates\n\n")
+	if len(a.Splits) == 0 {
+		b.WriteString("None.\n")
+	} else {
+		b.WriteString("Compiled side by side, the parts take as long as the largest of them at best. Check that they do not depend on each other in a cycle before splitting.\n\n")
+		b.WriteString("| Target | Lines | Files | Parts | Largest part | Time | Saving |\n|--------|-------|-------|-------|--------------|------|--------|\n")
+		for _, s := range a.Splits {
+			fmt.Fprintf(&b, "| `%s` | %d | %d | %d | %.0f%% | %s | %s |\n", s.Label, s.Code, s.Files, len(s.Parts), s.LargestShare*100, duration(s.Time), duration(s.Saving))
+		}
+		for _, s := range a.Splits {
+			fmt.Fprintf(&b, "\n### %s\n\n| Part | Lines | Files | Suggested target |\n|------|-------|-------|------------------|\n", s.Label)
+			for _, p := range s.Parts {
+				dir := p.Dir
+				if dir == "." {
+					dir = "(top level)"
+				}
+				fmt.Fprintf(&b, "| %s | %d | %d | `%s` |\n", dir, p.Code, p.Files, PartLabel(s, p))
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the advice as indented JSON.
+func WriteJSON(w io.Writer, a *Advice) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+func duration(d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
@@ -0,0 +1,246 @@
+// Package header checks that source files start with the standard UmbraCore
+// header comment and can insert or refresh it from a template.
+package header
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// DefaultTemplate mirrors the header written by the module generator.
+// Placeholders: {{file}}, {{module}} and {{year}}.
+const DefaultTemplate = `// {{file}}
+// {{module}}
+//
+// Part of UmbraCore project
+//
+`
+
+// DefaultMarker identifies a compliant header. Older files carry the
+// "Created as part of the UmbraCore ..." wording, which is also accepted.
+const DefaultMarker = `(?i)part of (the )?UmbraCore`
+
+// DefaultExcludes are path globs (matched against the slash-separated path
+// relative to the root) for generated and third-party files.
+var DefaultExcludes = []string{
+	"**/generated_code/**",
+	"**/ThirdParty/**",
+	"**/Vendor/**",
+	"**/external/**",
+	"**/*.generated.swift",
+	"**/Package.swift",
+}
+
+// generatedMarkers flag files produced by a tool within their first lines.
+var generatedMarkers = []string{"DO NOT EDIT", "Code generated", "Generated by", "@generated"}
+
+// Status describes the header state of a file.
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusMissing  Status = "missing"
+	StatusOutdated Status = "outdated"
+)
+
+// Result is the header state of one file.
+type Result struct {
+	File        string `json:"file"`
+	Module      string `json:"module"`
+	Status      Status `json:"status"`
+	Detail      string `json:"detail,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Checker verifies and fixes headers.
+type Checker struct {
+	Template   string
+	Marker     *regexp.Regexp
+	Extensions []string
+	Excludes   []string
+}
+
+// NewChecker returns a Checker for Swift files using the default template,
+// marker and exclusions.
+func NewChecker() *Checker {
+	return &Checker{
+		Template:   DefaultTemplate,
+		Marker:     regexp.MustCompile(DefaultMarker),
+		Extensions: []string{".swift"},
+		Excludes:   DefaultExcludes,
+	}
+}
+
+// Check walks root and returns the status of every file that is not
+// excluded.
+func (c *Checker) Check(root string) ([]Result, error) {
+	var results []Result
+	err := walker.Walk(root, walker.Options{Extensions: c.Extensions}, func(rel string) error {
+		if c.excluded(rel) {
+			return nil
+		}
+
+		content, err := os.ReadFile(filepath.Join(root, rel))
+		if err != nil {
+			return err
+		}
+		if r, ok := c.CheckFile(rel, string(content)); ok {
+			results = append(results, r)
+		}
+		return nil
+	})
+	return results, err
+}
+
+// CheckFile returns the status of the file rel with the given content. It
+// reports false for files the checker skips: other extensions, excluded
+// paths and generated files.
+func (c *Checker) CheckFile(rel, content string) (Result, bool) {
+	if len(c.Extensions) > 0 && !slices.Contains(c.Extensions, path.Ext(rel)) || c.excluded(rel) || isGenerated(content) {
+		return Result{}, false
+	}
+	return c.checkContent(rel, content), true
+}
+
+// Fix rewrites the file described by r so that it carries the expected
+// header. Files that are already compliant are left untouched.
+func (c *Checker) Fix(root string, r Result) error {
+	if r.Status == StatusOK {
+		return nil
+	}
+
+	full := filepath.Join(root, r.File)
+	content, err := os.ReadFile(full)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(full)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(full, []byte(c.FixContent(r.File, string(content))), info.Mode().Perm())
+}
+
+func (c *Checker) checkContent(rel, content string) Result {
+	result := Result{File: rel, Module: workspace.ModuleForPath(rel), Status: StatusOK}
+
+	block, _ := leadingComment(content)
+	if block == "" || !c.Marker.MatchString(block) {
+		result.Status = StatusMissing
+		return result
+	}
+
+	// The file and module lines go stale when files are moved between
+	// modules; flag them so --fix can refresh the header.
+	templateLines := strings.Split(c.Template, "\n")
+	for i, want := range strings.Split(c.render(rel), "\n") {
+		if !strings.Contains(templateLines[i], "{{") || strings.Contains(templateLines[i], "{{year}}") {
+			continue
+		}
+		if !containsLine(block, want) {
+			result.Status = StatusOutdated
+			result.Detail = "expected header line " + strconv.Quote(want)
+			break
+		}
+	}
+	return result
+}
+
+// FixContent returns content, the file rel, with the expected header in
+// place of its own or, when it has none, above it.
+func (c *Checker) FixContent(rel, content string) string {
+	header := c.render(rel)
+
+	block, rest := leadingComment(content)
+	if block != "" && c.looksLikeHeader(block, rel) {
+		return header + rest
+	}
+	return header + "\n" + content
+}
+
+func (c *Checker) render(rel string) string {
+	return strings.NewReplacer(
+		"{{file}}", path.Base(rel),
+		"{{module}}", workspace.ModuleForPath(rel),
+		"{{year}}", time.Now().Format("2006"),
+	).Replace(c.Template)
+}
+
+func (c *Checker) excluded(rel string) bool {
+	for _, pattern := range c.Excludes {
+		if walker.Match(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// leadingComment splits content into its leading block of "//" line
+// comments (excluding "///" doc comments) and the remainder.
+func leadingComment(content string) (block, rest string) {
+	end := 0
+	for end < len(content) {
+		next := strings.IndexByte(content[end:], '\n')
+		line := content[end:]
+		if next >= 0 {
+			line = content[end : end+next+1]
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "///") {
+			break
+		}
+		end += len(line)
+		if next < 0 {
+			break
+		}
+	}
+	return content[:end], content[end:]
+}
+
+// looksLikeHeader reports whether block is an existing file header (rather
+// than an ordinary comment) that should be replaced instead of kept.
+func (c *Checker) looksLikeHeader(block, rel string) bool {
+	first := firstLine(block)
+	return strings.HasSuffix(strings.TrimSpace(first), ".swift") ||
+		strings.Contains(first, path.Base(rel)) ||
+		c.Marker.MatchString(block)
+}
+
+func isGenerated(content string) bool {
+	head := content
+	if lines := strings.SplitN(content, "\n", 6); len(lines) == 6 {
+		head = strings.Join(lines[:5], "\n")
+	}
+	for _, marker := range generatedMarkers {
+		if strings.Contains(head, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return strings.TrimRight(line, "\r")
+}
+
+func containsLine(block, want string) bool {
+	for _, line := range strings.Split(block, "\n") {
+		if strings.TrimRight(line, "\r ") == want {
+			return true
+		}
+	}
+	return false
+}
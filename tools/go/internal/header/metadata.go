@@ -0,0 +1,210 @@
+package header
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+var (
+	// createdLine matches "Created by Jane on 12/03/2025." as Xcode writes
+	// it, and "Created: 2025-03-12" or "Created 2025-03-12".
+	createdLine    = regexp.MustCompile(`(?i)^created(?:\s+by\s+.+?\s+on|\s+on|:)?\s+(.+?)\.?$`)
+	moduleLine     = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+	boilerplateRow = regexp.MustCompile(`(?i)^(copyright|©|\(c\)|all rights reserved|swift-tools-version|-\*-)`)
+)
+
+// dateLayouts are the creation date forms understood, day first as
+// British Xcode installations write them.
+var dateLayouts = []string{
+	"2006-01-02", "02/01/2006", "2/1/2006", "02/01/06", "2/1/06", "02.01.2006",
+	"2 January 2006", "January 2, 2006", "2 Jan 2006", "Jan 2, 2006",
+}
+
+// Metadata is what a file's header says about it.
+type Metadata struct {
+	File string `json:"file"`
+	// Module is the module the file's directory belongs to.
+	Module    string `json:"module"`
+	HasHeader bool   `json:"hasHeader"`
+	// HeaderFile and HeaderModule are the file and module names the
+	// header gives.
+	HeaderFile   string `json:"headerFile,omitempty"`
+	HeaderModule string `json:"headerModule,omitempty"`
+	// Created is the creation date, as YYYY-MM-DD when it parses and as
+	// written otherwise.
+	Created     string `json:"created,omitempty"`
+	Description string `json:"description,omitempty"`
+	// ModuleMismatch and FileMismatch report a header naming another
+	// module or file, as a move or rename leaves behind.
+	ModuleMismatch bool `json:"moduleMismatch,omitempty"`
+	FileMismatch   bool `json:"fileMismatch,omitempty"`
+	// ModuleFingerprint and FileFingerprint identify the issues the
+	// mismatches raise.
+	ModuleFingerprint string `json:"moduleFingerprint,omitempty"`
+	FileFingerprint   string `json:"fileFingerprint,omitempty"`
+}
+
+// ModuleManifest lists the files of one module with their headers.
+type ModuleManifest struct {
+	Module    string     `json:"module"`
+	Files     []Metadata `json:"files"`
+	Headers   int        `json:"headers"`
+	Described int        `json:"described"`
+}
+
+// ParseHeader reads the header comment of content: the file name on its
+// first line, the module on the next, a "Created" line, and the remaining
+// lines, other than the UmbraCore marker and copyright notices, as the
+// description. It reports false when content has no header.
+func (c *Checker) ParseHeader(content string) (Metadata, bool) {
+	block, _ := leadingComment(strings.TrimLeft(content, "\n"))
+	if block == "" {
+		return Metadata{}, false
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(block, "\n"), "\n") {
+		lines = append(lines, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "//")))
+	}
+	var m Metadata
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	if len(lines) > 0 && strings.HasSuffix(lines[0], ".swift") && !strings.Contains(lines[0], " ") {
+		m.HeaderFile, lines = lines[0], lines[1:]
+		if len(lines) > 0 && moduleLine.MatchString(lines[0]) {
+			m.HeaderModule, lines = lines[0], lines[1:]
+		}
+	}
+
+	var description []string
+	for _, line := range lines {
+		switch match := createdLine.FindStringSubmatch(line); {
+		case match != nil && m.Created == "":
+			m.Created = normaliseDate(match[1])
+		case line == "", c.Marker.MatchString(line), boilerplateRow.MatchString(line):
+		default:
+			description = append(description, line)
+		}
+	}
+	m.Description = strings.Join(description, " ")
+	if m.HeaderFile == "" && m.Created == "" && !c.Marker.MatchString(block) {
+		// An ordinary comment opening the file, not a header.
+		return Metadata{}, false
+	}
+	m.HasHeader = true
+	return m, true
+}
+
+// Manifest reads the header of every Swift file below dirs and groups the
+// files by module, sorted. Rules name the module of each file; a header
+// naming the module its directory falls back to also matches.
+func (c *Checker) Manifest(root string, dirs []string, rules []modulenames.Rule) ([]ModuleManifest, error) {
+	return c.ManifestEach(root, dirs, rules, func(Metadata) error { return nil })
+}
+
+// ManifestEach is Manifest calling each with every file, in path order, as
+// soon as its header is read, so that the files can be streamed out.
+func (c *Checker) ManifestEach(root string, dirs []string, rules []modulenames.Rule, each func(Metadata) error) ([]ModuleManifest, error) {
+	index := &moduleindex.Index{Modules: rules}
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: c.Extensions}, func(rel string) error {
+			rel = path.Clean(filepath.ToSlash(filepath.Join(dir, rel)))
+			if !c.excluded(rel) {
+				paths = append(paths, rel)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	files, err := pool.Stream(paths, func(rel string) (*Metadata, error) {
+		content, err := os.ReadFile(filepath.Join(root, rel))
+		if err != nil {
+			return nil, err
+		}
+		if isGenerated(string(content)) {
+			return nil, nil
+		}
+		m, _ := c.ParseHeader(string(content))
+		m.File = rel
+		dirModule := workspace.ModuleForPath(rel)
+		m.Module = dirModule
+		if r, ok := index.ForPath(rel); ok {
+			m.Module = r.ModuleName
+		}
+		m.ModuleMismatch = m.HeaderModule != "" && m.HeaderModule != m.Module && m.HeaderModule != dirModule
+		m.FileMismatch = m.HeaderFile != "" && m.HeaderFile != path.Base(rel)
+		return &m, nil
+	}, func(m *Metadata) error {
+		if m == nil {
+			return nil
+		}
+		return each(*m)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byModule := make(map[string]*ModuleManifest)
+	var out []*ModuleManifest
+	for _, m := range files {
+		if m == nil {
+			continue
+		}
+		mm := byModule[m.Module]
+		if mm == nil {
+			mm = &ModuleManifest{Module: m.Module}
+			byModule[m.Module] = mm
+			out = append(out, mm)
+		}
+		mm.Files = append(mm.Files, *m)
+		if m.HasHeader {
+			mm.Headers++
+		}
+		if m.Description != "" {
+			mm.Described++
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Module < out[j].Module })
+	manifest := make([]ModuleManifest, len(out))
+	for i, mm := range out {
+		manifest[i] = *mm
+	}
+	return manifest, nil
+}
+
+func normaliseDate(s string) string {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	// Month first, when the day-first reading is impossible.
+	for _, layout := range []string{"01/02/2006", "1/2/2006", "01/02/06", "1/2/06"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return s
+}
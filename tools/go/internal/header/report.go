@@ -0,0 +1,95 @@
+package header
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteManifestMarkdown writes the headers naming another module or file,
+// the description coverage of each module, then the files of each module
+// with their creation dates and descriptions.
+func WriteManifestMarkdown(w io.Writer, manifest []ModuleManifest) error {
+	files, headers, described := 0, 0, 0
+	var mismatched []Metadata
+	for _, m := range manifest {
+		files += len(m.Files)
+		headers += m.Headers
+		described += m.Described
+		for _, f := range m.Files {
+			if f.ModuleMismatch || f.FileMismatch {
+				mismatched = append(mismatched, f)
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("# Swift File Manifest\n\n")
+	fmt.Fprintf(&b, "**%d files in %d modules, %d with headers, %d described (%s), %d headers naming another module or file**\n",
+		files, len(manifest), headers, described, percent(described, files), len(mismatched))
+
+	if len(mismatched) > 0 {
+		b.WriteString("\n## Header Mismatches\n\n")
+		b.WriteString("| File | Module | Header Module | Header File |\n")
+		b.WriteString("|------|--------|---------------|-------------|\n")
+		for _, f := range mismatched {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", f.File, f.Module, flagged(f.HeaderModule, f.ModuleMismatch), flagged(f.HeaderFile, f.FileMismatch))
+		}
+	}
+
+	if len(manifest) > 0 {
+		b.WriteString("\n## Coverage\n\n")
+		b.WriteString("| Module | Files | Headers | Described | Coverage |\n")
+		b.WriteString("|--------|-------|---------|-----------|----------|\n")
+		for _, m := range manifest {
+			fmt.Fprintf(&b, "| %s | %d | %d | %d | %s |\n", m.Module, len(m.Files), m.Headers, m.Described, percent(m.Described, len(m.Files)))
+		}
+	}
+
+	for _, m := range manifest {
+		fmt.Fprintf(&b, "\n## %s\n\n", m.Module)
+		b.WriteString("| File | Created | Description |\n")
+		b.WriteString("|------|---------|-------------|\n")
+		for _, f := range m.Files {
+			fmt.Fprintf(&b, "| `%s` | %s | %s |\n", f.File, orDash(f.Created), orDash(strings.ReplaceAll(f.Description, "|", `\|`)))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteManifestJSON writes the manifest as indented JSON.
+func WriteManifestJSON(w io.Writer, manifest []ModuleManifest) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Modules []ModuleManifest `json:"modules"`
+	}{manifest})
+}
+
+func percent(n, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", 100*float64(n)/float64(total))
+}
+
+// flagged renders a header value, in bold when it is the mismatch.
+func flagged(value string, mismatch bool) string {
+	switch {
+	case value == "":
+		return "-"
+	case mismatch:
+		return "**" + value + "**"
+	}
+	return value
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
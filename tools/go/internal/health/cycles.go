@@ -0,0 +1,148 @@
+package health
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// IssueCycle is the kind of the issue recorded for each cycle.
+const IssueCycle = "dependency_cycle"
+
+// Cycle is a set of modules that import each other, directly or through
+// one another.
+type Cycle struct {
+	// Modules are the members, sorted.
+	Modules []string `json:"modules"`
+	// Path is one import cycle through the first member, ending where it
+	// starts.
+	Path []string `json:"path"`
+}
+
+// String describes the cycle by its path, e.g. "Core → Services → Core".
+func (c Cycle) String() string {
+	return strings.Join(c.Path, " → ")
+}
+
+// FindCycles returns the import cycles between the workspace modules of
+// rules: one per strongly connected set of modules, sorted by its first
+// member.
+func FindCycles(rules []modulenames.Rule, files []imports.File) []Cycle {
+	owner := make(map[string]string, len(rules))
+	for _, r := range rules {
+		owner[r.ModuleName] = workspace.ModuleForPath(r.File)
+	}
+	edges := make(map[string]map[string]bool)
+	for _, f := range files {
+		for _, imp := range f.Imports {
+			if to, ok := owner[imp.Module]; ok && to != f.Module {
+				add(edges, f.Module, to)
+			}
+		}
+	}
+	graph := make(map[string][]string, len(edges))
+	var nodes []string
+	for from, tos := range edges {
+		nodes = append(nodes, from)
+		for to := range tos {
+			graph[from] = append(graph[from], to)
+		}
+		sort.Strings(graph[from])
+	}
+	sort.Strings(nodes)
+
+	var cycles []Cycle
+	for _, scc := range components(nodes, graph) {
+		if len(scc) < 2 {
+			continue
+		}
+		sort.Strings(scc)
+		cycles = append(cycles, Cycle{Modules: scc, Path: cyclePath(scc, graph)})
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i].Modules[0] < cycles[j].Modules[0] })
+	return cycles
+}
+
+// components returns the strongly connected components of graph, using
+// Tarjan's algorithm.
+func components(nodes []string, graph map[string][]string) [][]string {
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var out [][]string
+
+	var visit func(n string)
+	visit = func(n string) {
+		index[n] = len(index)
+		low[n] = index[n]
+		stack = append(stack, n)
+		onStack[n] = true
+		for _, m := range graph[n] {
+			if _, seen := index[m]; !seen {
+				visit(m)
+				low[n] = min(low[n], low[m])
+			} else if onStack[m] {
+				low[n] = min(low[n], index[m])
+			}
+		}
+		if low[n] != index[n] {
+			return
+		}
+		var scc []string
+		for {
+			m := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[m] = false
+			scc = append(scc, m)
+			if m == n {
+				break
+			}
+		}
+		out = append(out, scc)
+	}
+	for _, n := range nodes {
+		if _, seen := index[n]; !seen {
+			visit(n)
+		}
+	}
+	return out
+}
+
+// cyclePath returns the shortest import path from the first member of scc
+// back to itself, staying inside scc.
+func cyclePath(scc []string, graph map[string][]string) []string {
+	in := make(map[string]bool, len(scc))
+	for _, n := range scc {
+		in[n] = true
+	}
+	start := scc[0]
+	prev := map[string]string{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, m := range graph[n] {
+			if m == start {
+				path := []string{start}
+				for at := n; at != start; at = prev[at] {
+					path = append(path, at)
+				}
+				path = append(path, start)
+				// path runs backwards from start; reverse the middle.
+				for i, j := 1, len(path)-2; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return path
+			}
+			if _, seen := prev[m]; !seen && in[m] {
+				prev[m] = n
+				queue = append(queue, m)
+			}
+		}
+	}
+	return nil
+}
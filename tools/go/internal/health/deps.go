@@ -0,0 +1,75 @@
+package health
+
+import (
+	"sort"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Hygiene is the dependency hygiene of one module: workspace modules its
+// files import without any of its rules depending on them, and workspace
+// deps none of its files import.
+type Hygiene struct {
+	Missing []string `json:"missing"`
+	Unused  []string `json:"unused"`
+}
+
+// CheckDependencies compares, per module, the workspace Swift modules its
+// files import with the deps of the Swift rules in its BUILD files.
+func CheckDependencies(rules []modulenames.Rule, files []imports.File) map[string]Hygiene {
+	byLabel := make(map[string]string, len(rules))
+	known := make(map[string]bool, len(rules))
+	own := make(map[string]map[string]bool)
+	declared := make(map[string]map[string]bool)
+	for _, r := range rules {
+		byLabel[r.Label] = r.ModuleName
+		known[r.ModuleName] = true
+		mod := workspace.ModuleForPath(r.File)
+		add(own, mod, r.ModuleName)
+	}
+	for _, r := range rules {
+		mod := workspace.ModuleForPath(r.File)
+		for _, dep := range r.Deps {
+			if name, ok := byLabel[dep]; ok && !own[mod][name] {
+				add(declared, mod, name)
+			}
+		}
+	}
+
+	imported := make(map[string]map[string]bool)
+	for _, f := range files {
+		for _, imp := range f.Imports {
+			if known[imp.Module] && !own[f.Module][imp.Module] {
+				add(imported, f.Module, imp.Module)
+			}
+		}
+	}
+
+	out := make(map[string]Hygiene)
+	for mod := range own {
+		var h Hygiene
+		for name := range imported[mod] {
+			if !declared[mod][name] {
+				h.Missing = append(h.Missing, name)
+			}
+		}
+		for name := range declared[mod] {
+			if !imported[mod][name] {
+				h.Unused = append(h.Unused, name)
+			}
+		}
+		sort.Strings(h.Missing)
+		sort.Strings(h.Unused)
+		out[mod] = h
+	}
+	return out
+}
+
+func add(sets map[string]map[string]bool, key, value string) {
+	if sets[key] == nil {
+		sets[key] = make(map[string]bool)
+	}
+	sets[key][value] = true
+}
@@ -0,0 +1,263 @@
+// Package health combines the analyzers' per-module results into a single
+// weighted score and letter grade per module.
+package health
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/complexity"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modules"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/progress"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testmap"
+)
+
+// Factors scored per module.
+const (
+	FactorComplexity   = "complexity"
+	FactorTests        = "tests"
+	FactorDependencies = "dependencies"
+	FactorDeadCode     = "deadcode"
+	FactorMigration    = "migration"
+)
+
+// Factors lists every factor in report order.
+var Factors = []string{FactorComplexity, FactorTests, FactorDependencies, FactorDeadCode, FactorMigration}
+
+// DefaultWeights weight the factors when no others are given.
+var DefaultWeights = map[string]float64{
+	FactorComplexity:   3,
+	FactorTests:        3,
+	FactorDependencies: 2,
+	FactorDeadCode:     1,
+	FactorMigration:    1,
+}
+
+// ParseWeights parses "complexity=2,tests=1" into weights, starting from
+// DefaultWeights. A weight of 0 leaves a factor out.
+func ParseWeights(spec string) (map[string]float64, error) {
+	weights := make(map[string]float64, len(DefaultWeights))
+	for k, v := range DefaultWeights {
+		weights[k] = v
+	}
+	for _, part := range strings.Split(spec, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if _, known := DefaultWeights[name]; !ok || !known {
+			return nil, fmt.Errorf("bad weight %q (want factor=number, factors: %s)", part, strings.Join(Factors, ", "))
+		}
+		w, err := strconv.ParseFloat(value, 64)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("bad weight %q: want a non-negative number", part)
+		}
+		weights[name] = w
+	}
+	return weights, nil
+}
+
+// Inputs are the results health combines. Nil fields leave their factor
+// out of every module's score.
+type Inputs struct {
+	Complexity *complexity.Report
+	Tests      *testmap.Map
+	// Dependencies holds the dependency hygiene findings per module.
+	Dependencies map[string]Hygiene
+	// DeadCode counts unused library targets per module.
+	DeadCode map[string]int
+	// Migration holds the refactoring plan status.
+	Migration []progress.Status
+	// Imports are the scanned Swift imports, used to find files still
+	// importing modules the plan is retiring.
+	Imports []imports.File
+	// Cycles are the import cycles between modules. They are reported
+	// alongside the scores but do not change them.
+	Cycles []Cycle
+	// BuildRegressions describe the targets of each module that the latest
+	// build-times run found slower than before. Like Cycles, they are
+	// reported without changing the scores.
+	BuildRegressions map[string][]string
+}
+
+// Module is the health of one module.
+type Module struct {
+	Name string `json:"name"`
+	// Scores are the 0–100 factor scores that apply to the module.
+	Scores map[string]float64 `json:"scores"`
+	// Details explain each factor score.
+	Details map[string]string `json:"details"`
+	Score   float64           `json:"score"`
+	Grade   string            `json:"grade"`
+	// BuildRegressions are the module's entries of Inputs.BuildRegressions.
+	BuildRegressions []string `json:"buildRegressions,omitempty"`
+}
+
+// Gather runs the analyzers health needs. planFile is the refactoring plan
+// (skipped when it does not exist); deadCode comes from a stored
+// unused-targets run, since that needs a Bazel query.
+func Gather(root, planFile string, deadCode map[string]int) (*Inputs, error) {
+	in := &Inputs{DeadCode: deadCode}
+	var err error
+	if in.Complexity, err = complexity.Analyse(root); err != nil {
+		return nil, err
+	}
+	if in.Tests, err = testmap.Build(root); err != nil {
+		return nil, err
+	}
+	if in.Imports, err = imports.ScanTree(root, "Sources"); err != nil {
+		return nil, err
+	}
+	rules, err := moduleindex.Rules(root)
+	if err != nil {
+		return nil, err
+	}
+	in.Dependencies = CheckDependencies(rules, in.Imports)
+	in.Cycles = FindCycles(rules, in.Imports)
+
+	plan, err := progress.LoadPlan(planFile)
+	switch {
+	case err == nil:
+		if in.Migration, err = progress.Measure(root, plan); err != nil {
+			return nil, err
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	return in, nil
+}
+
+// Score grades every production module of root from in.
+func Score(root string, in *Inputs, weights map[string]float64) ([]Module, error) {
+	mods, err := modules.Discover(root)
+	if err != nil {
+		return nil, err
+	}
+
+	complexityByModule := make(map[string]complexity.Module)
+	if in.Complexity != nil {
+		for _, m := range in.Complexity.Modules {
+			complexityByModule[m.Name] = m
+		}
+	}
+	untested := make(map[string]bool)
+	if in.Tests != nil {
+		for _, name := range in.Tests.Untested {
+			untested[name] = true
+		}
+	}
+	retiring, retiringImports := migrationState(in)
+
+	var out []Module
+	for _, mod := range mods {
+		m := Module{Name: mod.Name, Scores: make(map[string]float64), Details: make(map[string]string), BuildRegressions: in.BuildRegressions[mod.Name]}
+
+		if c, ok := complexityByModule[mod.Name]; ok && c.Functions > 0 {
+			avg := float64(c.Complexity) / float64(c.Functions)
+			m.Scores[FactorComplexity] = clamp(100 - 10*(avg-2) - math.Max(0, float64(c.MaxComplexity-20)))
+			m.Details[FactorComplexity] = fmt.Sprintf("average %.1f, max %d over %d functions", avg, c.MaxComplexity, c.Functions)
+		}
+		if in.Tests != nil {
+			if untested[mod.Name] {
+				m.Scores[FactorTests], m.Details[FactorTests] = 0, "no test bundle"
+			} else {
+				m.Scores[FactorTests], m.Details[FactorTests] = 100, "tested"
+			}
+		}
+		if in.Dependencies != nil {
+			h := in.Dependencies[mod.Name]
+			m.Scores[FactorDependencies] = clamp(100 - 10*float64(len(h.Missing)) - 5*float64(len(h.Unused)))
+			m.Details[FactorDependencies] = fmt.Sprintf("%d undeclared, %d unused deps", len(h.Missing), len(h.Unused))
+		}
+		if in.DeadCode != nil {
+			n := in.DeadCode[mod.Name]
+			m.Scores[FactorDeadCode] = clamp(100 - 20*float64(n))
+			m.Details[FactorDeadCode] = fmt.Sprintf("%d unused targets", n)
+		}
+		if in.Migration != nil {
+			if item, ok := retiring[mod.Name]; ok {
+				m.Scores[FactorMigration] = 0
+				m.Details[FactorMigration] = "retired by plan item " + item
+			} else {
+				n := retiringImports[mod.Name]
+				m.Scores[FactorMigration] = clamp(100 - 5*float64(n))
+				m.Details[FactorMigration] = fmt.Sprintf("%d files import retiring modules", n)
+			}
+		}
+
+		var total, weight float64
+		for _, factor := range Factors {
+			score, ok := m.Scores[factor]
+			if !ok {
+				continue
+			}
+			total += score * weights[factor]
+			weight += weights[factor]
+		}
+		if weight > 0 {
+			m.Score = math.Round(total/weight*10) / 10
+		}
+		m.Grade = Grade(m.Score)
+		out = append(out, m)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+// migrationState returns the modules unfinished plan items still have to
+// retire, with the item retiring each, and per module the number of files
+// importing any of them.
+func migrationState(in *Inputs) (map[string]string, map[string]int) {
+	retiring := make(map[string]string)
+	for _, st := range in.Migration {
+		if st.State == progress.StateDone {
+			continue
+		}
+		for _, mod := range st.RemainingModules {
+			retiring[mod] = st.Item.ID
+		}
+	}
+	counts := make(map[string]int)
+	for _, f := range in.Imports {
+		for _, imp := range f.Imports {
+			if _, ok := retiring[imp.Module]; ok && imp.Module != f.Module {
+				counts[f.Module]++
+				break
+			}
+		}
+	}
+	return retiring, counts
+}
+
+// Grade maps a score to a letter: A from 90, B from 80, C from 70, D from
+// 60, F below.
+func Grade(score float64) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+func clamp(v float64) float64 {
+	return math.Round(math.Max(0, math.Min(100, v))*10) / 10
+}
@@ -0,0 +1,43 @@
+package health
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testfixture"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testmap"
+)
+
+// TestScoreIsReproducible scores with weights whose weighted sums round
+// to different tenths depending on the order the factors are added in, so
+// the scores are only stable when they are added in Factors order.
+func TestScoreIsReproducible(t *testing.T) {
+	root := testfixture.Write(t, map[string]string{
+		"Sources/Core/Vault.swift":  "struct Vault {}\n",
+		"Sources/Utils/Clock.swift": "struct Clock {}\n",
+	})
+	in := &Inputs{
+		Tests:        &testmap.Map{Untested: []string{"Utils"}},
+		Dependencies: map[string]Hygiene{"Core": {Missing: []string{"Crypto", "Logging", "Keychain"}}},
+		DeadCode:     map[string]int{},
+	}
+	weights := map[string]float64{FactorTests: 2.7, FactorDependencies: 0.6, FactorDeadCode: 1.5}
+
+	testfixture.Reproducible(t, func() []byte {
+		mods, err := Score(root, in, weights)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		if err := WriteMarkdown(&b, mods, weights, nil); err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(mods)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Write(data)
+		return b.Bytes()
+	})
+}
@@ -0,0 +1,87 @@
+package health
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes the ranked grade table, the import cycles between
+// modules, the build time regressions and the per-factor details of every
+// module graded below A.
+func WriteMarkdown(w io.Writer, mods []Module, weights map[string]float64, cycles []Cycle) error {
+	var b strings.Builder
+	b.WriteString("# Module Health Report\n\n")
+
+	var factors, used []string
+	for _, f := range Factors {
+		if weights[f] > 0 {
+			factors = append(factors, f)
+			used = append(used, fmt.Sprintf("%s ×%g", f, weights[f]))
+		}
+	}
+	fmt.Fprintf(&b, "Weights: %s\n\n", strings.Join(used, ", "))
+
+	b.WriteString("| Rank | Module | Grade | Score |")
+	for _, f := range factors {
+		fmt.Fprintf(&b, " %s |", f)
+	}
+	b.WriteString("\n|------|--------|-------|-------|")
+	for _, f := range factors {
+		b.WriteString(strings.Repeat("-", len(f)+2) + "|")
+	}
+	b.WriteString("\n")
+	for i, m := range mods {
+		fmt.Fprintf(&b, "| %d | %s | %s | %.1f |", i+1, m.Name, m.Grade, m.Score)
+		for _, f := range factors {
+			if s, ok := m.Scores[f]; ok {
+				fmt.Fprintf(&b, " %.0f |", s)
+			} else {
+				b.WriteString(" – |")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if len(cycles) > 0 {
+		b.WriteString("\n## Dependency Cycles\n\n")
+		b.WriteString("`umbratool break-cycles` suggests the imports to cut and the declarations to move for each.\n\n")
+		for _, c := range cycles {
+			fmt.Fprintf(&b, "- %s\n", c)
+		}
+	}
+
+	regressed := false
+	for _, m := range mods {
+		if len(m.BuildRegressions) == 0 {
+			continue
+		}
+		if !regressed {
+			b.WriteString("\n## Build Time Regressions\n\n")
+			regressed = true
+		}
+		for _, r := range m.BuildRegressions {
+			fmt.Fprintf(&b, "- **%s** %s\n", m.Name, r)
+		}
+	}
+
+	header := false
+	for _, m := range mods {
+		if m.Grade == "A" {
+			continue
+		}
+		if !header {
+			b.WriteString("\n## Details\n")
+			header = true
+		}
+		fmt.Fprintf(&b, "\n### %s (%s, %.1f)\n\n", m.Name, m.Grade, m.Score)
+		for _, f := range factors {
+			if d, ok := m.Details[f]; ok {
+				fmt.Fprintf(&b, "- **%s** %.0f: %s\n", f, m.Scores[f], d)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
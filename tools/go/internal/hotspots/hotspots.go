@@ -0,0 +1,206 @@
+// Package hotspots joins git churn with complexity: the files changed
+// most often while also being the most complex are where defects and
+// merge conflicts concentrate, and where refactoring pays back soonest.
+// Churn is compared with the window before, so a hotspot cooling down can
+// be told from one heating up.
+package hotspots
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/churn"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/complexity"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+)
+
+// Trends of a file's or module's churn from the previous window to the
+// current one.
+const (
+	// TrendNew is churn in the current window only.
+	TrendNew = "new"
+	// TrendUp and TrendDown are more and fewer commits than before.
+	TrendUp   = "up"
+	TrendDown = "down"
+	// TrendSteady is as many commits as before.
+	TrendSteady = "steady"
+)
+
+// Options configures Analyse.
+type Options struct {
+	// Dirs are the top-level directories whose Swift files are analysed.
+	Dirs []string
+	// Rules map files to their modules.
+	Rules []modulenames.Rule
+	// Until ends the current window, which covers the Window before it.
+	// The previous window covers the Window before that.
+	Until  time.Time
+	Window time.Duration
+}
+
+// Window is a span of history and the commits in it that changed the
+// analysed files.
+type Window struct {
+	Since   time.Time `json:"since"`
+	Until   time.Time `json:"until"`
+	Commits int       `json:"commits"`
+}
+
+// File is the churn and complexity of one file.
+type File struct {
+	Path   string `json:"path"`
+	Module string `json:"module"`
+	// Complexity is the sum of its functions' cyclomatic complexity.
+	Complexity    int `json:"complexity"`
+	MaxComplexity int `json:"maxComplexity"`
+	Commits       int `json:"commits"`
+	// PreviousCommits are the commits in the previous window.
+	PreviousCommits int    `json:"previousCommits"`
+	Trend           string `json:"trend"`
+	// Score is Commits times Complexity.
+	Score int `json:"score"`
+	// Fingerprint identifies the issue of a file among the top ones reported.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Module aggregates the files of one module.
+type Module struct {
+	Name string `json:"name"`
+	// Files counts the module's files changed in either window.
+	Files      int `json:"files"`
+	Complexity int `json:"complexity"`
+	// Commits and PreviousCommits count the distinct commits changing
+	// any of the module's files.
+	Commits         int    `json:"commits"`
+	PreviousCommits int    `json:"previousCommits"`
+	Trend           string `json:"trend"`
+	// Score is the sum of the files' scores.
+	Score int `json:"score"`
+}
+
+// Report is the outcome of Analyse.
+type Report struct {
+	Current  Window `json:"current"`
+	Previous Window `json:"previous"`
+	// Files are the complex files changed in either window, highest
+	// score first.
+	Files   []File   `json:"files"`
+	Modules []Module `json:"modules"`
+}
+
+// Analyse measures the complexity of the Swift files below opts.Dirs of
+// root and the commits changing them in the two windows. It fails outside
+// a git work tree.
+func Analyse(root string, opts Options) (*Report, error) {
+	current := Window{Since: opts.Until.Add(-opts.Window), Until: opts.Until}
+	previous := Window{Since: current.Since.Add(-opts.Window), Until: current.Since}
+	commits, err := churn.Log(root, previous.Since, opts.Until)
+	if err != nil {
+		return nil, err
+	}
+	cx, err := complexity.Analyse(root, opts.Dirs...)
+	if err != nil {
+		return nil, err
+	}
+
+	ix := &moduleindex.Index{Modules: opts.Rules}
+	files := make(map[string]*File, len(cx.Files))
+	for _, f := range cx.Files {
+		module := f.Module
+		if r, ok := ix.ForPath(f.Path); ok {
+			module = r.ModuleName
+		}
+		files[f.Path] = &File{Path: f.Path, Module: module, Complexity: f.Complexity, MaxComplexity: f.MaxComplexity}
+	}
+
+	type moduleCommits struct{ current, previous map[string]bool }
+	byModule := make(map[string]*moduleCommits)
+	for _, c := range commits {
+		inCurrent := c.Time.After(current.Since)
+		counted := false
+		for _, p := range c.Files {
+			f, ok := files[p]
+			if !ok {
+				continue
+			}
+			counted = true
+			m := byModule[f.Module]
+			if m == nil {
+				m = &moduleCommits{current: make(map[string]bool), previous: make(map[string]bool)}
+				byModule[f.Module] = m
+			}
+			if inCurrent {
+				f.Commits++
+				m.current[c.SHA] = true
+			} else {
+				f.PreviousCommits++
+				m.previous[c.SHA] = true
+			}
+		}
+		switch {
+		case !counted:
+		case inCurrent:
+			current.Commits++
+		default:
+			previous.Commits++
+		}
+	}
+
+	report := &Report{Current: current, Previous: previous}
+	modules := make(map[string]*Module)
+	for _, f := range files {
+		if f.Complexity == 0 || f.Commits+f.PreviousCommits == 0 {
+			continue
+		}
+		f.Score = f.Commits * f.Complexity
+		f.Trend = trend(f.Commits, f.PreviousCommits)
+		report.Files = append(report.Files, *f)
+
+		m := modules[f.Module]
+		if m == nil {
+			m = &Module{Name: f.Module}
+			modules[f.Module] = m
+		}
+		m.Files++
+		m.Complexity += f.Complexity
+		m.Score += f.Score
+	}
+	for name, m := range modules {
+		m.Commits, m.PreviousCommits = len(byModule[name].current), len(byModule[name].previous)
+		m.Trend = trend(m.Commits, m.PreviousCommits)
+		report.Modules = append(report.Modules, *m)
+	}
+
+	sort.Slice(report.Files, func(i, j int) bool {
+		a, b := report.Files[i], report.Files[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Commits != b.Commits {
+			return a.Commits > b.Commits
+		}
+		return a.Path < b.Path
+	})
+	sort.Slice(report.Modules, func(i, j int) bool {
+		a, b := report.Modules[i], report.Modules[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Name < b.Name
+	})
+	return report, nil
+}
+
+func trend(current, previous int) string {
+	switch {
+	case previous == 0 && current > 0:
+		return TrendNew
+	case current > previous:
+		return TrendUp
+	case current < previous:
+		return TrendDown
+	default:
+		return TrendSteady
+	}
+}
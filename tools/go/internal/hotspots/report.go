@@ -0,0 +1,60 @@
+package hotspots
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteMarkdown writes the two windows, the top files by score and every
+// module with a changed file.
+func WriteMarkdown(w io.Writer, r *Report, top int) error {
+	var b strings.Builder
+	b.WriteString("# Hotspots\n\n")
+	fmt.Fprintf(&b, "Current window %s, previous window %s. A file's hotspot score is its commits in the current window times its complexity.\n\n", window(r.Current), window(r.Previous))
+
+	if len(r.Files) == 0 {
+		b.WriteString("No complex file changed in either window.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	files := r.Files
+	if top > 0 && len(files) > top {
+		files = files[:top]
+	}
+	fmt.Fprintf(&b, "## Files\n\n%d of %d changed files.\n\n", len(files), len(r.Files))
+	b.WriteString("| Rank | File | Module | Commits | Previous | Trend | Complexity | Max function | Score |\n")
+	b.WriteString("|------|------|--------|---------|----------|-------|------------|--------------|-------|\n")
+	for i, f := range files {
+		fmt.Fprintf(&b, "| %d | `%s` | %s | %d | %d | %s | %d | %d | %d |\n", i+1, f.Path, f.Module, f.Commits, f.PreviousCommits, f.Trend, f.Complexity, f.MaxComplexity, f.Score)
+	}
+
+	b.WriteString("\n## Modules\n\n")
+	b.WriteString("| Module | Commits | Previous | Trend | Changed files | Complexity | Score |\n")
+	b.WriteString("|--------|---------|----------|-------|---------------|------------|-------|\n")
+	for _, m := range r.Modules {
+		fmt.Fprintf(&b, "| %s | %d | %d | %s | %d | %d | %d |\n", m.Name, m.Commits, m.PreviousCommits, m.Trend, m.Files, m.Complexity, m.Score)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// window renders a window as "2026-01-01 to 2026-04-01 (12 commits)".
+func window(w Window) string {
+	commits := "commits"
+	if w.Commits == 1 {
+		commits = "commit"
+	}
+	return fmt.Sprintf("%s to %s (%d %s)", w.Since.Format("2006-01-02"), w.Until.Add(-time.Nanosecond).Format("2006-01-02"), w.Commits, commits)
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
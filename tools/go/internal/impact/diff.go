@@ -0,0 +1,131 @@
+package impact
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ErrNoRepository is returned by Changed outside a git work tree.
+var ErrNoRepository = errors.New("not inside a git work tree")
+
+// Change is a file changed since the base commit.
+type Change struct {
+	// File is the path relative to root: the new path, or the old one for
+	// a deleted file.
+	File string `json:"file"`
+	// Lines are the first and last lines of each changed range of the new
+	// file. A deletion touches the lines either side of it. They are nil
+	// for a file added, deleted or untracked, which changed throughout.
+	Lines [][2]int `json:"lines,omitempty"`
+}
+
+// Touches reports whether the change covers any line from first to last.
+func (c Change) Touches(first, last int) bool {
+	if c.Lines == nil {
+		return true
+	}
+	for _, r := range c.Lines {
+		if r[0] <= last && first <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// Changed returns the merge base of ref and HEAD, and the files changed
+// below root between it and the work tree: committed, staged and unstaged
+// changes, and untracked files git does not ignore.
+func Changed(root, ref string) (string, []Change, error) {
+	if out, err := exec.Command("git", "-C", root, "rev-parse", "--is-inside-work-tree").Output(); err != nil || strings.TrimSpace(string(out)) != "true" {
+		return "", nil, ErrNoRepository
+	}
+	out, err := exec.Command("git", "-C", root, "merge-base", ref, "HEAD").Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("git merge-base %s HEAD: %w", ref, err)
+	}
+	base := strings.TrimSpace(string(out))
+	out, err = exec.Command("git", "-C", root, "-c", "core.quotePath=false", "diff", "--no-color", "--no-ext-diff", "--no-renames", "--relative", "-U0", base, "--").Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("git diff %s: %w", ref, err)
+	}
+	changes := parseDiff(string(out))
+
+	out, err = exec.Command("git", "-C", root, "ls-files", "--others", "--exclude-standard", "-z").Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("git ls-files: %w", err)
+	}
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" {
+			changes = append(changes, Change{File: f})
+		}
+	}
+	return base[:min(len(base), 12)], changes, nil
+}
+
+// parseDiff reads the changed files and line ranges of a unified diff
+// written with -U0.
+func parseDiff(diff string) []Change {
+	var changes []Change
+	var c *Change
+	header, oldPath := "", ""
+	// hunks is set once a file's hunks start, whose lines can begin
+	// with "--- " or "+++ " too.
+	hunks := false
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			c, oldPath, hunks = nil, "", false
+			header = line[strings.LastIndex(line, " b/")+3:]
+		case strings.HasPrefix(line, "@@ "):
+			// @@ -start[,count] +start[,count] @@
+			hunks = true
+			fields := strings.Fields(line)
+			if c == nil || len(fields) < 3 {
+				continue
+			}
+			start, count := hunkRange(fields[2])
+			if count == 0 {
+				c.Lines = append(c.Lines, [2]int{start, start + 1})
+			} else {
+				c.Lines = append(c.Lines, [2]int{start, start + count - 1})
+			}
+		case hunks:
+			continue
+		case strings.HasPrefix(line, "--- "):
+			oldPath = strings.TrimPrefix(strings.TrimPrefix(line, "--- "), "a/")
+		case strings.HasPrefix(line, "+++ "):
+			p := strings.TrimPrefix(line, "+++ ")
+			// Added and deleted files changed throughout, so their
+			// hunks are not read.
+			switch {
+			case p == "/dev/null":
+				changes = append(changes, Change{File: oldPath})
+			case oldPath == "/dev/null":
+				changes = append(changes, Change{File: strings.TrimPrefix(p, "b/")})
+			default:
+				changes = append(changes, Change{File: strings.TrimPrefix(p, "b/"), Lines: [][2]int{}})
+				c = &changes[len(changes)-1]
+			}
+		case strings.HasPrefix(line, "Binary files "):
+			// Binary changes have no --- and +++ lines.
+			changes = append(changes, Change{File: header})
+		}
+	}
+	return changes
+}
+
+// hunkRange parses the "+start,count" side of a hunk header; the count
+// defaults to one.
+func hunkRange(s string) (int, int) {
+	s = strings.TrimPrefix(s, "+")
+	from, n, found := strings.Cut(s, ",")
+	start, _ := strconv.Atoi(from)
+	count := 1
+	if found {
+		count, _ = strconv.Atoi(n)
+	}
+	return start, count
+}
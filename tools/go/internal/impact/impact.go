@@ -0,0 +1,237 @@
+// Package impact selects the test targets a change can break: those
+// depending, directly or through other targets, on a target that compiles
+// a changed file or declares a type conforming to a changed protocol.
+package impact
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/bazel"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/protocols"
+)
+
+// Protocol is a protocol whose declaration, or an extension of it, the
+// change touches, and the types conforming to it.
+type Protocol struct {
+	Name   string `json:"name"`
+	Module string `json:"module"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	// Conformers include the conformers of refining protocols.
+	Conformers []protocols.Conformer `json:"conformers"`
+}
+
+// Seed is a target the change affects directly.
+type Seed struct {
+	Label string `json:"label"`
+	// Reasons are the changed files the target compiles and the changed
+	// protocols its types conform to.
+	Reasons []string `json:"reasons"`
+}
+
+// Test is a test target to run.
+type Test struct {
+	Label string `json:"label"`
+	// Via is the shortest dependency path from the test to a seed, the
+	// test first and the seed last. It is empty for tests bazel query
+	// selected.
+	Via []string `json:"via,omitempty"`
+}
+
+// Report is the selection for one change.
+type Report struct {
+	// Since is the ref the change is measured from, and Base the merge
+	// base of it and HEAD.
+	Since     string     `json:"since"`
+	Base      string     `json:"base"`
+	Changes   []Change   `json:"changes"`
+	Protocols []Protocol `json:"protocols"`
+	Seeds     []Seed     `json:"seeds"`
+	Tests     []Test     `json:"tests"`
+	// Unowned are the changed files no Swift target compiles, such as
+	// documentation and tooling, which select no tests.
+	Unowned []string `json:"unowned"`
+}
+
+// Labels returns the labels of the selected tests.
+func (r *Report) Labels() []string {
+	labels := make([]string, len(r.Tests))
+	for i, t := range r.Tests {
+		labels[i] = t.Label
+	}
+	return labels
+}
+
+// Select finds the protocols, seeds and tests of changes, with ix indexing
+// the tree's protocols and conformers and rules its Swift targets. A test
+// is selected when it depends on a seed through the deps of rules, or is a
+// seed itself.
+func Select(changes []Change, ix *protocols.Index, rules []modulenames.Rule) *Report {
+	r := &Report{Changes: changes, Protocols: []Protocol{}, Seeds: []Seed{}, Tests: []Test{}, Unowned: []string{}}
+	r.Protocols = changedProtocols(changes, ix)
+
+	reasons := make(map[string][]string)
+	add := func(label, reason string) {
+		for _, have := range reasons[label] {
+			if have == reason {
+				return
+			}
+		}
+		reasons[label] = append(reasons[label], reason)
+	}
+	owners := &moduleindex.Index{Modules: rules}
+	for _, c := range changes {
+		if base := path.Base(c.File); base == "BUILD" || base == "BUILD.bazel" {
+			owned := false
+			for _, rule := range rules {
+				if rule.File == c.File {
+					add(rule.Label, c.File+" changed")
+					owned = true
+				}
+			}
+			if !owned {
+				r.Unowned = append(r.Unowned, c.File)
+			}
+			continue
+		}
+		if rule, ok := owners.ForPath(c.File); ok {
+			add(rule.Label, c.File+" changed")
+		} else {
+			r.Unowned = append(r.Unowned, c.File)
+		}
+	}
+	for _, p := range r.Protocols {
+		for _, conf := range p.Conformers {
+			if rule, ok := owners.ForPath(conf.File); ok {
+				add(rule.Label, fmt.Sprintf("%s conforms to %s.%s", conf.Type, p.Module, p.Name))
+			}
+		}
+	}
+	sort.Strings(r.Unowned)
+	for label, why := range reasons {
+		r.Seeds = append(r.Seeds, Seed{Label: label, Reasons: why})
+	}
+	sort.Slice(r.Seeds, func(i, j int) bool { return r.Seeds[i].Label < r.Seeds[j].Label })
+
+	// A breadth-first walk up the reverse deps from every seed at once
+	// reaches each target by its shortest path to one of them.
+	kinds := make(map[string]string, len(rules))
+	rdeps := make(map[string][]string)
+	for _, rule := range rules {
+		kinds[rule.Label] = rule.Kind
+		for _, dep := range rule.Deps {
+			rdeps[dep] = append(rdeps[dep], rule.Label)
+		}
+	}
+	parent := make(map[string]string)
+	queue := make([]string, 0, len(r.Seeds))
+	for _, s := range r.Seeds {
+		parent[s.Label] = ""
+		queue = append(queue, s.Label)
+	}
+	for len(queue) > 0 {
+		label := queue[0]
+		queue = queue[1:]
+		users := rdeps[label]
+		sort.Strings(users)
+		for _, user := range users {
+			if _, seen := parent[user]; !seen {
+				parent[user] = label
+				queue = append(queue, user)
+			}
+		}
+	}
+	for label := range parent {
+		if !IsTest(kinds[label]) {
+			continue
+		}
+		t := Test{Label: label}
+		for l := label; l != ""; l = parent[l] {
+			t.Via = append(t.Via, l)
+		}
+		r.Tests = append(r.Tests, t)
+	}
+	sort.Slice(r.Tests, func(i, j int) bool { return r.Tests[i].Label < r.Tests[j].Label })
+	return r
+}
+
+// IsTest reports whether rules of kind are test targets, such as
+// swift_test and the macros wrapping it, rather than test libraries.
+func IsTest(kind string) bool {
+	return strings.HasSuffix(kind, "_test")
+}
+
+// changedProtocols returns the protocols the changes touch the declaration
+// of, or an extension of, sorted by module and name.
+func changedProtocols(changes []Change, ix *protocols.Index) []Protocol {
+	byFile := make(map[string]Change, len(changes))
+	for _, c := range changes {
+		byFile[c.File] = c
+	}
+	named := make(map[string]bool)
+	for _, d := range ix.Decls {
+		if d.Kind == protocols.KindProtocol {
+			named[d.Name] = true
+		}
+	}
+	// A changed declaration selects that protocol, and a changed
+	// extension every protocol of its name.
+	declared := make(map[string]bool)
+	extended := make(map[string]bool)
+	for _, d := range ix.Decls {
+		c, ok := byFile[d.File]
+		if !ok || !c.Touches(d.Line, max(d.Line, d.EndLine)) {
+			continue
+		}
+		switch {
+		case d.Kind == protocols.KindProtocol:
+			declared[fmt.Sprintf("%s:%d", d.File, d.Line)] = true
+		case d.Kind == protocols.KindExtension && named[d.Name]:
+			extended[d.Name] = true
+		}
+	}
+
+	out := []Protocol{}
+	if len(declared) == 0 && len(extended) == 0 {
+		return out
+	}
+	for _, cov := range protocols.ComputeCoverage(ix, protocols.Options{}, nil) {
+		if declared[fmt.Sprintf("%s:%d", cov.File, cov.Line)] || extended[cov.Protocol] {
+			p := Protocol{Name: cov.Protocol, Module: cov.Module, File: cov.File, Line: cov.Line, Conformers: cov.Conformers}
+			if p.Conformers == nil {
+				p.Conformers = []protocols.Conformer{}
+			}
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// QueryTests asks Bazel for the swift_test targets depending on the seeds,
+// for graphs the BUILD files alone do not show, such as deps added by
+// macros.
+func QueryTests(ctx context.Context, runner *bazel.Runner, seeds []Seed) ([]Test, error) {
+	tests := []Test{}
+	if len(seeds) == 0 {
+		return tests, nil
+	}
+	labels := make([]string, len(seeds))
+	for i, s := range seeds {
+		labels[i] = s.Label
+	}
+	out, err := runner.Query(ctx, fmt.Sprintf(`kind("swift_test rule", rdeps(//..., set(%s)))`, strings.Join(labels, " ")))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(out)
+	for _, label := range out {
+		tests = append(tests, Test{Label: label})
+	}
+	return tests, nil
+}
@@ -0,0 +1,90 @@
+package impact
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes the selected tests, with the changed protocols and
+// targets that select them.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	b.WriteString("# Impacted Tests\n\n")
+	fmt.Fprintf(&b, "%s changed since `%s` (merge base `%s`), selecting %s.\n",
+		plural(len(r.Changes), "file", "files"), r.Since, r.Base, plural(len(r.Tests), "test target", "test targets"))
+
+	if len(r.Protocols) > 0 {
+		b.WriteString("\n## Changed Protocols\n\n| Protocol | Module | Declared | Conformers |\n|----------|--------|----------|------------|\n")
+		for _, p := range r.Protocols {
+			conformers := make([]string, len(p.Conformers))
+			for i, c := range p.Conformers {
+				conformers[i] = c.Module + "." + c.Type
+				if c.Via != "" {
+					conformers[i] += " (via " + c.Via + ")"
+				}
+			}
+			if len(conformers) == 0 {
+				conformers = []string{"none"}
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s:%d | %s |\n", p.Name, p.Module, p.File, p.Line, strings.Join(conformers, ", "))
+		}
+	}
+
+	if len(r.Seeds) > 0 {
+		b.WriteString("\n## Changed Targets\n\n| Target | Why |\n|--------|-----|\n")
+		for _, s := range r.Seeds {
+			fmt.Fprintf(&b, "| `%s` | %s |\n", s.Label, strings.Join(s.Reasons, "; "))
+		}
+	}
+
+	if len(r.Tests) > 0 {
+		b.WriteString("\n## Tests\n\n| Test | Reached through |\n|------|-----------------|\n")
+		for _, t := range r.Tests {
+			via := "bazel query"
+			switch {
+			case len(t.Via) == 1:
+				via = "a changed target"
+			case len(t.Via) > 1:
+				via = "`" + strings.Join(t.Via[1:], "` → `") + "`"
+			}
+			fmt.Fprintf(&b, "| `%s` | %s |\n", t.Label, via)
+		}
+	}
+
+	if len(r.Unowned) > 0 {
+		fmt.Fprintf(&b, "\n%s no Swift target compiles, selecting no tests:\n\n", plural(len(r.Unowned), "changed file", "changed files"))
+		for _, f := range r.Unowned {
+			fmt.Fprintf(&b, "- %s\n", f)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteLabels writes the labels of the selected tests, one per line, for
+// bazel test.
+func WriteLabels(w io.Writer, r *Report) error {
+	var b strings.Builder
+	for _, label := range r.Labels() {
+		b.WriteString(label + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
@@ -0,0 +1,105 @@
+package importrewrite
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/configschema"
+)
+
+// Config is a mapping file:
+//
+//	rewrites:
+//	  - from: CoreTypes
+//	    to: CoreTypesInterfaces
+//	    keepIfUsing: [SecureBytes, BinaryData]
+//	  - from: SecurityInterfacesBase
+//	    to: SecurityProtocolsCore
+//	    keepRemaining: true
+//	    modules: ["Security*"]
+//
+// Mappings apply in order, each to the result of the ones before, so
+// A → B followed by B → C moves imports of A to C.
+type Config struct {
+	Rewrites []Mapping `yaml:"rewrites"`
+}
+
+// Mapping rewrites the imports of one module to another.
+type Mapping struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+	// KeepIfUsing lists symbols that stay in From. A file using any of them
+	// keeps importing From and imports To as well.
+	KeepIfUsing []string `yaml:"keepIfUsing"`
+	// KeepRemaining adds the public declarations of From that To does not
+	// declare to KeepIfUsing, read from the modules' sources.
+	KeepRemaining bool `yaml:"keepRemaining"`
+	// Modules limits the rewrite to files of these modules, as path.Match
+	// globs; empty means every module.
+	Modules []string `yaml:"modules"`
+	// FromLabel and ToLabel are the Bazel targets of the two modules, by
+	// default the library compiling to each.
+	FromLabel string `yaml:"fromLabel"`
+	ToLabel   string `yaml:"toLabel"`
+}
+
+func (m Mapping) String() string {
+	return m.From + " → " + m.To
+}
+
+// LoadConfig reads and validates a mapping file.
+func LoadConfig(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := configschema.Validate(configschema.KindRewriteMapping, file, data); err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if len(c.Rewrites) == 0 {
+		return nil, fmt.Errorf("%s: no rewrites", file)
+	}
+	for i, m := range c.Rewrites {
+		if err := m.validate(); err != nil {
+			return nil, fmt.Errorf("%s: rewrite %d: %w", file, i+1, err)
+		}
+	}
+	return &c, nil
+}
+
+func (m Mapping) validate() error {
+	if m.From == "" || m.To == "" {
+		return errors.New("from and to are required")
+	}
+	if m.From == m.To {
+		return fmt.Errorf("%s maps to itself", m.From)
+	}
+	for _, pattern := range m.Modules {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad module pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchAny reports whether name matches one of the globs, or patterns is
+// empty.
+func matchAny(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
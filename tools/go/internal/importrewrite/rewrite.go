@@ -0,0 +1,546 @@
+// Package importrewrite moves Swift imports from one module to another
+// across the tree, as listed in a mapping file, and updates the deps of
+// the Bazel targets whose files it rewrites. Imports and module-qualified
+// names inside comments and string literals are left alone.
+package importrewrite
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/backup"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+var (
+	identPattern = regexp.MustCompile(`[A-Za-z_]\w*`)
+	// publicDecl matches a public or open declaration, capturing its name.
+	publicDecl = regexp.MustCompile(`^\s*(?:@\w+(?:\([^)]*\))?\s+)*(?:public|open)\s+(?:(?:final|static|class|indirect|nonisolated|override|dynamic|mutating)\s+)*(?:class|struct|enum|protocol|actor|typealias|func|let|var)\s+([A-Za-z_]\w*)`)
+)
+
+// Options configures a rewrite.
+type Options struct {
+	// Dirs are the top-level directories whose Swift files are rewritten.
+	Dirs []string
+	// Rules map files to their modules and Bazel targets.
+	Rules []modulenames.Rule
+	// Modules restricts every mapping to files of these modules, as
+	// path.Match globs; empty means every module.
+	Modules []string
+	// Deps updates the deps of the targets whose files are rewritten.
+	Deps bool
+}
+
+// Change is one file the rewrite changes.
+type Change struct {
+	// Path is relative to the workspace root.
+	Path string `json:"path"`
+	// Edits describe the changes, e.g. "CoreTypes → CoreTypesInterfaces"
+	// or, for BUILD files, "//Sources/Foo:Foo: +//Sources/Bar:Bar".
+	Edits  []string `json:"edits"`
+	Before string   `json:"-"`
+	After  string   `json:"-"`
+}
+
+// Plan holds the changes a rewrite makes, Swift files and BUILD files
+// each sorted by path.
+type Plan struct {
+	Swift    []Change `json:"swift"`
+	Build    []Change `json:"build"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// mapping is a Mapping resolved against the tree.
+type mapping struct {
+	Mapping
+	keep               map[string]bool
+	fromLabel, toLabel string
+}
+
+// fileResult is the outcome of rewriting one Swift file.
+type fileResult struct {
+	rel           string
+	before, after string
+	edits         []string
+	// rewritten marks the mappings that changed the file, by index.
+	rewritten map[int]bool
+	// imports are the modules the file imports after the rewrite.
+	imports map[string]bool
+}
+
+// Rewrite plans the rewrites of c for the Swift files below opts.Dirs of
+// root. Nothing is written; see Plan.Apply.
+func Rewrite(root string, c *Config, opts Options) (*Plan, error) {
+	ix := &moduleindex.Index{Modules: opts.Rules}
+	mappings := make([]*mapping, len(c.Rewrites))
+	for i, m := range c.Rewrites {
+		resolved, err := resolve(root, ix, m)
+		if err != nil {
+			return nil, err
+		}
+		mappings[i] = resolved
+	}
+
+	var paths []string
+	for _, dir := range opts.Dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	results, err := pool.Map(paths, func(rel string) (*fileResult, error) {
+		data, err := os.ReadFile(filepath.Join(root, rel))
+		if err != nil {
+			return nil, err
+		}
+		module := workspace.ModuleForPath(rel)
+		if rule, ok := ix.ForPath(rel); ok {
+			module = rule.ModuleName
+		}
+		r := &fileResult{rel: rel, before: string(data), after: string(data), rewritten: make(map[int]bool)}
+		for i, m := range mappings {
+			if !matchAny(opts.Modules, module) || !matchAny(m.Modules, module) {
+				continue
+			}
+			if after, edit, ok := rewriteFile(r.after, m); ok {
+				r.after = after
+				r.edits = append(r.edits, edit)
+				r.rewritten[i] = true
+			}
+		}
+		f, err := imports.Parse(strings.NewReader(r.after), rel)
+		if err != nil {
+			return nil, err
+		}
+		r.imports = make(map[string]bool)
+		for _, imp := range f.Imports {
+			r.imports[imp.Module] = true
+		}
+		return r, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	for i, m := range mappings {
+		n := 0
+		for _, r := range results {
+			if r.rewritten[i] {
+				n++
+			}
+		}
+		if n == 0 {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s: no file in scope imports %s", m, m.From))
+		}
+	}
+	for _, r := range results {
+		if r.after != r.before {
+			plan.Swift = append(plan.Swift, Change{Path: r.rel, Edits: r.edits, Before: r.before, After: r.after})
+		}
+	}
+	if opts.Deps {
+		changes, warnings, err := rewriteDeps(root, ix, mappings, results)
+		if err != nil {
+			return nil, err
+		}
+		plan.Build = changes
+		plan.Warnings = append(plan.Warnings, warnings...)
+	}
+	return plan, nil
+}
+
+// resolve finds the Bazel targets of a mapping's modules and, with
+// KeepRemaining, the symbols that stay in From.
+func resolve(root string, ix *moduleindex.Index, m Mapping) (*mapping, error) {
+	r := &mapping{Mapping: m, keep: make(map[string]bool), fromLabel: m.FromLabel, toLabel: m.ToLabel}
+	for _, sym := range m.KeepIfUsing {
+		r.keep[sym] = true
+	}
+	from, fromOK := library(ix, m.From)
+	to, toOK := library(ix, m.To)
+	if r.fromLabel == "" && fromOK {
+		r.fromLabel = from.Label
+	}
+	if r.toLabel == "" && toOK {
+		r.toLabel = to.Label
+	}
+	if !m.KeepRemaining {
+		return r, nil
+	}
+
+	if !fromOK {
+		return nil, fmt.Errorf("%s: keepRemaining needs the sources of %s, but no target compiles it", m, m.From)
+	}
+	declared, err := publicNames(root, from.SourceDir)
+	if err != nil {
+		return nil, err
+	}
+	moved := make(map[string]bool)
+	if toOK {
+		if moved, err = publicNames(root, to.SourceDir); err != nil {
+			return nil, err
+		}
+	}
+	for name := range declared {
+		if !moved[name] {
+			r.keep[name] = true
+		}
+	}
+	return r, nil
+}
+
+// Kept returns the symbols that stay in m.From, sorted: its KeepIfUsing
+// and, with KeepRemaining, the public declarations of From that To does
+// not declare. Rules locate the modules' sources.
+func Kept(root string, rules []modulenames.Rule, m Mapping) ([]string, error) {
+	r, err := resolve(root, &moduleindex.Index{Modules: rules}, m)
+	if err != nil {
+		return nil, err
+	}
+	kept := make([]string, 0, len(r.keep))
+	for name := range r.keep {
+		kept = append(kept, name)
+	}
+	sort.Strings(kept)
+	return kept, nil
+}
+
+// library returns the library rule compiling to module, preferring
+// non-test rules.
+func library(ix *moduleindex.Index, module string) (modulenames.Rule, bool) {
+	rules := ix.Named(module)
+	for _, r := range rules {
+		if !strings.Contains(r.Kind, "test") {
+			return r, true
+		}
+	}
+	if len(rules) > 0 {
+		return rules[0], true
+	}
+	return modulenames.Rule{}, false
+}
+
+// publicNames returns the names of the public and open declarations in
+// the Swift files below dir.
+func publicNames(root, dir string) (map[string]bool, error) {
+	names := make(map[string]bool)
+	base := filepath.Join(root, dir)
+	err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+		data, err := os.ReadFile(filepath.Join(base, rel))
+		if err != nil {
+			return err
+		}
+		inComment := false
+		for _, line := range strings.Split(string(data), "\n") {
+			var code string
+			code, inComment = swiftsrc.StripComments(line, inComment)
+			if m := publicDecl.FindStringSubmatch(code); m != nil {
+				names[m[1]] = true
+			}
+		}
+		return nil
+	})
+	return names, err
+}
+
+// rewriteFile applies one mapping to a Swift file. Imports of m.From
+// become imports of m.To, or are dropped when the file already imports
+// m.To; a file using a symbol that stays in m.From keeps importing it and
+// gains an import of m.To. Names qualified by m.From are requalified
+// unless they stay. It returns the new content, a description of the
+// edit, and false when the file does not import m.From.
+func rewriteFile(content string, m *mapping) (string, string, bool) {
+	lines := strings.SplitAfter(content, "\n")
+	code := make([]string, len(lines))
+	inComment := false
+	for i, line := range lines {
+		code[i], inComment = swiftsrc.Blank(line, inComment)
+	}
+
+	type fromImport struct {
+		line, start, end int
+		// symbol is set for imports of one declaration, as in
+		// "import struct CoreTypes.SecureBytes".
+		symbol string
+	}
+	var found []fromImport
+	hasTo := false
+	isImport := make(map[int]bool)
+	used := make(map[string]bool)
+	for i, c := range code {
+		module, start, end, ok := imports.ParseLineSpan(c)
+		if !ok {
+			for _, ident := range identPattern.FindAllString(c, -1) {
+				used[ident] = true
+			}
+			continue
+		}
+		isImport[i] = true
+		symbol := ""
+		if rest, ok := strings.CutPrefix(c[end:], "."); ok {
+			symbol = identPattern.FindString(rest)
+		}
+		switch module {
+		case m.From:
+			found = append(found, fromImport{i, start, end, symbol})
+		case m.To:
+			hasTo = hasTo || symbol == ""
+		}
+	}
+	if len(found) == 0 {
+		return content, "", false
+	}
+
+	keep := false
+	for sym := range m.keep {
+		if used[sym] {
+			keep = true
+			break
+		}
+	}
+
+	deleted := make(map[int]bool)
+	inserted := make(map[int]string)
+	for _, imp := range found {
+		line := lines[imp.line]
+		switch {
+		case imp.symbol != "":
+			if !m.keep[imp.symbol] {
+				lines[imp.line] = line[:imp.start] + m.To + line[imp.end:]
+			}
+		case keep:
+			if !hasTo {
+				inserted[imp.line] = line[:imp.start] + m.To + strings.TrimRight(code[imp.line][imp.end:], " \t\r\n") + "\n"
+				hasTo = true
+			}
+		case hasTo:
+			deleted[imp.line] = true
+		default:
+			lines[imp.line] = line[:imp.start] + m.To + line[imp.end:]
+			hasTo = true
+		}
+	}
+
+	for i, c := range code {
+		if !isImport[i] {
+			lines[i] = requalify(lines[i], c, m, keep)
+		}
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		if !deleted[i] {
+			b.WriteString(line)
+		}
+		if ins, ok := inserted[i]; ok {
+			if !strings.HasSuffix(line, "\n") {
+				b.WriteString("\n")
+			}
+			b.WriteString(ins)
+		}
+	}
+	edit := m.String()
+	if keep {
+		edit = fmt.Sprintf("%s kept for symbols staying in it, %s added", m.From, m.To)
+	}
+	return b.String(), edit, b.String() != content
+}
+
+// requalify rewrites "From.Name" to "To.Name" in line, whose blanked code
+// is code, except for names that stay in From while the file keeps
+// importing it.
+func requalify(line, code string, m *mapping, keep bool) string {
+	locs := identPattern.FindAllStringIndex(code, -1)
+	for k := len(locs) - 1; k >= 0; k-- {
+		start, end := locs[k][0], locs[k][1]
+		if code[start:end] != m.From || (start > 0 && code[start-1] == '.') {
+			continue
+		}
+		rest, ok := strings.CutPrefix(code[end:], ".")
+		if !ok {
+			continue
+		}
+		if name := identPattern.FindString(rest); name == "" || (keep && m.keep[name]) {
+			continue
+		}
+		line = line[:start] + m.To + line[end:]
+	}
+	return line
+}
+
+// rewriteDeps adds To to the deps of every target with a file rewritten
+// to import it, and drops From from targets none of whose files import it
+// any more.
+func rewriteDeps(root string, ix *moduleindex.Index, mappings []*mapping, results []*fileResult) ([]Change, []string, error) {
+	byRule := make(map[string][]*fileResult)
+	rules := make(map[string]modulenames.Rule)
+	for _, r := range results {
+		if rule, ok := ix.ForPath(r.rel); ok {
+			byRule[rule.Label] = append(byRule[rule.Label], r)
+			rules[rule.Label] = rule
+		}
+	}
+	labels := make([]string, 0, len(byRule))
+	for label := range byRule {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	importing := func(files []*fileResult, module string) bool {
+		for _, r := range files {
+			if r.imports[module] {
+				return true
+			}
+		}
+		return false
+	}
+
+	var warnings []string
+	warned := make(map[string]bool)
+	warn := func(msg string) {
+		if !warned[msg] {
+			warned[msg] = true
+			warnings = append(warnings, msg)
+		}
+	}
+	files := make(map[string]*buildfile.File)
+	edits := make(map[string][]string)
+	for _, label := range labels {
+		rule, rs := rules[label], byRule[label]
+		_, name, _ := strings.Cut(label, ":")
+		for i, m := range mappings {
+			if !slices.ContainsFunc(rs, func(r *fileResult) bool { return r.rewritten[i] }) {
+				continue
+			}
+			f, ok := files[rule.File]
+			if !ok {
+				var err error
+				if f, err = buildfile.Load(filepath.Join(root, rule.File), buildfile.PackageOf(rule.File)); err != nil {
+					return nil, nil, err
+				}
+				files[rule.File] = f
+			}
+
+			if m.toLabel == "" {
+				warn(fmt.Sprintf("%s: no target compiles %s; set toLabel to add it to deps", m, m.To))
+			} else if m.toLabel != label && importing(rs, m.To) {
+				added, err := f.AddDep(name, "deps", m.toLabel)
+				if err != nil {
+					return nil, nil, err
+				}
+				if added {
+					edits[rule.File] = append(edits[rule.File], label+": +"+m.toLabel)
+				}
+			}
+			if m.fromLabel != "" && !importing(rs, m.From) {
+				removed, err := f.RemoveDep(name, "deps", m.fromLabel)
+				if err != nil {
+					return nil, nil, err
+				}
+				if removed {
+					edits[rule.File] = append(edits[rule.File], label+": -"+m.fromLabel)
+				}
+			}
+		}
+	}
+
+	var changes []Change
+	for file, f := range files {
+		if len(edits[file]) == 0 {
+			continue
+		}
+		before, err := os.ReadFile(f.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		changes = append(changes, Change{Path: file, Edits: edits[file], Before: string(before), After: string(f.Format())})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, warnings, nil
+}
+
+// Apply writes the planned changes. When b is not nil, each file is saved
+// to it first and its result recorded, so that restore can undo the run.
+// Once ctx is done, or a file cannot be written, the files already
+// written are put back as they were, so a run either applies in full or
+// leaves the tree untouched. It returns the paths it wrote, including
+// those put back after a failure.
+func (p *Plan) Apply(ctx context.Context, root string, b *backup.Backup) ([]string, error) {
+	var written []Change
+	paths := func() []string {
+		out := make([]string, len(written))
+		for i, c := range written {
+			out[i] = c.Path
+		}
+		return out
+	}
+	for _, c := range append(slices.Clone(p.Swift), p.Build...) {
+		if err := ctx.Err(); err != nil {
+			return paths(), p.rollBack(root, b, written, err)
+		}
+		if err := apply(root, b, c.Path, c.After); err != nil {
+			return paths(), p.rollBack(root, b, written, err)
+		}
+		written = append(written, c)
+	}
+	return paths(), nil
+}
+
+// rollBack restores the content written's files had before Apply and
+// returns cause with what was undone.
+func (p *Plan) rollBack(root string, b *backup.Backup, written []Change, cause error) error {
+	for _, c := range written {
+		if err := apply(root, b, c.Path, c.Before); err != nil {
+			return fmt.Errorf("%w; rolling back %s failed, restore it from the backup: %v", cause, c.Path, err)
+		}
+	}
+	if len(written) == 0 {
+		return cause
+	}
+	return fmt.Errorf("%w; rolled back the %d files already rewritten", cause, len(written))
+}
+
+func apply(root string, b *backup.Backup, rel, content string) error {
+	file := filepath.Join(root, rel)
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if b != nil {
+		if err := b.Save(rel); err != nil {
+			return err
+		}
+	}
+	if err := atomicfile.WriteFile(file, []byte(content), info.Mode().Perm()); err != nil {
+		return err
+	}
+	if b != nil {
+		return b.RecordResult(rel)
+	}
+	return nil
+}
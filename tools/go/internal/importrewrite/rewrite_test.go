@@ -0,0 +1,113 @@
+package importrewrite
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testfixture"
+)
+
+var coreTypes = &Config{Rewrites: []Mapping{{From: "CoreTypes", To: "CoreTypesInterfaces", KeepIfUsing: []string{"SecureBytes"}}}}
+
+// rewritten returns what the rewrite of coreTypes makes of a single file,
+// or src when it leaves the file alone.
+func rewritten(t *testing.T, src string) string {
+	t.Helper()
+	root := testfixture.Write(t, map[string]string{"Sources/App/App.swift": src})
+	plan, err := Rewrite(root, coreTypes, Options{Dirs: []string{"Sources"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch len(plan.Swift) {
+	case 0:
+		return src
+	case 1:
+		return plan.Swift[0].After
+	}
+	t.Fatalf("%d files changed, want at most 1", len(plan.Swift))
+	return ""
+}
+
+func TestRewrite(t *testing.T) {
+	for name, tc := range map[string]struct {
+		src, want string
+	}{
+		"import": {
+			src:  "import CoreTypes\n\nlet v = CoreTypes.Value()\n",
+			want: "import CoreTypesInterfaces\n\nlet v = CoreTypesInterfaces.Value()\n",
+		},
+		"testable import": {
+			src:  "@testable import CoreTypes\nimport XCTest\n",
+			want: "@testable import CoreTypesInterfaces\nimport XCTest\n",
+		},
+		"import of one declaration": {
+			src:  "import struct CoreTypes.Value\n",
+			want: "import struct CoreTypesInterfaces.Value\n",
+		},
+		"import of a declaration that stays": {
+			src:  "import struct CoreTypes.SecureBytes\n",
+			want: "import struct CoreTypes.SecureBytes\n",
+		},
+		"already importing the target": {
+			src:  "import CoreTypes\nimport CoreTypesInterfaces\n\nlet v: Value\n",
+			want: "import CoreTypesInterfaces\n\nlet v: Value\n",
+		},
+		"using a symbol that stays": {
+			src:  "import CoreTypes\n\nlet b: SecureBytes\nlet v: CoreTypes.Value\nlet k: CoreTypes.SecureBytes\n",
+			want: "import CoreTypes\nimport CoreTypesInterfaces\n\nlet b: SecureBytes\nlet v: CoreTypesInterfaces.Value\nlet k: CoreTypes.SecureBytes\n",
+		},
+		"comments and strings": {
+			src:  "import CoreTypes\n// import CoreTypes\n/* CoreTypes.Value\nimport CoreTypes */\nlet s = \"CoreTypes.Value\"\nlet v = CoreTypes.Value()\n",
+			want: "import CoreTypesInterfaces\n// import CoreTypes\n/* CoreTypes.Value\nimport CoreTypes */\nlet s = \"CoreTypes.Value\"\nlet v = CoreTypesInterfaces.Value()\n",
+		},
+		"only in comments and strings": {
+			src:  "import Foundation\n// import CoreTypes\nlet s = \"import CoreTypes\"\n",
+			want: "import Foundation\n// import CoreTypes\nlet s = \"import CoreTypes\"\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := rewritten(t, tc.src); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// A second run over the tree the first one wrote changes nothing.
+func TestRewriteIsIdempotent(t *testing.T) {
+	root := testfixture.Write(t, map[string]string{
+		"Sources/App/App.swift":   "import CoreTypes\n\nlet v = CoreTypes.Value()\n",
+		"Sources/App/Keys.swift":  "import CoreTypes\n\nlet b: SecureBytes\nlet v: CoreTypes.Value\n",
+		"Sources/App/Both.swift":  "import CoreTypes\nimport CoreTypesInterfaces\n",
+		"Sources/App/One.swift":   "@testable import struct CoreTypes.Value\n",
+		"Sources/App/Other.swift": "import Foundation\n",
+	})
+	opts := Options{Dirs: []string{"Sources"}}
+	plan, err := Rewrite(root, coreTypes, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Swift) != 4 {
+		t.Fatalf("first run changed %d files, want 4", len(plan.Swift))
+	}
+	if _, err := plan.Apply(context.Background(), root, nil); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.ReadFile(filepath.Join(root, "Sources/App/Keys.swift"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := Rewrite(root, coreTypes, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range again.Swift {
+		t.Errorf("second run changed %s: %q", c.Path, c.After)
+	}
+	if want := "import CoreTypes\nimport CoreTypesInterfaces\n\nlet b: SecureBytes\nlet v: CoreTypesInterfaces.Value\n"; string(after) != want {
+		t.Errorf("Keys.swift: got %q, want %q", after, want)
+	}
+}
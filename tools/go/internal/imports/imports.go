@@ -0,0 +1,186 @@
+// Package imports extracts Swift import declarations from the source tree.
+package imports
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftlex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// accessLevels may come before "import", and importKinds after it, as in
+// "public import Foo" and "import struct Foo.Bar".
+var (
+	accessLevels = map[string]bool{"public": true, "package": true, "internal": true, "fileprivate": true, "private": true}
+	importKinds  = map[string]bool{"typealias": true, "struct": true, "class": true, "enum": true, "protocol": true, "let": true, "var": true, "func": true}
+)
+
+// Import is a single import declaration.
+type Import struct {
+	Module string `json:"module"`
+	Line   int    `json:"line"`
+	Text   string `json:"text"`
+}
+
+// File holds the imports of one Swift file.
+type File struct {
+	Path    string   `json:"path"`
+	Module  string   `json:"module"`
+	Imports []Import `json:"imports"`
+}
+
+// ParseLine returns the module imported by line, if it is an import.
+func ParseLine(line string) (string, bool) {
+	module, _, _, ok := ParseLineSpan(line)
+	return module, ok
+}
+
+// ParseLineSpan is ParseLine that also returns the byte offsets of the
+// module name in line, for tools that rewrite it.
+func ParseLineSpan(line string) (module string, start, end int, ok bool) {
+	if !strings.Contains(line, "import") {
+		return "", 0, 0, false
+	}
+	name, _, ok := match(swiftlex.Code(line), 0)
+	if !ok {
+		return "", 0, 0, false
+	}
+	return name.Text, name.Offset, name.Offset + len(name.Text), true
+}
+
+// match reports whether an import declaration, with optional attributes,
+// access level and kind, e.g. "@testable import Foo", starts at toks[i].
+// It returns the imported module's token and the index of the token after
+// it.
+func match(toks []swiftlex.Token, i int) (swiftlex.Token, int, bool) {
+	for i < len(toks) && toks[i].Kind == swiftlex.Attribute {
+		i++
+		// Arguments, as in "@_spi(Internal)".
+		if i < len(toks) && toks[i].Is(swiftlex.Punct, "(") && !toks[i].Space {
+			for i < len(toks) && !toks[i].Is(swiftlex.Punct, ")") {
+				i++
+			}
+			i++
+		}
+	}
+	if i < len(toks) && toks[i].Kind == swiftlex.Ident && accessLevels[toks[i].Text] {
+		i++
+	}
+	if i >= len(toks) || !toks[i].Is(swiftlex.Ident, "import") {
+		return swiftlex.Token{}, 0, false
+	}
+	i++
+	if i+1 < len(toks) && toks[i].Kind == swiftlex.Ident && importKinds[toks[i].Text] && toks[i+1].Kind == swiftlex.Ident {
+		i++
+	}
+	if i >= len(toks) || toks[i].Kind != swiftlex.Ident || strings.HasPrefix(toks[i].Text, "`") || strings.HasPrefix(toks[i].Text, "$") {
+		return swiftlex.Token{}, 0, false
+	}
+	return toks[i], i + 1, true
+}
+
+// ScanFile returns the imports declared in a Swift file. Imports inside
+// comments and string literals are ignored.
+func ScanFile(root, rel string) (File, error) {
+	f, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return File{Path: rel, Module: workspace.ModuleForPath(rel)}, err
+	}
+	defer f.Close()
+	return Parse(f, rel)
+}
+
+// Parse returns the imports declared in the content of the Swift file rel,
+// read from r.
+func Parse(r io.Reader, rel string) (File, error) {
+	file := File{Path: rel, Module: workspace.ModuleForPath(rel)}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return file, err
+	}
+	src := string(data)
+	if !strings.Contains(src, "import") {
+		return file, nil
+	}
+
+	toks := swiftlex.Code(src)
+	for i := 0; i < len(toks); i++ {
+		// A declaration starts a line or follows a semicolon.
+		if i > 0 && toks[i-1].EndLine() == toks[i].Line && !toks[i-1].Is(swiftlex.Punct, ";") {
+			continue
+		}
+		if name, next, ok := match(toks, i); ok {
+			file.Imports = append(file.Imports, Import{Module: name.Text, Line: toks[i].Line, Text: lineAt(src, toks[i].Offset)})
+			i = next - 1
+		}
+	}
+	return file, nil
+}
+
+// lineAt returns the line of src holding offset, without its line ending.
+func lineAt(src string, offset int) string {
+	start := strings.LastIndexByte(src[:offset], '\n') + 1
+	end := strings.IndexByte(src[offset:], '\n')
+	if end < 0 {
+		end = len(src)
+	} else {
+		end += offset
+	}
+	return strings.TrimSuffix(src[start:end], "\r")
+}
+
+// ScanTree returns the imports of every Swift file below the given
+// top-level directories of root (the whole tree when none are given),
+// sorted by path.
+func ScanTree(root string, dirs ...string) ([]File, error) {
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	files, err := pool.Map(paths, func(rel string) (File, error) {
+		return ScanFile(root, rel)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// Importers returns, for each imported module, the files importing it.
+func Importers(files []File) map[string][]string {
+	importers := make(map[string][]string)
+	for _, f := range files {
+		seen := make(map[string]bool)
+		for _, imp := range f.Imports {
+			if !seen[imp.Module] {
+				seen[imp.Module] = true
+				importers[imp.Module] = append(importers[imp.Module], f.Path)
+			}
+		}
+	}
+	return importers
+}
@@ -0,0 +1,17 @@
+package imports
+
+import (
+	"testing"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testfixture"
+)
+
+func BenchmarkScanTree(b *testing.B) {
+	root := testfixture.SwiftTree(b, 8, 40)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := ScanTree(root, "Sources"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
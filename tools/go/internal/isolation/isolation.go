@@ -0,0 +1,555 @@
+// Package isolation maps the boundaries of the actor-isolated service
+// types: the actors and @MainActor types of the service directories, the
+// modules calling into them, and accesses to their isolated members that
+// are not awaited. Swift 6 rejects such accesses from outside the
+// isolation domain, so the report is a per-module worklist for the
+// migration. The analysis is textual: receivers are followed through
+// variables, parameters and properties declared with the service type,
+// and an access counts as awaited when an await precedes it in its
+// statement.
+package isolation
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Isolation kinds.
+const (
+	IsolationActor     = "actor"
+	IsolationMainActor = "mainactor"
+)
+
+// DefaultServices are the directories whose types are mapped.
+var DefaultServices = []string{"Sources/Core/Services"}
+
+var (
+	attrsOnly   = regexp.MustCompile(`^\s*(?:@\w+(?:\([^)]*\))?\s*)+$`)
+	declPattern = regexp.MustCompile(`^\s*((?:@\w+(?:\([^)]*\))?\s+)*)(?:(?:public|private|fileprivate|internal|open|package|final|nonisolated|static|class|override|required|convenience|mutating|distributed|indirect)\s+)*(actor|class|struct|enum|extension|protocol|func|init|deinit)\b\s*([A-Za-z_]\w*)?`)
+	memberDecl  = regexp.MustCompile(`^\s*(?:@\w+(?:\([^)]*\))?\s+)*((?:(?:public|private|fileprivate|internal|open|package|final|nonisolated|static|class|override|lazy|weak|unowned|mutating|\w+\(set\))\s+)*)(func|var|let)\s+([A-Za-z_]\w*)`)
+	awaitWord   = regexp.MustCompile(`\bawait\b|\basync\s+let\b`)
+	mainActor   = regexp.MustCompile(`@MainActor\b`)
+)
+
+// Type is an isolated type of the service directories.
+type Type struct {
+	Name      string `json:"name"`
+	Isolation string `json:"isolation"`
+	Module    string `json:"module"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	// Members are the isolated members, those neither nonisolated, static
+	// nor private.
+	Members []string `json:"members"`
+	// Callers are the other modules referring to the type.
+	Callers []string `json:"callers"`
+	// lets are the members declared with let, which the type's own
+	// module may read without awaiting.
+	lets map[string]bool
+	// via are the modules whose import makes the type visible.
+	via map[string]bool
+}
+
+// Access is a use of an isolated member from outside the type.
+type Access struct {
+	Module  string `json:"module"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Type    string `json:"type"`
+	Member  string `json:"member"`
+	Awaited bool   `json:"awaited"`
+	Text    string `json:"text"`
+	// Fingerprint identifies an unawaited access's issue.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// ModuleRisk sums up one calling module.
+type ModuleRisk struct {
+	Module string `json:"module"`
+	// Types are the isolated types the module refers to.
+	Types     []string `json:"types"`
+	Accesses  int      `json:"accesses"`
+	Unawaited int      `json:"unawaited"`
+}
+
+// Report is the result of a scan.
+type Report struct {
+	Types    []Type       `json:"types"`
+	Modules  []ModuleRisk `json:"modules"`
+	Accesses []Access     `json:"accesses"`
+}
+
+// Options configures a scan.
+type Options struct {
+	// Services are the directories whose isolated types are mapped
+	// (default DefaultServices).
+	Services []string
+	// Dirs are the top-level directories searched for callers (default
+	// "Sources").
+	Dirs []string
+	// Rules name the module of each file; files outside every rule fall
+	// back to their top-level directory.
+	Rules []modulenames.Rule
+}
+
+type line struct {
+	no         int
+	code, text string
+}
+
+// frame is a declaration whose body encloses the current line.
+type frame struct {
+	kind, name string
+	mainActor  bool
+	// depth is the brace depth inside the body.
+	depth int
+}
+
+// typeDecl is a type or extension declared in a service directory.
+type typeDecl struct {
+	kind, name string
+	mainActor  bool
+	module     string
+	file       string
+	line       int
+	members    []string
+	lets       map[string]bool
+}
+
+// Scan maps the isolated types below opts.Services and their callers
+// below opts.Dirs.
+func Scan(root string, opts Options) (*Report, error) {
+	services := opts.Services
+	if len(services) == 0 {
+		services = DefaultServices
+	}
+	dirs := opts.Dirs
+	if len(dirs) == 0 {
+		dirs = []string{"Sources"}
+	}
+	index := &moduleindex.Index{Modules: opts.Rules}
+	moduleOf := func(rel string) string {
+		if r, ok := index.ForPath(rel); ok {
+			return r.ModuleName
+		}
+		return workspace.ModuleForPath(rel)
+	}
+
+	servicePaths, err := swiftFiles(root, services)
+	if err != nil {
+		return nil, err
+	}
+	perFile, err := pool.Map(servicePaths, func(rel string) ([]typeDecl, error) {
+		lines, err := readLines(root, rel)
+		if err != nil {
+			return nil, err
+		}
+		return declarations(lines, rel, moduleOf(rel)), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	types := isolatedTypes(perFile)
+	if len(types) == 0 {
+		return &Report{}, nil
+	}
+	// Callers written against the Swift package import the module of an
+	// enclosing directory, as in `import Core` for Core/Services.
+	for _, t := range types {
+		t.via = map[string]bool{t.Module: true}
+		for _, r := range opts.Rules {
+			if strings.HasPrefix(t.File, r.SourceDir+"/") {
+				t.via[r.ModuleName] = true
+			}
+		}
+	}
+
+	callerPaths, err := swiftFiles(root, dirs)
+	if err != nil {
+		return nil, err
+	}
+	results, err := pool.Map(callerPaths, func(rel string) (fileResult, error) {
+		lines, err := readLines(root, rel)
+		if err != nil {
+			return fileResult{}, err
+		}
+		return callers(lines, rel, moduleOf(rel), types), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return assemble(types, results), nil
+}
+
+func swiftFiles(root string, dirs []string) ([]string, error) {
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, filepath.FromSlash(dir))
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func readLines(root, rel string) ([]line, error) {
+	f, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []line
+	inComment := false
+	scanner := textscan.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		var code string
+		code, inComment = swiftsrc.StripComments(scanner.Text(), inComment)
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+		lines = append(lines, line{lineNo, code, strings.TrimSpace(scanner.Text())})
+	}
+	return lines, textscan.Check(rel, lineNo, scanner.Err())
+}
+
+// walk calls fn for every line with the declarations enclosing it, those
+// whose body the line opens, and the brace depth it starts at.
+func walk(lines []line, fn func(l line, stack, opened []frame, depth int)) {
+	var stack []frame
+	var pending *frame
+	attrs := ""
+	depth := 0
+	for _, l := range lines {
+		outer := append([]frame(nil), stack...)
+		start := depth
+		var opened []frame
+		if attrsOnly.MatchString(l.code) {
+			attrs += l.code
+			fn(l, outer, nil, start)
+			continue
+		}
+		if m := declPattern.FindStringSubmatch(l.code); m != nil {
+			pending = &frame{kind: m[2], name: m[3], mainActor: mainActor.MatchString(attrs + m[1])}
+		}
+		attrs = ""
+		for _, c := range l.code {
+			switch c {
+			case '{':
+				depth++
+				if pending != nil {
+					pending.depth = depth
+					stack = append(stack, *pending)
+					opened = append(opened, *pending)
+					pending = nil
+				}
+			case '}':
+				depth--
+				for len(stack) > 0 && stack[len(stack)-1].depth > depth {
+					stack = stack[:len(stack)-1]
+				}
+			}
+		}
+		fn(l, outer, opened, start)
+	}
+}
+
+func isTypeKind(kind string) bool {
+	switch kind {
+	case "actor", "class", "struct", "enum", "extension":
+		return true
+	}
+	return false
+}
+
+// declarations returns the types and extensions declared in a file with
+// the members isolated to them.
+func declarations(lines []line, rel, module string) []typeDecl {
+	var decls []typeDecl
+	byFrame := make(map[int]int)
+	walk(lines, func(l line, stack, _ []frame, depth int) {
+		if m := declPattern.FindStringSubmatch(l.code); m != nil && isTypeKind(m[2]) && m[3] != "" {
+			byFrame[len(stack)] = len(decls)
+			decls = append(decls, typeDecl{kind: m[2], name: m[3], module: module, file: rel, line: l.no, lets: make(map[string]bool)})
+		}
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		i, ok := byFrame[len(stack)-1]
+		if !ok || !isTypeKind(top.kind) || decls[i].name != top.name {
+			return
+		}
+		decls[i].mainActor = top.mainActor
+		if depth != top.depth {
+			return
+		}
+		m := memberDecl.FindStringSubmatch(l.code)
+		if m == nil {
+			return
+		}
+		for _, mod := range strings.Fields(m[1]) {
+			switch mod {
+			case "nonisolated", "static", "class", "private", "fileprivate":
+				return
+			}
+		}
+		decls[i].members = append(decls[i].members, m[3])
+		if m[2] == "let" {
+			decls[i].lets[m[3]] = true
+		}
+	})
+	return decls
+}
+
+// isolatedTypes keeps the actors and @MainActor types, with the members of
+// their extensions.
+func isolatedTypes(perFile [][]typeDecl) map[string]*Type {
+	types := make(map[string]*Type)
+	for _, decls := range perFile {
+		for _, d := range decls {
+			isolation := ""
+			switch {
+			case d.kind == "actor":
+				isolation = IsolationActor
+			case d.kind != "extension" && d.mainActor:
+				isolation = IsolationMainActor
+			default:
+				continue
+			}
+			if _, dup := types[d.name]; !dup {
+				types[d.name] = &Type{Name: d.name, Isolation: isolation, Module: d.module, File: d.file, Line: d.line, lets: make(map[string]bool)}
+			}
+		}
+	}
+	for _, decls := range perFile {
+		for _, d := range decls {
+			t, ok := types[d.name]
+			if !ok || d.kind != "extension" && (d.file != t.File || d.line != t.Line) {
+				continue
+			}
+			t.Members = append(t.Members, d.members...)
+			for m := range d.lets {
+				t.lets[m] = true
+			}
+		}
+	}
+	for _, t := range types {
+		sort.Strings(t.Members)
+		t.Members = dedupe(t.Members)
+	}
+	return types
+}
+
+func dedupe(sorted []string) []string {
+	out := sorted[:0]
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+type fileResult struct {
+	module     string
+	referenced []string
+	accesses   []Access
+}
+
+// callers returns the isolated types a file refers to and its accesses
+// to their members. Only files of the declaring module or importing a
+// module it is visible through can see a type, which keeps same-named
+// types of other modules out.
+func callers(lines []line, rel, module string, types map[string]*Type) fileResult {
+	imported := map[string]bool{module: true}
+	for _, l := range lines {
+		if mod, ok := imports.ParseLine(l.code); ok {
+			imported[mod] = true
+		}
+	}
+	var names []string
+	for name, t := range types {
+		for mod := range t.via {
+			if imported[mod] {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	out := fileResult{module: module}
+	if len(names) == 0 {
+		return out
+	}
+	sort.Strings(names)
+	alt := strings.Join(names, "|")
+	typeRef := regexp.MustCompile(`\b(` + alt + `)\b`)
+	typed := regexp.MustCompile(`\b([a-z_]\w*)\s*:\s*(?:[A-Z]\w*\.)?(` + alt + `)\b`)
+	built := regexp.MustCompile(`\b(?:let|var)\s+([a-z_]\w*)\s*=\s*(?:try\s+)?(?:await\s+)?(?:[A-Z]\w*\.)?(` + alt + `)\s*(?:\(|\.shared\b)`)
+	resolved := regexp.MustCompile(`\b(?:let|var)\s+([a-z_]\w*)\s*=.*\bresolve\(\s*(?:[A-Z]\w*\.)?(` + alt + `)\s*\.\s*self`)
+	access := regexp.MustCompile(`\b(?:self\s*\.\s*)?((?:[A-Z]\w*\.)?(?:` + alt + `)\s*\.\s*shared|[a-z_]\w*)\s*[?!]?\s*\.\s*([a-z_]\w*)\b`)
+
+	vars := make(map[string]string)
+	for _, l := range lines {
+		for _, re := range []*regexp.Regexp{typed, built, resolved} {
+			for _, m := range re.FindAllStringSubmatch(l.code, -1) {
+				vars[m[1]] = m[2]
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	prev := ""
+	walk(lines, func(l line, outer, opened []frame, _ int) {
+		stack := append(outer, opened...)
+		code := l.code
+		defer func() { prev = strings.TrimSpace(code) }()
+		inside := func(name string) bool {
+			for _, f := range stack {
+				if isTypeKind(f.kind) && f.name == name {
+					return true
+				}
+			}
+			return false
+		}
+		onMainActor := false
+		for _, f := range stack {
+			onMainActor = onMainActor || f.mainActor
+		}
+
+		for _, m := range typeRef.FindAllStringSubmatch(code, -1) {
+			if !inside(m[1]) && !seen[m[1]] {
+				seen[m[1]] = true
+				out.referenced = append(out.referenced, m[1])
+			}
+		}
+		for _, loc := range access.FindAllStringSubmatchIndex(code, -1) {
+			receiver := strings.Join(strings.Fields(code[loc[2]:loc[3]]), "")
+			member := code[loc[4]:loc[5]]
+			name, ok := vars[receiver]
+			if r, shared := strings.CutSuffix(receiver, ".shared"); shared {
+				name, ok = r[strings.LastIndex(r, ".")+1:], true
+			}
+			t := types[name]
+			if !ok || t == nil || !contains(t.Members, member) || inside(name) {
+				continue
+			}
+			if t.Isolation == IsolationMainActor && onMainActor {
+				continue
+			}
+			if t.lets[member] && module == t.Module {
+				continue
+			}
+			awaited := awaitWord.MatchString(code[:loc[0]]) || strings.HasSuffix(prev, "await")
+			out.accesses = append(out.accesses, Access{Module: module, File: rel, Line: l.no, Type: name, Member: member, Awaited: awaited, Text: l.text})
+		}
+	})
+	return out
+}
+
+func contains(sorted []string, s string) bool {
+	i := sort.SearchStrings(sorted, s)
+	return i < len(sorted) && sorted[i] == s
+}
+
+func assemble(types map[string]*Type, results []fileResult) *Report {
+	r := &Report{}
+	risks := make(map[string]*ModuleRisk)
+	risk := func(module string) *ModuleRisk {
+		m, ok := risks[module]
+		if !ok {
+			m = &ModuleRisk{Module: module}
+			risks[module] = m
+		}
+		return m
+	}
+	callers := make(map[string]map[string]bool)
+	typesUsed := make(map[string]map[string]bool)
+	for _, f := range results {
+		for _, name := range f.referenced {
+			if f.module != types[name].Module {
+				if callers[name] == nil {
+					callers[name] = make(map[string]bool)
+				}
+				callers[name][f.module] = true
+			}
+			if typesUsed[f.module] == nil {
+				typesUsed[f.module] = make(map[string]bool)
+			}
+			typesUsed[f.module][name] = true
+			risk(f.module)
+		}
+		for _, a := range f.accesses {
+			m := risk(a.Module)
+			m.Accesses++
+			if !a.Awaited {
+				m.Unawaited++
+			}
+		}
+		r.Accesses = append(r.Accesses, f.accesses...)
+	}
+
+	for _, t := range types {
+		t.Callers = sortedKeys(callers[t.Name])
+		r.Types = append(r.Types, *t)
+	}
+	sort.Slice(r.Types, func(i, j int) bool { return r.Types[i].Name < r.Types[j].Name })
+	for _, m := range risks {
+		m.Types = sortedKeys(typesUsed[m.Module])
+		r.Modules = append(r.Modules, *m)
+	}
+	sort.Slice(r.Modules, func(i, j int) bool {
+		a, b := r.Modules[i], r.Modules[j]
+		if a.Unawaited != b.Unawaited {
+			return a.Unawaited > b.Unawaited
+		}
+		if a.Accesses != b.Accesses {
+			return a.Accesses > b.Accesses
+		}
+		return a.Module < b.Module
+	})
+	return r
+}
+
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Unawaited returns the accesses without an await.
+func (r *Report) Unawaited() []Access {
+	var out []Access
+	for _, a := range r.Accesses {
+		if !a.Awaited {
+			out = append(out, a)
+		}
+	}
+	return out
+}
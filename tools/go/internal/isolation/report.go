@@ -0,0 +1,65 @@
+package isolation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes the isolated types, the calling modules by risk and
+// then the accesses without an await, grouped by module.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	unawaited := r.Unawaited()
+
+	var b strings.Builder
+	b.WriteString("# Actor Isolation Boundaries\n\n")
+	fmt.Fprintf(&b, "**%d isolated types, %d calling modules, %d accesses to isolated members, %d without await**\n",
+		len(r.Types), len(r.Modules), len(r.Accesses), len(unawaited))
+	if len(r.Types) == 0 {
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	b.WriteString("\n## Isolated Types\n\n")
+	b.WriteString("| Type | Isolation | Module | Isolated members | Called from |\n")
+	b.WriteString("|------|-----------|--------|------------------|-------------|\n")
+	for _, t := range r.Types {
+		callers := "-"
+		if len(t.Callers) > 0 {
+			callers = strings.Join(t.Callers, ", ")
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %d | %s |\n", t.Name, t.Isolation, t.Module, len(t.Members), callers)
+	}
+
+	if len(r.Modules) > 0 {
+		b.WriteString("\n## Modules\n\n")
+		b.WriteString("| Module | Types used | Accesses | Without await |\n")
+		b.WriteString("|--------|------------|----------|---------------|\n")
+		for _, m := range r.Modules {
+			fmt.Fprintf(&b, "| %s | %s | %d | %d |\n", m.Module, strings.Join(m.Types, ", "), m.Accesses, m.Unawaited)
+		}
+	}
+
+	if len(unawaited) > 0 {
+		b.WriteString("\n## Accesses Without Await\n\nSwift 6 rejects these outside the isolation domain; await them or mark the member nonisolated.\n")
+		module := ""
+		for _, a := range unawaited {
+			if a.Module != module {
+				module = a.Module
+				fmt.Fprintf(&b, "\n### %s\n\n", module)
+			}
+			fmt.Fprintf(&b, "- `%s:%d` `%s.%s`\n", a.File, a.Line, a.Type, a.Member)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
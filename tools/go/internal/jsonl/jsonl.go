@@ -0,0 +1,91 @@
+// Package jsonl writes reports as JSON Lines: one compact JSON object per
+// line, written as soon as it is produced, so that a consumer can act on
+// each record without holding the whole report. Every record starts with a
+// "record" field naming what it describes, such as "file" or "issue", and
+// the last is always the "summary", which carries the run's provenance.
+package jsonl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/provenance"
+)
+
+// Writer writes records to an underlying writer, one per line.
+type Writer struct {
+	w       io.Writer
+	records int
+}
+
+// NewWriter returns a Writer writing to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write writes v, which must encode as a JSON object, as a record of the
+// given kind.
+func (w *Writer) Write(kind string, v any) error {
+	line, err := record(kind, v)
+	if err != nil {
+		return err
+	}
+	if _, err := w.w.Write(line); err != nil {
+		return err
+	}
+	w.records++
+	return nil
+}
+
+// Summary writes the final record: the fields of v, which must encode as a
+// JSON object, with the stamp and the number of records before it.
+func (w *Writer) Summary(stamp provenance.Stamp, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	head, err := json.Marshal(struct {
+		Provenance provenance.Stamp `json:"provenance"`
+		Records    int              `json:"records"`
+	}{stamp, w.records})
+	if err != nil {
+		return err
+	}
+	joined, err := join(head, body)
+	if err != nil {
+		return err
+	}
+	return w.Write("summary", json.RawMessage(joined))
+}
+
+// record encodes v as one line with the "record" field first.
+func record(kind string, v any) ([]byte, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	head, err := json.Marshal(map[string]string{"record": kind})
+	if err != nil {
+		return nil, err
+	}
+	line, err := join(head, body)
+	if err != nil {
+		return nil, fmt.Errorf("%s record: %w", kind, err)
+	}
+	return append(line, '\n'), nil
+}
+
+// join merges two encoded JSON objects into one, the fields of a first.
+func join(a, b []byte) ([]byte, error) {
+	if !bytes.HasPrefix(b, []byte("{")) {
+		return nil, fmt.Errorf("record is not a JSON object: %.40s", b)
+	}
+	if bytes.Equal(b, []byte("{}")) {
+		return a, nil
+	}
+	out := append([]byte(nil), a[:len(a)-1]...)
+	out = append(out, ',')
+	return append(out, b[1:]...), nil
+}
@@ -0,0 +1,256 @@
+package l10n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/plist"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// CatalogExtensions are the localisation catalogue formats read.
+var CatalogExtensions = []string{".strings", ".stringsdict", ".xcstrings"}
+
+// Catalog is one localisation catalogue.
+type Catalog struct {
+	File   string `json:"file"`
+	Module string `json:"module"`
+	// Language comes from the enclosing .lproj directory; it is empty for
+	// string catalogues, which hold every language.
+	Language string `json:"language,omitempty"`
+	// Keys are the catalogue's keys. A string catalogue only lists those
+	// translated into at least one language or marked not to translate.
+	Keys []string `json:"keys"`
+	// Shared is set for catalogues outside Sources, which every module's
+	// strings are looked up in.
+	Shared bool `json:"shared"`
+}
+
+// LoadCatalogs reads every catalogue below root.
+func LoadCatalogs(root string) ([]Catalog, error) {
+	var paths []string
+	err := walker.Walk(root, walker.Options{Extensions: CatalogExtensions}, func(rel string) error {
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	catalogs := make([]Catalog, 0, len(paths))
+	for _, rel := range paths {
+		full := filepath.Join(root, filepath.FromSlash(rel))
+		var keys []string
+		switch path.Ext(rel) {
+		case ".strings":
+			keys, err = readStrings(full)
+		case ".stringsdict":
+			var dict map[string]any
+			if dict, err = plist.DecodeDict(full); err == nil {
+				for key := range dict {
+					keys = append(keys, key)
+				}
+			}
+		case ".xcstrings":
+			keys, err = readStringCatalog(full)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rel, err)
+		}
+		if keys == nil {
+			keys = []string{}
+		}
+		sort.Strings(keys)
+		c := Catalog{File: rel, Module: workspace.ModuleForPath(rel), Keys: keys, Shared: !strings.HasPrefix(rel, "Sources/")}
+		if dir := path.Base(path.Dir(rel)); strings.HasSuffix(dir, ".lproj") {
+			c.Language = strings.TrimSuffix(dir, ".lproj")
+		}
+		catalogs = append(catalogs, c)
+	}
+	return catalogs, nil
+}
+
+// readStrings returns the keys of a .strings file, which may be UTF-8 or,
+// as Xcode used to write them, UTF-16 with a byte order mark.
+func readStrings(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	text := decodeUTF16(data)
+
+	var keys []string
+	p := &stringsParser{text: text}
+	for {
+		p.skipSpace()
+		if p.done() {
+			return keys, nil
+		}
+		key, err := p.token()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.peek() == ';' {
+			// A bare "key"; means the key is its own value.
+			p.pos++
+			keys = append(keys, key)
+			continue
+		}
+		if err := p.expect('='); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if _, err := p.token(); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if err := p.expect(';'); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+}
+
+func decodeUTF16(data []byte) string {
+	var bigEndian bool
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		bigEndian = true
+	default:
+		return strings.TrimPrefix(string(data), "\ufeff")
+	}
+	units := make([]uint16, 0, len(data)/2)
+	for i := 2; i+1 < len(data); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+		} else {
+			units = append(units, uint16(data[i+1])<<8|uint16(data[i]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+type stringsParser struct {
+	text string
+	pos  int
+}
+
+func (p *stringsParser) done() bool { return p.pos >= len(p.text) }
+
+func (p *stringsParser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.text[p.pos]
+}
+
+func (p *stringsParser) line() int {
+	return strings.Count(p.text[:p.pos], "\n") + 1
+}
+
+func (p *stringsParser) expect(c byte) error {
+	if p.peek() != c {
+		return fmt.Errorf("line %d: expected %q", p.line(), c)
+	}
+	p.pos++
+	return nil
+}
+
+// skipSpace skips white space and comments.
+func (p *stringsParser) skipSpace() {
+	for !p.done() {
+		switch rest := p.text[p.pos:]; {
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				p.pos = len(p.text)
+				return
+			}
+			p.pos += end + 4
+		case strings.HasPrefix(rest, "//"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				p.pos = len(p.text)
+				return
+			}
+			p.pos += end + 1
+		case strings.ContainsRune(" \t\r\n", rune(rest[0])):
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// token reads a quoted string, resolving its escapes, or an unquoted word.
+func (p *stringsParser) token() (string, error) {
+	if p.peek() != '"' {
+		start := p.pos
+		for !p.done() && !strings.ContainsRune(" \t\r\n=;", rune(p.peek())) {
+			p.pos++
+		}
+		if p.pos == start {
+			return "", fmt.Errorf("line %d: expected a string", p.line())
+		}
+		return p.text[start:p.pos], nil
+	}
+
+	start := p.line()
+	var b strings.Builder
+	for p.pos++; !p.done(); p.pos++ {
+		c := p.text[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return b.String(), nil
+		case c == '\\' && p.pos+1 < len(p.text):
+			p.pos++
+			switch e := p.text[p.pos]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("line %d: unterminated string", start)
+}
+
+// readStringCatalog returns the keys of an Xcode string catalogue.
+func readStringCatalog(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var catalog struct {
+		Strings map[string]struct {
+			ShouldTranslate *bool                      `json:"shouldTranslate"`
+			Localizations   map[string]json.RawMessage `json:"localizations"`
+		} `json:"strings"`
+	}
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, err
+	}
+	var keys []string
+	for key, entry := range catalog.Strings {
+		if len(entry.Localizations) > 0 || (entry.ShouldTranslate != nil && !*entry.ShouldTranslate) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
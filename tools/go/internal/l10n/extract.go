@@ -0,0 +1,404 @@
+// Package l10n extracts the user-facing strings from Swift sources, such
+// as error descriptions and alert text, and checks them against the
+// localisation catalogues.
+package l10n
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// String kinds.
+const (
+	// KindError is a literal in the body of a LocalizedError property.
+	KindError = "error_description"
+	// KindUserInfo is the value of a localised NSError userInfo key.
+	KindUserInfo = "user_info"
+	// KindUI is a literal passed to an alert, notification or recovery
+	// option, or to another sink in Options.Sinks.
+	KindUI = "ui"
+)
+
+// DefaultProperties are the properties whose literals are shown to users:
+// those of LocalizedError, and localizedDescription overrides.
+var DefaultProperties = []string{"errorDescription", "failureReason", "recoverySuggestion", "helpAnchor", "localizedDescription"}
+
+// DefaultSinks are the calls and properties that put text in front of the
+// user, written like Swift selectors. A "*" in the callee matches any
+// characters, so "*RecoveryOption" covers every recovery option type; a
+// callee with a leading "." is a property assignment.
+var DefaultSinks = []string{
+	"addButton(withTitle:)",
+	".messageText",
+	".informativeText",
+	"ErrorNotification(title:message:)",
+	"*RecoveryOption(title:)",
+}
+
+// Sink is a parsed entry of Options.Sinks.
+type Sink struct {
+	Callee string
+	// Labels are the argument labels that take user-facing text, "_" for
+	// an unlabelled one. A property sink has none.
+	Labels []string
+}
+
+var sinkPattern = regexp.MustCompile(`^(\.?[\w.*]+)(?:\(((?:\w+:)+)\))?$`)
+
+// ParseSink parses a sink such as "ErrorNotification(title:message:)" or
+// ".messageText".
+func ParseSink(s string) (Sink, error) {
+	m := sinkPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || strings.HasPrefix(m[1], ".") != (m[2] == "") {
+		return Sink{}, fmt.Errorf("sink %q: want Callee(label:...) or .property", s)
+	}
+	sink := Sink{Callee: m[1]}
+	if m[2] != "" {
+		sink.Labels = strings.Split(strings.TrimSuffix(m[2], ":"), ":")
+	}
+	return sink, nil
+}
+
+// String is one user-facing string literal.
+type String struct {
+	Module string `json:"module"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Kind   string `json:"kind"`
+	// Context is the property, userInfo key or sink the literal feeds.
+	Context string `json:"context"`
+	// Text is the literal as written.
+	Text string `json:"text"`
+	// Key is the catalogue key the text corresponds to: escapes resolved
+	// and interpolations replaced by %@.
+	Key string `json:"key"`
+	// Wrapped is set when the literal goes through NSLocalizedString,
+	// String(localized:) or a similar localisation API.
+	Wrapped bool `json:"wrapped"`
+	// Status is set by Compare.
+	Status string `json:"status,omitempty"`
+	// Fingerprint is set by umbratool on the strings left unlocalised.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Options controls Extract.
+type Options struct {
+	// Dirs are the top-level directories to scan (default "Sources").
+	Dirs []string
+	// Properties default to DefaultProperties.
+	Properties []string
+	// Sinks default to the parsed DefaultSinks.
+	Sinks []Sink
+}
+
+var (
+	userInfoKey = regexp.MustCompile(`(NSLocalized\w*Key|NSHelpAnchorErrorKey|"NSLocalized\w+")\s*\]?\s*[:=]\s*$`)
+	wrapper     = regexp.MustCompile(`(?:\bNSLocalizedString\(|\bString\(\s*localized:|\bLocalizedStringResource\(|\bLocalizedStringKey\()\s*$`)
+	argLabel    = regexp.MustCompile(`[(,]\s*(?:(\w+):\s*)?$`)
+	calleeName  = regexp.MustCompile(`([\w.]+)\s*$`)
+	assignee    = regexp.MustCompile(`\.(\w+)\s*=\s*$`)
+)
+
+// Extract returns the user-facing strings in the Swift files below opts.Dirs,
+// sorted by file and line. Files below a Tests directory are skipped.
+func Extract(root string, opts Options) ([]String, error) {
+	if len(opts.Dirs) == 0 {
+		opts.Dirs = []string{"Sources"}
+	}
+	if len(opts.Properties) == 0 {
+		opts.Properties = DefaultProperties
+	}
+	if len(opts.Sinks) == 0 {
+		for _, s := range DefaultSinks {
+			sink, _ := ParseSink(s)
+			opts.Sinks = append(opts.Sinks, sink)
+		}
+	}
+	property := regexp.MustCompile(`\bvar\s+(` + strings.Join(opts.Properties, "|") + `)\s*:\s*String\??\s*\{`)
+
+	var paths []string
+	for _, dir := range opts.Dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			rel = path.Clean(filepath.ToSlash(filepath.Join(dir, rel)))
+			if !isTest(rel) {
+				paths = append(paths, rel)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	perFile, err := pool.Map(paths, func(rel string) ([]String, error) {
+		code, err := readCode(root, rel)
+		if err != nil {
+			return nil, err
+		}
+		f := &file{rel: rel, module: workspace.ModuleForPath(rel), code: code, opts: opts}
+		return f.extract(property), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var all []String
+	for _, s := range perFile {
+		all = append(all, s...)
+	}
+	return all, nil
+}
+
+func isTest(rel string) bool {
+	for _, part := range strings.Split(path.Dir(rel), "/") {
+		if part == "Tests" || strings.HasSuffix(part, "Tests") {
+			return true
+		}
+	}
+	return strings.HasSuffix(rel, "Tests.swift")
+}
+
+// readCode returns the file with its comments removed, lines kept.
+func readCode(root, rel string) (string, error) {
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	inComment := false
+	scanner := textscan.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		var code string
+		code, inComment = swiftsrc.RemoveComments(scanner.Text(), inComment)
+		b.WriteString(code)
+		b.WriteByte('\n')
+	}
+	return b.String(), textscan.Check(rel, lineNo, scanner.Err())
+}
+
+type file struct {
+	rel, module string
+	code        string
+	// bare is code with the literals blanked out.
+	bare string
+	opts Options
+}
+
+type span struct {
+	start, end int
+	name       string
+}
+
+func (f *file) extract(property *regexp.Regexp) []String {
+	literals := swiftsrc.Literals(f.code)
+	bare := []byte(f.code)
+	for _, lit := range literals {
+		for i := lit.Start; i < lit.End; i++ {
+			if bare[i] != '\n' {
+				bare[i] = ' '
+			}
+		}
+	}
+	f.bare = string(bare)
+
+	var bodies []span
+	for _, m := range property.FindAllStringSubmatchIndex(f.bare, -1) {
+		bodies = append(bodies, span{m[1], f.blockEnd(m[1]), f.bare[m[2]:m[3]]})
+	}
+
+	// lines holds the offset each line starts at.
+	lines := []int{0}
+	for i := 0; i < len(f.code); i++ {
+		if f.code[i] == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+
+	var out []String
+	for _, lit := range literals {
+		key := Key(lit.Text)
+		if !hasLetter(key) {
+			continue
+		}
+		// A wrapped literal takes its context from the wrapping call.
+		ctx, wrapped := lit.Start, false
+		before := tail(f.code, lit.Start)
+		if loc := wrapper.FindStringIndex(before); loc != nil {
+			ctx, wrapped = lit.Start-len(before)+loc[0], true
+		}
+		kind, context := f.context(ctx, bodies)
+		if kind == "" {
+			continue
+		}
+		out = append(out, String{Module: f.module, File: f.rel, Line: sort.SearchInts(lines, lit.Start+1),
+			Kind: kind, Context: context, Text: lit.Text, Key: key, Wrapped: wrapped})
+	}
+	return out
+}
+
+// context classifies a literal whose expression starts at offset.
+func (f *file) context(offset int, bodies []span) (kind, context string) {
+	before := tail(f.bare, offset)
+	if m := userInfoKey.FindStringSubmatch(before); m != nil {
+		return KindUserInfo, strings.Trim(m[1], `"`)
+	}
+	if m := assignee.FindStringSubmatch(before); m != nil {
+		for _, s := range f.opts.Sinks {
+			if s.Labels == nil && s.Callee == "."+m[1] {
+				return KindUI, s.Callee
+			}
+		}
+	}
+	if m := argLabel.FindStringSubmatch(before); m != nil {
+		label := m[1]
+		if label == "" {
+			label = "_"
+		}
+		if callee, ok := f.enclosingCall(offset); ok {
+			for _, s := range f.opts.Sinks {
+				if matchCallee(s.Callee, callee) && contains(s.Labels, label) {
+					return KindUI, s.Callee + "(" + label + ":)"
+				}
+			}
+		}
+	}
+	for _, b := range bodies {
+		if offset >= b.start && offset < b.end {
+			return KindError, b.name
+		}
+	}
+	return "", ""
+}
+
+// enclosingCall returns the callee of the innermost call whose argument
+// list contains offset.
+func (f *file) enclosingCall(offset int) (string, bool) {
+	depth := 0
+	for i := offset - 1; i >= 0 && i >= offset-4096; i-- {
+		switch f.bare[i] {
+		case ')', ']':
+			depth++
+		case '(', '[':
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if f.bare[i] == '[' {
+				return "", false
+			}
+			m := calleeName.FindStringSubmatch(tail(f.bare, i))
+			if m == nil {
+				return "", false
+			}
+			return m[1], true
+		case '{', '}':
+			if depth == 0 {
+				return "", false
+			}
+		}
+	}
+	return "", false
+}
+
+// lookBehind bounds how far before a literal the context patterns look.
+const lookBehind = 256
+
+// tail returns the lookBehind bytes of s before offset.
+func tail(s string, offset int) string {
+	return s[offset-min(offset, lookBehind) : offset]
+}
+
+// blockEnd returns the offset of the brace closing the block opened just
+// before start.
+func (f *file) blockEnd(start int) int {
+	depth := 1
+	for i := start; i < len(f.bare); i++ {
+		switch f.bare[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(f.bare)
+}
+
+// matchCallee matches a sink's callee against a call such as
+// "alert.addButton", by its full name or its last component.
+func matchCallee(pattern, callee string) bool {
+	if ok, _ := path.Match(pattern, callee); ok {
+		return true
+	}
+	ok, _ := path.Match(pattern, callee[strings.LastIndex(callee, ".")+1:])
+	return ok
+}
+
+// Key returns the catalogue key for a literal's text: escapes resolved and
+// each interpolation replaced by %@, as String(localized:) does for
+// objects.
+func Key(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' || i+1 == len(text) {
+			b.WriteByte(text[i])
+			continue
+		}
+		i++
+		switch text[i] {
+		case '(':
+			depth := 1
+			for i++; i < len(text) && depth > 0; i++ {
+				switch text[i] {
+				case '(':
+					depth++
+				case ')':
+					depth--
+				}
+			}
+			i--
+			b.WriteString("%@")
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		default:
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
+
+func hasLetter(key string) bool {
+	return strings.IndexFunc(strings.ReplaceAll(key, "%@", ""), unicode.IsLetter) >= 0
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
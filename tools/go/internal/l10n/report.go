@@ -0,0 +1,163 @@
+package l10n
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Statuses set by Compare.
+const (
+	// StatusLocalised strings are looked up through a localisation API and
+	// found in a catalogue.
+	StatusLocalised = "localised"
+	// StatusMissing strings are looked up but found in no catalogue.
+	StatusMissing = "missing"
+	// StatusUnwrapped strings are in a catalogue but used as plain
+	// literals, so the translation is never shown.
+	StatusUnwrapped = "unwrapped"
+	// StatusUnlocalised strings are plain literals in no catalogue.
+	StatusUnlocalised = "unlocalised"
+)
+
+var formatSpecifier = regexp.MustCompile(`%(?:\d+\$)?(?:@|l{0,2}[diuxXo]|[fegs])`)
+
+// normalise makes a source key and a catalogue key comparable whatever
+// format specifiers they use.
+func normalise(key string) string {
+	return formatSpecifier.ReplaceAllString(key, "%@")
+}
+
+// Compare sets the Status of every string: a string is catalogued when a
+// catalogue of its own module, or a shared one, has its key.
+func Compare(strs []String, catalogs []Catalog) []String {
+	byModule := make(map[string]map[string]bool)
+	shared := make(map[string]bool)
+	for _, c := range catalogs {
+		keys := shared
+		if !c.Shared {
+			if byModule[c.Module] == nil {
+				byModule[c.Module] = make(map[string]bool)
+			}
+			keys = byModule[c.Module]
+		}
+		for _, k := range c.Keys {
+			keys[normalise(k)] = true
+		}
+	}
+
+	out := make([]String, len(strs))
+	for i, s := range strs {
+		key := normalise(s.Key)
+		catalogued := byModule[s.Module][key] || shared[key]
+		switch {
+		case s.Wrapped && catalogued:
+			s.Status = StatusLocalised
+		case s.Wrapped:
+			s.Status = StatusMissing
+		case catalogued:
+			s.Status = StatusUnwrapped
+		default:
+			s.Status = StatusUnlocalised
+		}
+		out[i] = s
+	}
+	return out
+}
+
+// Unlocalised returns the strings users see untranslated, sorted by
+// module, file and line.
+func Unlocalised(strs []String) []String {
+	var out []String
+	for _, s := range strs {
+		if s.Status != StatusLocalised {
+			out = append(out, s)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Module != out[j].Module {
+			return out[i].Module < out[j].Module
+		}
+		if out[i].File != out[j].File {
+			return out[i].File < out[j].File
+		}
+		return out[i].Line < out[j].Line
+	})
+	return out
+}
+
+// WriteCSV writes the unlocalised strings, one row each, grouped by module.
+func WriteCSV(w io.Writer, strs []String) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"module", "file", "line", "kind", "context", "status", "key"}); err != nil {
+		return err
+	}
+	for _, s := range Unlocalised(strs) {
+		if err := cw.Write([]string{s.Module, s.File, strconv.Itoa(s.Line), s.Kind, s.Context, s.Status, s.Key}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ModuleSummary counts a module's strings by status.
+type ModuleSummary struct {
+	Module   string         `json:"module"`
+	Count    int            `json:"count"`
+	ByStatus map[string]int `json:"byStatus"`
+}
+
+// Summarise counts the strings of each module, sorted by module.
+func Summarise(strs []String) []ModuleSummary {
+	byModule := make(map[string]*ModuleSummary)
+	for _, s := range strs {
+		m := byModule[s.Module]
+		if m == nil {
+			m = &ModuleSummary{Module: s.Module, ByStatus: make(map[string]int)}
+			byModule[s.Module] = m
+		}
+		m.Count++
+		m.ByStatus[s.Status]++
+	}
+	out := make([]ModuleSummary, 0, len(byModule))
+	for _, m := range byModule {
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Module < out[j].Module })
+	return out
+}
+
+// WriteMarkdown writes the per-module counts.
+func WriteMarkdown(w io.Writer, strs []String, catalogs []Catalog) error {
+	var b strings.Builder
+	b.WriteString("# User-Facing Strings\n\n")
+	fmt.Fprintf(&b, "**%d user-facing strings, %d unlocalised, %d catalogues**\n", len(strs), len(Unlocalised(strs)), len(catalogs))
+	if len(strs) > 0 {
+		b.WriteString("\n| Module | Strings | Localised | Missing | Unwrapped | Unlocalised |\n")
+		b.WriteString("|--------|---------|-----------|---------|-----------|-------------|\n")
+		for _, m := range Summarise(strs) {
+			fmt.Fprintf(&b, "| %s | %d | %d | %d | %d | %d |\n", m.Module, m.Count, m.ByStatus[StatusLocalised],
+				m.ByStatus[StatusMissing], m.ByStatus[StatusUnwrapped], m.ByStatus[StatusUnlocalised])
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the catalogues, module summaries and strings as
+// indented JSON.
+func WriteJSON(w io.Writer, strs []String, catalogs []Catalog) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Catalogs []Catalog       `json:"catalogs"`
+		Modules  []ModuleSummary `json:"modules"`
+		Strings  []String        `json:"strings"`
+	}{catalogs, Summarise(strs), strs})
+}
@@ -0,0 +1,184 @@
+package logaudit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/importrewrite"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftlex"
+)
+
+// Fix plans rewriting the fixable sites of r to the wrapper. Each
+// rewritten file imports LoggingWrapper, and with deps set its Bazel
+// target depends on it. Nothing is written; see importrewrite.Plan.Apply.
+func Fix(root string, r *Report, rules []modulenames.Rule, deps bool) (*importrewrite.Plan, error) {
+	ix := &moduleindex.Index{Modules: rules}
+	wrapperLabel := ""
+	for _, rule := range ix.Named(Wrapper) {
+		if !strings.Contains(rule.Kind, "test") {
+			wrapperLabel = rule.Label
+			break
+		}
+	}
+
+	plan := &importrewrite.Plan{}
+	var rewritten []string
+	for _, f := range r.files {
+		after, edits := f.fix()
+		if len(edits) == 0 {
+			continue
+		}
+		plan.Swift = append(plan.Swift, importrewrite.Change{Path: f.rel, Edits: edits, Before: f.src, After: after})
+		rewritten = append(rewritten, f.rel)
+	}
+	sort.Slice(plan.Swift, func(i, j int) bool { return plan.Swift[i].Path < plan.Swift[j].Path })
+	if !deps || len(rewritten) == 0 {
+		return plan, nil
+	}
+	if wrapperLabel == "" {
+		plan.Warnings = append(plan.Warnings, "no target compiles "+Wrapper+"; add it to the deps of the rewritten files' targets by hand")
+		return plan, nil
+	}
+
+	files := make(map[string]*buildfile.File)
+	edits := make(map[string][]string)
+	warned := make(map[string]bool)
+	sort.Strings(rewritten)
+	for _, rel := range rewritten {
+		rule, ok := ix.ForPath(rel)
+		if !ok {
+			if dir := filepath.Dir(rel); !warned[dir] {
+				warned[dir] = true
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s: no Bazel target compiles %s; add %s to its deps by hand", dir, rel, wrapperLabel))
+			}
+			continue
+		}
+		if rule.Label == wrapperLabel {
+			continue
+		}
+		bf, ok := files[rule.File]
+		if !ok {
+			var err error
+			if bf, err = buildfile.Load(filepath.Join(root, rule.File), buildfile.PackageOf(rule.File)); err != nil {
+				return nil, err
+			}
+			files[rule.File] = bf
+		}
+		_, name, _ := strings.Cut(rule.Label, ":")
+		added, err := bf.AddDep(name, "deps", wrapperLabel)
+		if err != nil {
+			return nil, err
+		}
+		if added {
+			edits[rule.File] = append(edits[rule.File], rule.Label+": +"+wrapperLabel)
+		}
+	}
+	for file, bf := range files {
+		if len(edits[file]) == 0 {
+			continue
+		}
+		before, err := os.ReadFile(bf.Path)
+		if err != nil {
+			return nil, err
+		}
+		plan.Build = append(plan.Build, importrewrite.Change{Path: file, Edits: edits[file], Before: string(before), After: string(bf.Format())})
+	}
+	sort.Slice(plan.Build, func(i, j int) bool { return plan.Build[i].Path < plan.Build[j].Path })
+	return plan, nil
+}
+
+// fix returns the file with its fixable sites rewritten, and the edits
+// made, as "3 × print → Logger.debug".
+func (f *file) fix() (string, []string) {
+	var fixes []Site
+	for _, s := range f.sites {
+		if s.Fix != "" {
+			fixes = append(fixes, s)
+		}
+	}
+	if len(fixes) == 0 {
+		return f.src, nil
+	}
+
+	counts := make(map[string]int)
+	var order []string
+	src := f.src
+	for i := len(fixes) - 1; i >= 0; i-- {
+		s := fixes[i]
+		src = src[:s.start] + s.Fix + src[s.end:]
+	}
+	for _, s := range fixes {
+		name, _, _ := strings.Cut(s.Fix, "(")
+		edit := s.Kind + " → " + name
+		if counts[edit] == 0 {
+			order = append(order, edit)
+		}
+		counts[edit]++
+	}
+	var edits []string
+	for _, e := range order {
+		edits = append(edits, fmt.Sprintf("%d × %s", counts[e], e))
+	}
+	if !f.imports[Wrapper] {
+		src = addImport(src, Wrapper)
+		edits = append(edits, "import "+Wrapper)
+	}
+	return src, edits
+}
+
+// addImport adds "import module" after the last import outside #if
+// blocks, or before the first declaration when there is none.
+func addImport(src, module string) string {
+	depth := 0
+	at, first := -1, -1
+	for _, t := range swiftlex.Code(src) {
+		if first < 0 {
+			first = lineStart(src, t.Offset)
+		}
+		switch {
+		case t.Is(swiftlex.Directive, "#if"):
+			depth++
+		case t.Is(swiftlex.Directive, "#endif"):
+			depth--
+		case t.Is(swiftlex.Ident, "import") && depth == 0 && isImportPrefix(src[lineStart(src, t.Offset):t.Offset]):
+			end := strings.IndexByte(src[t.Offset:], '\n')
+			if end < 0 {
+				src += "\n"
+				end = len(src) - t.Offset - 1
+			}
+			at = t.Offset + end + 1
+		}
+	}
+	line := "import " + module + "\n"
+	switch {
+	case at >= 0:
+		return src[:at] + line + src[at:]
+	case first >= 0:
+		return src[:first] + line + "\n" + src[first:]
+	}
+	return src + line
+}
+
+// isImportPrefix reports whether text, which comes before "import" on
+// its line, holds only attributes and an access level, as in
+// "@testable " or "@_exported public ".
+func isImportPrefix(text string) bool {
+	for _, word := range strings.Fields(text) {
+		if !strings.HasPrefix(word, "@") && !accessLevels[word] {
+			return false
+		}
+	}
+	return true
+}
+
+var accessLevels = map[string]bool{"public": true, "package": true, "internal": true, "fileprivate": true, "private": true}
+
+func lineStart(src string, offset int) int {
+	return strings.LastIndexByte(src[:offset], '\n') + 1
+}
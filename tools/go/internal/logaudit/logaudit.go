@@ -0,0 +1,532 @@
+// Package logaudit finds the logging that bypasses the LoggingWrapper
+// facade, print and NSLog calls, direct SwiftyBeaver use and raw os_log,
+// measures each module's migration to the wrapper, and plans the rewrites
+// of the call sites whose translation is mechanical.
+package logaudit
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftlex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Kinds of legacy call site.
+const (
+	KindPrint        = "print"
+	KindNSLog        = "NSLog"
+	KindSwiftyBeaver = "SwiftyBeaver"
+	KindOSLog        = "os_log"
+)
+
+// Kinds lists the kinds in report order.
+var Kinds = []string{KindPrint, KindNSLog, KindSwiftyBeaver, KindOSLog}
+
+// Migration states of a module.
+const (
+	StateDone       = "done"
+	StateInProgress = "in progress"
+	StateNotStarted = "not started"
+)
+
+// Wrapper is the module whose Logger facade call sites migrate to.
+const Wrapper = "LoggingWrapper"
+
+// DefaultExempt are the modules implementing the logging stack, which
+// talk to SwiftyBeaver and the console themselves.
+var DefaultExempt = []string{"LoggingWrapper", "LoggingWrapperInterfaces", "UmbraLogging", "UmbraLoggingAdapters"}
+
+// Levels are the wrapper's log levels.
+var Levels = []string{"critical", "error", "warning", "info", "debug", "trace"}
+
+// Options configures Audit.
+type Options struct {
+	// Dirs are the top-level directories whose Swift files are audited.
+	Dirs []string
+	// Rules map files to their modules and Bazel targets.
+	Rules []modulenames.Rule
+	// Exempt modules are left out; nil means DefaultExempt.
+	Exempt []string
+	// PrintLevel is the level print calls are rewritten to, "debug" by
+	// default.
+	PrintLevel string
+}
+
+// Site is one legacy logging call.
+type Site struct {
+	File   string `json:"file"`
+	Module string `json:"module"`
+	Line   int    `json:"line"`
+	Kind   string `json:"kind"`
+	// Call is the call as written, shortened to one line.
+	Call string `json:"call"`
+	// Fix is the wrapper call replacing it, and Manual why there is none.
+	Fix         string `json:"fix,omitempty"`
+	Manual      string `json:"manual,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// start and end are the byte offsets of the text Fix replaces.
+	start, end int
+}
+
+// Module is one module's migration to the wrapper.
+type Module struct {
+	Name string `json:"name"`
+	// Legacy counts its legacy call sites per kind.
+	Legacy  map[string]int `json:"legacy"`
+	Fixable int            `json:"fixable"`
+	// Wrapper counts its calls of the wrapper's Logger.
+	Wrapper int    `json:"wrapper"`
+	State   string `json:"state"`
+}
+
+// LegacyTotal returns the module's legacy call sites.
+func (m Module) LegacyTotal() int {
+	n := 0
+	for _, c := range m.Legacy {
+		n += c
+	}
+	return n
+}
+
+// Migrated returns the share of the module's logging calls that go
+// through the wrapper, from 0 to 1.
+func (m Module) Migrated() float64 {
+	total := m.LegacyTotal() + m.Wrapper
+	if total == 0 {
+		return 1
+	}
+	return float64(m.Wrapper) / float64(total)
+}
+
+// Report is the result of an audit.
+type Report struct {
+	Sites   []Site   `json:"sites"`
+	Modules []Module `json:"modules"`
+	Exempt  []string `json:"exempt"`
+	// files holds the audited files' sources, for Fix.
+	files map[string]*file
+}
+
+type file struct {
+	rel, module, src string
+	imports          map[string]bool
+	// qualify is set when a bare Logger would be ambiguous or wrong: the
+	// file imports os, whose Logger clashes, or declares its own.
+	qualify bool
+	sites   []Site
+	wrapper int
+}
+
+// Audit scans the Swift files below opts.Dirs of root.
+func Audit(root string, opts Options) (*Report, error) {
+	exempt := opts.Exempt
+	if exempt == nil {
+		exempt = DefaultExempt
+	}
+	printLevel := opts.PrintLevel
+	if printLevel == "" {
+		printLevel = "debug"
+	}
+	if !isLevel(printLevel) {
+		return nil, fmt.Errorf("unknown print level %q: want one of %s", printLevel, strings.Join(Levels, ", "))
+	}
+	skip := make(map[string]bool, len(exempt))
+	for _, m := range exempt {
+		skip[m] = true
+	}
+	ix := &moduleindex.Index{Modules: opts.Rules}
+
+	var paths []string
+	for _, dir := range opts.Dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	files, err := pool.Map(paths, func(rel string) (*file, error) {
+		module := workspace.ModuleForPath(rel)
+		if rule, ok := ix.ForPath(rel); ok {
+			module = rule.ModuleName
+		}
+		if skip[module] {
+			return nil, nil
+		}
+		data, err := os.ReadFile(filepath.Join(root, rel))
+		if err != nil {
+			return nil, err
+		}
+		return scan(rel, module, string(data), printLevel), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{Exempt: exempt, files: make(map[string]*file)}
+	modules := make(map[string]*Module)
+	for _, f := range files {
+		if f == nil || len(f.sites) == 0 && f.wrapper == 0 {
+			continue
+		}
+		r.files[f.rel] = f
+		m := modules[f.module]
+		if m == nil {
+			m = &Module{Name: f.module, Legacy: make(map[string]int)}
+			modules[f.module] = m
+		}
+		m.Wrapper += f.wrapper
+		for _, s := range f.sites {
+			m.Legacy[s.Kind]++
+			if s.Fix != "" {
+				m.Fixable++
+			}
+		}
+		r.Sites = append(r.Sites, f.sites...)
+	}
+	for _, m := range modules {
+		switch {
+		case m.LegacyTotal() == 0:
+			m.State = StateDone
+		case m.Wrapper > 0:
+			m.State = StateInProgress
+		default:
+			m.State = StateNotStarted
+		}
+		r.Modules = append(r.Modules, *m)
+	}
+	sort.Slice(r.Modules, func(i, j int) bool {
+		a, b := r.Modules[i], r.Modules[j]
+		if a.LegacyTotal() != b.LegacyTotal() {
+			return a.LegacyTotal() > b.LegacyTotal()
+		}
+		return a.Name < b.Name
+	})
+	return r, nil
+}
+
+// scan finds the legacy call sites and the wrapper calls of one file.
+func scan(rel, module, src, printLevel string) *file {
+	f := &file{rel: rel, module: module, src: src, imports: make(map[string]bool)}
+	toks := swiftlex.Code(src)
+	for i, t := range toks {
+		if t.Kind != swiftlex.Ident {
+			continue
+		}
+		switch {
+		case t.Text == "import" && i+1 < len(toks):
+			f.imports[toks[i+1].Text] = true
+		case declKinds[t.Text] && i+1 < len(toks) && toks[i+1].Is(swiftlex.Ident, "Logger"):
+			f.qualify = true
+		}
+	}
+	f.qualify = f.qualify || f.imports["os"] || f.imports["OSLog"]
+	logger := "Logger"
+	if f.qualify {
+		logger = Wrapper + ".Logger"
+	}
+
+	for i, t := range toks {
+		if t.Kind != swiftlex.Ident || i > 0 && (toks[i-1].Is(swiftlex.Punct, ".") || toks[i-1].Is(swiftlex.Ident, "func") || toks[i-1].Is(swiftlex.Ident, "import")) {
+			continue
+		}
+		var site *Site
+		switch t.Text {
+		case "print", "debugPrint":
+			if call, ok := parseCall(toks, i+1); ok {
+				site = printSite(src, call, t.Text, logger, printLevel)
+			}
+		case "NSLog":
+			if call, ok := parseCall(toks, i+1); ok {
+				site = nslogSite(src, call, logger)
+			}
+		case "os_log":
+			if call, ok := parseCall(toks, i+1); ok {
+				site = oslogSite(src, call, logger)
+			}
+		case "Logger":
+			if call, ok := parseCall(toks, i+1); ok && len(call.args) > 0 && call.args[0].label == "subsystem" && !f.imports[Wrapper] {
+				site = &Site{Kind: KindOSLog, Manual: "creates an os.Logger; move the calls made on it to the wrapper"}
+				site.end = call.end
+			} else if isWrapperCall(toks, i) && (f.imports[Wrapper] || f.imports["LoggingWrapperInterfaces"]) {
+				f.wrapper++
+			}
+		case "SwiftyBeaver":
+			site = swiftyBeaverSite(toks, i, logger)
+		case Wrapper:
+			if i+2 < len(toks) && toks[i+1].Is(swiftlex.Punct, ".") && toks[i+2].Is(swiftlex.Ident, "Logger") && isWrapperCall(toks, i+2) {
+				f.wrapper++
+			}
+		}
+		if site == nil {
+			continue
+		}
+		site.File, site.Module, site.Line = rel, module, t.Line
+		site.start = t.Offset
+		if site.end == 0 {
+			site.end = t.Offset + len(t.Text)
+		}
+		site.Call = shorten(src[site.start:site.end])
+		f.sites = append(f.sites, *site)
+	}
+
+	// A call inside another's arguments is rewritten with the outer one,
+	// if at all.
+	end := 0
+	for i := range f.sites {
+		s := &f.sites[i]
+		if s.start < end && s.Fix != "" {
+			s.Fix, s.Manual = "", "is nested in another logging call; migrate that first"
+		}
+		end = max(end, s.end)
+	}
+	return f
+}
+
+var declKinds = map[string]bool{"class": true, "struct": true, "enum": true, "actor": true, "typealias": true, "protocol": true}
+
+// isWrapperCall reports whether toks[i], Logger, starts "Logger.level(".
+func isWrapperCall(toks []swiftlex.Token, i int) bool {
+	return i+3 < len(toks) && toks[i+1].Is(swiftlex.Punct, ".") && toks[i+2].Kind == swiftlex.Ident &&
+		(isLevel(toks[i+2].Text) || toks[i+2].Text == "log") && toks[i+3].Is(swiftlex.Punct, "(")
+}
+
+func isLevel(s string) bool {
+	for _, l := range Levels {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// call is a parsed argument list.
+type call struct {
+	args []arg
+	// end is the byte offset just past the closing parenthesis.
+	end int
+}
+
+type arg struct {
+	label string
+	toks  []swiftlex.Token
+}
+
+// text returns the argument as written in src.
+func (a arg) text(src string) string {
+	first, last := a.toks[0], a.toks[len(a.toks)-1]
+	return src[first.Offset : last.Offset+len(last.Text)]
+}
+
+// literal returns the argument's string literal, when it is a plain one
+// without interpolation.
+func (a arg) literal() (string, bool) {
+	if len(a.toks) != 1 || a.toks[0].Kind != swiftlex.String {
+		return "", false
+	}
+	return a.toks[0].Text, true
+}
+
+// member returns the name of an implicit member argument, as "error" for
+// ".error".
+func (a arg) member() (string, bool) {
+	if len(a.toks) == 2 && a.toks[0].Is(swiftlex.Punct, ".") && a.toks[1].Kind == swiftlex.Ident {
+		return a.toks[1].Text, true
+	}
+	return "", false
+}
+
+// parseCall reads the argument list opening at toks[i]. A trailing
+// closure or an unbalanced list is not a call it can rewrite.
+func parseCall(toks []swiftlex.Token, i int) (call, bool) {
+	if i >= len(toks) || !toks[i].Is(swiftlex.Punct, "(") {
+		return call{}, false
+	}
+	var c call
+	depth := 0
+	start := i + 1
+	for j := i; j < len(toks); j++ {
+		t := toks[j]
+		if t.Kind != swiftlex.Punct {
+			continue
+		}
+		switch t.Text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if j > start {
+				c.args = append(c.args, newArg(toks[start:j]))
+			}
+			c.end = t.Offset + 1
+			if j+1 < len(toks) && toks[j+1].Is(swiftlex.Punct, "{") {
+				return call{}, false
+			}
+			return c, true
+		case ",":
+			if depth == 1 {
+				c.args = append(c.args, newArg(toks[start:j]))
+				start = j + 1
+			}
+		}
+	}
+	return call{}, false
+}
+
+func newArg(toks []swiftlex.Token) arg {
+	if len(toks) > 2 && toks[0].Kind == swiftlex.Ident && toks[1].Is(swiftlex.Punct, ":") {
+		return arg{label: toks[0].Text, toks: toks[2:]}
+	}
+	return arg{toks: toks}
+}
+
+func printSite(src string, c call, name, logger, level string) *Site {
+	s := &Site{Kind: KindPrint, end: c.end}
+	switch {
+	case name == "debugPrint":
+		s.Manual = "debugPrint formats with debugDescription"
+	case len(c.args) != 1 || c.args[0].label != "":
+		s.Manual = "prints several items or sets a separator, terminator or output stream"
+	default:
+		s.Fix = fmt.Sprintf("%s.%s(%s)", logger, level, c.args[0].text(src))
+	}
+	return s
+}
+
+// formatOnly are the format strings that print their one argument as it
+// is, so NSLog("%@", message) logs message.
+var formatOnly = map[string]bool{`"%@"`: true, `"%{public}@"`: true, `"%{private}@"`: true}
+
+func nslogSite(src string, c call, logger string) *Site {
+	s := &Site{Kind: KindNSLog, end: c.end}
+	if len(c.args) == 0 {
+		s.Manual = "has no message"
+		return s
+	}
+	format, ok := c.args[0].literal()
+	switch {
+	case !ok || c.args[0].label != "":
+		s.Manual = "the format is not a string literal"
+	case len(c.args) == 1 && !strings.Contains(format, "%"):
+		s.Fix = logger + ".info(" + format + ")"
+	case len(c.args) == 2 && formatOnly[format] && c.args[1].label == "":
+		s.Fix = logger + ".info(" + c.args[1].text(src) + ")"
+	default:
+		s.Manual = "formats its arguments; convert the format to an interpolated string"
+	}
+	return s
+}
+
+// osLogLevels map OSLogType members to the wrapper's levels.
+var osLogLevels = map[string]string{"debug": "debug", "info": "info", "default": "info", "error": "error", "fault": "critical"}
+
+func oslogSite(src string, c call, logger string) *Site {
+	s := &Site{Kind: KindOSLog, end: c.end}
+	level := "info"
+	var format string
+	var rest []arg
+	for n, a := range c.args {
+		member, isMember := a.member()
+		switch {
+		case a.label == "type" || n == 0 && a.label == "" && isMember:
+			l, ok := osLogLevels[member]
+			if !ok {
+				s.Manual = "its log type is not a literal .debug, .info, .default, .error or .fault"
+				return s
+			}
+			level = l
+		case a.label == "log" || a.label == "dso":
+		case a.label != "":
+			s.Manual = "has an argument the wrapper cannot take: " + a.label
+			return s
+		case format == "":
+			lit, ok := a.literal()
+			if !ok {
+				s.Manual = "the format is not a string literal"
+				return s
+			}
+			format = lit
+		default:
+			rest = append(rest, a)
+		}
+	}
+	switch {
+	case format == "":
+		s.Manual = "has no message"
+	case len(rest) == 0 && !strings.Contains(format, "%"):
+		s.Fix = fmt.Sprintf("%s.%s(%s)", logger, level, format)
+	case len(rest) == 1 && formatOnly[format]:
+		s.Fix = fmt.Sprintf("%s.%s(%s)", logger, level, rest[0].text(src))
+	default:
+		s.Manual = "formats its arguments; convert the format to an interpolated string"
+	}
+	return s
+}
+
+// swiftyBeaverLevels map SwiftyBeaver's logging methods to the wrapper's.
+var swiftyBeaverLevels = map[string]string{"verbose": "trace", "debug": "debug", "info": "info", "warning": "warning", "error": "error", "critical": "critical", "fault": "critical"}
+
+func swiftyBeaverSite(toks []swiftlex.Token, i int, logger string) *Site {
+	s := &Site{Kind: KindSwiftyBeaver}
+	if i+2 >= len(toks) || !toks[i+1].Is(swiftlex.Punct, ".") {
+		s.Manual = "uses SwiftyBeaver directly; log through the wrapper"
+		return s
+	}
+	method := toks[i+2]
+	s.end = method.Offset + len(method.Text)
+	level, ok := swiftyBeaverLevels[method.Text]
+	if !ok {
+		if method.Text == "self" {
+			s.Manual = "keeps a SwiftyBeaver logger; replace its calls with the wrapper's"
+		} else {
+			s.Manual = "configures SwiftyBeaver; the wrapper owns its destinations"
+		}
+		return s
+	}
+	c, ok := parseCall(toks, i+3)
+	if !ok {
+		s.Manual = "passes SwiftyBeaver." + method.Text + " as a value"
+		return s
+	}
+	for _, a := range c.args {
+		if a.label == "context" {
+			s.Manual = "passes a context, which the wrapper does not take"
+			s.end = c.end
+			return s
+		}
+	}
+	s.Fix = logger + "." + level
+	return s
+}
+
+// shorten returns the first line of a call, marking any cut.
+func shorten(s string) string {
+	first, _, cut := strings.Cut(s, "\n")
+	const max = 80
+	if len(first) > max {
+		first, cut = first[:max], true
+	}
+	if cut {
+		first = strings.TrimRight(first, " \t") + " …"
+	}
+	return first
+}
@@ -0,0 +1,98 @@
+package logaudit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes each module's migration to the wrapper, the call
+// sites that need a hand and the files --fix would rewrite.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	b.WriteString("# Logging Audit\n\n")
+
+	legacy, fixable, wrapper := 0, 0, 0
+	migrating := 0
+	for _, m := range r.Modules {
+		legacy += m.LegacyTotal()
+		fixable += m.Fixable
+		wrapper += m.Wrapper
+		if m.LegacyTotal() > 0 {
+			migrating++
+		}
+	}
+	if legacy == 0 {
+		fmt.Fprintf(&b, "All %s go through the %s facade.\n", plural(wrapper, "logging call", "logging calls"), Wrapper)
+	} else {
+		share := 100 * float64(wrapper) / float64(legacy+wrapper)
+		fmt.Fprintf(&b, "%s in %s bypass the %s facade; %d can be rewritten with `--fix`. %s go through it, %.0f%% of all logging calls.\n",
+			plural(legacy, "logging call", "logging calls"), plural(migrating, "module", "modules"), Wrapper, fixable, plural(wrapper, "call", "calls"), share)
+	}
+	if len(r.Exempt) > 0 {
+		fmt.Fprintf(&b, "\nThe logging stack itself is exempt: %s.\n", strings.Join(r.Exempt, ", "))
+	}
+
+	if len(r.Modules) > 0 {
+		b.WriteString("\n## Modules\n\n| Module |")
+		for _, k := range Kinds {
+			fmt.Fprintf(&b, " %s |", k)
+		}
+		b.WriteString(" Fixable | Wrapper | Migrated | Status |\n|--------|")
+		for _, k := range Kinds {
+			b.WriteString(strings.Repeat("-", len(k)+2) + "|")
+		}
+		b.WriteString("---------|---------|----------|--------|\n")
+		for _, m := range r.Modules {
+			fmt.Fprintf(&b, "| %s |", m.Name)
+			for _, k := range Kinds {
+				fmt.Fprintf(&b, " %d |", m.Legacy[k])
+			}
+			fmt.Fprintf(&b, " %d | %d | %.0f%% | %s |\n", m.Fixable, m.Wrapper, 100*m.Migrated(), m.State)
+		}
+	}
+
+	var manual []Site
+	var fixFiles []string
+	fixCounts := make(map[string]int)
+	for _, s := range r.Sites {
+		if s.Fix == "" {
+			manual = append(manual, s)
+			continue
+		}
+		if fixCounts[s.File] == 0 {
+			fixFiles = append(fixFiles, s.File)
+		}
+		fixCounts[s.File]++
+	}
+	if len(manual) > 0 {
+		fmt.Fprintf(&b, "\n## Manual Migration\n\n%s need rewriting by hand.\n\n| Site | Call | Why |\n|------|------|-----|\n", plural(len(manual), "call", "calls"))
+		for _, s := range manual {
+			fmt.Fprintf(&b, "| `%s:%d` | `%s` | %s |\n", s.File, s.Line, strings.ReplaceAll(s.Call, "|", `\|`), s.Manual)
+		}
+	}
+	if len(fixFiles) > 0 {
+		fmt.Fprintf(&b, "\n## Fixable\n\n`--fix` rewrites %s in %s.\n\n| File | Calls |\n|------|-------|\n", plural(fixable, "call", "calls"), plural(len(fixFiles), "file", "files"))
+		for _, f := range fixFiles {
+			fmt.Fprintf(&b, "| `%s` | %d |\n", f, fixCounts[f])
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
@@ -0,0 +1,120 @@
+// Package maturity reads the maturity tiers umbratool.yaml gives modules,
+// and what an analyzer's issues in each tier do to its run, so that CI
+// can hold frozen modules to every finding and let experimental ones
+// through without configuring each analyzer.
+package maturity
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/configschema"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// Tiers, from least to most mature.
+const (
+	Experimental = "experimental"
+	Supported    = "supported"
+	Frozen       = "frozen"
+)
+
+// Tiers lists the tiers from least to most mature.
+var Tiers = []string{Experimental, Supported, Frozen}
+
+// Policies for a tier's issues.
+const (
+	// Report shows the issues but never fails the run.
+	Report = "report"
+	// Strict fails the run on the issues when the analyzer is run with
+	// --strict, or is otherwise failing on them.
+	Strict = "strict"
+	// Fail fails the run on any issue, with or without --strict.
+	Fail = "fail"
+)
+
+// defaultPolicies apply to the tiers the config sets no policy for.
+var defaultPolicies = map[string]string{Experimental: Report, Supported: Strict, Frozen: Strict}
+
+// Config is the maturity section of umbratool.yaml:
+//
+//	maturity:
+//	  default: supported
+//	  tiers:
+//	    frozen: [SecurityProtocolsCore, XPCProtocolsCore]
+//	    experimental: ["*Experimental*"]
+//	  policy:
+//	    frozen: fail
+//
+// Tiers name modules with walker.Match globs. A module matching several
+// tiers gets the most mature of them, and one matching none gets Default.
+type Config struct {
+	Default string              `yaml:"default"`
+	Tiers   map[string][]string `yaml:"tiers"`
+	Policy  map[string]string   `yaml:"policy"`
+}
+
+// LoadConfig reads the maturity section of a config file. A missing file,
+// or one without the section, puts every module in the supported tier.
+func LoadConfig(file string) (*Config, error) {
+	c := &Config{Default: Supported}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := configschema.Validate(configschema.KindUmbratool, file, data); err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Maturity *Config `yaml:"maturity"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if doc.Maturity == nil {
+		return c, nil
+	}
+	c = doc.Maturity
+	if c.Default == "" {
+		c.Default = Supported
+	}
+	return c, nil
+}
+
+// Tier returns the tier of module, or "" for an issue owned by no module.
+func (c *Config) Tier(module string) string {
+	if module == "" {
+		return ""
+	}
+	tier := ""
+	for _, t := range Tiers {
+		for _, pattern := range c.Tiers[t] {
+			if walker.Match(pattern, module) {
+				tier = t
+				break
+			}
+		}
+	}
+	if tier == "" {
+		return c.Default
+	}
+	return tier
+}
+
+// PolicyOf returns what issues in tier do to the run. Issues without a
+// tier are held to Strict, as they were before tiers.
+func (c *Config) PolicyOf(tier string) string {
+	if p := c.Policy[tier]; p != "" {
+		return p
+	}
+	if p := defaultPolicies[tier]; p != "" {
+		return p
+	}
+	return Strict
+}
@@ -0,0 +1,160 @@
+// Package metrics collects gauge samples from the analyzers and writes them
+// in the OpenMetrics text format for the Prometheus pushgateway.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+)
+
+// Prefix is prepended to every metric name.
+const Prefix = "umbracore_"
+
+// Set is a collection of gauge families. The zero value is not usable; use
+// NewSet.
+type Set struct {
+	families map[string]*family
+	order    []string
+}
+
+type family struct {
+	help    string
+	samples map[string]float64
+	labels  map[string][]string
+}
+
+// Sample is one gauge value.
+type Sample struct {
+	Name string
+	// Labels are name/value pairs in the order they were given.
+	Labels []string
+	Value  float64
+}
+
+// Label returns the value of the named label, or "".
+func (s Sample) Label(name string) string {
+	for i := 0; i+1 < len(s.Labels); i += 2 {
+		if s.Labels[i] == name {
+			return s.Labels[i+1]
+		}
+	}
+	return ""
+}
+
+// LabelString returns the labels in exposition form, e.g.
+// `{module="Core",tag="TODO"}`.
+func (s Sample) LabelString() string {
+	return formatLabels(s.Labels)
+}
+
+// NewSet returns an empty set.
+func NewSet() *Set {
+	return &Set{families: make(map[string]*family)}
+}
+
+// Gauge sets the value of a gauge sample. name is given without Prefix;
+// labels are name/value pairs, e.g. Gauge("todo_items", "...", 3,
+// "module", "Core", "tag", "FIXME"). Setting the same sample twice keeps
+// the last value.
+func (s *Set) Gauge(name, help string, value float64, labels ...string) {
+	if len(labels)%2 != 0 {
+		panic("metrics: odd number of label arguments")
+	}
+	f, ok := s.families[name]
+	if !ok {
+		f = &family{help: help, samples: make(map[string]float64), labels: make(map[string][]string)}
+		s.families[name] = f
+		s.order = append(s.order, name)
+	}
+	key := formatLabels(labels)
+	f.samples[key] = value
+	f.labels[key] = labels
+}
+
+// Add increments a gauge sample, creating it at zero.
+func (s *Set) Add(name, help string, delta float64, labels ...string) {
+	value := delta
+	if f, ok := s.families[name]; ok {
+		value += f.samples[formatLabels(labels)]
+	}
+	s.Gauge(name, help, value, labels...)
+}
+
+// Samples returns every sample, in the same order as Write.
+func (s *Set) Samples() []Sample {
+	var out []Sample
+	for _, name := range s.order {
+		f := s.families[name]
+		for _, k := range sortedKeys(f.samples) {
+			out = append(out, Sample{Name: name, Labels: f.labels[k], Value: f.samples[k]})
+		}
+	}
+	return out
+}
+
+// Write writes the set in OpenMetrics text format, families in the order
+// they were first set and samples sorted by label set.
+func (s *Set) Write(w io.Writer) error {
+	var b strings.Builder
+	for _, name := range s.order {
+		f := s.families[name]
+		full := Prefix + name
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", full)
+		if f.help != "" {
+			fmt.Fprintf(&b, "# HELP %s %s\n", full, escape(f.help, false))
+		}
+		for _, k := range sortedKeys(f.samples) {
+			fmt.Fprintf(&b, "%s%s %s\n", full, k, strconv.FormatFloat(f.samples[k], 'g', -1, 64))
+		}
+	}
+	b.WriteString("# EOF\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteFile writes the set to filename.
+func (s *Set) WriteFile(filename string) error {
+	f, err := atomicfile.Create(filename, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := s.Write(f); err != nil {
+		f.Abort()
+		return err
+	}
+	return f.Close()
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(labels)/2)
+	for i := 0; i < len(labels); i += 2 {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, labels[i], escape(labels[i+1], true)))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func escape(s string, quotes bool) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	if quotes {
+		s = strings.ReplaceAll(s, `"`, `\"`)
+	}
+	return s
+}
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+// BenchmarkWrite fills a set the size of a complexity run's, a gauge per
+// module and per file, and writes it.
+func BenchmarkWrite(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		s := NewSet()
+		for m := range 40 {
+			module := fmt.Sprintf("Module%02d", m)
+			s.Gauge("module_lines_of_code", "Lines of code per module.", float64(1000+m), "module", module)
+			s.Gauge("module_complexity", "Total cyclomatic complexity per module.", float64(200+m), "module", module)
+			for f := range 25 {
+				s.Gauge("file_complexity", "Total cyclomatic complexity per file.", float64(f), "module", module, "file", fmt.Sprintf("Sources/%s/File%03d.swift", module, f))
+			}
+		}
+		if err := s.Write(io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
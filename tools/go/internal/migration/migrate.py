@@ -0,0 +1,142 @@
+#!/usr/bin/env python3
+"""Migrate a Swift codebase to UmbraCore @VERSION@.
+
+Generated by `umbratool export-migration`; regenerate it rather than
+editing it. It rewrites the imports of moved modules and the names of
+renamed types in the Swift files below the given paths (the current
+directory by default), and lists the uses of deprecated symbols, which
+need a person to migrate them.
+
+    python3 migrate.py --dry-run Sources Tests
+    python3 migrate.py Sources Tests
+"""
+
+import argparse
+import difflib
+import json
+import os
+import re
+import sys
+
+MANIFEST = json.loads(@MANIFEST@)
+
+SKIP_DIRS = {".git", ".build", ".swiftpm", "DerivedData", "node_modules", "Pods", "Carthage"}
+IMPORT = re.compile(r"^(\s*(?:@\w+(?:\([^)]*\))?\s+)*import\s+(?:(?:typealias|struct|class|enum|protocol|let|var|func)\s+)?)([A-Za-z_]\w*)\b(.*)$")
+IDENT = re.compile(r"[A-Za-z_]\w*")
+
+
+def swift_files(paths):
+    for top in paths:
+        if os.path.isfile(top):
+            yield top
+            continue
+        for dirpath, dirnames, filenames in os.walk(top):
+            dirnames[:] = sorted(d for d in dirnames if d not in SKIP_DIRS)
+            for name in sorted(filenames):
+                if name.endswith(".swift"):
+                    yield os.path.join(dirpath, name)
+
+
+def rewrite_imports(lines, mapping):
+    """Applies one module move to the lines of a file, as rewrite-imports does."""
+    src, dst, keep = mapping["from"], mapping["to"], set(mapping["keep"])
+    found, has_dst, used = [], False, set()
+    for i, line in enumerate(lines):
+        m = IMPORT.match(line)
+        if not m:
+            used.update(IDENT.findall(line))
+            continue
+        if m.group(2) == src:
+            found.append(i)
+        elif m.group(2) == dst and not m.group(3).startswith("."):
+            has_dst = True
+    if not found:
+        return lines
+    kept = bool(keep & used)
+
+    out = []
+    for i, line in enumerate(lines):
+        if i in found:
+            m = IMPORT.match(line)
+            moved = m.group(1) + dst + m.group(3)
+            if m.group(3).startswith("."):
+                symbol = IDENT.match(m.group(3)[1:])
+                out.append(line if symbol and symbol.group(0) in keep else moved)
+            elif kept:
+                out.append(line)
+                if not has_dst:
+                    out.append(moved)
+                    has_dst = True
+            elif not has_dst:
+                out.append(moved)
+                has_dst = True
+            continue
+        out.append(requalify(line, src, dst, keep if kept else set()))
+    return out
+
+
+def requalify(line, src, dst, keep):
+    def repl(m):
+        return m.group(0) if m.group(1) in keep else dst + "." + m.group(1)
+    return re.sub(r"(?<![\w.])" + re.escape(src) + r"\.([A-Za-z_]\w*)", repl, line)
+
+
+def rename_types(line):
+    for r in MANIFEST["renames"]:
+        line = re.sub(r"\b" + re.escape(r["module"]) + r"\." + re.escape(r["from"]) + r"\b", r["module"] + "." + r["to"], line)
+        line = re.sub(r"(?<![\w.])" + re.escape(r["from"]) + r"\b", r["to"], line)
+    return line
+
+
+def deprecated_uses(path, lines):
+    """Yields the uses of deprecated symbols of the modules the file imports."""
+    imported = {m.group(2) for m in map(IMPORT.match, lines) if m}
+    names = {d["name"]: d for d in MANIFEST["deprecated"] if d["module"] in imported}
+    if not names:
+        return
+    for n, line in enumerate(lines, 1):
+        if IMPORT.match(line):
+            continue
+        code = line.split("//", 1)[0]
+        for ident in sorted(set(IDENT.findall(code))):
+            d = names.get(ident)
+            if d:
+                note = d.get("message") or ("renamed to " + d["renamed"] if d.get("renamed") else "deprecated")
+                yield "%s:%d: %s.%s: %s" % (path, n, d["module"], d["name"], note)
+
+
+def main():
+    parser = argparse.ArgumentParser(description="Migrate Swift code to UmbraCore " + MANIFEST["version"] + ".")
+    parser.add_argument("paths", nargs="*", default=["."], help="files and directories to migrate")
+    parser.add_argument("--dry-run", action="store_true", help="print a diff instead of writing the files")
+    args = parser.parse_args()
+
+    changed = 0
+    warnings = []
+    for path in swift_files(args.paths):
+        with open(path, encoding="utf-8", newline="") as f:
+            before = f.read()
+        lines = before.split("\n")
+        for mapping in MANIFEST["imports"]:
+            lines = rewrite_imports(lines, mapping)
+        lines = [rename_types(line) for line in lines]
+        warnings.extend(deprecated_uses(path, lines))
+        after = "\n".join(lines)
+        if after == before:
+            continue
+        changed += 1
+        if args.dry_run:
+            sys.stdout.writelines(difflib.unified_diff(
+                before.splitlines(True), after.splitlines(True), "a/" + path, "b/" + path))
+        else:
+            with open(path, "w", encoding="utf-8", newline="") as f:
+                f.write(after)
+
+    for w in warnings:
+        print("warning: " + w, file=sys.stderr)
+    verb = "would change" if args.dry_run else "changed"
+    print("%d files %s, %d uses of deprecated symbols to migrate by hand" % (changed, verb, len(warnings)))
+
+
+if __name__ == "__main__":
+    main()
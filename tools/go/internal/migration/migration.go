@@ -0,0 +1,193 @@
+// Package migration packages the import rewrites, type renames and
+// deprecations of UmbraCore's public modules for downstream codebases,
+// which need the same rewrites but do not have UmbraCore's sources to
+// resolve them from.
+package migration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/deprecation"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/importrewrite"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+)
+
+// Manifest is the migration a downstream codebase runs.
+type Manifest struct {
+	// Version is the UmbraCore version migrated to.
+	Version string `json:"version"`
+	// Imports apply in order, each to the result of the ones before.
+	Imports    []Import     `json:"imports"`
+	Renames    []Rename     `json:"renames"`
+	Deprecated []Deprecated `json:"deprecated"`
+}
+
+// Import moves the imports of one module to another, as rewrite-imports
+// does in this tree.
+type Import struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Keep lists the symbols that stay in From: a file using any of them
+	// keeps importing From and imports To as well.
+	Keep []string `json:"keep"`
+}
+
+// Rename is a type declared deprecated with a renamed: argument.
+type Rename struct {
+	Module string `json:"module"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// Deprecated is a deprecated symbol downstream code should stop using.
+type Deprecated struct {
+	Module  string `json:"module"`
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`
+	Message string `json:"message,omitempty"`
+	Renamed string `json:"renamed,omitempty"`
+	Sunset  string `json:"sunset"`
+}
+
+// typeKinds are the declarations whose renames the script applies. Other
+// renames, of functions and properties, usually change the labels too and
+// are only reported.
+var typeKinds = map[string]bool{
+	"class": true, "struct": true, "enum": true, "protocol": true, "typealias": true, "actor": true,
+}
+
+// Build builds the manifest from the mapping files' rewrites, resolving the
+// symbols each keeps from the sources at root, and from the deprecated
+// symbols. Rules locate the modules' sources.
+func Build(root, version string, configs []*importrewrite.Config, symbols []deprecation.Symbol, rules []modulenames.Rule) (*Manifest, error) {
+	m := &Manifest{Version: version, Imports: []Import{}, Renames: []Rename{}, Deprecated: []Deprecated{}}
+	for _, c := range configs {
+		for _, r := range c.Rewrites {
+			kept, err := importrewrite.Kept(root, rules, r)
+			if err != nil {
+				return nil, err
+			}
+			m.Imports = append(m.Imports, Import{From: r.From, To: r.To, Keep: kept})
+		}
+	}
+
+	for _, s := range symbols {
+		if !s.Tracked {
+			continue
+		}
+		if s.Renamed != "" && typeKinds[s.Kind] && isIdentifier(s.Renamed) {
+			m.Renames = append(m.Renames, Rename{Module: s.Module, From: s.Name, To: s.Renamed})
+			continue
+		}
+		m.Deprecated = append(m.Deprecated, Deprecated{Module: s.Module, Name: s.Name, Kind: s.Kind, Message: s.Message, Renamed: s.Renamed, Sunset: s.Sunset})
+	}
+	sort.Slice(m.Renames, func(i, j int) bool {
+		a, b := m.Renames[i], m.Renames[j]
+		if a.Module != b.Module {
+			return a.Module < b.Module
+		}
+		return a.From < b.From
+	})
+	sort.Slice(m.Deprecated, func(i, j int) bool {
+		a, b := m.Deprecated[i], m.Deprecated[j]
+		if a.Module != b.Module {
+			return a.Module < b.Module
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Kind < b.Kind
+	})
+	m.Deprecated = compactDeprecated(m.Deprecated)
+	return m, nil
+}
+
+// isIdentifier reports whether s is a plain type name, which the script
+// can put in place of another.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r != '_' && !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || i > 0 && '0' <= r && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// compactDeprecated drops overloads, which the script cannot tell apart.
+func compactDeprecated(sorted []Deprecated) []Deprecated {
+	out := sorted[:0]
+	for i, d := range sorted {
+		if i == 0 || d.Module != sorted[i-1].Module || d.Name != sorted[i-1].Name {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Empty reports whether the manifest migrates nothing.
+func (m *Manifest) Empty() bool {
+	return len(m.Imports) == 0 && len(m.Renames) == 0 && len(m.Deprecated) == 0
+}
+
+// WriteJSON writes the manifest as indented JSON.
+func WriteJSON(w io.Writer, m *Manifest) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// WriteMarkdown writes the manifest as migration notes.
+func WriteMarkdown(w io.Writer, m *Manifest) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Migrating to UmbraCore %s\n", m.Version)
+	if len(m.Imports) > 0 {
+		b.WriteString("\n## Imports\n\n")
+		for _, i := range m.Imports {
+			fmt.Fprintf(&b, "- Replace `import %s` with `import %s`", i.From, i.To)
+			if len(i.Keep) > 0 {
+				fmt.Fprintf(&b, "; files using %s keep importing %s as well", codeList(i.Keep), i.From)
+			}
+			b.WriteString(".\n")
+		}
+	}
+	if len(m.Renames) > 0 {
+		b.WriteString("\n## Renamed Types\n\n")
+		for _, r := range m.Renames {
+			fmt.Fprintf(&b, "- `%s.%s` is now `%s`.\n", r.Module, r.From, r.To)
+		}
+	}
+	if len(m.Deprecated) > 0 {
+		b.WriteString("\n## Deprecated\n\n")
+		b.WriteString("| Module | Symbol | Sunset | Message |\n")
+		b.WriteString("|--------|--------|--------|---------|\n")
+		for _, d := range m.Deprecated {
+			message := d.Message
+			if d.Renamed != "" {
+				message = strings.TrimSpace("Renamed to `" + d.Renamed + "`. " + message)
+			}
+			fmt.Fprintf(&b, "| %s | `%s` | %s | %s |\n", d.Module, d.Name, d.Sunset, strings.ReplaceAll(message, "|", "\\|"))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// codeList quotes the first few names.
+func codeList(names []string) string {
+	const shown = 8
+	var quoted []string
+	for _, n := range names[:min(len(names), shown)] {
+		quoted = append(quoted, "`"+n+"`")
+	}
+	if len(names) > shown {
+		quoted = append(quoted, fmt.Sprintf("%d more", len(names)-shown))
+	}
+	return strings.Join(quoted, ", ")
+}
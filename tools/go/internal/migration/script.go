@@ -0,0 +1,31 @@
+package migration
+
+import (
+	_ "embed"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// script is the migration script, into which WriteScript puts the
+// manifest.
+//
+//go:embed migrate.py
+var script string
+
+// WriteScript writes a standalone Python 3 script that applies m to the
+// Swift files of a downstream codebase.
+func WriteScript(w io.Writer, m *Manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	// A Go string quoted to ASCII reads the same as a Python literal.
+	out := strings.NewReplacer(
+		"@VERSION@", m.Version,
+		"@MANIFEST@", strconv.QuoteToASCII(string(data)),
+	).Replace(script)
+	_, err = io.WriteString(w, out)
+	return err
+}
@@ -0,0 +1,618 @@
+// Package mockgen generates mocks of the Swift protocols in the protocol
+// index: a final class conforming to the protocol that records every call
+// and returns what the test configures. Hand-written mocks drift from the
+// protocols they stand in for; generated ones are regenerated instead.
+package mockgen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/generated"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/protocols"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+)
+
+// Tool is the generator a mock's header names.
+const Tool = "umbratool genmock"
+
+// builtIn are standard protocols a mock conforms to without implementing
+// anything, or whose conformance its declaration provides.
+var builtIn = map[string]bool{"AnyObject": true, "Sendable": true, "NSObjectProtocol": true}
+
+// Options configures a mock.
+type Options struct {
+	// Name is the mock class (default "Mock" and the protocol's name,
+	// less a Protocol suffix).
+	Name string
+	// TypeAliases bind the protocol's associated types, by name.
+	TypeAliases map[string]string
+}
+
+// Mock is a generated mock.
+type Mock struct {
+	Protocol *protocols.Decl `json:"-"`
+	Name     string          `json:"name"`
+	// Imports are the modules the mock imports: the protocol's module and
+	// those its declaring files import.
+	Imports []string `json:"imports"`
+	// Skipped are requirements the mock does not implement, left to be
+	// written by hand in an extension.
+	Skipped []string `json:"skipped,omitempty"`
+	// Source is the mock's Swift source, stamped as generated.
+	Source string `json:"-"`
+}
+
+// Resolve finds the protocol called name in ix. module, when set, picks
+// among protocols of the same name in several modules.
+func Resolve(ix *protocols.Index, name, module string) (*protocols.Decl, error) {
+	var found []*protocols.Decl
+	for _, d := range ix.Decls {
+		if d.Kind == protocols.KindProtocol && d.Name == name && (module == "" || d.Module == module) {
+			found = append(found, d)
+		}
+	}
+	switch len(found) {
+	case 0:
+		if module != "" {
+			return nil, fmt.Errorf("no protocol %s in module %s", name, module)
+		}
+		return nil, fmt.Errorf("no protocol %s in the tree", name)
+	case 1:
+		return found[0], nil
+	}
+	modules := make([]string, len(found))
+	for i, d := range found {
+		modules[i] = d.Module + " (" + d.File + ")"
+	}
+	return nil, fmt.Errorf("protocol %s is declared in %s; pick one with --module", name, strings.Join(modules, ", "))
+}
+
+// DefaultName is the mock class name of a protocol.
+func DefaultName(protocol string) string {
+	name := protocol
+	if base, ok := strings.CutSuffix(name, "Protocol"); ok && base != "" {
+		name = base
+	}
+	return "Mock" + name
+}
+
+// Generate writes the mock of proto. Requirements of the protocols proto
+// inherits from are included when ix holds them. root is the project
+// root, from which the protocol's declaration is read again for its
+// access level.
+func Generate(root string, ix *protocols.Index, proto *protocols.Decl, opts Options) (*Mock, error) {
+	name := opts.Name
+	if name == "" {
+		name = DefaultName(proto.Name)
+	}
+	public, err := isPublic(root, proto)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{ix: ix, proto: proto, public: public, imports: map[string]bool{}, conditions: len(proto.Guard.Conditions)}
+	g.collect(proto, map[string]bool{})
+	for _, d := range g.decls {
+		if d.Module != "" {
+			g.imports[d.Module] = true
+		}
+		for module := range ix.Imports[d.File] {
+			g.imports[module] = true
+		}
+	}
+	if g.actor {
+		return nil, fmt.Errorf("%s is constrained to actors, which genmock does not mock", proto.Name)
+	}
+	for _, m := range g.members {
+		if m.Kind != protocols.MemberAssociatedType {
+			continue
+		}
+		if _, ok := opts.TypeAliases[m.Name]; !ok {
+			return nil, fmt.Errorf("%s has an associated type %s; bind it with --typealias %s=Type", proto.Name, m.Name, m.Name)
+		}
+	}
+
+	mock := &Mock{Protocol: proto, Name: name}
+	body := g.write(name, opts.TypeAliases)
+	for module := range g.imports {
+		mock.Imports = append(mock.Imports, module)
+	}
+	sort.Strings(mock.Imports)
+
+	var b strings.Builder
+	for _, module := range mock.Imports {
+		if module == proto.Module && !public {
+			b.WriteString("@testable ")
+		}
+		b.WriteString("import " + module + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(body)
+	mock.Skipped = g.skipped
+	mock.Source = generated.Header("//", Tool, proto.File, b.String())
+	return mock, nil
+}
+
+// FileName is the file a mock is written to.
+func (m *Mock) FileName() string {
+	return m.Name + ".swift"
+}
+
+type generator struct {
+	ix     *protocols.Index
+	proto  *protocols.Decl
+	public bool
+	// decls are proto and the protocols it inherits from, in the order
+	// their requirements are listed.
+	decls   []*protocols.Decl
+	members []protocols.Member
+	imports map[string]bool
+	// objc is set when the mock must be an NSObject, actor when proto is
+	// constrained to actors, and sendable when it must be Sendable.
+	objc, actor, sendable bool
+	// conditions is the number of #if conditions enclosing proto, which
+	// enclose every member too.
+	conditions int
+	skipped    []string
+}
+
+// collect adds the requirements of d and of the protocols it inherits
+// from, each signature once.
+func (g *generator) collect(d *protocols.Decl, seen map[string]bool) {
+	g.decls = append(g.decls, d)
+	if d.ObjC {
+		g.objc = true
+	}
+	for _, m := range d.Members {
+		key := m.Kind + " " + m.Signature
+		if m.Static {
+			key = "static " + key
+		}
+		if seen[key] || m.Kind == protocols.MemberTypeAlias {
+			continue
+		}
+		seen[key] = true
+		g.members = append(g.members, m)
+	}
+	for _, parent := range d.Inherits {
+		parent = strings.TrimSpace(parent)
+		switch {
+		case parent == "Actor" || parent == "GlobalActor":
+			g.actor = true
+		case parent == "Sendable":
+			g.sendable = true
+		case parent == "NSObjectProtocol":
+			g.objc = true
+		}
+		if builtIn[parent] || g.has(parent) {
+			continue
+		}
+		if inherited := g.lookup(parent, d.Module); inherited != nil {
+			g.collect(inherited, seen)
+		} else {
+			g.skipped = append(g.skipped, "the requirements of "+parent+", which is not declared in the tree")
+		}
+	}
+}
+
+func (g *generator) has(name string) bool {
+	for _, d := range g.decls {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup finds an inherited protocol, preferring one of module.
+func (g *generator) lookup(name, module string) *protocols.Decl {
+	var first *protocols.Decl
+	for _, d := range g.ix.Decls {
+		if d.Kind != protocols.KindProtocol || d.Name != name {
+			continue
+		}
+		if d.Module == module {
+			return d
+		}
+		if first == nil {
+			first = d
+		}
+	}
+	return first
+}
+
+// write renders the mock class.
+func (g *generator) write(name string, aliases map[string]string) string {
+	access := ""
+	if g.public {
+		access = "public "
+	}
+	var b strings.Builder
+	if conds := g.proto.Guard.Conditions; len(conds) > 0 {
+		b.WriteString("#if " + condition(conds) + "\n\n")
+	}
+
+	inherits := []string{g.proto.Name}
+	if g.objc {
+		inherits = append([]string{"NSObject"}, inherits...)
+		g.imports["Foundation"] = true
+	}
+	if g.sendable {
+		inherits = append(inherits, "@unchecked Sendable")
+	}
+	fmt.Fprintf(&b, "/// Mock of ``%s`` recording its calls, generated by `umbratool genmock`.\n", g.proto.Name)
+	fmt.Fprintf(&b, "%sfinal class %s: %s {\n", access, name, strings.Join(inherits, ", "))
+
+	var aliasNames []string
+	for _, m := range g.members {
+		if m.Kind == protocols.MemberAssociatedType {
+			aliasNames = append(aliasNames, m.Name)
+		}
+	}
+	sort.Strings(aliasNames)
+	for _, alias := range aliasNames {
+		fmt.Fprintf(&b, "  %stypealias %s=%s\n", access, alias, aliases[alias])
+	}
+	if len(aliasNames) > 0 {
+		b.WriteString("\n")
+	}
+
+	hasInit := false
+	for _, m := range g.members {
+		if m.Kind == protocols.MemberInit && len(m.Params) == 0 {
+			hasInit = true
+		}
+	}
+	if !hasInit {
+		if g.objc {
+			fmt.Fprintf(&b, "  %soverride init() {\n    super.init()\n  }\n", access)
+		} else {
+			fmt.Fprintf(&b, "  %sinit() {}\n", access)
+		}
+	}
+
+	overloads := make(map[string]int)
+	for _, m := range g.members {
+		if m.Kind == protocols.MemberFunc {
+			overloads[m.Name]++
+		}
+	}
+	for _, m := range g.members {
+		var block string
+		switch m.Kind {
+		case protocols.MemberFunc:
+			block = g.function(m, access, overloads[m.Name] > 1)
+		case protocols.MemberVar:
+			block = g.property(m, access)
+		case protocols.MemberInit:
+			block = g.initialiser(m, access)
+		case protocols.MemberSubscript:
+			g.skipped = append(g.skipped, fmt.Sprintf("subscript at %s:%d", g.fileOf(m), m.Line))
+		}
+		if block == "" {
+			continue
+		}
+		b.WriteString("\n")
+		if conds := m.Guard.Conditions[min(g.conditions, len(m.Guard.Conditions)):]; len(conds) > 0 {
+			block = "#if " + condition(conds) + "\n" + block + "#endif\n"
+		}
+		b.WriteString(block)
+	}
+	b.WriteString("}\n")
+	if len(g.proto.Guard.Conditions) > 0 {
+		b.WriteString("\n#endif\n")
+	}
+	return b.String()
+}
+
+// function renders a method requirement and the properties recording its
+// calls: <base>CallCount, <base>ReceivedArguments, <base>Error for
+// throwing methods, <base>ReturnValue for those returning a value, and
+// <base>Handler, which when set computes the result instead.
+func (g *generator) function(m protocols.Member, access string, overloaded bool) string {
+	base := strings.Trim(m.Name, "`")
+	if overloaded {
+		for _, p := range m.Params {
+			label := p.Label
+			if label == "_" {
+				label = p.Name
+			}
+			base += capitalise(strings.Trim(label, "`"))
+		}
+	}
+	static := ""
+	if m.Static {
+		static = "static "
+	}
+	decl := access + static + "var " + base
+	generics := m.GenericParams
+
+	if !identifier.MatchString(base) {
+		g.skipped = append(g.skipped, "operator "+m.Signature)
+		return ""
+	}
+	async, throws := false, ""
+	for _, effect := range strings.Fields(m.Effects) {
+		if effect == "async" {
+			async = true
+		}
+		if effect == "throws" || strings.HasPrefix(effect, "throws(") {
+			throws = effect
+		}
+	}
+	returns := m.Returns
+	if returns == "Void" || returns == "()" {
+		returns = ""
+	}
+	if strings.Contains(returns, "Self") {
+		g.skipped = append(g.skipped, fmt.Sprintf("%s, which returns Self", m.Signature))
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "  // MARK: - %s\n\n", m.Signature)
+	fmt.Fprintf(&b, "  %sprivate(set) %s%sCallCount=0\n", access, static, "var "+base)
+
+	var names, recorded, handlerTypes []string
+	for _, p := range m.Params {
+		names = append(names, p.Name)
+		handlerTypes = append(handlerTypes, valueType(p.Type, generics, true))
+		if t := valueType(p.Type, generics, false); t != "" {
+			recorded = append(recorded, p.Name+": "+t)
+		}
+	}
+	switch len(recorded) {
+	case 0:
+	case 1:
+		_, t, _ := strings.Cut(recorded[0], ": ")
+		fmt.Fprintf(&b, "  %sprivate(set) %s%sReceivedArguments: [%s]=[]\n", access, static, "var "+base, t)
+	default:
+		fmt.Fprintf(&b, "  %sprivate(set) %s%sReceivedArguments: [(%s)]=[]\n", access, static, "var "+base, strings.Join(recorded, ", "))
+	}
+	if throws != "" {
+		errorType := "Error"
+		if inner, ok := strings.CutPrefix(throws, "throws("); ok {
+			errorType = strings.TrimSuffix(inner, ")")
+		}
+		fmt.Fprintf(&b, "  %sError: %s?\n", decl, errorType)
+	}
+	generic := mentions(returns, generics)
+	switch {
+	case returns == "":
+	case generic:
+		fmt.Fprintf(&b, "  %sReturnValue: Any?\n", decl)
+	case strings.HasSuffix(returns, "?"):
+		fmt.Fprintf(&b, "  %sReturnValue: %s\n", decl, returns)
+	default:
+		fmt.Fprintf(&b, "  %sReturnValue: %s!\n", decl, returns)
+	}
+	result := "Void"
+	if returns != "" {
+		result = valueType(returns, generics, true)
+	}
+	effects := ""
+	if async {
+		effects += "async "
+	}
+	if throws != "" {
+		effects += throws + " "
+	}
+	fmt.Fprintf(&b, "  %sHandler: ((%s) %s-> %s)?\n\n", decl, strings.Join(handlerTypes, ", "), effects, result)
+
+	fmt.Fprintf(&b, "  %s%sfunc %s%s(%s)", access, static, m.Name, genericClause(m), parameterList(m))
+	if m.Effects != "" {
+		b.WriteString(" " + m.Effects)
+	}
+	if m.Returns != "" {
+		b.WriteString(" -> " + m.Returns)
+	}
+	if where := whereClause(m); where != "" {
+		b.WriteString(" where " + where)
+	}
+	b.WriteString(" {\n")
+	fmt.Fprintf(&b, "    %sCallCount += 1\n", base)
+	switch len(recorded) {
+	case 0:
+	case 1:
+		name, _, _ := strings.Cut(recorded[0], ": ")
+		fmt.Fprintf(&b, "    %sReceivedArguments.append(%s)\n", base, name)
+	default:
+		parts := make([]string, len(recorded))
+		for i, r := range recorded {
+			name, _, _ := strings.Cut(r, ": ")
+			parts[i] = name + ": " + name
+		}
+		fmt.Fprintf(&b, "    %sReceivedArguments.append((%s))\n", base, strings.Join(parts, ", "))
+	}
+	if throws != "" {
+		fmt.Fprintf(&b, "    if let error=%sError {\n      throw error\n    }\n", base)
+	}
+	call := "handler(" + strings.Join(names, ", ") + ")"
+	if async {
+		call = "await " + call
+	}
+	if throws != "" {
+		call = "try " + call
+	}
+	cast := ""
+	if generic {
+		cast = " as! " + returns
+	}
+	fmt.Fprintf(&b, "    if let handler=%sHandler {\n", base)
+	if returns == "" {
+		fmt.Fprintf(&b, "      %s\n    }\n", call)
+	} else {
+		fmt.Fprintf(&b, "      return %s%s\n    }\n", call, cast)
+		fmt.Fprintf(&b, "    return %sReturnValue%s\n", base, cast)
+	}
+	b.WriteString("  }\n")
+	return b.String()
+}
+
+var (
+	propertyPattern = regexp.MustCompile(`\bvar\s+([A-Za-z_]\w*)\s*:\s*(.+?)\s*\{`)
+	initPattern     = regexp.MustCompile(`\binit[?!]?`)
+	identifier      = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+)
+
+// property renders a property requirement. An optional is stored; any
+// other type is backed by an implicitly unwrapped underlying<Name> the
+// test sets before use.
+func (g *generator) property(m protocols.Member, access string) string {
+	match := propertyPattern.FindStringSubmatch(m.Text)
+	if match == nil {
+		g.skipped = append(g.skipped, fmt.Sprintf("property %s, whose declaration continues past %s:%d", m.Name, g.fileOf(m), m.Line))
+		return ""
+	}
+	name, typ := match[1], match[2]
+	static := ""
+	if m.Static {
+		static = "static "
+	}
+	if strings.HasSuffix(typ, "?") {
+		return fmt.Sprintf("  %s%svar %s: %s\n", access, static, name, typ)
+	}
+	underlying := "underlying" + capitalise(name)
+	return fmt.Sprintf("  %s%svar %s: %s!\n  %s%svar %s: %s {\n    get { %s }\n    set { %s=newValue }\n  }\n",
+		access, static, underlying, typ, access, static, name, typ, underlying, underlying)
+}
+
+// initialiser renders an initialiser requirement, which records nothing.
+func (g *generator) initialiser(m protocols.Member, access string) string {
+	body := "{}"
+	if g.objc {
+		body = "{\n    super.init()\n  }"
+	}
+	effects := ""
+	if m.Effects != "" {
+		effects = " " + m.Effects
+	}
+	init := initPattern.FindString(m.Text)
+	if init == "" {
+		init = "init"
+	}
+	return fmt.Sprintf("  // MARK: - %s\n\n  %s%s%s(%s)%s %s\n", m.Signature, access, init, genericClause(m), parameterList(m), effects, body)
+}
+
+func (g *generator) fileOf(m protocols.Member) string {
+	for _, d := range g.decls {
+		for _, dm := range d.Members {
+			if dm.Line == m.Line && dm.Signature == m.Signature {
+				return d.File
+			}
+		}
+	}
+	return g.proto.File
+}
+
+// parameterList renders the parameters of m as declared, with labels and
+// defaults dropped: requirements cannot have defaults.
+func parameterList(m protocols.Member) string {
+	parts := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		head := p.Label
+		if p.Name != p.Label {
+			head += " " + p.Name
+		}
+		parts[i] = head + ": " + p.Type
+	}
+	return strings.Join(parts, ", ")
+}
+
+func genericClause(m protocols.Member) string {
+	if len(m.GenericParams) == 0 {
+		return ""
+	}
+	return "<" + strings.Join(m.GenericParams, ", ") + ">"
+}
+
+func whereClause(m protocols.Member) string {
+	return strings.Join(m.Constraints, ", ")
+}
+
+// valueType is the type a mock stores an argument of type t as, or, with
+// handler, the type its handler takes it as. Arguments of generic types
+// are stored as Any. It returns "" for arguments that cannot be stored:
+// non-escaping closures and autoclosures.
+func valueType(t string, generics []string, handler bool) string {
+	t = strings.TrimSpace(t)
+	for _, prefix := range []string{"inout ", "__owned ", "borrowing ", "consuming "} {
+		t = strings.TrimPrefix(t, prefix)
+	}
+	escaping := strings.Contains(t, "@escaping")
+	autoclosure := strings.Contains(t, "@autoclosure")
+	t = strings.TrimSpace(strings.NewReplacer("@escaping ", "", "@autoclosure ", "").Replace(t))
+	if !handler && (autoclosure || strings.Contains(t, "->") && !escaping) {
+		return ""
+	}
+	if rest, ok := strings.CutPrefix(t, "some "); ok {
+		t = "any " + rest
+	}
+	if elem, ok := strings.CutSuffix(t, "..."); ok {
+		t = "[" + elem + "]"
+	}
+	if mentions(t, generics) {
+		return "Any"
+	}
+	return t
+}
+
+// mentions reports whether type t names one of the generic parameters.
+func mentions(t string, generics []string) bool {
+	for _, g := range generics {
+		name, _, _ := strings.Cut(g, ":")
+		if hasWord(t, strings.TrimSpace(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasWord(s, word string) bool {
+	return word != "" && regexp.MustCompile(`\b`+regexp.QuoteMeta(word)+`\b`).MatchString(s)
+}
+
+// condition joins #if conditions into one.
+func condition(conds []string) string {
+	if len(conds) == 1 {
+		return conds[0]
+	}
+	parts := make([]string, len(conds))
+	for i, c := range conds {
+		parts[i] = "(" + c + ")"
+	}
+	return strings.Join(parts, " && ")
+}
+
+func capitalise(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+var publicPattern = regexp.MustCompile(`(?:^|\s)(?:public|open)\s`)
+
+// isPublic reports whether the declaration of proto is public, reading
+// its line again since the index does not record access levels.
+func isPublic(root string, proto *protocols.Decl) (bool, error) {
+	f, err := os.Open(filepath.Join(root, proto.File))
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	sc := textscan.NewScanner(f)
+	n := 0
+	for sc.Scan() {
+		n++
+		if n == proto.Line {
+			return publicPattern.MatchString(" " + sc.Text()), nil
+		}
+	}
+	return false, textscan.Check(proto.File, n, sc.Err())
+}
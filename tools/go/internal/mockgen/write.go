@@ -0,0 +1,158 @@
+package mockgen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/generated"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+)
+
+// ErrEdited is returned for a mock that would overwrite a file edited by
+// hand, or one genmock did not write.
+var ErrEdited = errors.New("edited by hand")
+
+// TargetName is the test-support library of module.
+func TargetName(module string) string {
+	return module + "TestSupport"
+}
+
+// Placement is where a mock was written.
+type Placement struct {
+	// File is the mock's file, relative to the project root.
+	File string `json:"file"`
+	// Target is the label of the test-support library compiling it.
+	Target string `json:"target"`
+	// Created is set when the library's BUILD file was created, and Deps
+	// are the dependencies it gained.
+	Created bool     `json:"created,omitempty"`
+	Deps    []string `json:"deps,omitempty"`
+}
+
+// Write writes mock to dir, relative to root, and adds it to the
+// test-support library there, creating the library when dir has no BUILD
+// file. The library gains the targets of the modules the mock imports,
+// looked up in rules. An existing file is only overwritten when it is an
+// unedited mock, or with force. When the mock is written but its BUILD
+// file cannot be saved, Write returns the placement along with the error.
+func Write(root, dir string, mock *Mock, rules []modulenames.Rule, force bool) (*Placement, error) {
+	rel := path.Join(dir, mock.FileName())
+	file := filepath.Join(root, filepath.FromSlash(rel))
+	if data, err := os.ReadFile(file); err == nil && !force {
+		regions, err := generated.Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rel, err)
+		}
+		if len(regions) == 0 || regions[0].Kind != generated.KindHeader || regions[0].Tool != Tool || regions[0].Edited() {
+			return nil, fmt.Errorf("%s: %w; pass --force to overwrite it", rel, ErrEdited)
+		}
+	}
+
+	f, target, created, err := library(root, dir, mock.Protocol.Module)
+	if err != nil {
+		return nil, err
+	}
+	p := &Placement{File: rel, Target: "//" + dir + ":" + target, Created: created}
+	// A BUILD file is only saved when edited, since saving reformats it.
+	edited := created
+
+	labels := make(map[string]string)
+	for _, r := range rules {
+		if _, seen := labels[r.ModuleName]; r.ModuleName != "" && !seen && !isTest(r.Kind) {
+			labels[r.ModuleName] = r.Label
+		}
+	}
+	for _, module := range mock.Imports {
+		label, ok := labels[module]
+		if !ok {
+			continue
+		}
+		added, err := f.AddDep(target, "deps", label)
+		if err != nil {
+			return nil, err
+		}
+		if added {
+			p.Deps, edited = append(p.Deps, label), true
+		}
+	}
+	sort.Strings(p.Deps)
+	if !buildfile.Compiles(f.Rule(target), "srcs", mock.FileName()) {
+		if _, err := f.AddSource(target, "srcs", mock.FileName()); err != nil {
+			return nil, err
+		}
+		edited = true
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return nil, err
+	}
+	if err := atomicfile.WriteFile(file, []byte(mock.Source), 0o644); err != nil {
+		return nil, err
+	}
+	if edited {
+		if err := f.Save(); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
+
+// library loads the BUILD file of dir and names the Swift library in it
+// that mocks go into: the test-support library of module, or failing that
+// the package's only Swift library. Without a BUILD file it starts one.
+func library(root, dir, module string) (*buildfile.File, string, bool, error) {
+	target := TargetName(module)
+	for _, name := range []string{"BUILD.bazel", "BUILD"} {
+		rel := path.Join(dir, name)
+		file := filepath.Join(root, filepath.FromSlash(rel))
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		f, err := buildfile.Load(file, dir)
+		if err != nil {
+			return nil, "", false, err
+		}
+		if f.Rule(target) != nil {
+			return f, target, false, nil
+		}
+		var libraries []string
+		for _, r := range modulenames.FileRules(f, rel) {
+			if _, name, ok := strings.Cut(r.Label, ":"); ok && !isTest(r.Kind) {
+				libraries = append(libraries, name)
+			}
+		}
+		if len(libraries) != 1 {
+			return nil, "", false, fmt.Errorf("%s has no library named %s to hold mocks", rel, target)
+		}
+		return f, libraries[0], false, nil
+	}
+
+	file := filepath.Join(root, filepath.FromSlash(dir), "BUILD.bazel")
+	f, err := buildfile.New(file, dir, []byte(fmt.Sprintf(newBuild, target)))
+	if err != nil {
+		return nil, "", false, err
+	}
+	return f, target, true, nil
+}
+
+// newBuild is the BUILD file of a new test-support library.
+const newBuild = `load("//:bazel/macros/swift.bzl", "umbra_swift_library")
+
+umbra_swift_library(
+    name = %q,
+    testonly = True,
+    srcs = glob(["*.swift"]),
+    deps = [],
+)
+`
+
+func isTest(kind string) bool {
+	return strings.Contains(kind, "test")
+}
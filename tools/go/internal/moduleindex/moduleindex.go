@@ -0,0 +1,146 @@
+// Package moduleindex reads and writes swift_modules.json, the generated
+// index that maps every Swift module_name in the workspace to its Bazel
+// label and source directory, so tools need not rescan BUILD files.
+package moduleindex
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+)
+
+// File is the index's name, relative to the workspace root.
+const File = "swift_modules.json"
+
+// Index is the content of swift_modules.json.
+type Index struct {
+	// Modules are the workspace's Swift rules, sorted by module name and
+	// label.
+	Modules []modulenames.Rule `json:"modules"`
+	// BuildFiles are the BUILD files the index was built from.
+	BuildFiles []string `json:"buildFiles"`
+}
+
+// Build scans the BUILD files below root. Files that fail to parse are
+// returned in skipped and left out of the index.
+func Build(root string) (ix *Index, skipped []string, err error) {
+	rules, skipped, err := modulenames.Scan(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].ModuleName != rules[j].ModuleName {
+			return rules[i].ModuleName < rules[j].ModuleName
+		}
+		return rules[i].Label < rules[j].Label
+	})
+
+	bad := make(map[string]bool, len(skipped))
+	for _, rel := range skipped {
+		bad[rel] = true
+	}
+	seen := make(map[string]bool)
+	ix = &Index{Modules: rules, BuildFiles: []string{}}
+	for _, r := range rules {
+		if !seen[r.File] && !bad[r.File] {
+			seen[r.File] = true
+			ix.BuildFiles = append(ix.BuildFiles, r.File)
+		}
+	}
+	sort.Strings(ix.BuildFiles)
+	return ix, skipped, nil
+}
+
+// Marshal encodes ix as indented JSON, the format of swift_modules.json.
+func (ix *Index) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(ix); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Load reads the index at file.
+func Load(file string) (*Index, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var ix Index
+	if err := json.Unmarshal(data, &ix); err != nil {
+		return nil, err
+	}
+	return &ix, nil
+}
+
+// Rules returns the workspace's Swift rules from root's swift_modules.json
+// when it is at least as new as every BUILD file it lists, and from a scan
+// of the BUILD files otherwise. BUILD files added since the index was
+// generated are only seen by regenerating it.
+func Rules(root string) ([]modulenames.Rule, error) {
+	file := filepath.Join(root, File)
+	if ix, err := Load(file); err == nil && fresh(root, file, ix) {
+		return ix.Modules, nil
+	}
+	rules, _, err := modulenames.Scan(root)
+	return rules, err
+}
+
+func fresh(root, file string, ix *Index) bool {
+	info, err := os.Stat(file)
+	if err != nil {
+		return false
+	}
+	for _, rel := range ix.BuildFiles {
+		b, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil || b.ModTime().After(info.ModTime()) {
+			return false
+		}
+	}
+	return true
+}
+
+// Named returns the rules compiling to module name.
+func (ix *Index) Named(name string) []modulenames.Rule {
+	var out []modulenames.Rule
+	for _, r := range ix.Modules {
+		if r.ModuleName == name {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// ForPath returns the rule whose source directory most closely contains
+// rel, a slash-separated path relative to the workspace root. Libraries
+// win over tests sharing the directory.
+func (ix *Index) ForPath(rel string) (modulenames.Rule, bool) {
+	rel = path.Clean(filepath.ToSlash(rel))
+	var best modulenames.Rule
+	found := false
+	for _, r := range ix.Modules {
+		if r.SourceDir != rel && !strings.HasPrefix(rel, r.SourceDir+"/") {
+			continue
+		}
+		switch {
+		case !found, len(r.SourceDir) > len(best.SourceDir):
+		case len(r.SourceDir) == len(best.SourceDir) && isTest(best.Kind) && !isTest(r.Kind):
+		default:
+			continue
+		}
+		best, found = r, true
+	}
+	return best, found
+}
+
+func isTest(kind string) bool {
+	return strings.Contains(kind, "test")
+}
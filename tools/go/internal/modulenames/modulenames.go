@@ -0,0 +1,320 @@
+// Package modulenames finds Swift rules across the workspace that compile
+// to the same module_name, which Swift cannot link into one binary, and
+// suggests names that tell them apart.
+package modulenames
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildfile"
+)
+
+// Origins of a rule's module name.
+const (
+	// OriginExplicit is a module_name attribute.
+	OriginExplicit = "explicit"
+	// OriginMacro is a macro that names the module after the target.
+	OriginMacro = "macro"
+	// OriginDerived is rules_swift's default, derived from the label.
+	OriginDerived = "derived"
+)
+
+// nameMacros name the module after the target instead of deriving it
+// from the label (bazel/macros/swift.bzl, tools/build_defs).
+var nameMacros = map[string]bool{
+	"umbra_swift_library":              true,
+	"umbra_test_library":               true,
+	"umbracore_foundation_free_module": true,
+}
+
+// foundationFreeKinds are the macros declaring a module Foundation-free
+// (tools/build_defs/umbracore_module.bzl).
+var foundationFreeKinds = map[string]bool{
+	"umbracore_foundation_free_module":        true,
+	"umbracore_foundation_independent_module": true,
+}
+
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// Rule is one Swift rule and the module it compiles to.
+type Rule struct {
+	Label      string `json:"label"`
+	Kind       string `json:"kind"`
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	ModuleName string `json:"moduleName"`
+	Origin     string `json:"origin"`
+	// SourceDir is the slash-separated directory holding the rule's srcs,
+	// relative to the workspace root: the fixed part of its glob patterns
+	// or the common directory of its listed files.
+	SourceDir string `json:"sourceDir"`
+	// Deps are the rule's deps as normalised //pkg:name labels.
+	Deps []string `json:"deps,omitempty"`
+}
+
+// Collision is a module name produced by more than one rule.
+type Collision struct {
+	ModuleName string `json:"moduleName"`
+	Rules      []Rule `json:"rules"`
+	// Suggestions maps the label of every rule but the one keeping the name
+	// to a module name that no other rule uses.
+	Suggestions map[string]string `json:"suggestions"`
+	// Fingerprints maps the same labels to the fingerprints of their
+	// issues.
+	Fingerprints map[string]string `json:"fingerprints,omitempty"`
+}
+
+// IsFoundationFree reports whether rules of kind declare a Foundation-free
+// module.
+func IsFoundationFree(kind string) bool {
+	return foundationFreeKinds[kind]
+}
+
+// IsSwiftRule reports whether rules of kind compile a Swift module.
+func IsSwiftRule(kind string) bool {
+	return nameMacros[kind] || strings.HasSuffix(kind, "swift_library") ||
+		strings.HasSuffix(kind, "swift_test") || strings.HasSuffix(kind, "swift_binary") ||
+		strings.HasSuffix(kind, "swift_test_library")
+}
+
+// Scan reads every BUILD file below root and returns its Swift rules. Like
+// Bazel, it ignores a BUILD file next to a BUILD.bazel. Files that fail to
+// parse are returned in skipped.
+func Scan(root string) (rules []Rule, skipped []string, err error) {
+	files, err := buildfile.FindAll(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	present := make(map[string]bool, len(files))
+	for _, rel := range files {
+		present[rel] = true
+	}
+	for _, rel := range files {
+		if path.Base(rel) == "BUILD" && present[path.Join(path.Dir(rel), "BUILD.bazel")] {
+			continue
+		}
+		f, err := buildfile.Load(filepath.Join(root, rel), buildfile.PackageOf(rel))
+		if err != nil {
+			skipped = append(skipped, rel)
+			continue
+		}
+		rules = append(rules, FileRules(f, rel)...)
+	}
+	return rules, skipped, nil
+}
+
+// FileRules returns the Swift rules declared in f, found at rel.
+func FileRules(f *buildfile.File, rel string) []Rule {
+	var rules []Rule
+	for _, r := range f.Rules("") {
+		kind, name := r.Kind(), r.Name()
+		if name == "" || !IsSwiftRule(kind) {
+			continue
+		}
+		start, _ := r.Call.Span()
+		rule := Rule{
+			Label:     "//" + f.Package + ":" + name,
+			Kind:      kind,
+			File:      rel,
+			Line:      start.Line,
+			SourceDir: sourceDir(f.Package, r.Attr("srcs")),
+		}
+		switch explicit := r.AttrString("module_name"); {
+		case explicit != "":
+			rule.ModuleName, rule.Origin = explicit, OriginExplicit
+		case nameMacros[kind]:
+			rule.ModuleName, rule.Origin = name, OriginMacro
+		default:
+			rule.ModuleName, rule.Origin = DeriveModuleName(f.Package, name), OriginDerived
+		}
+		for _, dep := range r.AttrStrings("deps") {
+			rule.Deps = append(rule.Deps, buildfile.NormaliseLabel(f.Package, dep))
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// sourceDir returns the directory below pkg that a srcs expression draws
+// from, falling back to pkg itself.
+func sourceDir(pkg string, srcs build.Expr) string {
+	var dirs []string
+	collect := func(list build.Expr, glob bool) {
+		l, ok := list.(*build.ListExpr)
+		if !ok {
+			return
+		}
+		for _, e := range l.List {
+			str, ok := e.(*build.StringExpr)
+			if !ok || strings.Contains(str.Value, ":") {
+				continue
+			}
+			if !glob {
+				dirs = append(dirs, path.Dir(str.Value))
+				continue
+			}
+			var fixed []string
+			for _, seg := range strings.Split(path.Dir(str.Value), "/") {
+				if strings.ContainsAny(seg, "*?[") {
+					break
+				}
+				fixed = append(fixed, seg)
+			}
+			dirs = append(dirs, path.Join(fixed...))
+		}
+	}
+	for srcs != nil {
+		switch e := srcs.(type) {
+		case *build.BinaryExpr:
+			// glob([...]) + [...]: the glob names the directory.
+			srcs = e.X
+			continue
+		case *build.CallExpr:
+			if id, ok := e.X.(*build.Ident); ok && id.Name == "glob" && len(e.List) > 0 {
+				collect(e.List[0], true)
+			}
+		default:
+			collect(e, false)
+		}
+		break
+	}
+
+	common := ""
+	for i, d := range dirs {
+		if d == "" {
+			d = "."
+		}
+		if i == 0 {
+			common = d
+			continue
+		}
+		for common != "." && d != common && !strings.HasPrefix(d, common+"/") {
+			common = path.Dir(common)
+		}
+	}
+	if common == "" || common == "." {
+		return pkg
+	}
+	return path.Join(pkg, common)
+}
+
+// DeriveModuleName mirrors rules_swift's default module name: the package
+// and target name joined by "_", with other non-identifier characters
+// replaced by "_".
+func DeriveModuleName(pkg, name string) string {
+	if pkg != "" {
+		name = pkg + "_" + name
+	}
+	return nonIdentifier.ReplaceAllString(name, "_")
+}
+
+// FindCollisions groups rules by module name and returns the names used by
+// more than one rule, sorted by name.
+func FindCollisions(rules []Rule) []Collision {
+	byName := make(map[string][]Rule)
+	for _, r := range rules {
+		byName[r.ModuleName] = append(byName[r.ModuleName], r)
+	}
+	names := make([]string, 0, len(byName))
+	used := make(map[string]bool, len(byName))
+	for name := range byName {
+		names = append(names, name)
+		used[name] = true
+	}
+	// Suggestions avoid the names taken by earlier ones, so collisions
+	// are settled in name order for the same input to give the same names.
+	sort.Strings(names)
+
+	var collisions []Collision
+	for _, name := range names {
+		group := byName[name]
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return keeps(group[i], group[j]) })
+		c := Collision{ModuleName: name, Rules: group, Suggestions: make(map[string]string)}
+		for _, r := range group[1:] {
+			s := suggest(r, used)
+			used[s] = true
+			c.Suggestions[r.Label] = s
+		}
+		collisions = append(collisions, c)
+	}
+	return collisions
+}
+
+// keeps orders the rules of a collision so that the one that should keep
+// the name comes first: a library before a test, then the rule whose
+// package is shallowest, as in Sources/CoreTypes over Sources/Core/Types.
+func keeps(a, b Rule) bool {
+	if at, bt := isTest(a.Kind), isTest(b.Kind); at != bt {
+		return bt
+	}
+	ad, bd := strings.Count(a.Label, "/"), strings.Count(b.Label, "/")
+	if ad != bd {
+		return ad < bd
+	}
+	return a.Label < b.Label
+}
+
+func isTest(kind string) bool {
+	return strings.Contains(kind, "test")
+}
+
+// suggest returns a module name for r that is not in used: the package path
+// below its top-level directory joined with "_" (the existing
+// UmbraCoreTypes_CoreErrors convention), then with the target name added.
+func suggest(r Rule, used map[string]bool) string {
+	pkg, name, _ := strings.Cut(strings.TrimPrefix(r.Label, "//"), ":")
+	segments := strings.Split(pkg, "/")
+	if len(segments) > 1 {
+		segments = segments[1:]
+	}
+	candidates := []string{
+		strings.Join(segments, "_"),
+		strings.Join(append(segments, name), "_"),
+		strings.Join(strings.Split(pkg, "/"), "_") + "_" + name,
+	}
+	for _, c := range candidates {
+		if c = nonIdentifier.ReplaceAllString(c, "_"); c != "" && !used[c] {
+			return c
+		}
+	}
+	base := nonIdentifier.ReplaceAllString(candidates[len(candidates)-1], "_")
+	for n := 2; ; n++ {
+		if c := base + "_" + strconv.Itoa(n); !used[c] {
+			return c
+		}
+	}
+}
+
+// Format renders the collisions as text, one rule per line, with the
+// suggested disambiguation.
+func Format(collisions []Collision) string {
+	var b strings.Builder
+	for _, c := range collisions {
+		fmt.Fprintf(&b, "module_name %q is produced by %d rules:\n", c.ModuleName, len(c.Rules))
+		for i, r := range c.Rules {
+			fmt.Fprintf(&b, "  %s (%s, %s, %s:%d)", r.Label, r.Kind, r.Origin, r.File, r.Line)
+			if i == 0 {
+				b.WriteString(" keeps the name\n")
+				continue
+			}
+			if r.Origin == OriginMacro {
+				// The macro always names the module after the target.
+				fmt.Fprintf(&b, " -> rename the target to %q\n", c.Suggestions[r.Label])
+				continue
+			}
+			fmt.Fprintf(&b, " -> module_name = %q\n", c.Suggestions[r.Label])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,30 @@
+package modulenames
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testfixture"
+)
+
+// Both collisions would rename a rule to Core_Types, so which one gets it
+// depends on the order the collisions are settled in.
+var competingRules = []Rule{
+	{Label: "//Sources/Types:Types", Kind: "swift_library", File: "Sources/Types/BUILD.bazel", Line: 3, ModuleName: "Types", Origin: OriginDerived},
+	{Label: "//Sources/Core/Types:Types", Kind: "swift_library", File: "Sources/Core/Types/BUILD.bazel", Line: 3, ModuleName: "Types", Origin: OriginDerived},
+	{Label: "//Sources/Keys:Keys", Kind: "swift_library", File: "Sources/Keys/BUILD.bazel", Line: 3, ModuleName: "Keys", Origin: OriginExplicit},
+	{Label: "//Sources/Core/Types:KeyTypes", Kind: "swift_library", File: "Sources/Core/Types/BUILD.bazel", Line: 9, ModuleName: "Keys", Origin: OriginExplicit},
+	{Label: "//Sources/Alpha:Alpha", Kind: "swift_library", File: "Sources/Alpha/BUILD.bazel", Line: 3, ModuleName: "Alpha", Origin: OriginExplicit},
+	{Label: "//Sources/Core/Types:AlphaTypes", Kind: "swift_library", File: "Sources/Core/Types/BUILD.bazel", Line: 15, ModuleName: "Alpha", Origin: OriginExplicit},
+}
+
+func TestCollisionsAreReproducible(t *testing.T) {
+	testfixture.Reproducible(t, func() []byte {
+		collisions := FindCollisions(competingRules)
+		data, err := json.Marshal(collisions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append([]byte(Format(collisions)), data...)
+	})
+}
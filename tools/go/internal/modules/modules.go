@@ -0,0 +1,83 @@
+// Package modules discovers the Swift modules in the UmbraCore tree.
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// Module is a top-level module directory.
+type Module struct {
+	// Name is the directory name, which is also the module name for the
+	// primary target.
+	Name string `json:"name"`
+	// Dir is the slash-separated path relative to the workspace root.
+	Dir string `json:"dir"`
+	// Scope is the top-level directory the module lives in.
+	Scope string `json:"scope"`
+	// SwiftFiles counts the Swift sources below Dir.
+	SwiftFiles int `json:"swiftFiles"`
+	// HasBuildFile reports whether Dir itself contains a BUILD file.
+	HasBuildFile bool `json:"hasBuildFile"`
+}
+
+// DefaultScopes are the top-level directories holding modules.
+var DefaultScopes = []string{"Sources"}
+
+// Discover returns the modules directly below each scope directory that
+// contain at least one Swift file, sorted by scope and name.
+func Discover(root string, scopes ...string) ([]Module, error) {
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+
+	var mods []Module
+	for _, scope := range scopes {
+		entries, err := os.ReadDir(filepath.Join(root, scope))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			dir := scope + "/" + e.Name()
+			m := Module{Name: e.Name(), Dir: dir, Scope: scope, HasBuildFile: hasBuildFile(filepath.Join(root, dir))}
+
+			err := walker.Walk(filepath.Join(root, dir), walker.Options{Extensions: []string{".swift"}}, func(string) error {
+				m.SwiftFiles++
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			if m.SwiftFiles > 0 {
+				mods = append(mods, m)
+			}
+		}
+	}
+
+	sort.Slice(mods, func(i, j int) bool {
+		if mods[i].Scope != mods[j].Scope {
+			return mods[i].Scope < mods[j].Scope
+		}
+		return mods[i].Name < mods[j].Name
+	})
+	return mods, nil
+}
+
+func hasBuildFile(dir string) bool {
+	for _, name := range []string{"BUILD.bazel", "BUILD"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
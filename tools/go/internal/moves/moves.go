@@ -0,0 +1,216 @@
+// Package moves moves files for the tools that relocate them, and keeps a
+// record of each move so that a file can be found again. Tracked files are
+// moved with git mv, so that history follows them; every move is appended
+// to moves.log at the project root, one JSON object per line.
+package moves
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+)
+
+// LogFile is the move log, relative to the project root.
+const LogFile = "moves.log"
+
+// Entry is one recorded move. Paths are relative to the project root.
+type Entry struct {
+	Time time.Time `json:"time"`
+	Old  string    `json:"old"`
+	New  string    `json:"new"`
+	// Tool is the command that moved the file, and Run identifies its
+	// invocation: every move one run makes shares it.
+	Tool string `json:"tool"`
+	Run  string `json:"run"`
+	// Git is set when the move was made with git mv.
+	Git bool `json:"git,omitempty"`
+}
+
+// Mover moves files below a project root on behalf of one tool run.
+type Mover struct {
+	root, tool, run string
+	// git is set when root is inside a git work tree.
+	git bool
+}
+
+// NewMover returns a mover for a run of tool, with a fresh run id.
+func NewMover(root, tool string) *Mover {
+	m := &Mover{root: root, tool: tool, run: newRun(time.Now())}
+	if out, err := exec.Command("git", "-C", root, "rev-parse", "--is-inside-work-tree").Output(); err == nil {
+		m.git = strings.TrimSpace(string(out)) == "true"
+	}
+	return m
+}
+
+// newRun returns a run id: the UTC start time and a random suffix, so
+// that runs sort by time and two started together still differ.
+func newRun(now time.Time) string {
+	var b [3]byte
+	rand.Read(b[:])
+	return now.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(b[:])
+}
+
+// Root returns the project root the mover works in.
+func (m *Mover) Root() string {
+	return m.root
+}
+
+// Run returns the id of the run the mover records moves under.
+func (m *Mover) Run() string {
+	return m.run
+}
+
+// Move moves old to new, both relative to the root, creating new's
+// directory, and appends the move to the log. A file git tracks is moved
+// with git mv; anything else is renamed.
+func (m *Mover) Move(old, new string) error {
+	from := filepath.Join(m.root, filepath.FromSlash(old))
+	to := filepath.Join(m.root, filepath.FromSlash(new))
+	if _, err := os.Lstat(to); err == nil {
+		return fmt.Errorf("%s already exists", new)
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+
+	entry := Entry{Time: time.Now().UTC(), Old: path.Clean(filepath.ToSlash(old)), New: path.Clean(filepath.ToSlash(new)), Tool: m.tool, Run: m.run}
+	if m.git && exec.Command("git", "-C", m.root, "ls-files", "--error-unmatch", "--", entry.Old).Run() == nil {
+		var stderr bytes.Buffer
+		cmd := exec.Command("git", "-C", m.root, "mv", "--", entry.Old, entry.New)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("git mv %s %s: %v: %s", entry.Old, entry.New, err, strings.TrimSpace(stderr.String()))
+		}
+		entry.Git = true
+	} else if err := os.Rename(from, to); err != nil {
+		return err
+	}
+	return m.record(entry)
+}
+
+// record appends entry to the log.
+func (m *Mover) record(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(m.root, LogFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads the move log below root, oldest move first. A missing log
+// has no moves.
+func Load(root string) ([]Entry, error) {
+	f, err := os.Open(filepath.Join(root, LogFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := textscan.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", LogFile, lineNo, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, textscan.Check(LogFile, lineNo, scanner.Err())
+}
+
+// GitRenames returns the renames git finds in the history of the
+// repository containing root, oldest first, as entries of tool "git" whose
+// run is the commit. Paths are relative to root; renames outside it are
+// left out. Outside a git work tree there are none.
+func GitRenames(root string) ([]Entry, error) {
+	if out, err := exec.Command("git", "-C", root, "rev-parse", "--is-inside-work-tree").Output(); err != nil || strings.TrimSpace(string(out)) != "true" {
+		return nil, nil
+	}
+	out, err := exec.Command("git", "-C", root, "log", "--reverse", "-M", "--diff-filter=R", "--name-status", "--relative", "--format=commit %H %cI").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	var entries []Entry
+	var commit string
+	var when time.Time
+	for _, line := range strings.Split(string(out), "\n") {
+		if rest, ok := strings.CutPrefix(line, "commit "); ok {
+			sha, date, _ := strings.Cut(rest, " ")
+			commit = sha[:min(len(sha), 12)]
+			when, _ = time.Parse(time.RFC3339, date)
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) == 3 && strings.HasPrefix(fields[0], "R") {
+			entries = append(entries, Entry{Time: when.UTC(), Old: fields[1], New: fields[2], Tool: "git", Run: commit, Git: true})
+		}
+	}
+	return entries, nil
+}
+
+// Trace follows p through entries, in their order, and returns the moves
+// that took it to where it is now. A move of a directory containing p
+// counts as a move of p.
+func Trace(entries []Entry, p string) []Entry {
+	current := path.Clean(filepath.ToSlash(p))
+	var hops []Entry
+	for _, e := range entries {
+		hop := e
+		hop.Old = current
+		switch {
+		case e.Old == current:
+			current = e.New
+		case strings.HasPrefix(current, e.Old+"/"):
+			current = e.New + strings.TrimPrefix(current, e.Old)
+		default:
+			continue
+		}
+		hop.New = current
+		hops = append(hops, hop)
+	}
+	return hops
+}
+
+// Origin follows p back through entries, newest first, and returns the
+// path it had before any of them moved it. A move of a directory
+// containing p counts as a move of p.
+func Origin(entries []Entry, p string) string {
+	current := path.Clean(filepath.ToSlash(p))
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		switch {
+		case e.New == current:
+			current = e.Old
+		case strings.HasPrefix(current, e.New+"/"):
+			current = e.Old + strings.TrimPrefix(current, e.New)
+		}
+	}
+	return current
+}
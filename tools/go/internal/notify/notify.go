@@ -0,0 +1,344 @@
+// Package notify summarises a scheduled analysis run from the result store
+// and posts the summary to a Slack-compatible incoming webhook: health
+// score changes, new dependency cycles and refactoring plan progress.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/health"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/store"
+)
+
+// Thresholds decide whether a summary is worth sending. A zero threshold
+// never fires; failed tasks always do.
+type Thresholds struct {
+	// ScoreDelta is the smallest health score change, in points, that
+	// fires.
+	ScoreDelta float64
+	// Cycles is how many new dependency cycles fire.
+	Cycles int
+	// DoneItems is how many newly done refactoring plan items fire.
+	DoneItems int
+}
+
+// ScoreChange is a module whose health score changed.
+type ScoreChange struct {
+	Module string  `json:"module"`
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+}
+
+// Delta is After minus Before.
+func (c ScoreChange) Delta() float64 { return c.After - c.Before }
+
+// Migration is the refactoring plan's progress between two runs.
+type Migration struct {
+	Items int `json:"items"`
+	Done  int `json:"done"`
+	// NewlyDone are the items done now but not before.
+	NewlyDone       []string `json:"newlyDone"`
+	ImportingFiles  int      `json:"importingFiles"`
+	ImportingBefore int      `json:"importingBefore"`
+}
+
+// Summary is what a scheduled run changed.
+type Summary struct {
+	Commit string `json:"commit"`
+	// Failed are the tasks that failed.
+	Failed []string `json:"failed,omitempty"`
+	// Scores are the modules whose health score changed, largest change
+	// first.
+	Scores         []ScoreChange `json:"scores"`
+	NewCycles      []string      `json:"newCycles"`
+	ResolvedCycles []string      `json:"resolvedCycles"`
+	// Migration is nil when refactor-progress was not recorded.
+	Migration *Migration `json:"migration,omitempty"`
+}
+
+// Build compares, for health and refactor-progress, the newest run
+// recorded since since with the run before it. Tools not recorded since
+// then are left out, so a summary never repeats an earlier night's news.
+func Build(ctx context.Context, db *store.DB, since time.Time) (*Summary, error) {
+	s := &Summary{Scores: []ScoreChange{}, NewCycles: []string{}, ResolvedCycles: []string{}}
+
+	latest, previous, err := runs(ctx, db, "health", since)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil {
+		s.Commit = latest.GitSHA
+		after, err := db.MetricValues(ctx, latest.ID, "health_score", "")
+		if err != nil {
+			return nil, err
+		}
+		cycles, err := cycleMessages(ctx, db, latest)
+		if err != nil {
+			return nil, err
+		}
+		if previous != nil {
+			before, err := db.MetricValues(ctx, previous.ID, "health_score", "")
+			if err != nil {
+				return nil, err
+			}
+			for module, v := range after {
+				if b, ok := before[module]; ok && b != v {
+					s.Scores = append(s.Scores, ScoreChange{Module: module, Before: b, After: v})
+				}
+			}
+			sort.Slice(s.Scores, func(i, j int) bool {
+				a, b := math.Abs(s.Scores[i].Delta()), math.Abs(s.Scores[j].Delta())
+				if a != b {
+					return a > b
+				}
+				return s.Scores[i].Module < s.Scores[j].Module
+			})
+
+			old, err := cycleMessages(ctx, db, previous)
+			if err != nil {
+				return nil, err
+			}
+			s.NewCycles = subtract(cycles, old)
+			s.ResolvedCycles = subtract(old, cycles)
+		}
+	}
+
+	latest, previous, err = runs(ctx, db, "refactor-progress", since)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil {
+		if s.Commit == "" {
+			s.Commit = latest.GitSHA
+		}
+		if s.Migration, err = migration(ctx, db, latest, previous); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// runs returns tool's newest run, if recorded since since, and the one
+// before it.
+func runs(ctx context.Context, db *store.DB, tool string, since time.Time) (latest, previous *store.Run, err error) {
+	list, err := db.Runs(ctx, tool, 2)
+	if err != nil || len(list) == 0 || list[0].Timestamp.Before(since) {
+		return nil, nil, err
+	}
+	if len(list) > 1 {
+		previous = &list[1]
+	}
+	return &list[0], previous, nil
+}
+
+func cycleMessages(ctx context.Context, db *store.DB, run *store.Run) ([]string, error) {
+	issues, err := db.RunIssues(ctx, run.ID, health.IssueCycle)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(issues))
+	for _, i := range issues {
+		out = append(out, i.Message)
+	}
+	return out, nil
+}
+
+func migration(ctx context.Context, db *store.DB, latest, previous *store.Run) (*Migration, error) {
+	items, err := db.MetricValues(ctx, latest.ID, "refactor_item_state", "")
+	if err != nil {
+		return nil, err
+	}
+	done, err := db.MetricValues(ctx, latest.ID, "refactor_item_state", `state="done"`)
+	if err != nil {
+		return nil, err
+	}
+	importing, err := db.MetricValues(ctx, latest.ID, "refactor_importing_files", "")
+	if err != nil {
+		return nil, err
+	}
+	m := &Migration{Items: len(items), NewlyDone: []string{}}
+	for _, v := range done {
+		if v > 0 {
+			m.Done++
+		}
+	}
+	m.ImportingFiles = int(sum(importing))
+	if previous == nil {
+		m.ImportingBefore = m.ImportingFiles
+		return m, nil
+	}
+
+	wasDone, err := db.MetricValues(ctx, previous.ID, "refactor_item_state", `state="done"`)
+	if err != nil {
+		return nil, err
+	}
+	before, err := db.MetricValues(ctx, previous.ID, "refactor_importing_files", "")
+	if err != nil {
+		return nil, err
+	}
+	for item, v := range done {
+		if v > 0 && wasDone[item] == 0 {
+			m.NewlyDone = append(m.NewlyDone, item)
+		}
+	}
+	sort.Strings(m.NewlyDone)
+	m.ImportingBefore = int(sum(before))
+	return m, nil
+}
+
+func sum(values map[string]float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// subtract returns the entries of a that b does not have.
+func subtract(a, b []string) []string {
+	have := make(map[string]bool, len(b))
+	for _, s := range b {
+		have[s] = true
+	}
+	out := []string{}
+	for _, s := range a {
+		if !have[s] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Reasons returns why the summary crosses t, or nothing when it should not
+// be sent.
+func (s *Summary) Reasons(t Thresholds) []string {
+	var reasons []string
+	if len(s.Failed) > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d failed tasks", len(s.Failed)))
+	}
+	if t.ScoreDelta > 0 {
+		n := 0
+		for _, c := range s.Scores {
+			if math.Abs(c.Delta()) >= t.ScoreDelta {
+				n++
+			}
+		}
+		if n > 0 {
+			reasons = append(reasons, fmt.Sprintf("%d health scores moved by %g points or more", n, t.ScoreDelta))
+		}
+	}
+	if t.Cycles > 0 && len(s.NewCycles) >= t.Cycles {
+		reasons = append(reasons, fmt.Sprintf("%d new dependency cycles", len(s.NewCycles)))
+	}
+	if t.DoneItems > 0 && s.Migration != nil && len(s.Migration.NewlyDone) >= t.DoneItems {
+		reasons = append(reasons, fmt.Sprintf("%d plan items done", len(s.Migration.NewlyDone)))
+	}
+	return reasons
+}
+
+// maxScores is how many score changes a message lists.
+const maxScores = 10
+
+// Payload renders the summary as a Slack message: a plain text fallback
+// and mrkdwn blocks, which other Slack-compatible webhooks also accept.
+func (s *Summary) Payload(title string, reasons []string) map[string]any {
+	text := fmt.Sprintf("%s: %s", title, strings.Join(reasons, ", "))
+	if s.Commit != "" {
+		text = fmt.Sprintf("%s at %s: %s", title, short(s.Commit), strings.Join(reasons, ", "))
+	}
+
+	var sections []string
+	if len(s.Failed) > 0 {
+		sections = append(sections, "*Failed tasks:* "+strings.Join(s.Failed, ", "))
+	}
+	if len(s.Scores) > 0 {
+		var b strings.Builder
+		b.WriteString("*Health scores*")
+		for _, c := range s.Scores[:min(maxScores, len(s.Scores))] {
+			fmt.Fprintf(&b, "\n• %s: %.1f → %.1f (%+.1f)", c.Module, c.Before, c.After, c.Delta())
+		}
+		if len(s.Scores) > maxScores {
+			fmt.Fprintf(&b, "\n_%d smaller changes_", len(s.Scores)-maxScores)
+		}
+		sections = append(sections, b.String())
+	}
+	if len(s.NewCycles) > 0 || len(s.ResolvedCycles) > 0 {
+		var b strings.Builder
+		b.WriteString("*Dependency cycles*")
+		for _, c := range s.NewCycles {
+			fmt.Fprintf(&b, "\n• new: `%s`", c)
+		}
+		for _, c := range s.ResolvedCycles {
+			fmt.Fprintf(&b, "\n• resolved: `%s`", c)
+		}
+		sections = append(sections, b.String())
+	}
+	if m := s.Migration; m != nil {
+		line := fmt.Sprintf("*Migration:* %d of %d plan items done, %d files still import retiring modules (%+d)",
+			m.Done, m.Items, m.ImportingFiles, m.ImportingFiles-m.ImportingBefore)
+		if len(m.NewlyDone) > 0 {
+			line += "\n• done since the last run: " + strings.Join(m.NewlyDone, ", ")
+		}
+		sections = append(sections, line)
+	}
+
+	blocks := []map[string]any{{
+		"type": "header",
+		"text": map[string]any{"type": "plain_text", "text": title},
+	}}
+	for _, section := range sections {
+		blocks = append(blocks, map[string]any{
+			"type": "section",
+			"text": map[string]any{"type": "mrkdwn", "text": section},
+		})
+	}
+	return map[string]any{"text": text, "blocks": blocks}
+}
+
+func short(commit string) string {
+	sha, dirty := strings.CutSuffix(commit, "-dirty")
+	sha = sha[:min(12, len(sha))]
+	if dirty {
+		sha += "-dirty"
+	}
+	return sha
+}
+
+// Send posts payload as JSON to the webhook URL.
+func Send(ctx context.Context, webhook string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		// The URL is the webhook's credential; keep it out of the error.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			return fmt.Errorf("webhook: %w", uerr.Err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("webhook: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package objcbridge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/protocols"
+)
+
+// Issue kinds.
+const (
+	// IssueNoSwiftEquivalent is an Objective-C protocol no Swift protocol
+	// corresponds to.
+	IssueNoSwiftEquivalent = "no_swift_equivalent"
+	// IssueNotObjC is a Swift counterpart that is not marked @objc, so it
+	// cannot be used for an NSXPCInterface.
+	IssueNotObjC = "not_objc"
+	// IssueSelectorMismatch is a method whose Swift counterpart exposes a
+	// different selector.
+	IssueSelectorMismatch = "selector_mismatch"
+	// IssueSelectorMissing is a method the Swift counterpart lacks.
+	IssueSelectorMissing = "selector_missing"
+)
+
+// Issue is one bridging problem.
+type Issue struct {
+	Kind     string `json:"kind"`
+	Protocol string `json:"protocol"`
+	Module   string `json:"module"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Selector string `json:"selector,omitempty"`
+	// Swift is the Swift protocol, or the member, the issue refers to.
+	Swift       string `json:"swift,omitempty"`
+	Message     string `json:"message"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Mapping pairs an Objective-C protocol with its Swift counterpart.
+type Mapping struct {
+	Protocol
+	// Swift is the counterpart's name; empty when there is none.
+	Swift       string `json:"swift,omitempty"`
+	SwiftModule string `json:"swiftModule,omitempty"`
+	SwiftFile   string `json:"swiftFile,omitempty"`
+	SwiftLine   int    `json:"swiftLine,omitempty"`
+	// Matched counts the methods whose selector the counterpart exposes.
+	Matched int `json:"matched"`
+}
+
+// Report is the result of Compare.
+type Report struct {
+	Mappings []Mapping `json:"mappings"`
+	Issues   []Issue   `json:"issues"`
+}
+
+// Compare finds the Swift counterpart of every Objective-C protocol and
+// checks the selectors of its methods. The counterpart is the Swift
+// protocol declared with @objc(Name), else the one named by the
+// protocol's NS_SWIFT_NAME, else the Swift protocol of the same name.
+func Compare(objc []Protocol, ix *protocols.Index) Report {
+	byObjCName := make(map[string]*protocols.Decl)
+	byName := make(map[string]*protocols.Decl)
+	for _, d := range ix.Decls {
+		if d.Kind != protocols.KindProtocol {
+			continue
+		}
+		if d.ObjCName != "" && byObjCName[d.ObjCName] == nil {
+			byObjCName[d.ObjCName] = d
+		}
+		if byName[d.Name] == nil {
+			byName[d.Name] = d
+		}
+	}
+
+	report := Report{Mappings: []Mapping{}, Issues: []Issue{}}
+	for _, proto := range objc {
+		m := Mapping{Protocol: proto}
+		swift := byObjCName[proto.Name]
+		if swift == nil && proto.SwiftName != "" {
+			swift = byName[proto.SwiftName]
+		}
+		if swift == nil {
+			swift = byName[proto.Name]
+		}
+		if swift == nil {
+			report.Issues = append(report.Issues, Issue{Kind: IssueNoSwiftEquivalent, Protocol: proto.Name, Module: proto.Module, File: proto.File, Line: proto.Line,
+				Message: fmt.Sprintf("%s has no Swift protocol counterpart", proto.Name)})
+			report.Mappings = append(report.Mappings, m)
+			continue
+		}
+		m.Swift, m.SwiftModule, m.SwiftFile, m.SwiftLine = swift.Name, swift.Module, swift.File, swift.Line
+		if !swift.ObjC {
+			report.Issues = append(report.Issues, Issue{Kind: IssueNotObjC, Protocol: proto.Name, Module: swift.Module, File: swift.File, Line: swift.Line, Swift: swift.Name,
+				Message: fmt.Sprintf("%s, the Swift counterpart of %s, is not marked @objc", swift.Name, proto.Name)})
+		}
+
+		exposed := make(map[string]bool)
+		for _, member := range swift.Members {
+			if sel := Selector(member); sel != "" {
+				exposed[sel] = true
+			}
+		}
+		for _, method := range proto.Methods {
+			if exposed[method.Selector] {
+				m.Matched++
+				continue
+			}
+			issue := Issue{Protocol: proto.Name, Module: proto.Module, File: proto.File, Line: method.Line, Selector: method.Selector}
+			optional := ""
+			if method.Optional {
+				optional = "optional "
+			}
+			if member := counterpart(method, swift.Members); member != nil {
+				issue.Kind, issue.Swift = IssueSelectorMismatch, member.Signature
+				issue.Message = fmt.Sprintf("%s%s of %s is %s in %s, which exposes %s; add @objc(%s)",
+					optional, method.Selector, proto.Name, member.Signature, swift.Name, Selector(*member), method.Selector)
+			} else {
+				issue.Kind, issue.Swift = IssueSelectorMissing, swift.Name
+				issue.Message = fmt.Sprintf("%s%s of %s has no counterpart in %s", optional, method.Selector, proto.Name, swift.Name)
+			}
+			report.Issues = append(report.Issues, issue)
+		}
+		report.Mappings = append(report.Mappings, m)
+	}
+
+	sort.SliceStable(report.Mappings, func(i, j int) bool {
+		a, b := report.Mappings[i], report.Mappings[j]
+		if a.Module != b.Module {
+			return a.Module < b.Module
+		}
+		return a.Name < b.Name
+	})
+	return report
+}
+
+// counterpart returns the Swift member method most likely corresponds to:
+// the one matching its NS_SWIFT_NAME, else the one with the longest name
+// that starts its selector.
+func counterpart(method Method, members []protocols.Member) *protocols.Member {
+	var best *protocols.Member
+	first, _, _ := strings.Cut(method.Selector, ":")
+	for i := range members {
+		member := &members[i]
+		if Selector(*member) == "" {
+			continue
+		}
+		if method.SwiftName != "" && (member.Signature == method.SwiftName || member.Name == method.SwiftName) {
+			return member
+		}
+		if strings.HasPrefix(first, member.Name) && (best == nil || len(member.Name) > len(best.Name)) {
+			best = member
+		}
+	}
+	return best
+}
+
+// prepositions start or end a selector piece without an added "With",
+// as in Swift's own selector inference.
+var prepositions = map[string]bool{
+	"above": true, "after": true, "along": true, "alongside": true, "as": true, "at": true,
+	"before": true, "below": true, "by": true, "following": true, "for": true, "from": true,
+	"given": true, "in": true, "including": true, "inside": true, "into": true, "like": true,
+	"of": true, "on": true, "onto": true, "over": true, "since": true, "through": true,
+	"to": true, "toward": true, "towards": true, "under": true, "until": true, "upon": true,
+	"via": true, "with": true, "within": true, "without": true,
+}
+
+// Selector returns the Objective-C selector a Swift member exposes: its
+// @objc(name) if given, and otherwise the one Swift infers, so that
+// "fetch(id:reply:)" gives "fetchWithId:reply:" and "send(_:to:)" gives
+// "send:to:". Members other than functions, initialisers and properties
+// have no selector.
+func Selector(m protocols.Member) string {
+	if m.ObjCName != "" {
+		return m.ObjCName
+	}
+	switch m.Kind {
+	case protocols.MemberVar:
+		return m.Name
+	case protocols.MemberFunc, protocols.MemberInit:
+	default:
+		return ""
+	}
+
+	_, params, _ := strings.Cut(m.Signature, "(")
+	params = strings.TrimSuffix(params, ")")
+	if params == "" {
+		return m.Name
+	}
+	labels := strings.Split(strings.TrimSuffix(params, ":"), ":")
+
+	var b strings.Builder
+	b.WriteString(m.Name)
+	if first := labels[0]; first != "_" {
+		if !prepositions[firstWord(first)] && !prepositions[strings.ToLower(lastWord(m.Name))] {
+			b.WriteString("With")
+		}
+		r, size := utf8.DecodeRuneInString(first)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(first[size:])
+	}
+	b.WriteString(":")
+	for _, label := range labels[1:] {
+		if label != "_" {
+			b.WriteString(label)
+		}
+		b.WriteString(":")
+	}
+	return b.String()
+}
+
+// firstWord returns the leading lower-case word of a camel-case name.
+func firstWord(s string) string {
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// lastWord returns the trailing word of a camel-case name.
+func lastWord(s string) string {
+	for i := len(s) - 1; i > 0; i-- {
+		if unicode.IsUpper(rune(s[i])) {
+			return s[i:]
+		}
+	}
+	return s
+}
@@ -0,0 +1,278 @@
+// Package objcbridge reads the Objective-C @protocol declarations in the
+// headers and implementation files of the tree, matches them with their
+// Swift counterparts and checks that both expose the same selectors, as
+// XPC connections between Swift and Objective-C code require.
+package objcbridge
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Extensions are the Objective-C files scanned for protocols.
+var Extensions = []string{".h", ".m", ".mm"}
+
+// Method is an Objective-C protocol method or property.
+type Method struct {
+	// Selector is e.g. "fetchWithID:reply:"; for a property it is the
+	// getter.
+	Selector string `json:"selector"`
+	Static   bool   `json:"static,omitempty"`
+	Property bool   `json:"property,omitempty"`
+	// Optional marks methods in an @optional section.
+	Optional bool `json:"optional,omitempty"`
+	// SwiftName is the NS_SWIFT_NAME given to the method, if any.
+	SwiftName string `json:"swiftName,omitempty"`
+	Line      int    `json:"line"`
+}
+
+// Protocol is an Objective-C @protocol declaration.
+type Protocol struct {
+	Name     string   `json:"name"`
+	Module   string   `json:"module"`
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Inherits []string `json:"inherits,omitempty"`
+	// SwiftName is the NS_SWIFT_NAME given to the protocol, if any.
+	SwiftName string   `json:"swiftName,omitempty"`
+	Methods   []Method `json:"methods"`
+}
+
+var (
+	protocolPattern  = regexp.MustCompile(`^@protocol\s+([A-Za-z_]\w*)\s*(?:<([^>]*)>)?(.*)$`)
+	interfacePattern = regexp.MustCompile(`^@(?:interface|implementation)\b`)
+	swiftNamePattern = regexp.MustCompile(`NS_SWIFT_NAME\s*\(\s*([^)]*?\)?)\s*\)`)
+	getterPattern    = regexp.MustCompile(`\bgetter\s*=\s*([A-Za-z_]\w*)`)
+	selectorPiece    = regexp.MustCompile(`([A-Za-z_]\w*)\s*:`)
+	identPattern     = regexp.MustCompile(`[A-Za-z_]\w*`)
+	macroPattern     = regexp.MustCompile(`\b[A-Z][A-Z0-9_]*[A-Z0-9]\b`)
+)
+
+// Scan parses the Objective-C files below the given top-level directories
+// of root (default "Sources").
+func Scan(root string, dirs ...string) ([]Protocol, error) {
+	if len(dirs) == 0 {
+		dirs = []string{"Sources"}
+	}
+
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: Extensions}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	results, err := pool.Map(paths, func(rel string) ([]Protocol, error) {
+		f, err := os.Open(filepath.Join(root, rel))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return Parse(f, rel, workspace.ModuleForPath(rel))
+	})
+	if err != nil {
+		return nil, err
+	}
+	var protocols []Protocol
+	for _, r := range results {
+		protocols = append(protocols, r...)
+	}
+	return protocols, nil
+}
+
+// Parse reads the protocol declarations of one Objective-C file. Forward
+// declarations and the methods of @interface and @implementation blocks
+// are skipped.
+func Parse(r io.Reader, rel, module string) ([]Protocol, error) {
+	p := &headerParser{file: rel, module: module}
+	scanner := textscan.NewScanner(r)
+	for scanner.Scan() {
+		p.line(scanner.Text())
+	}
+	return p.protocols, textscan.Check(rel, p.lineNo, scanner.Err())
+}
+
+type headerParser struct {
+	file      string
+	module    string
+	protocols []Protocol
+
+	lineNo    int
+	inComment bool
+	// current is the protocol being read, up to its @end.
+	current *Protocol
+	// inInterface is set between @interface or @implementation and @end.
+	inInterface bool
+	optional    bool
+	// pendingSwiftName is an NS_SWIFT_NAME on the line before a protocol.
+	pendingSwiftName string
+	// pending accumulates a method declaration spanning several lines.
+	pending     string
+	pendingLine int
+}
+
+func (p *headerParser) line(raw string) {
+	p.lineNo++
+	var code string
+	code, p.inComment = swiftsrc.StripComments(raw, p.inComment)
+	code = strings.TrimSpace(code)
+	if code == "" || strings.HasPrefix(code, "#") {
+		return
+	}
+
+	if p.pending != "" {
+		p.pending += " " + code
+		if strings.Contains(code, ";") {
+			p.method(p.pending, p.pendingLine)
+			p.pending = ""
+		}
+		return
+	}
+
+	switch {
+	case code == "@end" || strings.HasPrefix(code, "@end "):
+		if p.current != nil {
+			p.protocols = append(p.protocols, *p.current)
+		}
+		p.current, p.inInterface, p.optional = nil, false, false
+	case p.inInterface:
+	case interfacePattern.MatchString(code):
+		p.inInterface = true
+	case p.current == nil:
+		p.declaration(code)
+	case code == "@optional":
+		p.optional = true
+	case code == "@required":
+		p.optional = false
+	case strings.HasPrefix(code, "-"), strings.HasPrefix(code, "+"), strings.HasPrefix(code, "@property"):
+		if strings.Contains(code, ";") {
+			p.method(code, p.lineNo)
+		} else {
+			p.pending, p.pendingLine = code, p.lineNo
+		}
+	}
+}
+
+// declaration handles a line outside any protocol, which may start one.
+func (p *headerParser) declaration(code string) {
+	m := protocolPattern.FindStringSubmatch(code)
+	if m == nil {
+		if s := swiftNamePattern.FindStringSubmatch(code); s != nil && strings.HasPrefix(code, "NS_SWIFT_NAME") {
+			p.pendingSwiftName = s[1]
+		} else {
+			p.pendingSwiftName = ""
+		}
+		return
+	}
+	swiftName := p.pendingSwiftName
+	p.pendingSwiftName = ""
+	rest := strings.TrimSpace(m[3])
+	if strings.HasPrefix(rest, ";") || strings.HasPrefix(rest, ",") {
+		// A forward declaration: @protocol Foo; or @protocol Foo, Bar;
+		return
+	}
+	if s := swiftNamePattern.FindStringSubmatch(rest); s != nil {
+		swiftName = s[1]
+	}
+	p.current = &Protocol{Name: m[1], Module: p.module, File: p.file, Line: p.lineNo, SwiftName: swiftName, Methods: []Method{}}
+	for _, name := range strings.Split(m[2], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			p.current.Inherits = append(p.current.Inherits, name)
+		}
+	}
+	p.optional = false
+}
+
+// method records the method or property declared by decl, which ends in
+// ";".
+func (p *headerParser) method(decl string, line int) {
+	decl, _, _ = strings.Cut(decl, ";")
+	m := Method{Optional: p.optional, Line: line}
+	if s := swiftNamePattern.FindStringSubmatch(decl); s != nil {
+		m.SwiftName = s[1]
+	}
+
+	if rest, ok := strings.CutPrefix(decl, "@property"); ok {
+		m.Property = true
+		attrs := ""
+		if strings.HasPrefix(strings.TrimSpace(rest), "(") {
+			attrs = rest[:strings.Index(rest, ")")+1]
+			rest = rest[len(attrs):]
+		}
+		m.Static = strings.Contains(attrs, "class")
+		if g := getterPattern.FindStringSubmatch(attrs); g != nil {
+			m.Selector = g[1]
+		} else {
+			idents := identPattern.FindAllString(macroPattern.ReplaceAllString(stripGroups(rest), ""), -1)
+			if len(idents) == 0 {
+				return
+			}
+			m.Selector = idents[len(idents)-1]
+		}
+	} else {
+		m.Static = strings.HasPrefix(decl, "+")
+		m.Selector = selector(decl[1:])
+		if m.Selector == "" {
+			return
+		}
+	}
+	p.current.Methods = append(p.current.Methods, m)
+}
+
+// selector returns the selector of a method declaration without its
+// leading "-" or "+": the return and parameter types, and the arguments
+// of macros such as NS_SWIFT_NAME, are dropped before collecting the
+// "piece:" parts.
+func selector(decl string) string {
+	bare := stripGroups(decl)
+	pieces := selectorPiece.FindAllStringSubmatch(bare, -1)
+	if len(pieces) == 0 {
+		return identPattern.FindString(bare)
+	}
+	var b strings.Builder
+	for _, piece := range pieces {
+		b.WriteString(piece[1])
+		b.WriteString(":")
+	}
+	return b.String()
+}
+
+// stripGroups removes every parenthesised group, nested ones included,
+// from s.
+func stripGroups(s string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')':
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
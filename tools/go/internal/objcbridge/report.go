@@ -0,0 +1,59 @@
+package objcbridge
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+var issueHeadings = []struct{ kind, heading, intro string }{
+	{IssueNoSwiftEquivalent, "Without Swift Equivalent", "No Swift protocol corresponds to these Objective-C protocols."},
+	{IssueNotObjC, "Swift Counterpart Not @objc", "These Swift protocols cannot back an NSXPCInterface until they are marked @objc."},
+	{IssueSelectorMismatch, "Selector Mismatches", "The Swift counterpart declares these methods under a different selector."},
+	{IssueSelectorMissing, "Missing Methods", "The Swift counterpart has no member for these methods."},
+}
+
+// WriteMarkdown writes the issues grouped by kind, then every Objective-C
+// protocol with its counterpart.
+func WriteMarkdown(w io.Writer, r Report) error {
+	methods, matched := 0, 0
+	for _, m := range r.Mappings {
+		methods += len(m.Methods)
+		matched += m.Matched
+	}
+
+	var b strings.Builder
+	b.WriteString("# Objective-C Bridging Report\n\n")
+	fmt.Fprintf(&b, "**%d Objective-C protocols, %d of %d methods matched, %d issues**\n", len(r.Mappings), matched, methods, len(r.Issues))
+	if len(r.Mappings) == 0 {
+		b.WriteString("\nNo Objective-C protocols found.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	for _, h := range issueHeadings {
+		var lines []string
+		for _, i := range r.Issues {
+			if i.Kind == h.kind {
+				lines = append(lines, fmt.Sprintf("- %s (%s:%d)\n", i.Message, i.File, i.Line))
+			}
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(&b, "\n## %s\n\n%s\n\n%s", h.heading, h.intro, strings.Join(lines, ""))
+		}
+	}
+
+	b.WriteString("\n## All Protocols\n\n")
+	b.WriteString("| Module | Objective-C | Swift | Methods | Matched |\n")
+	b.WriteString("|--------|-------------|-------|---------|---------|\n")
+	for _, m := range r.Mappings {
+		swift := "—"
+		if m.Swift != "" {
+			swift = fmt.Sprintf("%s (%s)", m.Swift, m.SwiftModule)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %d | %d |\n", m.Module, m.Name, swift, len(m.Methods), m.Matched)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,286 @@
+// Package objcsurface reports the Objective-C surface of the modules built
+// with library evolution: their @objc, @objcMembers and dynamic
+// declarations and the classes inheriting from NSObject. Each ties a
+// declaration to the Objective-C runtime, which limits how a resilient
+// module can change it, so the surface should shrink before library
+// evolution is relied upon.
+package objcsurface
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Finding kinds.
+const (
+	KindObjC        = "objc"
+	KindObjCMembers = "objcMembers"
+	KindDynamic     = "dynamic"
+	KindNSObject    = "nsobject"
+)
+
+// Kinds lists the finding kinds in report order.
+var Kinds = []string{KindObjC, KindObjCMembers, KindDynamic, KindNSObject}
+
+// evolutionFlag is the compiler option enabling library evolution.
+const evolutionFlag = "-enable-library-evolution"
+
+var (
+	objcAttr    = regexp.MustCompile(`@objc\b(?:\s*\([^)]*\))?`)
+	membersAttr = regexp.MustCompile(`@objcMembers\b`)
+	dynamicMod  = regexp.MustCompile(`(?:^|[\s)])dynamic\s+(?:(?:public|open|internal|package|fileprivate|private|final|static|class|override|@\w+)\s+)*(?:var|func|subscript|init)\b`)
+	declPattern = regexp.MustCompile(`\b(class|struct|enum|protocol|extension|func|var|let|init|subscript)\b\s*([A-Za-z_][\w.]*)?`)
+	classDecl   = regexp.MustCompile(`\bclass\s+([A-Za-z_]\w*)(?:<[^>]*>)?\s*:\s*([A-Za-z_][\w.]*)`)
+	attrsOnly   = regexp.MustCompile(`^\s*(?:@\w+(?:\([^)]*\))?\s*)+$`)
+)
+
+// Finding is one declaration tied to the Objective-C runtime.
+type Finding struct {
+	Module string `json:"module"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Kind   string `json:"kind"`
+	// Name is the declaration's name, or the keyword of an unnamed
+	// declaration such as init.
+	Name string `json:"name"`
+	// Super is the superclass of an NSObject subclass.
+	Super       string `json:"super,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Options configures a scan.
+type Options struct {
+	// Dirs are the top-level directories scanned (default "Sources").
+	Dirs []string
+	// Rules name the module of each file; files outside every rule fall
+	// back to their top-level directory.
+	Rules []modulenames.Rule
+	// Modules, when not nil, are the modules reported.
+	Modules map[string]bool
+}
+
+type class struct {
+	module, file, name, super string
+	line                      int
+}
+
+type file struct {
+	findings []Finding
+	classes  []class
+}
+
+// Evolving returns the modules of the Swift libraries below root built
+// with library evolution: umbra_swift_library targets, which enable it
+// unless enable_library_evolution = False, and libraries passing
+// -enable-library-evolution in their compiler options. Test targets are
+// left out.
+func Evolving(root string) (map[string]bool, error) {
+	files, err := buildfile.FindAll(root)
+	if err != nil {
+		return nil, err
+	}
+	modules := make(map[string]bool)
+	for _, rel := range files {
+		f, err := buildfile.Load(filepath.Join(root, rel), buildfile.PackageOf(rel))
+		if err != nil {
+			continue
+		}
+		rules := modulenames.FileRules(f, rel)
+		for _, r := range f.Rules("") {
+			kind := r.Kind()
+			if strings.Contains(kind, "test") || f.TestOnly(r.Name()) {
+				continue
+			}
+			evolving := kind == "umbra_swift_library"
+			if id := r.AttrLiteral("enable_library_evolution"); id != "" {
+				evolving = id == "True"
+			}
+			if slices.Contains(r.AttrStrings("copts"), evolutionFlag) || slices.Contains(r.AttrStrings("additional_copts"), evolutionFlag) {
+				evolving = true
+			}
+			if !evolving {
+				continue
+			}
+			for _, m := range rules {
+				if m.Label == "//"+f.Package+":"+r.Name() {
+					modules[m.ModuleName] = true
+				}
+			}
+		}
+	}
+	return modules, nil
+}
+
+// Scan returns the Objective-C surface of the modules below opts.Dirs,
+// sorted by module, file and line. A class inherits from NSObject when
+// its superclass is NSObject or a class of the scanned sources that
+// does.
+func Scan(root string, opts Options) ([]Finding, error) {
+	dirs := opts.Dirs
+	if len(dirs) == 0 {
+		dirs = []string{"Sources"}
+	}
+	index := &moduleindex.Index{Modules: opts.Rules}
+
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	files, err := pool.Map(paths, func(rel string) (*file, error) {
+		module := workspace.ModuleForPath(rel)
+		if r, ok := index.ForPath(rel); ok {
+			module = r.ModuleName
+		}
+		return readFile(root, rel, module)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	supers := make(map[string]string)
+	var findings []Finding
+	for _, f := range files {
+		for _, c := range f.classes {
+			supers[c.name] = c.super
+		}
+		findings = append(findings, f.findings...)
+	}
+	for _, f := range files {
+		for _, c := range f.classes {
+			if inheritsNSObject(c.name, supers) {
+				findings = append(findings, Finding{Module: c.module, File: c.file, Line: c.line, Kind: KindNSObject, Name: c.name, Super: c.super})
+			}
+		}
+	}
+
+	out := findings[:0]
+	for _, f := range findings {
+		if opts.Modules == nil || opts.Modules[f.Module] {
+			out = append(out, f)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Module != b.Module {
+			return a.Module < b.Module
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Kind < b.Kind
+	})
+	return out, nil
+}
+
+// inheritsNSObject follows the superclasses of name through supers.
+func inheritsNSObject(name string, supers map[string]string) bool {
+	seen := make(map[string]bool)
+	for name != "" && !seen[name] {
+		seen[name] = true
+		super := strings.TrimPrefix(supers[name], "ObjectiveC.")
+		super = strings.TrimPrefix(super, "Foundation.")
+		if super == "NSObject" {
+			return true
+		}
+		name = super
+	}
+	return false
+}
+
+func readFile(root, rel, module string) (*file, error) {
+	fh, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	type line struct {
+		no   int
+		code string
+	}
+	var lines []line
+	inComment := false
+	scanner := textscan.NewScanner(fh)
+	no := 0
+	for scanner.Scan() {
+		no++
+		var code string
+		code, inComment = swiftsrc.StripComments(scanner.Text(), inComment)
+		if strings.TrimSpace(code) != "" {
+			lines = append(lines, line{no, code})
+		}
+	}
+	if err := textscan.Check(rel, no, scanner.Err()); err != nil {
+		return nil, err
+	}
+
+	f := &file{}
+	// name returns the declaration at or after the end of an attribute at
+	// offset end of lines[i].
+	name := func(i, end int) string {
+		rest := lines[i].code[end:]
+		for strings.TrimSpace(rest) == "" || attrsOnly.MatchString(rest) {
+			if i++; i == len(lines) {
+				return ""
+			}
+			rest = lines[i].code
+		}
+		m := declPattern.FindStringSubmatch(rest)
+		switch {
+		case m == nil:
+			return ""
+		case m[2] == "" || m[1] == "init" || m[1] == "subscript":
+			return m[1]
+		default:
+			return m[2]
+		}
+	}
+	for i, l := range lines {
+		add := func(kind string, end int) {
+			f.findings = append(f.findings, Finding{Module: module, File: rel, Line: l.no, Kind: kind, Name: name(i, end)})
+		}
+		if loc := membersAttr.FindStringIndex(l.code); loc != nil {
+			add(KindObjCMembers, loc[1])
+		}
+		for _, loc := range objcAttr.FindAllStringIndex(l.code, -1) {
+			add(KindObjC, loc[1])
+		}
+		if loc := dynamicMod.FindStringIndex(l.code); loc != nil {
+			add(KindDynamic, strings.Index(l.code[loc[0]:], "dynamic")+loc[0]+len("dynamic"))
+		}
+		// "class var x: Int" declares a property, not a class.
+		if m := classDecl.FindStringSubmatch(l.code); m != nil && !slices.Contains([]string{"func", "var", "let", "subscript"}, m[1]) {
+			f.classes = append(f.classes, class{module: module, file: rel, name: m[1], super: m[2], line: l.no})
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,161 @@
+package objcsurface
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Summary counts one module's findings.
+type Summary struct {
+	Module string         `json:"module"`
+	Total  int            `json:"total"`
+	ByKind map[string]int `json:"byKind"`
+	// Previous is the module's total in the run ratcheted against, when
+	// there is one.
+	Previous *int `json:"previous,omitempty"`
+}
+
+// Summarise groups findings by module, largest surface first. previous,
+// when not nil, holds the totals of an earlier run; modules with findings
+// then but none now are listed too.
+func Summarise(findings []Finding, previous map[string]int) []Summary {
+	byModule := make(map[string]*Summary)
+	get := func(module string) *Summary {
+		s, ok := byModule[module]
+		if !ok {
+			s = &Summary{Module: module, ByKind: make(map[string]int)}
+			byModule[module] = s
+		}
+		return s
+	}
+	for _, f := range findings {
+		s := get(f.Module)
+		s.Total++
+		s.ByKind[f.Kind]++
+	}
+	if previous != nil {
+		for module := range previous {
+			get(module)
+		}
+		for module, s := range byModule {
+			n := previous[module]
+			s.Previous = &n
+		}
+	}
+	out := make([]Summary, 0, len(byModule))
+	for _, s := range byModule {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		return out[i].Module < out[j].Module
+	})
+	return out
+}
+
+// Increases returns the modules whose surface grew since the previous run.
+func Increases(summaries []Summary) []Summary {
+	var out []Summary
+	for _, s := range summaries {
+		if s.Previous != nil && s.Total > *s.Previous {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// WriteMarkdown writes the summary table and then each module's findings.
+func WriteMarkdown(w io.Writer, findings []Finding, summaries []Summary) error {
+	ratchet := len(summaries) > 0 && summaries[0].Previous != nil
+
+	var b strings.Builder
+	b.WriteString("# Objective-C Surface\n\n")
+	fmt.Fprintf(&b, "**%d declarations in %d modules built with library evolution**\n", len(findings), countModules(summaries))
+
+	if ratchet {
+		if grown := Increases(summaries); len(grown) > 0 {
+			b.WriteString("\n## Grown Since the Previous Run\n\n")
+			for _, s := range grown {
+				fmt.Fprintf(&b, "- %s: %d, up from %d\n", s.Module, s.Total, *s.Previous)
+			}
+		}
+	}
+
+	if len(summaries) > 0 {
+		b.WriteString("\n## Modules\n\n| Module | @objc | @objcMembers | dynamic | NSObject | Total |")
+		if ratchet {
+			b.WriteString(" Previous |")
+		}
+		b.WriteString("\n|--------|-------|--------------|---------|----------|-------|")
+		if ratchet {
+			b.WriteString("----------|")
+		}
+		b.WriteString("\n")
+		for _, s := range summaries {
+			fmt.Fprintf(&b, "| %s |", s.Module)
+			for _, k := range Kinds {
+				fmt.Fprintf(&b, " %d |", s.ByKind[k])
+			}
+			fmt.Fprintf(&b, " %d |", s.Total)
+			if ratchet {
+				fmt.Fprintf(&b, " %d |", *s.Previous)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	for i, f := range findings {
+		if i == 0 || f.Module != findings[i-1].Module {
+			fmt.Fprintf(&b, "\n## %s\n\n", f.Module)
+		}
+		what := label(f.Kind)
+		if f.Super != "" && f.Super != "NSObject" {
+			what += ", via " + f.Super
+		}
+		fmt.Fprintf(&b, "- `%s:%d` %s `%s`\n", f.File, f.Line, what, f.Name)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the summaries and findings as indented JSON.
+func WriteJSON(w io.Writer, findings []Finding, summaries []Summary) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if findings == nil {
+		findings = []Finding{}
+	}
+	return enc.Encode(struct {
+		Modules  []Summary `json:"modules"`
+		Findings []Finding `json:"findings"`
+	}{summaries, findings})
+}
+
+func label(kind string) string {
+	switch kind {
+	case KindObjC:
+		return "@objc"
+	case KindObjCMembers:
+		return "@objcMembers"
+	case KindNSObject:
+		return "NSObject subclass"
+	}
+	return kind
+}
+
+// countModules counts the modules with findings, leaving out those only
+// the previous run had.
+func countModules(summaries []Summary) int {
+	n := 0
+	for _, s := range summaries {
+		if s.Total > 0 {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,100 @@
+package orphans
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moves"
+)
+
+// Fixable reports whether --fix acts on o. Excluded files are left alone:
+// an exclude pattern usually keeps a file out on purpose.
+func Fixable(o Orphan) bool {
+	return o.Cause != CauseExcluded
+}
+
+// AddToTargets adds each fixable orphan with a suggested target to that
+// target's srcs, recording what it did in the orphan's Fix. It returns
+// how many files it added and the paths of the BUILD files it saved,
+// those before the error when there is one.
+func AddToTargets(root string, orphans []Orphan) (int, []string, error) {
+	files := make(map[string]*buildfile.File)
+	added := 0
+	for i := range orphans {
+		o := &orphans[i]
+		if !Fixable(*o) || o.Target == "" {
+			continue
+		}
+		f, ok := files[o.Package]
+		if !ok {
+			var err error
+			if f, err = loadPackage(root, o.Package); err != nil {
+				return added, nil, err
+			}
+			files[o.Package] = f
+		}
+		_, name, _ := strings.Cut(o.Target, ":")
+		if r := f.Rule(name); r != nil && r.Attr("srcs") == nil {
+			if patterns, ok := defaultSrcs[r.Kind()]; ok {
+				// Setting srcs would drop the macro's own glob, so spell
+				// it out first.
+				if _, err := f.EnsureGlob(name, "srcs", patterns); err != nil {
+					return added, nil, err
+				}
+			}
+		}
+		changed, err := f.AddSource(name, "srcs", within(o.Package, o.File))
+		if err != nil {
+			return added, nil, err
+		}
+		if changed {
+			o.Fix = "added to " + o.Target
+			added++
+		}
+	}
+	var saved []string
+	for _, f := range files {
+		if err := f.Save(); err != nil {
+			return added, saved, err
+		}
+		saved = append(saved, f.Path)
+	}
+	sort.Strings(saved)
+	return added, saved, nil
+}
+
+// MoveToAttic moves each fixable orphan below attic, a directory relative
+// to the mover's root, keeping its path, and records the move in the
+// orphan's Fix. It returns how many files it moved; on an error the Fix
+// of those moved before it is set.
+func MoveToAttic(mover *moves.Mover, attic string, orphans []Orphan) (int, error) {
+	moved := 0
+	for i := range orphans {
+		o := &orphans[i]
+		if !Fixable(*o) {
+			continue
+		}
+		dest := path.Join(filepath.ToSlash(attic), o.File)
+		if err := mover.Move(o.File, dest); err != nil {
+			return moved, err
+		}
+		o.Fix = "moved to " + dest
+		moved++
+	}
+	return moved, nil
+}
+
+// loadPackage loads the BUILD file of package name, preferring BUILD.bazel
+// as Bazel does.
+func loadPackage(root, name string) (*buildfile.File, error) {
+	dir := filepath.Join(root, filepath.FromSlash(name))
+	file := filepath.Join(dir, "BUILD.bazel")
+	if _, err := os.Stat(file); err != nil {
+		file = filepath.Join(dir, "BUILD")
+	}
+	return buildfile.Load(file, name)
+}
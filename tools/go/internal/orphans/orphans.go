@@ -0,0 +1,355 @@
+// Package orphans finds Swift files that no Bazel target's srcs capture,
+// either by name or through a glob. Nothing compiles them, so they rot
+// unnoticed: they fall behind the APIs they use and mislead readers who
+// take them for live code.
+package orphans
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// Likely causes of an orphaned file, in report order.
+const (
+	// CauseNoPackage is a file with no BUILD file above it.
+	CauseNoPackage = "no-package"
+	// CauseBoundary is a file a glob of a package above would capture if a
+	// BUILD file between them did not start a package of its own.
+	CauseBoundary = "package-boundary"
+	// CauseNoTarget is a file whose package has no Swift target.
+	CauseNoTarget = "no-target"
+	// CauseNotListed is a file of a package whose targets list their srcs
+	// by name without it, usually a file added without updating BUILD.
+	CauseNotListed = "not-listed"
+	// CauseOutsideGlob is a file that the globs of its package's targets
+	// do not match, often one outside the directory they cover.
+	CauseOutsideGlob = "outside-glob"
+	// CauseExcluded is a file a glob's exclude patterns leave out, usually
+	// on purpose.
+	CauseExcluded = "excluded"
+)
+
+// Causes lists the causes in report order.
+var Causes = []string{CauseNoPackage, CauseBoundary, CauseNoTarget, CauseNotListed, CauseOutsideGlob, CauseExcluded}
+
+// defaultSrcs are the srcs of macros that glob for their sources when
+// none are passed (tools/build_defs/umbracore_module.bzl).
+var defaultSrcs = map[string][]string{
+	"umbracore_foundation_free_module":        {"Sources/**/*.swift"},
+	"umbracore_foundation_independent_module": {"Sources/**/*.swift"},
+	"umbracore_module_test":                   {"Tests/**/*.swift"},
+}
+
+// Orphan is a Swift file no target compiles.
+type Orphan struct {
+	File string `json:"file"`
+	// Package is the Bazel package the file belongs to, empty when no
+	// BUILD file lies above it.
+	Package string `json:"package,omitempty"`
+	Cause   string `json:"cause"`
+	Detail  string `json:"detail"`
+	// Target is the Swift target of Package the file most likely belongs
+	// to, empty when the package has none.
+	Target string `json:"target,omitempty"`
+	// Fix describes what --fix did with the file.
+	Fix         string `json:"fix,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Options configures a scan.
+type Options struct {
+	// Dirs are the top-level directories scanned (default "Sources" and
+	// "Tests").
+	Dirs []string
+	// Allow are globs of files never reported, such as templates kept out
+	// of the build on purpose.
+	Allow []string
+}
+
+// glob is one glob call in the srcs of a rule.
+type glob struct {
+	label            string
+	include, exclude []string
+}
+
+// pkg is what the scan needs of one Bazel package.
+type pkg struct {
+	globs []glob
+	// listed is set when a rule names its srcs without a glob.
+	listed string
+	// swift are the package's Swift rules.
+	swift []modulenames.Rule
+}
+
+// Scan returns the Swift files below opts.Dirs that no target's srcs
+// capture, sorted by path.
+func Scan(root string, opts Options) ([]Orphan, error) {
+	dirs := opts.Dirs
+	if len(dirs) == 0 {
+		dirs = []string{"Sources", "Tests"}
+	}
+
+	packages, referenced, err := load(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []Orphan
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			rel = path.Clean(filepath.ToSlash(filepath.Join(dir, rel)))
+			if referenced[rel] || matchAny(opts.Allow, rel) {
+				return nil
+			}
+			name, ok := nearest(packages, rel)
+			if !ok {
+				orphans = append(orphans, Orphan{File: rel, Cause: CauseNoPackage, Detail: "no BUILD file lies above it"})
+				return nil
+			}
+			if !captured(packages[name], within(name, rel)) {
+				orphans = append(orphans, diagnose(packages, name, rel))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].File < orphans[j].File })
+	return orphans, nil
+}
+
+// load reads every BUILD file below root. It returns the packages by name
+// and the files the rules' srcs name explicitly, relative to root. Like
+// Bazel, it ignores a BUILD file next to a BUILD.bazel.
+func load(root string) (map[string]*pkg, map[string]bool, error) {
+	files, err := buildfile.FindAll(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	present := make(map[string]bool, len(files))
+	for _, rel := range files {
+		present[rel] = true
+	}
+
+	packages := make(map[string]*pkg)
+	referenced := make(map[string]bool)
+	for _, rel := range files {
+		if path.Base(rel) == "BUILD" && present[path.Join(path.Dir(rel), "BUILD.bazel")] {
+			continue
+		}
+		name := buildfile.PackageOf(rel)
+		f, err := buildfile.Load(filepath.Join(root, rel), name)
+		if err != nil {
+			// A BUILD file that does not parse still starts a package.
+			packages[name] = &pkg{}
+			continue
+		}
+		p := &pkg{swift: modulenames.FileRules(f, rel)}
+		packages[name] = p
+		for _, r := range f.Rules("") {
+			label := "//" + name + ":" + r.Name()
+			srcs := r.Attr("srcs")
+			if srcs == nil {
+				if patterns, ok := defaultSrcs[r.Kind()]; ok {
+					p.globs = append(p.globs, glob{label: label, include: patterns})
+				}
+				continue
+			}
+			collect(srcs, func(call *build.CallExpr) {
+				include, exclude := buildfile.GlobArgs(call)
+				p.globs = append(p.globs, glob{label: label, include: include, exclude: exclude})
+			}, func(entry string) {
+				if p.listed == "" && modulenames.IsSwiftRule(r.Kind()) {
+					p.listed = label
+				}
+				if f, ok := sourcePath(name, entry); ok {
+					referenced[f] = true
+				}
+			})
+		}
+	}
+	return packages, referenced, nil
+}
+
+// collect walks a srcs expression, calling globFn for each glob and
+// entryFn for each string. Every branch of a select counts, since a file
+// one configuration compiles is not orphaned.
+func collect(x build.Expr, globFn func(*build.CallExpr), entryFn func(string)) {
+	switch x := x.(type) {
+	case *build.BinaryExpr:
+		collect(x.X, globFn, entryFn)
+		collect(x.Y, globFn, entryFn)
+	case *build.ListExpr:
+		for _, v := range x.List {
+			collect(v, globFn, entryFn)
+		}
+	case *build.DictExpr:
+		for _, kv := range x.List {
+			collect(kv.Value, globFn, entryFn)
+		}
+	case *build.StringExpr:
+		entryFn(x.Value)
+	case *build.CallExpr:
+		id, _ := x.X.(*build.Ident)
+		switch {
+		case id == nil:
+		case id.Name == "glob":
+			globFn(x)
+		case id.Name == "select" && len(x.List) > 0:
+			collect(x.List[0], globFn, entryFn)
+		}
+	}
+}
+
+// sourcePath resolves a srcs entry of package name to a path relative to
+// the workspace root. Labels of other repositories resolve to nothing.
+func sourcePath(name, entry string) (string, bool) {
+	if strings.HasPrefix(entry, "@") {
+		return "", false
+	}
+	if !strings.HasPrefix(entry, "//") && !strings.HasPrefix(entry, ":") {
+		return path.Join(name, entry), true
+	}
+	p, n, _ := strings.Cut(strings.TrimPrefix(buildfile.NormaliseLabel(name, entry), "//"), ":")
+	return path.Join(p, n), true
+}
+
+// nearest returns the package holding rel: the deepest directory above it
+// with a BUILD file, "" being the workspace root. It reports false when
+// there is none.
+func nearest(packages map[string]*pkg, rel string) (string, bool) {
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		if _, ok := packages[dir]; ok {
+			return dir, true
+		}
+	}
+	_, ok := packages[""]
+	return "", ok
+}
+
+// within returns rel relative to the directory of package name.
+func within(name, rel string) string {
+	if name == "" {
+		return rel
+	}
+	return strings.TrimPrefix(rel, name+"/")
+}
+
+// captured reports whether a glob of p matches rel, a path relative to
+// the package.
+func captured(p *pkg, rel string) bool {
+	for _, g := range p.globs {
+		if matchAny(g.include, rel) && !matchAny(g.exclude, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// diagnose works out the likely cause of rel, an orphan of package name.
+func diagnose(packages map[string]*pkg, name, rel string) Orphan {
+	p := packages[name]
+	o := Orphan{File: rel, Package: name, Target: Nearest(p.swift, rel)}
+
+	for dir := name; dir != ""; {
+		if dir = path.Dir(dir); dir == "." {
+			dir = ""
+		}
+		above, ok := packages[dir]
+		if !ok {
+			continue
+		}
+		for _, g := range above.globs {
+			if inner := within(dir, rel); matchAny(g.include, inner) && !matchAny(g.exclude, inner) {
+				o.Cause = CauseBoundary
+				o.Detail = "the glob of " + g.label + " matches it, but the BUILD file of " + packageName(name) + " starts a package of its own"
+				return o
+			}
+		}
+	}
+
+	for _, g := range p.globs {
+		if matchAny(g.exclude, within(name, rel)) {
+			o.Cause, o.Detail = CauseExcluded, "the exclude patterns of "+g.label+" name it"
+			return o
+		}
+	}
+	switch {
+	case len(p.swift) == 0:
+		o.Cause, o.Detail = CauseNoTarget, packageName(name)+" has no Swift target"
+	case p.listed != "" && len(p.globs) == 0:
+		o.Cause, o.Detail = CauseNotListed, p.listed+" lists its srcs by name and this file is not among them"
+	default:
+		var patterns []string
+		for _, g := range p.globs {
+			for _, include := range g.include {
+				if !slices.Contains(patterns, include) {
+					patterns = append(patterns, include)
+				}
+			}
+		}
+		o.Cause, o.Detail = CauseOutsideGlob, "it matches none of "+strings.Join(patterns, ", ")
+	}
+	return o
+}
+
+// packageName names package name in a detail.
+func packageName(name string) string {
+	if name == "" {
+		return "the root package"
+	}
+	return "//" + name
+}
+
+// Nearest returns the label of the rule rel most likely belongs to: a
+// test rule for a file under Tests or named *Tests.swift and a library
+// otherwise, then the rule whose source directory shares most of rel's
+// path. It returns "" without rules.
+func Nearest(rules []modulenames.Rule, rel string) string {
+	test := strings.HasSuffix(rel, "Tests.swift") || strings.HasPrefix(rel, "Tests/") || strings.Contains(rel, "/Tests/")
+	best, bestScore := "", -1
+	for _, r := range rules {
+		score := shared(r.SourceDir, path.Dir(rel))
+		if strings.Contains(r.Kind, "test") == test {
+			score += 1 << 20
+		}
+		if score > bestScore || score == bestScore && r.Label < best {
+			best, bestScore = r.Label, score
+		}
+	}
+	return best
+}
+
+// shared counts the leading path segments a and b have in common.
+func shared(a, b string) int {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	n := 0
+	for n < len(as) && n < len(bs) && as[n] == bs[n] {
+		n++
+	}
+	return n
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if walker.Match(p, rel) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package orphans
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// causeText explains each cause in the report.
+var causeText = map[string]string{
+	CauseNoPackage:   "No BUILD file lies above the file.",
+	CauseBoundary:    "A BUILD file in a subdirectory starts a new package, so the glob above no longer reaches the file.",
+	CauseNoTarget:    "The file's package has no Swift target.",
+	CauseNotListed:   "The package's targets list their srcs by name, and this file was never added.",
+	CauseOutsideGlob: "The package's glob patterns do not match the file.",
+	CauseExcluded:    "A glob's exclude patterns leave the file out, usually on purpose; --fix leaves these alone.",
+}
+
+// WriteMarkdown writes a count per likely cause followed by the files.
+func WriteMarkdown(w io.Writer, orphans []Orphan) error {
+	var b strings.Builder
+	b.WriteString("# Orphaned Swift Files\n\n")
+	if len(orphans) == 0 {
+		b.WriteString("Every Swift file is captured by a target's srcs.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	fmt.Fprintf(&b, "**%d** Swift files are not captured by any target's srcs, so nothing compiles them.\n\n", len(orphans))
+	counts := make(map[string]int)
+	fixed := false
+	for _, o := range orphans {
+		counts[o.Cause]++
+		fixed = fixed || o.Fix != ""
+	}
+	b.WriteString("## Likely Causes\n\n| Cause | Files | Meaning |\n|-------|-------|---------|\n")
+	for _, c := range Causes {
+		if counts[c] > 0 {
+			fmt.Fprintf(&b, "| %s | %d | %s |\n", c, counts[c], causeText[c])
+		}
+	}
+
+	b.WriteString("\n## Files\n\n| File | Cause | Detail | Nearest target |")
+	if fixed {
+		b.WriteString(" Fix |")
+	}
+	b.WriteString("\n|------|-------|--------|----------------|")
+	if fixed {
+		b.WriteString("-----|")
+	}
+	b.WriteString("\n")
+	for _, o := range orphans {
+		target := "-"
+		if o.Target != "" {
+			target = "`" + o.Target + "`"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s |", o.File, o.Cause, o.Detail, target)
+		if fixed {
+			fmt.Fprintf(&b, " %s |", o.Fix)
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the orphans as indented JSON.
+func WriteJSON(w io.Writer, orphans []Orphan) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if orphans == nil {
+		orphans = []Orphan{}
+	}
+	return enc.Encode(orphans)
+}
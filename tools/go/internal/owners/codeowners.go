@@ -0,0 +1,140 @@
+package owners
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/generated"
+)
+
+// Tool names the generator in the markers around the generated block.
+const Tool = "umbratool codeowners"
+
+const (
+	beginMarker = "# BEGIN " + Tool
+	endMarker   = "# END " + Tool
+)
+
+// Render returns the generated CODEOWNERS block for assignments, fenced
+// with checksum markers.
+func Render(assignments []Assignment, manifestName string) string {
+	return generated.Fence("#", Tool, manifestName, Entries(assignments))
+}
+
+// Entries returns the lines of the generated block, sorted by directory.
+func Entries(assignments []Assignment) string {
+	sorted := append([]Assignment(nil), assignments...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Dir < sorted[j].Dir })
+
+	var b strings.Builder
+	for _, a := range sorted {
+		fmt.Fprintf(&b, "%s %s\n", Pattern(a.Dir), strings.Join(a.Owners, " "))
+	}
+	return b.String()
+}
+
+// Splice replaces the generated block in existing with block, appending it
+// when existing has no generated block yet. Hand-written entries outside
+// the block are preserved.
+func Splice(existing, block string) string {
+	start := strings.Index(existing, beginMarker)
+	end := strings.Index(existing, endMarker)
+	if start < 0 || end < start {
+		if existing != "" && !strings.HasSuffix(existing, "\n") {
+			existing += "\n"
+		}
+		if existing != "" {
+			existing += "\n"
+		}
+		return existing + block
+	}
+
+	end += len(endMarker)
+	if end < len(existing) && existing[end] == '\n' {
+		end++
+	}
+	return existing[:start] + block + existing[end:]
+}
+
+// Entry is one rule parsed from a CODEOWNERS file.
+type Entry struct {
+	Line    int
+	Pattern string
+	Owners  []string
+}
+
+// Parse reads CODEOWNERS rules, skipping blank lines and comments.
+func Parse(content string) []Entry {
+	var entries []Entry
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		entries = append(entries, Entry{Line: i + 1, Pattern: fields[0], Owners: fields[1:]})
+	}
+	return entries
+}
+
+// Validation issue kinds.
+const (
+	IssueMissingEntry  = "missing-entry"
+	IssueOwnerMismatch = "owner-mismatch"
+	IssueObsoleteEntry = "obsolete-entry"
+	IssueUnknownOwner  = "unknown-owner"
+)
+
+// Validate compares an existing CODEOWNERS file with the resolved
+// assignments. Module rules outside the generated block are validated too,
+// so hand-maintained files can be checked before switching to generation.
+func Validate(content string, assignments []Assignment, m *Manifest, scopes []string) []Issue {
+	var issues []Issue
+
+	expected := make(map[string][]string, len(assignments))
+	for _, a := range assignments {
+		expected[Pattern(a.Dir)] = a.Owners
+	}
+
+	members := m.Members()
+	actual := make(map[string]Entry)
+	for _, e := range Parse(content) {
+		actual[e.Pattern] = e
+		for _, owner := range e.Owners {
+			if !members[owner] {
+				issues = append(issues, Issue{IssueUnknownOwner, e.Pattern,
+					fmt.Sprintf("line %d: owner %s of %s is not a member of any manifest team", e.Line, owner, e.Pattern)})
+			}
+		}
+
+		if _, ok := expected[e.Pattern]; !ok && isModulePattern(e.Pattern, scopes) {
+			issues = append(issues, Issue{IssueObsoleteEntry, e.Pattern,
+				fmt.Sprintf("line %d: %s does not correspond to an owned module", e.Line, e.Pattern)})
+		}
+	}
+
+	for _, pattern := range sortedKeys(expected) {
+		e, ok := actual[pattern]
+		switch {
+		case !ok:
+			issues = append(issues, Issue{IssueMissingEntry, pattern, fmt.Sprintf("%s has no CODEOWNERS entry", pattern)})
+		case strings.Join(e.Owners, " ") != strings.Join(expected[pattern], " "):
+			issues = append(issues, Issue{IssueOwnerMismatch, pattern,
+				fmt.Sprintf("line %d: %s is owned by %s, manifest says %s", e.Line, pattern, strings.Join(e.Owners, " "), strings.Join(expected[pattern], " "))})
+		}
+	}
+	return issues
+}
+
+// isModulePattern reports whether pattern names a top-level module
+// directory such as /Sources/Core/.
+func isModulePattern(pattern string, scopes []string) bool {
+	for _, scope := range scopes {
+		rest, ok := strings.CutPrefix(pattern, "/"+scope+"/")
+		if ok && rest != "" && strings.Count(strings.TrimSuffix(rest, "/"), "/") == 0 {
+			return true
+		}
+	}
+	return false
+}
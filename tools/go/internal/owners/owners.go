@@ -0,0 +1,184 @@
+// Package owners maps modules to owning teams from a YAML manifest and
+// renders or validates the corresponding CODEOWNERS entries.
+package owners
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/configschema"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modules"
+)
+
+// Team is a group of GitHub users or teams that own modules.
+type Team struct {
+	Description string   `yaml:"description"`
+	Members     []string `yaml:"members"`
+}
+
+// Manifest is the ownership manifest. Module keys are module names or
+// path.Match globs such as "Security*"; an exact name wins over globs, and
+// the longest matching glob wins over shorter ones.
+type Manifest struct {
+	Teams   map[string]Team       `yaml:"teams"`
+	Modules map[string]stringList `yaml:"modules"`
+	Default stringList            `yaml:"default"`
+}
+
+// stringList accepts either a single YAML scalar or a sequence.
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = stringList{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// LoadManifest reads and decodes a manifest file.
+func LoadManifest(file string) (*Manifest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := configschema.Validate(configschema.KindOwners, file, data); err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return &m, nil
+}
+
+// Issue kinds.
+const (
+	IssueUnowned     = "unowned-module"
+	IssueUnknownTeam = "unknown-team"
+	IssueEmptyTeam   = "empty-team"
+	IssueStaleEntry  = "stale-entry"
+)
+
+// Issue is a problem found while resolving ownership.
+type Issue struct {
+	Kind    string `json:"kind"`
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+}
+
+// Assignment is the resolved ownership of one module.
+type Assignment struct {
+	Module string   `json:"module"`
+	Dir    string   `json:"dir"`
+	Teams  []string `json:"teams"`
+	Owners []string `json:"owners"`
+}
+
+// Resolve assigns every module to its owners and reports manifest problems.
+func (m *Manifest) Resolve(mods []modules.Module) ([]Assignment, []Issue) {
+	var issues []Issue
+
+	teamNames := sortedKeys(m.Teams)
+	for _, name := range teamNames {
+		if len(m.Teams[name].Members) == 0 {
+			issues = append(issues, Issue{IssueEmptyTeam, name, fmt.Sprintf("team %s has no members", name)})
+		}
+	}
+
+	used := make(map[string]bool)
+	var assignments []Assignment
+	for _, mod := range mods {
+		key, teams := m.teamsFor(mod.Name)
+		if key != "" {
+			used[key] = true
+		}
+		if len(teams) == 0 {
+			issues = append(issues, Issue{IssueUnowned, mod.Name, fmt.Sprintf("module %s (%s) has no owner", mod.Name, mod.Dir)})
+			continue
+		}
+
+		a := Assignment{Module: mod.Name, Dir: mod.Dir, Teams: teams}
+		seen := make(map[string]bool)
+		for _, team := range teams {
+			t, ok := m.Teams[team]
+			if !ok {
+				issues = append(issues, Issue{IssueUnknownTeam, mod.Name, fmt.Sprintf("module %s is owned by %s, which is not defined under teams", mod.Name, team)})
+				continue
+			}
+			for _, member := range t.Members {
+				if !seen[member] {
+					seen[member] = true
+					a.Owners = append(a.Owners, member)
+				}
+			}
+		}
+		if len(a.Owners) == 0 {
+			issues = append(issues, Issue{IssueUnowned, mod.Name, fmt.Sprintf("module %s resolves to teams with no members", mod.Name)})
+			continue
+		}
+		assignments = append(assignments, a)
+	}
+
+	for _, key := range sortedKeys(m.Modules) {
+		if !used[key] {
+			issues = append(issues, Issue{IssueStaleEntry, key, fmt.Sprintf("manifest entry %s matches no module", key)})
+		}
+	}
+	return assignments, issues
+}
+
+// teamsFor returns the manifest key that matched name and its teams,
+// falling back to the default owners.
+func (m *Manifest) teamsFor(name string) (string, []string) {
+	if teams, ok := m.Modules[name]; ok {
+		return name, teams
+	}
+
+	best := ""
+	for key := range m.Modules {
+		if ok, _ := path.Match(key, name); ok && (len(key) > len(best) || (len(key) == len(best) && key < best)) {
+			best = key
+		}
+	}
+	if best != "" {
+		return best, m.Modules[best]
+	}
+	return "", m.Default
+}
+
+// Members returns every handle defined by the manifest.
+func (m *Manifest) Members() map[string]bool {
+	members := make(map[string]bool)
+	for _, t := range m.Teams {
+		for _, member := range t.Members {
+			members[member] = true
+		}
+	}
+	return members
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Pattern is the CODEOWNERS path pattern for a module directory.
+func Pattern(dir string) string {
+	return "/" + strings.TrimSuffix(dir, "/") + "/"
+}
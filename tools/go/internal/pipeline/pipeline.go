@@ -0,0 +1,97 @@
+// Package pipeline chains umbratool stages in one process. Run as separate
+// commands, scan, plan and apply each parse the tree again; chained, each
+// stage reads what the stages before it left in a shared Model instead,
+// and leaves an artifact that can be persisted for audit.
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/deprecation"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errorreport"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/importrewrite"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/migration"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/tasks"
+)
+
+// Separator separates the stages of a pipeline.
+const Separator = "|"
+
+// Model is the state the stages of one pipeline share. Fields are nil
+// until a stage fills them.
+type Model struct {
+	// Root is the project root.
+	Root string
+
+	rules []modulenames.Rule
+
+	// Errors is the error report of scan-errors.
+	Errors *errorreport.Report
+	// Symbols are the deprecated symbols of scan-deprecations, and Version
+	// the release they were compared against.
+	Symbols []deprecation.Symbol
+	Version string
+	// Configs are the mapping files of plan-migration, in order.
+	Configs []*importrewrite.Config
+	// Plan is the rewrite plan-migration made, which apply writes.
+	Plan *importrewrite.Plan
+	// Manifest is the migration plan-migration made for downstream
+	// codebases, which export-migration writes.
+	Manifest *migration.Manifest
+}
+
+// Rules returns the workspace's Swift rules, read once per pipeline.
+func (m *Model) Rules() ([]modulenames.Rule, error) {
+	if m.rules == nil {
+		rules, err := moduleindex.Rules(m.Root)
+		if err != nil {
+			return nil, err
+		}
+		m.rules = rules
+	}
+	return m.rules, nil
+}
+
+// Need returns an error naming the stage that fills a field a stage
+// needs, when ok is false.
+func Need(ok bool, stage, producer string) error {
+	if ok {
+		return nil
+	}
+	return fmt.Errorf("%s needs %s earlier in the pipeline", stage, producer)
+}
+
+// Split splits the arguments of a pipeline into its stages at each
+// Separator. A first argument holding several stages, as when the whole
+// pipeline is quoted, is split into words first, quotes grouping as in a
+// shell.
+func Split(args []string) ([][]string, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	if args[0] != Separator && strings.Contains(args[0], Separator) {
+		words, err := tasks.Split(args[0])
+		if err != nil {
+			return nil, err
+		}
+		args = append(words, args[1:]...)
+	}
+	var stages [][]string
+	var stage []string
+	for _, arg := range append(args, Separator) {
+		if arg != Separator {
+			stage = append(stage, arg)
+			continue
+		}
+		if len(stage) == 0 {
+			return nil, errors.New("empty pipeline stage")
+		}
+		stages = append(stages, stage)
+		stage = nil
+	}
+	return stages, nil
+}
@@ -0,0 +1,170 @@
+// Package plist decodes XML property lists, such as Info.plist and
+// .entitlements files.
+package plist
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Decode reads an XML property list. Dictionaries decode to
+// map[string]any, arrays to []any, and the scalars to string, bool, int64,
+// float64, time.Time and []byte.
+func Decode(r io.Reader) (any, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte("bplist")) {
+		return nil, errors.New("binary property lists are not supported; convert with plutil -convert xml1")
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, errors.New("no plist element")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			if start.Name.Local != "plist" {
+				return decodeValue(d, start)
+			}
+			el, err := nextElement(d)
+			if err != nil {
+				return nil, err
+			}
+			if el == nil {
+				return nil, errors.New("empty plist")
+			}
+			return decodeValue(d, *el)
+		}
+	}
+}
+
+// DecodeFile reads the XML property list in file.
+func DecodeFile(file string) (any, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	v, err := Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return v, nil
+}
+
+// DecodeDict reads a property list whose top-level value is a dictionary.
+func DecodeDict(file string) (map[string]any, error) {
+	v, err := DecodeFile(file)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: top-level value is not a dictionary", file)
+	}
+	return dict, nil
+}
+
+// nextElement returns the next start element before the enclosing
+// element ends, or nil when it ends first.
+func nextElement(d *xml.Decoder) (*xml.StartElement, error) {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			return &t, nil
+		case xml.EndElement:
+			return nil, nil
+		}
+	}
+}
+
+func decodeValue(d *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := make(map[string]any)
+		for {
+			key, err := nextElement(d)
+			if err != nil {
+				return nil, err
+			}
+			if key == nil {
+				return dict, nil
+			}
+			if key.Name.Local != "key" {
+				return nil, fmt.Errorf("dict entry starts with <%s>, want <key>", key.Name.Local)
+			}
+			var name string
+			if err := d.DecodeElement(&name, key); err != nil {
+				return nil, err
+			}
+			el, err := nextElement(d)
+			if err != nil {
+				return nil, err
+			}
+			if el == nil {
+				return nil, fmt.Errorf("key %q has no value", name)
+			}
+			if dict[name], err = decodeValue(d, *el); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	case "array":
+		list := []any{}
+		for {
+			el, err := nextElement(d)
+			if err != nil {
+				return nil, err
+			}
+			if el == nil {
+				return list, nil
+			}
+			v, err := decodeValue(d, *el)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+	case "true", "false":
+		if err := d.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+	}
+
+	var text string
+	if err := d.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	switch start.Name.Local {
+	case "string":
+		return text, nil
+	case "integer":
+		return strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	case "real":
+		return strconv.ParseFloat(strings.TrimSpace(text), 64)
+	case "date":
+		return time.Parse(time.RFC3339, strings.TrimSpace(text))
+	case "data":
+		return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+	default:
+		return nil, fmt.Errorf("unknown plist element <%s>", start.Name.Local)
+	}
+}
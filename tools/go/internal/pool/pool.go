@@ -0,0 +1,164 @@
+// Package pool runs work on a bounded number of goroutines. The bound is
+// process-wide and set once from the --jobs flag, so every command shares
+// the same concurrency limit instead of hard-coding its own. So is the
+// context that cancels the work when the process is interrupted.
+package pool
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	jobs atomic.Int64
+	ctx  atomic.Pointer[context.Context]
+)
+
+func init() {
+	jobs.Store(int64(runtime.NumCPU()))
+}
+
+// SetJobs sets the number of concurrent workers. Values below one reset it
+// to the number of CPUs.
+func SetJobs(n int) {
+	if n < 1 {
+		n = runtime.NumCPU()
+	}
+	jobs.Store(int64(n))
+}
+
+// Jobs returns the number of concurrent workers.
+func Jobs() int {
+	return int(jobs.Load())
+}
+
+// SetContext sets the context Run and Map stop at, typically one cancelled
+// by SIGINT.
+func SetContext(c context.Context) {
+	ctx.Store(&c)
+}
+
+// Context returns the context set by SetContext, or context.Background().
+func Context() context.Context {
+	if c := ctx.Load(); c != nil {
+		return *c
+	}
+	return context.Background()
+}
+
+// Run calls fn for every item using at most Jobs() goroutines and returns
+// the errors joined together. It stops once Context() is done.
+func Run[T any](items []T, fn func(T) error) error {
+	return RunContext(Context(), items, fn)
+}
+
+// Map calls fn for every item using at most Jobs() goroutines and returns
+// the results in input order. Results for failed items are zero values.
+// It stops once Context() is done.
+func Map[T, R any](items []T, fn func(T) (R, error)) ([]R, error) {
+	return MapContext(Context(), items, fn)
+}
+
+// RunContext is Run stopping at c.
+func RunContext[T any](c context.Context, items []T, fn func(T) error) error {
+	_, err := MapContext(c, items, func(item T) (struct{}, error) {
+		return struct{}{}, fn(item)
+	})
+	return err
+}
+
+// MapContext is Map stopping at c: once c is done, no further item is
+// started, the items already started finish, and the error includes
+// c.Err() if any item was left out.
+func MapContext[T, R any](c context.Context, items []T, fn func(T) (R, error)) ([]R, error) {
+	results := make([]R, len(items))
+	errs := make([]error, len(items))
+
+	workers := Jobs()
+	if workers > len(items) {
+		workers = len(items)
+	}
+
+	var next atomic.Int64
+	var cancelled atomic.Bool
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= len(items) {
+					return
+				}
+				if c.Err() != nil {
+					cancelled.Store(true)
+					return
+				}
+				results[i], errs[i] = fn(items[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	if cancelled.Load() {
+		errs = append(errs, c.Err())
+	}
+	return results, errors.Join(errs...)
+}
+
+// Stream is Map calling emit with each result, in input order, as soon as
+// it and every result before it are ready, so that a caller can write out
+// results while later items are still being worked on. Emitting stops at
+// the first failed item. An error from emit stops the work and is returned.
+func Stream[T, R any](items []T, fn func(T) (R, error), emit func(R) error) ([]R, error) {
+	c, cancel := context.WithCancel(Context())
+	defer cancel()
+
+	results := make([]R, len(items))
+	failed := make([]bool, len(items))
+	done := make([]chan struct{}, len(items))
+	indices := make([]int, len(items))
+	for i := range items {
+		done[i] = make(chan struct{})
+		indices[i] = i
+	}
+	var err error
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		err = RunContext(c, indices, func(i int) error {
+			defer close(done[i])
+			var itemErr error
+			results[i], itemErr = fn(items[i])
+			failed[i] = itemErr != nil
+			return itemErr
+		})
+	}()
+
+	for i := range items {
+		select {
+		case <-done[i]:
+		case <-finished:
+			// Item i was never started, as the work was cancelled.
+			select {
+			case <-done[i]:
+			default:
+				return results, err
+			}
+		}
+		if failed[i] {
+			break
+		}
+		if emitErr := emit(results[i]); emitErr != nil {
+			cancel()
+			<-finished
+			return results, emitErr
+		}
+	}
+	<-finished
+	return results, err
+}
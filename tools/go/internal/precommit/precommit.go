@@ -0,0 +1,336 @@
+// Package precommit runs the fast subset of the analyzers on the files
+// staged for commit, for a git pre-commit hook: file headers, import
+// hygiene, error mapper coverage and BUILD files gazelle would rewrite.
+// Every failure carries the command, or edit, that fixes it.
+package precommit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/errormapper"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/header"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/health"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/staged"
+)
+
+// Checks, in the order they run.
+const (
+	CheckHeaders     = "headers"
+	CheckImports     = "imports"
+	CheckErrorMapper = "error-mapper"
+	CheckGazelle     = "gazelle"
+)
+
+// Checks lists every check.
+var Checks = []string{CheckHeaders, CheckImports, CheckErrorMapper, CheckGazelle}
+
+// Failure is one problem in the staged files.
+type Failure struct {
+	Check   string `json:"check"`
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+	// Fix is the command that fixes the failure, run from the project
+	// root, or the edit to make when there is none.
+	Fix string `json:"fix"`
+}
+
+// Files is the staged content of the files staged for commit, keyed by
+// their path relative to the project root.
+type Files map[string][]byte
+
+// ReadStaged returns the staged content of the files staged below root
+// that were added, copied, modified or renamed. Only Swift and BUILD files
+// are read; the others are listed with no content.
+func ReadStaged(root string) (Files, error) {
+	list, err := staged.Files(root)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := pool.Map(list, func(rel string) ([]byte, error) {
+		if path.Ext(rel) != ".swift" && !buildfile.IsBuildFile(path.Base(rel)) {
+			return nil, nil
+		}
+		return staged.Read(root, rel)
+	})
+	if err != nil {
+		return nil, err
+	}
+	files := make(Files, len(list))
+	for i, rel := range list {
+		files[rel] = contents[i]
+	}
+	return files, nil
+}
+
+// sorted returns the paths of files with extension ext, or every path when
+// ext is empty, in order.
+func (files Files) sorted(ext string) []string {
+	var out []string
+	for rel := range files {
+		if ext == "" || path.Ext(rel) == ext {
+			out = append(out, rel)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Headers checks the header of every staged file c covers.
+func Headers(c *header.Checker, files Files) []Failure {
+	var out []Failure
+	for _, rel := range files.sorted("") {
+		r, ok := c.CheckFile(rel, string(files[rel]))
+		if !ok || r.Status == header.StatusOK {
+			continue
+		}
+		msg := string(r.Status) + " header"
+		if r.Detail != "" {
+			msg += " (" + r.Detail + ")"
+		}
+		out = append(out, Failure{Check: CheckHeaders, File: rel, Message: msg,
+			Fix: "umbratool check-headers --fix " + shellQuote(rel)})
+	}
+	return out
+}
+
+// Imports reports the workspace modules staged Swift files import that no
+// rule of their module depends on, as health's dependency hygiene does.
+// The fix adds the dep to the rule whose sources hold the file.
+func Imports(rules []modulenames.Rule, files Files) ([]Failure, error) {
+	// A module compiled by several rules is depended on through its
+	// library rather than its tests.
+	byName := make(map[string]string, len(rules))
+	tests := make(map[string]bool)
+	for _, r := range rules {
+		test := strings.Contains(r.Kind, "test")
+		if _, ok := byName[r.ModuleName]; !ok || tests[r.ModuleName] && !test {
+			byName[r.ModuleName] = r.Label
+			tests[r.ModuleName] = test
+		}
+	}
+
+	var out []Failure
+	for _, rel := range files.sorted(".swift") {
+		f, err := imports.Parse(bytes.NewReader(files[rel]), rel)
+		if err != nil {
+			return nil, err
+		}
+		missing := health.CheckDependencies(rules, []imports.File{f})[f.Module].Missing
+		if len(missing) == 0 {
+			continue
+		}
+		owner := owningRule(rules, rel)
+		for _, name := range missing {
+			failure := Failure{Check: CheckImports, File: rel, Message: fmt.Sprintf("imports %s, which no rule of %s depends on", name, f.Module)}
+			for _, imp := range f.Imports {
+				if imp.Module == name {
+					failure.Line = imp.Line
+					break
+				}
+			}
+			if owner != "" {
+				failure.Fix = fmt.Sprintf("buildozer 'add deps %s' %s", byName[name], owner)
+			} else {
+				failure.Fix = fmt.Sprintf("add %s to the deps of the rule compiling %s", byName[name], rel)
+			}
+			out = append(out, failure)
+		}
+	}
+	return out, nil
+}
+
+// owningRule returns the label of the Swift rule whose source directory is
+// the closest parent of rel, or "" when no rule's sources hold it.
+func owningRule(rules []modulenames.Rule, rel string) string {
+	best, depth := "", -1
+	for _, r := range rules {
+		dir := r.SourceDir
+		if dir != "" && (rel == dir || strings.HasPrefix(rel, dir+"/")) && len(dir) > depth {
+			best, depth = r.Label, len(dir)
+		}
+	}
+	return best
+}
+
+// TouchesErrorMapper reports whether a staged file is one of the mapper
+// files or declares one of the enums they map.
+func TouchesErrorMapper(files Files, mappers, enums []string) bool {
+	for _, m := range mappers {
+		if _, ok := files[path.Clean(m)]; ok {
+			return true
+		}
+	}
+	if len(enums) == 0 {
+		return false
+	}
+	quoted := make([]string, len(enums))
+	for i, e := range enums {
+		quoted[i] = regexp.QuoteMeta(e)
+	}
+	declares := regexp.MustCompile(`\benum\s+(?:` + strings.Join(quoted, "|") + `)\b`)
+	for _, rel := range files.sorted(".swift") {
+		if declares.Match(files[rel]) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorMapper turns error-mapper-check issues into failures.
+func ErrorMapper(issues []errormapper.Issue) []Failure {
+	out := make([]Failure, 0, len(issues))
+	for _, i := range issues {
+		f := Failure{Check: CheckErrorMapper, File: i.File, Line: i.Line, Message: i.Message}
+		if i.Kind == errormapper.IssueStale {
+			f.Fix = fmt.Sprintf("remove case .%s from the switch at %s:%d", i.Case, i.File, i.Line)
+		} else {
+			f.Fix = fmt.Sprintf("add case .%s to the switch at %s:%d", i.Case, i.File, i.Line)
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// Gazelle is how to run the repository's gazelle without Bazel.
+type Gazelle struct {
+	// Binary is the built gazelle_binary.
+	Binary string
+	// Args are the arguments the gazelle rule passes it.
+	Args []string
+	// Target is the gazelle rule, which the fix commands run.
+	Target string
+}
+
+// ErrNoGazelle is returned by LoadGazelle when the gazelle binary has not
+// been built.
+var ErrNoGazelle = errors.New("gazelle binary not built")
+
+// LoadGazelle reads the arguments of the gazelle rule target ("//pkg:name")
+// from its BUILD file and checks that binary, relative to root unless
+// absolute, exists.
+func LoadGazelle(root, target, binary string) (*Gazelle, error) {
+	if !filepath.IsAbs(binary) {
+		binary = filepath.Join(root, filepath.FromSlash(binary))
+	}
+	if _, err := os.Stat(binary); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoGazelle, binary)
+	}
+
+	pkg, name, _ := strings.Cut(strings.TrimPrefix(target, "//"), ":")
+	if name == "" {
+		name = path.Base(pkg)
+	}
+	g := &Gazelle{Binary: binary, Target: target}
+	for _, base := range []string{"BUILD.bazel", "BUILD"} {
+		f, err := buildfile.Load(filepath.Join(root, filepath.FromSlash(pkg), base), pkg)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		r := f.Rule(name)
+		if r == nil {
+			return nil, fmt.Errorf("%s: no rule %s", target, name)
+		}
+		if prefix := r.AttrString("prefix"); prefix != "" {
+			g.Args = append(g.Args, "-go_prefix="+prefix)
+		}
+		g.Args = append(g.Args, r.AttrStrings("args")...)
+		return g, nil
+	}
+	return nil, fmt.Errorf("%s: no BUILD file", target)
+}
+
+// TouchedDirs returns the directories of the staged Swift and BUILD files,
+// in order.
+func TouchedDirs(files Files) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, rel := range files.sorted("") {
+		if path.Ext(rel) != ".swift" && !buildfile.IsBuildFile(path.Base(rel)) {
+			continue
+		}
+		if dir := path.Dir(rel); !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// Diff runs gazelle in diff mode on dirs, without recursing, and reports
+// each BUILD file it would change. It reads the work tree, not the index.
+func (g *Gazelle) Diff(ctx context.Context, root string, dirs []string) ([]Failure, error) {
+	if len(dirs) == 0 {
+		return nil, nil
+	}
+	args := append([]string{"-repo_root=" + root, "-mode=diff", "-r=false"}, g.Args...)
+	for _, d := range dirs {
+		args = append(args, filepath.Join(root, filepath.FromSlash(d)))
+	}
+	cmd := exec.CommandContext(ctx, g.Binary, args...)
+	cmd.Dir = root
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	changed := diffFiles(root, stdout.String())
+	if err != nil && len(changed) == 0 {
+		return nil, fmt.Errorf("gazelle: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	out := make([]Failure, 0, len(changed))
+	for _, rel := range changed {
+		out = append(out, Failure{Check: CheckGazelle, File: rel, Message: "gazelle would update this BUILD file",
+			Fix: "bazel run " + g.Target + " -- " + shellQuote(path.Dir(rel))})
+	}
+	return out, nil
+}
+
+// diffFiles returns the files, relative to root, a unified diff changes,
+// from its "+++" lines.
+func diffFiles(root, diff string) []string {
+	var out []string
+	for _, line := range strings.Split(diff, "\n") {
+		name, ok := strings.CutPrefix(line, "+++ ")
+		if !ok {
+			continue
+		}
+		if i := strings.IndexByte(name, '\t'); i >= 0 {
+			name = name[:i]
+		}
+		if name == "/dev/null" {
+			continue
+		}
+		if filepath.IsAbs(name) {
+			if rel, err := filepath.Rel(root, name); err == nil {
+				name = filepath.ToSlash(rel)
+			}
+		}
+		out = append(out, strings.TrimPrefix(name, "./"))
+	}
+	return out
+}
+
+// shellQuote quotes s for a POSIX shell when it needs it.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./+:@") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,232 @@
+// Package progress measures how far each item of the machine-readable
+// refactoring plan has got, using the module, import and Bazel dependency
+// scanners.
+package progress
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/configschema"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modules"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Plan actions.
+const (
+	ActionMerge  = "merge"
+	ActionRemove = "remove"
+	ActionSplit  = "split"
+	ActionRename = "rename"
+)
+
+// Item is one entry of the refactoring plan.
+type Item struct {
+	ID          string   `yaml:"id"`
+	Description string   `yaml:"description"`
+	Action      string   `yaml:"action"`
+	Modules     []string `yaml:"modules"`
+	// Target is the module merged or renamed into, or the replacement for
+	// removed modules.
+	Target string `yaml:"target"`
+	// Into lists the modules a split produces.
+	Into []string `yaml:"into"`
+	// Baseline is the number of importing files when the item was planned;
+	// any drop below it counts as progress.
+	Baseline int `yaml:"baseline"`
+}
+
+// Plan is the machine-readable refactoring plan.
+type Plan struct {
+	Title string `yaml:"title"`
+	Items []Item `yaml:"items"`
+}
+
+// LoadPlan reads and validates a plan file.
+func LoadPlan(file string) (*Plan, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := configschema.Validate(configschema.KindRefactoringPlan, file, data); err != nil {
+		return nil, err
+	}
+
+	var p Plan
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for i, item := range p.Items {
+		if err := item.validate(); err != nil {
+			return nil, fmt.Errorf("%s: item %d: %w", file, i+1, err)
+		}
+		if item.ID == "" {
+			p.Items[i].ID = fmt.Sprintf("%s-%s", item.Action, strings.Join(item.Modules, "-"))
+		}
+	}
+	return &p, nil
+}
+
+func (item Item) validate() error {
+	if len(item.Modules) == 0 {
+		return fmt.Errorf("no modules listed")
+	}
+	switch item.Action {
+	case ActionMerge, ActionRename:
+		if item.Target == "" {
+			return fmt.Errorf("%s requires a target", item.Action)
+		}
+	case ActionSplit:
+		if len(item.Into) == 0 {
+			return fmt.Errorf("split requires into")
+		}
+	case ActionRemove:
+	default:
+		return fmt.Errorf("unknown action %q", item.Action)
+	}
+	return nil
+}
+
+// State is the progress state of an item.
+type State string
+
+const (
+	StateDone       State = "done"
+	StateInProgress State = "in-progress"
+	StateNotStarted State = "not-started"
+)
+
+// Status is the measured progress of one plan item.
+type Status struct {
+	Item  Item  `json:"item"`
+	State State `json:"state"`
+	// RemainingModules are source modules that still exist.
+	RemainingModules []string `json:"remainingModules"`
+	// MissingTargets are target or split modules that do not exist yet.
+	MissingTargets []string `json:"missingTargets"`
+	// ImportingFiles counts files outside the source modules that still
+	// import them.
+	ImportingFiles int `json:"importingFiles"`
+	// BuildReferences counts BUILD files outside the source modules that
+	// still depend on them.
+	BuildReferences int `json:"buildReferences"`
+}
+
+// Measure computes the status of every item in plan against the tree.
+func Measure(root string, plan *Plan) ([]Status, error) {
+	mods, err := modules.Discover(root)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(mods))
+	for _, m := range mods {
+		existing[m.Name] = true
+	}
+
+	files, err := imports.ScanTree(root, "Sources", "Tests")
+	if err != nil {
+		return nil, err
+	}
+	importers := imports.Importers(files)
+
+	buildRefs, err := buildReferences(root)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(plan.Items))
+	for _, item := range plan.Items {
+		s := Status{Item: item}
+		sources := make(map[string]bool, len(item.Modules))
+		for _, m := range item.Modules {
+			sources[m] = true
+			if existing[m] {
+				s.RemainingModules = append(s.RemainingModules, m)
+			}
+		}
+
+		targets := item.Into
+		if item.Action == ActionMerge || item.Action == ActionRename {
+			targets = []string{item.Target}
+		}
+		for _, t := range targets {
+			if !existing[t] {
+				s.MissingTargets = append(s.MissingTargets, t)
+			}
+		}
+
+		// Files outside the source modules that import or reference them
+		// still have to be migrated, whatever the action, a split's
+		// importers included.
+		for _, m := range item.Modules {
+			for _, f := range importers[m] {
+				if !sources[workspace.ModuleForPath(f)] {
+					s.ImportingFiles++
+				}
+			}
+			for _, ref := range buildRefs[m] {
+				if !sources[workspace.ModuleForPath(ref)] {
+					s.BuildReferences++
+				}
+			}
+		}
+
+		s.State = state(item, s)
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+func state(item Item, s Status) State {
+	if len(s.RemainingModules) == 0 && len(s.MissingTargets) == 0 && s.ImportingFiles == 0 && s.BuildReferences == 0 {
+		return StateDone
+	}
+
+	started := len(s.RemainingModules) < len(item.Modules) ||
+		(item.Baseline > 0 && s.ImportingFiles < item.Baseline)
+	if item.Action == ActionSplit || item.Action == ActionRename {
+		targets := len(item.Into)
+		if item.Action == ActionRename {
+			targets = 1
+		}
+		started = started || len(s.MissingTargets) < targets
+	}
+	if started {
+		return StateInProgress
+	}
+	return StateNotStarted
+}
+
+var sourcesLabel = regexp.MustCompile(`"(?:@[A-Za-z0-9_]*)?//Sources/([A-Za-z0-9_]+)[/:"]`)
+
+// buildReferences maps each module to the BUILD files containing a label
+// inside it.
+func buildReferences(root string) (map[string][]string, error) {
+	refs := make(map[string][]string)
+	err := walker.Walk(root, walker.Options{}, func(rel string) error {
+		if base := filepath.Base(rel); base != "BUILD.bazel" && base != "BUILD" {
+			return nil
+		}
+		data, err := os.ReadFile(filepath.Join(root, rel))
+		if err != nil {
+			return err
+		}
+
+		seen := make(map[string]bool)
+		for _, m := range sourcesLabel.FindAllStringSubmatch(string(data), -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				refs[m[1]] = append(refs[m[1]], rel)
+			}
+		}
+		return nil
+	})
+	return refs, err
+}
@@ -0,0 +1,76 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+var stateLabels = map[State]string{
+	StateDone:       "Done",
+	StateInProgress: "In progress",
+	StateNotStarted: "Not started",
+}
+
+// WriteMarkdown writes a progress section suitable for pasting into the
+// refactoring plan document.
+func WriteMarkdown(w io.Writer, title string, statuses []Status) error {
+	if title == "" {
+		title = "Refactoring Progress"
+	}
+
+	counts := make(map[State]int)
+	for _, s := range statuses {
+		counts[s.State]++
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", title)
+	fmt.Fprintf(&b, "**%d done, %d in progress, %d not started** (of %d items)\n\n",
+		counts[StateDone], counts[StateInProgress], counts[StateNotStarted], len(statuses))
+
+	b.WriteString("| Item | Action | Change | Status | Details |\n")
+	b.WriteString("|------|--------|--------|--------|---------|\n")
+	for _, s := range statuses {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", s.Item.ID, s.Item.Action, describe(s.Item), stateLabels[s.State], details(s))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func describe(item Item) string {
+	from := strings.Join(item.Modules, ", ")
+	switch item.Action {
+	case ActionMerge, ActionRename:
+		return from + " → " + item.Target
+	case ActionSplit:
+		return from + " → " + strings.Join(item.Into, ", ")
+	default:
+		if item.Target != "" {
+			return from + " (use " + item.Target + ")"
+		}
+		return from
+	}
+}
+
+func details(s Status) string {
+	if s.State == StateDone {
+		return "—"
+	}
+
+	var parts []string
+	if n := len(s.RemainingModules); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d of %d source modules remain", n, len(s.Item.Modules)))
+	}
+	if len(s.MissingTargets) > 0 {
+		parts = append(parts, "missing "+strings.Join(s.MissingTargets, ", "))
+	}
+	if s.ImportingFiles > 0 {
+		parts = append(parts, fmt.Sprintf("%d importing files", s.ImportingFiles))
+	}
+	if s.BuildReferences > 0 {
+		parts = append(parts, fmt.Sprintf("%d BUILD files with deps", s.BuildReferences))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,473 @@
+package protocols
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Issue kinds.
+const (
+	IssueMissing             = "missing"
+	IssueSignatureMismatch   = "signature_mismatch"
+	IssueConstraintMismatch  = "constraint_mismatch"
+	IssueDefaultMismatch     = "default_mismatch"
+	IssueDuplicate           = "duplicate"
+	IssuePlatformConditional = "platform_conditional"
+)
+
+// Issue is one problem found by the checker.
+type Issue struct {
+	Kind     string `json:"kind"`
+	Severity string `json:"severity"`
+	Protocol string `json:"protocol"`
+	// ProtocolModule is the module declaring the protocol.
+	ProtocolModule string `json:"protocolModule"`
+	// Type is the conforming type; empty for duplicate protocols.
+	Type        string `json:"type,omitempty"`
+	Module      string `json:"module"`
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Requirement string `json:"requirement,omitempty"`
+	// Guard describes the platform guard of the conformance, when it is
+	// platform-only.
+	Guard   string `json:"guard,omitempty"`
+	Message string `json:"message"`
+	// Fingerprint is set by umbratool, as the run records the issue.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Options configures a check.
+type Options struct {
+	// Platform restricts the check to declarations compiled for one
+	// platform, e.g. "macOS". Empty checks every branch.
+	Platform string
+}
+
+var defaultSeverity = map[string]string{
+	IssueMissing:             "error",
+	IssueSignatureMismatch:   "error",
+	IssueConstraintMismatch:  "error",
+	IssueDefaultMismatch:     "warning",
+	IssueDuplicate:           "warning",
+	IssuePlatformConditional: "warning",
+}
+
+// Check verifies that every conformance to an indexed protocol implements
+// the protocol's requirements, directly, through a superclass or through a
+// default in a protocol extension.
+func Check(ix *Index, opts Options) []Issue {
+	c := newChecker(ix, opts)
+	var issues []Issue
+	issues = append(issues, c.duplicates()...)
+
+	seen := make(map[string]bool)
+	for _, d := range ix.Decls {
+		if d.Kind == KindProtocol || !d.Guard.ActiveOn(opts.Platform) {
+			continue
+		}
+		for _, name := range d.Inherits {
+			proto := c.resolve(name, d)
+			if proto == nil {
+				continue
+			}
+			key := d.Module + "\x00" + d.Name + "\x00" + proto.Module + "." + proto.Name
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			issues = append(issues, c.conformance(d, proto)...)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Requirement < b.Requirement
+	})
+	return issues
+}
+
+type checker struct {
+	ix         *Index
+	opts       Options
+	protocols  map[string][]*Decl
+	types      map[string][]*Decl
+	extensions map[string][]*Decl
+	modules    map[string]bool
+}
+
+func newChecker(ix *Index, opts Options) *checker {
+	c := &checker{
+		ix:         ix,
+		opts:       opts,
+		protocols:  make(map[string][]*Decl),
+		types:      make(map[string][]*Decl),
+		extensions: make(map[string][]*Decl),
+		modules:    make(map[string]bool),
+	}
+	for _, d := range ix.Decls {
+		c.modules[d.Module] = true
+	}
+	for _, d := range ix.Decls {
+		switch d.Kind {
+		case KindProtocol:
+			c.protocols[d.Name] = append(c.protocols[d.Name], d)
+		case KindExtension:
+			name := d.Name
+			if _, rest, ok := strings.Cut(name, "."); ok && c.isModuleQualified(name) {
+				name = rest
+			}
+			c.extensions[name] = append(c.extensions[name], d)
+		default:
+			c.types[d.Name] = append(c.types[d.Name], d)
+		}
+	}
+	return c
+}
+
+// isModuleQualified reports whether name starts with a module name, as in
+// "extension CoreErrors.SecurityError".
+func (c *checker) isModuleQualified(name string) bool {
+	mod, _, _ := strings.Cut(name, ".")
+	return c.modules[mod]
+}
+
+// resolve finds the protocol a declaration in from refers to as name,
+// preferring its own module and then the modules its file imports.
+func (c *checker) resolve(name string, from *Decl) *Decl {
+	module := ""
+	if mod, rest, ok := strings.Cut(name, "."); ok {
+		if _, isProto := c.protocols[rest]; isProto {
+			module, name = mod, rest
+		}
+	}
+	candidates := c.protocols[name]
+	if len(candidates) == 0 {
+		return nil
+	}
+	if module != "" {
+		for _, p := range candidates {
+			if p.Module == module {
+				return p
+			}
+		}
+		return nil
+	}
+	var best *Decl
+	for _, p := range candidates {
+		if p.Module == from.Module && (best == nil || commonPrefix(p.File, from.File) > commonPrefix(best.File, from.File)) {
+			best = p
+		}
+	}
+	if best != nil {
+		return best
+	}
+	imported := c.ix.Imports[from.File]
+	for _, p := range candidates {
+		if imported[p.Module] {
+			return p
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	return nil
+}
+
+// commonPrefix returns the number of leading path segments a and b share,
+// so that a module declaring a protocol twice resolves to the nearer one.
+func commonPrefix(a, b string) int {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	n := 0
+	for n < len(as) && n < len(bs) && as[n] == bs[n] {
+		n++
+	}
+	return n
+}
+
+func (c *checker) duplicates() []Issue {
+	var issues []Issue
+	for name, decls := range c.protocols {
+		modules := make(map[string]bool)
+		for _, d := range decls {
+			modules[d.Module] = true
+		}
+		if len(modules) < 2 {
+			continue
+		}
+		var names []string
+		for m := range modules {
+			names = append(names, m)
+		}
+		sort.Strings(names)
+		first := decls[0]
+		issues = append(issues, Issue{
+			Kind:           IssueDuplicate,
+			Severity:       defaultSeverity[IssueDuplicate],
+			Protocol:       name,
+			ProtocolModule: first.Module,
+			Module:         first.Module,
+			File:           first.File,
+			Line:           first.Line,
+			Message:        fmt.Sprintf("protocol %s is declared in %d modules: %s", name, len(names), strings.Join(names, ", ")),
+		})
+	}
+	return issues
+}
+
+// requirement is a protocol member together with the protocol declaring it.
+type requirement struct {
+	Member
+	protocol *Decl
+}
+
+// requirements returns the requirements of proto and the protocols it
+// inherits, without optional requirements and associated types.
+func (c *checker) requirements(proto *Decl, visited map[*Decl]bool) []requirement {
+	if visited[proto] {
+		return nil
+	}
+	visited[proto] = true
+
+	var reqs []requirement
+	for _, m := range proto.Members {
+		if m.Optional || m.Kind == MemberAssociatedType || m.Kind == MemberTypeAlias {
+			continue
+		}
+		if !m.Guard.ActiveOn(c.opts.Platform) {
+			continue
+		}
+		reqs = append(reqs, requirement{m, proto})
+	}
+	for _, name := range proto.Inherits {
+		if parent := c.resolve(name, proto); parent != nil {
+			reqs = append(reqs, c.requirements(parent, visited)...)
+		}
+	}
+	return reqs
+}
+
+// defaults returns the members of extensions of proto and the protocols it
+// inherits.
+func (c *checker) defaults(proto *Decl, visited map[*Decl]bool) []Member {
+	if visited[proto] {
+		return nil
+	}
+	visited[proto] = true
+
+	var members []Member
+	for _, ext := range c.extensions[proto.Name] {
+		members = append(members, ext.Members...)
+	}
+	for _, name := range proto.Inherits {
+		if parent := c.resolve(name, proto); parent != nil {
+			members = append(members, c.defaults(parent, visited)...)
+		}
+	}
+	return members
+}
+
+// implementations returns the members of the conforming type: its
+// declaration in its home module, all its extensions, and its superclasses.
+func (c *checker) implementations(conformance *Decl) []Member {
+	var members []Member
+	visited := make(map[*Decl]bool)
+	name, module := conformance.Name, conformance.Module
+	if conformance.Kind == KindExtension {
+		if _, rest, ok := strings.Cut(name, "."); ok && c.isModuleQualified(name) {
+			name = rest
+		}
+	}
+	for name != "" {
+		var superclass string
+		home := c.homeType(name, module)
+		for _, d := range c.types[name] {
+			if d.Module != home || visited[d] {
+				continue
+			}
+			visited[d] = true
+			members = append(members, d.Members...)
+			if d.Kind == KindClass && len(d.Inherits) > 0 {
+				if _, ok := c.types[d.Inherits[0]]; ok {
+					superclass = d.Inherits[0]
+				}
+			}
+		}
+		for _, ext := range c.extensions[name] {
+			if !visited[ext] {
+				visited[ext] = true
+				members = append(members, ext.Members...)
+			}
+		}
+		name = superclass
+	}
+	return append(members, synthesized(c.types[conformance.Name])...)
+}
+
+// rawValueTypes are the raw types that give an enum a synthesised rawValue
+// and init(rawValue:).
+var rawValueTypes = map[string]bool{"String": true, "Int": true, "UInt": true, "Int8": true, "Int16": true, "Int32": true, "Int64": true, "UInt8": true, "UInt16": true, "UInt32": true, "UInt64": true, "Double": true, "Float": true, "Character": true}
+
+// synthesized returns the members the compiler generates for decls.
+func synthesized(decls []*Decl) []Member {
+	for _, d := range decls {
+		if d.Kind == KindEnum && len(d.Inherits) > 0 && rawValueTypes[d.Inherits[0]] {
+			return []Member{
+				{Kind: MemberVar, Name: "rawValue", Signature: "rawValue", Guard: d.Guard},
+				{Kind: MemberInit, Name: "init", Signature: "init(rawValue:)", Guard: d.Guard},
+			}
+		}
+	}
+	return nil
+}
+
+// homeType returns the module declaring the type name, preferring module.
+func (c *checker) homeType(name, module string) string {
+	decls := c.types[name]
+	for _, d := range decls {
+		if d.Module == module {
+			return module
+		}
+	}
+	if len(decls) > 0 {
+		return decls[0].Module
+	}
+	return module
+}
+
+func (c *checker) conformance(d, proto *Decl) []Issue {
+	platform := c.opts.Platform
+	impls := c.implementations(d)
+	defaults := c.defaults(proto, make(map[*Decl]bool))
+
+	issue := func(kind string, req requirement, msg string) Issue {
+		i := Issue{
+			Kind:           kind,
+			Severity:       defaultSeverity[kind],
+			Protocol:       proto.Name,
+			ProtocolModule: proto.Module,
+			Type:           d.Name,
+			Module:         d.Module,
+			File:           d.File,
+			Line:           d.Line,
+			Requirement:    req.Signature,
+			Message:        msg,
+		}
+		if d.Guard.Conditional() {
+			i.Guard = d.Guard.String()
+		}
+		return i
+	}
+
+	var issues []Issue
+	for _, req := range c.requirements(proto, make(map[*Decl]bool)) {
+		exact := matching(impls, req.Member, platform, true)
+		fallbacks := matching(defaults, req.Member, platform, true)
+		if len(exact) > 0 {
+			witness, ok := genericWitness(exact, req)
+			if !ok {
+				msg := fmt.Sprintf("%s implements %s with %s but %s requires %s", d.Name, req.Signature,
+					describeGenerics(exact[0]), proto.Name, describeGenerics(req.Member))
+				if _, ok := genericWitness(fallbacks, req); ok {
+					msg += "; calls through the protocol use the default in the protocol extension"
+				}
+				issues = append(issues, issue(IssueConstraintMismatch, req, msg))
+				continue
+			}
+			for _, def := range fallbacks {
+				if diffs := differentDefaults(witness, def); len(diffs) > 0 {
+					issues = append(issues, issue(IssueDefaultMismatch, req,
+						fmt.Sprintf("%s.%s and the %s extension default differently (%s); calls through the protocol use the extension's",
+							d.Name, req.Signature, proto.Name, strings.Join(diffs, ", "))))
+					break
+				}
+			}
+		} else {
+			exact = fallbacks
+		}
+		if len(exact) > 0 {
+			if restricted := onlyRestricted(exact, d.Guard, platform); restricted != nil {
+				issues = append(issues, issue(IssuePlatformConditional, req,
+					fmt.Sprintf("%s implements %s.%s only under %s", d.Name, proto.Name, req.Signature, restricted.Guard)))
+			}
+			continue
+		}
+
+		similar := matching(impls, req.Member, platform, false)
+		if len(similar) > 0 {
+			var sigs []string
+			for _, m := range similar {
+				sigs = append(sigs, describe(m))
+			}
+			issues = append(issues, issue(IssueSignatureMismatch, req,
+				fmt.Sprintf("%s has %s but %s requires %s", d.Name, strings.Join(sigs, ", "), proto.Name, describe(req.Member))))
+			continue
+		}
+		issues = append(issues, issue(IssueMissing, req,
+			fmt.Sprintf("%s does not implement %s required by %s", d.Name, describe(req.Member), req.protocol.Name)))
+	}
+	return issues
+}
+
+// matching returns the members active on platform that satisfy req exactly
+// (same signature and staticness) or, when exact is false, that share its
+// name and kind.
+func matching(members []Member, req Member, platform string, exact bool) []Member {
+	var found []Member
+	for _, m := range members {
+		if !m.Guard.ActiveOn(platform) || m.Name != req.Name || !compatibleKind(m.Kind, req.Kind) {
+			continue
+		}
+		if exact && (m.Signature != req.Signature || m.Static != req.Static) {
+			continue
+		}
+		found = append(found, m)
+	}
+	return found
+}
+
+// genericWitness returns the first of the candidates whose generic
+// signature can satisfy req, and false when none can.
+func genericWitness(candidates []Member, req requirement) (Member, bool) {
+	want := shape(req.Member)
+	associated := associatedNames(req.protocol)
+	for _, m := range candidates {
+		if witnesses(shape(m), want, associated) {
+			return m, true
+		}
+	}
+	return Member{}, false
+}
+
+// compatibleKind reports whether a member of kind impl can satisfy a
+// requirement of kind req: enum cases satisfy static properties and
+// functions.
+func compatibleKind(impl, req string) bool {
+	return impl == req || (impl == MemberCase && (req == MemberVar || req == MemberFunc))
+}
+
+// onlyRestricted returns one of the implementations when all of them are
+// guarded more narrowly than the conformance, which is then only complete
+// on some platforms or OS versions.
+func onlyRestricted(impls []Member, conformance Guard, platform string) *Member {
+	for i := range impls {
+		if !impls[i].Guard.Restricted(platform) || impls[i].Guard.String() == conformance.String() {
+			return nil
+		}
+	}
+	return &impls[0]
+}
+
+func describe(m Member) string {
+	s := m.Kind + " " + m.Signature
+	if m.Static {
+		s = "static " + s
+	}
+	return s
+}
@@ -0,0 +1,145 @@
+package protocols
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/configschema"
+)
+
+// Severities accepted in a config file.
+var severities = map[string]bool{"error": true, "warning": true, "info": true}
+
+// Config selects which issues the checker reports, read from
+// protocolanalyzer.yaml:
+//
+//	issues:
+//	  duplicate: false
+//	exclude:
+//	  protocols: [NSObjectProtocol, "*Delegate"]
+//	  modules: [UmbraMocks]
+//	severity:
+//	  XPCServiceProtocolStandard: warning
+//
+// Protocol names and modules may be path.Match globs. For severity, an
+// exact protocol name wins over globs and the longest glob over shorter
+// ones.
+type Config struct {
+	// Issues enables or disables issue kinds; kinds not listed stay enabled.
+	Issues   map[string]bool   `yaml:"issues"`
+	Exclude  Exclude           `yaml:"exclude"`
+	Severity map[string]string `yaml:"severity"`
+}
+
+// Exclude lists protocols never checked, by name or by declaring module.
+type Exclude struct {
+	Protocols []string `yaml:"protocols"`
+	Modules   []string `yaml:"modules"`
+}
+
+// LoadConfig reads and validates a config file.
+func LoadConfig(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := configschema.Validate(configschema.KindProtocolAnalyzer, file, data); err != nil {
+		return nil, err
+	}
+
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for _, kind := range sortedKeys(c.Issues) {
+		if _, ok := defaultSeverity[kind]; !ok {
+			return nil, fmt.Errorf("%s: unknown issue type %q", file, kind)
+		}
+	}
+	for _, proto := range sortedKeys(c.Severity) {
+		if sev := c.Severity[proto]; !severities[sev] {
+			return nil, fmt.Errorf("%s: protocol %s: unknown severity %q (want error, warning or info)", file, proto, sev)
+		}
+	}
+	for _, pattern := range append(append([]string(nil), c.Exclude.Protocols...), c.Exclude.Modules...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s: bad pattern %q: %w", file, pattern, err)
+		}
+	}
+	return &c, nil
+}
+
+// Apply drops the issues of disabled kinds and excluded protocols and sets
+// the configured severities. A nil config keeps every issue.
+func (c *Config) Apply(issues []Issue) []Issue {
+	if c == nil {
+		return issues
+	}
+	kept := issues[:0]
+	for _, i := range issues {
+		if enabled, ok := c.Issues[i.Kind]; ok && !enabled {
+			continue
+		}
+		if c.Excluded(i.Protocol, i.ProtocolModule) {
+			continue
+		}
+		if sev := c.severity(i.Protocol); sev != "" {
+			i.Severity = sev
+		}
+		kept = append(kept, i)
+	}
+	return kept
+}
+
+// Excluded reports whether the config excludes the protocol name declared
+// in module. A nil config excludes nothing.
+func (c *Config) Excluded(name, module string) bool {
+	if c == nil {
+		return false
+	}
+	return matchAny(c.Exclude.Protocols, name) || matchAny(c.Exclude.Modules, module)
+}
+
+func (c *Config) severity(protocol string) string {
+	if sev, ok := c.Severity[protocol]; ok {
+		return sev
+	}
+	patterns := make([]string, 0, len(c.Severity))
+	for p := range c.Severity {
+		patterns = append(patterns, p)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, protocol); ok {
+			return c.Severity[p]
+		}
+	}
+	return ""
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
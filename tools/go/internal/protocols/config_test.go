@@ -0,0 +1,72 @@
+package protocols
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testfixture"
+)
+
+// A config with several bad entries names the same one on every load.
+func TestConfigErrorsAreReproducible(t *testing.T) {
+	for name, config := range map[string]string{
+		"issues":   "issues:\n  orphaned: false\n  shadowed: true\n  mislaid: false\n",
+		"severity": "severity:\n  CryptoProvider: fatal\n  KeyStore: loud\n  XPCService: critical\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			root := testfixture.Write(t, map[string]string{"protocolanalyzer.yaml": config})
+			testfixture.Reproducible(t, func() []byte {
+				_, err := LoadConfig(filepath.Join(root, "protocolanalyzer.yaml"))
+				if err == nil {
+					t.Fatal("LoadConfig accepted a bad config")
+				}
+				return []byte(err.Error())
+			})
+		})
+	}
+}
+
+const vaultProtocols = `public protocol KeyStoreProtocol {
+    func key(named: String) -> Data
+    func store(_ key: Data, named: String)
+}
+`
+
+const vaultConformers = `struct MemoryKeyStore: KeyStoreProtocol {
+    func key(named: String) -> Data { Data() }
+}
+
+struct DiskKeyStore: KeyStoreProtocol {
+}
+`
+
+// TestReportIsReproducible checks a tree whose issues the config gives
+// severities through globs of the same length, so only their order picks
+// the one that applies.
+func TestReportIsReproducible(t *testing.T) {
+	root := testfixture.Write(t, map[string]string{
+		"Sources/Vault/KeyStore.swift":  vaultProtocols,
+		"Sources/Vault/KeyStores.swift": vaultConformers,
+		"Sources/Legacy/KeyStore.swift": vaultProtocols,
+		"protocolanalyzer.yaml":         "severity:\n  \"KeyStore*\": info\n  \"*Protocol\": warning\n",
+	})
+	testfixture.Reproducible(t, func() []byte {
+		config, err := LoadConfig(filepath.Join(root, "protocolanalyzer.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ix, err := Build(root, "Sources")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		if err := WriteMarkdown(&b, ix, config.Apply(Check(ix, Options{})), Options{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteCoverageMarkdown(&b, ComputeCoverage(ix, Options{}, config), Options{}); err != nil {
+			t.Fatal(err)
+		}
+		return b.Bytes()
+	})
+}
@@ -0,0 +1,183 @@
+package protocols
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Coverage statuses.
+const (
+	// CoverageDead is a protocol nothing conforms to, directly or through
+	// a protocol refining it.
+	CoverageDead = "dead"
+	// CoverageSingle is a protocol with exactly one conformer, declared in
+	// the protocol's own module: a candidate for removal or for collapsing
+	// into the concrete type.
+	CoverageSingle = "single"
+	// CoverageUsed is every other protocol.
+	CoverageUsed = "used"
+)
+
+// Conformer is a type declaring conformance to a protocol, in its own
+// declaration or in an extension.
+type Conformer struct {
+	Type   string `json:"type"`
+	Module string `json:"module"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	// Via names the refining protocol the type conforms through, when it
+	// does not conform directly.
+	Via string `json:"via,omitempty"`
+}
+
+// Coverage lists the conformers of one protocol.
+type Coverage struct {
+	Protocol string `json:"protocol"`
+	Module   string `json:"module"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Status   string `json:"status"`
+	// Conformers include the conformers of refining protocols.
+	Conformers []Conformer `json:"conformers"`
+	// Refinements are the protocols inheriting from this one.
+	Refinements []string `json:"refinements,omitempty"`
+	// Fingerprint is set by umbratool for dead and single-conformer protocols.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// ComputeCoverage finds the conformers of every indexed protocol that the
+// config does not exclude, sorted by module and protocol. Protocols used
+// only as existential types, or adopted only by code outside the scanned
+// directories, count as dead.
+func ComputeCoverage(ix *Index, opts Options, config *Config) []Coverage {
+	c := newChecker(ix, opts)
+
+	direct := make(map[*Decl][]Conformer)
+	refinements := make(map[*Decl][]*Decl)
+	for _, d := range ix.Decls {
+		if !d.Guard.ActiveOn(opts.Platform) {
+			continue
+		}
+		for _, name := range d.Inherits {
+			proto := c.resolve(name, d)
+			if proto == nil || proto == d {
+				continue
+			}
+			if d.Kind == KindProtocol {
+				refinements[proto] = append(refinements[proto], d)
+				continue
+			}
+			typeName := d.Name
+			if _, rest, ok := strings.Cut(typeName, "."); ok && d.Kind == KindExtension && c.isModuleQualified(typeName) {
+				typeName = rest
+			}
+			direct[proto] = append(direct[proto], Conformer{Type: typeName, Module: d.Module, File: d.File, Line: d.Line})
+		}
+	}
+
+	var out []Coverage
+	for _, p := range ix.Decls {
+		if p.Kind != KindProtocol || !p.Guard.ActiveOn(opts.Platform) || config.Excluded(p.Name, p.Module) {
+			continue
+		}
+		cov := Coverage{Protocol: p.Name, Module: p.Module, File: p.File, Line: p.Line}
+		seen := make(map[string]bool)
+		var collect func(proto *Decl, via string, visited map[*Decl]bool)
+		collect = func(proto *Decl, via string, visited map[*Decl]bool) {
+			if visited[proto] {
+				return
+			}
+			visited[proto] = true
+			for _, conf := range direct[proto] {
+				if key := conf.Module + "." + conf.Type; !seen[key] {
+					seen[key] = true
+					conf.Via = via
+					cov.Conformers = append(cov.Conformers, conf)
+				}
+			}
+			for _, r := range refinements[proto] {
+				next := via
+				if next == "" {
+					next = r.Name
+				}
+				collect(r, next, visited)
+			}
+		}
+		collect(p, "", make(map[*Decl]bool))
+		for _, r := range refinements[p] {
+			cov.Refinements = append(cov.Refinements, r.Name)
+		}
+		sort.Strings(cov.Refinements)
+
+		switch {
+		case len(cov.Conformers) == 0:
+			cov.Status = CoverageDead
+		case len(cov.Conformers) == 1 && cov.Conformers[0].Module == p.Module:
+			cov.Status = CoverageSingle
+		default:
+			cov.Status = CoverageUsed
+		}
+		out = append(out, cov)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Module != out[j].Module {
+			return out[i].Module < out[j].Module
+		}
+		return out[i].Protocol < out[j].Protocol
+	})
+	return out
+}
+
+// WriteCoverageMarkdown writes the dead and single-conformer protocols,
+// then every protocol with its conformer count.
+func WriteCoverageMarkdown(w io.Writer, coverage []Coverage, opts Options) error {
+	counts := make(map[string]int)
+	for _, c := range coverage {
+		counts[c.Status]++
+	}
+
+	var b strings.Builder
+	b.WriteString("# Protocol Coverage Report\n\n")
+	if opts.Platform != "" {
+		fmt.Fprintf(&b, "Platform: **%s**\n\n", opts.Platform)
+	}
+	fmt.Fprintf(&b, "**%d protocols: %d dead, %d with a single conformer in their own module**\n",
+		len(coverage), counts[CoverageDead], counts[CoverageSingle])
+
+	if counts[CoverageDead] > 0 {
+		b.WriteString("\n## Dead Protocols\n\nNo type conforms to these protocols, directly or through a refinement.\n\n")
+		for _, c := range coverage {
+			if c.Status == CoverageDead {
+				fmt.Fprintf(&b, "- `%s` (%s, %s:%d)\n", c.Protocol, c.Module, c.File, c.Line)
+			}
+		}
+	}
+	if counts[CoverageSingle] > 0 {
+		b.WriteString("\n## Single Conformer\n\nEach of these protocols is adopted by one type in its own module; consider removing the protocol or collapsing it into the type.\n\n")
+		for _, c := range coverage {
+			if c.Status == CoverageSingle {
+				conf := c.Conformers[0]
+				fmt.Fprintf(&b, "- `%s` (%s:%d) — only `%s` (%s:%d)", c.Protocol, c.File, c.Line, conf.Type, conf.File, conf.Line)
+				if conf.Via != "" {
+					fmt.Fprintf(&b, " through `%s`", conf.Via)
+				}
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	if len(coverage) > 0 {
+		b.WriteString("\n## All Protocols\n\n")
+		b.WriteString("| Module | Protocol | Conformers | Refinements | Status |\n")
+		b.WriteString("|--------|----------|------------|-------------|--------|\n")
+		for _, c := range coverage {
+			fmt.Fprintf(&b, "| %s | %s | %d | %d | %s |\n", c.Module, c.Protocol, len(c.Conformers), len(c.Refinements), c.Status)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,335 @@
+package protocols
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var (
+	identPattern = regexp.MustCompile(`[A-Za-z_]\w*`)
+	wherePattern = regexp.MustCompile(`(?:^|[\s)>])where\s+(.*)$`)
+	// typeAttributes are the attributes and ownership modifiers a parameter
+	// type may start with.
+	typeAttributes = regexp.MustCompile(`^(?:@\w+(?:\([^)]*\))?\s+|inout\s+|borrowing\s+|consuming\s+|__owned\s+|__shared\s+|isolated\s+|sending\s+)+`)
+)
+
+// implied lists the standard protocols a conformance implies, so that an
+// implementation constrained to Equatable witnesses a requirement
+// constrained to Hashable.
+var implied = map[string][]string{
+	"Hashable":                   {"Equatable"},
+	"Comparable":                 {"Equatable"},
+	"Strideable":                 {"Comparable", "Equatable"},
+	"Codable":                    {"Encodable", "Decodable"},
+	"Error":                      {"Sendable"},
+	"Collection":                 {"Sequence"},
+	"BidirectionalCollection":    {"Collection", "Sequence"},
+	"RandomAccessCollection":     {"BidirectionalCollection", "Collection", "Sequence"},
+	"MutableCollection":          {"Collection", "Sequence"},
+	"RangeReplaceableCollection": {"Collection", "Sequence"},
+	"StringProtocol":             {"BidirectionalCollection", "Collection", "Sequence", "Comparable", "Hashable", "Equatable"},
+	"BinaryInteger":              {"Numeric", "Hashable", "Equatable", "Comparable", "Strideable"},
+	"FixedWidthInteger":          {"BinaryInteger", "Numeric", "Hashable", "Equatable", "Comparable", "Strideable"},
+	"SignedInteger":              {"BinaryInteger", "Numeric", "Hashable", "Equatable", "Comparable", "Strideable"},
+	"UnsignedInteger":            {"BinaryInteger", "Numeric", "Hashable", "Equatable", "Comparable", "Strideable"},
+	"FloatingPoint":              {"Numeric", "Hashable", "Equatable", "Comparable", "Strideable"},
+	"BinaryFloatingPoint":        {"FloatingPoint", "Numeric", "Hashable", "Equatable", "Comparable", "Strideable"},
+	"Numeric":                    {"Equatable"},
+}
+
+// genericClause reads a generic parameter clause such as
+// "<T: Codable & Sendable, U>" into its parameter names and constraints.
+func genericClause(clause string) ([]string, []string) {
+	clause = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(clause), "<"), ">")
+	var names, constraints []string
+	for _, part := range splitTopLevel(clause) {
+		part = strings.TrimPrefix(strings.TrimSpace(part), "each ")
+		name, bound, hasBound := strings.Cut(part, ":")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		names = append(names, name)
+		if hasBound {
+			constraints = append(constraints, constraint(name+": "+bound)...)
+		}
+	}
+	return names, constraints
+}
+
+// whereClause returns the constraints of the where clause in rest, the
+// part of a declaration after its parameter list, up to the body.
+func whereClause(rest string) []string {
+	m := wherePattern.FindStringSubmatch(strings.TrimSpace(rest))
+	if m == nil {
+		return nil
+	}
+	clause := m[1]
+	if i := topLevelIndex(clause, '{'); i >= 0 {
+		clause = clause[:i]
+	}
+	var constraints []string
+	for _, part := range splitTopLevel(clause) {
+		constraints = append(constraints, constraint(part)...)
+	}
+	return constraints
+}
+
+// constraint normalises one requirement, splitting "T: A & B" into
+// "T: A" and "T: B" and spacing "T==U" as "T == U".
+func constraint(req string) []string {
+	req = strings.Join(strings.Fields(req), " ")
+	if lhs, rhs, ok := strings.Cut(req, "=="); ok {
+		return []string{strings.TrimSpace(lhs) + " == " + strings.TrimSpace(rhs)}
+	}
+	lhs, rhs, ok := strings.Cut(req, ":")
+	if !ok {
+		return nil
+	}
+	var out []string
+	for _, bound := range splitComposition(rhs) {
+		out = append(out, strings.TrimSpace(lhs)+": "+bound)
+	}
+	return out
+}
+
+// splitComposition splits a protocol composition "A & B<C>" at its
+// top-level ampersands.
+func splitComposition(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	flush := func(end int) {
+		if part := strings.TrimSpace(s[start:end]); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	for i, r := range s {
+		switch r {
+		case '(', '[', '<':
+			depth++
+		case ')', ']', '>':
+			depth--
+		case '&':
+			if depth == 0 {
+				flush(i)
+				start = i + 1
+			}
+		}
+	}
+	flush(len(s))
+	return parts
+}
+
+// topLevelIndex returns the index of the first r in s outside brackets, or
+// -1.
+func topLevelIndex(s string, r rune) int {
+	depth := 0
+	for i, c := range s {
+		switch {
+		case c == r && depth == 0:
+			return i
+		case c == '(' || c == '[' || c == '<':
+			depth++
+		case c == ')' || c == ']' || c == '>':
+			depth--
+		}
+	}
+	return -1
+}
+
+// parameters reads a parameter list, without its parentheses.
+func parameters(inner string) []Param {
+	var params []Param
+	for _, part := range splitTopLevel(inner) {
+		part = strings.TrimSpace(part)
+		head, rest, ok := strings.Cut(part, ":")
+		fields := strings.Fields(head)
+		if !ok || len(fields) == 0 {
+			continue
+		}
+		p := Param{Label: fields[0], Name: fields[len(fields)-1], Type: strings.TrimSpace(rest)}
+		// Types never contain "=", so the first one starts the default.
+		if i := strings.IndexByte(p.Type, '='); i >= 0 {
+			p.Type, p.Default = strings.TrimSpace(p.Type[:i]), strings.Join(strings.Fields(p.Type[i+1:]), " ")
+		}
+		params = append(params, p)
+	}
+	return params
+}
+
+// genericShape is the generic signature of a function or initialiser with
+// its generic parameters renamed by position, so that "<T: P>(_ x: T)",
+// "<U: P>(_ x: U)" and "(_ x: some P)" have the same shape. Parameters are
+// numbered in the order the parameter list first uses them.
+type genericShape struct {
+	params      int
+	constraints []string
+}
+
+func shape(m Member) genericShape {
+	declared := make(map[string]bool, len(m.GenericParams))
+	for _, name := range m.GenericParams {
+		declared[name] = true
+	}
+	renamed := make(map[string]string)
+	n := 0
+	next := func() string {
+		n++
+		return fmt.Sprintf("τ%d", n-1)
+	}
+
+	var constraints []string
+	for _, p := range m.Params {
+		t := typeAttributes.ReplaceAllString(p.Type, "")
+		if bound, ok := strings.CutPrefix(t, "some "); ok {
+			name := next()
+			for _, b := range splitComposition(bound) {
+				constraints = append(constraints, name+": "+b)
+			}
+			continue
+		}
+		for _, ident := range identsOutsideMembers(t) {
+			if declared[ident] && renamed[ident] == "" {
+				renamed[ident] = next()
+			}
+		}
+	}
+	for _, name := range m.GenericParams {
+		if renamed[name] == "" {
+			renamed[name] = next()
+		}
+	}
+
+	for _, c := range m.Constraints {
+		constraints = append(constraints, rename(c, renamed))
+	}
+	sort.Strings(constraints)
+	return genericShape{params: n, constraints: slices.Compact(constraints)}
+}
+
+// identsOutsideMembers returns the identifiers of a type that are not
+// member names, so that "T.Element" yields only "T".
+func identsOutsideMembers(t string) []string {
+	var out []string
+	for _, loc := range identPattern.FindAllStringIndex(t, -1) {
+		if loc[0] == 0 || t[loc[0]-1] != '.' {
+			out = append(out, t[loc[0]:loc[1]])
+		}
+	}
+	return out
+}
+
+// rename replaces the generic parameter names in a constraint.
+func rename(c string, names map[string]string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range identPattern.FindAllStringIndex(c, -1) {
+		ident := c[loc[0]:loc[1]]
+		if to, ok := names[ident]; ok && (loc[0] == 0 || c[loc[0]-1] != '.') {
+			b.WriteString(c[last:loc[0]])
+			b.WriteString(to)
+			last = loc[1]
+		}
+	}
+	b.WriteString(c[last:])
+	return b.String()
+}
+
+// witnesses reports whether an implementation of shape impl can satisfy a
+// requirement of shape req. The implementation must be generic over as
+// many parameters, and may be less constrained but not more: each of its
+// constraints must be one of the requirement's or implied by one.
+// Constraints of the requirement on associated types and Self are
+// satisfied by whatever the conformer binds them to, so implementation
+// constraints on the same left-hand side are accepted.
+func witnesses(impl, req genericShape, associated map[string]bool) bool {
+	if impl.params != req.params {
+		return false
+	}
+	have := make(map[string]bool)
+	open := make(map[string]bool)
+	for _, c := range req.constraints {
+		have[c] = true
+		lhs, bound := splitConstraint(c)
+		for _, p := range implied[bound] {
+			have[lhs+": "+p] = true
+		}
+		for _, ident := range identsOutsideMembers(c) {
+			if associated[ident] {
+				open[lhs] = true
+			}
+		}
+	}
+	for _, c := range impl.constraints {
+		if lhs, _ := splitConstraint(c); !have[c] && !open[lhs] {
+			return false
+		}
+	}
+	return true
+}
+
+// splitConstraint returns the left-hand side of a constraint and, for a
+// conformance, the protocol.
+func splitConstraint(c string) (lhs, bound string) {
+	if l, _, ok := strings.Cut(c, " == "); ok {
+		return l, ""
+	}
+	l, r, _ := strings.Cut(c, ": ")
+	return l, r
+}
+
+// associatedNames returns the names a requirement declared in proto may
+// refer to without the conformer spelling them the same way: Self and the
+// protocol's associated types.
+func associatedNames(proto *Decl) map[string]bool {
+	names := map[string]bool{"Self": true}
+	for _, m := range proto.Members {
+		if m.Kind == MemberAssociatedType {
+			names[m.Name] = true
+		}
+	}
+	return names
+}
+
+// describeGenerics renders a member's generic signature as written, e.g.
+// "<T: Codable> where T: Sendable", or "no generic parameters".
+func describeGenerics(m Member) string {
+	var params []string
+	params = append(params, m.GenericParams...)
+	for _, p := range m.Params {
+		if t := typeAttributes.ReplaceAllString(p.Type, ""); strings.HasPrefix(t, "some ") {
+			params = append(params, t)
+		}
+	}
+	if len(params) == 0 && len(m.Constraints) == 0 {
+		return "no generic parameters"
+	}
+	s := "<" + strings.Join(params, ", ") + ">"
+	if len(m.Constraints) > 0 {
+		s += " where " + strings.Join(m.Constraints, ", ")
+	}
+	return s
+}
+
+// differentDefaults returns the parameters to which impl and def, an
+// overload in a protocol extension with the same signature, give
+// different default values, described as "label: impl vs def". String
+// literals are compared only by being strings: the index does not keep
+// their contents.
+func differentDefaults(impl, def Member) []string {
+	var out []string
+	for i, p := range impl.Params {
+		if i >= len(def.Params) {
+			break
+		}
+		d := def.Params[i].Default
+		if p.Default == "" || d == "" || p.Default == d {
+			continue
+		}
+		out = append(out, fmt.Sprintf("%s: %s vs %s", p.Label, p.Default, d))
+	}
+	return out
+}
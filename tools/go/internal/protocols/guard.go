@@ -0,0 +1,249 @@
+package protocols
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Guard records the conditional-compilation branches and availability
+// attributes a declaration sits under.
+type Guard struct {
+	// Conditions are the #if branch conditions enclosing the declaration,
+	// outermost first. Branches taken after an earlier one are written with
+	// the earlier conditions negated, e.g. "!(os(macOS))" for #else.
+	Conditions []string `json:"conditions,omitempty"`
+	// Available holds the platform entries of @available attributes, e.g.
+	// "macOS 14" or "iOS unavailable". The "*" wildcard is omitted.
+	Available []string `json:"available,omitempty"`
+}
+
+// tri is a three-valued truth value: a condition can depend on build
+// settings such as DEBUG that the analysis cannot know.
+type tri int
+
+const (
+	triFalse tri = iota
+	triUnknown
+	triTrue
+)
+
+var availablePattern = regexp.MustCompile(`@available\s*\(([^)]*)\)`)
+
+// parseAvailable returns the platform entries of every @available attribute
+// in code. Entries that do not restrict platforms (deprecations, "*", or
+// renamed/message arguments) are dropped.
+func parseAvailable(code string) []string {
+	var entries []string
+	for _, m := range availablePattern.FindAllStringSubmatch(code, -1) {
+		args := strings.Split(m[1], ",")
+		first := strings.TrimSpace(args[0])
+		switch {
+		case first == "*":
+			// @available(*, unavailable) removes the declaration everywhere.
+			for _, a := range args[1:] {
+				if strings.TrimSpace(a) == "unavailable" {
+					entries = append(entries, "* unavailable")
+				}
+			}
+		case len(args) > 1 && !strings.Contains(first, " ") && !strings.Contains(m[1], "*"):
+			// Long form: @available(iOS, unavailable) or
+			// @available(macOS, introduced: 14).
+			for _, a := range args[1:] {
+				a = strings.TrimSpace(a)
+				switch {
+				case a == "unavailable":
+					entries = append(entries, first+" unavailable")
+				case strings.HasPrefix(a, "introduced:"):
+					entries = append(entries, first+" "+strings.TrimSpace(strings.TrimPrefix(a, "introduced:")))
+				}
+			}
+		default:
+			// Shorthand: @available(macOS 14, iOS 17, *).
+			for _, a := range args {
+				if a = strings.TrimSpace(a); a != "*" && a != "" {
+					entries = append(entries, a)
+				}
+			}
+		}
+	}
+	return entries
+}
+
+// Conditional reports whether the guard restricts the declaration at all.
+func (g Guard) Conditional() bool {
+	return len(g.Conditions) > 0 || len(g.Available) > 0
+}
+
+// ActiveOn reports whether the declaration may be compiled and available
+// on platform. An empty platform means "any platform".
+func (g Guard) ActiveOn(platform string) bool {
+	return g.eval(platform) != triFalse
+}
+
+// Restricted reports whether the declaration is not known to be available
+// everywhere on platform (any platform when empty): it is under an #if
+// that cannot be resolved, or needs a minimum OS version.
+func (g Guard) Restricted(platform string) bool {
+	if g.eval(platform) != triTrue {
+		return true
+	}
+	for _, a := range g.Available {
+		name, version, _ := strings.Cut(a, " ")
+		if version != "unavailable" && (platform == "" || samePlatform(name, platform)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g Guard) eval(platform string) tri {
+	result := triTrue
+	for _, a := range g.Available {
+		name, version, _ := strings.Cut(a, " ")
+		if version != "unavailable" {
+			continue
+		}
+		if name == "*" || (platform != "" && samePlatform(name, platform)) {
+			return triFalse
+		}
+		if platform == "" {
+			result = triUnknown
+		}
+	}
+	for _, c := range g.Conditions {
+		result = and(result, evalCondition(c, platform))
+	}
+	return result
+}
+
+// String describes the guard, e.g. "#if os(macOS), @available(macOS 14)".
+func (g Guard) String() string {
+	var parts []string
+	if len(g.Conditions) > 0 {
+		parts = append(parts, "#if "+strings.Join(g.Conditions, " && "))
+	}
+	if len(g.Available) > 0 {
+		parts = append(parts, "@available("+strings.Join(g.Available, ", ")+")")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// nested returns the guard of a declaration inside one guarded by g. The
+// inner #if conditions already include the enclosing ones, since both come
+// from the same file; availability is inherited.
+func (g Guard) nested(inner Guard) Guard {
+	return Guard{
+		Conditions: inner.Conditions,
+		Available:  append(append([]string(nil), g.Available...), inner.Available...),
+	}
+}
+
+var platformAliases = map[string]string{"osx": "macos", "macosapplicationextension": "macos", "iosapplicationextension": "ios"}
+
+func normalisePlatform(p string) string {
+	p = strings.ToLower(strings.TrimSpace(p))
+	if alias, ok := platformAliases[p]; ok {
+		return alias
+	}
+	return p
+}
+
+func samePlatform(a, b string) bool {
+	return normalisePlatform(a) == normalisePlatform(b)
+}
+
+// evalCondition evaluates an #if condition for platform. Only os(...) is
+// decidable, and only when a platform is given; everything else (DEBUG,
+// canImport, swift(>=...)) is unknown.
+func evalCondition(cond, platform string) tri {
+	p := &condParser{tokens: tokenise(cond), platform: platform}
+	v := p.or()
+	if p.pos != len(p.tokens) {
+		return triUnknown
+	}
+	return v
+}
+
+var condToken = regexp.MustCompile(`&&|\|\||[!()]|[A-Za-z_][A-Za-z0-9_]*\s*\([^()]*\)|[A-Za-z_][A-Za-z0-9_]*|\S`)
+
+func tokenise(s string) []string {
+	return condToken.FindAllString(s, -1)
+}
+
+type condParser struct {
+	tokens   []string
+	pos      int
+	platform string
+}
+
+func (p *condParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *condParser) or() tri {
+	v := p.and()
+	for p.peek() == "||" {
+		p.pos++
+		v = or(v, p.and())
+	}
+	return v
+}
+
+func (p *condParser) and() tri {
+	v := p.unary()
+	for p.peek() == "&&" {
+		p.pos++
+		v = and(v, p.unary())
+	}
+	return v
+}
+
+func (p *condParser) unary() tri {
+	switch tok := p.peek(); tok {
+	case "!":
+		p.pos++
+		return not(p.unary())
+	case "(":
+		p.pos++
+		v := p.or()
+		if p.peek() == ")" {
+			p.pos++
+		}
+		return v
+	case "":
+		return triUnknown
+	default:
+		p.pos++
+		return p.atom(tok)
+	}
+}
+
+func (p *condParser) atom(tok string) tri {
+	name, arg, ok := strings.Cut(tok, "(")
+	if !ok {
+		return triUnknown
+	}
+	arg = strings.TrimSuffix(strings.TrimSpace(arg), ")")
+	if strings.TrimSpace(name) == "os" && p.platform != "" {
+		if samePlatform(arg, p.platform) {
+			return triTrue
+		}
+		return triFalse
+	}
+	return triUnknown
+}
+
+func not(v tri) tri {
+	return triTrue - v
+}
+
+func and(a, b tri) tri {
+	return min(a, b)
+}
+
+func or(a, b tri) tri {
+	return max(a, b)
+}
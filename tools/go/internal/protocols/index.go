@@ -0,0 +1,625 @@
+// Package protocols indexes the Swift protocols declared in the UmbraCore
+// tree, the types conforming to them and their members, and checks that
+// every conformance implements its requirements.
+package protocols
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Declaration kinds.
+const (
+	KindProtocol  = "protocol"
+	KindClass     = "class"
+	KindStruct    = "struct"
+	KindEnum      = "enum"
+	KindActor     = "actor"
+	KindExtension = "extension"
+)
+
+// Member kinds.
+const (
+	MemberFunc           = "func"
+	MemberVar            = "var"
+	MemberInit           = "init"
+	MemberSubscript      = "subscript"
+	MemberCase           = "case"
+	MemberAssociatedType = "associatedtype"
+	MemberTypeAlias      = "typealias"
+)
+
+// Member is a declaration directly inside a type, extension or protocol.
+type Member struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Signature is the name with argument labels, e.g. "encrypt(_:key:)"
+	// for functions and initialisers, and the name otherwise.
+	Signature string `json:"signature"`
+	Static    bool   `json:"static,omitempty"`
+	// Optional marks @objc optional protocol requirements.
+	Optional bool `json:"optional,omitempty"`
+	// ObjC is set for members marked @objc; ObjCName is the explicit
+	// selector of @objc(name), if any.
+	ObjC     bool   `json:"objc,omitempty"`
+	ObjCName string `json:"objcName,omitempty"`
+	// Params are the parameters of functions and initialisers.
+	Params []Param `json:"params,omitempty"`
+	// Effects are the effects of functions and initialisers, such as
+	// "async throws", and Returns the result type of functions; both are
+	// empty when absent.
+	Effects string `json:"effects,omitempty"`
+	Returns string `json:"returns,omitempty"`
+	// GenericParams are the declared generic parameters of functions and
+	// initialisers, e.g. ["T"] for "func f<T: Codable>(_ x: T)".
+	GenericParams []string `json:"genericParams,omitempty"`
+	// Constraints are the requirements of the generic clause and the where
+	// clause, one per conformance or same-type requirement, e.g.
+	// "T: Codable" and "T.Element == String".
+	Constraints []string `json:"constraints,omitempty"`
+	Line        int      `json:"line"`
+	Guard       Guard    `json:"guard"`
+	Text        string   `json:"text"`
+}
+
+// Param is one parameter of a function or initialiser.
+type Param struct {
+	// Label is the argument label, "_" for none, and Name the name the
+	// body uses, the label itself unless a separate name follows it.
+	Label   string `json:"label"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Default string `json:"default,omitempty"`
+}
+
+// Decl is a protocol, type or extension declaration.
+type Decl struct {
+	Kind string `json:"kind"`
+	// Name is qualified by enclosing types, e.g. "Outer.Inner".
+	Name   string `json:"name"`
+	Module string `json:"module"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	// EndLine is the line closing the declaration's body, or 0 when the
+	// body was never opened.
+	EndLine  int      `json:"endLine,omitempty"`
+	Inherits []string `json:"inherits"`
+	Members  []Member `json:"members"`
+	Guard    Guard    `json:"guard"`
+	// ObjC is set for declarations marked @objc; ObjCName is the
+	// Objective-C name given by @objc(Name), if any.
+	ObjC     bool   `json:"objc,omitempty"`
+	ObjCName string `json:"objcName,omitempty"`
+}
+
+// Index holds every declaration found in the scanned tree.
+type Index struct {
+	Decls []*Decl
+	// Imports maps each file to the modules it imports.
+	Imports map[string]map[string]bool
+}
+
+var (
+	typeDeclPattern = regexp.MustCompile(`^(?:(?:public|open|package|internal|fileprivate|private|final|indirect|nonisolated|distributed|@[A-Za-z_]\w*(?:\([^)]*\))?)\s+)*(protocol|class|struct|enum|actor|extension)\s+([A-Za-z_][\w.]*)\s*(?:<[^{]*?>)?\s*(?::\s*([^{]*?))?\s*(?:\bwhere\b[^{]*)?(?:\{.*)?$`)
+	funcPattern     = regexp.MustCompile(`(?:^|\s)func\s+([^\s(<]+)\s*(<[^(]*>)?\s*\(`)
+	initPattern     = regexp.MustCompile(`(?:^|\s)(init)[?!]?\s*(<[^(]*>)?\s*\(`)
+	subscriptPat    = regexp.MustCompile(`(?:^|\s)(subscript)\s*(?:<[^(]*>)?\s*\(`)
+	varPattern      = regexp.MustCompile(`(?:^|\s)(?:var|let)\s+([A-Za-z_]\w*)`)
+	casePattern     = regexp.MustCompile(`^(?:indirect\s+)?case\s+(.+)$`)
+	aliasPattern    = regexp.MustCompile(`^(?:(?:public|package|internal|fileprivate|private)\s+)?(associatedtype|typealias)\s+([A-Za-z_]\w*)`)
+	staticPattern   = regexp.MustCompile(`(?:^|\s)(?:static|class)\s+(?:\w+\s+)*(?:func|var|let|subscript)\b`)
+	attrOnlyPattern = regexp.MustCompile(`^(?:@[A-Za-z_]\w*(?:\([^)]*\))?\s*)+$`)
+	optionalPattern = regexp.MustCompile(`(?:^|\s)optional\s`)
+	objcPattern     = regexp.MustCompile(`@objc\b(?:\(\s*([^)]*?)\s*\))?`)
+	identStart      = regexp.MustCompile(`^[A-Za-z_]\w*`)
+)
+
+// notTypeNames are words that follow "class" as a modifier rather than
+// starting a class declaration.
+var notTypeNames = map[string]bool{"func": true, "var": true, "let": true, "subscript": true, "init": true, "override": true, "final": true, "open": true, "public": true}
+
+// Build scans the Swift files below the given top-level directories of
+// root (default "Sources").
+func Build(root string, dirs ...string) (*Index, error) {
+	if len(dirs) == 0 {
+		dirs = []string{"Sources"}
+	}
+
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return BuildFiles(root, paths)
+}
+
+// BuildFiles indexes the given Swift files, relative to root.
+func BuildFiles(root string, paths []string) (*Index, error) {
+	paths = slices.Clone(paths)
+	sort.Strings(paths)
+
+	type parsed struct {
+		decls   []*Decl
+		imports map[string]bool
+	}
+	results, err := pool.Map(paths, func(rel string) (parsed, error) {
+		f, err := os.Open(filepath.Join(root, rel))
+		if err != nil {
+			return parsed{}, err
+		}
+		defer f.Close()
+		decls, imps, err := Parse(f, rel, workspace.ModuleForPath(rel))
+		return parsed{decls, imps}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ix := &Index{Imports: make(map[string]map[string]bool)}
+	for i, r := range results {
+		ix.Decls = append(ix.Decls, r.decls...)
+		ix.Imports[paths[i]] = r.imports
+	}
+	return ix, nil
+}
+
+// Parse reads the declarations and imports of one Swift file.
+func Parse(r io.Reader, rel, module string) ([]*Decl, map[string]bool, error) {
+	p := &parser{file: rel, module: module, imports: make(map[string]bool)}
+	scanner := textscan.NewScanner(r)
+	for scanner.Scan() {
+		p.line(scanner.Text())
+	}
+	return p.decls, p.imports, textscan.Check(rel, p.lineNo, scanner.Err())
+}
+
+type context struct {
+	decl      *Decl
+	bodyDepth int
+}
+
+// ifFrame is one #if ... #endif block.
+type ifFrame struct {
+	prior   []string
+	current string
+}
+
+type parser struct {
+	file    string
+	module  string
+	imports map[string]bool
+	decls   []*Decl
+
+	lineNo    int
+	depth     int
+	inComment bool
+	contexts  []context
+	ifs       []ifFrame
+	// pendingAvail holds @available entries from attribute-only lines
+	// preceding a declaration.
+	pendingAvail []string
+	// pendingObjC holds the @objc attribute of the attribute-only lines
+	// preceding a declaration, if any.
+	pendingObjC []string
+	// pendingDecl is a type declaration whose "{" is on a later line.
+	pendingDecl *Decl
+	// pendingSig accumulates a multi-line function signature.
+	pendingSig    string
+	pendingMember *Member
+	// sigEnd is the line the last function or initialiser signature ended
+	// on, which a where clause on the next line belongs to.
+	sigEnd int
+}
+
+func (p *parser) line(raw string) {
+	p.lineNo++
+	var code string
+	code, p.inComment = swiftsrc.StripComments(raw, p.inComment)
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return
+	}
+
+	if p.directive(code) {
+		return
+	}
+	if mod, ok := imports.ParseLine(code); ok {
+		p.imports[mod] = true
+	}
+
+	startDepth := p.depth
+	defer func() {
+		p.depth += strings.Count(code, "{") - strings.Count(code, "}")
+		for len(p.contexts) > 0 && p.depth < p.contexts[len(p.contexts)-1].bodyDepth {
+			p.contexts[len(p.contexts)-1].decl.EndLine = p.lineNo
+			p.contexts = p.contexts[:len(p.contexts)-1]
+		}
+	}()
+
+	if p.pendingSig != "" {
+		p.pendingSig += " " + code
+		if balanced(p.pendingSig) {
+			p.finishMember(p.pendingSig)
+		}
+		return
+	}
+
+	if attrOnlyPattern.MatchString(code) {
+		p.pendingAvail = append(p.pendingAvail, parseAvailable(code)...)
+		if m := objcPattern.FindStringSubmatch(code); m != nil {
+			p.pendingObjC = m
+		}
+		return
+	}
+	guard := Guard{Conditions: p.conditions(), Available: append(p.pendingAvail, parseAvailable(code)...)}
+	objc := p.pendingObjC
+	if m := objcPattern.FindStringSubmatch(code); m != nil {
+		objc = m
+	}
+	p.pendingAvail, p.pendingObjC = nil, nil
+
+	if m := typeDeclPattern.FindStringSubmatch(code); m != nil && !notTypeNames[m[2]] {
+		name := m[2]
+		if m[1] != KindExtension {
+			if ctx := p.current(startDepth); ctx != nil && ctx.decl.Kind != KindProtocol {
+				name = ctx.decl.Name + "." + name
+			}
+		}
+		decl := &Decl{Kind: m[1], Name: name, Module: p.module, File: p.file, Line: p.lineNo, Inherits: splitInherits(m[3]), Guard: guard}
+		if objc != nil {
+			decl.ObjC, decl.ObjCName = true, objc[1]
+		}
+		if ctx := p.current(startDepth); ctx != nil {
+			decl.Guard = ctx.decl.Guard.nested(guard)
+		}
+		p.decls = append(p.decls, decl)
+		if idx := strings.Index(code, "{"); idx >= 0 {
+			p.contexts = append(p.contexts, context{decl: decl, bodyDepth: startDepth + 1})
+		} else {
+			p.pendingDecl = decl
+		}
+		return
+	}
+
+	if p.pendingDecl != nil {
+		// Lines up to the "{" continue the inheritance or where clause.
+		clause, _, _ := strings.Cut(code, "{")
+		if !strings.HasPrefix(clause, "where ") {
+			clause, _, _ = strings.Cut(clause, " where ")
+			p.pendingDecl.Inherits = append(p.pendingDecl.Inherits, splitInherits(strings.TrimPrefix(clause, ":"))...)
+		}
+		if strings.Contains(code, "{") {
+			p.contexts = append(p.contexts, context{decl: p.pendingDecl, bodyDepth: startDepth + 1})
+			p.pendingDecl = nil
+		}
+		return
+	}
+
+	if p.current(startDepth) != nil {
+		p.member(code, guard, objc)
+	}
+}
+
+// current returns the innermost declaration whose body directly contains a
+// line starting at depth, if any.
+func (p *parser) current(depth int) *context {
+	if len(p.contexts) == 0 {
+		return nil
+	}
+	ctx := &p.contexts[len(p.contexts)-1]
+	if ctx.bodyDepth != depth {
+		return nil
+	}
+	return ctx
+}
+
+// directive handles #if, #elseif, #else and #endif lines.
+func (p *parser) directive(code string) bool {
+	switch {
+	case strings.HasPrefix(code, "#if "), strings.HasPrefix(code, "#if("):
+		p.ifs = append(p.ifs, ifFrame{current: strings.TrimSpace(code[3:])})
+	case strings.HasPrefix(code, "#elseif"):
+		if n := len(p.ifs); n > 0 {
+			top := &p.ifs[n-1]
+			top.prior = append(top.prior, top.current)
+			top.current = strings.TrimSpace(strings.TrimPrefix(code, "#elseif"))
+		}
+	case code == "#else":
+		if n := len(p.ifs); n > 0 {
+			top := &p.ifs[n-1]
+			top.prior = append(top.prior, top.current)
+			top.current = ""
+		}
+	case code == "#endif":
+		if n := len(p.ifs); n > 0 {
+			p.ifs = p.ifs[:n-1]
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func (p *parser) conditions() []string {
+	var conds []string
+	for _, f := range p.ifs {
+		for _, prior := range f.prior {
+			conds = append(conds, "!("+prior+")")
+		}
+		if f.current != "" {
+			conds = append(conds, f.current)
+		}
+	}
+	return conds
+}
+
+func (p *parser) member(code string, guard Guard, objc []string) {
+	m := &Member{Line: p.lineNo, Guard: guard, Text: code}
+	if objc != nil {
+		m.ObjC, m.ObjCName = true, objc[1]
+	}
+	m.Static = staticPattern.MatchString(code)
+	m.Optional = optionalPattern.MatchString(code)
+
+	switch {
+	case strings.HasPrefix(code, "where "):
+		p.continueWhere(code)
+		return
+	case aliasPattern.MatchString(code):
+		a := aliasPattern.FindStringSubmatch(code)
+		m.Kind, m.Name, m.Signature = a[1], a[2], a[2]
+	case funcPattern.MatchString(code):
+		loc := funcPattern.FindStringSubmatchIndex(code)
+		m.Kind, m.Name = MemberFunc, code[loc[2]:loc[3]]
+		if loc[4] >= 0 {
+			m.GenericParams, m.Constraints = genericClause(code[loc[4]:loc[5]])
+		}
+		p.startSignature(m, code[loc[1]-1:])
+		return
+	case initPattern.MatchString(code):
+		loc := initPattern.FindStringSubmatchIndex(code)
+		m.Kind, m.Name = MemberInit, "init"
+		if loc[4] >= 0 {
+			m.GenericParams, m.Constraints = genericClause(code[loc[4]:loc[5]])
+		}
+		p.startSignature(m, code[loc[1]-1:])
+		return
+	case subscriptPat.MatchString(code):
+		m.Kind, m.Name, m.Signature = MemberSubscript, "subscript", "subscript"
+	case varPattern.MatchString(code):
+		m.Kind, m.Name = MemberVar, varPattern.FindStringSubmatch(code)[1]
+		m.Signature = m.Name
+	case casePattern.MatchString(code):
+		for _, part := range splitTopLevel(casePattern.FindStringSubmatch(code)[1]) {
+			part = strings.TrimSpace(part)
+			name := identStart.FindString(part)
+			if name == "" {
+				continue
+			}
+			c := *m
+			c.Kind, c.Name, c.Signature, c.Static = MemberCase, name, name, true
+			if rest := strings.TrimSpace(part[len(name):]); strings.HasPrefix(rest, "(") {
+				c.Signature = name + caseLabels(rest)
+			}
+			p.addMember(c)
+		}
+		return
+	default:
+		return
+	}
+	p.addMember(*m)
+}
+
+// startSignature records a function or initialiser whose parameter list
+// starts at params, which may continue on later lines.
+func (p *parser) startSignature(m *Member, params string) {
+	if balanced(params) {
+		m.setParameters(params)
+		p.sigEnd = p.lineNo
+		p.addMember(*m)
+		return
+	}
+	p.pendingMember = m
+	p.pendingSig = params
+}
+
+func (p *parser) finishMember(params string) {
+	m := p.pendingMember
+	p.pendingSig, p.pendingMember = "", nil
+	m.setParameters(params)
+	p.sigEnd = p.lineNo
+	p.addMember(*m)
+}
+
+// continueWhere adds a where clause on a line of its own to the function
+// or initialiser whose signature ended on the line before.
+func (p *parser) continueWhere(code string) {
+	if len(p.contexts) == 0 || p.sigEnd != p.lineNo-1 {
+		return
+	}
+	decl := p.contexts[len(p.contexts)-1].decl
+	if n := len(decl.Members); n > 0 {
+		last := &decl.Members[n-1]
+		if last.Kind == MemberFunc || last.Kind == MemberInit {
+			last.Constraints = append(last.Constraints, whereClause(code)...)
+		}
+	}
+}
+
+// setParameters fills in the signature and parameters of a function or
+// initialiser from its parameter list and the rest of its declaration,
+// which may hold a where clause.
+func (m *Member) setParameters(params string) {
+	inner, rest := splitParams(params)
+	m.Signature = m.Name + labels(inner)
+	m.Params = parameters(inner)
+	m.Effects, m.Returns = effects(rest)
+	m.Constraints = append(m.Constraints, whereClause(rest)...)
+}
+
+// effects splits what follows a parameter list into its effects and its
+// result type, stopping at a where clause or the body.
+func effects(rest string) (effects, returns string) {
+	if i := strings.Index(rest, "{"); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.Index(rest, " where "); i >= 0 {
+		rest = rest[:i]
+	}
+	head, result, _ := strings.Cut(rest, "->")
+	return strings.Join(strings.Fields(head), " "), strings.TrimSpace(result)
+}
+
+func (p *parser) addMember(m Member) {
+	if len(p.contexts) == 0 {
+		return
+	}
+	decl := p.contexts[len(p.contexts)-1].decl
+	m.Guard = decl.Guard.nested(m.Guard)
+	decl.Members = append(decl.Members, m)
+}
+
+// balanced reports whether the first parenthesised group in s is closed.
+func balanced(s string) bool {
+	depth := 0
+	for _, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitParams splits the parameter list at the start of params from what
+// follows it, returning the list without its parentheses.
+func splitParams(params string) (inner, rest string) {
+	depth := 0
+	for i, r := range params {
+		if r == '(' {
+			depth++
+		} else if r == ')' {
+			depth--
+			if depth == 0 {
+				return strings.TrimPrefix(params[:i], "("), params[i+1:]
+			}
+		}
+	}
+	return strings.TrimPrefix(params, "("), ""
+}
+
+// labels renders the argument labels of a parameter list as "(_:key:)".
+func labels(inner string) string {
+	var b strings.Builder
+	b.WriteString("(")
+	for _, param := range splitTopLevel(inner) {
+		param = strings.TrimSpace(param)
+		if param == "" {
+			continue
+		}
+		head, _, _ := strings.Cut(param, ":")
+		fields := strings.Fields(head)
+		if len(fields) == 0 {
+			continue
+		}
+		b.WriteString(fields[0])
+		b.WriteString(":")
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// splitTopLevel splits s at commas outside brackets. The ">" of a
+// function type's "->" closes nothing.
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(', '[', '<', '{':
+			depth++
+		case '>':
+			if i == 0 || s[i-1] != '-' {
+				depth--
+			}
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// splitInherits returns the names in an inheritance clause, without
+// attributes, module qualifiers or generic arguments.
+func splitInherits(clause string) []string {
+	var names []string
+	for _, part := range splitTopLevel(clause) {
+		part = strings.TrimSpace(part)
+		for strings.HasPrefix(part, "@") || strings.HasPrefix(part, "~") {
+			if _, rest, ok := strings.Cut(part, " "); ok {
+				part = strings.TrimSpace(rest)
+			} else {
+				part = ""
+			}
+		}
+		part = strings.TrimPrefix(part, "any ")
+		if idx := strings.Index(part, "<"); idx >= 0 {
+			part = part[:idx]
+		}
+		if part = strings.TrimSpace(part); part != "" {
+			names = append(names, part)
+		}
+	}
+	return names
+}
+
+// caseLabels renders the associated values of an enum case as argument
+// labels, so that "(reason: String)" gives "(reason:)" and "(String)" gives
+// "(_:)", matching a static function requirement the case satisfies.
+func caseLabels(values string) string {
+	inner := strings.TrimSuffix(strings.TrimPrefix(values, "("), ")")
+	var b strings.Builder
+	b.WriteString("(")
+	for _, value := range splitTopLevel(inner) {
+		label := "_"
+		if head, _, ok := strings.Cut(value, ":"); ok && identStart.MatchString(strings.TrimSpace(head)) && !strings.Contains(head, "<") {
+			label = strings.TrimSpace(head)
+		}
+		b.WriteString(label)
+		b.WriteString(":")
+	}
+	b.WriteString(")")
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package protocols
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteMarkdown writes the issues grouped by kind and then by protocol.
+func WriteMarkdown(w io.Writer, ix *Index, issues []Issue, opts Options) error {
+	protocols := 0
+	for _, d := range ix.Decls {
+		if d.Kind == KindProtocol {
+			protocols++
+		}
+	}
+	counts := make(map[string]int)
+	for _, i := range issues {
+		counts[i.Kind]++
+	}
+
+	var b strings.Builder
+	b.WriteString("# Protocol Conformance Report\n\n")
+	if opts.Platform != "" {
+		fmt.Fprintf(&b, "Platform: **%s**\n\n", opts.Platform)
+	}
+	fmt.Fprintf(&b, "**%d protocols, %d issues**\n\n", protocols, len(issues))
+	if len(issues) == 0 {
+		b.WriteString("No conformance issues found.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	b.WriteString("| Kind | Issues |\n")
+	b.WriteString("|------|--------|\n")
+	kinds := []string{IssueMissing, IssueSignatureMismatch, IssueConstraintMismatch, IssueDefaultMismatch, IssuePlatformConditional, IssueDuplicate}
+	for _, k := range kinds {
+		if counts[k] > 0 {
+			fmt.Fprintf(&b, "| %s | %d |\n", k, counts[k])
+		}
+	}
+
+	titles := map[string]string{
+		IssueMissing:             "Missing Requirements",
+		IssueSignatureMismatch:   "Signature Mismatches",
+		IssueConstraintMismatch:  "Generic Constraint Mismatches",
+		IssueDefaultMismatch:     "Default Value Mismatches",
+		IssuePlatformConditional: "Platform-Conditional Implementations",
+		IssueDuplicate:           "Duplicate Protocols",
+	}
+	for _, k := range kinds {
+		if counts[k] == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n", titles[k])
+		if k == IssueDuplicate {
+			b.WriteString("\n")
+		}
+
+		byProtocol := make(map[string][]Issue)
+		for _, i := range issues {
+			if i.Kind == k {
+				byProtocol[i.Protocol] = append(byProtocol[i.Protocol], i)
+			}
+		}
+		names := make([]string, 0, len(byProtocol))
+		for name := range byProtocol {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if k == IssueDuplicate {
+				for _, i := range byProtocol[name] {
+					fmt.Fprintf(&b, "- [%s] %s (%s:%d)\n", i.Severity, i.Message, i.File, i.Line)
+				}
+				continue
+			}
+			fmt.Fprintf(&b, "\n### %s\n\n", name)
+			for _, i := range byProtocol[name] {
+				fmt.Fprintf(&b, "- [%s] %s (%s:%d)", i.Severity, i.Message, i.File, i.Line)
+				if i.Guard != "" {
+					fmt.Fprintf(&b, " — conformance only under %s", i.Guard)
+				}
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
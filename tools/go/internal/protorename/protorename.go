@@ -0,0 +1,295 @@
+// Package protorename renames a Swift protocol across the tree: its
+// declaration, the conformances, type annotations and generic constraints
+// that name it, and the comments that mention it. It is token based, so an
+// identifier that merely contains the name, such as a test double called
+// MockName, is left alone, and so are string literals and the Objective-C
+// names given by @objc(Name).
+package protorename
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/importrewrite"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftlex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+var identPattern = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+
+// declKinds are the keywords that declare a type name.
+var declKinds = map[string]bool{"protocol": true, "class": true, "struct": true, "enum": true, "actor": true, "typealias": true, "associatedtype": true}
+
+// modifiers may come between a declaration's attributes and its keyword.
+var modifiers = map[string]bool{"public": true, "open": true, "package": true, "internal": true, "fileprivate": true, "private": true, "final": true, "indirect": true, "nonisolated": true, "distributed": true}
+
+// Options configures a rename.
+type Options struct {
+	// From is the protocol's name now and To the name it gets.
+	From, To string
+	// Dirs are the top-level directories whose Swift files are renamed in.
+	Dirs []string
+	// Rules map files to their modules.
+	Rules []modulenames.Rule
+}
+
+// decl is a declaration of the old or the new name.
+type decl struct {
+	Kind   string
+	Name   string
+	Module string
+	File   string
+	Line   int
+	// ObjC is set for an @objc protocol; ObjCName is the name given by
+	// @objc(Name), if any.
+	ObjC     bool
+	ObjCName string
+}
+
+// fileResult is the outcome of renaming in one Swift file.
+type fileResult struct {
+	rel           string
+	before, after string
+	decls         []decl
+	// code and comments count the names replaced in each.
+	code, comments int
+	// strings are the lines of string literals that mention From.
+	strings []int
+	toks    []swiftlex.Token
+}
+
+// Rename plans renaming opts.From to opts.To in the Swift files below
+// opts.Dirs of root. It fails unless From is declared as a protocol
+// exactly once, and declared as nothing else, and To is not declared at
+// all. Nothing is written; see importrewrite.Plan.Apply.
+func Rename(root string, opts Options) (*importrewrite.Plan, error) {
+	for _, name := range []string{opts.From, opts.To} {
+		if !identPattern.MatchString(name) {
+			return nil, fmt.Errorf("%q is not a Swift identifier", name)
+		}
+	}
+	if opts.From == opts.To {
+		return nil, fmt.Errorf("%s is already called %s", opts.From, opts.To)
+	}
+	ix := &moduleindex.Index{Modules: opts.Rules}
+
+	var paths []string
+	for _, dir := range opts.Dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	results, err := pool.Map(paths, func(rel string) (*fileResult, error) {
+		data, err := os.ReadFile(filepath.Join(root, rel))
+		if err != nil {
+			return nil, err
+		}
+		src := string(data)
+		if !strings.Contains(src, opts.From) && !strings.Contains(src, opts.To) {
+			return nil, nil
+		}
+		module := workspace.ModuleForPath(rel)
+		if rule, ok := ix.ForPath(rel); ok {
+			module = rule.ModuleName
+		}
+		return scan(rel, src, module, opts), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var protocol []decl
+	var others, clashes []string
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		for _, d := range r.decls {
+			at := fmt.Sprintf("%s %s in %s:%d", d.Kind, d.Name, d.File, d.Line)
+			switch {
+			case d.Name == opts.To:
+				clashes = append(clashes, at)
+			case d.Kind == "protocol":
+				protocol = append(protocol, d)
+			default:
+				others = append(others, at)
+			}
+		}
+	}
+	if len(clashes) > 0 {
+		return nil, fmt.Errorf("%s is already declared: %s", opts.To, strings.Join(clashes, ", "))
+	}
+	switch {
+	case len(protocol) == 0:
+		return nil, fmt.Errorf("no protocol %s is declared in %s", opts.From, strings.Join(opts.Dirs, ", "))
+	case len(protocol) > 1 || len(others) > 0:
+		var at []string
+		for _, d := range protocol {
+			at = append(at, fmt.Sprintf("protocol %s in %s:%d", d.Name, d.File, d.Line))
+		}
+		return nil, fmt.Errorf("%s is declared more than once, so its uses cannot be told apart: %s", opts.From, strings.Join(append(at, others...), ", "))
+	}
+	target := protocol[0]
+
+	plan := &importrewrite.Plan{}
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		rename(r, target.Module, opts)
+		for _, line := range r.strings {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s:%d: a string literal mentions %s and is left alone", r.rel, line, opts.From))
+		}
+		if r.after == r.before {
+			continue
+		}
+		edit := fmt.Sprintf("%s → %s: %d in code, %d in comments", opts.From, opts.To, r.code, r.comments)
+		plan.Swift = append(plan.Swift, importrewrite.Change{Path: r.rel, Edits: []string{edit}, Before: r.before, After: r.after})
+		if base := path.Base(r.rel); base == opts.From+".swift" || strings.HasPrefix(base, opts.From+"+") {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s is named after %s; rename the file too", r.rel, opts.From))
+		}
+	}
+	switch {
+	case target.ObjCName != "":
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s:%d: @objc(%s) keeps the protocol's Objective-C name", target.File, target.Line, target.ObjCName))
+	case target.ObjC:
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s:%d: the Objective-C name changes to %s as well; add @objc(%s) to keep XPC peers built against the old name working", target.File, target.Line, opts.To, opts.From))
+	}
+	return plan, nil
+}
+
+// scan lexes one file and finds its declarations of From and To.
+func scan(rel, src, module string, opts Options) *fileResult {
+	r := &fileResult{rel: rel, before: src, after: src, toks: swiftlex.Tokens(src)}
+	for i, t := range r.toks {
+		if t.Kind != swiftlex.Ident || (t.Text != opts.From && t.Text != opts.To) {
+			continue
+		}
+		p := prevCode(r.toks, i)
+		if p < 0 {
+			continue
+		}
+		if kw := r.toks[p]; kw.Kind == swiftlex.Ident && declKinds[kw.Text] {
+			d := decl{Kind: kw.Text, Name: t.Text, Module: module, File: rel, Line: t.Line}
+			if kw.Text == "protocol" {
+				d.ObjC, d.ObjCName = objcName(r.toks, p)
+			}
+			r.decls = append(r.decls, d)
+		}
+	}
+	return r
+}
+
+// rename replaces From with To in r's code and comments. A name after a
+// dot is only replaced when the dot qualifies it by module, the module
+// the protocol is declared in; one inside @objc(...) is an Objective-C
+// name and is kept.
+func rename(r *fileResult, module string, opts Options) {
+	word := regexp.MustCompile(`\b` + regexp.QuoteMeta(opts.From) + `\b`)
+	var b strings.Builder
+	last := 0
+	replace := func(t swiftlex.Token, text string) {
+		b.WriteString(r.before[last:t.Offset])
+		b.WriteString(text)
+		last = t.Offset + len(t.Text)
+	}
+	for i, t := range r.toks {
+		switch t.Kind {
+		case swiftlex.Comment:
+			if n := len(word.FindAllStringIndex(t.Text, -1)); n > 0 {
+				replace(t, word.ReplaceAllLiteralString(t.Text, opts.To))
+				r.comments += n
+			}
+		case swiftlex.String:
+			if word.MatchString(t.Text) {
+				r.strings = append(r.strings, t.Line)
+			}
+		case swiftlex.Ident:
+			if t.Text != opts.From {
+				continue
+			}
+			if p := prevCode(r.toks, i); p >= 0 {
+				if r.toks[p].Is(swiftlex.Punct, ".") {
+					if q := prevCode(r.toks, p); q < 0 || !r.toks[q].Is(swiftlex.Ident, module) {
+						continue
+					}
+				}
+				if r.toks[p].Is(swiftlex.Punct, "(") {
+					if q := prevCode(r.toks, p); q >= 0 && r.toks[q].Is(swiftlex.Attribute, "@objc") {
+						continue
+					}
+				}
+			}
+			replace(t, opts.To)
+			r.code++
+		}
+	}
+	b.WriteString(r.before[last:])
+	r.after = b.String()
+}
+
+// objcName reports whether the declaration whose keyword is toks[kw] is
+// marked @objc, and the Objective-C name @objc(Name) gives it.
+func objcName(toks []swiftlex.Token, kw int) (bool, string) {
+	for j := prevCode(toks, kw); j >= 0; j = prevCode(toks, j) {
+		t := toks[j]
+		switch {
+		case t.Kind == swiftlex.Ident && modifiers[t.Text]:
+		case t.Is(swiftlex.Punct, ")"):
+			for j > 0 && !toks[j].Is(swiftlex.Punct, "(") {
+				j--
+			}
+		case t.Is(swiftlex.Attribute, "@objc"):
+			open := nextCode(toks, j)
+			if open < 0 || !toks[open].Is(swiftlex.Punct, "(") {
+				return true, ""
+			}
+			if name := nextCode(toks, open); name >= 0 && toks[name].Kind == swiftlex.Ident {
+				return true, toks[name].Text
+			}
+			return true, ""
+		case t.Kind == swiftlex.Attribute:
+		default:
+			return false, ""
+		}
+	}
+	return false, ""
+}
+
+// prevCode returns the index of the last token before i that is not a
+// comment, or -1.
+func prevCode(toks []swiftlex.Token, i int) int {
+	for i--; i >= 0 && toks[i].Kind == swiftlex.Comment; i-- {
+	}
+	return i
+}
+
+// nextCode returns the index of the first token after i that is not a
+// comment, or -1.
+func nextCode(toks []swiftlex.Token, i int) int {
+	for i++; i < len(toks); i++ {
+		if toks[i].Kind != swiftlex.Comment {
+			return i
+		}
+	}
+	return -1
+}
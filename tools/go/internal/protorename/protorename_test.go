@@ -0,0 +1,106 @@
+package protorename
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testfixture"
+)
+
+const declaration = "public protocol KeyProvider: AnyObject {\n    func key() -> Data\n}\n"
+
+var keyProvider = Options{From: "KeyProvider", To: "KeySource", Dirs: []string{"Sources"}}
+
+// renamed returns what renaming KeyProvider, declared in module Core, makes
+// of src in module App, and the plan's warnings.
+func renamed(t *testing.T, src string) (string, []string) {
+	t.Helper()
+	root := testfixture.Write(t, map[string]string{
+		"Sources/Core/KeyProviding.swift": declaration,
+		"Sources/App/App.swift":           src,
+	})
+	plan, err := Rename(root, keyProvider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := src
+	for _, c := range plan.Swift {
+		if c.Path == "Sources/App/App.swift" {
+			got = c.After
+		}
+	}
+	return got, plan.Warnings
+}
+
+func TestRename(t *testing.T) {
+	for name, tc := range map[string]struct {
+		src, want string
+	}{
+		"conformance list": {
+			src:  "final class Vault: NSObject, KeyProvider, Sendable {}\n",
+			want: "final class Vault: NSObject, KeySource, Sendable {}\n",
+		},
+		"existential and opaque types": {
+			src:  "func a(_ p: any KeyProvider) -> some KeyProvider { p }\nlet ps: [any KeyProvider] = []\n",
+			want: "func a(_ p: any KeySource) -> some KeySource { p }\nlet ps: [any KeySource] = []\n",
+		},
+		"extensions": {
+			src:  "extension KeyProvider {\n    func rotate() {}\n}\nextension Vault: KeyProvider {}\n",
+			want: "extension KeySource {\n    func rotate() {}\n}\nextension Vault: KeySource {}\n",
+		},
+		"generic constraints": {
+			src:  "func use<T>(_ t: T) where T: KeyProvider {}\nstruct Box<T: KeyProvider> {}\nextension Array where Element == any KeyProvider {}\n",
+			want: "func use<T>(_ t: T) where T: KeySource {}\nstruct Box<T: KeySource> {}\nextension Array where Element == any KeySource {}\n",
+		},
+		"module-qualified": {
+			src:  "let p: Core.KeyProvider\nlet q: Other.KeyProvider\n",
+			want: "let p: Core.KeySource\nlet q: Other.KeyProvider\n",
+		},
+		"names containing it": {
+			src:  "final class MockKeyProvider: KeyProvider {}\nlet keyProviderCount = 0\n",
+			want: "final class MockKeyProvider: KeySource {}\nlet keyProviderCount = 0\n",
+		},
+		"strings": {
+			src:  "let s = \"KeyProvider\"\nlet t = #\"any KeyProvider\"#\n",
+			want: "let s = \"KeyProvider\"\nlet t = #\"any KeyProvider\"#\n",
+		},
+		"comments": {
+			src:  "// A KeyProvider, not a MockKeyProvider.\n/* KeyProviders */ let x = 1\n",
+			want: "// A KeySource, not a MockKeyProvider.\n/* KeyProviders */ let x = 1\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got, _ := renamed(t, tc.src); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRenameWarnsAboutStrings(t *testing.T) {
+	_, warnings := renamed(t, "let a = 1\nlet s = \"KeyProvider\"\n")
+	want := "Sources/App/App.swift:2: a string literal mentions KeyProvider and is left alone"
+	if !slices.Contains(warnings, want) {
+		t.Errorf("got warnings %q, want %q among them", warnings, want)
+	}
+}
+
+func TestRenameRefuses(t *testing.T) {
+	for name, tc := range map[string]struct {
+		src, want string
+	}{
+		"new name declared":       {src: "struct KeySource {}\n", want: "KeySource is already declared"},
+		"old name declared twice": {src: "enum KeyProvider {}\n", want: "KeyProvider is declared more than once"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			root := testfixture.Write(t, map[string]string{
+				"Sources/Core/KeyProviding.swift": declaration,
+				"Sources/App/App.swift":           tc.src,
+			})
+			if _, err := Rename(root, keyProvider); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("got error %v, want one saying %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+// Package provenance records how a report was produced, so a published
+// analysis can be reproduced: the commit and state of the analysed tree,
+// the umbratool version, when it ran and the flags it ran with.
+package provenance
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+)
+
+// version is set at link time with
+// -ldflags "-X github.com/mpy-dev-ml/UmbraCore/tools/go/internal/provenance.version=...".
+// Without it, Version falls back to the module version and VCS revision Go
+// stamps into the binary.
+var version string
+
+// Stamp is the provenance of one run.
+type Stamp struct {
+	// Command is the umbratool command that wrote the report.
+	Command string `json:"command"`
+	Version string `json:"version"`
+	// GitSHA is the HEAD commit of the analysed tree, empty outside a
+	// git checkout.
+	GitSHA string `json:"gitSha"`
+	// Dirty is set when tracked files differ from GitSHA.
+	Dirty     bool      `json:"dirty"`
+	Timestamp time.Time `json:"timestamp"`
+	// Flags are the flags given on the command line, by name, with
+	// credentials redacted, and Args the arguments after them.
+	Flags map[string]string `json:"flags"`
+	Args  []string          `json:"args,omitempty"`
+}
+
+// New stamps a run of the command fs was parsed for, over the tree at root.
+func New(fs *flag.FlagSet, root string, now time.Time) Stamp {
+	s := Stamp{
+		Command:   fs.Name(),
+		Version:   Version(),
+		Timestamp: now.UTC().Truncate(time.Second),
+		Flags:     make(map[string]string),
+		Args:      fs.Args(),
+	}
+	fs.Visit(func(f *flag.Flag) {
+		s.Flags[f.Name] = relative(root, f.Value.String())
+		if secret(f.Name) {
+			s.Flags[f.Name] = "REDACTED"
+		}
+	})
+	if out, err := exec.Command("git", "-C", root, "rev-parse", "HEAD").Output(); err == nil {
+		s.GitSHA = strings.TrimSpace(string(out))
+		status, err := exec.Command("git", "-C", root, "status", "--porcelain", "--untracked-files=no").Output()
+		s.Dirty = err == nil && len(bytes.TrimSpace(status)) > 0
+	}
+	return s
+}
+
+// relative returns value relative to root when it is an absolute path
+// inside it, so reports do not depend on where the tree is checked out.
+func relative(root, value string) string {
+	if !filepath.IsAbs(value) || root == "" {
+		return value
+	}
+	rel, err := filepath.Rel(root, value)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return value
+	}
+	return filepath.ToSlash(rel)
+}
+
+// secret reports whether a flag carries a credential, which a report must
+// not repeat.
+func secret(name string) bool {
+	for _, word := range []string{"token", "password", "secret", "webhook"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Version returns the umbratool version: the link-time version if set,
+// otherwise the one in the binary's build information.
+func Version() string {
+	if version != "" {
+		return version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	var revision, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		}
+	}
+	if revision == "" {
+		return "devel"
+	}
+	v := "devel-" + revision[:min(12, len(revision))]
+	if modified == "true" {
+		v += "+dirty"
+	}
+	return v
+}
+
+// CommandLine renders the run as an umbratool invocation that repeats it.
+func (s Stamp) CommandLine() string {
+	names := make([]string, 0, len(s.Flags))
+	for name := range s.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := []string{"umbratool", s.Command}
+	for _, name := range names {
+		parts = append(parts, "--"+name+"="+shellQuote(s.Flags[name]))
+	}
+	for _, arg := range s.Args {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n\"'`$\\|&;<>()*?[]{}!#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Markdown returns the stamp as a quoted paragraph.
+func (s Stamp) Markdown() string {
+	commit := "outside a git checkout"
+	if s.GitSHA != "" {
+		commit = fmt.Sprintf("from commit `%s`", s.GitSHA[:min(12, len(s.GitSHA))])
+		if s.Dirty {
+			commit += " with uncommitted changes"
+		}
+	}
+	return fmt.Sprintf("> Generated by umbratool %s on %s %s.  \n> Reproduce with `%s`.\n",
+		s.Version, s.Timestamp.Format(time.RFC3339), commit, s.CommandLine())
+}
+
+// StampMarkdown inserts the stamp below the report's first heading, or at
+// the top when it does not start with one.
+func StampMarkdown(report []byte, s Stamp) []byte {
+	block := []byte(s.Markdown() + "\n")
+	if !bytes.HasPrefix(report, []byte("#")) {
+		return append(block, report...)
+	}
+	end := bytes.IndexByte(report, '\n')
+	if end < 0 {
+		return append(append(report, "\n\n"...), block...)
+	}
+	head, rest := report[:end+1], bytes.TrimLeft(report[end+1:], "\n")
+	out := make([]byte, 0, len(report)+len(block)+1)
+	out = append(append(append(out, head...), '\n'), block...)
+	return append(out, rest...)
+}
+
+// StampJSON adds the stamp as a "provenance" field at the start of a report
+// that is a JSON object. A report that is any other JSON value becomes the
+// "results" field of an object beside it.
+func StampJSON(report []byte, s Stamp) ([]byte, error) {
+	stamp, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, report); err != nil {
+		return nil, fmt.Errorf("stamping report: %w", err)
+	}
+	body := compact.Bytes()
+
+	var joined []byte
+	switch {
+	case bytes.Equal(body, []byte("{}")):
+		joined = fmt.Appendf(nil, `{"provenance":%s}`, stamp)
+	case bytes.HasPrefix(body, []byte("{")):
+		joined = fmt.Appendf(nil, `{"provenance":%s,%s`, stamp, body[1:])
+	default:
+		joined = fmt.Appendf(nil, `{"provenance":%s,"results":%s}`, stamp, body)
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, joined, "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
@@ -0,0 +1,362 @@
+// Package refactorqueue ranks the tree's files as refactoring candidates.
+// It combines complexity, duplicated code, how many modules depend on the
+// file's module, untested public methods and churn into one weighted
+// priority, and keeps each factor's share of it so the ranking can be
+// explained.
+package refactorqueue
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/apidump"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/churn"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/clones"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/complexity"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testgen"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testmap"
+)
+
+// Factors scored per file.
+const (
+	FactorComplexity  = "complexity"
+	FactorDuplication = "duplication"
+	FactorFanIn       = "fanin"
+	FactorTests       = "tests"
+	FactorChurn       = "churn"
+)
+
+// Factors lists every factor in report order.
+var Factors = []string{FactorComplexity, FactorDuplication, FactorFanIn, FactorTests, FactorChurn}
+
+// DefaultWeights weight the factors when no others are given.
+var DefaultWeights = map[string]float64{
+	FactorComplexity:  3,
+	FactorDuplication: 2,
+	FactorFanIn:       1,
+	FactorTests:       1,
+	FactorChurn:       2,
+}
+
+// ParseWeights parses "complexity=2,churn=1" into weights, starting from
+// DefaultWeights. A weight of 0 leaves a factor out.
+func ParseWeights(spec string) (map[string]float64, error) {
+	weights := make(map[string]float64, len(DefaultWeights))
+	for k, v := range DefaultWeights {
+		weights[k] = v
+	}
+	for _, part := range strings.Split(spec, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if _, known := DefaultWeights[name]; !ok || !known {
+			return nil, fmt.Errorf("bad weight %q (want factor=number, factors: %s)", part, strings.Join(Factors, ", "))
+		}
+		w, err := strconv.ParseFloat(value, 64)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("bad weight %q: want a non-negative number", part)
+		}
+		weights[name] = w
+	}
+	return weights, nil
+}
+
+// Options configures Gather.
+type Options struct {
+	// Dirs are the top-level directories whose Swift files are ranked.
+	Dirs []string
+	// Rules map files to their modules.
+	Rules []modulenames.Rule
+	// Since limits churn to the commits after it; zero counts the whole
+	// history.
+	Since time.Time
+}
+
+// Inputs are the results the queue combines. Nil fields leave their
+// factor out of every file's priority.
+type Inputs struct {
+	Complexity *complexity.Report
+	Clones     *clones.Report
+	// Imports are the scanned Swift imports, from which each module's
+	// dependents are counted.
+	Imports []imports.File
+	// API and Scaffolds give each file's public methods and those no test
+	// mentions.
+	API       *apidump.API
+	Scaffolds []*testgen.Scaffold
+	// Churn counts the commits changing each file.
+	Churn map[string]int
+	Rules []modulenames.Rule
+}
+
+// Candidate is one file in the queue.
+type Candidate struct {
+	Rank   int    `json:"rank"`
+	File   string `json:"file"`
+	Module string `json:"module"`
+	// Priority is the weighted mean of the factor scores, 0–100.
+	Priority float64 `json:"priority"`
+	// Scores are the 0–1 factor scores that apply to the file: for
+	// tests the share of its public methods untested, and for the other
+	// factors its percentile among the ranked files measuring any of it.
+	Scores map[string]float64 `json:"scores"`
+	// Points are each factor's share of Priority; they add up to it,
+	// give or take rounding.
+	Points map[string]float64 `json:"points"`
+	// Details give the measurement behind each score.
+	Details map[string]string `json:"details"`
+	// Fingerprint identifies the issue of a candidate near enough the top to be recorded.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Why names the factors contributing most to the candidate's priority,
+// largest first.
+func (c Candidate) Why(n int) []string {
+	var factors []string
+	for _, f := range Factors {
+		if c.Points[f] > 0 {
+			factors = append(factors, f)
+		}
+	}
+	sort.SliceStable(factors, func(i, j int) bool { return c.Points[factors[i]] > c.Points[factors[j]] })
+	if len(factors) > n {
+		factors = factors[:n]
+	}
+	return factors
+}
+
+// Gather runs the analyzers the queue needs over the files below
+// opts.Dirs of root. Churn is left out outside a git work tree.
+func Gather(root string, opts Options) (*Inputs, error) {
+	in := &Inputs{Rules: opts.Rules}
+	var err error
+	if in.Complexity, err = complexity.Analyse(root, opts.Dirs...); err != nil {
+		return nil, err
+	}
+	in.Clones, err = clones.Find(root, clones.Options{Dirs: opts.Dirs, Rules: opts.Rules, MinTokens: clones.DefaultMinTokens, Similarity: clones.DefaultSimilarity})
+	if err != nil {
+		return nil, err
+	}
+	if in.Imports, err = imports.ScanTree(root, opts.Dirs...); err != nil {
+		return nil, err
+	}
+	if in.API, err = apidump.Dump(root, apidump.Options{Dirs: opts.Dirs, Rules: opts.Rules}); err != nil {
+		return nil, err
+	}
+	tests, err := testmap.Build(root)
+	if err != nil {
+		return nil, err
+	}
+	if in.Scaffolds, err = testgen.Plan(root, in.API, tests, nil); err != nil {
+		return nil, err
+	}
+	if in.Churn, err = churn.Commits(root, opts.Since); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// measure is what one file measures before scoring.
+type measure struct {
+	module             string
+	complexity, maxFn  int
+	duplicated, clones int
+	dependents         int
+	testable, untested int
+	commits            int
+}
+
+// Rank scores every file with code worth refactoring, that is with
+// branching functions or duplicated blocks, and returns them highest
+// priority first.
+func Rank(in *Inputs, weights map[string]float64) []Candidate {
+	ix := &moduleindex.Index{Modules: in.Rules}
+	moduleOf := func(rel string) string {
+		if r, ok := ix.ForPath(rel); ok {
+			return r.ModuleName
+		}
+		return ""
+	}
+
+	files := make(map[string]*measure)
+	var order []string
+	if in.Complexity != nil {
+		for _, f := range in.Complexity.Files {
+			module := moduleOf(f.Path)
+			if module == "" {
+				module = f.Module
+			}
+			files[f.Path] = &measure{module: module, complexity: f.Complexity - len(f.Functions), maxFn: f.MaxComplexity}
+			order = append(order, f.Path)
+		}
+	}
+	if in.Clones != nil {
+		for _, c := range in.Clones.Clones {
+			for _, b := range []clones.Block{c.A, c.B} {
+				if m, ok := files[b.File]; ok {
+					m.duplicated += b.Tokens
+					m.clones++
+				}
+			}
+		}
+	}
+	if in.Imports != nil {
+		dependents := make(map[string]map[string]bool)
+		for _, f := range in.Imports {
+			importer := moduleOf(f.Path)
+			if importer == "" {
+				importer = f.Module
+			}
+			for _, imp := range f.Imports {
+				if imp.Module == importer {
+					continue
+				}
+				if dependents[imp.Module] == nil {
+					dependents[imp.Module] = make(map[string]bool)
+				}
+				dependents[imp.Module][importer] = true
+			}
+		}
+		for _, m := range files {
+			m.dependents = len(dependents[m.module])
+		}
+	}
+	if in.API != nil {
+		for _, d := range in.API.Decls {
+			if m, ok := files[d.File]; ok && testgen.Testable(d) {
+				m.testable++
+			}
+		}
+		for _, s := range in.Scaffolds {
+			for _, g := range s.Gaps {
+				if m, ok := files[g.File]; ok {
+					m.untested++
+				}
+			}
+		}
+	}
+	for p, m := range files {
+		m.commits = in.Churn[p]
+	}
+
+	present := map[string]bool{
+		FactorComplexity:  in.Complexity != nil,
+		FactorDuplication: in.Clones != nil,
+		FactorFanIn:       in.Imports != nil,
+		FactorTests:       in.API != nil,
+		FactorChurn:       in.Churn != nil,
+	}
+	value := map[string]func(*measure) int{
+		FactorComplexity:  func(m *measure) int { return m.complexity },
+		FactorDuplication: func(m *measure) int { return m.duplicated },
+		FactorFanIn:       func(m *measure) int { return m.dependents },
+		FactorChurn:       func(m *measure) int { return m.commits },
+	}
+	var ranked []string
+	for _, p := range order {
+		if m := files[p]; m.complexity > 0 || m.duplicated > 0 {
+			ranked = append(ranked, p)
+		}
+	}
+	percentiles := make(map[string]func(int) float64)
+	for factor, v := range value {
+		values := make([]int, 0, len(ranked))
+		for _, p := range ranked {
+			values = append(values, v(files[p]))
+		}
+		percentiles[factor] = percentile(values)
+	}
+
+	out := make([]Candidate, 0, len(ranked))
+	for _, p := range ranked {
+		m := files[p]
+		c := Candidate{File: p, Module: m.module, Scores: make(map[string]float64), Points: make(map[string]float64), Details: make(map[string]string)}
+		// A test's dependents and its own tests say nothing about it.
+		test := testgen.InTests(p)
+		for factor, v := range value {
+			if present[factor] && !(test && factor == FactorFanIn) {
+				c.Scores[factor] = percentiles[factor](v(m))
+			}
+		}
+		if present[FactorTests] && m.testable > 0 {
+			c.Scores[FactorTests] = float64(m.untested) / float64(m.testable)
+		}
+		c.Details[FactorComplexity] = fmt.Sprintf("%s, most complex function %d", plural(m.complexity, "branch point"), m.maxFn)
+		c.Details[FactorDuplication] = fmt.Sprintf("%s in %s", plural(m.duplicated, "token"), plural(m.clones, "clone"))
+		c.Details[FactorFanIn] = fmt.Sprintf("%s imported by %s", m.module, plural(m.dependents, "module"))
+		c.Details[FactorTests] = fmt.Sprintf("%d of %s untested", m.untested, plural(m.testable, "public method"))
+		c.Details[FactorChurn] = plural(m.commits, "commit")
+		for _, factor := range Factors {
+			if _, ok := c.Scores[factor]; !ok {
+				delete(c.Details, factor)
+			}
+		}
+
+		var weight float64
+		for factor := range c.Scores {
+			weight += weights[factor]
+		}
+		if weight > 0 {
+			for factor, score := range c.Scores {
+				if weights[factor] > 0 {
+					c.Points[factor] = round(100 * score * weights[factor] / weight)
+					c.Priority += 100 * score * weights[factor] / weight
+				}
+			}
+		}
+		c.Priority = round(c.Priority)
+		out = append(out, c)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Priority != out[j].Priority {
+			return out[i].Priority > out[j].Priority
+		}
+		return out[i].File < out[j].File
+	})
+	for i := range out {
+		out[i].Rank = i + 1
+	}
+	return out
+}
+
+// percentile returns a function scoring a value by its rank among the
+// non-zero values: the share of them below it, counting those equal to it
+// as half below. Zero scores zero, so a file without any of a factor
+// gains nothing from it, and a factor every file has equally, such as
+// churn in a tree imported in one commit, scores every file 0.5.
+func percentile(values []int) func(int) float64 {
+	var sorted []int
+	for _, v := range values {
+		if v > 0 {
+			sorted = append(sorted, v)
+		}
+	}
+	sort.Ints(sorted)
+	return func(v int) float64 {
+		if v <= 0 || len(sorted) == 0 {
+			return 0
+		}
+		below, upTo := sort.SearchInts(sorted, v), sort.SearchInts(sorted, v+1)
+		return (float64(below) + float64(upTo-below)/2) / float64(len(sorted))
+	}
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
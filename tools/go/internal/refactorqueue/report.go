@@ -0,0 +1,85 @@
+package refactorqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// factorTitles head the factor columns of the Markdown table.
+var factorTitles = map[string]string{
+	FactorComplexity:  "Complexity",
+	FactorDuplication: "Duplication",
+	FactorFanIn:       "Fan-in",
+	FactorTests:       "Tests",
+	FactorChurn:       "Churn",
+}
+
+// WriteMarkdown writes the top candidates, each factor's points towards
+// their priority, and the measurements behind the scores.
+func WriteMarkdown(w io.Writer, queue []Candidate, weights map[string]float64, top int) error {
+	var b strings.Builder
+	b.WriteString("# Refactoring Queue\n\n")
+
+	var factors, used []string
+	for _, f := range Factors {
+		if weights[f] > 0 {
+			factors = append(factors, f)
+			used = append(used, fmt.Sprintf("%s ×%g", f, weights[f]))
+		}
+	}
+	shown := queue
+	if top > 0 && len(shown) > top {
+		shown = shown[:top]
+	}
+	fmt.Fprintf(&b, "%d of %d candidates. Weights: %s\n\n", len(shown), len(queue), strings.Join(used, ", "))
+	if len(shown) == 0 {
+		b.WriteString("No file has branching functions or duplicated blocks.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	b.WriteString("Each factor column gives the points it adds to the priority, out of 100.\n\n")
+	b.WriteString("| Rank | File | Module | Priority |")
+	for _, f := range factors {
+		fmt.Fprintf(&b, " %s |", factorTitles[f])
+	}
+	b.WriteString("\n|------|------|--------|----------|")
+	for _, f := range factors {
+		b.WriteString(strings.Repeat("-", len(factorTitles[f])+2) + "|")
+	}
+	b.WriteString("\n")
+	for _, c := range shown {
+		fmt.Fprintf(&b, "| %d | `%s` | %s | %.1f |", c.Rank, c.File, c.Module, c.Priority)
+		for _, f := range factors {
+			if _, ok := c.Scores[f]; ok {
+				fmt.Fprintf(&b, " %.1f |", c.Points[f])
+			} else {
+				b.WriteString(" – |")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n## Why\n\n")
+	for _, c := range shown {
+		var parts []string
+		for _, f := range c.Why(len(Factors)) {
+			if weights[f] > 0 {
+				parts = append(parts, c.Details[f])
+			}
+		}
+		fmt.Fprintf(&b, "%d. `%s`: %s\n", c.Rank, c.File, strings.Join(parts, "; "))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the whole queue as indented JSON.
+func WriteJSON(w io.Writer, queue []Candidate) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(queue)
+}
@@ -0,0 +1,184 @@
+// Package release bundles the checks that block a release into one
+// checklist. Each gate passes or fails on its own findings; the checklist
+// is signed off only when every gate that ran passed.
+package release
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/health"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+)
+
+// Gates, in checklist order.
+const (
+	GateCompat      = "api-compat"
+	GateLayering    = "layering"
+	GateFoundation  = "foundation"
+	GateSecrets     = "secret-scan"
+	GateErrorMapper = "error-mapper"
+)
+
+// Gates lists every gate in checklist order.
+var Gates = []string{GateCompat, GateLayering, GateFoundation, GateSecrets, GateErrorMapper}
+
+// titles describe the gates in the checklist.
+var titles = map[string]string{
+	GateCompat:      "Public API is compatible with the base, or the version is bumped for its breaking changes",
+	GateLayering:    "No import cycles between modules, and every imported module is a declared dep",
+	GateFoundation:  "Foundation-free modules neither import Foundation nor depend on modules that do",
+	GateSecrets:     "No secrets outside the secret-scan baseline",
+	GateErrorMapper: "The error mappers cover every case of the enums they map",
+}
+
+// Result is the outcome of one gate.
+type Result struct {
+	Gate   string `json:"gate"`
+	Title  string `json:"title"`
+	Passed bool   `json:"passed"`
+	// Summary is one line on what the gate found; Findings list what
+	// failed it, and Error is set when the gate could not run, which
+	// fails it too.
+	Summary  string   `json:"summary"`
+	Findings []string `json:"findings,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	// Fingerprint identifies a failed gate's issue.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// NewResult returns the result of gate from its blocking findings.
+func NewResult(gate, summary string, findings []string) Result {
+	return Result{Gate: gate, Title: titles[gate], Passed: len(findings) == 0, Summary: summary, Findings: findings}
+}
+
+// Failed returns the result of a gate that could not run.
+func Failed(gate string, err error) Result {
+	return Result{Gate: gate, Title: titles[gate], Summary: "could not run", Error: err.Error()}
+}
+
+// Checklist is the outcome of every gate run against one revision.
+type Checklist struct {
+	// Branch is the revision checked, as given, and Commit what it
+	// resolved to.
+	Branch  string    `json:"branch"`
+	Commit  string    `json:"commit"`
+	Base    string    `json:"base"`
+	Checked time.Time `json:"checked"`
+	Results []Result  `json:"results"`
+	// Signer signs the checklist off when every gate passed.
+	Signer    string `json:"signer,omitempty"`
+	SignedOff bool   `json:"signedOff"`
+}
+
+// Failures returns the number of gates that failed.
+func (c *Checklist) Failures() int {
+	n := 0
+	for _, r := range c.Results {
+		if !r.Passed {
+			n++
+		}
+	}
+	return n
+}
+
+// SignOff signs the checklist off as signer if every gate passed, and
+// reports whether it did.
+func (c *Checklist) SignOff(signer string) bool {
+	c.Signer = signer
+	c.SignedOff = signer != "" && len(c.Results) > 0 && c.Failures() == 0
+	return c.SignedOff
+}
+
+// ParseGates checks a list of gate names, returning every gate when it
+// is empty.
+func ParseGates(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return Gates, nil
+	}
+	var out []string
+	for _, g := range Gates {
+		if slices.Contains(names, g) {
+			out = append(out, g)
+		}
+	}
+	for _, n := range names {
+		if !slices.Contains(Gates, n) {
+			return nil, fmt.Errorf("unknown gate %q (gates: %s)", n, strings.Join(Gates, ", "))
+		}
+	}
+	return out, nil
+}
+
+// Layering checks the import graph: cycles between modules, and modules
+// importing workspace modules they declare no dep on.
+func Layering(rules []modulenames.Rule, files []imports.File) Result {
+	var findings []string
+	cycles := health.FindCycles(rules, files)
+	for _, c := range cycles {
+		findings = append(findings, "import cycle "+c.String())
+	}
+	hygiene := health.CheckDependencies(rules, files)
+	undeclared := 0
+	for _, mod := range sortedKeys(hygiene) {
+		for _, m := range hygiene[mod].Missing {
+			findings = append(findings, fmt.Sprintf("%s imports %s without a dep on it", mod, m))
+			undeclared++
+		}
+	}
+	return NewResult(GateLayering, fmt.Sprintf("%d import cycles, %d undeclared imports", len(cycles), undeclared), findings)
+}
+
+// Foundation checks the modules declared Foundation-free: none of their
+// files may import Foundation, and none of their workspace deps may be a
+// module that is not Foundation-free.
+func Foundation(rules []modulenames.Rule, files []imports.File) Result {
+	index := &moduleindex.Index{Modules: rules}
+	byLabel := make(map[string]modulenames.Rule, len(rules))
+	workspace := make(map[string]bool, len(rules))
+	var free []modulenames.Rule
+	for _, r := range rules {
+		byLabel[r.Label] = r
+		workspace[r.ModuleName] = true
+		if modulenames.IsFoundationFree(r.Kind) {
+			free = append(free, r)
+		}
+	}
+
+	var findings []string
+	for _, f := range files {
+		r, ok := index.ForPath(f.Path)
+		if !ok || !modulenames.IsFoundationFree(r.Kind) {
+			continue
+		}
+		for _, imp := range f.Imports {
+			// FoundationEssentials, FoundationNetworking and the like are
+			// Foundation too, unless the workspace builds the module.
+			if strings.HasPrefix(imp.Module, "Foundation") && !workspace[imp.Module] {
+				findings = append(findings, fmt.Sprintf("%s:%d imports %s in Foundation-free %s", f.Path, imp.Line, imp.Module, r.ModuleName))
+			}
+		}
+	}
+	for _, r := range free {
+		for _, dep := range r.Deps {
+			if d, ok := byLabel[dep]; ok && !modulenames.IsFoundationFree(d.Kind) && !strings.Contains(d.Kind, "test") {
+				findings = append(findings, fmt.Sprintf("Foundation-free %s depends on %s, which is not Foundation-free", r.Label, dep))
+			}
+		}
+	}
+	return NewResult(GateFoundation, fmt.Sprintf("%d Foundation-free modules checked", len(free)), findings)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
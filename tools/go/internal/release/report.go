@@ -0,0 +1,76 @@
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxFindings is the number of findings the Markdown checklist lists per
+// failed gate; the JSON report has them all.
+const maxFindings = 50
+
+// WriteMarkdown writes the checklist for the release pull request: a box
+// per gate, the findings of those that failed and the sign-off.
+func WriteMarkdown(w io.Writer, c *Checklist) error {
+	var b strings.Builder
+	b.WriteString("# Release Checklist\n\n")
+	fmt.Fprintf(&b, "Checked `%s` at `%s`", c.Branch, shortCommit(c.Commit))
+	if c.Base != "" {
+		fmt.Fprintf(&b, ", with the API compared against `%s`", c.Base)
+	}
+	b.WriteString(".\n\n")
+	for _, r := range c.Results {
+		box := " "
+		if r.Passed {
+			box = "x"
+		}
+		fmt.Fprintf(&b, "- [%s] **%s**: %s (%s)\n", box, r.Gate, r.Title, r.Summary)
+	}
+
+	for _, r := range c.Results {
+		if r.Passed {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", r.Gate)
+		if r.Error != "" {
+			fmt.Fprintf(&b, "The gate could not run: %s\n", r.Error)
+			continue
+		}
+		for i, f := range r.Findings {
+			if i == maxFindings {
+				fmt.Fprintf(&b, "- … and %d more\n", len(r.Findings)-maxFindings)
+				break
+			}
+			fmt.Fprintf(&b, "- %s\n", f)
+		}
+	}
+
+	b.WriteString("\n## Sign-off\n\n")
+	switch {
+	case c.SignedOff:
+		fmt.Fprintf(&b, "Signed off by %s on %s: every blocking gate passed at `%s`.\n", c.Signer, c.Checked.UTC().Format("2006-01-02"), shortCommit(c.Commit))
+	case c.Failures() > 0:
+		fmt.Fprintf(&b, "Not signed off: %d of %d blocking gates failed.\n", c.Failures(), len(c.Results))
+	default:
+		b.WriteString("Every blocking gate passed. Not yet signed off.\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the checklist as indented JSON.
+func WriteJSON(w io.Writer, c *Checklist) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
+
+func shortCommit(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
@@ -0,0 +1,116 @@
+package reportdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteMarkdown writes the new and resolved issues and, up to limit of
+// them, the metric changes. A limit of 0 lists every change.
+func WriteMarkdown(w io.Writer, d *Diff, limit int) error {
+	var b strings.Builder
+	b.WriteString("# Report Diff")
+	if d.Command != "" {
+		fmt.Fprintf(&b, ": %s", d.Command)
+	}
+	b.WriteString("\n\n")
+	fmt.Fprintf(&b, "Comparing %s with %s.\n\n", describe(d.Old), describe(d.New))
+	fmt.Fprintf(&b, "**%d new issues, %d resolved, %d metric changes of %s%% or more**\n",
+		len(d.Added), len(d.Resolved), len(d.Changes), strconv.FormatFloat(d.Threshold, 'f', -1, 64))
+
+	writeIssues(&b, "New issues", d.Added)
+	writeIssues(&b, "Resolved issues", d.Resolved)
+
+	if len(d.Changes) > 0 {
+		b.WriteString("\n## Metric changes\n\n")
+		b.WriteString("| Metric | Before | After | Change |\n")
+		b.WriteString("|--------|--------|-------|--------|\n")
+		shown := d.Changes
+		if limit > 0 && len(shown) > limit {
+			shown = shown[:limit]
+		}
+		for _, c := range shown {
+			before, after := number(c.Old), number(c.New)
+			switch {
+			case c.Added:
+				before = "-"
+			case c.Removed:
+				after = "-"
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", c.Metric, before, after, change(c))
+		}
+		if len(shown) < len(d.Changes) {
+			fmt.Fprintf(&b, "\n_%d smaller changes not shown._\n", len(d.Changes)-len(shown))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeIssues(b *strings.Builder, title string, issues []Issue) {
+	if len(issues) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n", title)
+	for _, i := range issues {
+		loc := i.File
+		if i.Line > 0 {
+			loc = fmt.Sprintf("%s:%d", i.File, i.Line)
+		}
+		switch {
+		case loc != "" && i.Kind != "":
+			fmt.Fprintf(b, "- `%s` %s: %s\n", loc, i.Kind, i.Message)
+		case loc != "":
+			fmt.Fprintf(b, "- `%s` %s\n", loc, i.Message)
+		case i.Kind != "":
+			fmt.Fprintf(b, "- %s: %s\n", i.Kind, i.Message)
+		default:
+			fmt.Fprintf(b, "- %s\n", i.Message)
+		}
+	}
+}
+
+// describe names a report by its file and, when stamped, its commit and
+// time.
+func describe(s Snapshot) string {
+	p := s.Provenance
+	if p == nil || p.GitSHA == "" {
+		return fmt.Sprintf("`%s`", s.File)
+	}
+	sha := p.GitSHA[:min(12, len(p.GitSHA))]
+	if p.Dirty {
+		sha += "+dirty"
+	}
+	return fmt.Sprintf("`%s` (`%s`, %s)", s.File, sha, p.Timestamp.Format(time.DateTime))
+}
+
+// number formats v to at most three decimals, which scores need and
+// counts do not show.
+func number(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+func change(c Change) string {
+	delta := c.New - c.Old
+	sign := "+"
+	if delta < 0 {
+		sign = ""
+	}
+	if math.IsInf(c.Percent, 0) {
+		return sign + number(delta)
+	}
+	return fmt.Sprintf("%s%s (%s%.1f%%)", sign, number(delta), sign, c.Percent)
+}
+
+// WriteJSON writes the diff as indented JSON.
+func WriteJSON(w io.Writer, d *Diff) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
@@ -0,0 +1,262 @@
+// Package reportdiff compares two JSON reports from the same analyzer: the
+// issues one has and the other has not, and the figures that changed.
+//
+// It works on any analyzer's JSON without knowing its schema. An object in
+// an array is an issue when it has a "message"; every other number in the
+// report, bar line and column numbers, is a metric named by its path.
+// Array elements are named by their "name", "path", "file", "id" or
+// similar field.
+package reportdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/provenance"
+)
+
+// Issue is one issue found in a report.
+type Issue struct {
+	// Key identifies the issue across runs: its fingerprint when the
+	// report has one, otherwise its fields without line and column
+	// numbers, which move as unrelated code changes, and without the
+	// severity, which --strict changes.
+	Key     string `json:"key"`
+	Kind    string `json:"kind,omitempty"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// Snapshot is a report reduced to its issues and metrics.
+type Snapshot struct {
+	File string `json:"file"`
+	// Provenance is nil for reports written before reports were stamped.
+	Provenance *provenance.Stamp  `json:"provenance,omitempty"`
+	Issues     []Issue            `json:"-"`
+	Metrics    map[string]float64 `json:"-"`
+}
+
+// Load reads a JSON report.
+func Load(file string) (*Snapshot, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	s := &Snapshot{File: file, Metrics: make(map[string]float64)}
+	if obj, ok := v.(map[string]any); ok {
+		if raw, ok := obj["provenance"]; ok {
+			delete(obj, "provenance")
+			var stamp provenance.Stamp
+			if b, err := json.Marshal(raw); err == nil && json.Unmarshal(b, &stamp) == nil {
+				s.Provenance = &stamp
+			}
+		}
+		// Bare lists are kept under "results"; name their metrics as
+		// before they were stamped.
+		if results, ok := obj["results"]; ok && len(obj) == 1 {
+			v = results
+		}
+	}
+	s.walk("", v)
+	return s, nil
+}
+
+// Command returns the analyzer that wrote the report, or "" if unknown.
+func (s *Snapshot) Command() string {
+	if s.Provenance == nil {
+		return ""
+	}
+	return s.Provenance.Command
+}
+
+// position are the fields holding where something is, not how much of it.
+var position = map[string]bool{"line": true, "column": true, "endLine": true, "endColumn": true}
+
+// identityFields name an array element, in order of preference.
+var identityFields = []string{"name", "path", "file", "id", "label", "moduleName", "protocol", "target", "item", "module"}
+
+func (s *Snapshot) walk(path string, v any) {
+	switch v := v.(type) {
+	case float64:
+		s.Metrics[path] = v
+	case map[string]any:
+		for key, child := range v {
+			if !position[key] {
+				s.walk(join(path, key), child)
+			}
+		}
+	case []any:
+		s.Metrics[join(path, "count")] = float64(len(v))
+		seen := make(map[string]int)
+		for i, child := range v {
+			obj, ok := child.(map[string]any)
+			if !ok {
+				continue
+			}
+			if _, ok := obj["message"].(string); ok {
+				s.Issues = append(s.Issues, newIssue(obj))
+				continue
+			}
+			id := strconv.Itoa(i)
+			for _, field := range identityFields {
+				if name, ok := obj[field].(string); ok && name != "" {
+					id = name
+					break
+				}
+			}
+			if seen[id]++; seen[id] > 1 {
+				id = fmt.Sprintf("%s#%d", id, seen[id])
+			}
+			s.walk(path+"["+id+"]", obj)
+		}
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func newIssue(obj map[string]any) Issue {
+	i := Issue{Message: obj["message"].(string)}
+	i.Kind = firstString(obj, "kind", "rule", "tag")
+	i.File = firstString(obj, "file", "path")
+	if line, ok := obj["line"].(float64); ok {
+		i.Line = int(line)
+	}
+
+	if fingerprint, ok := obj["fingerprint"].(string); ok && fingerprint != "" {
+		i.Key = fingerprint
+		return i
+	}
+	key := make(map[string]any, len(obj))
+	for field, value := range obj {
+		if !position[field] && field != "severity" {
+			key[field] = value
+		}
+	}
+	b, _ := json.Marshal(key) // map keys are sorted, so the key is stable
+	i.Key = string(b)
+	return i
+}
+
+func firstString(obj map[string]any, fields ...string) string {
+	for _, field := range fields {
+		if s, ok := obj[field].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// Change is a metric that differs between two reports.
+type Change struct {
+	Metric string  `json:"metric"`
+	Old    float64 `json:"old"`
+	New    float64 `json:"new"`
+	// Percent is the change relative to Old; it is infinite for metrics
+	// that were zero or absent.
+	Percent float64 `json:"-"`
+	// Added and Removed mark metrics only one report has.
+	Added   bool `json:"added,omitempty"`
+	Removed bool `json:"removed,omitempty"`
+}
+
+// Diff is the comparison of two reports.
+type Diff struct {
+	Command  string   `json:"command,omitempty"`
+	Old      Snapshot `json:"old"`
+	New      Snapshot `json:"new"`
+	Added    []Issue  `json:"newIssues"`
+	Resolved []Issue  `json:"resolvedIssues"`
+	Changes  []Change `json:"changes"`
+	// Threshold is the smallest relative change, in percent, reported.
+	Threshold float64 `json:"threshold"`
+}
+
+// Compare diffs before against after. Metrics are reported when they
+// changed by at least threshold percent, sorted by the size of the change.
+func Compare(before, after *Snapshot, threshold float64) (*Diff, error) {
+	if a, b := before.Command(), after.Command(); a != "" && b != "" && a != b {
+		return nil, fmt.Errorf("%s is a %s report but %s is a %s report", before.File, a, after.File, b)
+	}
+	d := &Diff{Command: after.Command(), Old: *before, New: *after, Changes: []Change{}, Threshold: threshold}
+	if d.Command == "" {
+		d.Command = before.Command()
+	}
+
+	d.Added = subtract(after.Issues, before.Issues)
+	d.Resolved = subtract(before.Issues, after.Issues)
+
+	for metric, n := range after.Metrics {
+		o, had := before.Metrics[metric]
+		if had && o == n {
+			continue
+		}
+		c := Change{Metric: metric, Old: o, New: n, Added: !had}
+		if c.Percent = percent(o, n); math.Abs(c.Percent) >= threshold {
+			d.Changes = append(d.Changes, c)
+		}
+	}
+	for metric, o := range before.Metrics {
+		if _, ok := after.Metrics[metric]; !ok {
+			c := Change{Metric: metric, Old: o, Removed: true, Percent: percent(o, 0)}
+			if math.Abs(c.Percent) >= threshold {
+				d.Changes = append(d.Changes, c)
+			}
+		}
+	}
+	sort.Slice(d.Changes, func(i, j int) bool {
+		a, b := d.Changes[i], d.Changes[j]
+		if da, db := math.Abs(a.New-a.Old), math.Abs(b.New-b.Old); da != db {
+			return da > db
+		}
+		return a.Metric < b.Metric
+	})
+	return d, nil
+}
+
+func percent(before, after float64) float64 {
+	if before == 0 {
+		if after == 0 {
+			return 0
+		}
+		return math.Inf(int(math.Copysign(1, after)))
+	}
+	return (after - before) / math.Abs(before) * 100
+}
+
+// subtract returns the issues of a whose key b does not have, as often as
+// a has them more than b does.
+func subtract(a, b []Issue) []Issue {
+	count := make(map[string]int)
+	for _, i := range b {
+		count[i.Key]++
+	}
+	out := []Issue{}
+	for _, i := range a {
+		if count[i.Key] > 0 {
+			count[i.Key]--
+			continue
+		}
+		out = append(out, i)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].File != out[j].File {
+			return out[i].File < out[j].File
+		}
+		return out[i].Line < out[j].Line
+	})
+	return out
+}
@@ -0,0 +1,90 @@
+package resticaudit
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/configschema"
+)
+
+// PolicyFile is the default policy, relative to the project root.
+const PolicyFile = "restic_policy.yaml"
+
+// Policy lists the rules restic invocations must follow, read from
+// restic_policy.yaml:
+//
+//	noLiteralValue: [--password-command, --password-file, --repo, -r]
+//	forbidden:
+//	  --insecure-no-password: Repositories must be encrypted with a password.
+//	required:
+//	  - flag: --json
+//	    option: jsonOutput
+//	noLiteralEnv: [RESTIC_PASSWORD, RESTIC_REPOSITORY]
+type Policy struct {
+	// NoLiteralValue are flags whose value must come from configuration,
+	// not from a string literal in the source.
+	NoLiteralValue []string `yaml:"noLiteralValue"`
+	// Forbidden maps flags never to be passed to the reason why.
+	Forbidden map[string]string `yaml:"forbidden"`
+	// Required are flags every invocation must pass.
+	Required []Required `yaml:"required"`
+	// NoLiteralEnv are environment variables that must not be set to a
+	// string literal.
+	NoLiteralEnv []string `yaml:"noLiteralEnv"`
+}
+
+// Required is a flag every invocation must pass.
+type Required struct {
+	Flag string `yaml:"flag"`
+	// Option is the Bool parameter of ResticTypes' CommonOptions that
+	// adds the flag; every CommonOptions construction must set it to true.
+	Option string `yaml:"option"`
+}
+
+// DefaultPolicy applies when the project has no policy file.
+var DefaultPolicy = Policy{
+	NoLiteralValue: []string{"--password-command", "--password-file", "--repo", "--repo2", "-r", "--repository-file"},
+	Forbidden: map[string]string{
+		"--insecure-no-password": "repositories must be protected by a password",
+		"--insecure-tls":         "TLS certificates must be verified",
+	},
+	Required:     []Required{{Flag: "--json", Option: "jsonOutput"}},
+	NoLiteralEnv: []string{"RESTIC_PASSWORD", "RESTIC_PASSWORD_COMMAND", "RESTIC_REPOSITORY", "RESTIC_REPOSITORY_FILE", "AWS_SECRET_ACCESS_KEY", "B2_ACCOUNT_KEY", "AZURE_ACCOUNT_KEY"},
+}
+
+// LoadPolicy reads and validates a policy file.
+func LoadPolicy(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := configschema.Validate(configschema.KindRestic, file, data); err != nil {
+		return nil, err
+	}
+
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	flags := append(append([]string(nil), p.NoLiteralValue...), mapKeys(p.Forbidden)...)
+	for _, r := range p.Required {
+		flags = append(flags, r.Flag)
+	}
+	for _, flag := range flags {
+		if !strings.HasPrefix(flag, "-") {
+			return nil, fmt.Errorf("%s: %q is not a flag", file, flag)
+		}
+	}
+	return &p, nil
+}
+
+func mapKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
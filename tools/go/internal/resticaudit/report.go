@@ -0,0 +1,55 @@
+package resticaudit
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+var headings = map[string]string{
+	IssueForbiddenFlag: "Forbidden Flags",
+	IssueInlineValue:   "Inlined Values",
+	IssueLiteralEnv:    "Literal Environment Variables",
+	IssueHardcodedRepo: "Hard-coded Repositories",
+	IssueMissingFlag:   "Missing Required Flags",
+	IssueOptionNotSet:  "Options Not Requesting Required Flags",
+}
+
+// WriteMarkdown writes the restic call sites and then the issues grouped
+// by kind.
+func WriteMarkdown(w io.Writer, r Report) error {
+	var b strings.Builder
+	b.WriteString("# Restic Invocation Audit\n\n")
+	fmt.Fprintf(&b, "**%d files mention restic, %d launch it, %d issues**\n", r.Files, len(r.Sites), len(r.Issues))
+
+	if len(r.Sites) > 0 {
+		b.WriteString("\n## Call Sites\n\n")
+		b.WriteString("| Module | Location | Arguments |\n")
+		b.WriteString("|--------|----------|-----------|\n")
+		for _, s := range r.Sites {
+			args := s.Arguments
+			if args == "" {
+				args = "(none)"
+			}
+			fmt.Fprintf(&b, "| %s | `%s:%d` | `%s` |\n", s.Module, s.File, s.Line, strings.ReplaceAll(args, "|", `\|`))
+		}
+	}
+
+	for _, kind := range Kinds {
+		var lines []string
+		for _, i := range r.Issues {
+			if i.Kind == kind {
+				lines = append(lines, fmt.Sprintf("- `%s:%d` %s\n", i.File, i.Line, i.Message))
+			}
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(&b, "\n## %s\n\n%s", headings[kind], strings.Join(lines, ""))
+		}
+	}
+	if len(r.Issues) == 0 {
+		b.WriteString("\nEvery restic invocation follows the policy.\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
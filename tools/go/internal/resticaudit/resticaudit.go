@@ -0,0 +1,333 @@
+// Package resticaudit checks how the backup layer invokes restic: the
+// Process call sites that launch it, the arguments the command types
+// build, and the environment and options they pass, against a policy.
+package resticaudit
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Issue kinds.
+const (
+	IssueForbiddenFlag = "forbidden_flag"
+	IssueInlineValue   = "inline_value"
+	IssueMissingFlag   = "missing_flag"
+	IssueOptionNotSet  = "option_not_set"
+	IssueLiteralEnv    = "literal_env"
+	IssueHardcodedRepo = "hardcoded_repository"
+)
+
+// Kinds lists every issue kind in report order.
+var Kinds = []string{IssueForbiddenFlag, IssueInlineValue, IssueLiteralEnv, IssueHardcodedRepo, IssueMissingFlag, IssueOptionNotSet}
+
+// Site is a Process that launches restic.
+type Site struct {
+	Module string `json:"module"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	// Arguments is the expression assigned to the process's arguments.
+	Arguments string `json:"arguments"`
+}
+
+// Issue is one policy violation.
+type Issue struct {
+	Kind        string `json:"kind"`
+	Module      string `json:"module"`
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Flag        string `json:"flag,omitempty"`
+	Message     string `json:"message"`
+	Text        string `json:"text"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Report is the result of Scan.
+type Report struct {
+	Files  int     `json:"files"`
+	Sites  []Site  `json:"sites"`
+	Issues []Issue `json:"issues"`
+}
+
+var (
+	processPattern   = regexp.MustCompile(`\b(?:let|var)\s+(\w+)\s*(?::\s*Process\s*)?=\s*Process\(\)`)
+	flagLiteral      = regexp.MustCompile(`"(-{1,2}[A-Za-z][\w-]*)(?:=((?:[^"\\]|\\.)*))?"`)
+	nextLiteral      = regexp.MustCompile(`^\s*,\s*"((?:[^"\\]|\\.)*)"`)
+	appendLiteral    = regexp.MustCompile(`\.append\(\s*"((?:[^"\\]|\\.)*)"\s*\)`)
+	envSubscript     = regexp.MustCompile(`\[\s*"(\w+)"\s*\]\s*=\s*"((?:[^"\\]|\\.)*)"`)
+	envEntry         = regexp.MustCompile(`"(\w+)"\s*:\s*"((?:[^"\\]|\\.)*)"`)
+	repositoryArg    = regexp.MustCompile(`\brepository:\s*"((?:[^"\\]|\\.)*)"`)
+	repositoryURL    = regexp.MustCompile(`"((?:s3|sftp|rest|b2|azure|gs|swift|rclone):[^"]+)"`)
+	delegatedArgs    = regexp.MustCompile(`(?:^|[^.\w]|\b(?:command|cmd|self)\.)arguments\b`)
+	commonOptionsNew = regexp.MustCompile(`\bCommonOptions\(`)
+)
+
+// statement is one Swift statement, joined across lines while brackets
+// are open.
+type statement struct {
+	line int
+	// code has its comments removed; bare also has its string literal
+	// contents removed.
+	code, bare string
+}
+
+// Scan audits the Swift files below the given top-level directories of
+// root (default "Sources") that mention restic.
+func Scan(root string, policy *Policy, dirs ...string) (Report, error) {
+	if len(dirs) == 0 {
+		dirs = []string{"Sources"}
+	}
+
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return Report{}, err
+		}
+	}
+	sort.Strings(paths)
+
+	type result struct {
+		restic bool
+		sites  []Site
+		issues []Issue
+	}
+	results, err := pool.Map(paths, func(rel string) (result, error) {
+		stmts, restic, err := readStatements(root, rel)
+		if err != nil || !restic {
+			return result{}, err
+		}
+		a := &auditor{policy: policy, rel: rel, module: workspace.ModuleForPath(rel), stmts: stmts}
+		a.run()
+		return result{true, a.sites, a.issues}, nil
+	})
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{Sites: []Site{}, Issues: []Issue{}}
+	for _, r := range results {
+		if r.restic {
+			report.Files++
+		}
+		report.Sites = append(report.Sites, r.sites...)
+		report.Issues = append(report.Issues, r.issues...)
+	}
+	return report, nil
+}
+
+// readStatements splits a file into statements and reports whether it
+// mentions restic at all.
+func readStatements(root, rel string) ([]statement, bool, error) {
+	f, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	var stmts []statement
+	var cur *statement
+	depth := 0
+	restic := strings.Contains(strings.ToLower(rel), "restic")
+	inComment := false
+	scanner := textscan.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := scanner.Text()
+		restic = restic || strings.Contains(strings.ToLower(raw), "restic")
+		code, _ := swiftsrc.RemoveComments(raw, inComment)
+		var bare string
+		bare, inComment = swiftsrc.StripComments(raw, inComment)
+		code, bare = strings.TrimSpace(code), strings.TrimSpace(bare)
+		if bare == "" && cur == nil {
+			continue
+		}
+		if cur == nil {
+			cur = &statement{line: lineNo, code: code, bare: bare}
+		} else {
+			cur.code += " " + code
+			cur.bare += " " + bare
+		}
+		depth += strings.Count(bare, "(") + strings.Count(bare, "[") - strings.Count(bare, ")") - strings.Count(bare, "]")
+		if depth <= 0 {
+			stmts = append(stmts, *cur)
+			cur, depth = nil, 0
+		}
+	}
+	if cur != nil {
+		stmts = append(stmts, *cur)
+	}
+	return stmts, restic, textscan.Check(rel, lineNo, scanner.Err())
+}
+
+type auditor struct {
+	policy *Policy
+	rel    string
+	module string
+	stmts  []statement
+	sites  []Site
+	issues []Issue
+}
+
+func (a *auditor) add(kind string, s statement, flag, format string, args ...any) {
+	a.issues = append(a.issues, Issue{Kind: kind, Module: a.module, File: a.rel, Line: s.line, Flag: flag,
+		Message: fmt.Sprintf(format, args...), Text: s.code})
+}
+
+func (a *auditor) run() {
+	for i, s := range a.stmts {
+		a.flags(i, s)
+		a.environment(s)
+		a.repository(s)
+		if commonOptionsNew.MatchString(s.bare) {
+			a.options(s)
+		}
+		if m := processPattern.FindStringSubmatch(s.bare); m != nil {
+			a.process(i, m[1])
+		}
+	}
+}
+
+// flags checks the flag literals of a statement.
+func (a *auditor) flags(i int, s statement) {
+	for _, loc := range flagLiteral.FindAllStringSubmatchIndex(s.code, -1) {
+		flag := s.code[loc[2]:loc[3]]
+		if reason, ok := a.policy.Forbidden[flag]; ok {
+			a.add(IssueForbiddenFlag, s, flag, "%s must not be passed: %s", flag, reason)
+		}
+		if !contains(a.policy.NoLiteralValue, flag) {
+			continue
+		}
+
+		value, literal := "", false
+		switch {
+		case loc[4] >= 0:
+			value, literal = s.code[loc[4]:loc[5]], true
+		case nextLiteral.MatchString(s.code[loc[1]:]):
+			value, literal = nextLiteral.FindStringSubmatch(s.code[loc[1]:])[1], true
+		case appendLiteral.MatchString(s.code) && i+1 < len(a.stmts):
+			if m := appendLiteral.FindStringSubmatch(a.stmts[i+1].code); m != nil {
+				value, literal = m[1], true
+			}
+		}
+		if literal && !strings.Contains(value, `\(`) {
+			a.add(IssueInlineValue, s, flag, "%s is given the literal %q; take it from configuration", flag, value)
+		}
+	}
+}
+
+// environment checks environment variables set to literals.
+func (a *auditor) environment(s statement) {
+	for _, pattern := range []*regexp.Regexp{envSubscript, envEntry} {
+		for _, m := range pattern.FindAllStringSubmatch(s.code, -1) {
+			if contains(a.policy.NoLiteralEnv, m[1]) && !strings.Contains(m[2], `\(`) {
+				a.add(IssueLiteralEnv, s, "", "%s is set to a string literal", m[1])
+			}
+		}
+	}
+}
+
+// repository checks for repository locations written into the source.
+func (a *auditor) repository(s statement) {
+	for _, m := range repositoryArg.FindAllStringSubmatch(s.code, -1) {
+		if m[1] != "" && !strings.Contains(m[1], `\(`) {
+			a.add(IssueHardcodedRepo, s, "", "repository is hard-coded as %q", m[1])
+		}
+	}
+	for _, m := range repositoryURL.FindAllStringSubmatch(s.code, -1) {
+		if !repositoryArg.MatchString(s.code) {
+			a.add(IssueHardcodedRepo, s, "", "repository location %q is hard-coded", m[1])
+		}
+	}
+}
+
+// options checks that a CommonOptions construction turns on the options
+// behind the required flags. Passing another value through, as in
+// "jsonOutput: options.jsonOutput", is accepted.
+func (a *auditor) options(s statement) {
+	for _, r := range a.policy.Required {
+		if r.Option == "" {
+			continue
+		}
+		set := regexp.MustCompile(`\b` + regexp.QuoteMeta(r.Option) + `:\s*([^,)]+)`).FindStringSubmatch(s.bare)
+		if set == nil || strings.TrimSpace(set[1]) == "false" {
+			a.add(IssueOptionNotSet, s, r.Flag, "CommonOptions is built without %s: true, so restic runs without %s", r.Option, r.Flag)
+		}
+	}
+}
+
+// process follows the Process declared at statement i through the
+// statements configuring it, up to its run() or launch().
+func (a *auditor) process(i int, name string) {
+	prefix := name + "."
+	site := Site{Module: a.module, File: a.rel, Line: a.stmts[i].line}
+	var args *statement
+	for j := i + 1; j < len(a.stmts); j++ {
+		s := a.stmts[j]
+		if processPattern.MatchString(s.bare) {
+			break
+		}
+		if rhs, ok := assignment(s.code, prefix+"arguments"); ok {
+			site.Arguments = rhs
+			args = &a.stmts[j]
+		}
+		if strings.Contains(s.bare, prefix+"run()") || strings.Contains(s.bare, prefix+"launch()") {
+			break
+		}
+	}
+	a.sites = append(a.sites, site)
+
+	for _, r := range a.policy.Required {
+		switch {
+		case args == nil:
+			a.add(IssueMissingFlag, a.stmts[i], r.Flag, "restic is launched without arguments, so without %s", r.Flag)
+		case strings.Contains(args.code, `"`+r.Flag+`"`), strings.Contains(args.code, `"`+r.Flag+`=`):
+		case delegatedArgs.MatchString(args.bare) && !strings.Contains(args.bare, "options.arguments"):
+			// The command's arguments property adds the flag when its
+			// options ask for it, which options() checks.
+		default:
+			a.add(IssueMissingFlag, *args, r.Flag, "restic is launched without %s", r.Flag)
+		}
+	}
+}
+
+// assignment returns the right-hand side of code if it assigns lhs.
+func assignment(code, lhs string) (string, bool) {
+	rest, ok := strings.CutPrefix(code, lhs)
+	if !ok {
+		return "", false
+	}
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, "==") {
+		return "", false
+	}
+	return strings.TrimSpace(rest[1:]), true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package rulepack
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Finding is one match of a rule.
+type Finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Module   string `json:"module"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+	// Fix is the text the rule's fix puts in place of the match.
+	Fix *string `json:"fix,omitempty"`
+	// Fixed reports that the fix was written to the file.
+	Fixed bool `json:"fixed,omitempty"`
+	// Fingerprint identifies the issue of a finding left unfixed.
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// applied is false for a fix given up for overlapping an earlier one.
+	applied bool
+}
+
+// Options control a check.
+type Options struct {
+	// Dirs are the directories searched, relative to the root.
+	Dirs []string
+	// Modules name the module of each file.
+	Modules []modulenames.Rule
+	// Fix writes the fixes of the matches to their files.
+	Fix bool
+}
+
+// Check matches rules against the files below opts.Dirs and returns the
+// findings sorted by file and position. With opts.Fix it also rewrites
+// each file that has fixable matches, and stops between files once ctx is
+// done. On an error it returns the findings of the files it got through,
+// so that those it fixed are known.
+func Check(ctx context.Context, root string, rules []*Rule, opts Options) ([]Finding, error) {
+	var paths []string
+	for _, dir := range opts.Dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{}, func(rel string) error {
+			rel = path.Join(filepath.ToSlash(dir), rel)
+			for _, r := range rules {
+				if r.Covers(rel) {
+					paths = append(paths, rel)
+					break
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+	paths = compact(paths)
+
+	index := &moduleindex.Index{Modules: opts.Modules}
+	perFile, err := pool.MapContext(ctx, paths, func(rel string) ([]Finding, error) {
+		full := filepath.Join(root, rel)
+		data, err := os.ReadFile(full)
+		if err != nil {
+			return nil, err
+		}
+		module := workspace.ModuleForPath(rel)
+		if r, ok := index.ForPath(rel); ok {
+			module = r.ModuleName
+		}
+		findings, fixed := match(rel, module, string(data), rules)
+		if opts.Fix && fixed != string(data) {
+			info, err := os.Stat(full)
+			if err != nil {
+				return nil, err
+			}
+			if err := atomicfile.WriteFile(full, []byte(fixed), info.Mode().Perm()); err != nil {
+				return nil, err
+			}
+			for i := range findings {
+				findings[i].Fixed = findings[i].applied
+			}
+		}
+		return findings, nil
+	})
+	var out []Finding
+	for _, f := range perFile {
+		out = append(out, f...)
+	}
+	return out, err
+}
+
+// edit is a fix in file offsets, for the finding at index finding.
+type edit struct {
+	start, end int
+	text       string
+	finding    int
+}
+
+// match returns the findings of rules in content and content with their
+// fixes applied. Where fixes overlap, the first by position wins.
+func match(rel, module, content string, rules []*Rule) ([]Finding, string) {
+	var applicable []*Rule
+	for _, r := range rules {
+		if r.Covers(rel) {
+			applicable = append(applicable, r)
+		}
+	}
+
+	var (
+		findings  []Finding
+		edits     []edit
+		inComment bool
+		offset    int
+	)
+	for n, line := range strings.Split(content, "\n") {
+		start := offset
+		offset += len(line) + 1
+		line = strings.TrimSuffix(line, "\r")
+		var code string
+		code, inComment = swiftsrc.Blank(line, inComment)
+		for _, r := range applicable {
+			for _, m := range r.re.FindAllStringSubmatchIndex(code, -1) {
+				from, to := m[0], m[1]
+				if r.target > 0 && m[2*r.target] >= 0 {
+					from, to = m[2*r.target], m[2*r.target+1]
+				}
+				if r.accept != nil && !r.accept(line[from:to]) {
+					continue
+				}
+				f := Finding{
+					Rule:     r.ID,
+					Severity: r.Severity,
+					Module:   module,
+					File:     rel,
+					Line:     n + 1,
+					Column:   from + 1,
+					Message:  string(r.re.ExpandString(nil, r.Message, line, m)),
+				}
+				if r.Fix != nil {
+					text := string(r.re.ExpandString(nil, *r.Fix, line, m))
+					f.Fix = &text
+					edits = append(edits, edit{start + from, start + to, text, len(findings)})
+				}
+				findings = append(findings, f)
+			}
+		}
+	}
+
+	fixed := content
+	if len(edits) > 0 {
+		sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+		var b strings.Builder
+		last := 0
+		for _, e := range edits {
+			if e.start < last {
+				continue
+			}
+			b.WriteString(content[last:e.start])
+			b.WriteString(e.text)
+			last = e.end
+			findings[e.finding].applied = true
+		}
+		b.WriteString(content[last:])
+		fixed = b.String()
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return a.Rule < b.Rule
+	})
+	return findings, fixed
+}
+
+func compact(sorted []string) []string {
+	out := sorted[:0]
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
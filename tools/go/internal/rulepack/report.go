@@ -0,0 +1,79 @@
+package rulepack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes a table of the rules with their finding counts,
+// then the findings of each rule that has any.
+func WriteMarkdown(w io.Writer, rules []*Rule, findings []Finding) error {
+	byRule := make(map[string][]Finding)
+	fixed := 0
+	for _, f := range findings {
+		byRule[f.Rule] = append(byRule[f.Rule], f)
+		if f.Fixed {
+			fixed++
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("# Rule Check\n\n")
+	if len(rules) == 0 {
+		b.WriteString("No rule packs found.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	fmt.Fprintf(&b, "**%d findings from %d rules", len(findings), len(rules))
+	if fixed > 0 {
+		fmt.Fprintf(&b, ", %d fixed", fixed)
+	}
+	b.WriteString("**\n\n")
+	b.WriteString("| Rule | Severity | Findings | Pack |\n")
+	b.WriteString("|------|----------|----------|------|\n")
+	for _, r := range rules {
+		fmt.Fprintf(&b, "| %s | %s | %d | `%s` |\n", r.ID, r.Severity, len(byRule[r.ID]), r.Pack)
+	}
+
+	for _, r := range rules {
+		found := byRule[r.ID]
+		if len(found) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n\n", r.ID, r.Message)
+		for _, f := range found {
+			fmt.Fprintf(&b, "- `%s:%d:%d`", f.File, f.Line, f.Column)
+			if f.Message != r.Message {
+				fmt.Fprintf(&b, " %s", f.Message)
+			}
+			switch {
+			case f.Fixed:
+				fmt.Fprintf(&b, " (fixed: `%s`)", *f.Fix)
+			case f.Fix != nil:
+				fmt.Fprintf(&b, " (fix: `%s`)", *f.Fix)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the rules and findings as indented JSON.
+func WriteJSON(w io.Writer, rules []*Rule, findings []Finding) error {
+	if rules == nil {
+		rules = []*Rule{}
+	}
+	if findings == nil {
+		findings = []Finding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Rules    []*Rule   `json:"rules"`
+		Findings []Finding `json:"findings"`
+	}{rules, findings})
+}
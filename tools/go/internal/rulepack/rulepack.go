@@ -0,0 +1,267 @@
+// Package rulepack checks source files against pattern rules read from
+// YAML rule packs, so that a convention such as a logging API, a banned
+// call or a naming rule is a few lines of data rather than a new checker.
+package rulepack
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/configschema"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// Severities.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// DefaultDir is where rule packs are kept, relative to the project root.
+const DefaultDir = "rules"
+
+// Query is a structural match, for rules that a regular expression would
+// state poorly. Exactly one of Call, Import and Declaration is set.
+type Query struct {
+	// Call matches calls of a function or qualified method, as in "NSLog"
+	// or "NSKeyedUnarchiver.unarchiveObject"; a leading dot, as in
+	// ".synchronize", matches the method on any receiver.
+	Call string `yaml:"call" json:"call,omitempty"`
+	// Import matches imports of the modules matching a glob.
+	Import string `yaml:"import" json:"import,omitempty"`
+	// Declaration matches declarations of a kind: class, struct, enum,
+	// protocol, actor, extension, func, typealias, var, let or case.
+	Declaration string `yaml:"declaration" json:"declaration,omitempty"`
+	// Name and Unless are regular expressions the name declared must and
+	// must not match to be reported.
+	Name   string `yaml:"name" json:"name,omitempty"`
+	Unless string `yaml:"unless" json:"unless,omitempty"`
+}
+
+// Rule is one check of a pack:
+//
+//	rules:
+//	  - id: no-nslog
+//	    message: Log through UmbraLogging rather than NSLog
+//	    severity: warning
+//	    query:
+//	      call: NSLog
+//	  - id: prefer-is-empty
+//	    message: Use isEmpty rather than comparing count with zero
+//	    pattern: '\.count\s*==\s*0\b'
+//	    fix: '.isEmpty'
+//	    paths: [Sources/**]
+//
+// A rule has either a Pattern or a Query. Patterns are matched one line
+// at a time against the code, with comments and the contents of string
+// literals blanked out.
+type Rule struct {
+	ID       string `yaml:"id" json:"id"`
+	Message  string `yaml:"message" json:"message"`
+	Severity string `yaml:"severity" json:"severity"`
+	Pattern  string `yaml:"pattern" json:"pattern,omitempty"`
+	Query    *Query `yaml:"query" json:"query,omitempty"`
+	// Fix, when set, replaces the match: the group named target when the
+	// pattern has one, the whole match otherwise, and the name matched for
+	// a query. $1 and ${name} in Fix and Message expand to the groups.
+	Fix *string `yaml:"fix" json:"fix,omitempty"`
+	// Paths and Exclude are walker.Match globs of the files checked, all
+	// files by default.
+	Paths   []string `yaml:"paths" json:"paths,omitempty"`
+	Exclude []string `yaml:"exclude" json:"exclude,omitempty"`
+	// Extensions are the file extensions checked, .swift by default.
+	Extensions []string `yaml:"extensions" json:"extensions"`
+	// Pack is the file the rule was read from, relative to the project
+	// root.
+	Pack string `yaml:"-" json:"pack"`
+
+	re     *regexp.Regexp
+	target int
+	accept func(name string) bool
+}
+
+var declarationKinds = []string{"class", "struct", "enum", "protocol", "actor", "extension", "func", "typealias", "var", "let", "case"}
+
+// Load reads the rule packs, *.yaml and *.yml files, of dir below root. A
+// missing directory has no rules.
+func Load(root, dir string) ([]*Rule, error) {
+	entries, err := os.ReadDir(filepath.Join(root, dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rules []*Rule
+	byID := make(map[string]*Rule)
+	for _, e := range entries {
+		if e.IsDir() || (path.Ext(e.Name()) != ".yaml" && path.Ext(e.Name()) != ".yml") {
+			continue
+		}
+		rel := path.Join(filepath.ToSlash(dir), e.Name())
+		pack, err := LoadPack(filepath.Join(root, rel))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range pack {
+			r.Pack = rel
+			if prev, dup := byID[r.ID]; dup {
+				return nil, fmt.Errorf("rule %s is defined in both %s and %s", r.ID, prev.Pack, r.Pack)
+			}
+			byID[r.ID] = r
+			rules = append(rules, r)
+		}
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules, nil
+}
+
+// LoadPack reads and compiles the rules of one pack file.
+func LoadPack(file string) ([]*Rule, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := configschema.Validate(configschema.KindRulePack, file, data); err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Rules []*Rule `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for i, r := range doc.Rules {
+		if r.ID == "" {
+			return nil, fmt.Errorf("%s: rule %d: id is required", file, i+1)
+		}
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("%s: rule %s: %w", file, r.ID, err)
+		}
+	}
+	return doc.Rules, nil
+}
+
+func (r *Rule) compile() error {
+	if r.Message == "" {
+		return fmt.Errorf("message is required")
+	}
+	switch r.Severity {
+	case "":
+		r.Severity = SeverityWarning
+	case SeverityError, SeverityWarning, SeverityInfo:
+	default:
+		return fmt.Errorf("unknown severity %q (want error, warning or info)", r.Severity)
+	}
+	if len(r.Extensions) == 0 {
+		r.Extensions = []string{".swift"}
+	}
+	for _, glob := range append(slices.Clone(r.Paths), r.Exclude...) {
+		if _, err := path.Match(strings.ReplaceAll(glob, "**", "*"), ""); err != nil {
+			return fmt.Errorf("bad glob %q: %w", glob, err)
+		}
+	}
+
+	expr, err := r.expression()
+	if err != nil {
+		return err
+	}
+	if r.re, err = regexp.Compile(expr); err != nil {
+		return err
+	}
+	r.target = r.re.SubexpIndex("target")
+	return nil
+}
+
+// expression returns the regular expression of the rule and sets accept
+// for the queries that filter the names matched.
+func (r *Rule) expression() (string, error) {
+	if (r.Pattern == "") == (r.Query == nil) {
+		return "", fmt.Errorf("set exactly one of pattern and query")
+	}
+	if r.Pattern != "" {
+		return r.Pattern, nil
+	}
+
+	q := r.Query
+	set := 0
+	for _, s := range []string{q.Call, q.Import, q.Declaration} {
+		if s != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return "", fmt.Errorf("set exactly one of call, import and declaration in the query")
+	}
+	switch {
+	case q.Call != "":
+		name := strings.ReplaceAll(regexp.QuoteMeta(strings.TrimPrefix(q.Call, ".")), `\.`, `\s*\.\s*`)
+		if strings.HasPrefix(q.Call, ".") {
+			return `\.\s*(?P<target>` + name + `)\s*\(`, nil
+		}
+		return `(?:^|[^\w.])(?P<target>` + name + `)\s*\(`, nil
+	case q.Import != "":
+		if _, err := path.Match(q.Import, ""); err != nil {
+			return "", fmt.Errorf("bad import glob %q: %w", q.Import, err)
+		}
+		r.accept = func(name string) bool {
+			ok, _ := path.Match(q.Import, name)
+			return ok
+		}
+		return `^\s*(?:@\w+(?:\([^)]*\))?\s+)*import\s+(?:(?:typealias|struct|class|enum|protocol|let|var|func)\s+)?(?P<target>[A-Za-z_][\w.]*)`, nil
+	default:
+		if !slices.Contains(declarationKinds, q.Declaration) {
+			return "", fmt.Errorf("unknown declaration kind %q (want one of %s)", q.Declaration, strings.Join(declarationKinds, ", "))
+		}
+		var name, unless *regexp.Regexp
+		var err error
+		if q.Name != "" {
+			if name, err = regexp.Compile(q.Name); err != nil {
+				return "", err
+			}
+		}
+		if q.Unless != "" {
+			if unless, err = regexp.Compile(q.Unless); err != nil {
+				return "", err
+			}
+		}
+		r.accept = func(s string) bool {
+			// "class func f()" declares f, not a class called func.
+			if slices.Contains(declarationKinds, s) {
+				return false
+			}
+			return (name == nil || name.MatchString(s)) && (unless == nil || !unless.MatchString(s))
+		}
+		return `(?:^|[^\w.@])` + q.Declaration + `\s+(?P<target>[A-Za-z_][\w.]*)`, nil
+	}
+}
+
+// Covers reports whether the rule checks the file rel.
+func (r *Rule) Covers(rel string) bool {
+	if !slices.Contains(r.Extensions, path.Ext(rel)) {
+		return false
+	}
+	for _, glob := range r.Exclude {
+		if walker.Match(glob, rel) {
+			return false
+		}
+	}
+	if len(r.Paths) == 0 {
+		return true
+	}
+	for _, glob := range r.Paths {
+		if walker.Match(glob, rel) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,286 @@
+// Package satellite writes the skeleton of a repository split out of
+// UmbraCore: MODULE.bazel with UmbraCore's dependency versions, its Bazel
+// macros and compiler options, a .bazelrc with the prod, dev and test
+// profiles, umbratool's config, a docs site, and the initial modules with
+// their tests.
+package satellite
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/bazelbuild/buildtools/build"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/header"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// UmbraCoreRemote is the repository satellites depend on.
+const UmbraCoreRemote = "https://github.com/mpy-dev-ml/UmbraCore"
+
+// DefaultOrg hosts new satellites unless Options.RepoURL says otherwise.
+const DefaultOrg = "https://github.com/mpy-dev-ml/"
+
+// defaultMacOSMinimum is used when UmbraCore's .bazelrc sets no minimum.
+const defaultMacOSMinimum = "14.7.4"
+
+// copied are UmbraCore's files a satellite takes as they are, so that its
+// targets build the way UmbraCore's do.
+var copied = []struct {
+	path     string
+	required bool
+}{
+	{"bazel/macros/swift.bzl", true},
+	{"tools/swift/compiler_options.bzl", true},
+	{".swiftformat", false},
+}
+
+// bazelDeps are the bazel_deps of UmbraCore a satellite declares too.
+var bazelDeps = []string{"platforms", "bazel_skylib", "rules_swift", "rules_apple", "apple_support"}
+
+var (
+	repoName     = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+	bazelName    = regexp.MustCompile(`^[a-z]([a-z0-9._-]*[a-z0-9])?$`)
+	swiftIdent   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	macOSMinimum = regexp.MustCompile(`(?m)^build\s+--macos_minimum_os=(\S+)`)
+)
+
+// Options configures Plan.
+type Options struct {
+	// Name is the repository's name, as UmbraBackup.
+	Name string
+	// BazelName is its Bazel module name; empty derives it from Name, as
+	// umbra_backup.
+	BazelName string
+	// Modules are the Swift modules it starts with.
+	Modules []string
+	// RepoURL is where it is hosted; empty means DefaultOrg + Name.
+	RepoURL string
+	// Commit pins the UmbraCore dependency with a git_override; empty
+	// leaves the override out.
+	Commit string
+}
+
+// File is one file of the skeleton.
+type File struct {
+	// Path is relative to the new repository's root.
+	Path    string
+	Content []byte
+}
+
+// Plan returns the skeleton's files, sorted by path, reading the versions
+// and copied files from the UmbraCore checkout at root.
+func Plan(root string, opts Options) ([]File, error) {
+	if !repoName.MatchString(opts.Name) {
+		return nil, fmt.Errorf("repository name %q must start with a letter and hold only letters, digits, - and _", opts.Name)
+	}
+	if opts.BazelName == "" {
+		opts.BazelName = SnakeCase(opts.Name)
+	}
+	if !bazelName.MatchString(opts.BazelName) {
+		return nil, fmt.Errorf("Bazel module name %q must be lower case letters, digits, ., - and _, starting with a letter", opts.BazelName)
+	}
+	if opts.RepoURL == "" {
+		opts.RepoURL = DefaultOrg + opts.Name
+	}
+	seen := make(map[string]bool)
+	for _, m := range opts.Modules {
+		if !swiftIdent.MatchString(m) {
+			return nil, fmt.Errorf("module name %q is not a Swift identifier", m)
+		}
+		if seen[m] {
+			return nil, fmt.Errorf("module %s is listed twice", m)
+		}
+		seen[m] = true
+	}
+
+	deps, version, err := readModule(root)
+	if err != nil {
+		return nil, err
+	}
+	minimum := defaultMacOSMinimum
+	if data, err := os.ReadFile(filepath.Join(root, ".bazelrc")); err == nil {
+		if m := macOSMinimum.FindSubmatch(data); m != nil {
+			minimum = string(m[1])
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	override := "# Pin UmbraCore with a git_override here until it is published to a registry."
+	if opts.Commit != "" {
+		override = fmt.Sprintf("git_override(\n    module_name = \"umbracore\",\n    remote = %q,\n    commit = %q,\n)", UmbraCoreRemote+".git", opts.Commit)
+	}
+
+	var nav, moduleList strings.Builder
+	if len(opts.Modules) > 0 {
+		nav.WriteString("  - Modules:\n")
+		moduleList.WriteString("\n## Modules\n\n")
+		for _, m := range opts.Modules {
+			fmt.Fprintf(&nav, "      - %s: modules/%s.md\n", m, m)
+			fmt.Fprintf(&moduleList, "- [%s](modules/%s.md)\n", m, m)
+		}
+	}
+	vars := strings.NewReplacer(
+		"@NAME@", opts.Name,
+		"@BAZEL_NAME@", opts.BazelName,
+		"@REPO_URL@", opts.RepoURL,
+		"@BAZEL_DEPS@", deps,
+		"@UMBRACORE_VERSION@", version,
+		"@UMBRACORE_OVERRIDE@", override,
+		"@MACOS_MINIMUM@", minimum,
+		"@NAV@\n", nav.String(),
+		"@MODULE_LIST@", moduleList.String(),
+	)
+
+	var files []File
+	add := func(rel, tmpl string, r *strings.Replacer) error {
+		data, err := templates.ReadFile("templates/" + tmpl + ".tmpl")
+		if err != nil {
+			return err
+		}
+		files = append(files, File{Path: rel, Content: []byte(r.Replace(string(data)))})
+		return nil
+	}
+	for rel, tmpl := range map[string]string{
+		"MODULE.bazel":            "MODULE.bazel",
+		"BUILD.bazel":             "BUILD.root",
+		".bazelrc":                "bazelrc",
+		".gitignore":              "gitignore",
+		"README.md":               "README.md",
+		"umbratool.yaml":          "umbratool.yaml",
+		"tag_policy.yaml":         "tag_policy.yaml",
+		"mkdocs.yml":              "mkdocs.yml",
+		"docs/index.md":           "index.md",
+		"tools/swift/BUILD.bazel": "BUILD.tools_swift",
+	} {
+		if err := add(rel, tmpl, vars); err != nil {
+			return nil, err
+		}
+	}
+
+	headers := header.NewChecker()
+	for _, m := range opts.Modules {
+		r := strings.NewReplacer("@MODULE@", m, "@NAME@", opts.Name)
+		for _, f := range []struct{ rel, tmpl string }{
+			{"Sources/" + m + "/BUILD.bazel", "BUILD.module"},
+			{"Sources/" + m + "/" + m + ".swift", "module.swift"},
+			{"Tests/" + m + "Tests/BUILD.bazel", "BUILD.test"},
+			{"Tests/" + m + "Tests/" + m + "Tests.swift", "test.swift"},
+			{"docs/modules/" + m + ".md", "module.md"},
+		} {
+			if err := add(f.rel, f.tmpl, r); err != nil {
+				return nil, err
+			}
+			if path.Ext(f.rel) == ".swift" {
+				last := &files[len(files)-1]
+				last.Content = []byte(headers.FixContent(f.rel, string(last.Content)))
+			}
+		}
+	}
+
+	for _, c := range copied {
+		data, err := os.ReadFile(filepath.Join(root, c.path))
+		if os.IsNotExist(err) && !c.required {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Path: c.path, Content: data})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// readModule returns the bazel_dep lines of UmbraCore's MODULE.bazel for
+// bazelDeps, and UmbraCore's version.
+func readModule(root string) (string, string, error) {
+	const name = "MODULE.bazel"
+	data, err := os.ReadFile(filepath.Join(root, name))
+	if err != nil {
+		return "", "", err
+	}
+	f, err := build.ParseModule(name, data)
+	if err != nil {
+		return "", "", err
+	}
+	version := ""
+	found := make(map[string]*build.Rule)
+	for _, r := range f.Rules("") {
+		switch r.Kind() {
+		case "module":
+			version = r.AttrString("version")
+		case "bazel_dep":
+			found[r.AttrString("name")] = r
+		}
+	}
+	if version == "" {
+		return "", "", fmt.Errorf("%s declares no module version for satellites to depend on", name)
+	}
+	var b strings.Builder
+	for _, dep := range bazelDeps {
+		r := found[dep]
+		if r == nil {
+			continue
+		}
+		fmt.Fprintf(&b, "bazel_dep(name = %q, version = %q", dep, r.AttrString("version"))
+		if repo := r.AttrString("repo_name"); repo != "" {
+			fmt.Fprintf(&b, ", repo_name = %q", repo)
+		}
+		b.WriteString(")\n")
+	}
+	return b.String(), version, nil
+}
+
+// SnakeCase turns a repository name into a Bazel module name, as
+// UmbraBackup into umbra_backup and XPCTools into xpc_tools.
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if r == '-' {
+			r = '_'
+		}
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextLower {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// Write writes files below dir. It refuses a dir that already has files
+// in it unless force is set, when the skeleton's files are overwritten.
+func Write(dir string, files []File, force bool) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(entries) > 0 && !force {
+		return fmt.Errorf("%s is not empty; pass --force to write the skeleton into it", dir)
+	}
+	for _, f := range files {
+		full := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			return err
+		}
+		if err := atomicfile.WriteFile(full, f.Content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
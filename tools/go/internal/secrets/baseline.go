@@ -0,0 +1,95 @@
+package secrets
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+)
+
+// BaselineFile is the default baseline, relative to the project root.
+const BaselineFile = ".secrets-baseline.json"
+
+// Baseline lists the accepted findings: test fixtures, public
+// certificates and false positives.
+type Baseline struct {
+	Allow []Allowed `json:"allow"`
+}
+
+// Allowed is one accepted finding.
+type Allowed struct {
+	Fingerprint string `json:"fingerprint"`
+	Rule        string `json:"rule"`
+	File        string `json:"file"`
+	// Line is where the finding was when it was accepted; it only helps
+	// readers find it.
+	Line int `json:"line"`
+	// Reason says why the finding is not a secret.
+	Reason string `json:"reason,omitempty"`
+}
+
+// LoadBaseline reads a baseline file. A missing file is an empty baseline.
+func LoadBaseline(file string) (*Baseline, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return &Baseline{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Filter returns the findings the baseline does not accept.
+func (b *Baseline) Filter(findings []Finding) []Finding {
+	accepted := make(map[string]bool, len(b.Allow))
+	for _, a := range b.Allow {
+		accepted[a.Fingerprint] = true
+	}
+	var out []Finding
+	for _, f := range findings {
+		if !accepted[f.Fingerprint] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Update returns a baseline accepting exactly the given findings, keeping
+// the reasons already recorded for them.
+func (b *Baseline) Update(findings []Finding) *Baseline {
+	reasons := make(map[string]string, len(b.Allow))
+	for _, a := range b.Allow {
+		reasons[a.Fingerprint] = a.Reason
+	}
+	updated := &Baseline{Allow: []Allowed{}}
+	seen := make(map[string]bool)
+	for _, f := range findings {
+		if seen[f.Fingerprint] {
+			continue
+		}
+		seen[f.Fingerprint] = true
+		updated.Allow = append(updated.Allow, Allowed{Fingerprint: f.Fingerprint, Rule: f.Rule, File: f.File, Line: f.Line, Reason: reasons[f.Fingerprint]})
+	}
+	sort.SliceStable(updated.Allow, func(i, j int) bool {
+		if updated.Allow[i].File != updated.Allow[j].File {
+			return updated.Allow[i].File < updated.Allow[j].File
+		}
+		return updated.Allow[i].Line < updated.Allow[j].Line
+	})
+	return updated
+}
+
+// Write saves the baseline as indented JSON.
+func (b *Baseline) Write(file string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(file, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,339 @@
+// Package secrets finds hard-coded credentials in the Swift, Go, property
+// list and Bazel files of the tree: known key and token formats, private
+// keys and certificates, string literals assigned to password-like names,
+// and high-entropy string literals.
+package secrets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/staged"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Rules.
+const (
+	RulePrivateKey  = "private_key"
+	RuleCertificate = "certificate"
+	RuleAWSKey      = "aws_access_key"
+	RuleGitHubToken = "github_token"
+	RuleSlackToken  = "slack_token"
+	RuleGoogleKey   = "google_api_key"
+	RuleStripeKey   = "stripe_key"
+	RuleJWT         = "jwt"
+	RulePassword    = "password_literal"
+	RuleEntropy     = "high_entropy"
+)
+
+// AllowMarker on a line suppresses its findings.
+const AllowMarker = "secret-scan:allow"
+
+// Extensions are the scanned file extensions.
+var Extensions = []string{".swift", ".go", ".plist", ".entitlements", ".bzl", ".bazel", ".bazelrc"}
+
+// bazelFiles are the scanned Bazel files without a scanned extension.
+var bazelFiles = map[string]bool{"BUILD": true, "WORKSPACE": true}
+
+var patterns = []struct {
+	rule    string
+	pattern *regexp.Regexp
+}{
+	{RulePrivateKey, regexp.MustCompile(`-{5}BEGIN (?:RSA |EC |DSA |OPENSSH |ENCRYPTED |PGP )?PRIVATE KEY(?: BLOCK)?-{5}`)},
+	{RuleCertificate, regexp.MustCompile(`-{5}BEGIN CERTIFICATE-{5}`)},
+	{RuleAWSKey, regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{RuleGitHubToken, regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{RuleSlackToken, regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{RuleGoogleKey, regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{RuleStripeKey, regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{24,}\b`)},
+	{RuleJWT, regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+}
+
+var (
+	// assignmentPattern matches a string literal assigned to, or keyed
+	// by, a name containing a secret word: `let apiKey = "..."`,
+	// `password := "..."`, `"API_TOKEN": "..."`.
+	assignmentPattern = regexp.MustCompile(`(?i)\b([A-Za-z_]\w*)"?\s*(?::\s*[A-Za-z_][\w.<>?]*\s*=|:=|:|=)\s*"([^"\\]*)"`)
+	secretWord        = regexp.MustCompile(`(?i)password|passwd|passphrase|secret|api_?key|access_?key|auth_?token|token|credential|private_?key`)
+	plistKeyPattern   = regexp.MustCompile(`<key>([^<]*)</key>`)
+	plistValuePattern = regexp.MustCompile(`<string>([^<]*)</string>`)
+	literalPattern    = regexp.MustCompile(`"([^"\\]{20,})"`)
+	candidatePattern  = regexp.MustCompile(`^[A-Za-z0-9+/=_-]+$`)
+	hexPattern        = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	placeholder       = regexp.MustCompile(`(?i)^(?:|x+|\*+|\.+|<[^>]*>|\$\{[^}]*\}|\$\([^)]*\)|%[@sd]|changeme|redacted|placeholder|example|dummy|test|none|null|nil)$`)
+)
+
+// nameSuffixes end names that describe a secret rather than hold one,
+// such as passwordKey or tokenPrompt.
+var nameSuffixes = map[string]bool{
+	"key": true, "keys": true, "name": true, "label": true, "identifier": true, "id": true, "field": true,
+	"type": true, "prefix": true, "account": true, "service": true, "attribute": true, "length": true,
+	"count": true, "policy": true, "message": true, "error": true, "hint": true, "prompt": true,
+	"placeholder": true, "url": true, "path": true, "header": true, "param": true, "parameter": true,
+	"query": true, "format": true, "pattern": true, "description": true, "title": true, "tag": true,
+}
+
+// Finding is one suspected secret.
+type Finding struct {
+	Rule   string `json:"rule"`
+	Module string `json:"module"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	// Match is the secret with all but its first characters masked.
+	Match string `json:"match"`
+	// Fingerprint identifies the finding in the baseline. It depends on
+	// the rule, file and secret but not on the line, so edits elsewhere
+	// in the file keep it stable.
+	Fingerprint string `json:"fingerprint"`
+	// IssueFingerprint identifies the finding's issue in the results
+	// store, as Fingerprint does in the baseline. umbratool sets it on
+	// the findings the baseline does not accept.
+	IssueFingerprint string `json:"issueFingerprint,omitempty"`
+}
+
+// Options configures a scan.
+type Options struct {
+	// Files restricts the scan to these paths, relative to the root.
+	Files []string
+	// Staged scans the staged version of the files staged for commit, for
+	// use in a pre-commit hook. It takes precedence over Files.
+	Staged bool
+	// MinEntropy is the Shannon entropy, in bits per character, above which
+	// a base64-like literal of 20 or more characters is reported. Hex
+	// literals need three quarters of it.
+	MinEntropy float64
+}
+
+// DefaultMinEntropy is the default Options.MinEntropy.
+const DefaultMinEntropy = 4.5
+
+// Scannable reports whether the scan covers rel.
+func Scannable(rel string) bool {
+	base := path.Base(filepath.ToSlash(rel))
+	if bazelFiles[base] {
+		return true
+	}
+	ext := path.Ext(base)
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan returns the findings in the files opts selects, sorted by file and
+// line.
+func Scan(root string, opts Options) ([]Finding, error) {
+	if opts.MinEntropy == 0 {
+		opts.MinEntropy = DefaultMinEntropy
+	}
+
+	var paths []string
+	switch {
+	case opts.Staged:
+		files, err := staged.Files(root)
+		if err != nil {
+			return nil, err
+		}
+		paths = files
+	case len(opts.Files) > 0:
+		for _, f := range opts.Files {
+			paths = append(paths, path.Clean(filepath.ToSlash(f)))
+		}
+	default:
+		err := walker.Walk(root, walker.Options{}, func(rel string) error {
+			paths = append(paths, rel)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	kept := paths[:0]
+	for _, rel := range paths {
+		if Scannable(rel) {
+			kept = append(kept, rel)
+		}
+	}
+	paths = kept
+	sort.Strings(paths)
+
+	perFile, err := pool.Map(paths, func(rel string) ([]Finding, error) {
+		var r io.ReadCloser
+		if opts.Staged {
+			out, err := staged.Read(root, rel)
+			if err != nil {
+				return nil, err
+			}
+			r = io.NopCloser(bytes.NewReader(out))
+		} else {
+			f, err := os.Open(filepath.Join(root, filepath.FromSlash(rel)))
+			if err != nil {
+				return nil, err
+			}
+			r = f
+		}
+		defer r.Close()
+		return ScanReader(r, rel, opts.MinEntropy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, found := range perFile {
+		findings = append(findings, found...)
+	}
+	return findings, nil
+}
+
+// ScanReader returns the findings in one file.
+func ScanReader(r io.Reader, rel string, minEntropy float64) ([]Finding, error) {
+	module := workspace.ModuleForPath(rel)
+	plist := strings.HasSuffix(rel, ".plist") || strings.HasSuffix(rel, ".entitlements")
+
+	var findings []Finding
+	add := func(rule string, line int, secret string) {
+		findings = append(findings, Finding{Rule: rule, Module: module, File: rel, Line: line, Match: mask(secret), Fingerprint: fingerprint(rule, rel, secret)})
+	}
+
+	scanner := textscan.NewScanner(r)
+	lineNo := 0
+	plistKey := ""
+	for scanner.Scan() {
+		lineNo++
+		text := scanner.Text()
+		if strings.Contains(text, AllowMarker) {
+			plistKey = ""
+			continue
+		}
+		seen := make(map[string]bool)
+		report := func(rule, secret string) {
+			if !seen[secret] {
+				seen[secret] = true
+				add(rule, lineNo, secret)
+			}
+		}
+
+		for _, p := range patterns {
+			for _, m := range p.pattern.FindAllString(text, -1) {
+				report(p.rule, m)
+			}
+		}
+
+		if plist {
+			if m := plistKeyPattern.FindStringSubmatch(text); m != nil {
+				plistKey = m[1]
+			}
+			if m := plistValuePattern.FindStringSubmatch(text); m != nil && plistKey != "" {
+				if secretName(plistKey) && secretValue(m[1]) {
+					report(RulePassword, m[1])
+				}
+				plistKey = ""
+			}
+		} else {
+			for _, m := range assignmentPattern.FindAllStringSubmatch(text, -1) {
+				if secretName(m[1]) && secretValue(m[2]) {
+					report(RulePassword, m[2])
+				}
+			}
+		}
+
+		for _, m := range literalPattern.FindAllStringSubmatch(text, -1) {
+			if !seen[m[1]] && highEntropy(m[1], minEntropy) {
+				report(RuleEntropy, m[1])
+			}
+		}
+	}
+	return findings, textscan.Check(rel, lineNo, scanner.Err())
+}
+
+// secretName reports whether name denotes a secret: it contains a secret
+// word and does not end in a word like Key or Prompt after it.
+func secretName(name string) bool {
+	locs := secretWord.FindAllStringIndex(name, -1)
+	if locs == nil {
+		return false
+	}
+	suffix := strings.ToLower(strings.Trim(name[locs[len(locs)-1][1]:], "_"))
+	if suffix == "" {
+		return true
+	}
+	return !nameSuffixes[suffix] && !nameSuffixes[strings.TrimSuffix(suffix, "s")]
+}
+
+// secretValue reports whether a literal could be a real credential rather
+// than a placeholder, a message or a key name.
+func secretValue(v string) bool {
+	v = strings.TrimSpace(v)
+	if len(v) < 6 || strings.ContainsAny(v, " \t") || placeholder.MatchString(v) {
+		return false
+	}
+	// Keychain item names and identifiers like "com.umbra.password".
+	if strings.Count(v, ".") >= 2 && !strings.ContainsAny(v, "+/=") {
+		return false
+	}
+	return !secretWord.MatchString(v) || len(v) >= 20
+}
+
+// highEntropy reports whether s looks like random key material.
+func highEntropy(s string, min float64) bool {
+	if !candidatePattern.MatchString(s) || !strings.ContainsAny(s, "0123456789") || sequential(s) {
+		return false
+	}
+	if hexPattern.MatchString(s) {
+		return len(s) >= 32 && entropy(s) >= min*0.75
+	}
+	return entropy(s) >= min
+}
+
+// sequential reports whether s is mostly runs of consecutive characters,
+// like the base64 alphabet, rather than random.
+func sequential(s string) bool {
+	runs := 0
+	for i := 1; i < len(s); i++ {
+		if s[i] == s[i-1]+1 {
+			runs++
+		}
+	}
+	return runs*2 >= len(s)
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	e := 0.0
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		e -= p * math.Log2(p)
+	}
+	return e
+}
+
+func mask(secret string) string {
+	if len(secret) <= 8 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:4] + strings.Repeat("*", min(len(secret)-4, 12))
+}
+
+func fingerprint(rule, rel, secret string) string {
+	sum := sha256.Sum256([]byte(rule + "\x00" + rel + "\x00" + secret))
+	return hex.EncodeToString(sum[:8])
+}
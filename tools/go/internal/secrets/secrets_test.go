@@ -0,0 +1,171 @@
+package secrets
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// The samples are split where a rule would match, so that scanning this
+// file finds nothing.
+
+func rules(t *testing.T, rel, src string) []string {
+	t.Helper()
+	findings, err := ScanReader(strings.NewReader(src), rel, DefaultMinEntropy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, f := range findings {
+		out = append(out, f.Rule)
+	}
+	return out
+}
+
+func TestRules(t *testing.T) {
+	for name, tc := range map[string]struct {
+		rel, src string
+		want     []string
+	}{
+		"private key": {
+			rel:  "Sources/Keys/Fixture.swift",
+			src:  `let pem = """` + "\n-----BEGIN " + "RSA PRIVATE KEY-----\n",
+			want: []string{RulePrivateKey},
+		},
+		"certificate": {
+			rel:  "Sources/Keys/Fixture.swift",
+			src:  "// -----BEGIN " + "CERTIFICATE-----\n",
+			want: []string{RuleCertificate},
+		},
+		"AWS key": {
+			rel:  "Sources/Cloud/Config.swift",
+			src:  `let id = "AKIA` + `IOSFODNN7EXAMPLE"` + "\n",
+			want: []string{RuleAWSKey},
+		},
+		"GitHub token": {
+			rel:  "tools/go/cmd/release.go",
+			src:  `const t = "ghp_` + `aB3dE5fG7hJ9kL1mN3pQ5rS7tV9wX1yZ3b5D"` + "\n",
+			want: []string{RuleGitHubToken},
+		},
+		"Slack token": {
+			rel:  "tools/go/cmd/notify.go",
+			src:  `hook := "xoxb` + `-1234567890-abcdefghij"` + "\n",
+			want: []string{RuleSlackToken},
+		},
+		"Google API key": {
+			rel:  "Sources/Maps/Maps.swift",
+			src:  `let k = "AIza` + `SyA1b2C3d4E5f6G7h8I9j0K1l2M3n4O5p6Q"` + "\n",
+			want: []string{RuleGoogleKey},
+		},
+		"Stripe key": {
+			rel:  "Sources/Pay/Pay.swift",
+			src:  `let k = "sk_` + `live_abcdefghijklmnopqrstuvwx"` + "\n",
+			want: []string{RuleStripeKey},
+		},
+		"JWT": {
+			rel:  "Sources/Auth/Fixture.swift",
+			src:  `let jwt = "eyJ` + `hbGciOiJIUzI1NiJ9.eyJ` + `zdWIiOiIxMjM0NTY3ODkwIn0.abcdefghijklmnop"` + "\n",
+			want: []string{RuleJWT},
+		},
+		"password literal": {
+			rel:  "Sources/Auth/Login.swift",
+			src:  `let password = "hunter2` + `hunter2"` + "\n",
+			want: []string{RulePassword},
+		},
+		"typed password literal": {
+			rel:  "Sources/Auth/Login.swift",
+			src:  `static let apiToken: String = "s3cr3t` + `Value"` + "\n",
+			want: []string{RulePassword},
+		},
+		"plist secret": {
+			rel:  "Sources/App/Info.plist",
+			src:  "<key>APIToken</key>\n<string>q8Zr2" + "LmX0pW</string>\n",
+			want: []string{RulePassword},
+		},
+		"high entropy literal": {
+			rel:  "Sources/Keys/Material.swift",
+			src:  `let blob = "q8Zr2LmX0pWv7T` + `n4Yc1Bk9Hs3Jd6Fg"` + "\n",
+			want: []string{RuleEntropy},
+		},
+		"names describing a secret": {
+			rel: "Sources/Auth/Keys.swift",
+			src: `let passwordKey = "hunter2` + `hunter2"` + "\n" +
+				`let tokenPrompt = "enterYour` + `Code"` + "\n" +
+				`let secretAccount = "umbra-` + `vault"` + "\n",
+		},
+		"placeholders": {
+			rel: "Sources/Auth/Defaults.swift",
+			src: `let password = "change` + `me"` + "\n" +
+				`let apiKey = "<your-` + `key>"` + "\n" +
+				`let token = "${API_` + `TOKEN}"` + "\n" +
+				`let secret = "xxxx` + `xxxx"` + "\n" +
+				`let password = "com.umbra.` + `password"` + "\n",
+		},
+		"plist key name": {
+			rel: "Sources/App/Info.plist",
+			src: "<key>APITokenKey</key>\n<string>q8Zr2" + "LmX0pW</string>\n",
+		},
+		"low entropy literal": {
+			rel: "Sources/App/Strings.swift",
+			src: `let s = "ABCDEFGHIJKLMNOPQRST` + `UVWXYZ0123"` + "\n",
+		},
+		"allow marker": {
+			rel: "Sources/Auth/Fixture.swift",
+			src: `let password = "hunter2` + `hunter2" // secret-scan:allow` + "\n" +
+				`let id = "AKIA` + `IOSFODNN7EXAMPLE" // ` + AllowMarker + "\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := rules(t, tc.rel, tc.src); !slices.Equal(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// Accepted findings stay accepted, with their reasons, when the lines
+// around them change.
+func TestBaselineSurvivesShiftedLines(t *testing.T) {
+	const rel = "Sources/Auth/Fixture.swift"
+	before := `let password = "hunter2` + `hunter2"` + "\n" + `let k = "sk_` + `live_abcdefghijklmnopqrstuvwx"` + "\n"
+	scan := func(src string) []Finding {
+		t.Helper()
+		findings, err := ScanReader(strings.NewReader(src), rel, DefaultMinEntropy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return findings
+	}
+
+	first := scan(before)
+	base := (&Baseline{}).Update(first)
+	for i := range base.Allow {
+		base.Allow[i].Reason = "test fixture " + base.Allow[i].Rule
+	}
+
+	after := "import Foundation\n\n" + before + `let password2 = "hunter3` + `hunter3"` + "\n"
+	shifted := scan(after)
+	remaining := base.Filter(shifted)
+	if len(remaining) != 1 || remaining[0].Line != 5 {
+		t.Fatalf("Filter kept %+v, want only the new password on line 5", remaining)
+	}
+
+	updated := base.Update(shifted)
+	if len(updated.Allow) != 3 {
+		t.Fatalf("Update gave %d entries, want 3", len(updated.Allow))
+	}
+	for i, a := range updated.Allow[:2] {
+		if a.Fingerprint != first[i].Fingerprint {
+			t.Errorf("entry %d: fingerprint %s, want %s", i, a.Fingerprint, first[i].Fingerprint)
+		}
+		if want := "test fixture " + a.Rule; a.Reason != want {
+			t.Errorf("entry %d: reason %q, want %q", i, a.Reason, want)
+		}
+		if a.Line != first[i].Line+2 {
+			t.Errorf("entry %d: line %d, want %d", i, a.Line, first[i].Line+2)
+		}
+	}
+	if a := updated.Allow[2]; a.Reason != "" || a.Line != 5 {
+		t.Errorf("new entry: got %+v, want line 5 without a reason", a)
+	}
+}
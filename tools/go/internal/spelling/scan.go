@@ -0,0 +1,150 @@
+package spelling
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// Finding kinds.
+const (
+	KindDocComment = "doc-comment"
+	KindMarkdown   = "markdown"
+	KindGoComment  = "go-comment"
+	KindIdentifier = "identifier"
+)
+
+// Scope selects which parts of the tree are linted.
+type Scope struct {
+	// SwiftDocs checks /// and /** */ doc comments in Swift files.
+	SwiftDocs bool
+	// Markdown checks prose in Markdown files under docs/.
+	Markdown bool
+	// GoComments checks comments in the Go tools under tools/.
+	GoComments bool
+	// Identifiers checks public Swift declaration names.
+	Identifiers bool
+}
+
+var (
+	inlineCode = regexp.MustCompile("`[^`]*`")
+	urlPattern = regexp.MustCompile(`\bhttps?://\S+`)
+	publicDecl = regexp.MustCompile(`\b(?:public|open)\s+(?:(?:static|final|override|class|mutating|nonisolated|indirect)\s+)*(?:func|var|let|class|struct|enum|protocol|actor|typealias)\s+([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// Scan lints root according to scope.
+func (d Dictionary) Scan(root string, scope Scope) ([]Finding, error) {
+	var findings []Finding
+	err := walker.Walk(root, walker.Options{Extensions: []string{".swift", ".md", ".go"}}, func(rel string) error {
+		var check func(string, int, string, *bool) []Finding
+		switch {
+		case strings.HasSuffix(rel, ".swift") && (scope.SwiftDocs || scope.Identifiers):
+			check = func(file string, n int, line string, inBlock *bool) []Finding {
+				return d.checkSwiftLine(file, n, line, inBlock, scope)
+			}
+		case strings.HasSuffix(rel, ".md") && scope.Markdown && strings.HasPrefix(rel, "docs/"):
+			check = d.checkMarkdownLine
+		case strings.HasSuffix(rel, ".go") && scope.GoComments && strings.HasPrefix(rel, "tools/"):
+			check = d.checkGoLine
+		default:
+			return nil
+		}
+
+		found, err := scanLines(root, rel, check)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, found...)
+		return nil
+	})
+	SortFindings(findings)
+	return findings, err
+}
+
+func scanLines(root, rel string, check func(string, int, string, *bool) []Finding) ([]Finding, error) {
+	f, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var findings []Finding
+	inBlock := false
+	scanner := textscan.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		findings = append(findings, check(rel, lineNo, scanner.Text(), &inBlock)...)
+	}
+	return findings, textscan.Check(rel, lineNo, scanner.Err())
+}
+
+func (d Dictionary) checkSwiftLine(file string, n int, line string, inDocBlock *bool, scope Scope) []Finding {
+	trimmed := strings.TrimSpace(line)
+
+	var prose string
+	switch {
+	case *inDocBlock:
+		prose = trimmed
+		if strings.Contains(trimmed, "*/") {
+			*inDocBlock = false
+		}
+	case strings.HasPrefix(trimmed, "///"):
+		prose = strings.TrimPrefix(trimmed, "///")
+	case strings.HasPrefix(trimmed, "/**"):
+		prose = strings.TrimPrefix(trimmed, "/**")
+		*inDocBlock = !strings.Contains(trimmed, "*/")
+	}
+
+	var findings []Finding
+	if scope.SwiftDocs && prose != "" {
+		findings = d.locate(file, n, KindDocComment, stripCode(prose))
+	}
+
+	if scope.Identifiers && prose == "" {
+		for _, m := range publicDecl.FindAllStringSubmatch(line, -1) {
+			if f, ok := d.CheckIdentifier(m[1]); ok {
+				f.File, f.Line, f.Kind = file, n, KindIdentifier
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings
+}
+
+func (d Dictionary) checkMarkdownLine(file string, n int, line string, inFence *bool) []Finding {
+	if strings.HasPrefix(strings.TrimSpace(line), "```") {
+		*inFence = !*inFence
+		return nil
+	}
+	if *inFence {
+		return nil
+	}
+	return d.locate(file, n, KindMarkdown, stripCode(line))
+}
+
+func (d Dictionary) checkGoLine(file string, n int, line string, _ *bool) []Finding {
+	idx := strings.Index(line, "//")
+	if idx < 0 {
+		return nil
+	}
+	return d.locate(file, n, KindGoComment, stripCode(line[idx+2:]))
+}
+
+func (d Dictionary) locate(file string, n int, kind, text string) []Finding {
+	found := d.CheckText(text)
+	for i := range found {
+		found[i].File, found[i].Line, found[i].Kind = file, n, kind
+	}
+	return found
+}
+
+// stripCode removes inline code spans and URLs, which name APIs and paths
+// rather than prose.
+func stripCode(s string) string {
+	return urlPattern.ReplaceAllString(inlineCode.ReplaceAllString(s, " "), " ")
+}
@@ -0,0 +1,215 @@
+// Package spelling flags American spellings in documentation and,
+// optionally, public identifiers, suggesting the British form UmbraCore
+// standardises on.
+package spelling
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/configschema"
+)
+
+// izeStems are verbs spelt -ize/-yze in American English. Every inflection
+// (-ize, -izes, -ized, -izing, -ization, -izations, -izer, -izers) is
+// derived from each stem.
+var izeStems = []string{
+	"initial", "organ", "serial", "deserial", "optim", "normal", "custom",
+	"recogn", "priorit", "categor", "summar", "minim", "maxim", "final",
+	"author", "standard", "central", "util", "special", "real", "synchron",
+	"modular", "local", "international", "emphas", "apolog", "visual",
+	"character", "memor", "sanit", "token", "parameter", "symbol",
+}
+
+// yzeStems are verbs spelt -yze (analyze, paralyze) in American English.
+var yzeStems = []string{"anal", "paral", "catal"}
+
+// fixedWords are irregular pairs not covered by the stem rules.
+var fixedWords = map[string]string{
+	"behavior":   "behaviour",
+	"behaviors":  "behaviours",
+	"behavioral": "behavioural",
+	"color":      "colour",
+	"colors":     "colours",
+	"favor":      "favour",
+	"favorite":   "favourite",
+	"honor":      "honour",
+	"center":     "centre",
+	"centered":   "centred",
+	"canceled":   "cancelled",
+	"canceling":  "cancelling",
+	"modeling":   "modelling",
+	"labeled":    "labelled",
+	"labeling":   "labelling",
+	"traveled":   "travelled",
+	"signaling":  "signalling",
+	"defense":    "defence",
+	"offense":    "offence",
+	"gray":       "grey",
+	"judgment":   "judgement",
+	"artifact":   "artefact",
+	"artifacts":  "artefacts",
+}
+
+// Config is the word-list configuration file format.
+type Config struct {
+	// Words adds or overrides American to British mappings.
+	Words map[string]string `json:"words"`
+	// Ignore lists American spellings that must not be reported, for
+	// example where they are part of an Apple API name.
+	Ignore []string `json:"ignore"`
+}
+
+// LoadConfig reads a JSON word-list configuration.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := configschema.Validate(configschema.KindSpelling, path, data); err != nil {
+		return cfg, err
+	}
+	err = json.Unmarshal(data, &cfg)
+	return cfg, err
+}
+
+// Dictionary maps lower-case American spellings to their British forms.
+type Dictionary map[string]string
+
+// DefaultDictionary returns the built-in word list.
+func DefaultDictionary() Dictionary {
+	d := make(Dictionary)
+	suffixes := []string{"", "s", "d", "r", "rs", "ing", "tion", "tions"}
+	for _, stem := range izeStems {
+		for _, suffix := range suffixes {
+			d.addInflection(stem+"iz", stem+"is", suffix)
+		}
+	}
+	for _, stem := range yzeStems {
+		for _, suffix := range suffixes {
+			if strings.HasPrefix(suffix, "t") {
+				continue // analyzation is not a word
+			}
+			d.addInflection(stem+"yz", stem+"ys", suffix)
+		}
+	}
+	for us, gb := range fixedWords {
+		d[us] = gb
+	}
+	return d
+}
+
+func (d Dictionary) addInflection(us, gb, suffix string) {
+	switch suffix {
+	case "ing":
+		d[us+"ing"] = gb + "ing"
+	case "tion", "tions":
+		d[us+"a"+suffix] = gb + "a" + suffix
+	default:
+		d[us+"e"+suffix] = gb + "e" + suffix
+	}
+}
+
+// Apply merges cfg into the dictionary.
+func (d Dictionary) Apply(cfg Config) {
+	for us, gb := range cfg.Words {
+		d[strings.ToLower(us)] = strings.ToLower(gb)
+	}
+	for _, word := range cfg.Ignore {
+		delete(d, strings.ToLower(word))
+	}
+}
+
+// Finding is an American spelling with its suggested replacement.
+type Finding struct {
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Kind        string `json:"kind"`
+	Word        string `json:"word"`
+	Suggestion  string `json:"suggestion"`
+	Identifier  string `json:"identifier,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+var wordPattern = regexp.MustCompile(`[A-Za-z]+`)
+
+// CheckText reports American words in a line of prose.
+func (d Dictionary) CheckText(text string) []Finding {
+	var findings []Finding
+	for _, word := range wordPattern.FindAllString(text, -1) {
+		if gb, ok := d[strings.ToLower(word)]; ok {
+			findings = append(findings, Finding{Word: word, Suggestion: matchCase(word, gb)})
+		}
+	}
+	return findings
+}
+
+// CheckIdentifier splits a camelCase or snake_case identifier into words and
+// reports the identifier when any word is American, suggesting the
+// identifier with every such word replaced.
+func (d Dictionary) CheckIdentifier(ident string) (Finding, bool) {
+	words := splitIdentifier(ident)
+
+	var american []string
+	var rebuilt strings.Builder
+	for _, w := range words {
+		if gb, ok := d[strings.ToLower(w)]; ok {
+			american = append(american, w)
+			rebuilt.WriteString(matchCase(w, gb))
+		} else {
+			rebuilt.WriteString(w)
+		}
+	}
+	if len(american) == 0 {
+		return Finding{}, false
+	}
+	return Finding{Word: strings.Join(american, ", "), Suggestion: rebuilt.String(), Identifier: ident}, true
+}
+
+// splitIdentifier breaks an identifier at case changes and underscores while
+// keeping the separators, so that joining the parts rebuilds the input.
+func splitIdentifier(ident string) []string {
+	var parts []string
+	runes := []rune(ident)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		boundary := cur == '_' || prev == '_' ||
+			(unicode.IsLower(prev) && unicode.IsUpper(cur)) ||
+			(unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1]))
+		if boundary {
+			parts = append(parts, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(parts, string(runes[start:]))
+}
+
+// matchCase returns replacement with the capitalisation pattern of original.
+func matchCase(original, replacement string) string {
+	switch {
+	case original == strings.ToUpper(original):
+		return strings.ToUpper(replacement)
+	case unicode.IsUpper([]rune(original)[0]):
+		r := []rune(replacement)
+		r[0] = unicode.ToUpper(r[0])
+		return string(r)
+	default:
+		return replacement
+	}
+}
+
+// SortFindings orders findings by file and line.
+func SortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+}
@@ -0,0 +1,25 @@
+package spelling
+
+import "testing"
+
+func TestDefaultDictionary(t *testing.T) {
+	d := DefaultDictionary()
+	for us, gb := range map[string]string{
+		"characterize":     "characterise",
+		"characterization": "characterisation",
+		"tokenize":         "tokenise",
+		"tokenizer":        "tokeniser",
+		"serialized":       "serialised",
+		"analyze":          "analyse",
+		"behavior":         "behaviour",
+	} {
+		if got, ok := d[us]; !ok || got != gb {
+			t.Errorf("%s: got %q, %v; want %q", us, got, ok, gb)
+		}
+	}
+	for _, word := range []string{"characterise", "tokenise", "analyse", "analyzation", "characterisize", "tokeniize"} {
+		if gb, ok := d[word]; ok {
+			t.Errorf("%s is flagged, as %q", word, gb)
+		}
+	}
+}
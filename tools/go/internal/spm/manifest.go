@@ -0,0 +1,213 @@
+package spm
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+)
+
+// Manifest renders p as a Package.swift in dir, the slash-separated
+// directory of the manifest relative to the workspace root. Every target
+// must lie inside it: SwiftPM refuses target paths outside the package.
+func (p *Package) Manifest(dir string) ([]byte, error) {
+	dir = path.Clean(dir)
+	var products, deps, targets []string
+	for _, t := range p.Targets {
+		switch {
+		case t.Type == TypeExecutable:
+			products = append(products, fmt.Sprintf(".executable(name: %s, targets: [%s])", quote(t.Name), quote(t.Name)))
+		case t.Type == TypeLibrary && !t.TestOnly:
+			products = append(products, fmt.Sprintf(".library(name: %s, targets: [%s])", quote(t.Name), quote(t.Name)))
+		}
+		rel, ok := within(dir, t.Path)
+		if !ok {
+			return nil, fmt.Errorf("%s: sources in %s are outside the package directory %s", t.Label, t.Path, dir)
+		}
+		targets = append(targets, renderTarget(t, rel))
+	}
+	for _, d := range p.Dependencies {
+		switch {
+		case d.Path != "":
+			deps = append(deps, fmt.Sprintf(".package(path: %s)", quote(d.Path)))
+		case d.Version != "":
+			deps = append(deps, fmt.Sprintf(".package(url: %s, exact: %s)", quote(d.URL), quote(d.Version)))
+		default:
+			deps = append(deps, fmt.Sprintf(".package(url: %s, revision: %s)", quote(d.URL), quote(d.Revision)))
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// swift-tools-version:%s\n", p.ToolsVersion)
+	b.WriteString("// Code generated by umbratool spm-export from the Bazel BUILD files; DO NOT EDIT.\n")
+	b.WriteString("import PackageDescription\n\n")
+	b.WriteString("let package = Package(\n")
+	fmt.Fprintf(&b, "  name: %s,\n", quote(p.Name))
+	fmt.Fprintf(&b, "  platforms: [\n    .macOS(%s)\n  ],\n", quote(p.MacOS))
+	writeList(&b, "  ", "products", products, ",")
+	writeList(&b, "  ", "dependencies", deps, ",")
+	writeList(&b, "  ", "targets", targets, "")
+	b.WriteString(")\n")
+	return []byte(b.String()), nil
+}
+
+func renderTarget(t Target, dir string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s\n.%s(\n", t.Label, t.Type)
+	args := []string{"  name: " + quote(t.Name)}
+	if len(t.Dependencies) > 0 {
+		var deps []string
+		for _, d := range t.Dependencies {
+			if d.Target != "" {
+				deps = append(deps, quote(d.Target))
+			} else {
+				deps = append(deps, fmt.Sprintf(".product(name: %s, package: %s)", quote(d.Product), quote(d.Package)))
+			}
+		}
+		args = append(args, list("  ", "dependencies", deps))
+	}
+	args = append(args, "  path: "+quote(dir))
+	args = append(args, list("  ", "sources", quoteAll(t.Sources)))
+	if len(t.Resources) > 0 {
+		var resources []string
+		for _, r := range t.Resources {
+			resources = append(resources, ".copy("+quote(r)+")")
+		}
+		args = append(args, list("  ", "resources", resources))
+	}
+	if len(t.Defines) > 0 {
+		var settings []string
+		for _, d := range t.Defines {
+			settings = append(settings, ".define("+quote(d)+")")
+		}
+		args = append(args, list("  ", "swiftSettings", settings))
+	}
+	if len(t.LinkedFrameworks) > 0 || len(t.LinkedLibraries) > 0 {
+		var settings []string
+		for _, f := range t.LinkedFrameworks {
+			settings = append(settings, ".linkedFramework("+quote(f)+")")
+		}
+		for _, l := range t.LinkedLibraries {
+			settings = append(settings, ".linkedLibrary("+quote(l)+")")
+		}
+		args = append(args, list("  ", "linkerSettings", settings))
+	}
+	b.WriteString(strings.Join(args, ",\n"))
+	b.WriteString("\n)")
+	return b.String()
+}
+
+// list renders a labelled array argument, one element per line.
+func list(indent, label string, elems []string) string {
+	var b strings.Builder
+	writeList(&b, indent, label, elems, "")
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func writeList(b *strings.Builder, indent, label string, elems []string, trailer string) {
+	if len(elems) == 0 {
+		fmt.Fprintf(b, "%s%s: []%s\n", indent, label, trailer)
+		return
+	}
+	fmt.Fprintf(b, "%s%s: [\n", indent, label)
+	for i, e := range elems {
+		e = strings.ReplaceAll(e, "\n", "\n"+indent+"  ")
+		sep := ","
+		if i == len(elems)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(b, "%s  %s%s\n", indent, e, sep)
+	}
+	fmt.Fprintf(b, "%s]%s\n", indent, trailer)
+}
+
+// WriteModules writes one package per target below dir, each in a
+// directory named after the target. The package reaches the target's
+// sources through a Sources symlink into root, and the targets it depends
+// on through path dependencies on its sibling packages, whose libraries
+// are all offered as products. It returns the manifests written.
+func (p *Package) WriteModules(root, dir string) ([]string, error) {
+	var written []string
+	for _, t := range p.Targets {
+		pkgDir := filepath.Join(dir, t.Name)
+		if err := os.MkdirAll(pkgDir, 0o755); err != nil {
+			return nil, err
+		}
+		link := filepath.Join(pkgDir, "Sources")
+		absDir, err := filepath.Abs(pkgDir)
+		if err != nil {
+			return nil, err
+		}
+		target, err := filepath.Rel(absDir, filepath.Join(root, filepath.FromSlash(t.Path)))
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err := os.Symlink(target, link); err != nil {
+			return nil, err
+		}
+
+		module := &Package{Name: t.Name, ToolsVersion: p.ToolsVersion, MacOS: p.MacOS}
+		local := t
+		local.Path = "Sources"
+		local.TestOnly = false
+		local.Dependencies = nil
+		external := make(map[string]bool)
+		for _, d := range t.Dependencies {
+			if d.Target != "" {
+				module.Dependencies = append(module.Dependencies, PackageDependency{Identity: d.Target, Path: "../" + d.Target})
+				local.Dependencies = append(local.Dependencies, Dependency{Product: d.Target, Package: d.Target})
+				continue
+			}
+			external[d.Package] = true
+			local.Dependencies = append(local.Dependencies, d)
+		}
+		module.Targets = []Target{local}
+		for _, d := range p.Dependencies {
+			if external[d.Identity] {
+				module.Dependencies = append(module.Dependencies, d)
+			}
+		}
+		manifest, err := module.Manifest("")
+		if err != nil {
+			return nil, err
+		}
+		file := filepath.Join(pkgDir, "Package.swift")
+		if err := atomicfile.WriteFile(file, manifest, 0o644); err != nil {
+			return nil, err
+		}
+		written = append(written, file)
+	}
+	return written, nil
+}
+
+// within returns p relative to dir when p is dir or below it.
+func within(dir, p string) (string, bool) {
+	switch {
+	case dir == "." || dir == "":
+		return p, true
+	case p == dir:
+		return ".", true
+	case strings.HasPrefix(p, dir+"/"):
+		return strings.TrimPrefix(p, dir+"/"), true
+	}
+	return "", false
+}
+
+// quote renders s as a Swift string literal.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func quoteAll(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = quote(v)
+	}
+	return out
+}
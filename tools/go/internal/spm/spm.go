@@ -0,0 +1,711 @@
+// Package spm exports the workspace's Swift rules as Swift package
+// manifests, for consumers that build with SwiftPM rather than Bazel, and
+// reports every part of the Bazel build the manifests cannot express.
+//
+// The rules are read from the BUILD files as written: attributes a macro
+// sets on the rules it expands to are not seen.
+package spm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/unused"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// Target types, named after the PackageDescription functions declaring
+// them.
+const (
+	TypeLibrary    = "target"
+	TypeTest       = "testTarget"
+	TypeExecutable = "executableTarget"
+)
+
+// Target is one SwiftPM target, exported from a Swift rule.
+type Target struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	// Path is the slash-separated directory holding the target's sources,
+	// relative to the workspace root.
+	Path string `json:"path"`
+	// Sources are the rule's Swift files, relative to Path. They are always
+	// listed, so that files of nested Bazel packages stay out.
+	Sources []string `json:"sources"`
+	// Resources are the rule's data files, relative to Path, copied into
+	// the target's bundle.
+	Resources    []string     `json:"resources,omitempty"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+	// Defines are the compilation conditions from -D copts and defines.
+	Defines          []string `json:"defines,omitempty"`
+	LinkedFrameworks []string `json:"linkedFrameworks,omitempty"`
+	LinkedLibraries  []string `json:"linkedLibraries,omitempty"`
+	// SwiftFlags and LinkerFlags are the copts and linkopts that would need
+	// unsafeFlags; the manifest leaves them out.
+	SwiftFlags  []string `json:"swiftFlags,omitempty"`
+	LinkerFlags []string `json:"linkerFlags,omitempty"`
+	// LibraryEvolution is set by an enable_library_evolution attribute.
+	LibraryEvolution bool `json:"libraryEvolution,omitempty"`
+	// TestOnly marks a testonly library, which is not offered as a product.
+	TestOnly bool `json:"testOnly,omitempty"`
+}
+
+// Dependency is a target dependency: another target of the export, or a
+// product of an external package.
+type Dependency struct {
+	Target  string `json:"target,omitempty"`
+	Product string `json:"product,omitempty"`
+	Package string `json:"package,omitempty"`
+}
+
+// PackageDependency is an external package pinned in Package.resolved, or
+// a sibling package of WriteModules.
+type PackageDependency struct {
+	Identity string `json:"identity"`
+	URL      string `json:"url,omitempty"`
+	// Version is the pinned version, or empty when only Revision is.
+	Version  string `json:"version,omitempty"`
+	Revision string `json:"revision,omitempty"`
+	// Path is the directory of a local package.
+	Path string `json:"path,omitempty"`
+}
+
+// LossUnsafeFlags is the kind of a loss whose options the target keeps in
+// SwiftFlags or LinkerFlags, for build systems that can pass them.
+const LossUnsafeFlags = "unsafe-flags"
+
+// Loss is part of a rule the export cannot express.
+type Loss struct {
+	Label     string `json:"label"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Attribute string `json:"attribute"`
+	// Kind is LossUnsafeFlags or empty.
+	Kind    string `json:"kind,omitempty"`
+	Message string `json:"message"`
+}
+
+// Package is the exported package.
+type Package struct {
+	Name         string              `json:"name"`
+	ToolsVersion string              `json:"toolsVersion"`
+	MacOS        string              `json:"macOS"`
+	Dependencies []PackageDependency `json:"dependencies"`
+	// Targets are sorted by name.
+	Targets []Target `json:"targets"`
+	Losses  []Loss   `json:"losses"`
+}
+
+// Options controls what Export exports.
+type Options struct {
+	// Name names the package.
+	Name string
+	// ToolsVersion is the swift-tools-version the manifest declares.
+	ToolsVersion string
+	// MacOS is the minimum macOS version, which -target copts naming a
+	// newer one are reported against.
+	MacOS string
+	// Targets are label patterns, as in unused.Allowed, of the rules to
+	// export with everything they depend on. Empty exports every rule.
+	Targets []string
+}
+
+// ignoredAttrs affect only how Bazel runs or exposes a rule, not what it
+// compiles.
+var ignoredAttrs = map[string]bool{
+	"name": true, "visibility": true, "tags": true, "size": true, "timeout": true,
+	"flaky": true, "localonly": true, "shard_count": true, "testonly": true,
+	"module_name": true, "swift_mode": true,
+}
+
+// ignoredCopts are compiler options SwiftPM passes itself, per build
+// configuration.
+var ignoredCopts = map[string]bool{
+	"-enable-testing": true, "-g": true, "-Onone": true, "-O": true, "-Osize": true,
+	"-wmo": true, "-whole-module-optimization": true,
+}
+
+// valueOpts are compiler and linker options taking the next one as their
+// value.
+var valueOpts = map[string]bool{
+	"-Xfrontend": true, "-Xcc": true, "-Xlinker": true, "-Xclang-linker": true,
+	"-swift-version": true, "-arch": true, "-module-cache-path": true, "-sdk": true,
+}
+
+var (
+	macOSTarget = regexp.MustCompile(`^[a-z0-9_]+-apple-macos([0-9.]+)$`)
+	nonRepoName = regexp.MustCompile(`[^a-z0-9_]`)
+)
+
+// Export reads the Swift rules of every BUILD file below root and returns
+// the package they export to.
+func Export(root string, opts Options) (*Package, error) {
+	files, err := buildfile.FindAll(root)
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]bool, len(files))
+	packages := make(map[string]bool, len(files))
+	for _, rel := range files {
+		present[rel] = true
+		packages[buildfile.PackageOf(rel)] = true
+	}
+
+	e := &exporter{root: root, opts: opts, packages: packages, listings: make(map[string][]string)}
+	var rules []modulenames.Rule
+	syntax := make(map[string]*build.Rule)
+	for _, rel := range files {
+		if path.Base(rel) == "BUILD" && present[path.Join(path.Dir(rel), "BUILD.bazel")] {
+			continue
+		}
+		f, err := buildfile.Load(filepath.Join(root, filepath.FromSlash(rel)), buildfile.PackageOf(rel))
+		if err != nil {
+			e.lose(modulenames.Rule{Label: "//" + buildfile.PackageOf(rel), File: rel}, "", "BUILD file does not parse; its rules are not exported")
+			continue
+		}
+		for _, r := range modulenames.FileRules(f, rel) {
+			rules = append(rules, r)
+			_, name, _ := strings.Cut(r.Label, ":")
+			syntax[r.Label] = f.Rule(name)
+		}
+	}
+
+	// Bazel cannot link two modules of one name either; the export keeps
+	// the rule module-names would keep and renames the others.
+	names := make(map[string]string, len(rules))
+	for _, r := range rules {
+		names[r.Label] = r.ModuleName
+	}
+	for _, c := range modulenames.FindCollisions(rules) {
+		for _, r := range c.Rules[1:] {
+			names[r.Label] = c.Suggestions[r.Label]
+			e.lose(r, "module_name", fmt.Sprintf("module %s is also compiled by %s; exported as %s", c.ModuleName, c.Rules[0].Label, names[r.Label]))
+		}
+	}
+
+	byLabel := make(map[string]modulenames.Rule, len(rules))
+	for _, r := range rules {
+		byLabel[r.Label] = r
+	}
+	selected := e.selectRules(rules, byLabel)
+
+	e.pins, err = LoadPins(filepath.Join(root, "Package.resolved"))
+	if err != nil {
+		return nil, err
+	}
+	e.used = make(map[string]bool)
+
+	pkg := &Package{Name: opts.Name, ToolsVersion: opts.ToolsVersion, MacOS: opts.MacOS, Dependencies: []PackageDependency{}, Targets: []Target{}}
+	exported := make(map[string]bool)
+	var targets []Target
+	for _, r := range selected {
+		t, ok := e.target(r, syntax[r.Label], names[r.Label])
+		if ok {
+			targets = append(targets, t)
+			exported[r.Label] = true
+		}
+	}
+
+	// Resolve deps once every target is known, dropping those on rules
+	// that did not export.
+	for i := range targets {
+		r := byLabel[targets[i].Label]
+		seen := make(map[string]bool)
+		for _, dep := range r.Deps {
+			if seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			switch {
+			case exported[dep]:
+				targets[i].Dependencies = append(targets[i].Dependencies, Dependency{Target: names[dep]})
+			case byLabel[dep].Label != "":
+				e.lose(r, "deps", fmt.Sprintf("%s has no Swift sources to export", dep))
+			default:
+				if d, ok := e.external(r, dep); ok {
+					targets[i].Dependencies = append(targets[i].Dependencies, d)
+				}
+			}
+		}
+	}
+	e.overlaps(targets, byLabel)
+
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Name < targets[j].Name })
+	pkg.Targets = append(pkg.Targets, targets...)
+	for _, p := range e.pins {
+		if e.used[p.Identity] {
+			pkg.Dependencies = append(pkg.Dependencies, p)
+		}
+	}
+	// Collisions were found across every rule; report only the exported.
+	chosen := make(map[string]bool, len(selected))
+	for _, r := range selected {
+		chosen[r.Label] = true
+	}
+	kept := e.losses[:0]
+	for _, l := range e.losses {
+		if chosen[l.Label] || l.Attribute == "" {
+			kept = append(kept, l)
+		}
+	}
+	e.losses = kept
+	sort.Slice(e.losses, func(i, j int) bool {
+		if e.losses[i].Label != e.losses[j].Label {
+			return e.losses[i].Label < e.losses[j].Label
+		}
+		return e.losses[i].Attribute < e.losses[j].Attribute
+	})
+	pkg.Losses = append([]Loss{}, e.losses...)
+	return pkg, nil
+}
+
+type exporter struct {
+	root string
+	opts Options
+	// packages holds the workspace's Bazel packages, whose directories
+	// globs of the packages above them do not descend into.
+	packages map[string]bool
+	// listings caches the files of each package.
+	listings map[string][]string
+	pins     []PackageDependency
+	// used holds the identities of the pins a target depends on.
+	used   map[string]bool
+	losses []Loss
+}
+
+func (e *exporter) lose(r modulenames.Rule, attr, msg string) {
+	e.losses = append(e.losses, Loss{Label: r.Label, File: r.File, Line: r.Line, Attribute: attr, Message: msg})
+}
+
+func (e *exporter) loseUnsafe(r modulenames.Rule, attr, msg string) {
+	e.lose(r, attr, msg)
+	e.losses[len(e.losses)-1].Kind = LossUnsafeFlags
+}
+
+// selectRules returns the rules matching the Targets patterns and the Swift
+// rules they depend on, transitively, or every rule without patterns.
+func (e *exporter) selectRules(rules []modulenames.Rule, byLabel map[string]modulenames.Rule) []modulenames.Rule {
+	if len(e.opts.Targets) == 0 {
+		return rules
+	}
+	seen := make(map[string]bool)
+	var out []modulenames.Rule
+	var visit func(r modulenames.Rule)
+	visit = func(r modulenames.Rule) {
+		if seen[r.Label] {
+			return
+		}
+		seen[r.Label] = true
+		out = append(out, r)
+		for _, dep := range r.Deps {
+			if d, ok := byLabel[dep]; ok {
+				visit(d)
+			}
+		}
+	}
+	for _, r := range rules {
+		if unused.Allowed(e.opts.Targets, r.Label) {
+			visit(r)
+		}
+	}
+	return out
+}
+
+// target exports r, whose syntax is call, as the target name. It reports
+// false when r has no Swift sources, which SwiftPM refuses.
+func (e *exporter) target(r modulenames.Rule, call *build.Rule, name string) (Target, bool) {
+	pkg := unused.Package(r.Label)
+	t := Target{Name: name, Label: r.Label, Type: TypeLibrary}
+	switch {
+	case strings.HasSuffix(r.Kind, "_binary"):
+		t.Type = TypeExecutable
+	case strings.Contains(r.Kind, "test") && !strings.Contains(r.Kind, "library"):
+		t.Type = TypeTest
+	case strings.Contains(r.Kind, "test"):
+		t.TestOnly = true
+	}
+	if v, ok := call.Attr("testonly").(*build.Ident); ok && v.Name == "True" && t.Type == TypeLibrary {
+		t.TestOnly = true
+	}
+
+	srcs := e.files(r, call, "srcs", pkg)
+	var swift []string
+	for _, f := range srcs {
+		if path.Ext(f) == ".swift" {
+			swift = append(swift, f)
+		} else {
+			e.lose(r, "srcs", fmt.Sprintf("%s is not Swift; SwiftPM targets compile one language", f))
+		}
+	}
+	if len(swift) == 0 {
+		e.lose(r, "srcs", "no Swift sources; the rule is not exported")
+		return t, false
+	}
+	t.Path = commonDir(swift)
+	for _, f := range swift {
+		t.Sources = append(t.Sources, relTo(t.Path, f))
+	}
+	for _, f := range e.files(r, call, "data", pkg) {
+		if f != t.Path && !strings.HasPrefix(f, t.Path+"/") {
+			e.lose(r, "data", fmt.Sprintf("%s is outside the target directory %s, where SwiftPM resources must be", f, t.Path))
+			continue
+		}
+		t.Resources = append(t.Resources, relTo(t.Path, f))
+	}
+
+	for _, attr := range call.AttrKeys() {
+		switch attr {
+		case "srcs", "data", "deps":
+		case "copts", "additional_copts":
+			e.copts(r, &t, attr, e.strings(r, call, attr))
+		case "defines":
+			t.Defines = append(t.Defines, e.strings(r, call, attr)...)
+		case "linkopts":
+			e.linkopts(r, &t, e.strings(r, call, attr))
+		case "target_compatible_with":
+			for _, c := range e.strings(r, call, attr) {
+				if c != "@platforms//os:macos" {
+					e.lose(r, attr, fmt.Sprintf("constraint %s is not expressed; the package builds for every macOS architecture", c))
+				}
+			}
+		case "enable_library_evolution", "library_evolution":
+			if v, ok := call.Attr(attr).(*build.Ident); !ok || v.Name != "False" {
+				t.LibraryEvolution = true
+				e.loseUnsafe(r, attr, "library evolution needs unsafeFlags, which packages used as dependencies may not have")
+			}
+		default:
+			if !ignoredAttrs[attr] {
+				e.lose(r, attr, "no SwiftPM equivalent")
+			}
+		}
+	}
+	if _, ok := call.Attr("deps").(*build.ListExpr); !ok && call.Attr("deps") != nil {
+		e.lose(r, "deps", "not a plain list; deps it adds are not exported")
+	}
+	return t, true
+}
+
+// strings returns the string values of attr, reporting values that are not
+// a plain list of strings.
+func (e *exporter) strings(r modulenames.Rule, call *build.Rule, attr string) []string {
+	list, ok := call.Attr(attr).(*build.ListExpr)
+	if !ok {
+		e.lose(r, attr, "not a plain list; not exported")
+		return nil
+	}
+	var out []string
+	for _, v := range list.List {
+		if s, ok := v.(*build.StringExpr); ok {
+			out = append(out, s.Value)
+		} else {
+			e.lose(r, attr, "computed value not exported")
+		}
+	}
+	return out
+}
+
+func (e *exporter) copts(r modulenames.Rule, t *Target, attr string, opts []string) {
+	for i := 0; i < len(opts); i++ {
+		opt := opts[i]
+		switch {
+		case ignoredCopts[opt]:
+		case opt == "-D" && i+1 < len(opts):
+			i++
+			t.Defines = append(t.Defines, opts[i])
+		case strings.HasPrefix(opt, "-D") && len(opt) > 2:
+			t.Defines = append(t.Defines, opt[2:])
+		case opt == "-target" && i+1 < len(opts):
+			i++
+			e.checkTarget(r, attr, opts[i])
+		case opt == "-Xfrontend" && i+1 < len(opts) && ignoredCopts[opts[i+1]]:
+			i++
+		default:
+			t.SwiftFlags = append(t.SwiftFlags, e.unsafe(r, attr, opts, &i)...)
+		}
+	}
+}
+
+// checkTarget reports a -target value other than the package platform, or
+// newer than its minimum version.
+func (e *exporter) checkTarget(r modulenames.Rule, attr, triple string) {
+	m := macOSTarget.FindStringSubmatch(triple)
+	if m == nil || VersionLess(e.opts.MacOS, m[1]) {
+		e.lose(r, attr, fmt.Sprintf("-target %s is not the package platform, macOS %s", triple, e.opts.MacOS))
+	}
+}
+
+// unsafe reports the option at opts[*i], and its value, as needing
+// unsafeFlags, advancing *i past the value. It returns the option and value.
+func (e *exporter) unsafe(r modulenames.Rule, attr string, opts []string, i *int) []string {
+	flags := []string{opts[*i]}
+	if valueOpts[opts[*i]] && *i+1 < len(opts) {
+		*i++
+		flags = append(flags, opts[*i])
+	}
+	e.loseUnsafe(r, attr, fmt.Sprintf("%s needs unsafeFlags, which packages used as dependencies may not have", strings.Join(flags, " ")))
+	return flags
+}
+
+func (e *exporter) linkopts(r modulenames.Rule, t *Target, opts []string) {
+	for i := 0; i < len(opts); i++ {
+		opt := opts[i]
+		switch {
+		case opt == "-framework" && i+1 < len(opts):
+			i++
+			t.LinkedFrameworks = append(t.LinkedFrameworks, opts[i])
+		case strings.HasPrefix(opt, "-l") && len(opt) > 2:
+			t.LinkedLibraries = append(t.LinkedLibraries, opt[2:])
+		case opt == "-target" && i+1 < len(opts):
+			i++
+			e.checkTarget(r, "linkopts", opts[i])
+		default:
+			t.LinkerFlags = append(t.LinkerFlags, e.unsafe(r, "linkopts", opts, &i)...)
+		}
+	}
+}
+
+// files evaluates the file list attr of call, in package pkg, to paths
+// relative to the workspace root. Labels of other rules and configurable
+// values are reported and left out.
+func (e *exporter) files(r modulenames.Rule, call *build.Rule, attr, pkg string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	add := func(f string) {
+		if !seen[f] {
+			seen[f] = true
+			out = append(out, f)
+		}
+	}
+	var eval func(x build.Expr)
+	eval = func(x build.Expr) {
+		switch x := x.(type) {
+		case nil:
+		case *build.BinaryExpr:
+			if x.Op != "+" {
+				e.lose(r, attr, "computed value not exported")
+				return
+			}
+			eval(x.X)
+			eval(x.Y)
+		case *build.ListExpr:
+			for _, v := range x.List {
+				s, ok := v.(*build.StringExpr)
+				if !ok {
+					e.lose(r, attr, "computed value not exported")
+					continue
+				}
+				if f, ok := e.sourceFile(pkg, s.Value); ok {
+					add(f)
+				} else {
+					e.lose(r, attr, fmt.Sprintf("%s is a rule's output, not a source file; not exported", s.Value))
+				}
+			}
+		case *build.CallExpr:
+			id, _ := x.X.(*build.Ident)
+			switch {
+			case id != nil && id.Name == "glob":
+				include, exclude := buildfile.GlobArgs(x)
+				for _, f := range e.glob(pkg, include, exclude) {
+					add(f)
+				}
+			case id != nil && id.Name == "select":
+				e.lose(r, attr, "configurable (select) value not exported")
+			default:
+				e.lose(r, attr, "computed value not exported")
+			}
+		default:
+			e.lose(r, attr, "computed value not exported")
+		}
+	}
+	eval(call.Attr(attr))
+	sort.Strings(out)
+	return out
+}
+
+// sourceFile resolves a srcs entry of pkg to a file relative to the
+// workspace root, reporting false for labels of files that do not exist,
+// which name rule outputs.
+func (e *exporter) sourceFile(pkg, entry string) (string, bool) {
+	if strings.HasPrefix(entry, "@") {
+		return "", false
+	}
+	var name string
+	if strings.HasPrefix(entry, "//") || strings.HasPrefix(entry, ":") {
+		label := buildfile.NormaliseLabel(pkg, entry)
+		p, n, _ := strings.Cut(strings.TrimPrefix(label, "//"), ":")
+		name = path.Join(p, n)
+	} else {
+		name = path.Join(pkg, entry)
+	}
+	info, err := os.Stat(filepath.Join(e.root, filepath.FromSlash(name)))
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+	return name, true
+}
+
+// glob returns the files of pkg matching include and not exclude, relative
+// to the workspace root. Like Bazel, it does not descend into packages
+// below pkg.
+func (e *exporter) glob(pkg string, include, exclude []string) []string {
+	var out []string
+	for _, rel := range e.listing(pkg) {
+		matches := func(patterns []string) bool {
+			for _, p := range patterns {
+				if walker.Match(p, rel) {
+					return true
+				}
+			}
+			return false
+		}
+		if matches(include) && !matches(exclude) {
+			out = append(out, path.Join(pkg, rel))
+		}
+	}
+	return out
+}
+
+// listing returns the files of pkg, relative to its directory.
+func (e *exporter) listing(pkg string) []string {
+	if files, ok := e.listings[pkg]; ok {
+		return files
+	}
+	var files []string
+	_ = walker.Walk(filepath.Join(e.root, filepath.FromSlash(pkg)), walker.Options{}, func(rel string) error {
+		for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+			if e.packages[path.Join(pkg, dir)] {
+				return nil
+			}
+		}
+		files = append(files, rel)
+		return nil
+	})
+	e.listings[pkg] = files
+	return files
+}
+
+// external maps a dep outside the workspace's Swift rules to a product of
+// a package pinned in Package.resolved, as rules_swift_package_manager
+// names their repositories: @swiftpkg_<identity>//:<product>.
+func (e *exporter) external(r modulenames.Rule, dep string) (Dependency, bool) {
+	repo, product, ok := strings.Cut(dep, "//:")
+	repo, isPkg := strings.CutPrefix(repo, "@swiftpkg_")
+	if !ok || !isPkg {
+		e.lose(r, "deps", fmt.Sprintf("%s is not a Swift rule of the workspace or a Swift package product", dep))
+		return Dependency{}, false
+	}
+	for _, p := range e.pins {
+		if RepoName(p.Identity) == repo {
+			e.used[p.Identity] = true
+			return Dependency{Product: product, Package: p.Identity}, true
+		}
+	}
+	e.lose(r, "deps", fmt.Sprintf("%s: no package pinned in Package.resolved has identity %s", dep, repo))
+	return Dependency{}, false
+}
+
+// RepoName is the repository name part rules_swift_package_manager derives
+// from a package identity.
+func RepoName(identity string) string {
+	return nonRepoName.ReplaceAllString(strings.ToLower(identity), "_")
+}
+
+// overlaps drops the files a target shares with one before it, which
+// SwiftPM refuses.
+func (e *exporter) overlaps(targets []Target, byLabel map[string]modulenames.Rule) {
+	owner := make(map[string]string)
+	for i := range targets {
+		t := &targets[i]
+		kept := t.Sources[:0]
+		for _, src := range t.Sources {
+			f := path.Join(t.Path, src)
+			if first, ok := owner[f]; ok {
+				e.lose(byLabel[t.Label], "srcs", fmt.Sprintf("%s is also compiled by %s; SwiftPM targets cannot share sources", f, first))
+				continue
+			}
+			owner[f] = t.Label
+			kept = append(kept, src)
+		}
+		t.Sources = kept
+	}
+}
+
+// LoadPins reads the pins of a Package.resolved, sorted by identity; a
+// missing file has none.
+func LoadPins(file string) ([]PackageDependency, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var resolved struct {
+		Pins []struct {
+			Identity string `json:"identity"`
+			Location string `json:"location"`
+			State    struct {
+				Revision string `json:"revision"`
+				Version  string `json:"version"`
+			} `json:"state"`
+		} `json:"pins"`
+	}
+	if err := json.Unmarshal(data, &resolved); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	pins := make([]PackageDependency, 0, len(resolved.Pins))
+	for _, p := range resolved.Pins {
+		pins = append(pins, PackageDependency{Identity: p.Identity, URL: p.Location, Version: p.State.Version, Revision: p.State.Revision})
+	}
+	sort.Slice(pins, func(i, j int) bool { return pins[i].Identity < pins[j].Identity })
+	return pins, nil
+}
+
+// commonDir returns the deepest directory holding every file.
+func commonDir(files []string) string {
+	common := path.Dir(files[0])
+	for _, f := range files[1:] {
+		for common != "." && !strings.HasPrefix(f, common+"/") {
+			common = path.Dir(common)
+		}
+	}
+	return common
+}
+
+// relTo returns f relative to dir, both relative to the workspace root.
+func relTo(dir, f string) string {
+	if dir == "." {
+		return f
+	}
+	return strings.TrimPrefix(f, dir+"/")
+}
+
+// VersionLess reports whether dotted version a is older than b.
+func VersionLess(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			fmt.Sscan(as[i], &x)
+		}
+		if i < len(bs) {
+			fmt.Sscan(bs[i], &y)
+		}
+		if x != y {
+			return x < y
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+// Package staged reads the files staged for commit from the git index, so
+// that pre-commit checks see what will be committed rather than the work
+// tree.
+package staged
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Files lists the files below root added, copied, modified or renamed in
+// the index, relative to root.
+func Files(root string) ([]string, error) {
+	out, err := exec.Command("git", "-C", root, "diff", "--cached", "--name-only", "--relative", "--diff-filter=ACMR", "-z").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff --cached: %w", err)
+	}
+	var files []string
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// Read returns the staged content of rel, relative to root.
+func Read(root, rel string) ([]byte, error) {
+	out, err := exec.Command("git", "-C", root, "show", ":./"+rel).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git show :%s: %w", rel, err)
+	}
+	return out, nil
+}
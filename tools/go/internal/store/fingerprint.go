@@ -0,0 +1,33 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var digits = regexp.MustCompile(`[0-9]+`)
+
+// Fingerprint identifies i across runs, for baselines, dashboards and
+// tracking an issue from one pull request comment to the next. It hashes
+// the file, symbol, kind and message, but not the line, so edits elsewhere
+// in the file keep it. Paths and messages are compared without case, and
+// numbers in the message are ignored, so an issue whose count or line
+// reference changes stays the same issue. origin, when not nil, maps the
+// file to the path it was first added under, so that a moved file's
+// issues keep their fingerprints. Identical issues in one file share a
+// fingerprint.
+func Fingerprint(i Issue, origin func(string) string) string {
+	file := i.File
+	if file != "" {
+		file = path.Clean(strings.ReplaceAll(file, `\`, "/"))
+		if origin != nil {
+			file = origin(file)
+		}
+	}
+	message := strings.Join(strings.Fields(digits.ReplaceAllString(i.Message, "#")), " ")
+	sum := sha256.Sum256([]byte(strings.ToLower(file) + "\x00" + i.Symbol + "\x00" + i.Kind + "\x00" + strings.ToLower(message)))
+	return hex.EncodeToString(sum[:8])
+}
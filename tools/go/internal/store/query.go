@@ -0,0 +1,412 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+)
+
+// IssuesMetric is the pseudo-metric name under which issue counts are
+// compared.
+const IssuesMetric = "issues"
+
+// Point is one value of a metric trend.
+type Point struct {
+	RunID     int64     `json:"runId"`
+	Tool      string    `json:"tool"`
+	Timestamp time.Time `json:"timestamp"`
+	GitSHA    string    `json:"gitSha"`
+	Module    string    `json:"module"`
+	Value     float64   `json:"value"`
+}
+
+// Delta is the change of one metric for one module between two commits.
+type Delta struct {
+	Tool   string  `json:"tool"`
+	Metric string  `json:"metric"`
+	Module string  `json:"module"`
+	From   float64 `json:"from"`
+	To     float64 `json:"to"`
+	Change float64 `json:"change"`
+}
+
+// Runs returns the most recent runs, newest first, optionally restricted
+// to one tool.
+func (d *DB) Runs(ctx context.Context, tool string, limit int) ([]Run, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT r.id, r.tool, r.timestamp, r.git_sha,
+			(SELECT COUNT(*) FROM metrics m WHERE m.run_id = r.id),
+			(SELECT COUNT(*) FROM issues i WHERE i.run_id = r.id)
+		FROM runs r
+		WHERE ? = '' OR r.tool = ?
+		ORDER BY r.id DESC
+		LIMIT ?`, tool, tool, limitOrAll(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []Run
+	for rows.Next() {
+		var r Run
+		var ts string
+		if err := rows.Scan(&r.ID, &r.Tool, &ts, &r.GitSHA, &r.Metrics, &r.Issues); err != nil {
+			return nil, err
+		}
+		r.Timestamp, _ = time.Parse(time.RFC3339, ts)
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// Trend returns a metric's per-module value for the last limit runs of
+// tool recording it (all runs when limit is 0), oldest first. Samples that
+// differ only in labels other than the module are summed. Empty tool and
+// module select all of them; the metric may be IssuesMetric.
+func (d *DB) Trend(ctx context.Context, tool, metric, module string, limit int) ([]Point, error) {
+	table, value, where, inner := "metrics", "SUM(x.value)", "x.name = ?", "y.name = ?"
+	if metric == IssuesMetric {
+		table, value, where, inner = "issues", "COUNT(*)", "? != ''", "? != ''"
+	}
+	query := fmt.Sprintf(`
+		SELECT r.id, r.tool, r.timestamp, r.git_sha, x.module, %s
+		FROM %s x JOIN runs r ON r.id = x.run_id
+		WHERE %s AND (? = '' OR r.tool = ?) AND (? = '' OR x.module = ?)
+			AND r.id IN (
+				SELECT DISTINCT y.run_id FROM %s y JOIN runs ry ON ry.id = y.run_id
+				WHERE %s AND (? = '' OR ry.tool = ?)
+				ORDER BY y.run_id DESC LIMIT ?)
+		GROUP BY r.id, x.module
+		ORDER BY r.id, x.module`, value, table, where, table, inner)
+	rows, err := d.db.QueryContext(ctx, query, metric, tool, tool, module, module, metric, tool, tool, limitOrAll(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []Point
+	for rows.Next() {
+		var p Point
+		var ts string
+		if err := rows.Scan(&p.RunID, &p.Tool, &ts, &p.GitSHA, &p.Module, &p.Value); err != nil {
+			return nil, err
+		}
+		p.Timestamp, _ = time.Parse(time.RFC3339, ts)
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// Regressions compares, for every tool, the latest run at the commit
+// matching fromSHA with the latest at toSHA (either may be a prefix) and
+// returns the metrics and issue counts that increased, largest first. An
+// empty metric compares all of them.
+func (d *DB) Regressions(ctx context.Context, fromSHA, toSHA, metric string) ([]Delta, error) {
+	from, err := d.latestRuns(ctx, fromSHA)
+	if err != nil {
+		return nil, err
+	}
+	to, err := d.latestRuns(ctx, toSHA)
+	if err != nil {
+		return nil, err
+	}
+	if len(from) == 0 {
+		return nil, fmt.Errorf("no runs recorded for %s", fromSHA)
+	}
+	if len(to) == 0 {
+		return nil, fmt.Errorf("no runs recorded for %s", toSHA)
+	}
+
+	var deltas []Delta
+	for tool, toRun := range to {
+		fromRun, ok := from[tool]
+		if !ok {
+			continue
+		}
+		before, err := d.values(ctx, fromRun)
+		if err != nil {
+			return nil, err
+		}
+		after, err := d.values(ctx, toRun)
+		if err != nil {
+			return nil, err
+		}
+		for key, v := range after {
+			if metric != "" && key.metric != metric {
+				continue
+			}
+			if change := v - before[key]; change > 0 {
+				deltas = append(deltas, Delta{Tool: tool, Metric: key.metric, Module: key.module, From: before[key], To: v, Change: change})
+			}
+		}
+	}
+
+	sort.Slice(deltas, func(i, j int) bool {
+		a, b := deltas[i], deltas[j]
+		if a.Change != b.Change {
+			return a.Change > b.Change
+		}
+		if a.Metric != b.Metric {
+			return a.Metric < b.Metric
+		}
+		return a.Module < b.Module
+	})
+	return deltas, nil
+}
+
+// LatestIssueCounts returns the issue count per module of the newest run of
+// tool, and false when tool has never been recorded.
+func (d *DB) LatestIssueCounts(ctx context.Context, tool string) (map[string]int, bool, error) {
+	var id sql.NullInt64
+	if err := d.db.QueryRowContext(ctx, `SELECT MAX(id) FROM runs WHERE tool = ?`, tool).Scan(&id); err != nil {
+		return nil, false, err
+	}
+	if !id.Valid {
+		return nil, false, nil
+	}
+	rows, err := d.db.QueryContext(ctx, `SELECT module, COUNT(*) FROM issues WHERE run_id = ? GROUP BY module`, id.Int64)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var module string
+		var n int
+		if err := rows.Scan(&module, &n); err != nil {
+			return nil, false, err
+		}
+		counts[module] = n
+	}
+	return counts, true, rows.Err()
+}
+
+// MetricValues returns a run's values of one metric, summed per module.
+// A non-empty label, e.g. `state="done"`, keeps only the samples with
+// that label.
+func (d *DB) MetricValues(ctx context.Context, run int64, metric, label string) (map[string]float64, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT module, SUM(value) FROM metrics
+		WHERE run_id = ? AND name = ? AND (? = '' OR instr(labels, ?) > 0)
+		GROUP BY module`, run, metric, label, label)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make(map[string]float64)
+	for rows.Next() {
+		var module string
+		var v float64
+		if err := rows.Scan(&module, &v); err != nil {
+			return nil, err
+		}
+		values[module] = v
+	}
+	return values, rows.Err()
+}
+
+// RunSamples returns a run's samples of one metric with their labels, in
+// the order they were recorded.
+func (d *DB) RunSamples(ctx context.Context, run int64, metric string) ([]metrics.Sample, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT labels, value FROM metrics
+		WHERE run_id = ? AND name = ?
+		ORDER BY rowid`, run, metric)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []metrics.Sample
+	for rows.Next() {
+		var labels string
+		s := metrics.Sample{Name: metric}
+		if err := rows.Scan(&labels, &s.Value); err != nil {
+			return nil, err
+		}
+		s.Labels = parseLabels(labels)
+		samples = append(samples, s)
+	}
+	return samples, rows.Err()
+}
+
+// RunIssues returns a run's issues, optionally only those of one kind.
+// Issues recorded before fingerprints were are fingerprinted as they are
+// read, without following moves.
+func (d *DB) RunIssues(ctx context.Context, run int64, kind string) ([]Issue, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT module, file, line, kind, message, symbol, fingerprint, tier FROM issues
+		WHERE run_id = ? AND (? = '' OR kind = ?)
+		ORDER BY rowid`, run, kind, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var issues []Issue
+	for rows.Next() {
+		var i Issue
+		if err := rows.Scan(&i.Module, &i.File, &i.Line, &i.Kind, &i.Message, &i.Symbol, &i.Fingerprint, &i.Tier); err != nil {
+			return nil, err
+		}
+		if i.Fingerprint == "" {
+			i.Fingerprint = Fingerprint(i, nil)
+		}
+		issues = append(issues, i)
+	}
+	return issues, rows.Err()
+}
+
+// IssueChange is an issue that appeared or went away between two runs.
+type IssueChange struct {
+	// Change is "new" or "resolved".
+	Change string `json:"change,omitempty"`
+	Issue
+}
+
+// IssueChanges compares the issues of tool's latest run at the commit
+// matching fromSHA with those of its latest at toSHA, by fingerprint, and
+// returns the new ones followed by the resolved ones.
+func (d *DB) IssueChanges(ctx context.Context, tool, fromSHA, toSHA string) ([]IssueChange, error) {
+	var runs [2]int64
+	for n, sha := range []string{fromSHA, toSHA} {
+		latest, err := d.latestRuns(ctx, sha)
+		if err != nil {
+			return nil, err
+		}
+		id, ok := latest[tool]
+		if !ok {
+			return nil, fmt.Errorf("no %s run recorded for %s", tool, sha)
+		}
+		runs[n] = id
+	}
+	before, err := d.RunIssues(ctx, runs[0], "")
+	if err != nil {
+		return nil, err
+	}
+	after, err := d.RunIssues(ctx, runs[1], "")
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]IssueChange, 0)
+	for _, i := range subtract(after, before) {
+		changes = append(changes, IssueChange{Change: "new", Issue: i})
+	}
+	for _, i := range subtract(before, after) {
+		changes = append(changes, IssueChange{Change: "resolved", Issue: i})
+	}
+	return changes, nil
+}
+
+// subtract returns the issues of a whose fingerprint b does not have, as
+// often as a has it more than b.
+func subtract(a, b []Issue) []Issue {
+	count := make(map[string]int)
+	for _, i := range b {
+		count[i.Fingerprint]++
+	}
+	var out []Issue
+	for _, i := range a {
+		if count[i.Fingerprint] > 0 {
+			count[i.Fingerprint]--
+		} else {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// latestRuns returns the newest run id per tool at the commit matching sha.
+func (d *DB) latestRuns(ctx context.Context, sha string) (map[string]int64, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT tool, MAX(id) FROM runs WHERE git_sha LIKE ? || '%' GROUP BY tool`, sha)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make(map[string]int64)
+	for rows.Next() {
+		var tool string
+		var id int64
+		if err := rows.Scan(&tool, &id); err != nil {
+			return nil, err
+		}
+		runs[tool] = id
+	}
+	return runs, rows.Err()
+}
+
+type valueKey struct {
+	metric string
+	module string
+}
+
+// values returns a run's metrics summed per module, plus its issue counts.
+func (d *DB) values(ctx context.Context, run int64) (map[valueKey]float64, error) {
+	values := make(map[valueKey]float64)
+	queries := []string{
+		`SELECT name, module, SUM(value) FROM metrics WHERE run_id = ? GROUP BY name, module`,
+		`SELECT '` + IssuesMetric + `', module, COUNT(*) FROM issues WHERE run_id = ? GROUP BY module`,
+	}
+	for _, q := range queries {
+		rows, err := d.db.QueryContext(ctx, q, run)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var key valueKey
+			var v float64
+			if err := rows.Scan(&key.metric, &key.module, &v); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			values[key] = v
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// parseLabels turns a label string as Record stores it, such as
+// `{module="Core",tag="TODO"}`, back into name/value pairs.
+func parseLabels(s string) []string {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+	var labels []string
+	for s != "" {
+		name, rest, ok := strings.Cut(s, `="`)
+		if !ok {
+			break
+		}
+		var value strings.Builder
+		i := 0
+		for ; i < len(rest) && rest[i] != '"'; i++ {
+			if rest[i] == '\\' && i+1 < len(rest) {
+				i++
+				if rest[i] == 'n' {
+					value.WriteByte('\n')
+					continue
+				}
+			}
+			value.WriteByte(rest[i])
+		}
+		labels = append(labels, name, value.String())
+		s = strings.TrimPrefix(rest[min(i+1, len(rest)):], ",")
+	}
+	return labels
+}
+
+func limitOrAll(limit int) int {
+	if limit <= 0 {
+		return -1
+	}
+	return limit
+}
@@ -0,0 +1,194 @@
+// Package store records analyzer runs in a SQLite database so that
+// metrics and issues can be compared over time and between commits.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/metrics"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	tool      TEXT NOT NULL,
+	timestamp TEXT NOT NULL,
+	git_sha   TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS metrics (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	name   TEXT NOT NULL,
+	module TEXT NOT NULL,
+	labels TEXT NOT NULL,
+	value  REAL NOT NULL
+);
+CREATE TABLE IF NOT EXISTS issues (
+	run_id  INTEGER NOT NULL REFERENCES runs(id),
+	module  TEXT NOT NULL,
+	file    TEXT NOT NULL,
+	line    INTEGER NOT NULL,
+	kind    TEXT NOT NULL,
+	message TEXT NOT NULL,
+	symbol      TEXT NOT NULL DEFAULT '',
+	fingerprint TEXT NOT NULL DEFAULT '',
+	tier        TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS metrics_run ON metrics(run_id, name, module);
+CREATE INDEX IF NOT EXISTS issues_run ON issues(run_id, module);
+CREATE INDEX IF NOT EXISTS runs_sha ON runs(git_sha);
+`
+
+// addedColumns are the issues columns added since the store was first
+// written, which Open adds to older stores.
+var addedColumns = []string{
+	`symbol TEXT NOT NULL DEFAULT ''`,
+	`fingerprint TEXT NOT NULL DEFAULT ''`,
+	`tier TEXT NOT NULL DEFAULT ''`,
+}
+
+// Issue is one problem reported by an analyzer run.
+type Issue struct {
+	Module  string `json:"module"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	// Symbol names the declaration, target or key the issue is about,
+	// when the analyzer knows one.
+	Symbol      string `json:"symbol,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Tier is the maturity tier of the owning module, as umbratool.yaml
+	// gives it.
+	Tier string `json:"tier,omitempty"`
+}
+
+// Run describes one recorded analyzer run.
+type Run struct {
+	ID        int64     `json:"id"`
+	Tool      string    `json:"tool"`
+	Timestamp time.Time `json:"timestamp"`
+	GitSHA    string    `json:"gitSha"`
+	Metrics   int       `json:"metrics"`
+	Issues    int       `json:"issues"`
+}
+
+// DB is an open result store.
+type DB struct {
+	db *sql.DB
+}
+
+// Open opens, creating if necessary, the store at filename.
+func Open(filename string) (*DB, error) {
+	db, err := sql.Open("sqlite", filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &DB{db: db}, nil
+}
+
+// migrate adds the columns a store written by an older umbratool lacks.
+func migrate(db *sql.DB) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('issues')`)
+	if err != nil {
+		return err
+	}
+	have := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		have[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, column := range addedColumns {
+		name, _, _ := strings.Cut(column, " ")
+		if have[name] {
+			continue
+		}
+		if _, err := db.Exec(`ALTER TABLE issues ADD COLUMN ` + column); err != nil {
+			return err
+		}
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS issues_fingerprint ON issues(fingerprint)`)
+	return err
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Record appends a run of tool with its metric samples and issues. The
+// sample's "module" label, or "item" when there is none, becomes the
+// row's module.
+func (d *DB) Record(ctx context.Context, tool, sha string, at time.Time, samples []metrics.Sample, issues []Issue) (int64, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO runs (tool, timestamp, git_sha) VALUES (?, ?, ?)`,
+		tool, at.UTC().Format(time.RFC3339), sha)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, s := range samples {
+		module := s.Label("module")
+		if module == "" {
+			module = s.Label("item")
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO metrics (run_id, name, module, labels, value) VALUES (?, ?, ?, ?, ?)`,
+			id, s.Name, module, s.LabelString(), s.Value)
+		if err != nil {
+			return 0, err
+		}
+	}
+	for _, i := range issues {
+		_, err := tx.ExecContext(ctx, `INSERT INTO issues (run_id, module, file, line, kind, message, symbol, fingerprint, tier) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, i.Module, i.File, i.Line, i.Kind, i.Message, i.Symbol, i.Fingerprint, i.Tier)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+// GitSHA returns the commit checked out at root, with a "-dirty" suffix
+// when the work tree has local changes, or "" outside a git checkout.
+func GitSHA(root string) string {
+	out, err := exec.Command("git", "-C", root, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	sha := strings.TrimSpace(string(out))
+	if status, err := exec.Command("git", "-C", root, "status", "--porcelain", "--untracked-files=no").Output(); err == nil && len(strings.TrimSpace(string(status))) > 0 {
+		sha += "-dirty"
+	}
+	return sha
+}
@@ -0,0 +1,120 @@
+package swiftdiag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteMarkdown writes the per-module and per-kind counts, then the
+// warnings grouped by module. With a previous run, modules whose warning
+// count grew are listed first.
+func WriteMarkdown(w io.Writer, diags []Diagnostic, summaries []ModuleSummary) error {
+	warnings, errors := 0, 0
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	ratchet := len(summaries) > 0 && summaries[0].Previous != nil
+
+	var b strings.Builder
+	b.WriteString("# Swift Compiler Warnings\n\n")
+	fmt.Fprintf(&b, "**%d warnings and %d errors in %d modules**\n", warnings, errors, len(summaries))
+
+	if ratchet {
+		increases := Increases(summaries)
+		b.WriteString("\n## Ratchet\n\n")
+		if len(increases) == 0 {
+			b.WriteString("No module has more warnings than in the previous run.\n")
+		} else {
+			b.WriteString("These modules have more warnings than in the previous run:\n\n")
+			for _, s := range increases {
+				fmt.Fprintf(&b, "- %s: %d, up from %d\n", s.Module, s.Warnings, *s.Previous)
+			}
+		}
+	}
+
+	if len(summaries) > 0 {
+		b.WriteString("\n## Modules\n\n")
+		if ratchet {
+			b.WriteString("| Module | Warnings | Previous | Errors | Top kinds |\n")
+			b.WriteString("|--------|----------|----------|--------|-----------|\n")
+		} else {
+			b.WriteString("| Module | Warnings | Errors | Top kinds |\n")
+			b.WriteString("|--------|----------|--------|-----------|\n")
+		}
+		for _, s := range summaries {
+			if ratchet {
+				fmt.Fprintf(&b, "| %s | %d | %d | %d | %s |\n", s.Module, s.Warnings, *s.Previous, s.Errors, topKinds(s.ByKind))
+			} else {
+				fmt.Fprintf(&b, "| %s | %d | %d | %s |\n", s.Module, s.Warnings, s.Errors, topKinds(s.ByKind))
+			}
+		}
+	}
+
+	if kinds := KindCounts(diags); len(kinds) > 0 {
+		b.WriteString("\n## Kinds\n\n| Kind | Warnings |\n|------|----------|\n")
+		for _, k := range kinds {
+			fmt.Fprintf(&b, "| %s | %d |\n", k.Kind, k.Count)
+		}
+	}
+
+	module := ""
+	for _, d := range diags {
+		if d.Module != module {
+			if module == "" {
+				b.WriteString("\n## Diagnostics\n")
+			}
+			module = d.Module
+			fmt.Fprintf(&b, "\n### %s\n\n", module)
+		}
+		fmt.Fprintf(&b, "- `%s:%d` %s %s: %s\n", d.File, d.Line, d.Severity, d.Kind, d.Message)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// topKinds renders the three most common kinds of a module.
+func topKinds(byKind map[string]int) string {
+	kinds := make([]string, 0, len(byKind))
+	for k := range byKind {
+		kinds = append(kinds, k)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if byKind[kinds[i]] != byKind[kinds[j]] {
+			return byKind[kinds[i]] > byKind[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+	if len(kinds) > 3 {
+		kinds = kinds[:3]
+	}
+	parts := make([]string, len(kinds))
+	for i, k := range kinds {
+		parts[i] = fmt.Sprintf("%s (%d)", k, byKind[k])
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// WriteJSON writes the summaries, kinds and diagnostics as indented JSON.
+func WriteJSON(w io.Writer, diags []Diagnostic, summaries []ModuleSummary) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+	return enc.Encode(struct {
+		Modules     []ModuleSummary `json:"modules"`
+		Kinds       []KindCount     `json:"kinds"`
+		Diagnostics []Diagnostic    `json:"diagnostics"`
+	}{summaries, KindCounts(diags), diags})
+}
@@ -0,0 +1,264 @@
+// Package swiftdiag parses the diagnostics swiftc prints during a Bazel
+// build into structured issues, classifies warnings by kind and groups
+// them by module, so that warning counts can be tracked and ratcheted
+// down module by module.
+package swiftdiag
+
+import (
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Severities.
+const (
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// KindOther is the kind of warnings no rule classifies.
+const KindOther = "other"
+
+var (
+	// diagnosticLine matches "file.swift:12:5: warning: message", the
+	// form swiftc prints, with an optional trailing diagnostic group.
+	diagnosticLine = regexp.MustCompile(`^(.+?\.swift):(\d+):(?:(\d+):)?\s*(warning|error):\s*(.*?)(?:\s*\[#(\w+)\])?\s*$`)
+	ansiEscape     = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	// execroot matches the sandbox and output base prefixes Bazel puts in
+	// front of workspace paths.
+	execroot = regexp.MustCompile(`^.*?/execroot/[^/]+/`)
+	// volume matches the drive letter of an absolute Windows path.
+	volume = regexp.MustCompile(`^[A-Za-z]:/`)
+)
+
+// kindRules classify warnings by message, first match winning.
+var kindRules = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"deprecated", regexp.MustCompile(`\bis deprecated\b|\bwas deprecated\b|\brenamed to\b`)},
+	{"concurrency", regexp.MustCompile(`(?i)\bSendable\b|actor-isolated|main actor|global actor|nonisolated|data race|concurrency`)},
+	{"unused", regexp.MustCompile(`was never used|is unused|was written to, but never read|never used`)},
+	{"never-mutated", regexp.MustCompile(`was never mutated`)},
+	{"unreachable", regexp.MustCompile(`will never be executed`)},
+	{"redundant-try-await", regexp.MustCompile(`no calls to throwing functions occur within 'try'|no 'async' operations occur within 'await'`)},
+	{"cast", regexp.MustCompile(`cast from .* (?:always succeeds|always fails)|conditional cast|forced cast`)},
+	{"implicit-coercion", regexp.MustCompile(`implicitly coerced`)},
+	{"unhandled-case", regexp.MustCompile(`default will never be executed|switch must be exhaustive|@unknown default`)},
+	{"availability", regexp.MustCompile(`is only available in|unnecessary check for`)},
+}
+
+// Diagnostic is one compiler diagnostic.
+type Diagnostic struct {
+	Module   string `json:"module"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"`
+	// Kind classifies warnings: the kind the message matches, or else the
+	// diagnostic group swiftc names. Errors are all of kind "error".
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	// Fingerprint is set on warnings, the diagnostics recorded as issues.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Options configures parsing.
+type Options struct {
+	// Root is the workspace root; diagnostics for files outside it are
+	// dropped along with generated files.
+	Root string
+	// Rules name the module of each file; files outside every rule fall
+	// back to their top-level directory.
+	Rules []modulenames.Rule
+}
+
+// Parse reads a build log and returns its diagnostics, each once, sorted
+// by file and line. A file compiled by several targets reports the same
+// diagnostic more than once; only the first is kept. A log line longer
+// than textscan.MaxLine is warned about, and the diagnostics before it are
+// returned.
+func Parse(r io.Reader, opts Options) ([]Diagnostic, error) {
+	index := &moduleindex.Index{Modules: opts.Rules}
+	root := strings.TrimSuffix(slashed(opts.Root), "/") + "/"
+
+	seen := make(map[string]bool)
+	var out []Diagnostic
+	scanner := textscan.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		text := ansiEscape.ReplaceAllString(scanner.Text(), "")
+		m := diagnosticLine.FindStringSubmatch(strings.TrimSpace(text))
+		if m == nil {
+			continue
+		}
+		rel, ok := relPath(m[1], root)
+		if !ok {
+			continue
+		}
+		d := Diagnostic{File: rel, Severity: m[4], Message: m[5], Kind: Classify(m[5])}
+		d.Line, _ = strconv.Atoi(m[2])
+		d.Column, _ = strconv.Atoi(m[3])
+		if d.Kind == KindOther && m[6] != "" {
+			d.Kind = m[6]
+		}
+		if d.Severity == SeverityError {
+			d.Kind = SeverityError
+		}
+		key := d.File + ":" + m[2] + ":" + m[3] + ":" + d.Severity + ":" + d.Message
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if rule, ok := index.ForPath(rel); ok {
+			d.Module = rule.ModuleName
+		} else {
+			d.Module = workspace.ModuleForPath(rel)
+		}
+		out = append(out, d)
+	}
+	if err := textscan.Check("build log", lineNo, scanner.Err()); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].File != out[j].File {
+			return out[i].File < out[j].File
+		}
+		return out[i].Line < out[j].Line
+	})
+	return out, nil
+}
+
+// relPath turns a path from the log into one relative to the workspace
+// root. Generated files below bazel-out and files of external
+// repositories are not the workspace's to fix.
+func relPath(p, root string) (string, bool) {
+	p = strings.TrimPrefix(slashed(p), root)
+	p = execroot.ReplaceAllString(p, "")
+	p = path.Clean(strings.TrimPrefix(p, "./"))
+	if path.IsAbs(p) || volume.MatchString(p) || strings.HasPrefix(p, "..") || strings.HasPrefix(p, "bazel-out/") || strings.HasPrefix(p, "external/") {
+		return "", false
+	}
+	return p, true
+}
+
+// Classify returns the kind of a warning message.
+func Classify(message string) string {
+	for _, r := range kindRules {
+		if r.pattern.MatchString(message) {
+			return r.kind
+		}
+	}
+	return KindOther
+}
+
+// ModuleSummary counts one module's diagnostics.
+type ModuleSummary struct {
+	Module   string         `json:"module"`
+	Warnings int            `json:"warnings"`
+	Errors   int            `json:"errors"`
+	ByKind   map[string]int `json:"byKind"`
+	// Previous is the module's warning count in the run ratcheted
+	// against, when there is one.
+	Previous *int `json:"previous,omitempty"`
+}
+
+// Summarise groups diagnostics by module, most warnings first. previous,
+// when not nil, holds the warning counts of an earlier run; modules with
+// warnings then but none now are listed too.
+func Summarise(diags []Diagnostic, previous map[string]int) []ModuleSummary {
+	byModule := make(map[string]*ModuleSummary)
+	get := func(module string) *ModuleSummary {
+		s, ok := byModule[module]
+		if !ok {
+			s = &ModuleSummary{Module: module, ByKind: make(map[string]int)}
+			byModule[module] = s
+		}
+		return s
+	}
+	for _, d := range diags {
+		s := get(d.Module)
+		if d.Severity == SeverityError {
+			s.Errors++
+			continue
+		}
+		s.Warnings++
+		s.ByKind[d.Kind]++
+	}
+	if previous != nil {
+		for module := range previous {
+			get(module)
+		}
+		for module, s := range byModule {
+			n := previous[module]
+			s.Previous = &n
+		}
+	}
+
+	out := make([]ModuleSummary, 0, len(byModule))
+	for _, s := range byModule {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Warnings != out[j].Warnings {
+			return out[i].Warnings > out[j].Warnings
+		}
+		return out[i].Module < out[j].Module
+	})
+	return out
+}
+
+// Increases returns the modules with more warnings than before.
+func Increases(summaries []ModuleSummary) []ModuleSummary {
+	var out []ModuleSummary
+	for _, s := range summaries {
+		if s.Previous != nil && s.Warnings > *s.Previous {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// KindCounts returns the number of warnings of each kind, most common
+// first.
+func KindCounts(diags []Diagnostic) []KindCount {
+	counts := make(map[string]int)
+	for _, d := range diags {
+		if d.Severity == SeverityWarning {
+			counts[d.Kind]++
+		}
+	}
+	out := make([]KindCount, 0, len(counts))
+	for k, n := range counts {
+		out = append(out, KindCount{k, n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Kind < out[j].Kind
+	})
+	return out
+}
+
+// KindCount is the number of warnings of one kind.
+type KindCount struct {
+	Kind  string `json:"kind"`
+	Count int    `json:"count"`
+}
+
+// slashed returns p with forward slashes. Logs from a Windows build use
+// backslashes whatever the platform reading them, so filepath.ToSlash
+// would not do.
+func slashed(p string) string {
+	return strings.ReplaceAll(p, `\`, "/")
+}
@@ -0,0 +1,314 @@
+// Package swiftlex splits Swift source into tokens, so that analyzers match
+// keywords and declarations in code rather than regular expressions in raw
+// lines, where an import in a comment, "func" in a string or a brace in a
+// literal would count. It knows only as much Swift as that takes: line and
+// nested block comments, string literals of every form, identifiers,
+// attributes, directives, numbers and punctuation.
+package swiftlex
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind is the kind of a token.
+type Kind uint8
+
+// Token kinds.
+const (
+	// Comment is a line comment, without its newline, or a block comment.
+	Comment Kind = iota + 1
+	// String is a string literal with its delimiters, escapes and
+	// interpolations as written, e.g. `"a\(b)"` or `#"raw"#`.
+	String
+	// Ident is an identifier or keyword, e.g. "func", "$0" or "`class`".
+	Ident
+	// Attribute is an @ and the name after it, e.g. "@objc"; arguments
+	// in parentheses are separate tokens.
+	Attribute
+	// Directive is a # and the name after it, e.g. "#if" or "#selector".
+	Directive
+	// Number is an integer or floating-point literal.
+	Number
+	// Punct is a run of operator characters, e.g. "&&" or "->", a run of
+	// dots, or any other single character, e.g. "{".
+	Punct
+)
+
+var kindNames = [...]string{Comment: "comment", String: "string", Ident: "ident", Attribute: "attribute", Directive: "directive", Number: "number", Punct: "punct"}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) && kindNames[k] != "" {
+		return kindNames[k]
+	}
+	return "invalid"
+}
+
+// Token is one token of Swift source.
+type Token struct {
+	Kind Kind
+	Text string
+	// Line is the line the token starts on, counting from 1, and Offset
+	// its byte offset in the source.
+	Line   int
+	Offset int
+	// Space is set when whitespace, a comment or the start of the source
+	// comes before the token. Swift tells a binary operator, such as the
+	// ternary ?, from a postfix one by it.
+	Space bool
+}
+
+// EndLine returns the line the token ends on, which is Line but for
+// multi-line comments and strings.
+func (t Token) EndLine() int {
+	return t.Line + strings.Count(t.Text, "\n")
+}
+
+// Is reports whether the token is of kind and reads text.
+func (t Token) Is(kind Kind, text string) bool {
+	return t.Kind == kind && t.Text == text
+}
+
+// Tokens returns the tokens of src, comments included.
+func Tokens(src string) []Token {
+	return lex(src, true)
+}
+
+// Code returns the tokens of src other than comments.
+func Code(src string) []Token {
+	return lex(src, false)
+}
+
+func lex(src string, comments bool) []Token {
+	var out []Token
+	line := 1
+	space := true
+	for i := 0; i < len(src); {
+		c := src[i]
+		if c == '\n' || c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v' {
+			if c == '\n' {
+				line++
+			}
+			space = true
+			i++
+			continue
+		}
+		var kind Kind
+		end := i + 1
+		switch {
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			kind = Comment
+			end = strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src)
+			} else {
+				end += i
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			kind, end = Comment, blockCommentEnd(src, i)
+		case c == '"':
+			kind, end = String, stringEnd(src, i)
+		case c == '#':
+			if rawStringStart(src, i) >= 0 {
+				kind, end = String, stringEnd(src, i)
+			} else if end = identEnd(src, i+1); end > i+1 {
+				kind = Directive
+			} else {
+				kind = Punct
+			}
+		case c == '@':
+			if end = identEnd(src, i+1); end > i+1 {
+				kind = Attribute
+			} else {
+				kind = Punct
+			}
+		case c == '`':
+			kind = Ident
+			if j := strings.IndexByte(src[i+1:], '`'); j >= 0 && !strings.Contains(src[i+1:i+1+j], "\n") {
+				end = i + j + 2
+			} else {
+				kind = Punct
+			}
+		case c >= '0' && c <= '9':
+			kind, end = Number, numberEnd(src, i)
+		case c == '.':
+			kind, end = Punct, i+1
+			if i+1 < len(src) && src[i+1] == '.' {
+				end = operatorEnd(src, i, true)
+			}
+		case isOperator(c):
+			kind, end = Punct, operatorEnd(src, i, false)
+		case c == '_' || c == '$' || c >= utf8.RuneSelf || isLetter(c):
+			if end = identEnd(src, i); end > i {
+				kind = Ident
+			} else {
+				// A character no identifier may start with.
+				_, size := utf8.DecodeRuneInString(src[i:])
+				kind, end = Punct, i+size
+			}
+		default:
+			kind = Punct
+		}
+		text := src[i:end]
+		if kind != Comment || comments {
+			out = append(out, Token{Kind: kind, Text: text, Line: line, Offset: i, Space: space})
+		}
+		line += strings.Count(text, "\n")
+		space = kind == Comment
+		i = end
+	}
+	return out
+}
+
+// blockCommentEnd returns the offset just past the block comment starting
+// at src[i], which may hold further block comments.
+func blockCommentEnd(src string, i int) int {
+	depth := 0
+	for j := i; j+1 < len(src); j++ {
+		switch {
+		case src[j] == '/' && src[j+1] == '*':
+			depth++
+			j++
+		case src[j] == '*' && src[j+1] == '/':
+			j++
+			if depth--; depth == 0 {
+				return j + 1
+			}
+		}
+	}
+	return len(src)
+}
+
+// rawStringStart returns the offset of the quote of the raw string whose
+// hashes start at src[i], or -1 when no quote follows them.
+func rawStringStart(src string, i int) int {
+	j := i
+	for j < len(src) && src[j] == '#' {
+		j++
+	}
+	if j < len(src) && src[j] == '"' {
+		return j
+	}
+	return -1
+}
+
+// stringEnd returns the offset just past the string literal starting at
+// src[i], with its hashes if it is raw. A single-line literal missing its
+// closing quote ends at the end of its line.
+func stringEnd(src string, i int) int {
+	hashes := 0
+	for i+hashes < len(src) && src[i+hashes] == '#' {
+		hashes++
+	}
+	open := i + hashes
+	delim := `"`
+	if strings.HasPrefix(src[open:], `"""`) {
+		delim = `"""`
+	}
+	closing := delim + strings.Repeat("#", hashes)
+	escape := `\` + strings.Repeat("#", hashes)
+	for j := open + len(delim); j < len(src); j++ {
+		switch {
+		case strings.HasPrefix(src[j:], escape+"("):
+			j = interpolationEnd(src, j+len(escape)+1) - 1
+		case strings.HasPrefix(src[j:], escape):
+			j += len(escape)
+		case strings.HasPrefix(src[j:], closing):
+			return j + len(closing)
+		case src[j] == '\n' && delim == `"`:
+			return j
+		}
+	}
+	return len(src)
+}
+
+// interpolationEnd returns the offset just past the parenthesis closing the
+// interpolation whose contents start at src[j]. An interpolation left open
+// ends at the end of its line, so that one stray parenthesis cannot turn
+// the rest of the file into a string.
+func interpolationEnd(src string, j int) int {
+	depth := 1
+	for ; j < len(src); j++ {
+		switch c := src[j]; {
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth == 0 {
+				return j + 1
+			}
+		case c == '"' || c == '#' && rawStringStart(src, j) >= 0:
+			j = stringEnd(src, j) - 1
+		case c == '/' && j+1 < len(src) && src[j+1] == '*':
+			j = blockCommentEnd(src, j) - 1
+		case c == '\n':
+			return j
+		}
+	}
+	return j
+}
+
+// identEnd returns the offset just past the identifier starting at src[i],
+// or i when there is none.
+func identEnd(src string, i int) int {
+	j := i
+	for j < len(src) {
+		c := src[j]
+		if c < utf8.RuneSelf {
+			if !isLetter(c) && c != '_' && c != '$' && (c < '0' || c > '9' || j == i) {
+				break
+			}
+			j++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(src[j:])
+		if !unicode.IsLetter(r) && (j == i || !unicode.IsDigit(r) && !unicode.Is(unicode.M, r)) {
+			break
+		}
+		j += size
+	}
+	return j
+}
+
+// numberEnd returns the offset just past the number starting at src[i].
+// A dot belongs to it only when a digit follows, so "1..<n" is a range.
+func numberEnd(src string, i int) int {
+	hex := strings.HasPrefix(src[i:], "0x")
+	j := i + 1
+	for j < len(src) {
+		c := src[j]
+		switch {
+		case isLetter(c) || c == '_' || c >= '0' && c <= '9':
+			j++
+		case c == '.' && j+1 < len(src) && src[j+1] >= '0' && src[j+1] <= '9':
+			j++
+		case (c == '+' || c == '-') && (!hex && (src[j-1] == 'e' || src[j-1] == 'E') || hex && (src[j-1] == 'p' || src[j-1] == 'P')):
+			j++
+		default:
+			return j
+		}
+	}
+	return j
+}
+
+// operatorEnd returns the offset just past the operator starting at
+// src[i]. Only operators starting with a dot may hold dots. The operator
+// stops short of a comment.
+func operatorEnd(src string, i int, dots bool) int {
+	j := i
+	for j < len(src) && (isOperator(src[j]) || dots && src[j] == '.') {
+		if j > i && src[j] == '/' && j+1 < len(src) && (src[j+1] == '/' || src[j+1] == '*') {
+			break
+		}
+		j++
+	}
+	return j
+}
+
+func isOperator(c byte) bool {
+	return strings.IndexByte("/=-+!*%<>&|^~?", c) >= 0
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,217 @@
+package swiftlex
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// show renders tokens as kind:text, one per token, with the line the token
+// starts on when it is not the first.
+func show(tokens []Token) []string {
+	var out []string
+	for _, t := range tokens {
+		s := t.Kind.String() + ":" + t.Text
+		if t.Line != 1 {
+			s = fmt.Sprintf("%s@%d", s, t.Line)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestTokens(t *testing.T) {
+	for name, tc := range map[string]struct {
+		src  string
+		want []string
+	}{
+		"line comment": {
+			src:  "let a = 1 // import Foundation\nlet b",
+			want: []string{"ident:let", "ident:a", "punct:=", "number:1", "comment:// import Foundation", "ident:let@2", "ident:b@2"},
+		},
+		"line comment at the end": {
+			src:  "x // no newline",
+			want: []string{"ident:x", "comment:// no newline"},
+		},
+		"nested block comment": {
+			src:  "a /* outer /* inner */ still outer */ b",
+			want: []string{"ident:a", "comment:/* outer /* inner */ still outer */", "ident:b"},
+		},
+		"multi-line block comment": {
+			src:  "/* one\n two */\nfunc",
+			want: []string{"comment:/* one\n two */", "ident:func@3"},
+		},
+		"string": {
+			src:  `let s = "func \"quoted\" {"`,
+			want: []string{"ident:let", "ident:s", "punct:=", `string:"func \"quoted\" {"`},
+		},
+		"raw string": {
+			src:  `#"a "quoted" \n"# + x`,
+			want: []string{`string:#"a "quoted" \n"#`, "punct:+", "ident:x"},
+		},
+		"raw string with two hashes": {
+			src:  `##"ends "# here"## y`,
+			want: []string{`string:##"ends "# here"##`, "ident:y"},
+		},
+		"raw string interpolation": {
+			src:  `#"\#(name) and \(not)"#`,
+			want: []string{`string:#"\#(name) and \(not)"#`},
+		},
+		"multi-line string": {
+			src:  "let s = \"\"\"\n  a \"quote\"\n  \"\"\"\nnext",
+			want: []string{"ident:let", "ident:s", "punct:=", "string:\"\"\"\n  a \"quote\"\n  \"\"\"", "ident:next@4"},
+		},
+		"interpolation with nested parentheses": {
+			src:  `"total: \(sum(of: (a + b) * 2)) done" x`,
+			want: []string{`string:"total: \(sum(of: (a + b) * 2)) done"`, "ident:x"},
+		},
+		"interpolation holding a string": {
+			src:  `"a \(f(")")) b" c`,
+			want: []string{`string:"a \(f(")")) b"`, "ident:c"},
+		},
+		"numbers": {
+			src:  "1 1_000 3.14 1e-3 2.5E+10 0x1F 0x1p-2 0b1010 0o17",
+			want: []string{"number:1", "number:1_000", "number:3.14", "number:1e-3", "number:2.5E+10", "number:0x1F", "number:0x1p-2", "number:0b1010", "number:0o17"},
+		},
+		"range after number": {
+			src:  "0..<n 1...3",
+			want: []string{"number:0", "punct:..<", "ident:n", "number:1", "punct:...", "number:3"},
+		},
+		"member of number": {
+			src:  "1.description",
+			want: []string{"number:1", "punct:.", "ident:description"},
+		},
+		"operators": {
+			src:  "a && b || !c -> d ?? e != f",
+			want: []string{"ident:a", "punct:&&", "ident:b", "punct:||", "punct:!", "ident:c", "punct:->", "ident:d", "punct:??", "ident:e", "punct:!=", "ident:f"},
+		},
+		"operator before comment": {
+			src:  "a +// comment\nb",
+			want: []string{"ident:a", "punct:+", "comment:// comment", "ident:b@2"},
+		},
+		"punctuation": {
+			src:  "f(x: [1], y: {})",
+			want: []string{"ident:f", "punct:(", "ident:x", "punct::", "punct:[", "number:1", "punct:]", "punct:,", "ident:y", "punct::", "punct:{", "punct:}", "punct:)"},
+		},
+		"attributes and directives": {
+			src:  "@objc #if DEBUG #selector(f) @ #",
+			want: []string{"attribute:@objc", "directive:#if", "ident:DEBUG", "directive:#selector", "punct:(", "ident:f", "punct:)", "punct:@", "punct:#"},
+		},
+		"identifiers": {
+			src:  "`class` $0 _x café",
+			want: []string{"ident:`class`", "ident:$0", "ident:_x", "ident:café"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := show(Tokens(tc.src)); !slices.Equal(got, tc.want) {
+				t.Errorf("Tokens(%q)\n got %q\nwant %q", tc.src, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCodeDropsComments(t *testing.T) {
+	got := show(Code("import A // import B\n/* import C */ import D"))
+	want := []string{"ident:import", "ident:A", "ident:import@2", "ident:D@2"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSpace(t *testing.T) {
+	tokens := Tokens("a ? b : c?.d")
+	var spaced []string
+	for _, tok := range tokens {
+		if tok.Space {
+			spaced = append(spaced, tok.Text)
+		}
+	}
+	if want := []string{"a", "?", "b", ":", "c"}; !slices.Equal(spaced, want) {
+		t.Errorf("tokens after space: got %q, want %q", spaced, want)
+	}
+}
+
+// Unterminated input ends where the lexer says it does, without a panic
+// and without the lexer looping on it.
+func TestUnterminated(t *testing.T) {
+	for name, tc := range map[string]struct {
+		src  string
+		want []string
+	}{
+		"string ends at its line": {
+			src:  "\"open\nfunc f",
+			want: []string{`string:"open`, "ident:func@2", "ident:f@2"},
+		},
+		"multi-line string": {
+			src:  "\"\"\"\nnever closed",
+			want: []string{"string:\"\"\"\nnever closed"},
+		},
+		"raw string": {
+			src:  `#"open`,
+			want: []string{`string:#"open`},
+		},
+		"block comment": {
+			src:  "a /* outer /* inner */",
+			want: []string{"ident:a", "comment:/* outer /* inner */"},
+		},
+		"interpolation ends at its line": {
+			src:  "\"\\(f(\"\nnext",
+			want: []string{"string:\"\\(f(\"", "ident:next@2"},
+		},
+		"escape at the end": {
+			src:  `"a\`,
+			want: []string{`string:"a\`},
+		},
+		"backtick": {
+			src:  "`class\nx",
+			want: []string{"punct:`", "ident:class", "ident:x@2"},
+		},
+		"lone slash": {
+			src:  "/",
+			want: []string{"punct:/"},
+		},
+		"hashes": {
+			src:  "##",
+			want: []string{"punct:#", "punct:#"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			done := make(chan []string, 1)
+			go func() { done <- show(Tokens(tc.src)) }()
+			select {
+			case got := <-done:
+				if !slices.Equal(got, tc.want) {
+					t.Errorf("Tokens(%q)\n got %q\nwant %q", tc.src, got, tc.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Tokens(%q) did not return", tc.src)
+			}
+		})
+	}
+}
+
+// Every prefix of a file ends the lexer, with the tokens in order and
+// inside the source.
+func TestPrefixes(t *testing.T) {
+	src := strings.Join([]string{
+		`/* a /* b */ c */ let s = #"raw \#(x)"# + """`,
+		`  multi \(f(g(1), "s")) line`,
+		`  """ // tail`,
+		"@objc func `f`(_ x: Int...) -> Bool { 0x1p-2 ..< 1e3 }",
+	}, "\n")
+	for n := range len(src) + 1 {
+		prefix := src[:n]
+		end := 0
+		for _, tok := range Tokens(prefix) {
+			if tok.Offset < end || tok.Offset+len(tok.Text) > len(prefix) || tok.Text == "" {
+				t.Fatalf("prefix %q: token %q at %d out of place", prefix, tok.Text, tok.Offset)
+			}
+			if prefix[tok.Offset:tok.Offset+len(tok.Text)] != tok.Text {
+				t.Fatalf("prefix %q: token %q is not the source at %d", prefix, tok.Text, tok.Offset)
+			}
+			end = tok.Offset + len(tok.Text)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Package swiftlint runs SwiftLint with the JSON reporter and returns its
+// violations as structured values.
+package swiftlint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Violation is one SwiftLint finding.
+type Violation struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Character int    `json:"character"`
+	Severity  string `json:"severity"`
+	Type      string `json:"type"`
+	RuleID    string `json:"rule_id"`
+	Reason    string `json:"reason"`
+	// Fingerprint is umbratool's, not SwiftLint's; see lint.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Options controls a SwiftLint invocation.
+type Options struct {
+	// Binary defaults to "swiftlint".
+	Binary string
+	// Dir is the working directory, normally the workspace root. Reported
+	// file paths are made relative to it.
+	Dir string
+	// Config is passed as --config when set.
+	Config string
+	// Files restricts linting to these paths (relative to Dir). An empty
+	// list lints the whole directory.
+	Files []string
+	// CachePath is passed as --cache-path when set, instead of SwiftLint
+	// caching below the user's home directory.
+	CachePath string
+}
+
+func (o Options) binary() string {
+	if o.Binary != "" {
+		return o.Binary
+	}
+	return "swiftlint"
+}
+
+// Available reports whether the SwiftLint binary can be found.
+func Available(opts Options) bool {
+	_, err := exec.LookPath(opts.binary())
+	return err == nil
+}
+
+// Lint runs "swiftlint lint --reporter json" and returns the violations,
+// sorted by file and position.
+func Lint(ctx context.Context, opts Options) ([]Violation, error) {
+	args := []string{"lint", "--reporter", "json", "--quiet"}
+	if opts.Config != "" {
+		args = append(args, "--config", opts.Config)
+	}
+	if opts.CachePath != "" {
+		args = append(args, "--cache-path", opts.CachePath)
+	}
+	args = append(args, opts.Files...)
+
+	out, err := run(ctx, opts, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []Violation
+	if len(bytes.TrimSpace(out)) > 0 {
+		if err := json.Unmarshal(out, &violations); err != nil {
+			return nil, fmt.Errorf("parsing swiftlint output: %w", err)
+		}
+	}
+
+	for i := range violations {
+		if rel, err := filepath.Rel(opts.Dir, violations[i].File); err == nil && !strings.HasPrefix(rel, "..") {
+			violations[i].File = filepath.ToSlash(rel)
+		}
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		a, b := violations[i], violations[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Character < b.Character
+	})
+	return violations, nil
+}
+
+// Fix runs "swiftlint --fix" on the files and then lints them, returning
+// the violations autocorrection could not resolve.
+func Fix(ctx context.Context, opts Options) ([]Violation, error) {
+	args := []string{"--fix", "--quiet"}
+	if opts.Config != "" {
+		args = append(args, "--config", opts.Config)
+	}
+	if opts.CachePath != "" {
+		args = append(args, "--cache-path", opts.CachePath)
+	}
+	args = append(args, opts.Files...)
+
+	if _, err := run(ctx, opts, args); err != nil {
+		return nil, err
+	}
+	return Lint(ctx, opts)
+}
+
+// Filter keeps the violations in the given files.
+func Filter(violations []Violation, files []string) []Violation {
+	keep := make(map[string]bool, len(files))
+	for _, f := range files {
+		keep[filepath.ToSlash(f)] = true
+	}
+
+	var out []Violation
+	for _, v := range violations {
+		if keep[v.File] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Counts returns the number of error and warning violations.
+func Counts(violations []Violation) (errs, warnings int) {
+	for _, v := range violations {
+		if strings.EqualFold(v.Severity, "error") {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+	return errs, warnings
+}
+
+// run executes SwiftLint. A non-zero exit caused by serious violations
+// still produces a valid report, so it is only an error when nothing was
+// written to stdout.
+func run(ctx context.Context, opts Options, args []string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, opts.binary(), args...)
+	cmd.Dir = opts.Dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && stdout.Len() > 0 {
+		return stdout.Bytes(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("swiftlint %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,90 @@
+package swiftsrc
+
+import "strings"
+
+// Literal is a string literal found by Literals.
+type Literal struct {
+	// Text is the literal's content as written, escapes and
+	// interpolations included.
+	Text string
+	// Start and End delimit the literal in the scanned code, quotes
+	// included.
+	Start, End int
+	// Multiline is set for """ literals.
+	Multiline bool
+}
+
+// Literals returns the string literals in code, which should already have
+// its comments removed. It handles escapes, interpolations holding further
+// literals, raw strings (#"..."#) and multi-line literals. Literals nested
+// in an interpolation are not returned separately.
+func Literals(code string) []Literal {
+	var out []Literal
+	for i := 0; i < len(code); i++ {
+		if code[i] != '"' && code[i] != '#' {
+			continue
+		}
+		if lit, ok := literalAt(code, i); ok {
+			out = append(out, lit)
+			i = lit.End - 1
+		}
+	}
+	return out
+}
+
+// literalAt scans the literal starting at code[i], if there is one.
+func literalAt(code string, i int) (Literal, bool) {
+	hashes := 0
+	for i+hashes < len(code) && code[i+hashes] == '#' {
+		hashes++
+	}
+	open := i + hashes
+	if open >= len(code) || code[open] != '"' {
+		return Literal{}, false
+	}
+	delim := `"`
+	if strings.HasPrefix(code[open:], `"""`) {
+		delim = `"""`
+	}
+	closing := delim + strings.Repeat("#", hashes)
+	escape := `\` + strings.Repeat("#", hashes)
+
+	body := open + len(delim)
+	for j := body; j < len(code); j++ {
+		switch {
+		case strings.HasPrefix(code[j:], escape+"("):
+			j = interpolationEnd(code, j+len(escape)+1) - 1
+		case strings.HasPrefix(code[j:], escape):
+			j += len(escape)
+		case strings.HasPrefix(code[j:], closing):
+			end := j + len(closing)
+			return Literal{Text: code[body:j], Start: i, End: end, Multiline: delim == `"""`}, true
+		case code[j] == '\n' && delim == `"`:
+			return Literal{Text: code[body:j], Start: i, End: j}, true
+		}
+	}
+	return Literal{Text: code[body:], Start: i, End: len(code), Multiline: delim == `"""`}, true
+}
+
+// interpolationEnd returns the offset just past the parenthesis closing the
+// interpolation whose contents start at j.
+func interpolationEnd(code string, j int) int {
+	depth := 1
+	for ; j < len(code); j++ {
+		switch code[j] {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return j + 1
+			}
+		case '"', '#':
+			if lit, ok := literalAt(code, j); ok {
+				j = lit.End - 1
+			}
+		case '\n':
+			return j
+		}
+	}
+	return j
+}
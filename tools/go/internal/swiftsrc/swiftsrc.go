@@ -0,0 +1,144 @@
+// Package swiftsrc holds the lightweight Swift source handling shared by
+// the analyzers.
+package swiftsrc
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// StripComments removes comments and string literal contents from one
+// line of Swift so that braces, keywords and identifiers inside them are
+// ignored. inComment reports whether the line starts inside a block
+// comment; the second result reports whether the next line does.
+func StripComments(line string, inComment bool) (string, bool) {
+	return strip(line, inComment, false)
+}
+
+// RemoveComments is StripComments for analyzers that read string
+// literals: it removes only the comments.
+func RemoveComments(line string, inComment bool) (string, bool) {
+	return strip(line, inComment, true)
+}
+
+// Blank is StripComments for tools that edit the line: comments and string
+// literal contents are replaced by spaces rather than removed, so offsets
+// into the result are offsets into line.
+func Blank(line string, inComment bool) (string, bool) {
+	b := []byte(line)
+	inString := false
+	for i := 0; i < len(b); i++ {
+		switch {
+		case inComment:
+			if strings.HasPrefix(line[i:], "*/") {
+				inComment = false
+				b[i+1] = ' '
+				b[i] = ' '
+				i++
+				continue
+			}
+			b[i] = ' '
+		case inString:
+			if line[i] == '\\' && i+1 < len(b) {
+				b[i], b[i+1] = ' ', ' '
+				i++
+			} else if line[i] == '"' {
+				inString = false
+			} else {
+				b[i] = ' '
+			}
+		case strings.HasPrefix(line[i:], "//"):
+			for ; i < len(b); i++ {
+				b[i] = ' '
+			}
+			return string(b), false
+		case strings.HasPrefix(line[i:], "/*"):
+			inComment = true
+			b[i], b[i+1] = ' ', ' '
+			i++
+		case line[i] == '"':
+			inString = true
+		}
+	}
+	return string(b), inComment
+}
+
+// HasCode reports whether line holds anything but comments and
+// whitespace, as strings.TrimSpace of StripComments' result being
+// non-empty would, without building that result. The second result
+// reports whether the next line starts inside a block comment.
+func HasCode(line string, inComment bool) (bool, bool) {
+	code := false
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case inComment:
+			if strings.HasPrefix(line[i:], "*/") {
+				inComment = false
+				i++
+			}
+		case inString:
+			if line[i] == '\\' {
+				i++
+			} else if line[i] == '"' {
+				inString = false
+			}
+		case strings.HasPrefix(line[i:], "//"):
+			return code, false
+		case strings.HasPrefix(line[i:], "/*"):
+			inComment = true
+			i++
+		case line[i] == '"':
+			inString = true
+			code = true
+		case code:
+		case line[i] < utf8.RuneSelf:
+			code = !asciiSpace[line[i]]
+		default:
+			r, size := utf8.DecodeRuneInString(line[i:])
+			code = !unicode.IsSpace(r)
+			i += size - 1
+		}
+	}
+	return code, inComment
+}
+
+var asciiSpace = [utf8.RuneSelf]bool{'\t': true, '\n': true, '\v': true, '\f': true, '\r': true, ' ': true}
+
+func strip(line string, inComment, keepStrings bool) (string, bool) {
+	var b strings.Builder
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case inComment:
+			if strings.HasPrefix(line[i:], "*/") {
+				inComment = false
+				i++
+			}
+		case inString:
+			if line[i] == '\\' {
+				if keepStrings && i+1 < len(line) {
+					b.WriteString(line[i : i+2])
+				}
+				i++
+			} else if line[i] == '"' {
+				inString = false
+				b.WriteByte('"')
+			} else if keepStrings {
+				b.WriteByte(line[i])
+			}
+		case strings.HasPrefix(line[i:], "//"):
+			return b.String(), false
+		case strings.HasPrefix(line[i:], "/*"):
+			inComment = true
+			i++
+		case line[i] == '"':
+			inString = true
+			b.WriteByte('"')
+		default:
+			b.WriteByte(line[i])
+		}
+	}
+	return b.String(), inComment
+}
@@ -0,0 +1,35 @@
+package swiftsrc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testfixture"
+)
+
+func BenchmarkStripComments(b *testing.B) {
+	root := testfixture.SwiftTree(b, 1, 40)
+	paths, err := filepath.Glob(filepath.Join(root, "Sources", "*", "*.swift"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	var contents []string
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			b.Fatal(err)
+		}
+		contents = append(contents, string(data))
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, content := range contents {
+			inComment := false
+			for line := range strings.Lines(content) {
+				_, inComment = StripComments(line, inComment)
+			}
+		}
+	}
+}
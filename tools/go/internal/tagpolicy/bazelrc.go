@@ -0,0 +1,153 @@
+package tagpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Tag filter flags.
+const (
+	FlagBuild = "build_tag_filters"
+	FlagTest  = "test_tag_filters"
+)
+
+// Filter is one --build_tag_filters or --test_tag_filters option of a
+// bazelrc file.
+type Filter struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	// Command is the command the option applies to, e.g. "build", and
+	// Config the config it belongs to, empty for the default options.
+	Command string `json:"command"`
+	Config  string `json:"config,omitempty"`
+	Flag    string `json:"flag"`
+	// Tags are the filter's tags as written, a leading - excluding those
+	// carrying it.
+	Tags []string `json:"tags"`
+	// Targets is the number of targets the filter applies to, those whose
+	// kind ends in _test for a test filter, and Excluded the number it
+	// leaves out.
+	Targets  int `json:"targets"`
+	Excluded int `json:"excluded"`
+}
+
+// ReadFilters returns the tag filters of the bazelrc file rc, relative to
+// root, and of the files it imports from the workspace.
+func ReadFilters(root, rc string) ([]Filter, error) {
+	return readFilters(root, filepath.ToSlash(rc), true, map[string]bool{})
+}
+
+func readFilters(root, rel string, required bool, seen map[string]bool) ([]Filter, error) {
+	if seen[rel] {
+		return nil, nil
+	}
+	seen[rel] = true
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+	if os.IsNotExist(err) && !required {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var filters []Filter
+	for n, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if (fields[0] == "import" || fields[0] == "try-import") && len(fields) == 2 {
+			imported, ok := strings.CutPrefix(fields[1], "%workspace%/")
+			if !ok {
+				continue
+			}
+			more, err := readFilters(root, imported, fields[0] == "import", seen)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, more...)
+			continue
+		}
+
+		command, config, _ := strings.Cut(fields[0], ":")
+		for i := 1; i < len(fields); i++ {
+			arg := fields[i]
+			if strings.HasPrefix(arg, "#") {
+				break
+			}
+			for _, flag := range []string{FlagBuild, FlagTest} {
+				value, ok := strings.CutPrefix(arg, "--"+flag+"=")
+				if !ok && arg == "--"+flag && i+1 < len(fields) {
+					i++
+					value, ok = fields[i], true
+				}
+				if ok {
+					filters = append(filters, Filter{File: rel, Line: n + 1, Command: command, Config: config, Flag: flag, Tags: splitTags(value)})
+				}
+			}
+		}
+	}
+	return filters, nil
+}
+
+func splitTags(value string) []string {
+	var tags []string
+	for _, t := range strings.Split(value, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// count sets f.Targets and f.Excluded. Bazel keeps a target that carries
+// none of the filter's excluded tags and, when the filter names tags to
+// include, at least one of those.
+func (f *Filter) count(targets []Target) {
+	var include, exclude []string
+	for _, tag := range f.Tags {
+		if name, ok := strings.CutPrefix(tag, "-"); ok {
+			exclude = append(exclude, name)
+		} else {
+			include = append(include, strings.TrimPrefix(tag, "+"))
+		}
+	}
+	for _, t := range targets {
+		if f.Flag == FlagTest && !strings.HasSuffix(t.Kind, "_test") {
+			continue
+		}
+		f.Targets++
+		if hasAny(t.Tags, exclude) || len(include) > 0 && !hasAny(t.Tags, include) {
+			f.Excluded++
+		}
+	}
+}
+
+// Option returns the command, config and flag of the filter, e.g.
+// "build:prod --build_tag_filters".
+func (f *Filter) Option() string {
+	where := f.Command
+	if f.Config != "" {
+		where += ":" + f.Config
+	}
+	return where + " --" + f.Flag
+}
+
+func (f *Filter) issue(kind, tag, message string) Issue {
+	return Issue{Kind: kind, Severity: severity[kind], Module: workspace.ModuleForPath(f.File), File: f.File, Line: f.Line,
+		Tag: tag, Message: message}
+}
+
+func hasAny(tags, names []string) bool {
+	for _, t := range tags {
+		for _, n := range names {
+			if t == n {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package tagpolicy
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/configschema"
+)
+
+// PolicyFile is the default policy, relative to the project root.
+const PolicyFile = "tag_policy.yaml"
+
+// Policy is the tag policy, read from tag_policy.yaml:
+//
+//	allowed:
+//	  manual: Left out of wildcard patterns such as //...
+//	  test: Test code, left out by the configs filtering -test.
+//	required:
+//	  - tags: [test]
+//	    kinds: ["*_test"]
+//	  - tags: [test]
+//	    testonly: true
+//	  - tags: [foundation-free]
+//	    paths: ["Sources/*ProtocolsCore"]
+//
+// A requirement applies to the rules that match all of its selectors:
+// one of its kinds, one of its paths (walker.Match globs on the Bazel
+// package, e.g. "Sources/Security*/**") and, when testonly is set,
+// testonly = True.
+type Policy struct {
+	// Allowed maps every tag a target or a tag filter may use to what it
+	// means.
+	Allowed  map[string]string `yaml:"allowed"`
+	Required []Requirement     `yaml:"required"`
+}
+
+// Requirement is a set of tags the rules it selects must carry.
+type Requirement struct {
+	Tags     []string `yaml:"tags"`
+	Kinds    []string `yaml:"kinds"`
+	Paths    []string `yaml:"paths"`
+	TestOnly bool     `yaml:"testonly"`
+	Reason   string   `yaml:"reason"`
+}
+
+// LoadPolicy reads and validates a policy file.
+func LoadPolicy(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := configschema.Validate(configschema.KindTagPolicy, file, data); err != nil {
+		return nil, err
+	}
+
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for i, r := range p.Required {
+		if len(r.Kinds) == 0 && len(r.Paths) == 0 && !r.TestOnly {
+			return nil, fmt.Errorf("%s: requirement %d selects every rule; give it kinds, paths or testonly", file, i+1)
+		}
+		for _, tag := range r.Tags {
+			if _, ok := p.Allowed[tag]; !ok {
+				return nil, fmt.Errorf("%s: requirement %d: tag %q is not allowed", file, i+1, tag)
+			}
+		}
+	}
+	return &p, nil
+}
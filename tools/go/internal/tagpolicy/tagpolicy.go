@@ -0,0 +1,270 @@
+// Package tagpolicy checks the tags of the tree's Bazel targets against a
+// policy, and the tag filters of .bazelrc against the tags in use, so that
+// a config such as build:prod --build_tag_filters=-test,-tests leaves out
+// the targets it is meant to.
+package tagpolicy
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Issue kinds.
+const (
+	IssueUnknown       = "tag_unknown"
+	IssueMissing       = "tag_missing"
+	IssueUnchecked     = "tag_unchecked"
+	IssueFilterUnknown = "tag_filter_unknown"
+	IssueFilterUnused  = "tag_filter_unused"
+)
+
+var severity = map[string]string{
+	IssueUnknown:       "error",
+	IssueMissing:       "error",
+	IssueFilterUnknown: "error",
+	IssueUnchecked:     "warning",
+	IssueFilterUnused:  "warning",
+}
+
+// Issue is one policy violation.
+type Issue struct {
+	Kind     string `json:"kind"`
+	Severity string `json:"severity"`
+	Module   string `json:"module"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	// Target is the label of the rule, for issues with a target's tags.
+	Target      string `json:"target,omitempty"`
+	Tag         string `json:"tag"`
+	Message     string `json:"message"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Target is one rule of a BUILD file and its tags.
+type Target struct {
+	Label    string   `json:"label"`
+	Kind     string   `json:"kind"`
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Tags     []string `json:"tags"`
+	TestOnly bool     `json:"testonly,omitempty"`
+	// Unchecked is set when tags is not a list of string literals, as in
+	// tags = COMMON_TAGS + ["unit"], so the tags cannot be read.
+	Unchecked bool `json:"unchecked,omitempty"`
+}
+
+// Report is the result of Check.
+type Report struct {
+	Targets []Target `json:"targets"`
+	Filters []Filter `json:"filters"`
+	Issues  []Issue  `json:"issues"`
+}
+
+// Check reads the rules of every BUILD file below root and the tag filters
+// of the given bazelrc files, relative to root, and checks both against
+// policy. BUILD files that do not parse are skipped.
+func Check(root string, policy *Policy, bazelrcs []string) (Report, error) {
+	var report Report
+	files, err := buildfile.FindAll(root)
+	if err != nil {
+		return report, err
+	}
+	for _, rel := range files {
+		f, err := buildfile.Load(filepath.Join(root, rel), buildfile.PackageOf(rel))
+		if err != nil {
+			continue
+		}
+		report.Targets = append(report.Targets, targets(f, rel)...)
+	}
+	for _, rc := range bazelrcs {
+		filters, err := ReadFilters(root, rc)
+		if err != nil {
+			return report, err
+		}
+		report.Filters = append(report.Filters, filters...)
+	}
+
+	carried := make(map[string]bool)
+	for _, t := range report.Targets {
+		report.Issues = append(report.Issues, checkTarget(t, policy)...)
+		for _, tag := range t.Tags {
+			carried[tag] = true
+		}
+	}
+	for i := range report.Filters {
+		f := &report.Filters[i]
+		f.count(report.Targets)
+		for _, tag := range f.Tags {
+			name := strings.TrimLeft(tag, "+-")
+			switch {
+			case !hasKey(policy.Allowed, name):
+				report.Issues = append(report.Issues, f.issue(IssueFilterUnknown, name,
+					fmt.Sprintf("%s filters on tag %q, which the policy does not allow", f.Option(), name)))
+			case !carried[name]:
+				report.Issues = append(report.Issues, f.issue(IssueFilterUnused, name,
+					fmt.Sprintf("%s filters on tag %q, which no target carries", f.Option(), name)))
+			}
+		}
+	}
+	return report, nil
+}
+
+// targets returns the named rules of f, a BUILD file at rel.
+func targets(f *buildfile.File, rel string) []Target {
+	var out []Target
+	for _, r := range f.Rules("") {
+		if r.Name() == "" {
+			continue
+		}
+		start, _ := r.Call.Span()
+		t := Target{
+			Label:    "//" + f.Package + ":" + r.Name(),
+			Kind:     r.Kind(),
+			File:     rel,
+			Line:     start.Line,
+			TestOnly: f.TestOnly(r.Name()),
+		}
+		switch v := r.Attr("tags").(type) {
+		case nil:
+		case *build.ListExpr:
+			for _, e := range v.List {
+				s, ok := e.(*build.StringExpr)
+				if !ok {
+					t.Unchecked = true
+					break
+				}
+				t.Tags = append(t.Tags, s.Value)
+			}
+		default:
+			t.Unchecked = true
+		}
+		if t.Unchecked {
+			t.Tags = nil
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func checkTarget(t Target, policy *Policy) []Issue {
+	issue := func(kind, tag, message string) Issue {
+		return Issue{Kind: kind, Severity: severity[kind], Module: workspace.ModuleForPath(t.File), File: t.File, Line: t.Line,
+			Target: t.Label, Tag: tag, Message: message}
+	}
+	if t.Unchecked {
+		if len(policy.requirements(t)) == 0 {
+			return nil
+		}
+		return []Issue{issue(IssueUnchecked, "", fmt.Sprintf("%s: tags are not a list of strings, so the required tags cannot be checked", t.Label))}
+	}
+
+	var issues []Issue
+	for _, tag := range t.Tags {
+		if !hasKey(policy.Allowed, tag) {
+			issues = append(issues, issue(IssueUnknown, tag, fmt.Sprintf("%s: tag %q is not allowed by the policy", t.Label, tag)))
+		}
+	}
+	for _, tag := range Missing(t, policy) {
+		issues = append(issues, issue(IssueMissing, tag, fmt.Sprintf("%s (%s): missing required tag %q", t.Label, t.Kind, tag)))
+	}
+	return issues
+}
+
+// Missing returns the tags the policy requires of t that it does not
+// carry, sorted.
+func Missing(t Target, policy *Policy) []string {
+	var missing []string
+	for _, r := range policy.requirements(t) {
+		for _, tag := range r.Tags {
+			if !slices.Contains(t.Tags, tag) && !slices.Contains(missing, tag) {
+				missing = append(missing, tag)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// requirements returns the requirements that apply to t.
+func (p *Policy) requirements(t Target) []Requirement {
+	var out []Requirement
+	for _, r := range p.Required {
+		if r.TestOnly && !t.TestOnly {
+			continue
+		}
+		if len(r.Kinds) > 0 && !matchAny(r.Kinds, t.Kind) {
+			continue
+		}
+		pkg := strings.TrimPrefix(t.Label[:strings.LastIndexByte(t.Label, ':')], "//")
+		if len(r.Paths) > 0 && !matchAny(r.Paths, pkg) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// Fix adds the missing tags the issues report to their targets' BUILD
+// files below root. It returns the number of tags added and the BUILD
+// files it saved, relative to root, those before the error when there is
+// one.
+func Fix(root string, issues []Issue) (int, []string, error) {
+	byFile := make(map[string][]Issue)
+	var files []string
+	for _, i := range issues {
+		if i.Kind != IssueMissing {
+			continue
+		}
+		if _, ok := byFile[i.File]; !ok {
+			files = append(files, i.File)
+		}
+		byFile[i.File] = append(byFile[i.File], i)
+	}
+	sort.Strings(files)
+
+	added := 0
+	var saved []string
+	for _, rel := range files {
+		f, err := buildfile.Load(filepath.Join(root, rel), buildfile.PackageOf(rel))
+		if err != nil {
+			return added, saved, err
+		}
+		for _, i := range byFile[rel] {
+			changed, err := f.AddTag(i.Target[strings.LastIndexByte(i.Target, ':')+1:], i.Tag)
+			if err != nil {
+				return added, saved, err
+			}
+			if changed {
+				added++
+			}
+		}
+		if err := f.Save(); err != nil {
+			return added, saved, err
+		}
+		saved = append(saved, rel)
+	}
+	return added, saved, nil
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if walker.Match(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasKey(m map[string]string, key string) bool {
+	_, ok := m[key]
+	return ok
+}
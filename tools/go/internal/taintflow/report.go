@@ -0,0 +1,65 @@
+package taintflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes the findings per sink and then each finding,
+// grouped by module.
+func WriteMarkdown(w io.Writer, findings []Finding, sanitisers []string) error {
+	var b strings.Builder
+	b.WriteString("# Tainted Flow Review\n\n")
+	fmt.Fprintf(&b, "Validation helpers: names starting %s\n\n", strings.Join(sanitisers, ", "))
+	fmt.Fprintf(&b, "**%d low-confidence flows of user input or network data into keychain, crypto or file APIs**\n", len(findings))
+	if len(findings) == 0 {
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	counts := make(map[string]map[string]int)
+	for _, f := range findings {
+		if counts[f.Sink] == nil {
+			counts[f.Sink] = make(map[string]int)
+		}
+		counts[f.Sink][f.Source]++
+	}
+	b.WriteString("\n| Sink | User input | Network |\n")
+	b.WriteString("|------|------------|---------|\n")
+	for _, sink := range []string{SinkKeychain, SinkCrypto, SinkFile} {
+		fmt.Fprintf(&b, "| %s | %d | %d |\n", sink, counts[sink][SourceUserInput], counts[sink][SourceNetwork])
+	}
+
+	b.WriteString("\nConfirm each flow is validated before it reaches the API, or route it through a validation helper.\n")
+	module := ""
+	for _, f := range findings {
+		if f.Module != module {
+			module = f.Module
+			fmt.Fprintf(&b, "\n## %s\n\n", module)
+		}
+		from := fmt.Sprintf("line %d", f.Origin)
+		if f.Via != "" {
+			from = fmt.Sprintf("`%s` from line %d", f.Via, f.Origin)
+		} else if f.Origin == f.Line {
+			from = "the same line"
+		}
+		fmt.Fprintf(&b, "- `%s:%d` in `%s`: %s data reaches %s `%s` via %s\n", f.File, f.Line, f.Function, f.Source, f.Sink, f.API, from)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the findings as indented JSON.
+func WriteJSON(w io.Writer, findings []Finding) error {
+	if findings == nil {
+		findings = []Finding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Findings []Finding `json:"findings"`
+	}{findings})
+}
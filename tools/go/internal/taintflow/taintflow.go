@@ -0,0 +1,315 @@
+// Package taintflow looks for data read from user input or the network
+// that reaches the keychain, crypto or file APIs in the same function
+// without passing through a validation helper. It follows values line by
+// line through assignments, without types or control flow, so a finding
+// is a lead for security review rather than a proven flaw: every finding
+// is low confidence.
+package taintflow
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Kinds of source and sink.
+const (
+	SourceUserInput = "user-input"
+	SourceNetwork   = "network"
+
+	SinkKeychain = "keychain"
+	SinkCrypto   = "crypto"
+	SinkFile     = "file"
+
+	// ConfidenceLow is the confidence of every finding.
+	ConfidenceLow = "low"
+)
+
+// DefaultSanitisers are the name prefixes of the sanctioned validation
+// helpers.
+var DefaultSanitisers = []string{"validate", "sanitise", "sanitize", "isValid"}
+
+type pattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+var (
+	sources = []pattern{
+		{SourceUserInput, regexp.MustCompile(`\breadLine\s*\(|\bCommandLine\.arguments\b|\bProcessInfo\.processInfo\.(?:arguments|environment)\b|\bFileHandle\.standardInput\b|\.stringValue\b|\b(?:NS|UI)Pasteboard\b`)},
+		{SourceNetwork, regexp.MustCompile(`\b(?:URLSession\b[^=]*|\w*[sS]ession)\.(?:data|download|upload|bytes|dataTask|downloadTask)\s*\(|\bNWConnection\b[^=]*\.receive\w*\s*\(|\.httpBody\b|\.allHTTPHeaderFields\b|\.queryItems\b`)},
+	}
+	// networkTypes are parameter types whose values come off the network.
+	networkTypes = regexp.MustCompile(`\b(?:URLRequest|URLResponse|HTTPURLResponse|URLComponents|URLQueryItem|NWConnection)\b`)
+	sinks        = []pattern{
+		{SinkKeychain, regexp.MustCompile(`\bSecItem(?:Add|Update|Delete|CopyMatching)\s*\(|\b(?:storeSecurely|storeKey|storePassword|addItem|updateItem)\s*\(|\bkeychain\.\w+\s*\(`)},
+		{SinkCrypto, regexp.MustCompile(`\b(?:encrypt|decrypt|deriveKey|sign|verify)\w*\s*\(|\bSymmetricKey\s*\(\s*data:|\b(?:AES\.GCM|ChaChaPoly)\.(?:seal|open)\s*\(|\bCCCrypt\w*\s*\(|\bSecKeyCreate\w*\s*\(`)},
+		{SinkFile, regexp.MustCompile(`\b(?:createFile|removeItem|moveItem|copyItem|createDirectory|contentsOfDirectory)\s*\(|\.write\s*\(\s*(?:to|toFile):|\bFileHandle\s*\(\s*for\w+(?:AtPath|URL)?:|\bURL\s*\(\s*(?:fileURLWithPath|resolvingBookmarkData):|\bcontentsOfFile:`)},
+	}
+
+	funcStart = regexp.MustCompile(`(?:^|[^\w.])(?:func\s+[^\s(<]+|init[?!]?)\s*(?:<[^(]*>)?\s*\(`)
+	// paramPattern matches one parameter's internal name and type.
+	paramPattern = regexp.MustCompile(`(\w+)\s*:\s*([^,)]+)`)
+	// bindPattern matches a binding, "let x = ...", "var (a, b) = ..." or
+	// "x = ...", capturing the names bound and the value.
+	bindPattern   = regexp.MustCompile(`^\s*(?:(?:if|guard|while)\s+)?(?:(?:let|var)\s+)?(\(\s*\w+(?:\s*,\s*\w+)*\s*\)|\w+)\s*(?::\s*[^=]+)?=\s*([^=].*)$`)
+	closureParams = regexp.MustCompile(`\{\s*\[?[^\]{]*?\]?\s*\(?\s*(\w+(?:\s*,\s*\w+)*)\s*\)?\s+in\b`)
+	identPattern  = regexp.MustCompile(`\b[A-Za-z_]\w*\b`)
+	callPattern   = regexp.MustCompile(`\b([A-Za-z_]\w*)\s*\(`)
+)
+
+// Finding is one flow from a source to a sink.
+type Finding struct {
+	Module   string `json:"module"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"function"`
+	// Sink is the kind of API reached, and API the call on the line.
+	Sink string `json:"sink"`
+	API  string `json:"api"`
+	// Source is the kind of data that reaches it, Origin the line it was
+	// read on and Via the variable carrying it, empty when the source is
+	// read on the sink's own line.
+	Source      string `json:"source"`
+	Origin      int    `json:"origin"`
+	Via         string `json:"via,omitempty"`
+	Text        string `json:"text"`
+	Confidence  string `json:"confidence"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Options configures a scan.
+type Options struct {
+	// Dirs are the top-level directories scanned (default "Sources").
+	Dirs []string
+	// Sanitisers are the name prefixes of the validation helpers; a value
+	// passed to one is clean from then on.
+	Sanitisers []string
+}
+
+// Scan returns the findings in the Swift files below opts.Dirs, sorted by
+// module, file and line.
+func Scan(root string, opts Options) ([]Finding, error) {
+	dirs := opts.Dirs
+	if len(dirs) == 0 {
+		dirs = []string{"Sources"}
+	}
+	var paths []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	perFile, err := pool.Map(paths, func(rel string) ([]Finding, error) {
+		return scanFile(root, rel, opts.Sanitisers)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, found := range perFile {
+		findings = append(findings, found...)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Module < findings[j].Module
+	})
+	return findings, nil
+}
+
+// taint records where a variable's data came from.
+type taint struct {
+	source string
+	line   int
+}
+
+// function is the state of the function being read.
+type function struct {
+	name string
+	// depth is the brace depth of the body; 0 while the signature is
+	// still being read.
+	depth     int
+	signature string
+	tainted   map[string]taint
+}
+
+func scanFile(root, rel string, sanitisers []string) ([]Finding, error) {
+	f, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	module := workspace.ModuleForPath(rel)
+	var (
+		findings  []Finding
+		fn        *function
+		depth     int
+		inComment bool
+		lineNo    int
+	)
+	scanner := textscan.NewScanner(f)
+	for scanner.Scan() {
+		lineNo++
+		var code string
+		code, inComment = swiftsrc.StripComments(scanner.Text(), inComment)
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+
+		if fn == nil {
+			if m := funcStart.FindStringIndex(code); m != nil {
+				name := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(code[m[0]:m[1]-1]), "func"))
+				fn = &function{name: name, tainted: make(map[string]taint)}
+				code = code[m[1]-1:]
+			}
+		}
+		if fn != nil && fn.depth == 0 {
+			body := strings.Index(code, "{")
+			if body < 0 {
+				fn.signature += code
+				if strings.Contains(code, "}") || declStarts(code) {
+					// A requirement without a body.
+					fn = nil
+				}
+				depth += strings.Count(code, "{") - strings.Count(code, "}")
+				continue
+			}
+			fn.signature += code[:body]
+			fn.depth = depth + strings.Count(code[:body], "{") - strings.Count(code[:body], "}") + 1
+			for _, m := range paramPattern.FindAllStringSubmatch(fn.signature, -1) {
+				if networkTypes.MatchString(m[2]) {
+					fn.tainted[m[1]] = taint{SourceNetwork, lineNo}
+				}
+			}
+		}
+
+		if fn != nil && fn.depth > 0 {
+			findings = append(findings, fn.line(code, strings.TrimSpace(scanner.Text()), lineNo, module, rel, sanitisers)...)
+		}
+		depth += strings.Count(code, "{") - strings.Count(code, "}")
+		if fn != nil && fn.depth > 0 && depth < fn.depth {
+			fn = nil
+		}
+	}
+	return findings, textscan.Check(rel, lineNo, scanner.Err())
+}
+
+// declStarts reports whether code begins another declaration, which ends
+// a signature that had no body.
+func declStarts(code string) bool {
+	trimmed := strings.TrimSpace(code)
+	for _, kw := range []string{"func ", "var ", "let ", "case ", "init", "associatedtype ", "subscript", "@"} {
+		if strings.HasPrefix(trimmed, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// line follows taint through one line of the function's body and returns
+// the flows into sinks it finds.
+func (fn *function) line(code, text string, no int, module, rel string, sanitisers []string) []Finding {
+	source, direct := sourceOf(code)
+	sanitised := sanitises(code, sanitisers)
+
+	var findings []Finding
+	for _, s := range sinks {
+		m := s.re.FindString(code)
+		if m == "" || sanitised {
+			continue
+		}
+		api, _, _ := strings.Cut(m, "(")
+		api = strings.Trim(strings.TrimSpace(api), ".:")
+		if direct {
+			findings = append(findings, Finding{Module: module, File: rel, Line: no, Function: fn.name, Sink: s.kind, API: api, Source: source, Origin: no, Text: text, Confidence: ConfidenceLow})
+			continue
+		}
+		if name, t, ok := fn.mentions(code); ok {
+			findings = append(findings, Finding{Module: module, File: rel, Line: no, Function: fn.name, Sink: s.kind, API: api, Source: t.source, Origin: t.line, Via: name, Text: text, Confidence: ConfidenceLow})
+		}
+	}
+
+	if m := bindPattern.FindStringSubmatch(code); m != nil {
+		var names []string
+		for _, name := range strings.Split(strings.Trim(m[1], "() "), ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+		value := m[2]
+		var t taint
+		tainted := false
+		if source, direct := sourceOf(value); direct {
+			t, tainted = taint{source, no}, true
+		} else if _, from, ok := fn.mentions(value); ok {
+			t, tainted = from, true
+		}
+		for _, name := range names {
+			if tainted && !sanitised {
+				fn.tainted[name] = t
+			} else {
+				delete(fn.tainted, name)
+			}
+		}
+	} else if sanitised {
+		// A value checked in place, as in "try validate(input)", is clean.
+		for _, ident := range identPattern.FindAllString(code, -1) {
+			delete(fn.tainted, ident)
+		}
+	}
+	if m := closureParams.FindStringSubmatch(code); m != nil && direct {
+		for _, name := range strings.Split(m[1], ",") {
+			fn.tainted[strings.TrimSpace(name)] = taint{source, no}
+		}
+	}
+	return findings
+}
+
+// mentions returns a tainted variable code refers to.
+func (fn *function) mentions(code string) (string, taint, bool) {
+	for _, ident := range identPattern.FindAllString(code, -1) {
+		if t, ok := fn.tainted[ident]; ok {
+			return ident, t, true
+		}
+	}
+	return "", taint{}, false
+}
+
+// sourceOf returns the kind of source code reads, if any.
+func sourceOf(code string) (string, bool) {
+	for _, s := range sources {
+		if s.re.MatchString(code) {
+			return s.kind, true
+		}
+	}
+	return "", false
+}
+
+// sanitises reports whether code calls a validation helper.
+func sanitises(code string, sanitisers []string) bool {
+	for _, m := range callPattern.FindAllStringSubmatch(code, -1) {
+		for _, prefix := range sanitisers {
+			if strings.HasPrefix(m[1], prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,277 @@
+// Package tasks runs the named pipelines of umbratool commands defined in
+// umbratool.yaml, ordering tasks by their dependencies and running
+// independent work in parallel.
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/configschema"
+)
+
+// Task is one named pipeline. In umbratool.yaml it is either a list of
+// steps or a mapping with needs and run:
+//
+//	tasks:
+//	  checks: [protocol-check, "complexity --max-function 30"]
+//	  pre-merge:
+//	    needs: [checks]
+//	    run: [health --format json --output health.json]
+//
+// A step naming another task depends on it; any other step is an umbratool
+// command line.
+type Task struct {
+	Needs []string `yaml:"needs"`
+	Run   []string `yaml:"run"`
+}
+
+// UnmarshalYAML accepts the list shorthand as well as the mapping.
+func (t *Task) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		return node.Decode(&t.Run)
+	}
+	type plain Task
+	return node.Decode((*plain)(t))
+}
+
+// Config is the task section of umbratool.yaml.
+type Config struct {
+	Tasks map[string]Task `yaml:"tasks"`
+}
+
+// LoadConfig reads and decodes a config file.
+func LoadConfig(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := configschema.Validate(configschema.KindUmbratool, file, data); err != nil {
+		return nil, err
+	}
+
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return &c, nil
+}
+
+// Names returns the task names, sorted.
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Tasks))
+	for name := range c.Tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Node is a task scheduled for execution.
+type Node struct {
+	Name string
+	// Needs are the tasks that must succeed first.
+	Needs []string
+	// Steps are the command lines the task runs, in parallel, each split
+	// into arguments.
+	Steps [][]string
+}
+
+// Plan returns the nodes needed to run the named tasks, dependencies
+// first. isCommand reports whether a step's first word is a known command;
+// a step that is neither a task nor a command is an error, as is a cycle.
+func Plan(c *Config, targets []string, isCommand func(string) bool) ([]*Node, error) {
+	var order []*Node
+	state := make(map[string]int) // 0 unseen, 1 visiting, 2 done
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("task cycle: %s -> %s", strings.Join(path, " -> "), name)
+		case 2:
+			return nil
+		}
+		task, ok := c.Tasks[name]
+		if !ok {
+			return fmt.Errorf("unknown task %q", name)
+		}
+		state[name] = 1
+		path = append(path, name)
+
+		node := &Node{Name: name}
+		for _, need := range task.Needs {
+			if err := visit(need, path); err != nil {
+				return err
+			}
+			node.Needs = append(node.Needs, need)
+		}
+		for _, step := range task.Run {
+			args, err := Split(step)
+			if err != nil {
+				return fmt.Errorf("task %s: %w", name, err)
+			}
+			if len(args) == 0 {
+				continue
+			}
+			if _, isTask := c.Tasks[args[0]]; isTask && len(args) == 1 {
+				if err := visit(args[0], path); err != nil {
+					return err
+				}
+				node.Needs = append(node.Needs, args[0])
+				continue
+			}
+			if !isCommand(args[0]) {
+				return fmt.Errorf("task %s: %q is neither a task nor an umbratool command", name, args[0])
+			}
+			node.Steps = append(node.Steps, args)
+		}
+
+		state[name] = 2
+		order = append(order, node)
+		return nil
+	}
+
+	for _, name := range targets {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// Result is the outcome of one step.
+type Result struct {
+	Task   string
+	Args   []string
+	Output []byte
+	Err    error
+}
+
+// ErrSkipped marks the tasks not run because a task they need failed.
+var ErrSkipped = errors.New("skipped: a needed task failed")
+
+// ErrCancelled marks the steps not started because the run was cancelled.
+var ErrCancelled = errors.New("cancelled")
+
+// Execute runs the planned nodes, starting each task once the tasks it
+// needs have succeeded and running at most jobs steps at a time. report
+// is called once per finished step, from one goroutine at a time. Once ctx
+// is done, the steps running finish and no others start; each is reported
+// with ErrCancelled and its task counts as failed. It returns the failed
+// and skipped task names.
+func Execute(ctx context.Context, nodes []*Node, jobs int, run func(args []string) ([]byte, error), report func(Result)) (failed, skipped []string) {
+	if jobs < 1 {
+		jobs = 1
+	}
+	sem := make(chan struct{}, jobs)
+	done := make(map[string]chan struct{}, len(nodes))
+	ok := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		done[n.Name] = make(chan struct{})
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, n := range nodes {
+		wg.Add(1)
+		go func(n *Node) {
+			defer wg.Done()
+			defer close(done[n.Name])
+
+			for _, need := range n.Needs {
+				<-done[need]
+				mu.Lock()
+				needOK := ok[need]
+				mu.Unlock()
+				if !needOK {
+					mu.Lock()
+					skipped = append(skipped, n.Name)
+					report(Result{Task: n.Name, Err: ErrSkipped})
+					mu.Unlock()
+					return
+				}
+			}
+
+			var steps sync.WaitGroup
+			success := true
+			for _, args := range n.Steps {
+				steps.Add(1)
+				go func(args []string) {
+					defer steps.Done()
+					sem <- struct{}{}
+					var out []byte
+					err := ErrCancelled
+					if ctx.Err() == nil {
+						out, err = run(args)
+					}
+					<-sem
+
+					mu.Lock()
+					defer mu.Unlock()
+					if err != nil {
+						success = false
+					}
+					report(Result{Task: n.Name, Args: args, Output: out, Err: err})
+				}(args)
+			}
+			steps.Wait()
+
+			mu.Lock()
+			ok[n.Name] = success
+			if !success {
+				failed = append(failed, n.Name)
+			}
+			mu.Unlock()
+		}(n)
+	}
+	wg.Wait()
+
+	sort.Strings(failed)
+	sort.Strings(skipped)
+	return failed, skipped
+}
+
+// Split splits a command line into arguments. Single and double quotes
+// group words; there is no other shell syntax.
+func Split(line string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inWord := false
+	var quote rune
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				args = append(args, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", line)
+	}
+	if inWord {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,87 @@
+// Package testfixture writes the small source trees the package tests run
+// the analyzers over.
+package testfixture
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Write creates files, keyed by slash-separated path, below a temporary
+// directory and returns it.
+func Write(t testing.TB, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for rel, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// Runs is how often Reproducible renders a report. Collections written in
+// map order come out in a different order within a few runs.
+const Runs = 20
+
+// Reproducible renders a report Runs times and fails t unless every run
+// gives the bytes of the first.
+func Reproducible(t *testing.T, render func() []byte) {
+	t.Helper()
+	want := render()
+	for i := 2; i <= Runs; i++ {
+		if got := render(); !bytes.Equal(got, want) {
+			t.Fatalf("run %d differs from the first:\n%s\nfirst run:\n%s", i, got, want)
+		}
+	}
+}
+
+// SwiftTree writes a Sources tree of modules modules with files Swift
+// files each, for the benchmarks. Every file imports Foundation and the
+// module before its own, and holds documented types whose methods branch,
+// loop and comment, so that each analyzer has work on every line kind.
+func SwiftTree(t testing.TB, modules, files int) string {
+	t.Helper()
+	tree := make(map[string]string, modules*files)
+	for m := range modules {
+		module := fmt.Sprintf("Module%02d", m)
+		for f := range files {
+			var b strings.Builder
+			b.WriteString("// Generated benchmark source.\n\nimport Foundation\n")
+			if m > 0 {
+				fmt.Fprintf(&b, "import Module%02d\n", m-1)
+			}
+			for ty := range 4 {
+				fmt.Fprintf(&b, "\n/// Type%d keeps a running total.\npublic struct Type%d_%d {\n    private var total = 0\n", ty, f, ty)
+				for fn := range 5 {
+					fmt.Fprintf(&b, `
+    /* Adds the values above a limit, or "all" of them. */
+    public mutating func add%d(_ values: [Int], limit: Int?) -> Int {
+        for value in values where value > (limit ?? 0) {
+            if value %% 2 == 0 && value > 10 {
+                total += value // even
+            } else if value %% 3 == 0 || value < 0 {
+                total -= value
+            } else {
+                total += 1
+            }
+        }
+        return total
+    }
+`, fn)
+				}
+				b.WriteString("}\n")
+			}
+			tree[fmt.Sprintf("Sources/%s/File%03d.swift", module, f)] = b.String()
+		}
+	}
+	return Write(t, tree)
+}
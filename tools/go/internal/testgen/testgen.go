@@ -0,0 +1,309 @@
+// Package testgen turns gaps in test coverage into test skeletons: for
+// each module, an XCTest case with one skipped test per public method
+// that the module's tests never refer to, written into the bundle that
+// tests the module. A gap becomes a test to fill in rather than a number
+// on a report.
+package testgen
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/apidump"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/generated"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testmap"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Tool is the generator a scaffold's header names.
+const Tool = "umbratool scaffold-tests"
+
+var (
+	identPattern = regexp.MustCompile(`[A-Za-z_]\w*`)
+	methodName   = regexp.MustCompile("^`?[A-Za-z_]\\w*`?$")
+)
+
+// Gap is a public method no test refers to.
+type Gap struct {
+	// Name is the method as api-dump names it, e.g.
+	// "SecureBytes.append(contentsOf:)".
+	Name      string `json:"name"`
+	Signature string `json:"signature"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	// Test is the skeleton's test function.
+	Test string `json:"test"`
+}
+
+// Scaffold is the skeleton test case of one module.
+type Scaffold struct {
+	Module string `json:"module"`
+	// Bundle is the test bundle the scaffold goes into, and Dir its
+	// directory. New is set when no bundle tests the module yet, and the
+	// bundle is to be created.
+	Bundle string `json:"bundle"`
+	Dir    string `json:"dir"`
+	New    bool   `json:"new,omitempty"`
+	Gaps   []Gap  `json:"gaps"`
+	// Source is the test case's Swift source, stamped as generated.
+	Source string `json:"-"`
+}
+
+// ClassName is the scaffold's XCTestCase.
+func (s *Scaffold) ClassName() string {
+	return s.Module + "ScaffoldTests"
+}
+
+// FileName is the scaffold's file, in Dir.
+func (s *Scaffold) FileName() string {
+	return s.ClassName() + ".swift"
+}
+
+// Plan finds the public methods of api that the tests in tests leave
+// untested and lays out a scaffold for each module with any; modules,
+// when set, restricts it to those modules. A method counts as tested when
+// a test file of a bundle testing its module mentions its name, which
+// errs towards counting a method tested: the scaffold lists the methods
+// certainly untested. Scaffolds are sorted by module.
+func Plan(root string, api *apidump.API, tests *testmap.Map, modules []string) ([]*Scaffold, error) {
+	wanted := make(map[string]bool, len(modules))
+	for _, m := range modules {
+		wanted[m] = true
+	}
+
+	byModule := make(map[string][]apidump.Decl)
+	var order []string
+	for _, d := range api.Decls {
+		if len(wanted) > 0 && !wanted[d.Module] {
+			continue
+		}
+		if !Testable(d) {
+			continue
+		}
+		if byModule[d.Module] == nil {
+			order = append(order, d.Module)
+		}
+		byModule[d.Module] = append(byModule[d.Module], d)
+	}
+	sort.Strings(order)
+
+	// Read each bundle's references once, however many modules it tests.
+	var dirs []string
+	for _, b := range tests.Bundles {
+		dirs = append(dirs, b.Dir)
+	}
+	refs, err := pool.Map(dirs, func(dir string) (map[string]bool, error) {
+		return references(root, dir)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*Scaffold
+	for _, module := range order {
+		decls := byModule[module]
+		bundles := testedBy(tests, module, workspace.ModuleForPath(decls[0].File))
+		mentioned := make(map[string]bool)
+		for _, i := range bundles {
+			for ident := range refs[i] {
+				mentioned[ident] = true
+			}
+		}
+
+		s := &Scaffold{Module: module}
+		if i, ok := pick(tests, bundles, module); ok {
+			s.Bundle, s.Dir = tests.Bundles[i].Name, tests.Bundles[i].Dir
+		} else {
+			s.Bundle, s.Dir, s.New = module+"Tests", "Tests/"+module+"Tests", true
+		}
+		used := make(map[string]int)
+		for _, d := range decls {
+			_, base, _ := split(d.Name)
+			if mentioned[strings.Trim(base, "`")] {
+				continue
+			}
+			test := testName(d.Name)
+			if used[test]++; used[test] > 1 {
+				test += fmt.Sprint(used[test])
+			}
+			s.Gaps = append(s.Gaps, Gap{Name: d.Name, Signature: d.Signature, File: d.File, Line: d.Line, Test: test})
+		}
+		if len(s.Gaps) == 0 {
+			continue
+		}
+		s.Source = generated.Header("//", Tool, path.Dir(decls[0].File), s.render())
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// Testable reports whether Plan expects a test of d: a method of the
+// module's sources, not a protocol requirement, and not an operator, since
+// operators are tested through the types they combine.
+func Testable(d apidump.Decl) bool {
+	if d.Kind != "func" || d.Requirement || InTests(d.File) {
+		return false
+	}
+	_, base, _ := split(d.Name)
+	return methodName.MatchString(base)
+}
+
+// testedBy returns the indexes in tests of the bundles testing module, or
+// failing that top, the top-level module its sources are under.
+func testedBy(tests *testmap.Map, module, top string) []int {
+	for _, name := range []string{module, top} {
+		var found []int
+		for i, b := range tests.Bundles {
+			for _, m := range b.Modules {
+				if m == name {
+					found = append(found, i)
+				}
+			}
+		}
+		if len(found) > 0 {
+			return found
+		}
+	}
+	return nil
+}
+
+// pick chooses the bundle a module's scaffold goes into: the one named
+// after the module, or failing that the first named as a test bundle.
+// Test kits such as UmbraTestKit exercise modules too, but hold no tests.
+func pick(tests *testmap.Map, bundles []int, module string) (int, bool) {
+	for _, i := range bundles {
+		if tests.Bundles[i].Name == module+"Tests" {
+			return i, true
+		}
+	}
+	for _, i := range bundles {
+		if name := tests.Bundles[i].Name; strings.HasSuffix(name, "Tests") || strings.HasSuffix(name, "Test") {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// references returns the identifiers the Swift files below dir use
+// outside comments and strings. Scaffolds are left out, since every
+// method they list is untested.
+func references(root, dir string) (map[string]bool, error) {
+	refs := make(map[string]bool)
+	base := filepath.Join(root, filepath.FromSlash(dir))
+	if _, err := os.Stat(base); os.IsNotExist(err) {
+		return refs, nil
+	}
+	err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+		data, err := os.ReadFile(filepath.Join(base, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		if regions, err := generated.Parse(string(data)); err == nil && len(regions) > 0 && regions[0].Kind == generated.KindHeader && regions[0].Tool == Tool {
+			return nil
+		}
+		var lines int
+		inComment := false
+		scanner := textscan.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			var code string
+			code, inComment = swiftsrc.StripComments(scanner.Text(), inComment)
+			for _, ident := range identPattern.FindAllString(code, -1) {
+				refs[ident] = true
+			}
+			lines++
+		}
+		return textscan.Check(path.Join(dir, rel), lines, scanner.Err())
+	})
+	return refs, err
+}
+
+// InTests reports whether file, in a module's sources, belongs to the
+// module's own tests.
+func InTests(file string) bool {
+	for _, part := range strings.Split(path.Dir(file), "/") {
+		if part == "Tests" {
+			return true
+		}
+	}
+	return false
+}
+
+// split breaks a method's name, "Outer.Inner.method(label:_:)", into
+// its owner "Outer.Inner", its base name and its argument labels.
+func split(name string) (owner, base string, labels []string) {
+	qualified, args, _ := strings.Cut(name, "(")
+	owner, base = "", qualified
+	if i := strings.LastIndex(qualified, "."); i >= 0 {
+		owner, base = qualified[:i], qualified[i+1:]
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(args, ")"), ":") {
+		if label != "" && label != "_" {
+			labels = append(labels, label)
+		}
+	}
+	return owner, base, labels
+}
+
+// testName is the skeleton test of a method, as in
+// "testSecureBytesAppendContentsOf".
+func testName(name string) string {
+	owner, base, labels := split(name)
+	var b strings.Builder
+	b.WriteString("test")
+	for _, part := range strings.Split(owner, ".") {
+		b.WriteString(capitalise(part))
+	}
+	b.WriteString(capitalise(strings.Trim(base, "`")))
+	for _, label := range labels {
+		b.WriteString(capitalise(label))
+	}
+	return b.String()
+}
+
+func capitalise(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// render writes the scaffold's test case.
+func (s *Scaffold) render() string {
+	var b strings.Builder
+	b.WriteString("import XCTest\n")
+	fmt.Fprintf(&b, "@testable import %s\n\n", s.Module)
+	fmt.Fprintf(&b, "/// Skeletons of tests for the public methods of %s that no test refers to,\n", s.Module)
+	b.WriteString("/// generated by `umbratool scaffold-tests`. Write each test in a test file of\n")
+	b.WriteString("/// its own; the next run drops it from here.\n")
+	fmt.Fprintf(&b, "final class %s: XCTestCase {\n", s.ClassName())
+	owner := "\x00"
+	for i, g := range s.Gaps {
+		if o, _, _ := split(g.Name); o != owner {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			owner = o
+			if o == "" {
+				o = "Global functions"
+			}
+			fmt.Fprintf(&b, "  // MARK: - %s\n", o)
+		}
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "  /// `%s` (%s:%d)\n", g.Signature, g.File, g.Line)
+		fmt.Fprintf(&b, "  func %s() throws {\n", g.Test)
+		fmt.Fprintf(&b, "    throw XCTSkip(%q)\n", "No test of "+g.Name+" yet")
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
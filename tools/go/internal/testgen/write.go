@@ -0,0 +1,146 @@
+package testgen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/generated"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+)
+
+// ErrEdited is returned for a scaffold that would overwrite a file edited
+// by hand, or one scaffold-tests did not write.
+var ErrEdited = errors.New("edited by hand")
+
+// Placement is where a scaffold was written.
+type Placement struct {
+	// File is the scaffold's file, relative to the project root.
+	File string `json:"file"`
+	// Target is the label of the test target compiling it.
+	Target string `json:"target"`
+	// Created is set when the bundle's BUILD file was created, and Dep is
+	// the module's library when the target gained it.
+	Created bool   `json:"created,omitempty"`
+	Dep     string `json:"dep,omitempty"`
+}
+
+// Write writes s into its bundle, relative to root, and adds it to the
+// bundle's test target, creating the target for a new bundle. The target
+// gains the library of the module, looked up in rules. An existing file is
+// only overwritten when it is an unedited scaffold, or with force. When
+// the file is written but its BUILD file cannot be saved, Write returns
+// the placement along with the error.
+func Write(root string, s *Scaffold, rules []modulenames.Rule, force bool) (*Placement, error) {
+	rel := path.Join(s.Dir, s.FileName())
+	file := filepath.Join(root, filepath.FromSlash(rel))
+	if data, err := os.ReadFile(file); err == nil && !force {
+		regions, err := generated.Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rel, err)
+		}
+		if len(regions) == 0 || regions[0].Kind != generated.KindHeader || regions[0].Tool != Tool || regions[0].Edited() {
+			return nil, fmt.Errorf("%s: %w; pass --force to overwrite it", rel, ErrEdited)
+		}
+	}
+
+	f, target, created, err := testTarget(root, s)
+	if err != nil {
+		return nil, err
+	}
+	p := &Placement{File: rel, Target: "//" + s.Dir + ":" + target, Created: created}
+	// A BUILD file is only saved when edited, since saving reformats it.
+	edited := created
+	for _, r := range rules {
+		if r.ModuleName != s.Module || isTest(r.Kind) {
+			continue
+		}
+		added, err := f.AddDep(target, "deps", r.Label)
+		if err != nil {
+			return nil, err
+		}
+		if added {
+			p.Dep, edited = r.Label, true
+		}
+		break
+	}
+	if !buildfile.Compiles(f.Rule(target), "srcs", s.FileName()) {
+		if _, err := f.AddSource(target, "srcs", s.FileName()); err != nil {
+			return nil, err
+		}
+		edited = true
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return nil, err
+	}
+	if err := atomicfile.WriteFile(file, []byte(s.Source), 0o644); err != nil {
+		return nil, err
+	}
+	if edited {
+		if err := f.Save(); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
+
+// testTarget loads the BUILD file of the scaffold's bundle and names its
+// test target: the one named after the bundle, or failing that the
+// package's only test. A new bundle gets a BUILD file of its own.
+func testTarget(root string, s *Scaffold) (*buildfile.File, string, bool, error) {
+	for _, name := range []string{"BUILD.bazel", "BUILD"} {
+		rel := path.Join(s.Dir, name)
+		file := filepath.Join(root, filepath.FromSlash(rel))
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		f, err := buildfile.Load(file, s.Dir)
+		if err != nil {
+			return nil, "", false, err
+		}
+		if r := f.Rule(s.Bundle); r != nil && isTest(r.Kind()) {
+			return f, s.Bundle, false, nil
+		}
+		var tests []string
+		for _, r := range modulenames.FileRules(f, rel) {
+			if _, name, ok := strings.Cut(r.Label, ":"); ok && isTest(r.Kind) {
+				tests = append(tests, name)
+			}
+		}
+		if len(tests) != 1 {
+			return nil, "", false, fmt.Errorf("%s has no test target named %s to hold the scaffold", rel, s.Bundle)
+		}
+		return f, tests[0], false, nil
+	}
+	if !s.New {
+		return nil, "", false, fmt.Errorf("%s has no BUILD file to add the scaffold to", s.Dir)
+	}
+
+	file := filepath.Join(root, filepath.FromSlash(s.Dir), "BUILD.bazel")
+	f, err := buildfile.New(file, s.Dir, []byte(fmt.Sprintf(newBuild, s.Bundle, s.Bundle)))
+	if err != nil {
+		return nil, "", false, err
+	}
+	return f, s.Bundle, true, nil
+}
+
+// newBuild is the BUILD file of a new test bundle.
+const newBuild = `load("//:bazel/macros/swift.bzl", "umbra_swift_test")
+
+umbra_swift_test(
+    name = %q,
+    srcs = glob(["*.swift"]),
+    module_name = %q,
+    deps = [],
+)
+`
+
+func isTest(kind string) bool {
+	return strings.HasSuffix(kind, "_test")
+}
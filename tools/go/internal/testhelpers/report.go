@@ -0,0 +1,73 @@
+package testhelpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/atomicfile"
+)
+
+var kindTitles = map[string]string{
+	KindXCTestImport:   "XCTest import",
+	KindTestDouble:     "test double",
+	KindTestingMember:  "member for tests",
+	KindTestOnlyUse:    "used only by tests",
+	KindTestOnlyModule: "testonly module",
+}
+
+// WriteMarkdown writes the move plan, then the findings grouped by
+// module.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	modules := make(map[string]bool)
+	for _, f := range r.Findings {
+		modules[f.Module] = true
+	}
+
+	var b strings.Builder
+	b.WriteString("# Test Helpers in Production Modules\n\n")
+	fmt.Fprintf(&b, "**%d findings in %d modules, %d moves planned**\n", len(r.Findings), len(modules), len(r.Moves))
+
+	if len(r.Moves) > 0 {
+		b.WriteString("\n## Move Plan\n\n")
+		b.WriteString("| From | To | Reason |\n")
+		b.WriteString("|------|----|--------|\n")
+		for _, m := range r.Moves {
+			fmt.Fprintf(&b, "| `%s` | `%s` | %s |\n", m.From, m.To, m.Reason)
+		}
+	}
+
+	module := ""
+	for _, f := range r.Findings {
+		if f.Module != module {
+			module = f.Module
+			fmt.Fprintf(&b, "\n## %s\n\n", module)
+			b.WriteString("| Location | Kind | Finding |\n")
+			b.WriteString("|----------|------|---------|\n")
+		}
+		fmt.Fprintf(&b, "| `%s:%d` | %s | %s |\n", f.File, f.Line, kindTitles[f.Kind], f.Message())
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WritePlan writes the move plan to file as the restructurer reads it:
+// an object holding the list of moves, sorted by source.
+func WritePlan(file string, moves []Move) error {
+	data, err := json.MarshalIndent(struct {
+		Moves []Move `json:"moves"`
+	}{moves}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(file, append(data, '\n'), 0o644)
+}
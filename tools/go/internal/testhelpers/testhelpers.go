@@ -0,0 +1,395 @@
+// Package testhelpers finds test-support code inside production modules:
+// files importing XCTest, test doubles such as mocks and spies, members
+// made for tests, types only tests use, and testonly modules kept under
+// Sources. It proposes a destination under the test-support directory for
+// each file or module that is test support as a whole, as a move plan the
+// restructurer can execute.
+package testhelpers
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/moduleindex"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/swiftsrc"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// Finding kinds.
+const (
+	KindXCTestImport   = "xctest_import"
+	KindTestDouble     = "test_double"
+	KindTestingMember  = "testing_member"
+	KindTestOnlyUse    = "test_only_use"
+	KindTestOnlyModule = "testonly_module"
+)
+
+// DefaultDest is where test support belongs.
+const DefaultDest = "Tests/TestSupport"
+
+var (
+	typeDecl = regexp.MustCompile(`^\s*(?:@\w+(?:\([^)]*\))?\s+)*(?:(?:public|open|package|internal|fileprivate|private|final|indirect)\s+)*(class|struct|enum|actor|protocol)\s+([A-Za-z_]\w*)`)
+	// testDouble matches the names of mocks, fakes, stubs, spies and
+	// dummies, by prefix or suffix.
+	testDouble    = regexp.MustCompile(`^(?:Mock|Fake|Stub|Spy|Dummy)[A-Z_]|[a-z0-9](?:Mock|Fake|Stub|Spy|Dummy|Double)$|^(?:Mock|Fake|Stub|Spy|Dummy)$`)
+	testingMember = regexp.MustCompile(`\b(?:func|var|let|case)\s+(\w*(?:ForTesting|forTesting|ForTests|forTests)\w*)`)
+	identPattern  = regexp.MustCompile(`[A-Za-z_]\w*`)
+)
+
+// Options configures a scan.
+type Options struct {
+	// Dirs are the production directories scanned (default "Sources").
+	Dirs []string
+	// TestDirs are the directories of tests, searched for uses of
+	// production types (default "Tests").
+	TestDirs []string
+	// Rules name the module of each file.
+	Rules []modulenames.Rule
+	// Dest is the test-support directory moves lead to (default
+	// DefaultDest).
+	Dest string
+}
+
+// Finding is test-support code in a production module.
+type Finding struct {
+	Module      string `json:"module"`
+	File        string `json:"file"`
+	Line        int    `json:"line,omitempty"`
+	Kind        string `json:"kind"`
+	Symbol      string `json:"symbol,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Message describes the finding.
+func (f Finding) Message() string {
+	switch f.Kind {
+	case KindXCTestImport:
+		return "imports XCTest"
+	case KindTestDouble:
+		return f.Symbol + " is a test double"
+	case KindTestingMember:
+		return f.Symbol + " exists for tests"
+	case KindTestOnlyUse:
+		return f.Symbol + " is only used by tests"
+	case KindTestOnlyModule:
+		return f.Symbol + " is testonly but lives under Sources"
+	}
+	return f.Kind
+}
+
+// Move is a file or directory to relocate under the test-support
+// directory.
+type Move struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Module string `json:"module"`
+	Reason string `json:"reason"`
+}
+
+// Report is the outcome of a scan.
+type Report struct {
+	Findings []Finding `json:"findings"`
+	Moves    []Move    `json:"moves"`
+}
+
+type decl struct {
+	name string
+	line int
+}
+
+type file struct {
+	rel, module string
+	rule        modulenames.Rule
+	hasRule     bool
+	// test marks a file of a test or testonly target, or one in a tests
+	// directory; production marks one under opts.Dirs that is not.
+	test, production bool
+	xctest           int
+	types            []decl
+	members          []decl
+	idents           map[string]bool
+}
+
+// Scan reports the test support in the production modules below
+// opts.Dirs.
+func Scan(root string, opts Options) (*Report, error) {
+	dirs, testDirs, dest := opts.Dirs, opts.TestDirs, opts.Dest
+	if len(dirs) == 0 {
+		dirs = []string{"Sources"}
+	}
+	if len(testDirs) == 0 {
+		testDirs = []string{"Tests"}
+	}
+	if dest == "" {
+		dest = DefaultDest
+	}
+	index := &moduleindex.Index{Modules: opts.Rules}
+	testOnly, err := testOnlyRules(root, opts.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	isProdDir := make(map[string]bool)
+	var paths []string
+	for i, dir := range append(append([]string(nil), dirs...), testDirs...) {
+		base := filepath.Join(root, dir)
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			continue
+		}
+		if i < len(dirs) {
+			isProdDir[path.Clean(filepath.ToSlash(dir))] = true
+		}
+		err := walker.Walk(base, walker.Options{Extensions: []string{".swift"}}, func(rel string) error {
+			paths = append(paths, path.Clean(filepath.ToSlash(filepath.Join(dir, rel))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+	paths = compact(paths)
+
+	files, err := pool.Map(paths, func(rel string) (*file, error) {
+		f, err := readFile(root, rel)
+		if err != nil {
+			return nil, err
+		}
+		f.rule, f.hasRule = index.ForPath(rel)
+		if f.hasRule {
+			f.module = f.rule.ModuleName
+			f.test = isTestKind(f.rule.Kind) || testOnly[f.rule.Label]
+		} else {
+			f.test = !underAny(isProdDir, rel) || inTestsDir(rel)
+		}
+		f.production = !f.test && underAny(isProdDir, rel)
+		return f, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{Findings: []Finding{}, Moves: []Move{}}
+	r.testOnlyModules(files, opts.Rules, testOnly, isProdDir, dest)
+
+	// Types only tests refer to. Names declared more than once are left
+	// out, since their uses cannot be told apart.
+	declaredIn := make(map[string][]*file)
+	for _, f := range files {
+		if f.production {
+			for _, t := range f.types {
+				declaredIn[t.name] = append(declaredIn[t.name], f)
+			}
+		}
+	}
+	testUse, prodUse := make(map[string]bool), make(map[string]bool)
+	for _, f := range files {
+		for name := range f.idents {
+			owners := declaredIn[name]
+			if len(owners) != 1 || owners[0] == f {
+				continue
+			}
+			if f.test {
+				testUse[name] = true
+			} else {
+				prodUse[name] = true
+			}
+		}
+	}
+
+	for _, f := range files {
+		if !f.production {
+			continue
+		}
+		var found []Finding
+		doubles := 0
+		if f.xctest > 0 {
+			found = append(found, Finding{Module: f.module, File: f.rel, Line: f.xctest, Kind: KindXCTestImport})
+		}
+		for _, t := range f.types {
+			switch {
+			case testDouble.MatchString(t.name):
+				found = append(found, Finding{Module: f.module, File: f.rel, Line: t.line, Kind: KindTestDouble, Symbol: t.name})
+				doubles++
+			case len(declaredIn[t.name]) == 1 && testUse[t.name] && !prodUse[t.name]:
+				found = append(found, Finding{Module: f.module, File: f.rel, Line: t.line, Kind: KindTestOnlyUse, Symbol: t.name})
+			}
+		}
+		for _, m := range f.members {
+			found = append(found, Finding{Module: f.module, File: f.rel, Line: m.line, Kind: KindTestingMember, Symbol: m.name})
+		}
+		r.Findings = append(r.Findings, found...)
+
+		// A file moves when it is test support as a whole: it imports
+		// XCTest, or every type it declares is a test double. Types only
+		// tests use are reported but do not move a file, since production
+		// code often reaches them through a protocol.
+		if f.xctest > 0 || (doubles > 0 && doubles == len(f.types)) {
+			var reasons []string
+			for _, x := range found {
+				if x.Kind == KindXCTestImport || x.Kind == KindTestDouble {
+					reasons = append(reasons, x.Message())
+				}
+			}
+			r.Moves = append(r.Moves, Move{From: f.rel, To: path.Join(dest, f.module, f.relToModule()), Module: f.module, Reason: strings.Join(reasons, "; ")})
+		}
+	}
+
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		a, b := r.Findings[i], r.Findings[j]
+		if a.Module != b.Module {
+			return a.Module < b.Module
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	sort.Slice(r.Moves, func(i, j int) bool { return r.Moves[i].From < r.Moves[j].From })
+	return r, nil
+}
+
+// testOnlyModules reports the testonly libraries under the production
+// directories, moving the source directory of each that has it to itself.
+// Their files are not examined further.
+func (r *Report) testOnlyModules(files []*file, rules []modulenames.Rule, testOnly map[string]bool, isProdDir map[string]bool, dest string) {
+	shared := make(map[string]int)
+	for _, rule := range rules {
+		shared[rule.SourceDir]++
+	}
+	for _, rule := range rules {
+		if !testOnly[rule.Label] || isTestKind(rule.Kind) || !underAny(isProdDir, rule.SourceDir) || inTestsDir(rule.SourceDir+"/") {
+			continue
+		}
+		r.Findings = append(r.Findings, Finding{Module: rule.ModuleName, File: rule.File, Line: rule.Line, Kind: KindTestOnlyModule, Symbol: rule.Label})
+		if shared[rule.SourceDir] == 1 {
+			r.Moves = append(r.Moves, Move{From: rule.SourceDir, To: path.Join(dest, rule.ModuleName), Module: rule.ModuleName,
+				Reason: rule.Label + " is testonly"})
+		}
+	}
+	for _, f := range files {
+		if f.hasRule && testOnly[f.rule.Label] {
+			f.production = false
+		}
+	}
+}
+
+// relToModule returns the file's path within its module's sources.
+func (f *file) relToModule() string {
+	base := ""
+	if f.hasRule {
+		base = f.rule.SourceDir
+	} else if parts := strings.SplitN(f.rel, "/", 3); len(parts) == 3 {
+		base = parts[0] + "/" + parts[1]
+	}
+	if rel := strings.TrimPrefix(f.rel, base+"/"); base != "" && rel != f.rel {
+		return rel
+	}
+	return path.Base(f.rel)
+}
+
+func readFile(root, rel string) (*file, error) {
+	fh, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	f := &file{rel: rel, module: workspace.ModuleForPath(rel), idents: make(map[string]bool)}
+	inComment := false
+	depth := 0
+	scanner := textscan.NewScanner(fh)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		var code string
+		code, inComment = swiftsrc.StripComments(scanner.Text(), inComment)
+		if mod, ok := imports.ParseLine(code); ok {
+			if mod == "XCTest" && f.xctest == 0 {
+				f.xctest = lineNo
+			}
+			continue
+		}
+		if depth == 0 {
+			if m := typeDecl.FindStringSubmatch(code); m != nil {
+				f.types = append(f.types, decl{m[2], lineNo})
+			}
+		}
+		for _, m := range testingMember.FindAllStringSubmatch(code, -1) {
+			f.members = append(f.members, decl{m[1], lineNo})
+		}
+		for _, name := range identPattern.FindAllString(code, -1) {
+			f.idents[name] = true
+		}
+		depth += strings.Count(code, "{") - strings.Count(code, "}")
+	}
+	return f, textscan.Check(rel, lineNo, scanner.Err())
+}
+
+// testOnlyRules returns the labels of the rules that are testonly, by
+// attribute or by a test library macro.
+func testOnlyRules(root string, rules []modulenames.Rule) (map[string]bool, error) {
+	out := make(map[string]bool)
+	loaded := make(map[string]*buildfile.File)
+	for _, r := range rules {
+		if strings.HasSuffix(r.Kind, "test_library") {
+			out[r.Label] = true
+			continue
+		}
+		f, ok := loaded[r.File]
+		if !ok {
+			var err error
+			if f, err = buildfile.Load(filepath.Join(root, filepath.FromSlash(r.File)), buildfile.PackageOf(r.File)); err != nil {
+				return nil, fmt.Errorf("%s: %w", r.File, err)
+			}
+			loaded[r.File] = f
+		}
+		_, name, _ := strings.Cut(r.Label, ":")
+		if f.TestOnly(name) {
+			out[r.Label] = true
+		}
+	}
+	return out, nil
+}
+
+func isTestKind(kind string) bool {
+	return strings.HasSuffix(kind, "test")
+}
+
+// inTestsDir reports whether rel has a Tests directory below its top
+// level, as in Sources/Core/Tests/CoreTests.swift.
+func inTestsDir(rel string) bool {
+	parts := strings.Split(rel, "/")
+	for _, p := range parts[1 : len(parts)-1] {
+		if p == "Tests" || strings.HasSuffix(p, "Tests") {
+			return true
+		}
+	}
+	return false
+}
+
+func underAny(dirs map[string]bool, rel string) bool {
+	top, _, _ := strings.Cut(rel, "/")
+	return dirs[top] || dirs[rel]
+}
+
+func compact(sorted []string) []string {
+	out := sorted[:0]
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
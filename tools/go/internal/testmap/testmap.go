@@ -0,0 +1,137 @@
+// Package testmap maps test bundles to the production modules they
+// exercise.
+package testmap
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/imports"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modules"
+)
+
+// Bundle is a test bundle and the modules it tests.
+type Bundle struct {
+	// Name is the bundle (test target) name, e.g. "CoreTests".
+	Name string `json:"name"`
+	// Dir is the bundle's directory relative to the workspace root.
+	Dir string `json:"dir"`
+	// Modules are the production modules the bundle tests.
+	Modules []string `json:"modules"`
+}
+
+// Map is the test map for the workspace.
+type Map struct {
+	Bundles []Bundle `json:"bundles"`
+	// Untested lists production modules no bundle tests.
+	Untested []string `json:"untested"`
+
+	byName map[string]int
+}
+
+var testableImport = regexp.MustCompile(`^\s*@testable\s+import\s+([A-Za-z_][A-Za-z0-9_]*)`)
+
+// Build derives the test map from Tests/<Bundle> directories and
+// Sources/<Module>/Tests directories. A bundle tests the modules it
+// imports with @testable; failing that, the module its name refers to
+// (CoreTests tests Core); failing that, every production module it imports.
+func Build(root string) (*Map, error) {
+	prod, err := modules.Discover(root, "Sources")
+	if err != nil {
+		return nil, err
+	}
+	isProd := make(map[string]bool, len(prod))
+	for _, m := range prod {
+		isProd[m.Name] = true
+	}
+
+	files, err := imports.ScanTree(root, "Tests", "Sources")
+	if err != nil {
+		return nil, err
+	}
+
+	type acc struct {
+		dir      string
+		testable map[string]bool
+		imported map[string]bool
+	}
+	bundles := make(map[string]*acc)
+	for _, f := range files {
+		name, dir, ok := bundleFor(f.Path)
+		if !ok {
+			continue
+		}
+		a, ok := bundles[name]
+		if !ok {
+			a = &acc{dir: dir, testable: map[string]bool{}, imported: map[string]bool{}}
+			bundles[name] = a
+		}
+		for _, imp := range f.Imports {
+			if !isProd[imp.Module] {
+				continue
+			}
+			a.imported[imp.Module] = true
+			if testableImport.MatchString(imp.Text) {
+				a.testable[imp.Module] = true
+			}
+		}
+	}
+
+	m := &Map{byName: make(map[string]int)}
+	tested := make(map[string]bool)
+	for _, name := range sortedKeys(bundles) {
+		a := bundles[name]
+		var mods []string
+		switch subject := strings.TrimSuffix(strings.TrimSuffix(name, "Tests"), "Test"); {
+		case len(a.testable) > 0:
+			mods = sortedKeys(a.testable)
+		case isProd[subject]:
+			mods = []string{subject}
+		default:
+			mods = sortedKeys(a.imported)
+		}
+		for _, mod := range mods {
+			tested[mod] = true
+		}
+		m.byName[name] = len(m.Bundles)
+		m.Bundles = append(m.Bundles, Bundle{Name: name, Dir: a.dir, Modules: mods})
+	}
+
+	for _, p := range prod {
+		if !tested[p.Name] {
+			m.Untested = append(m.Untested, p.Name)
+		}
+	}
+	return m, nil
+}
+
+// Lookup returns the bundle with the given name.
+func (m *Map) Lookup(name string) (Bundle, bool) {
+	i, ok := m.byName[name]
+	if !ok {
+		return Bundle{}, false
+	}
+	return m.Bundles[i], true
+}
+
+// bundleFor returns the bundle a test file belongs to.
+func bundleFor(path string) (name, dir string, ok bool) {
+	parts := strings.Split(path, "/")
+	switch {
+	case len(parts) > 2 && parts[0] == "Tests":
+		return parts[1], "Tests/" + parts[1], true
+	case len(parts) > 3 && parts[0] == "Sources" && parts[2] == "Tests":
+		return parts[1] + "Tests", strings.Join(parts[:3], "/"), true
+	}
+	return "", "", false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,153 @@
+package testresults
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testmap"
+)
+
+// Module health states.
+const (
+	HealthFailing = "failing"
+	HealthSlow    = "slow"
+	HealthOK      = "ok"
+	HealthNotRun  = "not-run"
+	HealthNoTests = "no-tests"
+)
+
+// ModuleHealth summarises the test results of one production module.
+type ModuleHealth struct {
+	Module   string        `json:"module"`
+	Bundles  []string      `json:"bundles"`
+	State    string        `json:"state"`
+	Tests    int           `json:"tests"`
+	Passed   int           `json:"passed"`
+	Failed   int           `json:"failed"`
+	Skipped  int           `json:"skipped"`
+	Duration time.Duration `json:"duration"`
+	Failures []Case        `json:"failures,omitempty"`
+	Slow     []Case        `json:"slow,omitempty"`
+}
+
+// Summarise attributes cases to modules through the test map and flags
+// failing modules and tests slower than slow. Modules with test bundles
+// but no results are reported as not run; modules with no bundle at all as
+// having no tests.
+func Summarise(cases []Case, tm *testmap.Map, slow time.Duration) []ModuleHealth {
+	byModule := make(map[string]*ModuleHealth)
+	get := func(module string) *ModuleHealth {
+		h, ok := byModule[module]
+		if !ok {
+			h = &ModuleHealth{Module: module}
+			byModule[module] = h
+		}
+		return h
+	}
+
+	for _, b := range tm.Bundles {
+		for _, m := range b.Modules {
+			h := get(m)
+			h.Bundles = appendUnique(h.Bundles, b.Name)
+		}
+	}
+	for _, m := range tm.Untested {
+		get(m)
+	}
+
+	for _, c := range cases {
+		mods := []string{strings.TrimSuffix(c.Bundle, "Tests")}
+		if b, ok := tm.Lookup(c.Bundle); ok && len(b.Modules) > 0 {
+			mods = b.Modules
+		}
+		for _, m := range mods {
+			h := get(m)
+			h.Bundles = appendUnique(h.Bundles, c.Bundle)
+			h.Tests++
+			h.Duration += c.Duration
+			switch c.Status {
+			case StatusPassed:
+				h.Passed++
+			case StatusSkipped:
+				h.Skipped++
+			default:
+				h.Failed++
+				h.Failures = append(h.Failures, c)
+			}
+			if slow > 0 && c.Duration >= slow {
+				h.Slow = append(h.Slow, c)
+			}
+		}
+	}
+
+	result := make([]ModuleHealth, 0, len(byModule))
+	for _, h := range byModule {
+		switch {
+		case h.Failed > 0:
+			h.State = HealthFailing
+		case len(h.Slow) > 0:
+			h.State = HealthSlow
+		case h.Tests > 0:
+			h.State = HealthOK
+		case len(h.Bundles) > 0:
+			h.State = HealthNotRun
+		default:
+			h.State = HealthNoTests
+		}
+		sort.Slice(h.Slow, func(i, j int) bool { return h.Slow[i].Duration > h.Slow[j].Duration })
+		result = append(result, *h)
+	}
+
+	rank := map[string]int{HealthFailing: 0, HealthSlow: 1, HealthNotRun: 2, HealthNoTests: 3, HealthOK: 4}
+	sort.Slice(result, func(i, j int) bool {
+		if rank[result[i].State] != rank[result[j].State] {
+			return rank[result[i].State] < rank[result[j].State]
+		}
+		return result[i].Module < result[j].Module
+	})
+	return result
+}
+
+// WriteMarkdown writes the module test health report.
+func WriteMarkdown(w io.Writer, health []ModuleHealth) error {
+	var b strings.Builder
+	b.WriteString("# Module Test Health\n\n")
+	b.WriteString("| Module | State | Bundles | Tests | Passed | Failed | Skipped | Duration |\n")
+	b.WriteString("|--------|-------|---------|-------|--------|--------|---------|----------|\n")
+	for _, h := range health {
+		fmt.Fprintf(&b, "| %s | %s | %s | %d | %d | %d | %d | %.2fs |\n",
+			h.Module, h.State, strings.Join(h.Bundles, ", "), h.Tests, h.Passed, h.Failed, h.Skipped, h.Duration.Seconds())
+	}
+
+	for _, h := range health {
+		if len(h.Failures) == 0 && len(h.Slow) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", h.Module)
+		for _, c := range h.Failures {
+			fmt.Fprintf(&b, "- FAILED `%s.%s.%s`", c.Bundle, c.Suite, c.Name)
+			if c.Message != "" {
+				fmt.Fprintf(&b, ": %s", c.Message)
+			}
+			b.WriteString("\n")
+		}
+		for _, c := range h.Slow {
+			fmt.Fprintf(&b, "- SLOW `%s.%s.%s` (%.2fs)\n", c.Bundle, c.Suite, c.Name, c.Duration.Seconds())
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,162 @@
+// Package testresults parses XCTest results from .xcresult bundles and
+// xctest console logs.
+package testresults
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// Test statuses.
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// Case is the result of one test case.
+type Case struct {
+	// Bundle is the test bundle (module) name, e.g. "CoreTests".
+	Bundle   string        `json:"bundle"`
+	Suite    string        `json:"suite"`
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Duration time.Duration `json:"duration"`
+	Message  string        `json:"message,omitempty"`
+	// Fingerprint is set by umbratool on the failures it reports.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+var (
+	// Darwin: Test Case '-[CoreTests.CoreTests testVersion]' passed (0.001 seconds).
+	darwinCase = regexp.MustCompile(`^Test Case '-\[([A-Za-z0-9_]+)\.([A-Za-z0-9_]+) ([A-Za-z0-9_]+)\]' (passed|failed|skipped) \((\d+(?:\.\d+)?) seconds\)`)
+	// swift-corelibs-xctest: Test Case 'CoreTests.testVersion' passed (0.001 seconds)
+	linuxCase = regexp.MustCompile(`^Test Case '([A-Za-z0-9_]+)\.([A-Za-z0-9_]+)' (passed|failed|skipped) \((\d+(?:\.\d+)?) seconds\)`)
+	// Test Suite 'CoreTests.xctest' started at ...
+	bundleSuite = regexp.MustCompile(`^Test Suite '([A-Za-z0-9_]+)\.xctest' started`)
+	// /path/File.swift:12: error: -[CoreTests.CoreTests testVersion] : message
+	failureLine = regexp.MustCompile(`^.+:\d+: error: (?:-\[)?([A-Za-z0-9_.]+)[ .]([A-Za-z0-9_]+)\]? : (.*)$`)
+)
+
+// ParseLog parses xctest console output. bundle names the test bundle for
+// log formats that do not include it; it is usually derived from the log's
+// location (bazel-testlogs/<pkg>/<target>/test.log).
+func ParseLog(r io.Reader, bundle string) ([]Case, error) {
+	var cases []Case
+	messages := make(map[string]string)
+
+	scanner := textscan.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if m := bundleSuite.FindStringSubmatch(line); m != nil {
+			bundle = m[1]
+			continue
+		}
+		if m := failureLine.FindStringSubmatch(line); m != nil {
+			key := m[1] + " " + m[2]
+			if messages[key] == "" {
+				messages[key] = m[3]
+			}
+			continue
+		}
+		if m := darwinCase.FindStringSubmatch(line); m != nil {
+			cases = append(cases, Case{Bundle: m[1], Suite: m[2], Name: m[3], Status: m[4], Duration: seconds(m[5])})
+			continue
+		}
+		if m := linuxCase.FindStringSubmatch(line); m != nil {
+			cases = append(cases, Case{Bundle: bundle, Suite: m[1], Name: m[2], Status: m[3], Duration: seconds(m[4])})
+		}
+	}
+
+	for i := range cases {
+		c := &cases[i]
+		if msg, ok := messages[c.Bundle+"."+c.Suite+" "+c.Name]; ok {
+			c.Message = msg
+		} else if msg, ok := messages[c.Suite+" "+c.Name]; ok {
+			c.Message = msg
+		}
+	}
+	return cases, scanner.Err()
+}
+
+// Load reads results from path, which may be an .xcresult bundle, a log
+// file, or a directory (such as bazel-testlogs) searched for test.log files
+// and .xcresult bundles.
+func Load(path string) ([]Case, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasSuffix(path, ".xcresult"):
+		return LoadXCResult(path)
+	case !info.IsDir():
+		return loadLog(path)
+	}
+
+	var cases []Case
+	var bundles []string
+	err = walker.Walk(path, walker.Options{}, func(rel string) error {
+		if dir := filepath.Dir(rel); strings.Contains(dir, ".xcresult") {
+			bundle := dir[:strings.Index(dir, ".xcresult")+len(".xcresult")]
+			if len(bundles) == 0 || bundles[len(bundles)-1] != bundle {
+				bundles = append(bundles, bundle)
+			}
+			return nil
+		}
+		if filepath.Base(rel) != "test.log" {
+			return nil
+		}
+		found, err := loadLog(filepath.Join(path, rel))
+		if err != nil {
+			return err
+		}
+		cases = append(cases, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range bundles {
+		found, err := LoadXCResult(filepath.Join(path, b))
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, found...)
+	}
+	return cases, nil
+}
+
+func loadLog(path string) ([]Case, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Bazel writes bazel-testlogs/<package>/<target>/test.log.
+	bundle := filepath.Base(filepath.Dir(path))
+	if filepath.Base(path) != "test.log" {
+		bundle = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return ParseLog(f, bundle)
+}
+
+func seconds(s string) time.Duration {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(f * float64(time.Second))
+}
@@ -0,0 +1,213 @@
+package testresults
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadXCResult extracts test results from an .xcresult bundle with
+// xcresulttool. The Xcode 16 "test-results tests" report is tried first;
+// older Xcode versions fall back to the legacy object graph.
+func LoadXCResult(bundle string) ([]Case, error) {
+	out, err := exec.Command("xcrun", "xcresulttool", "get", "test-results", "tests", "--path", bundle).Output()
+	if err == nil {
+		return parseTestResults(out)
+	}
+
+	root, err := xcresultObject(bundle, "")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", bundle, err)
+	}
+
+	var cases []Case
+	for _, id := range findTestsRefs(root) {
+		obj, err := xcresultObject(bundle, id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", bundle, err)
+		}
+		cases = append(cases, parseLegacySummaries(obj)...)
+	}
+	return cases, nil
+}
+
+// testNode is a node of the Xcode 16 test-results report.
+type testNode struct {
+	Name              string     `json:"name"`
+	NodeType          string     `json:"nodeType"`
+	Result            string     `json:"result"`
+	Duration          string     `json:"duration"`
+	DurationInSeconds *float64   `json:"durationInSeconds"`
+	Children          []testNode `json:"children"`
+}
+
+func parseTestResults(data []byte) ([]Case, error) {
+	var report struct {
+		TestNodes []testNode `json:"testNodes"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	var cases []Case
+	var walk func(n testNode, bundle, suite string)
+	walk = func(n testNode, bundle, suite string) {
+		switch n.NodeType {
+		case "Unit test bundle", "UI test bundle":
+			bundle = n.Name
+		case "Test Suite":
+			suite = n.Name
+		case "Test Case":
+			c := Case{Bundle: bundle, Suite: suite, Name: strings.TrimSuffix(n.Name, "()"), Status: normaliseStatus(n.Result)}
+			if n.DurationInSeconds != nil {
+				c.Duration = time.Duration(*n.DurationInSeconds * float64(time.Second))
+			} else {
+				c.Duration = parseDuration(n.Duration)
+			}
+			for _, child := range n.Children {
+				if child.NodeType == "Failure Message" && c.Message == "" {
+					c.Message = child.Name
+				}
+			}
+			cases = append(cases, c)
+			return
+		}
+		for _, child := range n.Children {
+			walk(child, bundle, suite)
+		}
+	}
+	for _, n := range report.TestNodes {
+		walk(n, "", "")
+	}
+	return cases, nil
+}
+
+func xcresultObject(bundle, id string) (map[string]any, error) {
+	args := []string{"xcresulttool", "get", "--legacy", "--format", "json", "--path", bundle}
+	if id != "" {
+		args = append(args, "--id", id)
+	}
+	out, err := exec.Command("xcrun", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("xcresulttool: %w", err)
+	}
+
+	var obj map[string]any
+	if err := json.Unmarshal(out, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// findTestsRefs returns the ids of every actionResult.testsRef in the
+// invocation record.
+func findTestsRefs(v any) []string {
+	var ids []string
+	switch t := v.(type) {
+	case map[string]any:
+		if ref, ok := t["testsRef"].(map[string]any); ok {
+			if id := value(ref["id"]); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		for _, child := range t {
+			ids = append(ids, findTestsRefs(child)...)
+		}
+	case []any:
+		for _, child := range t {
+			ids = append(ids, findTestsRefs(child)...)
+		}
+	}
+	return dedupe(ids)
+}
+
+// parseLegacySummaries walks an ActionTestPlanRunSummaries object and
+// returns every ActionTestMetadata leaf, attributed to its testable
+// summary's target.
+func parseLegacySummaries(root map[string]any) []Case {
+	var cases []Case
+	var walk func(v any, bundle, suite string)
+	walk = func(v any, bundle, suite string) {
+		switch t := v.(type) {
+		case map[string]any:
+			switch typeName(t) {
+			case "ActionTestableSummary":
+				bundle = value(t["targetName"])
+			case "ActionTestSummaryGroup":
+				if name := value(t["name"]); !strings.HasSuffix(name, ".xctest") && name != "All tests" {
+					suite = name
+				}
+			case "ActionTestMetadata":
+				duration, _ := strconv.ParseFloat(value(t["duration"]), 64)
+				cases = append(cases, Case{
+					Bundle:   bundle,
+					Suite:    suite,
+					Name:     strings.TrimSuffix(value(t["name"]), "()"),
+					Status:   normaliseStatus(value(t["testStatus"])),
+					Duration: time.Duration(duration * float64(time.Second)),
+				})
+				return
+			}
+			for _, child := range t {
+				walk(child, bundle, suite)
+			}
+		case []any:
+			for _, child := range t {
+				walk(child, bundle, suite)
+			}
+		}
+	}
+	walk(root, "", "")
+	return cases
+}
+
+// value unwraps the {"_value": ...} envelope used by legacy xcresult JSON.
+func value(v any) string {
+	if m, ok := v.(map[string]any); ok {
+		if s, ok := m["_value"].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+func typeName(m map[string]any) string {
+	if t, ok := m["_type"].(map[string]any); ok {
+		if name, ok := t["_name"].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+func normaliseStatus(s string) string {
+	switch strings.ToLower(s) {
+	case "passed", "success", "expected failure":
+		return StatusPassed
+	case "skipped":
+		return StatusSkipped
+	default:
+		return StatusFailed
+	}
+}
+
+// parseDuration parses xcresulttool durations such as "0.012s" or "0,012s".
+func parseDuration(s string) time.Duration {
+	s = strings.ReplaceAll(strings.TrimSuffix(strings.TrimSpace(s), "s"), ",", ".")
+	return seconds(s)
+}
+
+func dedupe(ids []string) []string {
+	seen := make(map[string]bool)
+	out := ids[:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package textdiff
+
+// Row kinds of a side-by-side diff: a line both sides have, a line each
+// side has in place of the other's, and a line only one side has.
+const (
+	RowSame    = "same"
+	RowChanged = "changed"
+	RowLeft    = "left"
+	RowRight   = "right"
+)
+
+// Row is one line of a side-by-side diff. Line numbers count from 1 and
+// are 0 on the side a row has no line.
+type Row struct {
+	Kind      string `json:"kind"`
+	LeftLine  int    `json:"leftLine,omitempty"`
+	Left      string `json:"left,omitempty"`
+	RightLine int    `json:"rightLine,omitempty"`
+	Right     string `json:"right,omitempty"`
+}
+
+// SideBySide returns the rows of a two-column diff of left and right.
+// Within a run of changes, removed and added lines are paired off as
+// changed rows; the rest stand on one side.
+func SideBySide(left, right string) []Row {
+	ops := diff(splitLines(left), splitLines(right))
+	var rows []Row
+	l, r := 0, 0
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			l++
+			r++
+			rows = append(rows, Row{Kind: RowSame, LeftLine: l, Left: ops[i].line, RightLine: r, Right: ops[i].line})
+			i++
+			continue
+		}
+		var removed, added []string
+		for ; i < len(ops) && ops[i].kind != ' '; i++ {
+			if ops[i].kind == '-' {
+				removed = append(removed, ops[i].line)
+			} else {
+				added = append(added, ops[i].line)
+			}
+		}
+		for k := 0; k < len(removed) || k < len(added); k++ {
+			row := Row{Kind: RowChanged}
+			if k < len(removed) {
+				l++
+				row.LeftLine, row.Left = l, removed[k]
+			} else {
+				row.Kind = RowRight
+			}
+			if k < len(added) {
+				r++
+				row.RightLine, row.Right = r, added[k]
+			} else {
+				row.Kind = RowLeft
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
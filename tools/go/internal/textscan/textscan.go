@@ -0,0 +1,86 @@
+// Package textscan reads files line by line for the analyzers. Its scanners
+// accept lines far longer than bufio.Scanner's 64 KB default, so generated
+// code and minified resources are analysed instead of cut short, and a file
+// that still cannot be read to the end is reported rather than dropped.
+package textscan
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// MaxLine is the longest line, in bytes, the scanners accept.
+var MaxLine = 16 * 1024 * 1024
+
+// Warnings receives the warnings about files that were only partly read.
+var Warnings io.Writer = os.Stderr
+
+// NewScanner returns a line scanner over r that accepts lines of up to
+// MaxLine bytes.
+func NewScanner(r io.Reader) *bufio.Scanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), MaxLine)
+	return s
+}
+
+// Partial reports whether err, returned by a scanner from NewScanner, means
+// the reader holds a line longer than MaxLine and was read only up to the
+// line before it.
+func Partial(err error) bool {
+	return errors.Is(err, bufio.ErrTooLong)
+}
+
+// Check handles the error of a scanner that stopped after line lines of
+// rel. A line longer than MaxLine is warned about and the results gathered
+// so far are kept, so Check returns nil; any other error is returned.
+func Check(rel string, line int, err error) error {
+	if !Partial(err) {
+		return err
+	}
+	Warn(rel, line)
+	return nil
+}
+
+// Warn reports that rel was analysed only up to line because the next line
+// is longer than MaxLine.
+func Warn(rel string, line int) {
+	fmt.Fprintf(Warnings, "warning: %s: line %d is longer than %d bytes; analysed the first %d lines only\n", rel, line+1, MaxLine, line)
+}
+
+// Count counts the lines in content as CountLines does.
+func Count(content string) int {
+	lines := strings.Count(content, "\n")
+	if content != "" && content[len(content)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
+
+// CountLines counts the lines in r by their newlines, however long they
+// are. A final line without a newline is counted.
+func CountLines(r io.Reader) (int, error) {
+	buf := make([]byte, 64*1024)
+	lines := 0
+	last := byte('\n')
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			lines += bytes.Count(buf[:n], []byte{'\n'})
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			if last != '\n' {
+				lines++
+			}
+			return lines, nil
+		}
+		if err != nil {
+			return lines, err
+		}
+	}
+}
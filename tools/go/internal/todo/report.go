@@ -0,0 +1,102 @@
+package todo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ModuleSummary aggregates the debt found in one module.
+type ModuleSummary struct {
+	Module     string         `json:"module"`
+	Critical   bool           `json:"critical"`
+	Count      int            `json:"count"`
+	ByTag      map[string]int `json:"byTag"`
+	OldestDays int            `json:"oldestDays"`
+	TotalScore float64        `json:"totalScore"`
+}
+
+// Summarise groups items by module, ordered by descending total score.
+func Summarise(items []Item) []ModuleSummary {
+	byModule := make(map[string]*ModuleSummary)
+	for _, item := range items {
+		s, ok := byModule[item.Module]
+		if !ok {
+			s = &ModuleSummary{Module: item.Module, Critical: item.Critical, ByTag: make(map[string]int)}
+			byModule[item.Module] = s
+		}
+		s.Count++
+		s.ByTag[item.Tag]++
+		s.TotalScore += item.Score
+		if item.AgeDays > s.OldestDays {
+			s.OldestDays = item.AgeDays
+		}
+	}
+
+	summaries := make([]ModuleSummary, 0, len(byModule))
+	for _, s := range byModule {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].TotalScore != summaries[j].TotalScore {
+			return summaries[i].TotalScore > summaries[j].TotalScore
+		}
+		return summaries[i].Module < summaries[j].Module
+	})
+	return summaries
+}
+
+// WriteMarkdown writes a prioritised debt report, listing at most limit
+// individual items (all of them when limit is zero or negative).
+func WriteMarkdown(w io.Writer, items []Item, limit int) error {
+	var b strings.Builder
+
+	b.WriteString("# Comment Debt Report\n\n")
+	fmt.Fprintf(&b, "Total items: %d\n\n", len(items))
+
+	b.WriteString("## Modules\n\n")
+	b.WriteString("| Module | Critical | Items | TODO | FIXME | HACK | Oldest (days) | Score |\n")
+	b.WriteString("|--------|----------|-------|------|-------|------|---------------|-------|\n")
+	for _, s := range Summarise(items) {
+		fmt.Fprintf(&b, "| %s | %s | %d | %d | %d | %d | %d | %.1f |\n",
+			s.Module, yesNo(s.Critical), s.Count, s.ByTag["TODO"], s.ByTag["FIXME"], s.ByTag["HACK"], s.OldestDays, s.TotalScore)
+	}
+
+	b.WriteString("\n## Prioritised Items\n\n")
+	b.WriteString("| Score | Tag | Location | Author | Age (days) | Text |\n")
+	b.WriteString("|-------|-----|----------|--------|------------|------|\n")
+	for i, item := range items {
+		if limit > 0 && i >= limit {
+			fmt.Fprintf(&b, "\n_%d further items omitted._\n", len(items)-limit)
+			break
+		}
+		fmt.Fprintf(&b, "| %.1f | %s | `%s:%d` | %s | %d | %s |\n",
+			item.Score, item.Tag, item.File, item.Line, item.Author, item.AgeDays, escapeCell(item.Text))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the items and module summaries as indented JSON.
+func WriteJSON(w io.Writer, items []Item) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Modules []ModuleSummary `json:"modules"`
+		Items   []Item          `json:"items"`
+	}{Summarise(items), items})
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
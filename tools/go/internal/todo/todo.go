@@ -0,0 +1,227 @@
+// Package todo collects TODO, FIXME and HACK comments from Swift and Go
+// sources and attributes them to modules and, via git blame, to authors.
+package todo
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/pool"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/workspace"
+)
+
+// markerPattern matches a debt marker that starts a comment or a comment
+// continuation line. An optional "(owner)" suffix on the tag is ignored.
+var markerPattern = regexp.MustCompile(`(?://+|/\*+|^\s*\*)\s*(TODO|FIXME|HACK)\b(?:\([^)]*\))?:?\s*(.*)$`)
+
+// tagWeights rank how urgently each marker kind should be paid down.
+var tagWeights = map[string]float64{
+	"FIXME": 3,
+	"HACK":  2,
+	"TODO":  1,
+}
+
+// Item is a single debt comment found in the tree.
+type Item struct {
+	Tag         string    `json:"tag"`
+	Text        string    `json:"text"`
+	File        string    `json:"file"`
+	Line        int       `json:"line"`
+	Module      string    `json:"module"`
+	Author      string    `json:"author,omitempty"`
+	AuthorTime  time.Time `json:"authorTime,omitempty"`
+	AgeDays     int       `json:"ageDays"`
+	Critical    bool      `json:"critical"`
+	Score       float64   `json:"score"`
+	Fingerprint string    `json:"fingerprint,omitempty"`
+}
+
+// Options controls a scan.
+type Options struct {
+	// Blame enables git blame attribution. Without it items have no author
+	// and an age of zero.
+	Blame bool
+	// CriticalModules are weighted more heavily and are the only modules
+	// considered by stale-item enforcement.
+	CriticalModules []string
+	// Now is the reference time for ages; zero means time.Now().
+	Now time.Time
+}
+
+// Scan walks root for .swift and .go files and returns every debt comment,
+// ordered by descending priority score.
+func Scan(root string, opts Options) ([]Item, error) {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	critical := make(map[string]bool, len(opts.CriticalModules))
+	for _, m := range opts.CriticalModules {
+		critical[m] = true
+	}
+
+	var files []string
+	err := walker.Walk(root, walker.Options{Extensions: []string{".swift", ".go"}}, func(rel string) error {
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// git blame dominates the run time, so files are processed in parallel.
+	perFile, err := pool.Map(files, func(rel string) ([]Item, error) {
+		found, err := scanFile(root, rel)
+		if err != nil || len(found) == 0 {
+			return nil, err
+		}
+
+		if opts.Blame {
+			if blame, err := blameFile(root, rel); err == nil {
+				for i := range found {
+					if b, ok := blame[found[i].Line]; ok {
+						found[i].Author = b.author
+						found[i].AuthorTime = b.time
+					}
+				}
+			}
+		}
+
+		for i := range found {
+			found[i].Critical = critical[found[i].Module]
+			if !found[i].AuthorTime.IsZero() {
+				found[i].AgeDays = int(now.Sub(found[i].AuthorTime).Hours() / 24)
+			}
+			found[i].Score = score(found[i])
+		}
+		return found, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	for _, found := range perFile {
+		items = append(items, found...)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		if items[i].File != items[j].File {
+			return items[i].File < items[j].File
+		}
+		return items[i].Line < items[j].Line
+	})
+	return items, nil
+}
+
+// Stale returns the items in critical modules older than maxAgeDays.
+func Stale(items []Item, maxAgeDays int) []Item {
+	var stale []Item
+	for _, item := range items {
+		if item.Critical && item.AgeDays > maxAgeDays {
+			stale = append(stale, item)
+		}
+	}
+	return stale
+}
+
+func score(item Item) float64 {
+	s := tagWeights[item.Tag] * (1 + float64(item.AgeDays)/30)
+	if item.Critical {
+		s *= 2
+	}
+	return s
+}
+
+func scanFile(root, rel string) ([]Item, error) {
+	f, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	module := workspace.ModuleForPath(rel)
+
+	var items []Item
+	scanner := textscan.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		m := markerPattern.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		items = append(items, Item{
+			Tag:    m[1],
+			Text:   strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(m[2]), "*/")),
+			File:   rel,
+			Line:   lineNo,
+			Module: module,
+		})
+	}
+	return items, textscan.Check(rel, lineNo, scanner.Err())
+}
+
+type blameLine struct {
+	author string
+	time   time.Time
+}
+
+// blameFile runs git blame in porcelain mode and indexes the result by final
+// line number. Lines that are not yet committed keep a zero time.
+func blameFile(root, rel string) (map[int]blameLine, error) {
+	out, err := exec.Command("git", "-C", root, "blame", "--line-porcelain", "--", rel).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]blameLine)
+	var current blameLine
+	line := 0
+	scanner := textscan.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "\t"):
+			result[line] = current
+			current = blameLine{}
+		case strings.HasPrefix(text, "author "):
+			current.author = strings.TrimPrefix(text, "author ")
+		case strings.HasPrefix(text, "author-time "):
+			if secs, err := strconv.ParseInt(strings.TrimPrefix(text, "author-time "), 10, 64); err == nil {
+				current.time = time.Unix(secs, 0)
+			}
+		default:
+			// Header lines are "<sha> <orig-line> <final-line> [<count>]".
+			fields := strings.Fields(text)
+			if len(fields) >= 3 && len(fields[0]) == 40 {
+				if n, err := strconv.Atoi(fields[2]); err == nil {
+					line = n
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for n, b := range result {
+		if b.author == "Not Committed Yet" {
+			result[n] = blameLine{author: b.author}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,383 @@
+// Package umbrella maintains aggregation targets, whose deps are every
+// module matching a predicate, such as all the libraries below a
+// directory or all the Foundation-free ones. It rewrites each umbrella's
+// deps to exactly the matching modules, sorted, so a new module cannot be
+// left out by hand.
+package umbrella
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/buildfile"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/configschema"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/modulenames"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// Umbrella is an aggregation target and the predicate selecting its
+// members. A library rule is a member when it satisfies every condition
+// set: its package matches one of Paths, it carries one of Tags, and, with
+// FoundationFree, a Foundation-free macro declares it.
+type Umbrella struct {
+	Target string `yaml:"target"`
+	// Paths are walker.Match globs on the member's package, such as
+	// "Sources/Core*" or "Sources/**".
+	Paths          []string `yaml:"paths"`
+	Tags           []string `yaml:"tags"`
+	FoundationFree bool     `yaml:"foundationFree"`
+	// Exclude lists labels, or globs on packages, that are never members.
+	Exclude []string `yaml:"exclude"`
+	// Extra lists labels kept in the deps whatever the predicate, such as
+	// external repositories.
+	Extra []string `yaml:"extra"`
+	// Attr is the list holding the members (default "deps").
+	Attr string `yaml:"attr"`
+}
+
+// Config is the umbrellas section of umbratool.yaml:
+//
+//	umbrellas:
+//	  - target: //Sources:AllCore
+//	    paths: ["Sources/Core*"]
+//	    exclude: [Sources/CoreLegacy]
+//	  - target: //Sources:FoundationFree
+//	    paths: ["Sources/**"]
+//	    foundationFree: true
+type Config struct {
+	Umbrellas []Umbrella `yaml:"umbrellas"`
+}
+
+// LoadConfig reads and validates the umbrellas section of a config file.
+// A file without one has no umbrellas.
+func LoadConfig(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := configschema.Validate(configschema.KindUmbratool, file, data); err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	seen := make(map[string]bool)
+	for i := range c.Umbrellas {
+		u := &c.Umbrellas[i]
+		if err := u.validate(); err != nil {
+			return nil, fmt.Errorf("%s: umbrella %d: %w", file, i+1, err)
+		}
+		if seen[u.Target] {
+			return nil, fmt.Errorf("%s: umbrella %s listed twice", file, u.Target)
+		}
+		seen[u.Target] = true
+	}
+	return &c, nil
+}
+
+func (u *Umbrella) validate() error {
+	if !strings.HasPrefix(u.Target, "//") {
+		return fmt.Errorf("target %q is not a //package:name label", u.Target)
+	}
+	u.Target = buildfile.NormaliseLabel("", u.Target)
+	if len(u.Paths) == 0 && len(u.Tags) == 0 && !u.FoundationFree {
+		return errors.New("set at least one of paths, tags and foundationFree")
+	}
+	for _, p := range append(append([]string(nil), u.Paths...), u.Exclude...) {
+		if _, err := path.Match(strings.ReplaceAll(p, "**", "*"), ""); err != nil {
+			return fmt.Errorf("bad pattern %q: %w", p, err)
+		}
+	}
+	if u.Attr == "" {
+		u.Attr = "deps"
+	}
+	return nil
+}
+
+// Result is the state of one umbrella's deps against its predicate.
+type Result struct {
+	Target string `json:"target"`
+	// File is the BUILD file declaring the umbrella.
+	File string `json:"file"`
+	// Members are the labels the predicate selects, sorted.
+	Members []string `json:"members"`
+	// Missing are members the deps lack, and Unexpected deps that are
+	// neither members nor extras.
+	Missing    []string `json:"missing,omitempty"`
+	Unexpected []string `json:"unexpected,omitempty"`
+	// Unsorted reports deps out of order.
+	Unsorted bool `json:"unsorted,omitempty"`
+}
+
+// Stale reports whether the umbrella's deps differ from what Update
+// writes.
+func (r Result) Stale() bool {
+	return len(r.Missing) > 0 || len(r.Unexpected) > 0 || r.Unsorted
+}
+
+// Plan holds the umbrellas brought up to date in memory.
+type Plan struct {
+	Results []Result
+	files   map[string]*buildfile.File
+}
+
+// member is a candidate rule with the attributes the predicates read.
+type member struct {
+	modulenames.Rule
+	tags     []string
+	testOnly bool
+}
+
+// Update computes the members of every umbrella among rules and rewrites
+// the umbrellas' deps to them in memory; Plan.Save writes the files. A
+// member must be a library, and a testonly one only joins a testonly
+// umbrella. Umbrellas never contain each other. only limits the update to
+// the umbrellas it labels; empty means all of them.
+func Update(root string, umbrellas []Umbrella, rules []modulenames.Rule, only []string) (*Plan, error) {
+	p := &Plan{files: make(map[string]*buildfile.File)}
+	targets := make(map[string]bool, len(umbrellas))
+	for _, u := range umbrellas {
+		targets[u.Target] = true
+	}
+	selected := make(map[string]bool, len(only))
+	for _, label := range only {
+		label = buildfile.NormaliseLabel("", label)
+		if !targets[label] {
+			return nil, fmt.Errorf("%s is not an umbrella", label)
+		}
+		selected[label] = true
+	}
+
+	var candidates []member
+	for _, r := range rules {
+		if targets[r.Label] || strings.HasSuffix(r.Kind, "test") || strings.HasSuffix(r.Kind, "binary") {
+			continue
+		}
+		rule, err := p.rule(root, r.File, r.Label)
+		if err != nil {
+			return nil, err
+		}
+		m := member{Rule: r, testOnly: strings.HasSuffix(r.Kind, "test_library")}
+		if rule != nil {
+			m.tags = rule.AttrStrings("tags")
+			m.testOnly = m.testOnly || p.files[r.File].TestOnly(rule.Name())
+		}
+		candidates = append(candidates, m)
+	}
+
+	for _, u := range umbrellas {
+		if len(selected) > 0 && !selected[u.Target] {
+			continue
+		}
+		pkg, name, _ := strings.Cut(strings.TrimPrefix(u.Target, "//"), ":")
+		file, err := buildFile(root, pkg)
+		if err != nil {
+			return nil, fmt.Errorf("umbrella %s: %w", u.Target, err)
+		}
+		rule, err := p.rule(root, file, u.Target)
+		if err != nil {
+			return nil, err
+		}
+		if rule == nil {
+			return nil, fmt.Errorf("umbrella %s: %s has no rule named %q", u.Target, file, name)
+		}
+
+		res := Result{Target: u.Target, File: file, Members: []string{}}
+		testOnly := p.files[file].TestOnly(name)
+		for _, m := range candidates {
+			if u.matches(m) && (testOnly || !m.testOnly) {
+				res.Members = append(res.Members, m.Label)
+			}
+		}
+		sort.Strings(res.Members)
+		if err := p.rewrite(&res, u, name); err != nil {
+			return nil, err
+		}
+		p.Results = append(p.Results, res)
+	}
+	return p, nil
+}
+
+func (u Umbrella) matches(m member) bool {
+	pkg := path.Dir(m.File)
+	for _, e := range u.Exclude {
+		if buildfile.NormaliseLabel(pkg, e) == m.Label || walker.Match(e, pkg) {
+			return false
+		}
+	}
+	if len(u.Paths) > 0 && !anyMatch(u.Paths, pkg) {
+		return false
+	}
+	if len(u.Tags) > 0 && !overlaps(u.Tags, m.tags) {
+		return false
+	}
+	return !u.FoundationFree || modulenames.IsFoundationFree(m.Kind)
+}
+
+// rewrite brings the umbrella's deps in line with res.Members, keeping
+// the comments of the entries that stay, and records the differences.
+func (p *Plan) rewrite(res *Result, u Umbrella, name string) error {
+	f := p.files[res.File]
+	pkg := buildfile.PackageOf(res.File)
+	want := make(map[string]bool)
+	for _, label := range res.Members {
+		want[label] = true
+	}
+	for _, label := range u.Extra {
+		want[buildfile.NormaliseLabel(pkg, label)] = true
+	}
+
+	have := make(map[string]bool)
+	var current []string
+	if list, ok := f.Rule(name).Attr(u.Attr).(*build.ListExpr); ok {
+		for _, e := range list.List {
+			if s, ok := e.(*build.StringExpr); ok {
+				current = append(current, s.Value)
+			}
+		}
+		res.Unsorted = !sortedList(list)
+	} else if f.Rule(name).Attr(u.Attr) != nil {
+		return fmt.Errorf("umbrella %s: %s is not a plain list", u.Target, u.Attr)
+	}
+	for _, value := range current {
+		label := buildfile.NormaliseLabel(pkg, value)
+		have[label] = true
+		if !want[label] {
+			res.Unexpected = append(res.Unexpected, label)
+			if _, err := f.RemoveDep(name, u.Attr, label); err != nil {
+				return err
+			}
+		}
+	}
+	for _, label := range res.Members {
+		if !have[label] {
+			res.Missing = append(res.Missing, label)
+			if _, err := f.AddDep(name, u.Attr, shortLabel(label)); err != nil {
+				return err
+			}
+		}
+	}
+
+	list, ok := f.Rule(name).Attr(u.Attr).(*build.ListExpr)
+	if !ok {
+		return nil
+	}
+	sort.SliceStable(list.List, func(i, j int) bool { return less(list.List[i], list.List[j]) })
+	list.ForceMultiLine = len(list.List) > 1
+	return nil
+}
+
+// rule returns the rule label names in the BUILD file rel, or nil, loading
+// each file once.
+func (p *Plan) rule(root, rel, label string) (*build.Rule, error) {
+	f, ok := p.files[rel]
+	if !ok {
+		var err error
+		if f, err = buildfile.Load(filepath.Join(root, filepath.FromSlash(rel)), buildfile.PackageOf(rel)); err != nil {
+			return nil, err
+		}
+		p.files[rel] = f
+	}
+	_, name, _ := strings.Cut(label, ":")
+	return f.Rule(name), nil
+}
+
+// Save writes the BUILD files of the stale umbrellas, stopping between
+// files once ctx is done. It returns the files it saved, those before the
+// error when there is one.
+func (p *Plan) Save(ctx context.Context) ([]string, error) {
+	var saved []string
+	for _, r := range p.Results {
+		if !r.Stale() {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return saved, fmt.Errorf("%w before updating %s", err, r.Target)
+		}
+		if err := p.files[r.File].Save(); err != nil {
+			return saved, err
+		}
+		saved = append(saved, r.File)
+	}
+	return saved, nil
+}
+
+// buildFile returns the BUILD file of package pkg, relative to root.
+func buildFile(root, pkg string) (string, error) {
+	for _, name := range []string{"BUILD.bazel", "BUILD"} {
+		rel := path.Join(pkg, name)
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel))); err == nil {
+			return rel, nil
+		}
+	}
+	return "", fmt.Errorf("package %s has no BUILD file", pkg)
+}
+
+// shortLabel drops the name of a label naming its package's default
+// target: "//Sources/Core:Core" becomes "//Sources/Core".
+func shortLabel(label string) string {
+	pkg, name, ok := strings.Cut(label, ":")
+	if ok && name == path.Base(pkg) {
+		return pkg
+	}
+	return label
+}
+
+func sortedList(list *build.ListExpr) bool {
+	return sort.SliceIsSorted(list.List, func(i, j int) bool { return less(list.List[i], list.List[j]) })
+}
+
+// less orders list entries as buildifier orders deps: local labels, then
+// workspace ones, then external ones, each alphabetically.
+func less(a, b build.Expr) bool {
+	sa, okA := a.(*build.StringExpr)
+	sb, okB := b.(*build.StringExpr)
+	if !okA || !okB {
+		return false
+	}
+	if ra, rb := rank(sa.Value), rank(sb.Value); ra != rb {
+		return ra < rb
+	}
+	return sa.Value < sb.Value
+}
+
+func rank(label string) int {
+	switch {
+	case strings.HasPrefix(label, ":"):
+		return 0
+	case strings.HasPrefix(label, "//"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+func anyMatch(patterns []string, pkg string) bool {
+	for _, p := range patterns {
+		if walker.Match(p, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+func overlaps(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package unused
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// SourcePath converts a source file label such as "//Sources/Foo:Bar/x.swift"
+// to its workspace-relative path.
+func SourcePath(label string) string {
+	if strings.HasPrefix(label, "@") {
+		return label
+	}
+	pkg := Package(label)
+	name := label[strings.LastIndex(label, ":")+1:]
+	return path.Join(pkg, name)
+}
+
+// WriteMarkdown writes the findings as a table followed by the source files
+// that can be deleted with each target.
+func WriteMarkdown(w io.Writer, findings []Finding) error {
+	var b strings.Builder
+	b.WriteString("## Unused Bazel Targets\n\n")
+	if len(findings) == 0 {
+		b.WriteString("No unused library targets found.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	fmt.Fprintf(&b, "**%d** library targets have no reverse dependencies.\n\n", len(findings))
+	b.WriteString("| Target | Kind | Sources | Only used by |\n")
+	b.WriteString("|--------|------|---------|--------------|\n")
+	for _, f := range findings {
+		fmt.Fprintf(&b, "| `%s` | %s | %d | %s |\n", f.Label, f.Kind, len(f.Srcs), strings.Join(f.Via, ", "))
+	}
+
+	for _, f := range findings {
+		if len(f.Srcs) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", f.Label)
+		for _, src := range f.Srcs {
+			fmt.Fprintf(&b, "- %s\n", SourcePath(src))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
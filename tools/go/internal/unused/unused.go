@@ -0,0 +1,245 @@
+// Package unused finds Bazel library targets that nothing depends on,
+// typically leftovers of module migrations.
+package unused
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/bazel"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/textscan"
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/walker"
+)
+
+// DefaultLibraryKinds are the rule kinds checked for reverse dependencies.
+var DefaultLibraryKinds = []string{"swift_library"}
+
+// DefaultRootKinds are top-level rule kinds that are expected to have no
+// reverse dependencies. Their own dependencies still count as uses.
+var DefaultRootKinds = []string{"*_test", "*_binary", "*_application", "*_extension", "test_suite", "xcodeproj"}
+
+// Target is a rule from the query output.
+type Target struct {
+	Label string
+	Kind  string
+	// Srcs are the labels of the target's srcs attribute.
+	Srcs []string
+	// Deps are all of the rule's direct inputs.
+	Deps []string
+}
+
+// Finding is a library target with no reverse dependencies.
+type Finding struct {
+	Label   string   `json:"label"`
+	Kind    string   `json:"kind"`
+	Package string   `json:"package"`
+	Srcs    []string `json:"srcs"`
+	// Via lists, for transitive findings, the unused targets that were the
+	// only ones depending on this one.
+	Via         []string `json:"via,omitempty"`
+	Fingerprint string   `json:"fingerprint,omitempty"`
+}
+
+// Options controls which targets are reported.
+type Options struct {
+	LibraryKinds []string
+	RootKinds    []string
+	// Allow holds label patterns of entry points that are never reported:
+	// exact labels, globs such as "//Sources/Foo:*", or "//Sources/..."
+	// package prefixes.
+	Allow []string
+	// Transitive also reports libraries whose only reverse dependencies
+	// are themselves unused.
+	Transitive bool
+}
+
+// Query loads every rule matching scope (usually "//...") with a single
+// bazel query.
+func Query(ctx context.Context, runner *bazel.Runner, scope string) ([]Target, error) {
+	out, err := runner.Run(ctx, "query", scope, "--output=xml", "--noshow_progress")
+	if err != nil {
+		return nil, err
+	}
+	return ParseXML(out)
+}
+
+type xmlQuery struct {
+	Rules []struct {
+		Class string `xml:"class,attr"`
+		Name  string `xml:"name,attr"`
+		Lists []struct {
+			Name   string `xml:"name,attr"`
+			Labels []struct {
+				Value string `xml:"value,attr"`
+			} `xml:"label"`
+		} `xml:"list"`
+		Inputs []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"rule-input"`
+	} `xml:"rule"`
+}
+
+// ParseXML parses "bazel query --output=xml" output.
+func ParseXML(data []byte) ([]Target, error) {
+	// Bazel declares XML 1.1, which encoding/xml refuses; the documents are
+	// otherwise plain 1.0.
+	data = bytes.Replace(data, []byte(`<?xml version="1.1"`), []byte(`<?xml version="1.0"`), 1)
+
+	var q xmlQuery
+	if err := xml.Unmarshal(data, &q); err != nil {
+		return nil, err
+	}
+
+	targets := make([]Target, 0, len(q.Rules))
+	for _, r := range q.Rules {
+		t := Target{Label: r.Name, Kind: r.Class}
+		for _, l := range r.Lists {
+			if l.Name != "srcs" {
+				continue
+			}
+			for _, label := range l.Labels {
+				t.Srcs = append(t.Srcs, label.Value)
+			}
+		}
+		for _, in := range r.Inputs {
+			t.Deps = append(t.Deps, in.Name)
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// Find returns the library targets without reverse dependencies, sorted by
+// label.
+func Find(targets []Target, opts Options) []Finding {
+	if len(opts.LibraryKinds) == 0 {
+		opts.LibraryKinds = DefaultLibraryKinds
+	}
+	if len(opts.RootKinds) == 0 {
+		opts.RootKinds = DefaultRootKinds
+	}
+
+	rdeps := make(map[string]map[string]bool)
+	for _, t := range targets {
+		for _, dep := range t.Deps {
+			if dep == t.Label {
+				continue
+			}
+			if rdeps[dep] == nil {
+				rdeps[dep] = make(map[string]bool)
+			}
+			rdeps[dep][t.Label] = true
+		}
+	}
+
+	candidates := make(map[string]Target)
+	for _, t := range targets {
+		if matchKind(opts.LibraryKinds, t.Kind) && !matchKind(opts.RootKinds, t.Kind) && !Allowed(opts.Allow, t.Label) {
+			candidates[t.Label] = t
+		}
+	}
+
+	dead := make(map[string][]string)
+	for label := range candidates {
+		if len(rdeps[label]) == 0 {
+			dead[label] = nil
+		}
+	}
+
+	// Removing one dead target can leave its dependencies without users;
+	// repeat until nothing changes.
+	for changed := opts.Transitive; changed; {
+		changed = false
+		for label := range candidates {
+			if _, ok := dead[label]; ok || len(rdeps[label]) == 0 {
+				continue
+			}
+			var via []string
+			for user := range rdeps[label] {
+				if _, ok := dead[user]; !ok {
+					via = nil
+					break
+				}
+				via = append(via, user)
+			}
+			if via != nil {
+				sort.Strings(via)
+				dead[label] = via
+				changed = true
+			}
+		}
+	}
+
+	findings := make([]Finding, 0, len(dead))
+	for label, via := range dead {
+		t := candidates[label]
+		findings = append(findings, Finding{Label: label, Kind: t.Kind, Package: Package(label), Srcs: t.Srcs, Via: via})
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Label < findings[j].Label })
+	return findings
+}
+
+// Package returns the package part of a label: "Sources/Foo" for
+// "//Sources/Foo:Foo".
+func Package(label string) string {
+	pkg := strings.TrimPrefix(label, "//")
+	if idx := strings.Index(pkg, ":"); idx >= 0 {
+		pkg = pkg[:idx]
+	}
+	return pkg
+}
+
+// Allowed reports whether label matches one of the allowlist patterns.
+func Allowed(patterns []string, label string) bool {
+	for _, p := range patterns {
+		switch {
+		case p == label:
+			return true
+		case strings.HasSuffix(p, "/..."):
+			prefix := strings.TrimPrefix(strings.TrimSuffix(p, "/..."), "//")
+			if pkg := Package(label); pkg == prefix || strings.HasPrefix(pkg, prefix+"/") {
+				return true
+			}
+		case walker.Match(p, label):
+			return true
+		}
+	}
+	return false
+}
+
+// LoadAllowlist reads label patterns from a file, one per line. Blank lines
+// and "#" comments are ignored.
+func LoadAllowlist(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := textscan.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns, scanner.Err()
+}
+
+func matchKind(patterns []string, kind string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, kind); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+// Package walker provides the shared directory walk used by the analysers.
+package walker
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSkipDirs are directory names never worth descending into.
+var DefaultSkipDirs = []string{
+	".git",
+	".build",
+	".swiftpm",
+	"node_modules",
+	"DerivedData",
+	"xpc_fix_backup_20250320",
+}
+
+// FollowSymlinks makes Walk descend into symlinked directories and report
+// symlinked files. By default both are skipped, so links such as the
+// bazel-* convenience symlinks never pull the output tree into a scan.
+var FollowSymlinks = false
+
+// Warnings receives the warnings Walk prints, such as names that differ
+// only in case.
+var Warnings io.Writer = os.Stderr
+
+// Options controls which files Walk reports.
+type Options struct {
+	// Extensions restricts the walk to files with these extensions
+	// (including the leading dot). An empty list matches every file.
+	Extensions []string
+	// SkipDirs replaces DefaultSkipDirs when non-nil.
+	SkipDirs []string
+}
+
+// Walk calls fn for every regular file below root that matches opts. Paths
+// passed to fn are relative to root and use forward slashes. root is
+// resolved to its canonical path first, so a symlinked root is walked.
+// With FollowSymlinks, a linked directory is walked once under the link's
+// path unless its target lies inside root, which the walk reaches anyway.
+//
+// Walk warns about entries of a directory whose names differ only in case:
+// on a case-insensitive file system such as APFS only one of them can
+// exist, and module names derived from them collide.
+func Walk(root string, opts Options, fn func(rel string) error) error {
+	canonical, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	if canonical, err = filepath.Abs(canonical); err != nil {
+		return err
+	}
+
+	w := &walk{
+		opts:   opts,
+		skip:   opts.SkipDirs,
+		root:   canonical,
+		fn:     fn,
+		walked: make(map[string]bool),
+		folded: make(map[string]string),
+	}
+	if w.skip == nil {
+		w.skip = DefaultSkipDirs
+	}
+	return w.dir(canonical, "")
+}
+
+type walk struct {
+	opts Options
+	skip []string
+	root string
+	fn   func(rel string) error
+	// walked holds the canonical directories already walked.
+	walked map[string]bool
+	// folded maps a directory and lower-cased entry name to the first
+	// spelling seen.
+	folded map[string]string
+}
+
+// dir walks the canonical directory base, reporting paths below it with
+// prefix prepended.
+func (w *walk) dir(base, prefix string) error {
+	return filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			return err
+		}
+		rel = path.Join(prefix, filepath.ToSlash(rel))
+		if p != base {
+			w.checkCase(rel)
+		}
+
+		switch {
+		case d.IsDir():
+			if p != base && shouldSkipDir(d.Name(), w.skip) {
+				return filepath.SkipDir
+			}
+			w.walked[p] = true
+			return nil
+		case d.Type()&fs.ModeSymlink != 0:
+			if !FollowSymlinks {
+				return nil
+			}
+			return w.link(p, rel)
+		case !d.Type().IsRegular() || !hasExtension(p, w.opts.Extensions):
+			return nil
+		}
+		return w.fn(rel)
+	})
+}
+
+// link follows the symlink at p. Dangling links are ignored.
+func (w *walk) link(p, rel string) error {
+	target, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return nil
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil
+	}
+	if !info.IsDir() {
+		if info.Mode().IsRegular() && hasExtension(p, w.opts.Extensions) {
+			return w.fn(rel)
+		}
+		return nil
+	}
+	if shouldSkipDir(path.Base(rel), w.skip) || w.walked[target] || within(w.root, target) {
+		return nil
+	}
+	return w.dir(target, rel)
+}
+
+func (w *walk) checkCase(rel string) {
+	key := path.Join(path.Dir(rel), strings.ToLower(path.Base(rel)))
+	first, seen := w.folded[key]
+	if !seen {
+		w.folded[key] = rel
+		return
+	}
+	if first != rel {
+		fmt.Fprintf(Warnings, "warning: %s and %s differ only in case and collide on case-insensitive file systems\n", first, rel)
+	}
+}
+
+// within reports whether p is dir or below it.
+func within(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func shouldSkipDir(name string, skip []string) bool {
+	// bazel-bin, bazel-out and friends point into the output base.
+	if strings.HasPrefix(name, "bazel-") {
+		return true
+	}
+	for _, s := range skip {
+		if name == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasExtension(path string, exts []string) bool {
+	if len(exts) == 0 {
+		return true
+	}
+	ext := filepath.Ext(path)
+	for _, e := range exts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Match matches a slash-separated path against a glob where "**"
+// matches any number of path segments and other segments follow path.Match.
+func Match(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
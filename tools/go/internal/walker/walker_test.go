@@ -0,0 +1,135 @@
+package walker
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/testfixture"
+)
+
+// walkAll returns the files Walk reports below root, with FollowSymlinks
+// set to follow, and what it warned about.
+func walkAll(t *testing.T, root string, follow bool) ([]string, string) {
+	t.Helper()
+	var warnings bytes.Buffer
+	oldFollow, oldWarnings := FollowSymlinks, Warnings
+	FollowSymlinks, Warnings = follow, &warnings
+	t.Cleanup(func() { FollowSymlinks, Warnings = oldFollow, oldWarnings })
+
+	var files []string
+	if err := Walk(root, Options{}, func(rel string) error {
+		files = append(files, rel)
+		return nil
+	}); err != nil {
+		t.Error(err)
+	}
+	slices.Sort(files)
+	return files, warnings.String()
+}
+
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+}
+
+func TestSymlinks(t *testing.T) {
+	outside := testfixture.Write(t, map[string]string{"Shared/Key.swift": "", "Note.txt": ""})
+	root := testfixture.Write(t, map[string]string{"Sources/App/App.swift": ""})
+	symlink(t, filepath.Join(outside, "Shared"), filepath.Join(root, "Sources", "Shared"))
+	symlink(t, filepath.Join(outside, "Note.txt"), filepath.Join(root, "Note.txt"))
+	symlink(t, filepath.Join(root, "Sources", "App"), filepath.Join(root, "AppLink"))
+	symlink(t, filepath.Join(root, "missing"), filepath.Join(root, "Dangling.swift"))
+
+	for name, tc := range map[string]struct {
+		follow bool
+		want   []string
+	}{
+		"skipped by default": {
+			want: []string{"Sources/App/App.swift"},
+		},
+		// The link to a directory inside the root is not walked twice.
+		"followed": {
+			follow: true,
+			want:   []string{"Note.txt", "Sources/App/App.swift", "Sources/Shared/Key.swift"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got, _ := walkAll(t, root, tc.follow); !slices.Equal(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// Links that lead back to a directory already walked end the walk there.
+func TestSymlinkCycle(t *testing.T) {
+	outside := testfixture.Write(t, map[string]string{"A/a.swift": "", "B/b.swift": ""})
+	root := testfixture.Write(t, map[string]string{"Sources/App.swift": ""})
+	symlink(t, filepath.Join(outside, "A"), filepath.Join(root, "Sources", "A"))
+	symlink(t, filepath.Join(outside, "B"), filepath.Join(outside, "A", "B"))
+	symlink(t, filepath.Join(outside, "A"), filepath.Join(outside, "B", "A"))
+	symlink(t, filepath.Join(outside, "A"), filepath.Join(outside, "A", "Self"))
+	symlink(t, root, filepath.Join(root, "Sources", "Root"))
+
+	done := make(chan []string, 1)
+	go func() {
+		got, _ := walkAll(t, root, true)
+		done <- got
+	}()
+	select {
+	case got := <-done:
+		if want := []string{"Sources/A/B/b.swift", "Sources/A/a.swift", "Sources/App.swift"}; !slices.Equal(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Walk did not return")
+	}
+}
+
+func TestCaseCollision(t *testing.T) {
+	root := testfixture.Write(t, map[string]string{
+		"Sources/Keys/Key.swift":   "",
+		"Sources/Keys/key.swift":   "",
+		"Sources/keys/Other.swift": "",
+		"Sources/App/App.swift":    "",
+		"Sources/App/Key.swift":    "",
+	})
+	// A case-insensitive file system holds only one of each pair.
+	if _, err := os.Stat(filepath.Join(root, "Sources", "keys", "Key.swift")); err == nil {
+		t.Skip("the file system is case-insensitive")
+	}
+
+	files, warnings := walkAll(t, root, false)
+	if len(files) != 5 {
+		t.Errorf("got %q, want all 5 files", files)
+	}
+	for _, want := range []string{
+		"warning: Sources/Keys and Sources/keys differ only in case",
+		"warning: Sources/Keys/Key.swift and Sources/Keys/key.swift differ only in case",
+	} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("warnings %q lack %q", warnings, want)
+		}
+	}
+	if n := strings.Count(warnings, "\n"); n != 2 {
+		t.Errorf("got %d warnings, want 2:\n%s", n, warnings)
+	}
+}
+
+func BenchmarkWalk(b *testing.B) {
+	root := filepath.Join(testfixture.SwiftTree(b, 8, 40), "Sources")
+	b.ReportAllocs()
+	for b.Loop() {
+		err := Walk(root, Options{Extensions: []string{".swift"}}, func(string) error { return nil })
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package workspace
+
+import (
+	"path"
+	"strings"
+)
+
+// Scopes that --scope accepts by name, each standing for one part of the
+// tree. Any other value is taken as a directory relative to the root.
+const (
+	ScopeSources     = "sources"
+	ScopeTests       = "tests"
+	ScopeTestSupport = "testsupport"
+)
+
+// AllScopes is every named scope, as the verification commands check by
+// default so that breakage in the tests and their support code shows up
+// before the build does.
+const AllScopes = ScopeSources + "," + ScopeTests + "," + ScopeTestSupport
+
+// scopeDirs are the directories of the named scopes. The test support
+// lives inside Tests, so the tests scope covers it too; the testsupport
+// scope analyses it alone.
+var scopeDirs = map[string]string{
+	ScopeSources:     "Sources",
+	ScopeTests:       "Tests",
+	ScopeTestSupport: "Tests/TestSupport",
+}
+
+// ScopeDirs resolves --scope values to the directories they cover,
+// relative to the root, in the order given. Names are matched without
+// case, so "Sources" is the sources scope. A directory inside another one
+// already covered is dropped, as walking the outer one reaches it.
+func ScopeDirs(scopes []string) []string {
+	var dirs []string
+	for _, s := range scopes {
+		dir, ok := scopeDirs[strings.ToLower(s)]
+		if !ok {
+			dir = path.Clean(strings.Trim(strings.ReplaceAll(s, `\`, "/"), "/"))
+		}
+		dirs = append(dirs, dir)
+	}
+
+	var out []string
+	for i, dir := range dirs {
+		covered := false
+		for j, other := range dirs {
+			if dir == other && j < i || dir != other && strings.HasPrefix(dir, other+"/") {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			out = append(out, dir)
+		}
+	}
+	return out
+}
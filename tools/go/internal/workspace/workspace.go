@@ -0,0 +1,86 @@
+// Package workspace locates the UmbraCore project root and maps paths
+// inside it to the module that owns them.
+package workspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// markers are the files that identify the root of the Bazel workspace.
+var markers = []string{"MODULE.bazel", "WORKSPACE", "WORKSPACE.bazel"}
+
+// FindRoot walks up from start until it finds a directory containing one of
+// the Bazel workspace markers.
+func FindRoot(start string) (string, error) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		for _, marker := range markers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return dir, nil
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("no MODULE.bazel or WORKSPACE found above " + start)
+		}
+		dir = parent
+	}
+}
+
+// ResolveRoot returns root made absolute, or the discovered workspace root
+// when root is empty.
+func ResolveRoot(root string) (string, error) {
+	if root != "" {
+		return filepath.Abs(root)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return FindRoot(wd)
+}
+
+// ModuleForPath returns the module owning rel, a slash- or OS-separated path
+// relative to the workspace root. Files under Sources/ and Tests/ belong to
+// the directory directly below them, except that the test support of a
+// module under Tests/TestSupport/<name> belongs to <name>TestSupport; tool
+// files are attributed to "tools/<name>"; anything else is attributed to
+// its top-level directory.
+func ModuleForPath(rel string) string {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if len(parts) < 2 {
+		return "(root)"
+	}
+
+	switch parts[0] {
+	case "Tests":
+		if len(parts) > 3 && parts[1] == "TestSupport" {
+			return parts[2] + "TestSupport"
+		}
+		if len(parts) > 2 {
+			return parts[1]
+		}
+		return parts[0]
+	case "Sources", "TestSupport":
+		if len(parts) > 2 {
+			return parts[1]
+		}
+		return parts[0]
+	case "tools":
+		if len(parts) > 4 && parts[1] == "go" && parts[2] == "cmd" {
+			return "tools/go/" + parts[3]
+		}
+		return "tools/" + parts[1]
+	default:
+		return parts[0]
+	}
+}
@@ -0,0 +1,74 @@
+// Package worktree checks other revisions of the repository out into
+// temporary git worktrees, for tools that compare the tree with another
+// revision without touching the work tree the user is in.
+package worktree
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Tree is a revision checked out in a temporary worktree.
+type Tree struct {
+	// Rev is the revision as given, and Commit the commit it resolved to.
+	Rev, Commit string
+	// Root is the project root inside the worktree: the directory that
+	// corresponds to the root it was checked out from.
+	Root string
+
+	top, dir string
+}
+
+// Checkout checks rev out, detached, into a new temporary worktree of the
+// repository containing root. The caller removes the tree when done.
+func Checkout(root, rev string) (*Tree, error) {
+	top, err := git(root, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := git(root, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	commit, err := git(root, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %s", rev)
+	}
+	dir, err := os.MkdirTemp("", "umbratool-worktree-")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := git(top, "worktree", "add", "--detach", "--quiet", dir, commit); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return &Tree{Rev: rev, Commit: commit, Root: filepath.Join(dir, filepath.FromSlash(prefix)), top: top, dir: dir}, nil
+}
+
+// Remove deletes the worktree and its directory.
+func (t *Tree) Remove() error {
+	_, err := git(t.top, "worktree", "remove", "--force", t.dir)
+	if rmErr := os.RemoveAll(t.dir); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// git runs a git command in dir and returns its output, trimmed.
+func git(dir string, args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
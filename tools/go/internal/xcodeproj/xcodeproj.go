@@ -0,0 +1,211 @@
+// Package xcodeproj writes XcodeGen project specs for a slice of the
+// workspace's Swift modules, so that a few modules can be built and
+// debugged in Xcode without generating a project for the whole Bazel
+// build. The modules come from an spm export, which already maps the
+// Swift rules to targets.
+package xcodeproj
+
+import (
+	"bytes"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mpy-dev-ml/UmbraCore/tools/go/internal/spm"
+)
+
+// Options controls the spec.
+type Options struct {
+	// Name names the project.
+	Name string
+	// BundleIDPrefix prefixes the targets' bundle identifiers.
+	BundleIDPrefix string
+	// Base is the slash-separated path from the directory of the spec to
+	// the workspace root, which target paths are joined to.
+	Base string
+}
+
+// defaultSwiftVersion is the Swift language mode of targets whose copts do
+// not set one; Xcode refuses to build without it.
+const defaultSwiftVersion = "5.0"
+
+type spec struct {
+	Name     string                  `yaml:"name"`
+	Options  specOptions             `yaml:"options"`
+	Packages map[string]swiftPackage `yaml:"packages,omitempty"`
+	Targets  map[string]target       `yaml:"targets"`
+}
+
+type specOptions struct {
+	BundleIDPrefix           string            `yaml:"bundleIdPrefix"`
+	DeploymentTarget         map[string]string `yaml:"deploymentTarget"`
+	CreateIntermediateGroups bool              `yaml:"createIntermediateGroups"`
+}
+
+type swiftPackage struct {
+	URL          string `yaml:"url"`
+	ExactVersion string `yaml:"exactVersion,omitempty"`
+	Revision     string `yaml:"revision,omitempty"`
+}
+
+type target struct {
+	Type         string       `yaml:"type"`
+	Platform     string       `yaml:"platform"`
+	Sources      []source     `yaml:"sources"`
+	Dependencies []dependency `yaml:"dependencies,omitempty"`
+	Settings     settings     `yaml:"settings"`
+	Scheme       scheme       `yaml:"scheme"`
+}
+
+type source struct {
+	Path       string   `yaml:"path"`
+	Includes   []string `yaml:"includes"`
+	BuildPhase string   `yaml:"buildPhase,omitempty"`
+}
+
+type dependency struct {
+	Target  string `yaml:"target,omitempty"`
+	Package string `yaml:"package,omitempty"`
+	Product string `yaml:"product,omitempty"`
+	SDK     string `yaml:"sdk,omitempty"`
+}
+
+type settings struct {
+	Base map[string]string `yaml:"base"`
+}
+
+type scheme struct {
+	TestTargets []string `yaml:"testTargets,omitempty"`
+}
+
+// productTypes maps spm target types to XcodeGen product types.
+var productTypes = map[string]string{
+	spm.TypeLibrary:    "framework",
+	spm.TypeTest:       "bundle.unit-test",
+	spm.TypeExecutable: "tool",
+}
+
+// Spec renders p as an XcodeGen project.yml. Every module becomes a
+// framework that defines a module of its name, so Xcode generates its
+// module map and Swift interface header; tests become unit test bundles
+// with a scheme running them. Copts and linkopts SwiftPM cannot take are
+// passed as OTHER_SWIFT_FLAGS and OTHER_LDFLAGS.
+func Spec(p *spm.Package, opts Options) ([]byte, error) {
+	s := spec{
+		Name: opts.Name,
+		Options: specOptions{
+			BundleIDPrefix:           opts.BundleIDPrefix,
+			DeploymentTarget:         map[string]string{"macOS": p.MacOS},
+			CreateIntermediateGroups: true,
+		},
+		Packages: make(map[string]swiftPackage),
+		Targets:  make(map[string]target, len(p.Targets)),
+	}
+	for _, d := range p.Dependencies {
+		s.Packages[d.Identity] = swiftPackage{URL: d.URL, ExactVersion: d.Version, Revision: revisionIfUnversioned(d)}
+	}
+	for _, t := range p.Targets {
+		s.Targets[t.Name] = convert(t, opts)
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), enc.Close()
+}
+
+func revisionIfUnversioned(d spm.PackageDependency) string {
+	if d.Version != "" {
+		return ""
+	}
+	return d.Revision
+}
+
+func convert(t spm.Target, opts Options) target {
+	dir := path.Join(opts.Base, t.Path)
+	out := target{
+		Type:     productTypes[t.Type],
+		Platform: "macOS",
+		Sources:  []source{{Path: dir, Includes: t.Sources}},
+	}
+	if len(t.Resources) > 0 {
+		out.Sources = append(out.Sources, source{Path: dir, Includes: t.Resources, BuildPhase: "resources"})
+	}
+	for _, d := range t.Dependencies {
+		if d.Target != "" {
+			out.Dependencies = append(out.Dependencies, dependency{Target: d.Target})
+		} else {
+			out.Dependencies = append(out.Dependencies, dependency{Package: d.Package, Product: d.Product})
+		}
+	}
+	for _, f := range t.LinkedFrameworks {
+		out.Dependencies = append(out.Dependencies, dependency{SDK: f + ".framework"})
+	}
+	for _, l := range t.LinkedLibraries {
+		out.Dependencies = append(out.Dependencies, dependency{SDK: "lib" + l + ".tbd"})
+	}
+
+	base := map[string]string{
+		"PRODUCT_NAME":            t.Name,
+		"PRODUCT_MODULE_NAME":     t.Name,
+		"GENERATE_INFOPLIST_FILE": "YES",
+		"SWIFT_VERSION":           defaultSwiftVersion,
+	}
+	if t.Type == spm.TypeLibrary {
+		base["DEFINES_MODULE"] = "YES"
+	}
+	if t.LibraryEvolution {
+		base["BUILD_LIBRARY_FOR_DISTRIBUTION"] = "YES"
+	}
+	if len(t.Defines) > 0 {
+		base["SWIFT_ACTIVE_COMPILATION_CONDITIONS"] = "$(inherited) " + strings.Join(t.Defines, " ")
+	}
+	var swiftFlags []string
+	for i := 0; i < len(t.SwiftFlags); i++ {
+		if t.SwiftFlags[i] == "-swift-version" && i+1 < len(t.SwiftFlags) {
+			i++
+			base["SWIFT_VERSION"] = t.SwiftFlags[i]
+			continue
+		}
+		swiftFlags = append(swiftFlags, quoteSetting(t.SwiftFlags[i]))
+	}
+	if len(swiftFlags) > 0 {
+		base["OTHER_SWIFT_FLAGS"] = "$(inherited) " + strings.Join(swiftFlags, " ")
+	}
+	if len(t.LinkerFlags) > 0 {
+		flags := make([]string, len(t.LinkerFlags))
+		for i, f := range t.LinkerFlags {
+			flags[i] = quoteSetting(f)
+		}
+		base["OTHER_LDFLAGS"] = "$(inherited) " + strings.Join(flags, " ")
+	}
+	out.Settings = settings{Base: base}
+	if t.Type == spm.TypeTest {
+		out.Scheme.TestTargets = []string{t.Name}
+	}
+	return out
+}
+
+// quoteSetting quotes a value of a list build setting that holds spaces.
+func quoteSetting(s string) string {
+	if !strings.ContainsAny(s, " \t\"") {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// Losses returns the losses of p the spec has too. The options SwiftPM
+// would need unsafeFlags for are not among them: the spec passes them to
+// the compiler and linker.
+func Losses(p *spm.Package) []spm.Loss {
+	var out []spm.Loss
+	for _, l := range p.Losses {
+		if l.Kind != spm.LossUnsafeFlags {
+			out = append(out, l)
+		}
+	}
+	return out
+}
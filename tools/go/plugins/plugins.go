@@ -0,0 +1,10 @@
+// Package plugins compiles custom analyzers into umbratool. To add one,
+// put its package below this directory, have its init function call
+// analyzer.Register, and import it blank here:
+//
+//	import _ "github.com/mpy-dev-ml/UmbraCore/tools/go/plugins/noforceunwrap"
+//
+// then enable it by name in the analyzers section of umbratool.yaml. An
+// analyzer kept outside this repository is better run as a subprocess;
+// see analyzer.Process.
+package plugins